@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/st0012/Rooby/token"
+	"math/big"
 	"strings"
 )
 
@@ -15,6 +16,7 @@ type Node interface {
 type Statement interface {
 	Node
 	statementNode()
+	Line() int
 }
 
 type Expression interface {
@@ -51,6 +53,9 @@ type AssignStatement struct {
 }
 
 func (as *AssignStatement) statementNode() {}
+func (as *AssignStatement) Line() int {
+	return as.Token.Line
+}
 func (as *AssignStatement) TokenLiteral() string {
 	return as.Token.Literal
 }
@@ -77,6 +82,9 @@ type DefStatement struct {
 }
 
 func (ds *DefStatement) statementNode() {}
+func (ds *DefStatement) Line() int {
+	return ds.Token.Line
+}
 func (ds *DefStatement) TokenLiteral() string {
 	return ds.Token.Literal
 }
@@ -110,6 +118,9 @@ type ClassStatement struct {
 }
 
 func (cs *ClassStatement) statementNode() {}
+func (cs *ClassStatement) Line() int {
+	return cs.Token.Line
+}
 func (cs *ClassStatement) TokenLiteral() string {
 	return cs.Token.Literal
 }
@@ -125,12 +136,40 @@ func (cs *ClassStatement) String() string {
 	return out.String()
 }
 
+type ModuleStatement struct {
+	Token token.Token
+	Name  *Constant
+	Body  *BlockStatement
+}
+
+func (ms *ModuleStatement) statementNode() {}
+func (ms *ModuleStatement) Line() int {
+	return ms.Token.Line
+}
+func (ms *ModuleStatement) TokenLiteral() string {
+	return ms.Token.Literal
+}
+func (ms *ModuleStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("module ")
+	out.WriteString(ms.Name.TokenLiteral())
+	out.WriteString(" {\n")
+	out.WriteString(ms.Body.String())
+	out.WriteString("\n}")
+
+	return out.String()
+}
+
 type ReturnStatement struct {
 	Token       token.Token
 	ReturnValue Expression
 }
 
 func (rs *ReturnStatement) statementNode() {}
+func (rs *ReturnStatement) Line() int {
+	return rs.Token.Line
+}
 func (rs *ReturnStatement) TokenLiteral() string {
 	return rs.Token.Literal
 }
@@ -154,6 +193,9 @@ type ExpressionStatement struct {
 }
 
 func (es *ExpressionStatement) statementNode() {}
+func (es *ExpressionStatement) Line() int {
+	return es.Token.Line
+}
 func (es *ExpressionStatement) TokenLiteral() string {
 	return es.Token.Literal
 }
@@ -168,6 +210,8 @@ func (es *ExpressionStatement) String() string {
 type IntegerLiteral struct {
 	Token token.Token
 	Value int
+	// Big is the value of literals too large for Value
+	Big *big.Int
 }
 
 func (il *IntegerLiteral) expressionNode() {}
@@ -178,6 +222,33 @@ func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+func (fl *FloatLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+func (fl *FloatLiteral) String() string {
+	return fl.Token.Literal
+}
+
+// RationalLiteral is a number with an r suffix like 3r or 1.5r, whose value is exact
+type RationalLiteral struct {
+	Token token.Token
+	Value *big.Rat
+}
+
+func (rl *RationalLiteral) expressionNode() {}
+func (rl *RationalLiteral) TokenLiteral() string {
+	return rl.Token.Literal
+}
+func (rl *RationalLiteral) String() string {
+	return rl.Token.Literal
+}
+
 type StringLiteral struct {
 	Token token.Token
 	Value string
@@ -229,6 +300,8 @@ func (ae *ArrayExpression) String() string {
 type HashExpression struct {
 	Token token.Token
 	Data  map[string]Expression
+	// Keys are Data's keys in the order they're written, since hash keeps its insertion order.
+	Keys []string
 }
 
 func (he *HashExpression) expressionNode() {}
@@ -239,8 +312,8 @@ func (he *HashExpression) String() string {
 	var out bytes.Buffer
 	var pairs []string
 
-	for key, value := range he.Data {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", key, value.String()))
+	for _, key := range he.Keys {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", key, he.Data[key].String()))
 	}
 
 	out.WriteString("{ ")
@@ -296,6 +369,40 @@ func (ie *InfixExpression) String() string {
 	return out.String()
 }
 
+// RangeExpression is a range literal like `1..5`, or `1...5` which excludes its end.
+type RangeExpression struct {
+	Token     token.Token
+	Start     Expression
+	End       Expression
+	Exclusive bool
+}
+
+func (re *RangeExpression) expressionNode() {}
+func (re *RangeExpression) TokenLiteral() string {
+	return re.Token.Literal
+}
+
+// Operator returns `..`, or `...` if the range excludes its end.
+func (re *RangeExpression) Operator() string {
+	if re.Exclusive {
+		return "..."
+	}
+
+	return ".."
+}
+
+func (re *RangeExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(re.Start.String())
+	out.WriteString(re.Operator())
+	out.WriteString(re.End.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -346,6 +453,9 @@ type BlockStatement struct {
 }
 
 func (bs *BlockStatement) statementNode() {}
+func (bs *BlockStatement) Line() int {
+	return bs.Token.Line
+}
 func (bs *BlockStatement) TokenLiteral() string {
 	return bs.Token.Literal
 }
@@ -366,6 +476,9 @@ type CallExpression struct {
 	Arguments      []Expression
 	Block          *BlockStatement
 	BlockArguments []*Identifier
+	// DestructuredArguments holds the names of destructured block parameters, like `k` and `v` in `|(k, v), i|`,
+	// by the parameter's index in BlockArguments. The parameter itself is named after its source, "(k, v)".
+	DestructuredArguments map[int][]*Identifier
 }
 
 func (ce *CallExpression) expressionNode() {}
@@ -421,6 +534,38 @@ func (se *SelfExpression) String() string {
 	return "self"
 }
 
+// FileExpression is `__FILE__`, which evaluates to the path of the file being compiled.
+type FileExpression struct {
+	Token token.Token
+}
+
+func (fe *FileExpression) expressionNode() {}
+func (fe *FileExpression) TokenLiteral() string {
+	return fe.Token.Literal
+}
+func (fe *FileExpression) String() string {
+	return "__FILE__"
+}
+
+// ErrorStatement marks a region of source the parser couldn't parse, from Token's line to EndLine.
+// The parser still returns statements around it, so tools can work with files that have syntax errors.
+type ErrorStatement struct {
+	Token   token.Token
+	EndLine int
+	Errors  []string
+}
+
+func (es *ErrorStatement) statementNode() {}
+func (es *ErrorStatement) Line() int {
+	return es.Token.Line
+}
+func (es *ErrorStatement) TokenLiteral() string {
+	return es.Token.Literal
+}
+func (es *ErrorStatement) String() string {
+	return "<error>"
+}
+
 type WhileStatement struct {
 	Token     token.Token
 	Condition Expression
@@ -428,6 +573,9 @@ type WhileStatement struct {
 }
 
 func (ws *WhileStatement) statementNode() {}
+func (ws *WhileStatement) Line() int {
+	return ws.Token.Line
+}
 func (ws *WhileStatement) TokenLiteral() string {
 	return ws.Token.Literal
 }
@@ -467,3 +615,106 @@ func (ye *YieldExpression) String() string {
 
 	return out.String()
 }
+
+// SuperExpression calls the superclass's implementation of the current method.
+// Without parentheses it passes the current method's arguments.
+type SuperExpression struct {
+	Token     token.Token
+	Arguments []Expression
+	Explicit  bool
+}
+
+func (se *SuperExpression) expressionNode() {}
+func (se *SuperExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+func (se *SuperExpression) String() string {
+	var out bytes.Buffer
+	var args []string
+
+	out.WriteString(se.TokenLiteral())
+
+	if !se.Explicit {
+		return out.String()
+	}
+
+	for _, arg := range se.Arguments {
+		args = append(args, arg.String())
+	}
+
+	out.WriteString("(")
+	out.WriteString(strings.Join(args, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+
+type BeginExpression struct {
+	Token   token.Token
+	Body    *BlockStatement
+	Rescues []*RescueClause
+	Ensure  *BlockStatement
+}
+
+func (be *BeginExpression) expressionNode() {}
+func (be *BeginExpression) TokenLiteral() string {
+	return be.Token.Literal
+}
+func (be *BeginExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("begin\n")
+	out.WriteString(be.Body.String())
+
+	for _, rc := range be.Rescues {
+		out.WriteString("\n")
+		out.WriteString(rc.String())
+	}
+
+	if be.Ensure != nil {
+		out.WriteString("\nensure\n")
+		out.WriteString(be.Ensure.String())
+	}
+
+	out.WriteString("\nend")
+
+	return out.String()
+}
+
+// RescueClause is a `rescue` branch of BeginExpression.
+// Empty Exceptions means the clause rescues StandardError.
+type RescueClause struct {
+	Token      token.Token
+	Exceptions []*Constant
+	Variable   *Identifier
+	Body       *BlockStatement
+}
+
+func (rc *RescueClause) TokenLiteral() string {
+	return rc.Token.Literal
+}
+func (rc *RescueClause) String() string {
+	var out bytes.Buffer
+	var exceptions []string
+
+	for _, e := range rc.Exceptions {
+		exceptions = append(exceptions, e.String())
+	}
+
+	out.WriteString("rescue")
+
+	if len(exceptions) > 0 {
+		out.WriteString(" ")
+		out.WriteString(strings.Join(exceptions, ", "))
+	}
+
+	if rc.Variable != nil {
+		out.WriteString(" => ")
+		out.WriteString(rc.Variable.String())
+	}
+
+	out.WriteString("\n")
+	out.WriteString(rc.Body.String())
+
+	return out.String()
+}
@@ -0,0 +1,384 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.golang file.
+
+// Apply, Cursor and their traversal are adapted from golang.org/x/tools/go/ast/astutil/rewrite.go for Rooby's AST.
+
+// Package astutil provides helpers for rewriting Rooby ASTs, such as desugaring passes and codemods.
+package astutil
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/st0012/Rooby/ast"
+)
+
+// ApplyFunc is invoked by Apply for each node n, even if n is nil, before and/or after the node's children.
+// Its return value controls the traversal, see Apply.
+type ApplyFunc func(*Cursor) bool
+
+// Apply traverses a syntax tree recursively, starting with root, and calls pre and post for each node.
+//
+// If pre is not nil, it's called for each node before the node's children are traversed (pre-order).
+// If pre returns false, no children are traversed and post is not called for that node.
+//
+// If post is not nil, and a prior call of pre didn't return false, post is called for each node
+// after its children are traversed (post-order). If post returns false, traversal is terminated and
+// Apply returns immediately.
+//
+// Only fields that refer to AST nodes are considered children. Children are traversed in the order
+// they appear in the source, and hash values in their key order.
+//
+// The Cursor passed to pre and post can be used to replace, delete or insert nodes. Apply returns the
+// possibly modified root; if root itself was replaced, the replacement is returned.
+func Apply(root ast.Node, pre, post ApplyFunc) (result ast.Node) {
+	parent := &rootNode{Node: root}
+
+	defer func() {
+		if r := recover(); r != nil && r != abort {
+			panic(r)
+		}
+		result = parent.Node
+	}()
+
+	a := &application{pre: pre, post: post}
+	a.apply(parent, "Node", nil, root)
+	return
+}
+
+var abort = new(int) // singleton, to signal termination of Apply
+
+// rootNode holds the root passed to Apply, so the root can be replaced like any other node.
+type rootNode struct {
+	Node ast.Node
+}
+
+func (r *rootNode) TokenLiteral() string { return "" }
+func (r *rootNode) String() string       { return "" }
+
+// A Cursor describes a node encountered during Apply.
+// Information about the node and its parent is available from the Node, Parent, Name and Index methods.
+//
+// If p is a variable of type and value of the current parent node c.Parent(),
+// and f is the field identifier with name c.Name(), the following invariants hold:
+//
+//	p.f            == c.Node()  if c.Index() <  0 and c.Key() == ""
+//	p.f[c.Index()] == c.Node()  if c.Index() >= 0
+//	p.f[c.Key()]   == c.Node()  if c.Key() != ""
+//
+// The methods Replace, Delete, InsertBefore and InsertAfter can be used to change the AST
+// without disrupting Apply.
+type Cursor struct {
+	parent ast.Node
+	name   string
+	iter   *iterator // valid if non-nil
+	key    string    // non-empty for hash values
+	node   ast.Node
+}
+
+// Node returns the current Node.
+func (c *Cursor) Node() ast.Node { return c.node }
+
+// Parent returns the parent of the current Node, or nil if the current Node is the root.
+func (c *Cursor) Parent() ast.Node {
+	if _, ok := c.parent.(*rootNode); ok {
+		return nil
+	}
+	return c.parent
+}
+
+// Name returns the name of the parent Node field that contains the current Node.
+// If the parent is a *ast.HashExpression, it's "Data" for hash values.
+func (c *Cursor) Name() string { return c.name }
+
+// Index reports the index >= 0 of the current Node in the slice of Nodes that contains it,
+// or a value < 0 if the current Node is not part of a slice.
+func (c *Cursor) Index() int {
+	if c.iter != nil {
+		return c.iter.index
+	}
+	return -1
+}
+
+// Key returns the hash key of the current Node if it's a value of a *ast.HashExpression, otherwise "".
+func (c *Cursor) Key() string { return c.key }
+
+// field returns the current node's parent field value.
+func (c *Cursor) field() reflect.Value {
+	return reflect.Indirect(reflect.ValueOf(c.parent)).FieldByName(c.name)
+}
+
+// Replace replaces the current Node with n.
+// If it's called by pre, Apply walks the children of n instead of the replaced node's.
+func (c *Cursor) Replace(n ast.Node) {
+	if c.key != "" {
+		e, ok := n.(ast.Expression)
+
+		if !ok {
+			panic(fmt.Sprintf("astutil: can't replace hash value with %T", n))
+		}
+
+		c.parent.(*ast.HashExpression).Data[c.key] = e
+		c.node = n
+		return
+	}
+
+	v := c.field()
+
+	if i := c.Index(); i >= 0 {
+		v = v.Index(i)
+	}
+
+	v.Set(nodeValue(n, v.Type(), c.name))
+	c.node = n
+}
+
+// Delete deletes the current Node from its containing slice or hash.
+// If the current Node is not part of a slice or hash, Delete panics.
+func (c *Cursor) Delete() {
+	if c.key != "" {
+		he := c.parent.(*ast.HashExpression)
+		delete(he.Data, c.key)
+
+		for i, k := range he.Keys {
+			if k == c.key {
+				he.Keys = append(he.Keys[:i], he.Keys[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+
+	i := c.Index()
+
+	if i < 0 {
+		panic("astutil: Delete node not contained in slice or hash")
+	}
+
+	v := c.field()
+	l := v.Len()
+	reflect.Copy(v.Slice(i, l), v.Slice(i+1, l))
+	v.Index(l - 1).Set(reflect.Zero(v.Type().Elem()))
+	v.SetLen(l - 1)
+	c.iter.step--
+}
+
+// InsertAfter inserts n after the current Node in its containing slice.
+// If the current Node is not part of a slice, InsertAfter panics.
+// Apply does not walk n.
+func (c *Cursor) InsertAfter(n ast.Node) {
+	i := c.Index()
+
+	if i < 0 {
+		panic("astutil: InsertAfter node not contained in slice")
+	}
+
+	v := c.field()
+	v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+	l := v.Len()
+	reflect.Copy(v.Slice(i+2, l), v.Slice(i+1, l))
+	v.Index(i + 1).Set(nodeValue(n, v.Type().Elem(), c.name))
+	c.iter.step++
+}
+
+// InsertBefore inserts n before the current Node in its containing slice.
+// If the current Node is not part of a slice, InsertBefore panics.
+// Apply will not walk n.
+func (c *Cursor) InsertBefore(n ast.Node) {
+	i := c.Index()
+
+	if i < 0 {
+		panic("astutil: InsertBefore node not contained in slice")
+	}
+
+	v := c.field()
+	v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+	l := v.Len()
+	reflect.Copy(v.Slice(i+1, l), v.Slice(i, l))
+	v.Index(i).Set(nodeValue(n, v.Type().Elem(), c.name))
+	c.iter.index++
+}
+
+// nodeValue converts n into a value that can be stored in a field of type t, or panics if it can't.
+func nodeValue(n ast.Node, t reflect.Type, name string) reflect.Value {
+	if n == nil {
+		return reflect.Zero(t)
+	}
+
+	v := reflect.ValueOf(n)
+
+	if !v.Type().AssignableTo(t) {
+		panic(fmt.Sprintf("astutil: can't use %T as %s in field %s", n, t, name))
+	}
+
+	return v
+}
+
+type iterator struct {
+	index, step int
+}
+
+type application struct {
+	pre, post ApplyFunc
+	cursor    Cursor
+	iter      iterator
+}
+
+func (a *application) apply(parent ast.Node, name string, iter *iterator, n ast.Node) {
+	a.applyNode(parent, name, iter, "", n)
+}
+
+func (a *application) applyNode(parent ast.Node, name string, iter *iterator, key string, n ast.Node) {
+	// A nil pointer stored in an interface, like a missing ClassStatement.SuperClass, is a nil node.
+	if n != nil && reflect.ValueOf(n).IsNil() {
+		n = nil
+	}
+
+	// avoid heap-allocating a new cursor for each apply call; reuse a.cursor instead
+	saved := a.cursor
+	a.cursor.parent = parent
+	a.cursor.name = name
+	a.cursor.iter = iter
+	a.cursor.key = key
+	a.cursor.node = n
+
+	if a.pre != nil && !a.pre(&a.cursor) {
+		a.cursor = saved
+		return
+	}
+
+	// walk children
+	switch n := a.cursor.node.(type) {
+	case nil:
+		// nothing to do
+
+	case *ast.Program:
+		a.applyList(n, "Statements")
+
+	case *ast.AssignStatement:
+		a.apply(n, "Name", nil, n.Name)
+		a.apply(n, "Value", nil, n.Value)
+
+	case *ast.DefStatement:
+		a.apply(n, "Receiver", nil, n.Receiver)
+		a.apply(n, "Name", nil, n.Name)
+		a.applyList(n, "Parameters")
+		a.apply(n, "BlockStatement", nil, n.BlockStatement)
+
+	case *ast.ClassStatement:
+		a.apply(n, "Name", nil, n.Name)
+		a.apply(n, "SuperClass", nil, n.SuperClass)
+		a.apply(n, "Body", nil, n.Body)
+
+	case *ast.ModuleStatement:
+		a.apply(n, "Name", nil, n.Name)
+		a.apply(n, "Body", nil, n.Body)
+
+	case *ast.ReturnStatement:
+		a.apply(n, "ReturnValue", nil, n.ReturnValue)
+
+	case *ast.ExpressionStatement:
+		a.apply(n, "Expression", nil, n.Expression)
+
+	case *ast.BlockStatement:
+		a.applyList(n, "Statements")
+
+	case *ast.WhileStatement:
+		a.apply(n, "Condition", nil, n.Condition)
+		a.apply(n, "Body", nil, n.Body)
+
+	case *ast.ArrayExpression:
+		a.applyList(n, "Elements")
+
+	case *ast.HashExpression:
+		// copy the keys so values can be deleted during the walk
+		keys := append([]string{}, n.Keys...)
+
+		for _, k := range keys {
+			if v, ok := n.Data[k]; ok {
+				a.applyNode(n, "Data", nil, k, v)
+			}
+		}
+
+	case *ast.PrefixExpression:
+		a.apply(n, "Right", nil, n.Right)
+
+	case *ast.InfixExpression:
+		a.apply(n, "Left", nil, n.Left)
+		a.apply(n, "Right", nil, n.Right)
+
+	case *ast.RangeExpression:
+		a.apply(n, "Start", nil, n.Start)
+		a.apply(n, "End", nil, n.End)
+
+	case *ast.IfExpression:
+		a.apply(n, "Condition", nil, n.Condition)
+		a.apply(n, "Consequence", nil, n.Consequence)
+		a.apply(n, "Alternative", nil, n.Alternative)
+
+	case *ast.CallExpression:
+		a.apply(n, "Receiver", nil, n.Receiver)
+		a.applyList(n, "Arguments")
+		a.applyList(n, "BlockArguments")
+		a.apply(n, "Block", nil, n.Block)
+
+	case *ast.YieldExpression:
+		a.applyList(n, "Arguments")
+
+	case *ast.SuperExpression:
+		a.applyList(n, "Arguments")
+
+	case *ast.BeginExpression:
+		a.apply(n, "Body", nil, n.Body)
+		a.applyList(n, "Rescues")
+		a.apply(n, "Ensure", nil, n.Ensure)
+
+	case *ast.RescueClause:
+		a.applyList(n, "Exceptions")
+		a.apply(n, "Variable", nil, n.Variable)
+		a.apply(n, "Body", nil, n.Body)
+
+	case *ast.Identifier, *ast.InstanceVariable, *ast.Constant, *ast.GlobalVariable,
+		*ast.IntegerLiteral, *ast.FloatLiteral, *ast.RationalLiteral, *ast.StringLiteral, *ast.Boolean, *ast.SelfExpression,
+		*ast.FileExpression, *ast.ErrorStatement:
+		// nothing to do
+
+	default:
+		panic(fmt.Sprintf("astutil: Apply: unexpected node type %T", n))
+	}
+
+	if a.post != nil && !a.post(&a.cursor) {
+		panic(abort)
+	}
+
+	a.cursor = saved
+}
+
+func (a *application) applyList(parent ast.Node, name string) {
+	// avoid heap-allocating a new iterator for each applyList call; reuse a.iter instead
+	saved := a.iter
+	a.iter.index = 0
+
+	for {
+		// must reload parent.name each time, since cursor modifications might change it
+		v := reflect.Indirect(reflect.ValueOf(parent)).FieldByName(name)
+
+		if a.iter.index >= v.Len() {
+			break
+		}
+
+		// element x may be nil in a bad AST - be cautious
+		var x ast.Node
+
+		if e := v.Index(a.iter.index); e.IsValid() && e.CanInterface() {
+			x, _ = e.Interface().(ast.Node)
+		}
+
+		a.iter.step = 1
+		a.apply(parent, name, &a.iter, x)
+		a.iter.index += a.iter.step
+	}
+
+	a.iter = saved
+}
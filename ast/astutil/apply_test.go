@@ -0,0 +1,251 @@
+package astutil
+
+import (
+	"testing"
+
+	"github.com/st0012/Rooby/ast"
+	"github.com/st0012/Rooby/lexer"
+	"github.com/st0012/Rooby/parser"
+)
+
+func parseProgram(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		t.Fatalf("Unexpected parser errors: %v", p.Errors())
+	}
+
+	return program
+}
+
+func TestApplyReplace(t *testing.T) {
+	program := parseProgram(t, `
+a = x + 0
+foo(y + 0, [z + 0])
+`)
+
+	// desugar `expr + 0` into `expr`
+	Apply(program, nil, func(c *Cursor) bool {
+		if ie, ok := c.Node().(*ast.InfixExpression); ok && ie.Operator == "+" {
+			if il, ok := ie.Right.(*ast.IntegerLiteral); ok && il.Value == 0 {
+				c.Replace(ie.Left)
+			}
+		}
+		return true
+	})
+
+	expected := "a = xself.foo(y, [z])"
+
+	if program.String() != expected {
+		t.Fatalf("Expect program to be %q. got=%q", expected, program.String())
+	}
+}
+
+func TestApplyReplaceWalksReplacement(t *testing.T) {
+	program := parseProgram(t, `bar(1)`)
+	var visited []string
+
+	Apply(program, func(c *Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.CallExpression:
+			if n.Method == "bar" {
+				n := &ast.CallExpression{Receiver: n.Receiver, Token: n.Token, Method: "baz", Arguments: []ast.Expression{n.Arguments[0], n.Arguments[0]}}
+				c.Replace(n)
+			}
+		case *ast.IntegerLiteral:
+			visited = append(visited, n.String())
+		}
+		return true
+	}, nil)
+
+	if program.String() != "self.baz(1, 1)" {
+		t.Fatalf("Expect program to be %q. got=%q", "self.baz(1, 1)", program.String())
+	}
+
+	if len(visited) != 2 {
+		t.Fatalf("Expect both arguments of the replacement to be visited. got=%v", visited)
+	}
+}
+
+func TestApplyDeleteAndInsert(t *testing.T) {
+	program := parseProgram(t, `
+a = 1
+debug(a)
+b = 2
+debug(b)
+c = 3
+`)
+
+	Apply(program, func(c *Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.ExpressionStatement:
+			if ce, ok := n.Expression.(*ast.CallExpression); ok && ce.Method == "debug" {
+				c.Delete()
+			}
+		case *ast.AssignStatement:
+			if n.Name.ReturnValue() == "b" {
+				c.InsertBefore(parseProgram(t, "x = 0").Statements[0])
+				c.InsertAfter(parseProgram(t, "y = 0").Statements[0])
+			}
+		}
+		return true
+	}, nil)
+
+	expected := []string{"a = 1", "x = 0", "b = 2", "y = 0", "c = 3"}
+
+	if len(program.Statements) != len(expected) {
+		t.Fatalf("Expect %d statements. got=%d (%q)", len(expected), len(program.Statements), program.String())
+	}
+
+	for i, stmt := range program.Statements {
+		if stmt.String() != expected[i] {
+			t.Fatalf("Expect statement %d to be %q. got=%q", i, expected[i], stmt.String())
+		}
+	}
+}
+
+func TestApplyHashValues(t *testing.T) {
+	program := parseProgram(t, `{ a: 1, b: 2, c: 3 }`)
+
+	Apply(program, func(c *Cursor) bool {
+		if c.Key() == "b" {
+			c.Delete()
+		} else if c.Key() == "c" {
+			c.Replace(&ast.Boolean{Value: true})
+		}
+		return true
+	}, nil)
+
+	he := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.HashExpression)
+
+	if len(he.Keys) != 2 || he.Keys[0] != "a" || he.Keys[1] != "c" {
+		t.Fatalf("Expect hash keys to be [a c]. got=%v", he.Keys)
+	}
+
+	if _, ok := he.Data["b"]; ok {
+		t.Fatalf("Expect key b to be deleted")
+	}
+
+	if _, ok := he.Data["c"].(*ast.Boolean); !ok {
+		t.Fatalf("Expect value of c to be replaced. got=%T", he.Data["c"])
+	}
+}
+
+func TestApplyTraversal(t *testing.T) {
+	program := parseProgram(t, `
+class Foo < Bar
+  def baz(a)
+    if a
+      yield(a)
+    end
+  end
+end
+`)
+	var order []string
+	var parents []ast.Node
+
+	Apply(program, func(c *Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.Constant:
+			order = append(order, n.Value)
+		case *ast.Identifier:
+			order = append(order, n.Value)
+		case *ast.YieldExpression:
+			parents = append(parents, c.Parent())
+		}
+		return true
+	}, nil)
+
+	expected := []string{"Foo", "Bar", "baz", "a", "a", "a"}
+
+	if len(order) != len(expected) {
+		t.Fatalf("Expect nodes %v to be visited. got=%v", expected, order)
+	}
+
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expect nodes %v to be visited. got=%v", expected, order)
+		}
+	}
+
+	if len(parents) != 1 {
+		t.Fatalf("Expect yield to be visited once. got=%d", len(parents))
+	}
+
+	if _, ok := parents[0].(*ast.ExpressionStatement); !ok {
+		t.Fatalf("Expect parent of yield to be an expression statement. got=%T", parents[0])
+	}
+}
+
+func TestApplyStops(t *testing.T) {
+	program := parseProgram(t, `
+a = 1
+b = 2
+c = 3
+`)
+	var preVisited, postVisited int
+
+	Apply(program, func(c *Cursor) bool {
+		preVisited++
+		// skip children of statements
+		_, ok := c.Node().(ast.Statement)
+		return !ok
+	}, func(c *Cursor) bool {
+		postVisited++
+		return true
+	})
+
+	// the program and its three statements
+	if preVisited != 4 || postVisited != 1 {
+		t.Fatalf("Expect pre to be called 4 times and post once. got=%d, %d", preVisited, postVisited)
+	}
+
+	preVisited = 0
+
+	Apply(program, func(c *Cursor) bool {
+		preVisited++
+		return true
+	}, func(c *Cursor) bool {
+		_, ok := c.Node().(*ast.AssignStatement)
+		return !ok
+	})
+
+	// program, a = 1, a and 1
+	if preVisited != 4 {
+		t.Fatalf("Expect traversal to stop after first assignment. got=%d nodes visited", preVisited)
+	}
+}
+
+func TestApplyReplaceRoot(t *testing.T) {
+	program := parseProgram(t, `1`)
+	replacement := &ast.Program{}
+
+	result := Apply(program, func(c *Cursor) bool {
+		if c.Parent() == nil {
+			c.Replace(replacement)
+		}
+		return true
+	}, nil)
+
+	if result != replacement {
+		t.Fatalf("Expect root to be replaced. got=%v", result)
+	}
+}
+
+func TestApplyInvalidReplacement(t *testing.T) {
+	program := parseProgram(t, `a = 1`)
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("Expect replacing an expression with a statement to panic")
+		}
+	}()
+
+	Apply(program, func(c *Cursor) bool {
+		if _, ok := c.Node().(*ast.IntegerLiteral); ok {
+			c.Replace(&ast.ReturnStatement{})
+		}
+		return true
+	}, nil)
+}
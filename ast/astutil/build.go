@@ -0,0 +1,178 @@
+package astutil
+
+import (
+	"strconv"
+
+	"github.com/st0012/Rooby/ast"
+	"github.com/st0012/Rooby/token"
+)
+
+// The functions below build AST nodes from Go code. Nodes they return have the same shape as the
+// ones produced by the parser, so they can be compiled directly or turned into source with Source.
+
+// Ident returns an identifier, which is a local variable or a method name.
+func Ident(name string) *ast.Identifier {
+	return &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: name}, Value: name}
+}
+
+// Const returns a constant.
+func Const(name string) *ast.Constant {
+	return &ast.Constant{Token: token.Token{Type: token.CONSTANT, Literal: name}, Value: name}
+}
+
+// Variable returns a variable whose kind depends on name: `@foo` is an instance variable,
+// `$foo` a global variable, `Foo` a constant and anything else a local variable.
+func Variable(name string) ast.Variable {
+	switch {
+	case len(name) > 0 && name[0] == '@':
+		return &ast.InstanceVariable{Token: token.Token{Type: token.INSTANCE_VARIABLE, Literal: name}, Value: name}
+	case len(name) > 0 && name[0] == '$':
+		return &ast.GlobalVariable{Token: token.Token{Type: token.GLOBAL_VARIABLE, Literal: name}, Value: name}
+	case len(name) > 0 && 'A' <= name[0] && name[0] <= 'Z':
+		return Const(name)
+	default:
+		return Ident(name)
+	}
+}
+
+// Int returns an integer literal.
+func Int(value int) *ast.IntegerLiteral {
+	return &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: strconv.Itoa(value)}, Value: value}
+}
+
+// Float returns a float literal.
+func Float(value float64) *ast.FloatLiteral {
+	return &ast.FloatLiteral{Token: token.Token{Type: token.FLOAT, Literal: formatFloat(value)}, Value: value}
+}
+
+// Str returns a string literal.
+func Str(value string) *ast.StringLiteral {
+	return &ast.StringLiteral{Token: token.Token{Type: token.STRING, Literal: value}, Value: value}
+}
+
+// Bool returns `true` or `false`.
+func Bool(value bool) *ast.Boolean {
+	t := token.Token{Type: token.FALSE, Literal: "false"}
+
+	if value {
+		t = token.Token{Type: token.TRUE, Literal: "true"}
+	}
+
+	return &ast.Boolean{Token: t, Value: value}
+}
+
+// Self returns `self`.
+func Self() *ast.SelfExpression {
+	return &ast.SelfExpression{Token: token.Token{Type: token.SELF, Literal: "self"}}
+}
+
+// Array returns an array literal of elements.
+func Array(elements ...ast.Expression) *ast.ArrayExpression {
+	if elements == nil {
+		elements = []ast.Expression{}
+	}
+
+	return &ast.ArrayExpression{Token: token.Token{Type: token.LBRACKET, Literal: "["}, Elements: elements}
+}
+
+// Hash returns an empty hash literal. Use HashWith to add pairs in order.
+func Hash() *ast.HashExpression {
+	return &ast.HashExpression{Token: token.Token{Type: token.LBRACE, Literal: "{"}, Data: map[string]ast.Expression{}}
+}
+
+// HashWith adds the pair key: value to he and returns he, so pairs can be chained in the order they're written.
+func HashWith(he *ast.HashExpression, key string, value ast.Expression) *ast.HashExpression {
+	if _, ok := he.Data[key]; !ok {
+		he.Keys = append(he.Keys, key)
+	}
+
+	he.Data[key] = value
+	return he
+}
+
+// Infix returns the binary operation `left operator right`.
+func Infix(left ast.Expression, operator string, right ast.Expression) *ast.InfixExpression {
+	return &ast.InfixExpression{Token: token.Token{Type: token.TokenType(operator), Literal: operator}, Left: left, Operator: operator, Right: right}
+}
+
+// Call returns a call of method on receiver. A nil receiver means the method is called on self.
+func Call(receiver ast.Expression, method string, args ...ast.Expression) *ast.CallExpression {
+	if receiver == nil {
+		receiver = Self()
+	}
+
+	if args == nil {
+		args = []ast.Expression{}
+	}
+
+	return &ast.CallExpression{Token: token.Token{Type: token.IDENT, Literal: method}, Receiver: receiver, Method: method, Arguments: args}
+}
+
+// CallWithBlock returns a call like Call, with a block that takes params.
+func CallWithBlock(receiver ast.Expression, method string, args []ast.Expression, params []string, body ...ast.Statement) *ast.CallExpression {
+	c := Call(receiver, method, args...)
+	c.BlockArguments = identifiers(params)
+	c.Block = Block(body...)
+	return c
+}
+
+// If returns an if expression. alternative can be nil if there's no else branch.
+func If(condition ast.Expression, consequence, alternative *ast.BlockStatement) *ast.IfExpression {
+	return &ast.IfExpression{Token: token.Token{Type: token.IF, Literal: "if"}, Condition: condition, Consequence: consequence, Alternative: alternative}
+}
+
+// Block returns a block of statements, which is the body of methods, classes, blocks and branches.
+func Block(stmts ...ast.Statement) *ast.BlockStatement {
+	return &ast.BlockStatement{Token: token.Token{Type: token.DO, Literal: "do"}, Statements: stmts}
+}
+
+// Stmt wraps exp into a statement.
+func Stmt(exp ast.Expression) *ast.ExpressionStatement {
+	return &ast.ExpressionStatement{Token: token.Token{Literal: exp.TokenLiteral()}, Expression: exp}
+}
+
+// Assign returns the assignment `name = value`, see Variable for how name is interpreted.
+func Assign(name string, value ast.Expression) *ast.AssignStatement {
+	return &ast.AssignStatement{Token: token.Token{Type: token.ASSIGN, Literal: "="}, Name: Variable(name), Value: value}
+}
+
+// Return returns `return value`.
+func Return(value ast.Expression) *ast.ReturnStatement {
+	return &ast.ReturnStatement{Token: token.Token{Type: token.RETURN, Literal: "return"}, ReturnValue: value}
+}
+
+// Def returns a method definition.
+func Def(name string, params []string, body ...ast.Statement) *ast.DefStatement {
+	return &ast.DefStatement{Token: token.Token{Type: token.DEF, Literal: "def"}, Name: Ident(name), Parameters: identifiers(params), BlockStatement: Block(body...)}
+}
+
+// Class returns a class definition. superClass can be empty if the class inherits from Object.
+func Class(name, superClass string, body ...ast.Statement) *ast.ClassStatement {
+	cs := &ast.ClassStatement{Token: token.Token{Type: token.CLASS, Literal: "class"}, Name: Const(name), Body: Block(body...)}
+
+	if superClass != "" {
+		cs.SuperClass = Const(superClass)
+	}
+
+	return cs
+}
+
+// Module returns a module definition.
+func Module(name string, body ...ast.Statement) *ast.ModuleStatement {
+	return &ast.ModuleStatement{Token: token.Token{Type: token.MODULE, Literal: "module"}, Name: Const(name), Body: Block(body...)}
+}
+
+// Program returns a program of stmts.
+func Program(stmts ...ast.Statement) *ast.Program {
+	return &ast.Program{Statements: stmts}
+}
+
+func identifiers(names []string) []*ast.Identifier {
+	idents := []*ast.Identifier{}
+
+	for _, name := range names {
+		idents = append(idents, Ident(name))
+	}
+
+	return idents
+}
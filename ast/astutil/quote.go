@@ -0,0 +1,139 @@
+package astutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/st0012/Rooby/ast"
+	"github.com/st0012/Rooby/lexer"
+	"github.com/st0012/Rooby/parser"
+)
+
+// Quote parses template as Rooby source and substitutes its placeholders with bindings.
+// A placeholder is a variable, constant or method name that's a key of bindings:
+//
+//   - a string binding renames the placeholder. Use Str to insert a string literal instead.
+//   - int and bool bindings become integer and boolean literals.
+//   - ast.Node bindings replace the placeholder. An ast.Statement or []ast.Statement binding can only
+//     replace a placeholder that's a statement on its own, which is how method and class bodies are injected.
+//
+// For example:
+//
+//	Quote(`
+//	class NAME
+//	  def getter
+//	    @value
+//	  end
+//	end
+//	`, map[string]interface{}{"NAME": "User", "getter": "name", "@value": "@name"})
+func Quote(template string, bindings map[string]interface{}) (stmts []ast.Statement, err error) {
+	p := parser.New(lexer.New(template))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		return nil, fmt.Errorf("astutil: can't parse template: %s", strings.Join(p.Errors(), "; "))
+	}
+
+	// Cursor.Replace panics when a binding doesn't fit the placeholder's position
+	defer func() {
+		if r := recover(); r != nil {
+			stmts, err = nil, fmt.Errorf("%v", r)
+		}
+	}()
+
+	Apply(program, func(c *Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.ExpressionStatement:
+			b, ok := bindings[placeholder(n.Expression)]
+
+			if !ok {
+				return true
+			}
+
+			switch b := b.(type) {
+			case ast.Statement:
+				c.Replace(b)
+				return false
+			case []ast.Statement:
+				for _, stmt := range b {
+					c.InsertBefore(stmt)
+				}
+
+				c.Delete()
+				return false
+			}
+		case *ast.CallExpression:
+			if name, ok := bindings[n.Method].(string); ok {
+				n.Method = name
+				n.Token.Literal = name
+			}
+		case *ast.Identifier, *ast.Constant, *ast.InstanceVariable, *ast.GlobalVariable:
+			b, ok := bindings[placeholder(n.(ast.Expression))]
+
+			if !ok {
+				return true
+			}
+
+			if name, ok := b.(string); ok {
+				rename(n, name)
+				return true
+			}
+
+			node, err := literal(b)
+
+			if err != nil {
+				panic(err.Error())
+			}
+
+			c.Replace(node)
+			return false
+		}
+
+		return true
+	}, nil)
+
+	return program.Statements, nil
+}
+
+// placeholder returns the name of exp if it can be a placeholder.
+func placeholder(exp ast.Expression) string {
+	switch e := exp.(type) {
+	case *ast.Identifier:
+		return e.Value
+	case *ast.Constant:
+		return e.Value
+	case *ast.InstanceVariable:
+		return e.Value
+	case *ast.GlobalVariable:
+		return e.Value
+	default:
+		return ""
+	}
+}
+
+func rename(n ast.Node, name string) {
+	switch n := n.(type) {
+	case *ast.Identifier:
+		n.Value, n.Token.Literal = name, name
+	case *ast.Constant:
+		n.Value, n.Token.Literal = name, name
+	case *ast.InstanceVariable:
+		n.Value, n.Token.Literal = name, name
+	case *ast.GlobalVariable:
+		n.Value, n.Token.Literal = name, name
+	}
+}
+
+// literal converts a binding value into an AST node.
+func literal(v interface{}) (ast.Node, error) {
+	switch v := v.(type) {
+	case ast.Node:
+		return v, nil
+	case int:
+		return Int(v), nil
+	case bool:
+		return Bool(v), nil
+	default:
+		return nil, fmt.Errorf("astutil: can't convert %T into an AST node", v)
+	}
+}
@@ -0,0 +1,110 @@
+package astutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/st0012/Rooby/ast"
+)
+
+func TestQuote(t *testing.T) {
+	template := `
+class NAME < Base
+  def initialize(arg)
+    @attr = arg
+  end
+
+  def getter
+    @attr
+  end
+
+  BODY
+end
+
+NAME.new(VALUE).getter
+`
+
+	stmts, err := Quote(template, map[string]interface{}{
+		"NAME":   "User",
+		"arg":    "name",
+		"@attr":  "@name",
+		"getter": "name",
+		"VALUE":  Str("Stan"),
+		"BODY": []ast.Statement{
+			Def("admin?", nil, Stmt(Bool(false))),
+			Def("age", nil, Stmt(Int(20))),
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	expected := `class User < Base
+  def initialize(name)
+    @name = name
+  end
+  def name
+    @name
+  end
+  def admin?
+    false
+  end
+  def age
+    20
+  end
+end
+User.new("Stan").name
+`
+
+	if Source(Program(stmts...)) != expected {
+		t.Fatalf("Expect quoted source to be:\n%s\ngot:\n%s", expected, Source(Program(stmts...)))
+	}
+}
+
+func TestQuoteExpressionsAndStatements(t *testing.T) {
+	stmts, err := Quote(`
+x = LIMIT
+if ENABLED
+  STMT
+end
+`, map[string]interface{}{
+		"LIMIT":   10,
+		"ENABLED": true,
+		"STMT":    Return(Infix(Ident("x"), "*", Int(2))),
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	expected := "x = 10\nif true\n  return x * 2\nend\n"
+
+	if Source(Program(stmts...)) != expected {
+		t.Fatalf("Expect quoted source to be %q. got=%q", expected, Source(Program(stmts...)))
+	}
+}
+
+func TestQuoteErrors(t *testing.T) {
+	tests := []struct {
+		template string
+		bindings map[string]interface{}
+		expected string
+	}{
+		{`class`, nil, "astutil: can't parse template"},
+		{`a = VALUE`, map[string]interface{}{"VALUE": 1.5}, "astutil: can't convert float64"},
+		{`a = VALUE`, map[string]interface{}{"VALUE": Return(Int(1))}, "astutil: can't use *ast.ReturnStatement"},
+	}
+
+	for _, tt := range tests {
+		_, err := Quote(tt.template, tt.bindings)
+
+		if err == nil {
+			t.Fatalf("Expect quoting %q to return an error", tt.template)
+		}
+
+		if !strings.HasPrefix(err.Error(), tt.expected) {
+			t.Fatalf("Expect error to start with %q. got=%q", tt.expected, err.Error())
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package astutil
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/st0012/Rooby/ast"
+)
+
+// indentation is the indentation of each nested block in generated source.
+const indentation = "  "
+
+// Source returns Rooby source code of node that parses back into an equivalent AST.
+// Unlike node.String(), which is meant for debugging, it puts every statement on its own line
+// and indents the bodies of methods, classes and blocks.
+func Source(node ast.Node) string {
+	p := &printer{}
+
+	switch n := node.(type) {
+	case *ast.Program:
+		p.statements(n.Statements)
+	case *ast.BlockStatement:
+		p.statements(n.Statements)
+	case ast.Statement:
+		p.statement(n)
+		p.newline()
+	case ast.Expression:
+		p.expression(n)
+		p.newline()
+	default:
+		panic(fmt.Sprintf("astutil: Source: unexpected node type %T", n))
+	}
+
+	return p.out.String()
+}
+
+type printer struct {
+	out    bytes.Buffer
+	indent int
+}
+
+func (p *printer) write(s string) {
+	p.out.WriteString(s)
+}
+
+func (p *printer) newline() {
+	p.write("\n")
+}
+
+func (p *printer) statements(stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		p.write(strings.Repeat(indentation, p.indent))
+		p.statement(stmt)
+		p.newline()
+	}
+}
+
+// block writes a newline, the indented statements of b and the indentation of the line that closes b.
+func (p *printer) block(b *ast.BlockStatement) {
+	p.newline()
+	p.indent++
+
+	if b != nil {
+		p.statements(b.Statements)
+	}
+
+	p.indent--
+	p.write(strings.Repeat(indentation, p.indent))
+}
+
+func (p *printer) statement(stmt ast.Statement) {
+	switch s := stmt.(type) {
+	case *ast.AssignStatement:
+		p.write(s.Name.ReturnValue())
+		p.write(" = ")
+		p.expression(s.Value)
+	case *ast.DefStatement:
+		p.write("def ")
+
+		if s.Receiver != nil {
+			p.expression(s.Receiver)
+			p.write(".")
+		}
+
+		p.write(s.Name.Value)
+
+		if len(s.Parameters) > 0 {
+			p.write("(")
+			p.identifiers(s.Parameters)
+			p.write(")")
+		}
+
+		p.block(s.BlockStatement)
+		p.write("end")
+	case *ast.ClassStatement:
+		p.write("class ")
+		p.write(s.Name.Value)
+
+		if s.SuperClass != nil {
+			p.write(" < ")
+			p.write(s.SuperClass.Value)
+		}
+
+		p.block(s.Body)
+		p.write("end")
+	case *ast.ModuleStatement:
+		p.write("module ")
+		p.write(s.Name.Value)
+		p.block(s.Body)
+		p.write("end")
+	case *ast.ReturnStatement:
+		p.write("return")
+
+		if s.ReturnValue != nil {
+			p.write(" ")
+			p.expression(s.ReturnValue)
+		}
+	case *ast.ExpressionStatement:
+		p.expression(s.Expression)
+	case *ast.WhileStatement:
+		p.write("while ")
+		p.expression(s.Condition)
+		p.block(s.Body)
+		p.write("end")
+	case *ast.ErrorStatement:
+		p.write("# error: ")
+		p.write(strings.Join(s.Errors, "; "))
+	default:
+		panic(fmt.Sprintf("astutil: Source: unexpected statement type %T", s))
+	}
+}
+
+func (p *printer) expression(exp ast.Expression) {
+	switch e := exp.(type) {
+	case *ast.Identifier:
+		p.write(e.Value)
+	case *ast.InstanceVariable:
+		p.write(e.Value)
+	case *ast.Constant:
+		p.write(e.Value)
+	case *ast.GlobalVariable:
+		p.write(e.Value)
+	case *ast.IntegerLiteral:
+		if e.Big != nil {
+			p.write(e.Big.String())
+		} else {
+			p.write(strconv.Itoa(e.Value))
+		}
+	case *ast.FloatLiteral:
+		p.write(formatFloat(e.Value))
+	case *ast.RationalLiteral:
+		p.write(e.Token.Literal)
+	case *ast.StringLiteral:
+		p.write(quote(e.Value))
+	case *ast.Boolean:
+		p.write(strconv.FormatBool(e.Value))
+	case *ast.SelfExpression:
+		p.write("self")
+	case *ast.FileExpression:
+		p.write("__FILE__")
+	case *ast.ArrayExpression:
+		p.write("[")
+		p.expressions(e.Elements)
+		p.write("]")
+	case *ast.HashExpression:
+		if len(e.Keys) == 0 {
+			p.write("{}")
+			return
+		}
+
+		p.write("{ ")
+
+		for i, key := range e.Keys {
+			if i > 0 {
+				p.write(", ")
+			}
+
+			p.write(key)
+			p.write(": ")
+			p.expression(e.Data[key])
+		}
+
+		p.write(" }")
+	case *ast.PrefixExpression:
+		p.write(e.Operator)
+		p.operand(e.Right)
+	case *ast.InfixExpression:
+		p.operand(e.Left)
+		p.write(" " + e.Operator + " ")
+		p.operand(e.Right)
+	case *ast.RangeExpression:
+		p.operand(e.Start)
+		p.write(e.Operator())
+		p.operand(e.End)
+	case *ast.IfExpression:
+		p.write("if ")
+		p.expression(e.Condition)
+		p.block(e.Consequence)
+
+		if e.Alternative != nil {
+			p.write("else")
+			p.block(e.Alternative)
+		}
+
+		p.write("end")
+	case *ast.CallExpression:
+		p.call(e)
+	case *ast.YieldExpression:
+		p.write("yield")
+
+		if len(e.Arguments) > 0 {
+			p.write("(")
+			p.expressions(e.Arguments)
+			p.write(")")
+		}
+	case *ast.SuperExpression:
+		p.write("super")
+
+		if e.Explicit {
+			p.write("(")
+			p.expressions(e.Arguments)
+			p.write(")")
+		}
+	case *ast.BeginExpression:
+		p.write("begin")
+		p.block(e.Body)
+
+		for _, r := range e.Rescues {
+			p.write("rescue")
+
+			for i, c := range r.Exceptions {
+				if i > 0 {
+					p.write(",")
+				}
+
+				p.write(" " + c.Value)
+			}
+
+			if r.Variable != nil {
+				p.write(" => " + r.Variable.Value)
+			}
+
+			p.block(r.Body)
+		}
+
+		if e.Ensure != nil {
+			p.write("ensure")
+			p.block(e.Ensure)
+		}
+
+		p.write("end")
+	default:
+		panic(fmt.Sprintf("astutil: Source: unexpected expression type %T", e))
+	}
+}
+
+func (p *printer) call(e *ast.CallExpression) {
+	_, implicitSelf := e.Receiver.(*ast.SelfExpression)
+	args := e.Arguments
+
+	switch {
+	case e.Method == "[]" && len(args) > 0:
+		p.operand(e.Receiver)
+		p.write("[")
+		p.expressions(args)
+		p.write("]")
+	case e.Method == "[]=" && len(args) > 1:
+		p.operand(e.Receiver)
+		p.write("[")
+		p.expressions(args[:len(args)-1])
+		p.write("] = ")
+		p.expression(args[len(args)-1])
+	case (e.Method == "++" || e.Method == "--") && len(args) == 0:
+		p.operand(e.Receiver)
+		p.write(e.Method)
+	case strings.HasSuffix(e.Method, "=") && len(args) == 1 && !implicitSelf:
+		p.operand(e.Receiver)
+		p.write("." + strings.TrimSuffix(e.Method, "=") + " = ")
+		p.expression(args[0])
+	case implicitSelf:
+		// a method call without receiver must have parentheses, otherwise it's parsed as a local variable
+		p.write(e.Method + "(")
+		p.expressions(args)
+		p.write(")")
+	default:
+		p.operand(e.Receiver)
+		p.write("." + e.Method)
+
+		if len(args) > 0 {
+			p.write("(")
+			p.expressions(args)
+			p.write(")")
+		}
+	}
+
+	if e.Block != nil {
+		p.write(" do")
+
+		if len(e.BlockArguments) > 0 {
+			p.write(" |")
+			p.identifiers(e.BlockArguments)
+			p.write("|")
+		}
+
+		p.block(e.Block)
+		p.write("end")
+	}
+}
+
+// operand writes exp and wraps it with parentheses if it's an operation itself,
+// so the generated source doesn't depend on operator precedence.
+func (p *printer) operand(exp ast.Expression) {
+	switch exp.(type) {
+	case *ast.InfixExpression, *ast.PrefixExpression, *ast.RangeExpression:
+		p.write("(")
+		p.expression(exp)
+		p.write(")")
+	default:
+		p.expression(exp)
+	}
+}
+
+func (p *printer) expressions(exps []ast.Expression) {
+	for i, exp := range exps {
+		if i > 0 {
+			p.write(", ")
+		}
+
+		p.expression(exp)
+	}
+}
+
+func (p *printer) identifiers(idents []*ast.Identifier) {
+	for i, ident := range idents {
+		if i > 0 {
+			p.write(", ")
+		}
+
+		p.write(ident.Value)
+	}
+}
+
+// formatFloat formats value the way float literals are written, with a decimal point and without an exponent.
+func formatFloat(value float64) string {
+	s := strconv.FormatFloat(value, 'f', -1, 64)
+
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+
+	return s
+}
+
+// quote wraps s with quotes. Rooby strings don't support escape sequences,
+// so it uses single quotes when s contains double quotes.
+func quote(s string) string {
+	if strings.Contains(s, `"`) {
+		return "'" + s + "'"
+	}
+
+	return `"` + s + `"`
+}
@@ -0,0 +1,127 @@
+package astutil
+
+import (
+	"testing"
+
+	"github.com/st0012/Rooby/ast"
+)
+
+func TestSourceRoundTrip(t *testing.T) {
+	input := `class Foo < Bar
+  def initialize(a, b)
+    @a = a
+    @b = -(b + 1) * 2
+    @c = 1.5 ** 2 % 3.25 >= 0.5
+    @d = (a...b + 1).to_a
+  end
+  def self.build
+    new(1, 2)
+  end
+  def sum
+    [@a, @b].each do |x|
+      puts(x)
+    end
+    h = { a: 1, b: "it's" }
+    h["a"] = 'say "hi"'
+    if @a > 0
+      yield(@a)
+    else
+      return false
+    end
+  end
+end
+i = 0
+while i < 10
+  i++
+end
+begin
+  raise(RuntimeError, "foo")
+rescue TypeError, RuntimeError => e
+  $err = e.message
+ensure
+  puts(__FILE__)
+end
+foo.bar = self.baz
+`
+	program := parseProgram(t, input)
+	source := Source(program)
+	reparsed := parseProgram(t, source)
+
+	if program.String() != reparsed.String() {
+		t.Fatalf("Expect generated source to parse into the same AST.\nsource:\n%s\nexpected=%q\ngot=%q", source, program.String(), reparsed.String())
+	}
+
+	if Source(reparsed) != source {
+		t.Fatalf("Expect source to be stable. got:\n%s\nexpected:\n%s", Source(reparsed), source)
+	}
+}
+
+func TestSourceOfBuiltNodes(t *testing.T) {
+	program := Program(
+		Class("User", "",
+			Def("initialize", []string{"name"},
+				Assign("@name", Ident("name")),
+			),
+			Def("greet", nil,
+				Stmt(Infix(Str("Hello, "), "+", Ident("@name"))),
+			),
+		),
+		Assign("users", Array(Call(Const("User"), "new", Str("Stan")))),
+		Stmt(CallWithBlock(Ident("users"), "each", nil, []string{"u"},
+			Stmt(Call(nil, "puts", Call(Ident("u"), "greet"))),
+		)),
+		Assign("config", HashWith(HashWith(Hash(), "debug", Bool(true)), "retries", Int(3))),
+		Stmt(If(Infix(Ident("config"), "==", Const("Nil")), Block(Return(Int(1))), nil)),
+	)
+
+	expected := `class User
+  def initialize(name)
+    @name = name
+  end
+  def greet
+    "Hello, " + @name
+  end
+end
+users = [User.new("Stan")]
+users.each do |u|
+  puts(u.greet)
+end
+config = { debug: true, retries: 3 }
+if config == Nil
+  return 1
+end
+`
+
+	if Source(program) != expected {
+		t.Fatalf("Expect source to be:\n%s\ngot:\n%s", expected, Source(program))
+	}
+
+	reparsed := parseProgram(t, expected)
+
+	if reparsed.String() != program.String() {
+		t.Fatalf("Expect built AST to be the same as the parsed one.\nexpected=%q\ngot=%q", reparsed.String(), program.String())
+	}
+}
+
+func TestSourceOfSingleNodes(t *testing.T) {
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{Infix(Infix(Int(1), "+", Int(2)), "*", Int(3)), "(1 + 2) * 3\n"},
+		{Call(Infix(Int(1), "+", Int(2)), "to_s"), "(1 + 2).to_s\n"},
+		{Assign("Foo", Int(1)), "Foo = 1\n"},
+		{Infix(Float(2), "**", Float(0.5)), "2.0 ** 0.5\n"},
+		{Assign("$foo", Bool(false)), "$foo = false\n"},
+		{Block(Stmt(Int(1)), Stmt(Int(2))), "1\n2\n"},
+		{Call(nil, "foo"), "foo()\n"},
+		{Call(Ident("s"), "[]", Int(0), Int(2)), "s[0, 2]\n"},
+		{Call(Ident("s"), "[]=", Int(0), Int(2), Str("a")), "s[0, 2] = \"a\"\n"},
+	}
+
+	for _, tt := range tests {
+		if Source(tt.node) != tt.expected {
+			t.Fatalf("Expect source to be %q. got=%q", tt.expected, Source(tt.node))
+		}
+	}
+}
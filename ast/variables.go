@@ -58,3 +58,20 @@ func (c *Constant) TokenLiteral() string {
 func (c *Constant) String() string {
 	return c.Value
 }
+
+type GlobalVariable struct {
+	Token token.Token
+	Value string
+}
+
+func (gv *GlobalVariable) variableNode() {}
+func (gv *GlobalVariable) ReturnValue() string {
+	return gv.Value
+}
+func (gv *GlobalVariable) expressionNode() {}
+func (gv *GlobalVariable) TokenLiteral() string {
+	return gv.Token.Literal
+}
+func (gv *GlobalVariable) String() string {
+	return gv.Value
+}
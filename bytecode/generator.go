@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/st0012/Rooby/ast"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -47,7 +48,7 @@ func (lt *localTable) setLCL(v string, d int) (index, depth int) {
 
 	if !ok {
 		index = lt.set(v)
-		depth = lt.depth
+		depth = d - lt.depth
 		return index, depth
 	}
 
@@ -74,6 +75,16 @@ type Generator struct {
 	program         *ast.Program
 	instructionSets []*instructionSet
 	blockCounter    int
+	ensureCounter   int
+	fileName        string
+	defines         map[string]ast.Expression
+	// locals are the top level local variables, in the order of their indexes
+	locals []string
+	// outerLocals are the local variables of the scopes around the top level, innermost first
+	outerLocals [][]string
+	// callsEval is set if the program calls eval or instance_eval, then instruction sets list the names of their local
+	// variables, so the evaluated code can use the variables of its caller
+	callsEval bool
 }
 
 // NewGenerator initializes new Generator with complete AST tree.
@@ -81,43 +92,122 @@ func NewGenerator(program *ast.Program) *Generator {
 	return &Generator{program: program}
 }
 
+// SetFileName sets the path of compiled source file, which is what `__FILE__` evaluates to.
+// It also makes the generator emit line tables, so runtime errors can be traced back to the source.
+func (g *Generator) SetFileName(name string) {
+	g.fileName = name
+}
+
+// Define makes every reference to constant name compile to value, so hosts can bake configurations like build flags
+// into bytecodes. Defined constants take precedence over constants assigned by the program.
+func (g *Generator) Define(name string, value ast.Expression) {
+	if g.defines == nil {
+		g.defines = make(map[string]ast.Expression)
+	}
+
+	g.defines[name] = value
+}
+
+// SetLocals makes names local variables of the program's top level before it's compiled, in the order of their indexes.
+// Hosts that compile a program piece by piece, like a REPL, use it so a piece can read the variables of earlier ones.
+func (g *Generator) SetLocals(names []string) {
+	g.locals = names
+}
+
+// SetOuterLocals makes names local variables of the scopes around the program's top level, innermost first.
+// Hosts that compile code run inside a block, like eval, use it so the code can read the variables around the block.
+func (g *Generator) SetOuterLocals(names [][]string) {
+	g.outerLocals = names
+}
+
+// Locals returns the local variables of the program's top level after GenerateByteCode, in the order of their indexes.
+func (g *Generator) Locals() []string {
+	return g.locals
+}
+
 // GenerateByteCode returns compiled bytecodes
 func (g *Generator) GenerateByteCode(program *ast.Program) string {
 	scope := &scope{program: program, localTable: newLocalTable(0)}
+
+	for _, name := range g.locals {
+		scope.localTable.set(name)
+	}
+
+	// outer tables have negative depths, so variables found in them are the given number of frames up
+	table := scope.localTable
+
+	for i, names := range g.outerLocals {
+		table.upper = newLocalTable(-i - 1)
+		table = table.upper
+
+		for _, name := range names {
+			table.set(name)
+		}
+	}
+
 	g.compileStatements(program.Statements, scope, scope.localTable)
+	g.locals = scope.localTable.names()
 	var out bytes.Buffer
 
+	if g.fileName != "" {
+		out.WriteString(fmt.Sprintf("file %s\n", g.fileName))
+	}
+
 	for _, is := range g.instructionSets {
-		out.WriteString(is.compile())
+		out.WriteString(is.compile(g.callsEval))
 	}
 
 	return strings.TrimSpace(removeEmptyLine(out.String()))
 }
 
 func (g *Generator) compileStatements(stmts []ast.Statement, scope *scope, table *localTable) {
-	is := &instructionSet{label: &label{Name: "ProgramStart"}}
+	is := &instructionSet{label: &label{Name: "ProgramStart"}, locals: table}
 
-	for _, statement := range stmts {
+	for i, statement := range stmts {
 		g.compileStatement(is, statement, scope, table)
+
+		// Values of top level expressions are discarded once they're evaluated, so a long running program
+		// doesn't keep every object they refer to alive. The last one is kept as the program's result.
+		if stmt, ok := statement.(*ast.ExpressionStatement); ok && i < len(stmts)-1 && leavesValue(stmt.Expression) {
+			is.define("pop")
+		}
 	}
 
 	g.endInstructions(is)
 	g.instructionSets = append(g.instructionSets, is)
 }
 
+// leavesValue returns false for expressions that may not leave a value on the stack. Bodies of if and begin expressions
+// are statements, which don't leave a value if the last one is an assignment.
+func leavesValue(exp ast.Expression) bool {
+	switch exp.(type) {
+	case *ast.IfExpression, *ast.BeginExpression:
+		return false
+	default:
+		return true
+	}
+}
+
 func (g *Generator) compileStatement(is *instructionSet, statement ast.Statement, scope *scope, table *localTable) {
 	scope.line++
+
+	// Line tables are only useful when there's a file to point to. Token lines are zero-based.
+	if g.fileName != "" {
+		is.markLine(statement.Line() + 1)
+	}
+
 	switch stmt := statement.(type) {
 	case *ast.ExpressionStatement:
 		g.compileExpression(is, stmt.Expression, scope, table)
 	case *ast.DefStatement:
-		is.define("putself")
-		is.define("putstring", fmt.Sprintf("\"%s\"", stmt.Name.Value))
-		switch stmt.Receiver.(type) {
-		case *ast.SelfExpression:
-			is.define("def_singleton_method", len(stmt.Parameters))
-		case nil:
+		if stmt.Receiver == nil {
+			is.define("putself")
+			is.define("putstring", fmt.Sprintf("\"%s\"", stmt.Name.Value))
 			is.define("def_method", len(stmt.Parameters))
+		} else {
+			g.compileExpression(is, stmt.Receiver, scope, table)
+			is.define("putstring", fmt.Sprintf("\"%s\"", stmt.Name.Value))
+			is.define("def_singleton_method", len(stmt.Parameters))
 		}
 
 		g.compileDefStmt(stmt, scope)
@@ -134,15 +224,32 @@ func (g *Generator) compileStatement(is *instructionSet, statement ast.Statement
 
 		is.define("pop")
 		g.compileClassStmt(stmt, scope)
+	case *ast.ModuleStatement:
+		is.define("putself")
+		is.define("def_module", stmt.Name.Value)
+		is.define("pop")
+		g.compileModuleStmt(stmt, scope)
 	case *ast.ReturnStatement:
 		g.compileExpression(is, stmt.ReturnValue, scope, table)
+		g.compileEnsures(is, scope, table)
 		g.endInstructions(is)
 	}
 }
 
 func (g *Generator) compileClassStmt(stmt *ast.ClassStatement, scope *scope) {
 	scope = newScope(scope, stmt)
-	is := &instructionSet{}
+	is := &instructionSet{locals: scope.localTable}
+	is.setLabel(fmt.Sprintf("DefClass:%s", stmt.Name.Value))
+
+	g.compileBlockStatement(is, stmt.Body, scope, scope.localTable)
+	is.define("leave")
+	g.instructionSets = append(g.instructionSets, is)
+}
+
+// compileModuleStmt compiles module body like a class body, so it's looked up with the DefClass label by the VM
+func (g *Generator) compileModuleStmt(stmt *ast.ModuleStatement, scope *scope) {
+	scope = newScope(scope, stmt)
+	is := &instructionSet{locals: scope.localTable}
 	is.setLabel(fmt.Sprintf("DefClass:%s", stmt.Name.Value))
 
 	g.compileBlockStatement(is, stmt.Body, scope, scope.localTable)
@@ -159,6 +266,8 @@ func (g *Generator) compileAssignStmt(is *instructionSet, stmt *ast.AssignStatem
 		is.define("setlocal", index, depth)
 	case *ast.InstanceVariable:
 		is.define("setinstancevariable", name.Value)
+	case *ast.GlobalVariable:
+		is.define("setglobal", name.Value)
 	case *ast.Constant:
 		is.define("setconstant", name.Value)
 	}
@@ -167,7 +276,7 @@ func (g *Generator) compileAssignStmt(is *instructionSet, stmt *ast.AssignStatem
 func (g *Generator) compileDefStmt(stmt *ast.DefStatement, scope *scope) {
 	scope = newScope(scope, stmt)
 
-	is := &instructionSet{}
+	is := &instructionSet{locals: scope.localTable}
 	is.setLabel(fmt.Sprintf("Def:%s", stmt.Name.Value))
 
 	for i := 0; i < len(stmt.Parameters); i++ {
@@ -179,6 +288,35 @@ func (g *Generator) compileDefStmt(stmt *ast.DefStatement, scope *scope) {
 	g.instructionSets = append(g.instructionSets, is)
 }
 
+// compileSuperExpression pushes the arguments of super. Without parentheses they're the current values of the method's parameters,
+// which are looked up in the method's own local table, so block parameters with the same names don't shadow them.
+func (g *Generator) compileSuperExpression(is *instructionSet, exp *ast.SuperExpression, scope *scope, table *localTable) {
+	is.define("putself")
+
+	if exp.Explicit {
+		for _, arg := range exp.Arguments {
+			g.compileExpression(is, arg, scope, table)
+		}
+
+		is.define("invokesuper", len(exp.Arguments))
+		return
+	}
+
+	def, ok := scope.self.(*ast.DefStatement)
+
+	if !ok {
+		is.define("invokesuper", 0)
+		return
+	}
+
+	for _, param := range def.Parameters {
+		index, _ := scope.localTable.get(param.Value)
+		is.define("getlocal", index, table.depth-scope.localTable.depth)
+	}
+
+	is.define("invokesuper", len(def.Parameters))
+}
+
 func (g *Generator) compileExpression(is *instructionSet, exp ast.Expression, scope *scope, table *localTable) {
 	switch exp := exp.(type) {
 	case *ast.Identifier:
@@ -195,11 +333,28 @@ func (g *Generator) compileExpression(is *instructionSet, exp ast.Expression, sc
 		is.define("send", exp.Value, 0)
 
 	case *ast.Constant:
+		if value, ok := g.defines[exp.Value]; ok {
+			g.compileExpression(is, value, scope, table)
+			return
+		}
+
 		is.define("getconstant", exp.Value)
 	case *ast.InstanceVariable:
 		is.define("getinstancevariable", exp.Value)
+	case *ast.GlobalVariable:
+		is.define("getglobal", exp.Value)
+	case *ast.FileExpression:
+		is.define("putstring", fmt.Sprintf("\"%s\"", g.fileName))
 	case *ast.IntegerLiteral:
-		is.define("putobject", fmt.Sprint(exp.Value))
+		if exp.Big != nil {
+			is.define("putobject", exp.Big.String())
+		} else {
+			is.define("putobject", fmt.Sprint(exp.Value))
+		}
+	case *ast.FloatLiteral:
+		is.define("putfloat", strconv.FormatFloat(exp.Value, 'g', -1, 64))
+	case *ast.RationalLiteral:
+		is.define("putrational", exp.Value.String())
 	case *ast.StringLiteral:
 		is.define("putstring", fmt.Sprintf("\"%s\"", exp.Value))
 	case *ast.Boolean:
@@ -210,16 +365,25 @@ func (g *Generator) compileExpression(is *instructionSet, exp ast.Expression, sc
 		}
 		is.define("newarray", len(exp.Elements))
 	case *ast.HashExpression:
-		for key, value := range exp.Data {
+		for _, key := range exp.Keys {
 			is.define("putstring", fmt.Sprintf("\"%s\"", key))
-			g.compileExpression(is, value, scope, table)
+			g.compileExpression(is, exp.Data[key], scope, table)
 		}
-		is.define("newhash", len(exp.Data)*2)
+		is.define("newhash", len(exp.Keys)*2)
 	case *ast.InfixExpression:
 		g.compileInfixExpression(is, exp, scope, table)
+	case *ast.RangeExpression:
+		g.compileExpression(is, exp.Start, scope, table)
+		g.compileExpression(is, exp.End, scope, table)
+
+		if exp.Exclusive {
+			is.define("newrange", 1)
+		} else {
+			is.define("newrange", 0)
+		}
 	case *ast.PrefixExpression:
 		switch exp.Operator {
-		case "!":
+		case "!", "~":
 			g.compileExpression(is, exp.Right, scope, table)
 			is.define("send", exp.Operator, 0)
 		case "-":
@@ -230,6 +394,8 @@ func (g *Generator) compileExpression(is *instructionSet, exp ast.Expression, sc
 
 	case *ast.IfExpression:
 		g.compileIfExpression(is, exp, scope, table)
+	case *ast.BeginExpression:
+		g.compileBeginExpression(is, exp, scope, table)
 	case *ast.SelfExpression:
 		is.define("putself")
 	case *ast.YieldExpression:
@@ -240,7 +406,17 @@ func (g *Generator) compileExpression(is *instructionSet, exp ast.Expression, sc
 		}
 
 		is.define("invokeblock", len(exp.Arguments))
+	case *ast.SuperExpression:
+		g.compileSuperExpression(is, exp, scope, table)
 	case *ast.CallExpression:
+		if g.compileIncrement(is, exp, table) {
+			return
+		}
+
+		if exp.Method == "eval" || exp.Method == "instance_eval" {
+			g.callsEval = true
+		}
+
 		g.compileExpression(is, exp.Receiver, scope, table)
 
 		for _, arg := range exp.Arguments {
@@ -260,14 +436,66 @@ func (g *Generator) compileExpression(is *instructionSet, exp ast.Expression, sc
 	}
 }
 
+// compileIncrement compiles `a++` and `a--` into `a = a + 1` and `a = a - 1` when a is a variable.
+// Integers are immutable, so the result has to be stored back. It returns false if exp isn't such expression.
+func (g *Generator) compileIncrement(is *instructionSet, exp *ast.CallExpression, table *localTable) bool {
+	if (exp.Method != "++" && exp.Method != "--") || len(exp.Arguments) != 0 || exp.Block != nil {
+		return false
+	}
+
+	var get, set string
+	var params []interface{}
+
+	switch v := exp.Receiver.(type) {
+	case *ast.Identifier:
+		index, depth, ok := table.getLCL(v.Value, table.depth)
+
+		if !ok {
+			return false
+		}
+
+		get, set, params = "getlocal", "setlocal", []interface{}{index, depth}
+	case *ast.InstanceVariable:
+		get, set, params = "getinstancevariable", "setinstancevariable", []interface{}{v.Value}
+	case *ast.GlobalVariable:
+		get, set, params = "getglobal", "setglobal", []interface{}{v.Value}
+	default:
+		return false
+	}
+
+	is.define(get, params...)
+	is.define("send", exp.Method, 0)
+	is.define(set, params...)
+	is.define(get, params...)
+	return true
+}
+
 func (g *Generator) compileBlockArgExpression(index int, exp *ast.CallExpression, scope *scope, table *localTable) {
-	is := &instructionSet{}
+	is := &instructionSet{locals: table}
 	is.setLabel(fmt.Sprintf("Block:%d", index))
+	is.arity = len(exp.BlockArguments)
 
 	for i := 0; i < len(exp.BlockArguments); i++ {
 		table.set(exp.BlockArguments[i].Value)
 	}
 
+	// a destructured parameter like `(k, v)` is expanded into its own locals before the block body runs
+	for i := 0; i < len(exp.BlockArguments); i++ {
+		names, ok := exp.DestructuredArguments[i]
+
+		if !ok {
+			continue
+		}
+
+		index, _ := table.get(exp.BlockArguments[i].Value)
+		is.define("getlocal", index, 0)
+		is.define("expandarray", len(names))
+
+		for _, name := range names {
+			is.define("setlocal", table.set(name.Value), 0)
+		}
+	}
+
 	g.compileBlockStatement(is, exp.Block, scope, table)
 	g.endInstructions(is)
 	g.instructionSets = append(g.instructionSets, is)
@@ -297,6 +525,128 @@ func (g *Generator) compileIfExpression(is *instructionSet, exp *ast.IfExpressio
 	anchor2.line = is.Count
 }
 
+// compileBeginExpression compiles begin/rescue/ensure into following layout:
+//
+//	start:   body
+//	         jump ok
+//	handler: check each rescue clause and jump to ok after its body, or throw if no clause matches
+//	ok:      ensure body (value of begin expression is preserved)
+//	         jump end
+//	ensure:  ensure body, then throw the exception again
+//	end:
+//
+// Body is protected by a catch entry that jumps to handler, and both body and handler are protected by another one that jumps to ensure.
+// A return in body or handler leaves without reaching either, so it runs the ensure body itself, see compileEnsures.
+func (g *Generator) compileBeginExpression(is *instructionSet, exp *ast.BeginExpression, scope *scope, table *localTable) {
+	start := is.Count
+	okAnchor := &anchor{}
+	var ensure *ensureClause
+
+	if exp.Ensure != nil {
+		ensure = &ensureClause{body: exp.Ensure}
+		is.ensures = append(is.ensures, ensure)
+	}
+
+	g.compileBlockStatementOrNil(is, exp.Body, scope, table)
+	is.define("jump", okAnchor)
+
+	if len(exp.Rescues) > 0 {
+		is.catchTable = append(is.catchTable, &catchEntry{start: start, end: is.Count, handler: is.Count})
+
+		for _, rc := range exp.Rescues {
+			g.compileRescueClause(is, rc, okAnchor, scope, table)
+		}
+
+		// No rescue clause matches the exception
+		is.define("throw")
+	}
+
+	handlerEnd := is.Count
+	okAnchor.line = is.Count
+
+	if ensure == nil {
+		return
+	}
+
+	// A return in the ensure body itself only runs the ensure clauses around this begin expression
+	is.ensures = is.ensures[:len(is.ensures)-1]
+	index := g.ensureLocal(ensure, table)
+	endAnchor := &anchor{}
+	is.define("setlocal", index, 0)
+	g.compileBlockStatement(is, exp.Ensure, scope, table)
+	is.define("getlocal", index, 0)
+	is.define("jump", endAnchor)
+
+	is.catchTable = append(is.catchTable, &catchEntry{start: start, end: handlerEnd, handler: is.Count})
+	is.define("setlocal", index, 0)
+	g.compileBlockStatement(is, exp.Ensure, scope, table)
+	is.define("getlocal", index, 0)
+	is.define("throw")
+
+	endAnchor.line = is.Count
+}
+
+// compileEnsures runs the ensure bodies of the begin expressions a return is in before it leaves, innermost first.
+// Like the ok path of compileBeginExpression, the value being returned is kept in each one's hidden local meanwhile.
+func (g *Generator) compileEnsures(is *instructionSet, scope *scope, table *localTable) {
+	ensures := is.ensures
+
+	for i := len(ensures) - 1; i >= 0; i-- {
+		index := g.ensureLocal(ensures[i], table)
+		is.ensures = ensures[:i]
+		is.define("setlocal", index, 0)
+		g.compileBlockStatement(is, ensures[i].body, scope, table)
+		is.define("getlocal", index, 0)
+	}
+
+	is.ensures = ensures
+}
+
+// ensureLocal returns the index of the hidden local of an ensure clause. Ensure body shouldn't change
+// begin expression's value, so the value is kept there while the body runs.
+func (g *Generator) ensureLocal(ensure *ensureClause, table *localTable) int {
+	if !ensure.allocated {
+		ensure.local = table.set(fmt.Sprintf("<ensure:%d>", g.ensureCounter))
+		ensure.allocated = true
+		g.ensureCounter++
+	}
+
+	return ensure.local
+}
+
+func (g *Generator) compileRescueClause(is *instructionSet, rc *ast.RescueClause, okAnchor *anchor, scope *scope, table *localTable) {
+	nextAnchor := &anchor{}
+
+	exceptions := []string{}
+
+	for _, e := range rc.Exceptions {
+		exceptions = append(exceptions, e.Value)
+	}
+
+	if len(exceptions) == 0 {
+		exceptions = append(exceptions, "StandardError")
+	}
+
+	for _, e := range exceptions {
+		is.define("getconstant", e)
+	}
+
+	is.define("checkmatch", len(exceptions))
+	is.define("branchunless", nextAnchor)
+
+	if rc.Variable != nil {
+		index, depth := table.setLCL(rc.Variable.Value, table.depth)
+		is.define("setlocal", index, depth)
+	} else {
+		is.define("pop")
+	}
+
+	g.compileBlockStatementOrNil(is, rc.Body, scope, table)
+	is.define("jump", okAnchor)
+
+	nextAnchor.line = is.Count
+}
+
 func (g *Generator) compileInfixExpression(is *instructionSet, node *ast.InfixExpression, scope *scope, table *localTable) {
 	g.compileExpression(is, node.Left, scope, table)
 	g.compileExpression(is, node.Right, scope, table)
@@ -309,6 +659,16 @@ func (g *Generator) compileBlockStatement(is *instructionSet, stmt *ast.BlockSta
 	}
 }
 
+// compileBlockStatementOrNil makes sure an empty block statement still leaves a value (nil) on the stack.
+func (g *Generator) compileBlockStatementOrNil(is *instructionSet, stmt *ast.BlockStatement, scope *scope, table *localTable) {
+	if len(stmt.Statements) == 0 {
+		is.define("putnil")
+		return
+	}
+
+	g.compileBlockStatement(is, stmt, scope, table)
+}
+
 func (g *Generator) endInstructions(is *instructionSet) {
 	is.define("leave")
 }
@@ -323,6 +683,17 @@ func removeEmptyLine(s string) string {
 	return s
 }
 
+// names returns the names of the table's local variables, in the order of their indexes.
+func (lt *localTable) names() []string {
+	names := make([]string, lt.count)
+
+	for name, index := range lt.store {
+		names[index] = name
+	}
+
+	return names
+}
+
 func newLocalTable(depth int) *localTable {
 	s := make(map[string]int)
 	return &localTable{store: s, depth: depth}
@@ -1,6 +1,7 @@
 package bytecode
 
 import (
+	"github.com/st0012/Rooby/ast"
 	"github.com/st0012/Rooby/lexer"
 	"github.com/st0012/Rooby/parser"
 	"strings"
@@ -68,8 +69,9 @@ i
 11 setlocal 3 0
 12 getlocal 3 0
 13 send bar 0 block:0
-14 getlocal 1 0
-15 leave
+14 pop
+15 getlocal 1 0
+16 leave
 `
 	bytecode := compileToBytecode(input)
 	compareBytecode(t, bytecode, expected)
@@ -95,6 +97,7 @@ end
 3 invokeblock 2
 4 leave
 <Block:0>
+arity 2
 0 getlocal 0 0
 1 getlocal 1 0
 2 send - 1
@@ -114,6 +117,50 @@ end
 	compareBytecode(t, bytecode, expected)
 }
 
+func TestDestructuredBlockArgumentsCompilation(t *testing.T) {
+	input := `
+def foo
+  yield([1, 2], 3)
+end
+
+self.foo do |(a, b), c|
+  a + b + c
+end
+`
+	expected := `
+<Def:foo>
+0 putself
+1 putobject 1
+2 putobject 2
+3 newarray 2
+4 putobject 3
+5 invokeblock 2
+6 leave
+<Block:0>
+arity 2
+0 getlocal 0 0
+1 expandarray 2
+2 setlocal 2 0
+3 setlocal 3 0
+4 getlocal 2 0
+5 getlocal 3 0
+6 send + 1
+7 getlocal 1 0
+8 send + 1
+9 leave
+<ProgramStart>
+0 putself
+1 putstring "foo"
+2 def_method 0
+3 putself
+4 send foo 0 block:0
+5 leave
+`
+
+	bytecode := compileToBytecode(input)
+	compareBytecode(t, bytecode, expected)
+}
+
 func TestHashCompilation(t *testing.T) {
 	input := `
 	a = { foo: 1, bar: 5 }
@@ -122,7 +169,7 @@ func TestHashCompilation(t *testing.T) {
 	b["baz"] + a["bar"]
 `
 
-	expected1 := `
+	expected := `
 <ProgramStart>
 0 putstring "foo"
 1 putobject 1
@@ -142,66 +189,19 @@ func TestHashCompilation(t *testing.T) {
 15 send [] 1
 16 send - 1
 17 send []= 2
-18 getlocal 1 0
-19 putstring "baz"
-20 send [] 1
-21 getlocal 0 0
-22 putstring "bar"
-23 send [] 1
-24 send + 1
-25 leave
-`
-	expected2 := `
-<ProgramStart>
-0 putstring "bar"
-1 putobject 5
-2 putstring "foo"
-3 putobject 1
-4 newhash 4
-5 setlocal 0 0
-6 newhash 0
-7 setlocal 1 0
-8 getlocal 1 0
-9 putstring "baz"
-10 getlocal 0 0
-11 putstring "bar"
-12 send [] 1
-13 getlocal 0 0
-14 putstring "foo"
-15 send [] 1
-16 send - 1
-17 send []= 2
-18 getlocal 1 0
-19 putstring "baz"
-20 send [] 1
-21 getlocal 0 0
-22 putstring "bar"
-23 send [] 1
-24 send + 1
-25 leave
-`
-	bytecode := strings.TrimSpace(compileToBytecode(input))
-
-	// This is because hash stores data using map.
-	// And map's keys won't be sorted when running in for loop.
-	// So we can get 2 possible results.
-	expected1 = strings.TrimSpace(expected1)
-	expected2 = strings.TrimSpace(expected2)
-	if bytecode != expected1 && bytecode != expected2 {
-		t.Fatalf(`
-Bytecode compare failed
-Expect:
-"%s"
-
-Or:
-
-"%s"
-
-Got:
-"%s"
-`, expected1, expected2, bytecode)
-	}
+18 pop
+19 getlocal 1 0
+20 putstring "baz"
+21 send [] 1
+22 getlocal 0 0
+23 putstring "bar"
+24 send [] 1
+25 send + 1
+26 leave
+`
 
+	bytecode := compileToBytecode(input)
+	compareBytecode(t, bytecode, expected)
 }
 
 func TestArrayCompilation(t *testing.T) {
@@ -222,11 +222,12 @@ func TestArrayCompilation(t *testing.T) {
 6 putobject 0
 7 putstring "foo"
 8 send []= 2
-9 getlocal 0 0
-10 putobject 0
-11 send [] 1
-12 setlocal 1 0
-13 leave
+9 pop
+10 getlocal 0 0
+11 putobject 0
+12 send [] 1
+13 setlocal 1 0
+14 leave
 `
 	bytecode := compileToBytecode(input)
 	compareBytecode(t, bytecode, expected)
@@ -322,6 +323,36 @@ Foo.bar
 	compareBytecode(t, bytecode, expected)
 }
 
+func TestSingletonMethodDefinition(t *testing.T) {
+	input := `
+foo = Object.new
+
+def foo.bar
+  10
+end
+
+foo.bar
+`
+	expected := `
+<Def:bar>
+0 putobject 10
+1 leave
+<ProgramStart>
+0 getconstant Object
+1 send new 0
+2 setlocal 0 0
+3 getlocal 0 0
+4 putstring "bar"
+5 def_singleton_method 0
+6 getlocal 0 0
+7 send bar 0
+8 leave
+`
+
+	bytecode := compileToBytecode(input)
+	compareBytecode(t, bytecode, expected)
+}
+
 func TestClassDefinition(t *testing.T) {
 	input := `
 class Bar
@@ -362,6 +393,45 @@ Foo.new.bar
 	compareBytecode(t, bytecode, expected)
 }
 
+func TestModuleDefinition(t *testing.T) {
+	input := `
+module Bar
+  def bar
+    10
+  end
+end
+
+class Foo
+  include(Bar)
+end
+`
+	expected := `
+<Def:bar>
+0 putobject 10
+1 leave
+<DefClass:Bar>
+0 putself
+1 putstring "bar"
+2 def_method 0
+3 leave
+<DefClass:Foo>
+0 putself
+1 getconstant Bar
+2 send include 1
+3 leave
+<ProgramStart>
+0 putself
+1 def_module Bar
+2 pop
+3 putself
+4 def_class Foo
+5 pop
+6 leave
+`
+	bytecode := compileToBytecode(input)
+	compareBytecode(t, bytecode, expected)
+}
+
 func TestBasicMethodReDefineAndExecution(t *testing.T) {
 	input := `
 	def foo(x)
@@ -487,6 +557,144 @@ func TestLocalVariableAccessInCurrentScope(t *testing.T) {
 	compareBytecode(t, bytecode, expected)
 }
 
+func TestCompilationWithFileName(t *testing.T) {
+	input := `
+	$foo = __FILE__
+	$0 == $foo
+	`
+
+	expected := `
+file foo.ro
+<ProgramStart>
+line 0 2
+line 2 3
+0 putstring "foo.ro"
+1 setglobal $foo
+2 getglobal $0
+3 getglobal $foo
+4 send == 1
+5 leave
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	p.CheckErrors()
+	g := NewGenerator(program)
+	g.SetFileName("foo.ro")
+
+	bytecode := g.GenerateByteCode(program)
+	compareBytecode(t, bytecode, expected)
+}
+
+func TestDefinedConstantCompilation(t *testing.T) {
+	input := `
+	if DEBUG
+	  puts(LEVEL)
+	end
+	Foo
+	`
+
+	expected := `
+<ProgramStart>
+0 putobject true
+1 branchunless 5
+2 putself
+3 putobject 3
+4 send puts 1
+5 putnil
+6 getconstant Foo
+7 leave
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	p.CheckErrors()
+	g := NewGenerator(program)
+	g.Define("DEBUG", &ast.Boolean{Value: true})
+	g.Define("LEVEL", &ast.IntegerLiteral{Value: 3})
+
+	bytecode := g.GenerateByteCode(program)
+	compareBytecode(t, bytecode, expected)
+}
+
+func TestIncrementCompilation(t *testing.T) {
+	input := `
+	a = 1
+	a++
+	@b--
+	`
+
+	expected := `
+<ProgramStart>
+0 putobject 1
+1 setlocal 0 0
+2 getlocal 0 0
+3 send ++ 0
+4 setlocal 0 0
+5 getlocal 0 0
+6 pop
+7 getinstancevariable @b
+8 send -- 0
+9 setinstancevariable @b
+10 getinstancevariable @b
+11 leave
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	p.CheckErrors()
+	g := NewGenerator(program)
+
+	bytecode := g.GenerateByteCode(program)
+	compareBytecode(t, bytecode, expected)
+}
+
+func TestSuperCompilation(t *testing.T) {
+	input := `
+	def foo(a, b)
+	  super(1)
+	  [1].each do |a|
+	    super
+	  end
+	end
+	`
+
+	expected := `
+<Block:0>
+arity 1
+0 putself
+1 getlocal 0 1
+2 getlocal 1 1
+3 invokesuper 2
+4 leave
+<Def:foo>
+0 putself
+1 putobject 1
+2 invokesuper 1
+3 putobject 1
+4 newarray 1
+5 send each 0 block:0
+6 leave
+<ProgramStart>
+0 putself
+1 putstring "foo"
+2 def_method 2
+3 leave
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	p.CheckErrors()
+	g := NewGenerator(program)
+
+	bytecode := g.GenerateByteCode(program)
+	compareBytecode(t, bytecode, expected)
+}
+
 func TestConditionWithoutAlternativeCompilation(t *testing.T) {
 	input := `
 	a = 10
@@ -559,6 +767,103 @@ func TestConditionWithAlternativeCompilation(t *testing.T) {
 	compareBytecode(t, bytecode, expected)
 }
 
+func TestBeginRescueEnsureCompilation(t *testing.T) {
+	input := `
+a = begin
+  foo
+rescue TypeError => e
+  10
+ensure
+  a = 1
+end
+`
+	expected := `
+<ProgramStart>
+catch 0 3 3
+catch 0 10 15
+0 putself
+1 send foo 0
+2 jump 10
+3 getconstant TypeError
+4 checkmatch 1
+5 branchunless 9
+6 setlocal 0 0
+7 putobject 10
+8 jump 10
+9 throw
+10 setlocal 1 0
+11 putobject 1
+12 setlocal 2 0
+13 getlocal 1 0
+14 jump 20
+15 setlocal 1 0
+16 putobject 1
+17 setlocal 2 0
+18 getlocal 1 0
+19 throw
+20 setlocal 2 0
+21 leave
+`
+
+	bytecode := compileToBytecode(input)
+	compareBytecode(t, bytecode, expected)
+}
+
+func TestBeginWithBareRescueCompilation(t *testing.T) {
+	input := `
+begin
+rescue
+  10
+end
+`
+	expected := `
+<ProgramStart>
+catch 0 2 2
+0 putnil
+1 jump 9
+2 getconstant StandardError
+3 checkmatch 1
+4 branchunless 8
+5 pop
+6 putobject 10
+7 jump 9
+8 throw
+9 leave
+`
+
+	bytecode := compileToBytecode(input)
+	compareBytecode(t, bytecode, expected)
+}
+
+func TestLocalsCompilationWithEval(t *testing.T) {
+	input := `
+a = 1
+[a].each do |b|
+  eval("a + b")
+end
+`
+	expected := `
+<Block:0>
+arity 1
+locals b
+0 putself
+1 putstring "a + b"
+2 send eval 1
+3 leave
+<ProgramStart>
+locals a
+0 putobject 1
+1 setlocal 0 0
+2 getlocal 0 0
+3 newarray 1
+4 send each 0 block:0
+5 leave
+`
+
+	bytecode := compileToBytecode(input)
+	compareBytecode(t, bytecode, expected)
+}
+
 func compileToBytecode(input string) string {
 	l := lexer.New(input)
 	p := parser.New(l)
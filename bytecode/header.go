@@ -0,0 +1,83 @@
+package bytecode
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MagicNumber is the first token of every serialized bytecode file.
+	MagicNumber = "ROBC"
+	// Version is the current bytecode format version. Bump it whenever the instruction format changes incompatibly.
+	Version = 3
+)
+
+// Header describes the first line of a serialized bytecode file.
+type Header struct {
+	Version    int
+	SourceHash string
+	Length     int
+}
+
+func (h *Header) compile() string {
+	return fmt.Sprintf("%s %d %s %d\n", MagicNumber, h.Version, h.SourceHash, h.Length)
+}
+
+// HashSource returns the hash of Rooby source code that is stored in bytecode header.
+func HashSource(source string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(source)))
+}
+
+// AddHeader prefixes compiled bytecodes with a header so they can be validated before execution.
+func AddHeader(bytecodes, source string) string {
+	h := &Header{Version: Version, SourceHash: HashSource(source), Length: len(bytecodes)}
+	return h.compile() + bytecodes
+}
+
+// ReadHeader validates the header of a serialized bytecode file and returns it along with the remaining bytecodes.
+// It returns an error if the file isn't Rooby bytecode, was compiled by an incompatible version or is truncated.
+func ReadHeader(file string) (*Header, string, error) {
+	var firstLine, bytecodes string
+
+	if i := strings.Index(file, "\n"); i >= 0 {
+		firstLine, bytecodes = file[:i], file[i+1:]
+	} else {
+		firstLine = file
+	}
+
+	fields := strings.Fields(firstLine)
+
+	if len(fields) == 0 || fields[0] != MagicNumber {
+		return nil, "", fmt.Errorf("not a Rooby bytecode file: missing %s header", MagicNumber)
+	}
+
+	if len(fields) != 4 {
+		return nil, "", fmt.Errorf("malformed bytecode header: %q", firstLine)
+	}
+
+	version, err := strconv.Atoi(fields[1])
+
+	if err != nil {
+		return nil, "", fmt.Errorf("malformed bytecode version: %q", fields[1])
+	}
+
+	if version != Version {
+		return nil, "", fmt.Errorf("bytecode version %d is not supported, expect version %d. Please recompile the source file", version, Version)
+	}
+
+	length, err := strconv.Atoi(fields[3])
+
+	if err != nil {
+		return nil, "", fmt.Errorf("malformed bytecode length: %q", fields[3])
+	}
+
+	if len(bytecodes) != length {
+		return nil, "", fmt.Errorf("bytecode is truncated or corrupted: expect %d bytes, got %d", length, len(bytecodes))
+	}
+
+	h := &Header{Version: version, SourceHash: fields[2], Length: length}
+
+	return h, bytecodes, nil
+}
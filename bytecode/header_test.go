@@ -0,0 +1,65 @@
+package bytecode
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHeaderRoundTrip(t *testing.T) {
+	source := `
+a = 1
+a + 2
+`
+	bytecodes := compileToBytecode(source)
+	file := AddHeader(bytecodes, source)
+
+	if !strings.HasPrefix(file, MagicNumber+" ") {
+		t.Fatalf("Expect bytecode file to start with %s. got=%q", MagicNumber, file)
+	}
+
+	h, body, err := ReadHeader(file)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	if h.Version != Version {
+		t.Fatalf("Expect header version to be %d. got=%d", Version, h.Version)
+	}
+
+	if h.SourceHash != HashSource(source) {
+		t.Fatalf("Expect header source hash to be %s. got=%s", HashSource(source), h.SourceHash)
+	}
+
+	compareBytecode(t, body, bytecodes)
+}
+
+func TestReadHeaderErrors(t *testing.T) {
+	valid := AddHeader(compileToBytecode("1 + 1"), "1 + 1")
+	truncated := valid[:len(valid)-5]
+	outdated := strings.Replace(valid, fmt.Sprintf("%s %d ", MagicNumber, Version), MagicNumber+" 0 ", 1)
+
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{"<ProgramStart>\n0 putobject 1\n1 leave", "not a Rooby bytecode file"},
+		{"", "not a Rooby bytecode file"},
+		{MagicNumber + " 1\n<ProgramStart>", "malformed bytecode header"},
+		{outdated, "bytecode version 0 is not supported"},
+		{truncated, "bytecode is truncated or corrupted"},
+	}
+
+	for _, tt := range tests {
+		_, _, err := ReadHeader(tt.file)
+
+		if err == nil {
+			t.Fatalf("Expect %q to return an error", tt.file)
+		}
+
+		if !strings.HasPrefix(err.Error(), tt.expected) {
+			t.Fatalf("Expect error to start with %q. got=%q", tt.expected, err.Error())
+		}
+	}
+}
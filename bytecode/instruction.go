@@ -3,6 +3,7 @@ package bytecode
 import (
 	"bytes"
 	"fmt"
+	"github.com/st0012/Rooby/ast"
 	"strings"
 )
 
@@ -36,10 +37,48 @@ func (l *label) compile() string {
 	return fmt.Sprintf("<%s>\n", l.Name)
 }
 
+// catchEntry marks instructions in [start, end) as protected: when one of them raises an exception,
+// VM jumps to handler with the exception pushed onto the stack.
+type catchEntry struct {
+	start   int
+	end     int
+	handler int
+}
+
+func (ce *catchEntry) compile() string {
+	return fmt.Sprintf("catch %d %d %d\n", ce.start, ce.end, ce.handler)
+}
+
+// lineEntry maps instructions from pc until next entry to a line of source file.
+type lineEntry struct {
+	pc   int
+	line int
+}
+
+func (le *lineEntry) compile() string {
+	return fmt.Sprintf("line %d %d\n", le.pc, le.line)
+}
+
 type instructionSet struct {
 	label        *label
 	Instructions []*instruction
 	Count        int
+	catchTable   []*catchEntry
+	lineTable    []*lineEntry
+	// arity is the number of parameters a block takes
+	arity int
+	// locals is the table of the instruction set's local variables
+	locals *localTable
+	// ensures are the ensure clauses around the instruction being compiled, innermost last
+	ensures []*ensureClause
+}
+
+// ensureClause is the ensure body of a begin expression and the hidden local that keeps the expression's value while it runs.
+type ensureClause struct {
+	body  *ast.BlockStatement
+	local int
+	// allocated is set once local is, which is when a return first needs it or when the ensure body is compiled
+	allocated bool
 }
 
 func (is *instructionSet) setLabel(name string) {
@@ -47,6 +86,24 @@ func (is *instructionSet) setLabel(name string) {
 	is.label = l
 }
 
+// markLine records that instructions defined from now on are compiled from the given source line.
+func (is *instructionSet) markLine(line int) {
+	if n := len(is.lineTable); n > 0 {
+		last := is.lineTable[n-1]
+
+		if last.line == line {
+			return
+		}
+
+		if last.pc == is.Count {
+			last.line = line
+			return
+		}
+	}
+
+	is.lineTable = append(is.lineTable, &lineEntry{pc: is.Count, line: line})
+}
+
 func (is *instructionSet) define(action string, params ...interface{}) {
 	ps := []string{}
 	i := &instruction{action: action, params: ps, line: is.Count}
@@ -71,9 +128,23 @@ func (is *instructionSet) define(action string, params ...interface{}) {
 	is.Count++
 }
 
-func (is *instructionSet) compile() string {
+// compile returns the bytecodes of the instruction set. The names of its local variables are only listed if withLocals
+// is true, since they're not needed to run it.
+func (is *instructionSet) compile(withLocals bool) string {
 	var out bytes.Buffer
 	out.WriteString(is.label.compile())
+	if is.arity > 0 {
+		out.WriteString(fmt.Sprintf("arity %d\n", is.arity))
+	}
+	if withLocals && is.locals != nil && is.locals.count > 0 {
+		out.WriteString(fmt.Sprintf("locals %s\n", strings.Join(is.locals.names(), " ")))
+	}
+	for _, ce := range is.catchTable {
+		out.WriteString(ce.compile())
+	}
+	for _, le := range is.lineTable {
+		out.WriteString(le.compile())
+	}
 	for _, i := range is.Instructions {
 		out.WriteString(i.compile())
 	}
@@ -20,6 +20,14 @@ func New(input string) *Lexer {
 	return l
 }
 
+// NewFromLine initializes a new lexer with input that starts at the given line of a larger source,
+// so tokens' lines are counted from that line
+func NewFromLine(input string, line int) *Lexer {
+	l := New(input)
+	l.line = line
+	return l
+}
+
 // NextToken makes lexer tokenize next character(s)
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
@@ -32,11 +40,24 @@ func (l *Lexer) NextToken() token.Token {
 		tok.Type = token.STRING
 		tok.Line = l.line
 		return tok
+	case '`':
+		tok.Literal = l.readString(l.ch)
+		tok.Type = token.COMMAND
+		tok.Line = l.line
+		return tok
 	case '=':
 		if l.peekChar() == '=' {
 			currentByte := l.ch
 			l.readChar()
 			tok = token.Token{Type: token.EQ, Literal: string(currentByte) + string(l.ch), Line: l.line}
+		} else if l.peekChar() == '>' {
+			currentByte := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.ARROW, Literal: string(currentByte) + string(l.ch), Line: l.line}
+		} else if l.peekChar() == '~' {
+			currentByte := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.MATCH, Literal: string(currentByte) + string(l.ch), Line: l.line}
 		} else {
 			tok = newToken(token.ASSIGN, l.ch, l.line)
 		}
@@ -60,12 +81,40 @@ func (l *Lexer) NextToken() token.Token {
 		}
 	case '/':
 		tok = newToken(token.SLASH, l.ch, l.line)
+	case '%':
+		tok = newToken(token.PERCENT, l.ch, l.line)
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch, l.line)
+		if l.peekChar() == '*' {
+			currentByte := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.POW, Literal: string(currentByte) + string(l.ch), Line: l.line}
+		} else {
+			tok = newToken(token.ASTERISK, l.ch, l.line)
+		}
 	case '<':
-		tok = newToken(token.LT, l.ch, l.line)
+		if l.peekChar() == '<' {
+			currentByte := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.LSHIFT, Literal: string(currentByte) + string(l.ch), Line: l.line}
+		} else if l.peekChar() == '=' {
+			currentByte := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.LTE, Literal: string(currentByte) + string(l.ch), Line: l.line}
+		} else {
+			tok = newToken(token.LT, l.ch, l.line)
+		}
 	case '>':
-		tok = newToken(token.GT, l.ch, l.line)
+		if l.peekChar() == '>' {
+			currentByte := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.RSHIFT, Literal: string(currentByte) + string(l.ch), Line: l.line}
+		} else if l.peekChar() == '=' {
+			currentByte := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.GTE, Literal: string(currentByte) + string(l.ch), Line: l.line}
+		} else {
+			tok = newToken(token.GT, l.ch, l.line)
+		}
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch, l.line)
 	case '(':
@@ -93,11 +142,28 @@ func (l *Lexer) NextToken() token.Token {
 	case ']':
 		tok = newToken(token.RBRACKET, l.ch, l.line)
 	case '.':
-		tok = newToken(token.DOT, l.ch, l.line)
+		if l.peekChar() == '.' {
+			l.readChar()
+
+			if l.peekChar() == '.' {
+				l.readChar()
+				tok = token.Token{Type: token.EXCLUSIVE_RANGE, Literal: "...", Line: l.line}
+			} else {
+				tok = token.Token{Type: token.RANGE, Literal: "..", Line: l.line}
+			}
+		} else {
+			tok = newToken(token.DOT, l.ch, l.line)
+		}
 	case ':':
 		tok = newToken(token.COLON, l.ch, l.line)
 	case '|':
 		tok = newToken(token.BAR, l.ch, l.line)
+	case '&':
+		tok = newToken(token.AMPERSAND, l.ch, l.line)
+	case '^':
+		tok = newToken(token.CARET, l.ch, l.line)
+	case '~':
+		tok = newToken(token.TILDE, l.ch, l.line)
 	case '#':
 		tok.Literal = l.absorbComment()
 		tok.Type = token.COMMENT
@@ -129,9 +195,19 @@ func (l *Lexer) NextToken() token.Token {
 			}
 
 			return newToken(token.ILLEGAL, l.ch, l.line)
+		} else if isGlobalVariable(l.ch) {
+			if isLetter(l.peekChar()) || isDigit(l.peekChar()) || l.peekChar() == '?' {
+				tok.Literal = l.readGlobalVariable()
+				tok.Type = token.GLOBAL_VARIABLE
+				tok.Line = l.line
+				return tok
+			}
+
+			tok = newToken(token.ILLEGAL, l.ch, l.line)
+			l.readChar()
+			return tok
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readNumber()
-			tok.Type = token.INT
+			tok.Literal, tok.Type = l.readNumber()
 			tok.Line = l.line
 			return tok
 		}
@@ -152,12 +228,31 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
-func (l *Lexer) readNumber() string {
+func (l *Lexer) readNumber() (string, token.TokenType) {
 	position := l.position
+	tokenType := token.TokenType(token.INT)
+
 	for isDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+
+	// a dot followed by a digit is a decimal point, otherwise it's a method call like 1.to_s
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
+		l.readChar()
+
+		for isDigit(l.ch) {
+			l.readChar()
+		}
+	}
+
+	// an r suffix makes a rational like 3r or 1.5r, unless it starts a name like in 3rd
+	if l.ch == 'r' && !isLetter(l.peekChar()) && !isDigit(l.peekChar()) {
+		tokenType = token.RATIONAL
+		l.readChar()
+	}
+
+	return l.input[position:l.position], tokenType
 }
 
 func (l *Lexer) readIdentifier() string {
@@ -165,13 +260,24 @@ func (l *Lexer) readIdentifier() string {
 	for isLetter(l.ch) || isDigit(l.ch) {
 		l.readChar()
 	}
+	// predicate methods like `locked?` end with a question mark, and methods like `map!` that change their
+	// receiver end with a bang, unless it's the start of `!=`
+	if l.ch == '?' || (l.ch == '!' && l.peekChar() != '=') {
+		l.readChar()
+	}
 	return l.input[position:l.position]
 }
 
+// readConstant reads a constant, scoped constants like `Net::HTTP` are read as one constant.
 func (l *Lexer) readConstant() string {
 	position := l.position
 	for isLetter(l.ch) || isDigit(l.ch) {
 		l.readChar()
+
+		if l.ch == ':' && l.peekChar() == ':' && l.readPosition+1 < len(l.input) && isUppercase(l.input[l.readPosition+1]) {
+			l.readChar()
+			l.readChar()
+		}
 	}
 	return l.input[position:l.position]
 }
@@ -184,6 +290,22 @@ func (l *Lexer) readInstanceVariable() string {
 	return l.input[position:l.position]
 }
 
+func (l *Lexer) readGlobalVariable() string {
+	position := l.position
+	l.readChar() // $
+
+	// `$?` is the only global variable named by a symbol
+	if l.ch == '?' {
+		l.readChar()
+		return l.input[position:l.position]
+	}
+
+	for isLetter(l.ch) || isDigit(l.ch) {
+		l.readChar()
+	}
+	return l.input[position:l.position]
+}
+
 func (l *Lexer) readString(ch byte) string {
 	l.readChar()
 	position := l.position // currently at string's first letter
@@ -235,10 +357,18 @@ func isLetter(ch byte) bool {
 	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
 }
 
+func isUppercase(ch byte) bool {
+	return 'A' <= ch && ch <= 'Z'
+}
+
 func isInstanceVariable(ch byte) bool {
 	return ch == '@'
 }
 
+func isGlobalVariable(ch byte) bool {
+	return ch == '$'
+}
+
 func newToken(tokenType token.TokenType, ch byte, line int) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch), Line: line}
 }
@@ -245,3 +245,345 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestExceptionTokens(t *testing.T) {
+	input := `
+	begin
+	  raise(Foo)
+	rescue Foo => e
+	ensure
+	end
+	`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.BEGIN, "begin"},
+		{token.IDENT, "raise"},
+		{token.LPAREN, "("},
+		{token.CONSTANT, "Foo"},
+		{token.RPAREN, ")"},
+		{token.RESCUE, "rescue"},
+		{token.CONSTANT, "Foo"},
+		{token.ARROW, "=>"},
+		{token.IDENT, "e"},
+		{token.ENSURE, "ensure"},
+		{token.END, "end"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. exprected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. exprected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestGlobalVariableTokens(t *testing.T) {
+	input := `
+	if __FILE__ == $PROGRAM_NAME
+	  $0
+	end
+	$
+	`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IF, "if"},
+		{token.FILE, "__FILE__"},
+		{token.EQ, "=="},
+		{token.GLOBAL_VARIABLE, "$PROGRAM_NAME"},
+		{token.GLOBAL_VARIABLE, "$0"},
+		{token.END, "end"},
+		{token.ILLEGAL, "$"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. exprected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. exprected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestPredicateMethodTokens(t *testing.T) {
+	input := `m.locked?
+	empty?(a)`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "m"},
+		{token.DOT, "."},
+		{token.IDENT, "locked?"},
+		{token.IDENT, "empty?"},
+		{token.LPAREN, "("},
+		{token.IDENT, "a"},
+		{token.RPAREN, ")"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. exprected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. exprected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestBangMethodTokens(t *testing.T) {
+	input := `a.map!
+	a!=b`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.DOT, "."},
+		{token.IDENT, "map!"},
+		{token.IDENT, "a"},
+		{token.NOT_EQ, "!="},
+		{token.IDENT, "b"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. exprected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. exprected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestOperatorMethodTokens(t *testing.T) {
+	input := `def <<(v)
+	a << 1 < 2
+	def []=(k, v)`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.DEF, "def"},
+		{token.LSHIFT, "<<"},
+		{token.LPAREN, "("},
+		{token.IDENT, "v"},
+		{token.RPAREN, ")"},
+		{token.IDENT, "a"},
+		{token.LSHIFT, "<<"},
+		{token.INT, "1"},
+		{token.LT, "<"},
+		{token.INT, "2"},
+		{token.DEF, "def"},
+		{token.LBRACKET, "["},
+		{token.RBRACKET, "]"},
+		{token.ASSIGN, "="},
+		{token.LPAREN, "("},
+		{token.IDENT, "k"},
+		{token.COMMA, ","},
+		{token.IDENT, "v"},
+		{token.RPAREN, ")"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. exprected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. exprected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestBitwiseOperatorTokens(t *testing.T) {
+	input := `a & b | c ^ ~d >> 1 >= 2`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.AMPERSAND, "&"},
+		{token.IDENT, "b"},
+		{token.BAR, "|"},
+		{token.IDENT, "c"},
+		{token.CARET, "^"},
+		{token.TILDE, "~"},
+		{token.IDENT, "d"},
+		{token.RSHIFT, ">>"},
+		{token.INT, "1"},
+		{token.GTE, ">="},
+		{token.INT, "2"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. exprected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. exprected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestScopedConstantTokens(t *testing.T) {
+	input := `Net::HTTP.get(A::B::C2) Foo:: Bar`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.CONSTANT, "Net::HTTP"},
+		{token.DOT, "."},
+		{token.IDENT, "get"},
+		{token.LPAREN, "("},
+		{token.CONSTANT, "A::B::C2"},
+		{token.RPAREN, ")"},
+		{token.CONSTANT, "Foo"},
+		{token.COLON, ":"},
+		{token.COLON, ":"},
+		{token.CONSTANT, "Bar"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. exprected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. exprected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestCommandTokens(t *testing.T) {
+	input := "out = `ls -l`\nputs($?.success?)"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "out"},
+		{token.ASSIGN, "="},
+		{token.COMMAND, "ls -l"},
+		{token.IDENT, "puts"},
+		{token.LPAREN, "("},
+		{token.GLOBAL_VARIABLE, "$?"},
+		{token.DOT, "."},
+		{token.IDENT, "success?"},
+		{token.RPAREN, ")"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. exprected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. exprected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNumericTokens(t *testing.T) {
+	input := `1.5 + 10.25 ** 2 % 3
+	1.to_s
+	a >= 2.0 <= b
+	1..n 0...2
+	3r + 1.5r 3rd`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FLOAT, "1.5"},
+		{token.PLUS, "+"},
+		{token.FLOAT, "10.25"},
+		{token.POW, "**"},
+		{token.INT, "2"},
+		{token.PERCENT, "%"},
+		{token.INT, "3"},
+		{token.INT, "1"},
+		{token.DOT, "."},
+		{token.IDENT, "to_s"},
+		{token.IDENT, "a"},
+		{token.GTE, ">="},
+		{token.FLOAT, "2.0"},
+		{token.LTE, "<="},
+		{token.IDENT, "b"},
+		{token.INT, "1"},
+		{token.RANGE, ".."},
+		{token.IDENT, "n"},
+		{token.INT, "0"},
+		{token.EXCLUSIVE_RANGE, "..."},
+		{token.INT, "2"},
+		{token.RATIONAL, "3r"},
+		{token.PLUS, "+"},
+		{token.RATIONAL, "1.5r"},
+		{token.INT, "3"},
+		{token.IDENT, "rd"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. exprected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. exprected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
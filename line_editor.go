@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxHistory is how many lines the REPL history keeps, older lines are dropped when it's loaded.
+const maxHistory = 1000
+
+// errInterrupted is returned by readLine when Ctrl-C is pressed, which discards the input being typed.
+var errInterrupted = errors.New("interrupted")
+
+// lineReader reads the REPL's input one line at a time.
+type lineReader interface {
+	readLine(prompt string) (string, error)
+}
+
+// lineScanner reads plain lines, it's used when the input isn't a terminal.
+type lineScanner struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (s *lineScanner) readLine(prompt string) (string, error) {
+	fmt.Fprint(s.out, prompt)
+
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return "", err
+		}
+
+		return "", io.EOF
+	}
+
+	return s.scanner.Text(), nil
+}
+
+// history is the lines typed in the REPL. It's saved in a file as lines are added, so it's kept across sessions.
+type history struct {
+	lines []string
+	path  string
+}
+
+// historyPath returns where the REPL history is saved, which is ~/.rooby_history, or "" if there's no home directory.
+func historyPath() string {
+	home := os.Getenv("HOME")
+
+	if home == "" {
+		return ""
+	}
+
+	return filepath.Join(home, ".rooby_history")
+}
+
+// loadHistory reads the history saved in path, it's empty if path doesn't exist yet.
+func loadHistory(path string) *history {
+	h := &history{path: path}
+
+	if path == "" {
+		return h
+	}
+
+	f, err := os.Open(path)
+
+	if err != nil {
+		return h
+	}
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		h.lines = append(h.lines, scanner.Text())
+	}
+
+	f.Close()
+
+	if len(h.lines) > maxHistory {
+		h.lines = h.lines[len(h.lines)-maxHistory:]
+		h.save()
+	}
+
+	return h
+}
+
+// add appends line to the history unless it's blank or the same as the last line. Failing to save it isn't an error,
+// the REPL works without a history file.
+func (h *history) add(line string) {
+	if strings.TrimSpace(line) == "" || len(h.lines) > 0 && h.lines[len(h.lines)-1] == line {
+		return
+	}
+
+	h.lines = append(h.lines, line)
+
+	if h.path == "" {
+		return
+	}
+
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintln(f, line)
+	f.Close()
+}
+
+// save rewrites the history file with the lines kept in memory.
+func (h *history) save() {
+	f, err := os.OpenFile(h.path, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0600)
+
+	if err != nil {
+		return
+	}
+
+	for _, line := range h.lines {
+		fmt.Fprintln(f, line)
+	}
+
+	f.Close()
+}
+
+// lineEditor reads lines from a terminal in raw mode, so the cursor can be moved, earlier lines recalled with the
+// up and down arrows and words completed with tab. The terminal is only raw while a line is being typed,
+// programs that read from it get it back as it was.
+type lineEditor struct {
+	fd       uintptr
+	in       *bufio.Reader
+	out      io.Writer
+	history  *history
+	complete func(line string) (prefix string, candidates []string)
+}
+
+// editing is the state of the line being typed.
+type editing struct {
+	prompt string
+	buf    []rune
+	pos    int
+	// recalled is the index of the history line being shown, saved is what was typed before it was recalled
+	recalled int
+	saved    []rune
+}
+
+func newLineEditor(f *os.File, out io.Writer, h *history, complete func(string) (string, []string)) *lineEditor {
+	return &lineEditor{fd: f.Fd(), in: bufio.NewReader(f), out: out, history: h, complete: complete}
+}
+
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	restore, err := makeRaw(e.fd)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer restore()
+
+	l := &editing{prompt: prompt, recalled: len(e.history.lines)}
+	e.refresh(l)
+
+	for {
+		r, _, err := e.in.ReadRune()
+
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			line := string(l.buf)
+			e.history.add(line)
+
+			return line, nil
+		case 3: // Ctrl-C
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupted
+		case 4: // Ctrl-D
+			if len(l.buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+
+			l.delete(l.pos)
+		case 1: // Ctrl-A
+			l.pos = 0
+		case 5: // Ctrl-E
+			l.pos = len(l.buf)
+		case 2: // Ctrl-B
+			l.move(-1)
+		case 6: // Ctrl-F
+			l.move(1)
+		case 11: // Ctrl-K
+			l.buf = l.buf[:l.pos]
+		case 21: // Ctrl-U
+			l.buf = l.buf[l.pos:]
+			l.pos = 0
+		case 8, 127: // Backspace
+			if l.pos > 0 {
+				l.pos--
+				l.delete(l.pos)
+			}
+		case '\t':
+			e.completeWord(l)
+		case 27:
+			e.escape(l)
+		default:
+			if r >= ' ' {
+				l.insert([]rune{r})
+			}
+		}
+
+		e.refresh(l)
+	}
+}
+
+// escape handles the escape sequences of arrow, home, end and delete keys. Other sequences are ignored.
+func (e *lineEditor) escape(l *editing) {
+	if b, err := e.in.ReadByte(); err != nil || b != '[' && b != 'O' {
+		return
+	}
+
+	// a sequence is parameters like the 3 of delete's "\x1b[3~" followed by a final byte from @ to ~
+	var params []byte
+	var final byte
+
+	for {
+		b, err := e.in.ReadByte()
+
+		if err != nil {
+			return
+		}
+
+		if '@' <= b && b <= '~' {
+			final = b
+			break
+		}
+
+		params = append(params, b)
+	}
+
+	switch final {
+	case 'A':
+		e.recall(l, -1)
+	case 'B':
+		e.recall(l, 1)
+	case 'C':
+		l.move(1)
+	case 'D':
+		l.move(-1)
+	case 'H':
+		l.pos = 0
+	case 'F':
+		l.pos = len(l.buf)
+	case '~':
+		switch string(params) {
+		case "1", "7":
+			l.pos = 0
+		case "4", "8":
+			l.pos = len(l.buf)
+		case "3":
+			l.delete(l.pos)
+		}
+	}
+}
+
+// recall shows the history line step lines after the one shown now. Going past the last line shows what was typed.
+func (e *lineEditor) recall(l *editing, step int) {
+	i := l.recalled + step
+
+	if i < 0 || i > len(e.history.lines) {
+		return
+	}
+
+	if l.recalled == len(e.history.lines) {
+		l.saved = l.buf
+	}
+
+	l.recalled = i
+
+	if i == len(e.history.lines) {
+		l.buf = l.saved
+	} else {
+		l.buf = []rune(e.history.lines[i])
+	}
+
+	l.pos = len(l.buf)
+}
+
+// completeWord completes the word before the cursor. A word with one candidate is completed, a word with several is
+// completed as far as they agree and the candidates are listed if that doesn't add anything.
+func (e *lineEditor) completeWord(l *editing) {
+	before := string(l.buf[:l.pos])
+	prefix, candidates := e.complete(before)
+	word := before[len(prefix):]
+
+	if len(candidates) == 0 {
+		fmt.Fprint(e.out, "\a")
+		return
+	}
+
+	common := candidates[0]
+
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, common) {
+			common = common[:len(common)-1]
+		}
+	}
+
+	if len(common) > len(word) {
+		l.insert([]rune(common[len(word):]))
+		return
+	}
+
+	fmt.Fprint(e.out, "\r\n"+strings.Join(candidates, "  ")+"\r\n")
+}
+
+// refresh redraws the line and puts the cursor where it's being edited.
+func (e *lineEditor) refresh(l *editing) {
+	fmt.Fprint(e.out, "\r"+l.prompt+string(l.buf)+"\x1b[K")
+
+	if back := len(l.buf) - l.pos; back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+func (l *editing) insert(rs []rune) {
+	buf := append([]rune{}, l.buf[:l.pos]...)
+	buf = append(buf, rs...)
+	l.buf = append(buf, l.buf[l.pos:]...)
+	l.pos += len(rs)
+}
+
+func (l *editing) delete(i int) {
+	if i < len(l.buf) {
+		l.buf = append(l.buf[:i:i], l.buf[i+1:]...)
+	}
+}
+
+func (l *editing) move(step int) {
+	if pos := l.pos + step; pos >= 0 && pos <= len(l.buf) {
+		l.pos = pos
+	}
+}
@@ -35,6 +35,10 @@ func (p *Parser) peekTokenIs(t token.TokenType) bool {
 }
 
 func (p *Parser) peekError(t token.TokenType) {
+	if p.peekTokenIs(token.EOF) {
+		p.unexpectedEOF = true
+	}
+
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead. Line: %d", t, p.peekToken.Type, p.peekToken.Line)
 	p.errors = append(p.errors, msg)
 }
@@ -58,6 +62,10 @@ func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
+	if t == token.EOF {
+		p.unexpectedEOF = true
+	}
+
 	msg := fmt.Sprintf("no prefix function for %s. Line: %d", t, p.curToken.Line)
 	p.errors = append(p.errors, msg)
 }
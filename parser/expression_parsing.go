@@ -4,33 +4,53 @@ import (
 	"fmt"
 	"github.com/st0012/Rooby/ast"
 	"github.com/st0012/Rooby/token"
+	"math/big"
 	"strconv"
+	"strings"
 )
 
 var precedence = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.INCR:     SUM,
-	token.DECR:     SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.LBRACKET: INDEX,
-	token.DOT:      CALL,
-	token.LPAREN:   CALL,
+	token.EQ:        EQUALS,
+	token.NOT_EQ:    EQUALS,
+	token.MATCH:     EQUALS,
+	token.LT:        LESSGREATER,
+	token.GT:        LESSGREATER,
+	token.LTE:       LESSGREATER,
+	token.GTE:       LESSGREATER,
+	token.LSHIFT:    SHIFT,
+	token.RSHIFT:    SHIFT,
+	token.BAR:       BIT_OR,
+	token.CARET:     BIT_OR,
+	token.AMPERSAND: BIT_AND,
+	token.PLUS:      SUM,
+	token.MINUS:     SUM,
+	token.INCR:      SUM,
+	token.DECR:      SUM,
+	token.SLASH:     PRODUCT,
+	token.ASTERISK:  PRODUCT,
+	token.PERCENT:   PRODUCT,
+	token.POW:       POWER,
+	token.LBRACKET:  INDEX,
+	token.DOT:       CALL,
+	token.LPAREN:    CALL,
+
+	token.RANGE:           RANGE,
+	token.EXCLUSIVE_RANGE: RANGE,
 }
 
 const (
 	_ int = iota
 	LOWEST
+	RANGE
 	EQUALS
 	LESSGREATER
+	BIT_OR
+	BIT_AND
+	SHIFT
 	SUM
 	PRODUCT
 	PREFIX
+	POWER
 	INDEX
 	CALL
 )
@@ -76,10 +96,25 @@ func (p *Parser) parseInstanceVariable() ast.Expression {
 	return &ast.InstanceVariable{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseGlobalVariable() ast.Expression {
+	return &ast.GlobalVariable{Token: p.curToken, Value: p.curToken.Literal}
+}
+
+func (p *Parser) parseFileExpression() ast.Expression {
+	return &ast.FileExpression{Token: p.curToken}
+}
+
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
 	value, err := strconv.ParseInt(lit.TokenLiteral(), 0, 64)
+	if e, ok := err.(*strconv.NumError); ok && e.Err == strconv.ErrRange {
+		if n, ok := new(big.Int).SetString(lit.TokenLiteral(), 0); ok {
+			lit.Big = n
+			return lit
+		}
+	}
+
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as integer", lit.TokenLiteral())
 		p.errors = append(p.errors, msg)
@@ -91,6 +126,36 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return lit
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(lit.TokenLiteral(), 64)
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as float", lit.TokenLiteral())
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	lit.Value = value
+
+	return lit
+}
+
+func (p *Parser) parseRationalLiteral() ast.Expression {
+	lit := &ast.RationalLiteral{Token: p.curToken}
+
+	value, ok := new(big.Rat).SetString(strings.TrimSuffix(lit.TokenLiteral(), "r"))
+	if !ok {
+		msg := fmt.Sprintf("could not parse %q as rational", lit.TokenLiteral())
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	lit.Value = value
+
+	return lit
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	lit := &ast.StringLiteral{Token: p.curToken}
 	lit.Value = p.curToken.Literal
@@ -98,6 +163,14 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return lit
 }
 
+// parseCommandExpression parses `cmd` as a call to the "`" method with the command as a string, like Ruby does.
+func (p *Parser) parseCommandExpression() ast.Expression {
+	self := &ast.SelfExpression{Token: token.Token{Type: token.SELF, Literal: "self", Line: p.curToken.Line}}
+	command := &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+
+	return &ast.CallExpression{Token: p.curToken, Receiver: self, Method: "`", Arguments: []ast.Expression{command}}
+}
+
 func (p *Parser) parseBooleanLiteral() ast.Expression {
 	lit := &ast.Boolean{Token: p.curToken}
 
@@ -119,35 +192,32 @@ func (p *Parser) parsePostfixExpression(receiver ast.Expression) ast.Expression
 }
 
 func (p *Parser) parseHashExpression() ast.Expression {
-	hash := &ast.HashExpression{Token: p.curToken}
-	hash.Data = p.parseHashPairs()
+	hash := &ast.HashExpression{Token: p.curToken, Data: map[string]ast.Expression{}}
+	p.parseHashPairs(hash)
 	return hash
 }
 
-func (p *Parser) parseHashPairs() map[string]ast.Expression {
-	pairs := map[string]ast.Expression{}
-
+func (p *Parser) parseHashPairs(hash *ast.HashExpression) {
 	if p.peekTokenIs(token.RBRACE) {
 		p.nextToken() // '}'
-		return pairs
+		return
 	}
 
-	p.parseHashPair(pairs)
+	p.parseHashPair(hash)
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 
-		p.parseHashPair(pairs)
+		p.parseHashPair(hash)
 	}
 
 	if !p.expectPeek(token.RBRACE) {
-		return nil
+		hash.Data = nil
+		hash.Keys = nil
 	}
-
-	return pairs
 }
 
-func (p *Parser) parseHashPair(pairs map[string]ast.Expression) {
+func (p *Parser) parseHashPair(hash *ast.HashExpression) {
 	var key string
 	var value ast.Expression
 
@@ -163,7 +233,13 @@ func (p *Parser) parseHashPair(pairs map[string]ast.Expression) {
 
 	p.nextToken()
 	value = p.parseExpression(LOWEST)
-	pairs[key] = value
+
+	// Like Ruby, a duplicated key keeps its first position but takes the last value
+	if _, ok := hash.Data[key]; !ok {
+		hash.Keys = append(hash.Keys, key)
+	}
+
+	hash.Data[key] = value
 }
 
 func (p *Parser) parseArrayExpression() ast.Expression {
@@ -183,6 +259,13 @@ func (p *Parser) parseArrayIndexExpression(left ast.Expression) ast.Expression {
 
 	callExpression.Arguments = []ast.Expression{p.parseExpression(LOWEST)}
 
+	// str[start, length]
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		callExpression.Arguments = append(callExpression.Arguments, p.parseExpression(LOWEST))
+	}
+
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
@@ -244,6 +327,12 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	}
 
 	precedence := p.curPrecedence()
+
+	// ** is right associative, 2 ** 3 ** 2 is 2 ** (3 ** 2)
+	if exp.Operator == token.POW {
+		precedence--
+	}
+
 	p.nextToken()
 	exp.Right = p.parseExpression(precedence)
 
@@ -262,6 +351,19 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 	return exp
 }
 
+func (p *Parser) parseRangeExpression(start ast.Expression) ast.Expression {
+	exp := &ast.RangeExpression{
+		Token:     p.curToken,
+		Start:     start,
+		Exclusive: p.curTokenIs(token.EXCLUSIVE_RANGE),
+	}
+
+	p.nextToken()
+	exp.End = p.parseExpression(RANGE)
+
+	return exp
+}
+
 func (p *Parser) parseIfExpression() ast.Expression {
 	ie := &ast.IfExpression{Token: p.curToken}
 	p.nextToken()
@@ -280,8 +382,15 @@ func (p *Parser) parseCallExpression(receiver ast.Expression) ast.Expression {
 	var exp *ast.CallExpression
 
 	if p.curTokenIs(token.LPAREN) { // call expression doesn't have a receiver foo(x) || foo()
-		// method name is receiver, for example 'foo' of foo(x)
-		m := receiver.(*ast.Identifier).Value
+		// method name is receiver, for example 'foo' of foo(x), or 'Integer' of Integer(x)
+		var m string
+
+		if c, ok := receiver.(*ast.Constant); ok {
+			m = c.Value
+		} else {
+			m = receiver.(*ast.Identifier).Value
+		}
+
 		// receiver is self
 		selfTok := token.Token{Type: token.SELF, Literal: "self", Line: p.curToken.Line}
 		self := &ast.SelfExpression{Token: selfTok}
@@ -334,14 +443,12 @@ func (p *Parser) parseBlockParameters(exp *ast.CallExpression) {
 		p.nextToken()
 		p.nextToken()
 
-		param := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-		params = append(params, param)
+		params = append(params, p.parseBlockParameter(exp, len(params)))
 
 		for p.peekTokenIs(token.COMMA) {
 			p.nextToken()
 			p.nextToken()
-			param := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-			params = append(params, param)
+			params = append(params, p.parseBlockParameter(exp, len(params)))
 		}
 
 		if !p.expectPeek(token.BAR) {
@@ -354,6 +461,46 @@ func (p *Parser) parseBlockParameters(exp *ast.CallExpression) {
 	exp.Block = p.parseBlockStatement()
 }
 
+// parseBlockParameter parses the block parameter at index, which is either a name or names to destructure
+// the yielded array into, like `(k, v)`.
+func (p *Parser) parseBlockParameter(exp *ast.CallExpression, index int) *ast.Identifier {
+	if !p.curTokenIs(token.LPAREN) {
+		return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	param := &ast.Identifier{Token: p.curToken}
+	var names []*ast.Identifier
+	var literals []string
+
+	for {
+		if !p.expectPeek(token.IDENT) {
+			return param
+		}
+
+		names = append(names, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+		literals = append(literals, p.curToken.Literal)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return param
+	}
+
+	if exp.DestructuredArguments == nil {
+		exp.DestructuredArguments = map[int][]*ast.Identifier{}
+	}
+
+	exp.DestructuredArguments[index] = names
+	param.Value = "(" + strings.Join(literals, ", ") + ")"
+
+	return param
+}
+
 func (p *Parser) parseCallArguments() []ast.Expression {
 	args := []ast.Expression{}
 
@@ -388,3 +535,71 @@ func (p *Parser) parseYieldExpression() ast.Expression {
 
 	return ye
 }
+
+func (p *Parser) parseSuperExpression() ast.Expression {
+	se := &ast.SuperExpression{Token: p.curToken}
+
+	if p.peekTokenIs(token.LPAREN) {
+		p.nextToken()
+		se.Arguments = p.parseCallArguments()
+		se.Explicit = true
+	}
+
+	return se
+}
+
+func (p *Parser) parseBeginExpression() ast.Expression {
+	be := &ast.BeginExpression{Token: p.curToken}
+	be.Body = p.parseBlockStatement()
+
+	// curToken is now RESCUE, ENSURE or END
+	for p.curTokenIs(token.RESCUE) {
+		rc := p.parseRescueClause()
+
+		if rc == nil {
+			return nil
+		}
+
+		be.Rescues = append(be.Rescues, rc)
+	}
+
+	if p.curTokenIs(token.ENSURE) {
+		be.Ensure = p.parseBlockStatement()
+	}
+
+	if !p.curTokenIs(token.END) {
+		msg := fmt.Sprintf("expected begin expression to be closed by end, got %s instead. Line: %d", p.curToken.Type, p.curToken.Line)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	return be
+}
+
+func (p *Parser) parseRescueClause() *ast.RescueClause {
+	rc := &ast.RescueClause{Token: p.curToken}
+
+	// rescue FooError, BarError => e
+	for p.peekTokenIs(token.CONSTANT) && p.peekTokenAtSameLine() {
+		p.nextToken()
+		rc.Exceptions = append(rc.Exceptions, &ast.Constant{Token: p.curToken, Value: p.curToken.Literal})
+
+		if p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+		}
+	}
+
+	if p.peekTokenIs(token.ARROW) {
+		p.nextToken()
+
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		rc.Variable = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	rc.Body = p.parseBlockStatement()
+
+	return rc
+}
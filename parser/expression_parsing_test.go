@@ -50,6 +50,35 @@ func TestHashExpression(t *testing.T) {
 	}
 }
 
+func TestHashExpressionKeyOrder(t *testing.T) {
+	input := `{ foo: 1, bar: 2, baz: 3, bar: 4 }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash := stmt.Expression.(*ast.HashExpression)
+	expected := []string{"foo", "bar", "baz"}
+
+	if len(hash.Keys) != len(expected) {
+		t.Fatalf("Expect hash to have %d keys. got=%d", len(expected), len(hash.Keys))
+	}
+
+	for i, key := range expected {
+		if hash.Keys[i] != key {
+			t.Fatalf("Expect key %d to be %s. got=%s", i, key, hash.Keys[i])
+		}
+	}
+
+	testIntegerLiteral(t, hash.Data["bar"], 4)
+
+	if hash.String() != "{ foo: 1, bar: 4, baz: 3 }" {
+		t.Fatalf("Expect hash to be printed in key order. got=%s", hash.String())
+	}
+}
+
 func TestHashAccessExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -166,6 +195,38 @@ func TestArrayIndexExpression(t *testing.T) {
 	}
 }
 
+func TestIndexExpressionWithManyArguments(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedMethod string
+		expectedArgc   int
+	}{
+		{`str[1, 2]`, "[]", 2},
+		{`str[1, 2] = "a"`, "[]=", 3},
+		{`str[1..2] = "a"`, "[]=", 2},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		call, ok := stmt.Expression.(*ast.CallExpression)
+
+		if !ok {
+			t.Fatalf("expect expression to be CallExpression. got=%T", stmt.Expression)
+		}
+
+		testMethodName(t, call, tt.expectedMethod)
+
+		if len(call.Arguments) != tt.expectedArgc {
+			t.Fatalf("expect %d arguments. got=%d", tt.expectedArgc, len(call.Arguments))
+		}
+	}
+}
+
 func TestIdentifierExpression(t *testing.T) {
 	input := `foobar;`
 
@@ -231,6 +292,75 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	testIntegerLiteral(t, literal, 5)
 }
 
+func TestBigIntegerLiteralExpression(t *testing.T) {
+	input := `123456789012345678901234567890;`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+	if !ok {
+		t.Fatalf("expression is not *ast.IntegerLiteral. got=%T", stmt.Expression)
+	}
+
+	if literal.Big == nil || literal.Big.String() != "123456789012345678901234567890" {
+		t.Fatalf("literal.Big is not 123456789012345678901234567890. got=%v", literal.Big)
+	}
+}
+
+func TestRationalLiteralExpression(t *testing.T) {
+	input := `1.5r;`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.RationalLiteral)
+	if !ok {
+		t.Fatalf("expression is not *ast.RationalLiteral. got=%T", stmt.Expression)
+	}
+
+	if literal.Value.String() != "3/2" {
+		t.Fatalf("literal.Value is not 3/2. got=%s", literal.Value.String())
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	input := `12.25;`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has wrong number of statements. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("first program statement is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("expression is not ast.FloatLiteral. got=%T", stmt.Expression)
+	}
+
+	if literal.Value != 12.25 {
+		t.Errorf("literal.Value not %g. got=%g", 12.25, literal.Value)
+	}
+
+	if literal.TokenLiteral() != "12.25" {
+		t.Errorf("literal.TokenLiteral not %s. got=%s", "12.25", literal.TokenLiteral())
+	}
+}
+
 func TestStringLiteralExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -352,6 +482,7 @@ func TestParsingInfixExpression(t *testing.T) {
 	}{
 		{"4 + 1;", 4, "+", 1},
 		{"3 - 2;", 3, "-", 2},
+		{"3 << 2;", 3, "<<", 2},
 	}
 
 	for _, tt := range infixTests {
@@ -522,6 +653,54 @@ func TestSelfCallExpression(t *testing.T) {
 	testInfixExpression(t, callExpression.Arguments[2], 4, "+", 5)
 }
 
+func TestCommandExpression(t *testing.T) {
+	input := "`ls -l`"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	callExpression := stmt.Expression.(*ast.CallExpression)
+
+	if _, ok := callExpression.Receiver.(*ast.SelfExpression); !ok {
+		t.Fatalf("expect receiver to be SelfExpression. got=%T", callExpression.Receiver)
+	}
+
+	testMethodName(t, callExpression, "`")
+
+	if len(callExpression.Arguments) != 1 {
+		t.Fatalf("expect %d arguments. got=%d", 1, len(callExpression.Arguments))
+	}
+
+	testStringLiteral(t, callExpression.Arguments[0], "ls -l")
+}
+
+func TestCapitalizedMethodCallExpression(t *testing.T) {
+	input := `
+		Integer("10");
+	`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	callExpression := stmt.Expression.(*ast.CallExpression)
+
+	if _, ok := callExpression.Receiver.(*ast.SelfExpression); !ok {
+		t.Fatalf("expect receiver to be SelfExpression. got=%T", callExpression.Receiver)
+	}
+
+	testMethodName(t, callExpression, "Integer")
+
+	if len(callExpression.Arguments) != 1 {
+		t.Fatalf("expect %d arguments. got=%d", 1, len(callExpression.Arguments))
+	}
+}
+
 func TestCallExpressionWithBlock(t *testing.T) {
 	input := `
 	[1, 2, 3, 4].each do |i|
@@ -548,3 +727,132 @@ func TestCallExpressionWithBlock(t *testing.T) {
 	exp := block.Statements[0].(*ast.ExpressionStatement).Expression
 	testMethodName(t, exp, "puts")
 }
+
+func TestCallExpressionWithDestructuredBlockArguments(t *testing.T) {
+	input := `
+	[[1, 2]].each_with_index do |(k, v), i|
+	  puts(k)
+	end
+	`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	callExpression := stmt.Expression.(*ast.CallExpression)
+
+	if len(callExpression.BlockArguments) != 2 {
+		t.Fatalf("Expect 2 block arguments. got=%d", len(callExpression.BlockArguments))
+	}
+
+	if callExpression.BlockArguments[0].Value != "(k, v)" {
+		t.Fatalf("Expect first block argument to be (k, v). got=%s", callExpression.BlockArguments[0].Value)
+	}
+
+	testIdentifier(t, callExpression.BlockArguments[1], "i")
+
+	names := callExpression.DestructuredArguments[0]
+
+	if len(names) != 2 {
+		t.Fatalf("Expect first block argument to be destructured into 2 names. got=%d", len(names))
+	}
+
+	testIdentifier(t, names[0], "k")
+	testIdentifier(t, names[1], "v")
+
+	if _, ok := callExpression.DestructuredArguments[1]; ok {
+		t.Fatalf("Expect second block argument not to be destructured")
+	}
+}
+
+func TestBeginExpression(t *testing.T) {
+	input := `
+	begin
+	  foo
+	rescue TypeError, RuntimeError => e
+	  x + 1
+	rescue
+	  2
+	ensure
+	  bar
+	end
+	`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("expect program's statements to be 1. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+
+	if !ok {
+		t.Fatalf("expect program.Statements[0] to be *ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	exp, ok := stmt.Expression.(*ast.BeginExpression)
+
+	if !ok {
+		t.Fatalf("expect statement to be a BeginExpression. got=%T", stmt.Expression)
+	}
+
+	if len(exp.Body.Statements) != 1 {
+		t.Fatalf("expect begin body to have 1 statement. got=%d", len(exp.Body.Statements))
+	}
+
+	testIdentifier(t, exp.Body.Statements[0].(*ast.ExpressionStatement).Expression, "foo")
+
+	if len(exp.Rescues) != 2 {
+		t.Fatalf("expect 2 rescue clauses. got=%d", len(exp.Rescues))
+	}
+
+	first := exp.Rescues[0]
+
+	if len(first.Exceptions) != 2 {
+		t.Fatalf("expect first rescue clause to have 2 exception classes. got=%d", len(first.Exceptions))
+	}
+
+	testConstant(t, first.Exceptions[0], "TypeError")
+	testConstant(t, first.Exceptions[1], "RuntimeError")
+
+	if first.Variable == nil || first.Variable.Value != "e" {
+		t.Fatalf("expect first rescue clause to assign exception to e. got=%v", first.Variable)
+	}
+
+	testInfixExpression(t, first.Body.Statements[0].(*ast.ExpressionStatement).Expression, "x", "+", 1)
+
+	second := exp.Rescues[1]
+
+	if len(second.Exceptions) != 0 || second.Variable != nil {
+		t.Fatalf("expect second rescue clause to be a bare rescue. got=%s", second.String())
+	}
+
+	testIntegerLiteral(t, second.Body.Statements[0].(*ast.ExpressionStatement).Expression, 2)
+
+	if exp.Ensure == nil {
+		t.Fatal("expect begin expression to have ensure body")
+	}
+
+	testIdentifier(t, exp.Ensure.Statements[0].(*ast.ExpressionStatement).Expression, "bar")
+}
+
+func TestBeginExpressionWithoutEnd(t *testing.T) {
+	input := `
+	begin
+	  foo
+	rescue
+	  1
+	`
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	if len(p.Errors()) == 0 {
+		t.Fatal("expect parser to report unclosed begin expression")
+	}
+}
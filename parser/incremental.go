@@ -0,0 +1,201 @@
+package parser
+
+import (
+	"github.com/st0012/Rooby/ast"
+	"github.com/st0012/Rooby/lexer"
+	"github.com/st0012/Rooby/token"
+	"reflect"
+	"strings"
+)
+
+// Position is a zero-based line and column in source. Column is a byte offset in the line.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// Edit replaces source from Start up to, but not including, End with Text.
+type Edit struct {
+	Start Position
+	End   Position
+	Text  string
+}
+
+// Reparse applies edit to source, which program is parsed from, and returns the edited source.
+// Instead of parsing the whole source again, only top level statements the edit touches are reparsed,
+// and they're patched into program in place. Statements after them get their lines shifted.
+// Syntax errors of the new program can be found in its ast.ErrorStatement nodes.
+func Reparse(program *ast.Program, source string, edit Edit) (*ast.Program, string) {
+	newSource := applyEdit(source, edit)
+	delta := strings.Count(edit.Text, "\n") - (edit.End.Line - edit.Start.Line)
+	lastLine := strings.Count(source, "\n")
+	stmts := program.Statements
+
+	// Statements overlapping [first, last) are affected. A statement spans lines until next one starts.
+	first, last := 0, len(stmts)
+
+	for i, stmt := range stmts {
+		if stmt.Line() <= edit.Start.Line {
+			first = i
+		}
+
+		if stmt.Line() > edit.End.Line {
+			last = i
+			break
+		}
+	}
+
+	startLine := 0
+
+	if first < len(stmts) && stmts[first].Line() <= edit.Start.Line {
+		// Lines are reparsed as a whole, so they include every statement that starts on the line
+		for first > 0 && stmts[first-1].Line() == stmts[first].Line() {
+			first--
+		}
+
+		startLine = stmts[first].Line()
+	} else {
+		first = 0
+	}
+
+	// Error messages contain line numbers that can't be shifted, so statements with errors are reparsed too.
+	if delta != 0 && containsError(stmts[last:]) {
+		last = len(stmts)
+	}
+
+	endLine := lastLine + delta
+
+	if last < len(stmts) {
+		endLine = stmts[last].Line() + delta - 1
+	}
+
+	reparsed, incomplete := parseLines(newSource, startLine, endLine)
+
+	// Reparsed statements may continue after the lines, like when `end` is removed. Then we reparse until the end.
+	if last < len(stmts) && incomplete {
+		last = len(stmts)
+		reparsed, _ = parseLines(newSource, startLine, lastLine+delta)
+	}
+
+	rest := stmts[last:]
+
+	if delta != 0 {
+		for _, stmt := range rest {
+			shiftLines(reflect.ValueOf(stmt), delta)
+		}
+	}
+
+	program.Statements = append(append(stmts[:first:first], reparsed...), rest...)
+
+	return program, newSource
+}
+
+func applyEdit(source string, edit Edit) string {
+	return source[:offsetOf(source, edit.Start)] + edit.Text + source[offsetOf(source, edit.End):]
+}
+
+func offsetOf(source string, pos Position) int {
+	offset := 0
+
+	for line := 0; line < pos.Line; line++ {
+		i := strings.Index(source[offset:], "\n")
+
+		if i < 0 {
+			return len(source)
+		}
+
+		offset += i + 1
+	}
+
+	if offset+pos.Column > len(source) {
+		return len(source)
+	}
+
+	return offset + pos.Column
+}
+
+// parseLines parses lines from start to end (inclusive) of source.
+// It also reports whether the lines end in the middle of a statement.
+func parseLines(source string, start, end int) ([]ast.Statement, bool) {
+	lines := strings.Split(source, "\n")
+
+	if end >= len(lines) {
+		end = len(lines) - 1
+	}
+
+	if start > end {
+		return []ast.Statement{}, false
+	}
+
+	l := lexer.NewFromLine(strings.Join(lines[start:end+1], "\n"), start)
+	p := New(l)
+	stmts := p.ParseProgram().Statements
+
+	return stmts, p.unexpectedEOF
+}
+
+func containsError(stmts []ast.Statement) bool {
+	found := false
+
+	for _, stmt := range stmts {
+		walkNodes(reflect.ValueOf(stmt), func(v reflect.Value) {
+			if _, ok := v.Interface().(*ast.ErrorStatement); ok {
+				found = true
+			}
+		})
+	}
+
+	return found
+}
+
+var tokenType = reflect.TypeOf(token.Token{})
+
+// shiftLines moves every token in the node by delta lines.
+func shiftLines(node reflect.Value, delta int) {
+	walkNodes(node, func(v reflect.Value) {
+		s := v.Elem()
+
+		for i := 0; i < s.NumField(); i++ {
+			if f := s.Field(i); f.Type() == tokenType {
+				tok := f.Addr().Interface().(*token.Token)
+				tok.Line += delta
+			}
+		}
+
+		if es, ok := v.Interface().(*ast.ErrorStatement); ok {
+			es.EndLine += delta
+		}
+	})
+}
+
+// walkNodes calls fn with every AST node, which is a pointer to struct, reachable from v.
+func walkNodes(v reflect.Value, fn func(reflect.Value)) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			walkNodes(v.Elem(), fn)
+		}
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return
+		}
+
+		fn(v)
+
+		s := v.Elem()
+
+		for i := 0; i < s.NumField(); i++ {
+			if s.Type().Field(i).PkgPath == "" {
+				walkNodes(s.Field(i), fn)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walkNodes(v.Index(i), fn)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			walkNodes(v.MapIndex(key), fn)
+		}
+	}
+}
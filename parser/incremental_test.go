@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"github.com/st0012/Rooby/ast"
+	"github.com/st0012/Rooby/lexer"
+	"reflect"
+	"testing"
+)
+
+func TestReparse(t *testing.T) {
+	source := `a = 1
+b = 2
+def foo
+  x = 10
+  x
+end
+c = 3
+`
+
+	tests := []struct {
+		source   string
+		edit     Edit
+		expected string
+		// indexes of statements in the new program that should be reused from the old one
+		reused []int
+	}{
+		// change a value in place
+		{"", Edit{Start: Position{1, 4}, End: Position{1, 5}, Text: "20"}, "a = 1\nb = 20\ndef foo\n  x = 10\n  x\nend\nc = 3\n", []int{0, 2, 3}},
+		// insert a new statement
+		{"", Edit{Start: Position{1, 0}, End: Position{1, 0}, Text: "d = 4\n"}, "a = 1\nd = 4\nb = 2\ndef foo\n  x = 10\n  x\nend\nc = 3\n", []int{0, 4}},
+		// edit inside a method body
+		{"", Edit{Start: Position{4, 2}, End: Position{4, 3}, Text: "x + 1"}, "a = 1\nb = 2\ndef foo\n  x = 10\n  x + 1\nend\nc = 3\n", []int{0, 1, 3}},
+		// remove lines
+		{"", Edit{Start: Position{0, 0}, End: Position{2, 0}, Text: ""}, "def foo\n  x = 10\n  x\nend\nc = 3\n", []int{1}},
+		// break a statement
+		{"", Edit{Start: Position{1, 4}, End: Position{1, 5}, Text: ")"}, "a = 1\nb = )\ndef foo\n  x = 10\n  x\nend\nc = 3\n", []int{0, 2, 3}},
+		// remove `end`, so statements after it are reparsed
+		{"", Edit{Start: Position{5, 0}, End: Position{6, 0}, Text: ""}, "a = 1\nb = 2\ndef foo\n  x = 10\n  x\nc = 3\n", []int{0, 1}},
+		// statements on the same line are reparsed together
+		{"a = 1; b = 2\nc = 3\n", Edit{Start: Position{0, 11}, End: Position{0, 12}, Text: "5"}, "a = 1; b = 5\nc = 3\n", []int{2}},
+	}
+
+	for i, tt := range tests {
+		if tt.source == "" {
+			tt.source = source
+		}
+
+		source := tt.source
+		program := parseSource(source)
+		old := append([]ast.Statement{}, program.Statements...)
+
+		program, newSource := Reparse(program, source, tt.edit)
+
+		if newSource != tt.expected {
+			t.Fatalf("tests[%d] - expect edited source to be %q. got=%q", i, tt.expected, newSource)
+		}
+
+		testSameProgram(t, i, program, parseSource(newSource))
+
+		for _, index := range tt.reused {
+			if !containsStatement(old, program.Statements[index]) {
+				t.Fatalf("tests[%d] - expect statement %d to be reused", i, index)
+			}
+		}
+	}
+}
+
+func TestReparseErrorStatementLines(t *testing.T) {
+	source := "a = 1\nb = 2\nc = )\n"
+	program := parseSource(source)
+
+	// Adding a line before an error reparses it, so its message has the right line
+	program, newSource := Reparse(program, source, Edit{Start: Position{0, 0}, End: Position{0, 0}, Text: "z = 0\n"})
+	testSameProgram(t, 0, program, parseSource(newSource))
+
+	es, ok := program.Statements[3].(*ast.ErrorStatement)
+	if !ok {
+		t.Fatalf("Expect last statement to be an ErrorStatement. got=%T", program.Statements[3])
+	}
+
+	if es.Line() != 3 || es.Errors[0] != "no prefix function for ). Line: 3" {
+		t.Fatalf("Expect error statement at line 3. got=%d: %q", es.Line(), es.Errors)
+	}
+}
+
+func parseSource(source string) *ast.Program {
+	p := New(lexer.New(source))
+	return p.ParseProgram()
+}
+
+// testSameProgram compares an incrementally reparsed program with the one parsed from scratch
+func testSameProgram(t *testing.T, i int, program, expected *ast.Program) {
+	if len(program.Statements) != len(expected.Statements) {
+		t.Fatalf("tests[%d] - expect %d statements. got=%d", i, len(expected.Statements), len(program.Statements))
+	}
+
+	for j, stmt := range expected.Statements {
+		if !reflect.DeepEqual(program.Statements[j], stmt) {
+			t.Fatalf("tests[%d] - statement %d is different from the parsed one. expect=%s (line %d), got=%s (line %d)",
+				i, j, stmt.String(), stmt.Line(), program.Statements[j].String(), program.Statements[j].Line())
+		}
+	}
+}
+
+func containsStatement(stmts []ast.Statement, stmt ast.Statement) bool {
+	for _, s := range stmts {
+		if s == stmt {
+			return true
+		}
+	}
+
+	return false
+}
@@ -11,6 +11,10 @@ import (
 type Parser struct {
 	l      *lexer.Lexer
 	errors []string
+	// recovered counts errors that are already covered by an ast.ErrorStatement
+	recovered int
+	// unexpectedEOF is set when input ends in the middle of a statement
+	unexpectedEOF bool
 
 	curToken  token.Token
 	peekToken token.Token
@@ -33,12 +37,18 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.CONSTANT, p.parseConstant)
 	p.registerPrefix(token.INSTANCE_VARIABLE, p.parseInstanceVariable)
+	p.registerPrefix(token.GLOBAL_VARIABLE, p.parseGlobalVariable)
+	p.registerPrefix(token.FILE, p.parseFileExpression)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.RATIONAL, p.parseRationalLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.COMMAND, p.parseCommandExpression)
 	p.registerPrefix(token.TRUE, p.parseBooleanLiteral)
 	p.registerPrefix(token.FALSE, p.parseBooleanLiteral)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
+	p.registerPrefix(token.TILDE, p.parsePrefixExpression)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.SELF, p.parseSelfExpression)
@@ -46,6 +56,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.LBRACE, p.parseHashExpression)
 	p.registerPrefix(token.SEMICOLON, p.parseSemicolon)
 	p.registerPrefix(token.YIELD, p.parseYieldExpression)
+	p.registerPrefix(token.SUPER, p.parseSuperExpression)
+	p.registerPrefix(token.BEGIN, p.parseBeginExpression)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -53,10 +65,22 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.POW, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.MATCH, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LTE, p.parseInfixExpression)
+	p.registerInfix(token.GTE, p.parseInfixExpression)
+	p.registerInfix(token.LSHIFT, p.parseInfixExpression)
+	p.registerInfix(token.RSHIFT, p.parseInfixExpression)
+	p.registerInfix(token.AMPERSAND, p.parseInfixExpression)
+	p.registerInfix(token.BAR, p.parseInfixExpression)
+	p.registerInfix(token.CARET, p.parseInfixExpression)
 	p.registerInfix(token.DOT, p.parseCallExpression)
+	p.registerInfix(token.RANGE, p.parseRangeExpression)
+	p.registerInfix(token.EXCLUSIVE_RANGE, p.parseRangeExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseArrayIndexExpression)
 	p.registerInfix(token.INCR, p.parsePostfixExpression)
@@ -70,7 +94,7 @@ func (p *Parser) ParseProgram() *ast.Program {
 	program.Statements = []ast.Statement{}
 
 	for !p.curTokenIs(token.EOF) {
-		stmt := p.parseStatement()
+		stmt := p.parseStatementOrError()
 		if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
@@ -80,6 +104,43 @@ func (p *Parser) ParseProgram() *ast.Program {
 	return program
 }
 
+// parseStatementOrError parses a statement like parseStatement does. If the statement has errors,
+// it's replaced by an ast.ErrorStatement that also covers the rest of its line, and parsing goes on from next line.
+func (p *Parser) parseStatementOrError() ast.Statement {
+	start := p.curToken
+	errCount := len(p.errors)
+	recovered := p.recovered
+	stmt := p.tryParseStatement()
+
+	// Errors inside nested blocks that are already recovered don't make this statement an error
+	if len(p.errors)-errCount == p.recovered-recovered {
+		return stmt
+	}
+
+	for !p.peekTokenIs(token.EOF) && p.peekToken.Line == p.curToken.Line {
+		p.nextToken()
+	}
+
+	p.recovered = recovered + len(p.errors) - errCount
+	errors := append([]string{}, p.errors[errCount:]...)
+
+	return &ast.ErrorStatement{Token: start, EndLine: p.curToken.Line, Errors: errors}
+}
+
+// tryParseStatement is parseStatement, but input that makes the parser panic, like `1(2)` which isn't a method call,
+// is reported as an error of the statement instead.
+func (p *Parser) tryParseStatement() (stmt ast.Statement) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg := fmt.Sprintf("unexpected %s. Line: %d", p.curToken.Literal, p.curToken.Line)
+			p.errors = append(p.errors, msg)
+			stmt = nil
+		}
+	}()
+
+	return p.parseStatement()
+}
+
 func (p *Parser) parseSemicolon() ast.Expression {
 	return nil
 }
@@ -88,11 +149,17 @@ func (p *Parser) Errors() []string {
 	return p.errors
 }
 
+// Incomplete reports whether the input ends in the middle of a statement, like a def without its end.
+// Interactive hosts use it to read more lines instead of reporting the errors.
+func (p *Parser) Incomplete() bool {
+	return p.unexpectedEOF
+}
+
 func (p *Parser) CheckErrors() {
 	errors := p.Errors()
 	if len(errors) == 0 {
 		return
 	}
 
-	panic(fmt.Sprintf(strings.Join(errors, "\n")))
+	panic(strings.Join(errors, "\n"))
 }
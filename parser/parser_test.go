@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/st0012/Rooby/ast"
 	"github.com/st0012/Rooby/lexer"
+	"strings"
 	"testing"
 )
 
@@ -96,6 +97,30 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"3 + 4 * 5 == 3 * 1 + 4 * 5",
 			"((3 + (4 * 5)) == ((3 * 1) + (4 * 5)))",
 		},
+		{
+			"a + b % c * d",
+			"(a + ((b % c) * d))",
+		},
+		{
+			"-a ** b ** c * d",
+			"((-(a ** (b ** c))) * d)",
+		},
+		{
+			"a >= b == c <= 1.5",
+			"((a >= b) == (c <= 1.5))",
+		},
+		{
+			"a + 1 =~ b",
+			"((a + 1) =~ b)",
+		},
+		{
+			"1..n + 1",
+			"(1..(n + 1))",
+		},
+		{
+			"(0...a * 2).size",
+			"(0...(a * 2)).size()",
+		},
 		{
 			"true",
 			"true",
@@ -132,6 +157,22 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"-(5 + 5)",
 			"(-(5 + 5))",
 		},
+		{
+			"a | b & c ^ d",
+			"((a | (b & c)) ^ d)",
+		},
+		{
+			"a & b << 1 + c",
+			"(a & (b << (1 + c)))",
+		},
+		{
+			"a >> 2 == b | c",
+			"((a >> 2) == (b | c))",
+		},
+		{
+			"~a & b",
+			"((~a) & b)",
+		},
 		{
 			"!(true == true)",
 			"(!(true == true))",
@@ -349,7 +390,7 @@ func testBoolLiteral(t *testing.T, exp ast.Expression, v bool) bool {
 	}
 
 	if bo.TokenLiteral() != fmt.Sprintf("%t", v) {
-		t.Errorf("bo.TokenLiteral is not %t. got=%t", v, exp.TokenLiteral())
+		t.Errorf("bo.TokenLiteral is not %t. got=%s", v, exp.TokenLiteral())
 	}
 
 	return true
@@ -373,3 +414,131 @@ func testLiteralExpression(
 	t.Errorf("type of exp not handled. got=%T", exp)
 	return false
 }
+
+func TestPartialASTWithErrors(t *testing.T) {
+	input := `
+	a = 1
+	b = )
+	c = 3
+	def foo
+	  x = )
+	  x
+	end
+	d = [1, 2
+	e = 5
+	`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 3 {
+		t.Fatalf("Expect parser to have 3 errors. got=%d: %q", len(p.Errors()), p.Errors())
+	}
+
+	if len(program.Statements) != 6 {
+		t.Fatalf("Expect program to have 6 statements. got=%d", len(program.Statements))
+	}
+
+	testAssignStatement(t, program.Statements[0], "a", 1)
+	testErrorStatement(t, program.Statements[1], 2, 2, "no prefix function for )")
+	testAssignStatement(t, program.Statements[2], "c", 3)
+
+	def, ok := program.Statements[3].(*ast.DefStatement)
+	if !ok {
+		t.Fatalf("Expect statement to be a DefStatement. got=%T", program.Statements[3])
+	}
+
+	testErrorStatement(t, def.BlockStatement.Statements[0], 5, 5, "no prefix function for )")
+
+	if def.BlockStatement.Statements[1].String() != "x" {
+		t.Fatalf("Expect method body to be parsed after error. got=%s", def.BlockStatement.Statements[1].String())
+	}
+
+	testErrorStatement(t, program.Statements[4], 8, 8, "expected next token to be ]")
+	testAssignStatement(t, program.Statements[5], "e", 5)
+}
+
+func TestPartialASTWithPanics(t *testing.T) {
+	input := `
+	a = 1
+	1(2)
+	[[1, [2, 3]]].each do |a, (b, (c, d))| c end
+	def foo
+	  "foo"(1)
+	  x
+	end
+	e = 5
+	`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	if len(program.Statements) != 5 {
+		t.Fatalf("Expect program to have 5 statements. got=%d", len(program.Statements))
+	}
+
+	testAssignStatement(t, program.Statements[0], "a", 1)
+	testErrorStatement(t, program.Statements[1], 2, 2, "unexpected (")
+
+	// nested destructuring isn't supported, the errors it reports before the panic belong to the same statement
+	if es, ok := program.Statements[2].(*ast.ErrorStatement); !ok || es.Line() != 3 || es.Errors[len(es.Errors)-1] != "unexpected (. Line: 3" {
+		t.Fatalf("Expect statement to be an ErrorStatement of line 3. got=%s", program.Statements[2].String())
+	}
+
+	def, ok := program.Statements[3].(*ast.DefStatement)
+	if !ok {
+		t.Fatalf("Expect statement to be a DefStatement. got=%T", program.Statements[3])
+	}
+
+	testErrorStatement(t, def.BlockStatement.Statements[0], 5, 5, "unexpected (")
+
+	if def.BlockStatement.Statements[1].String() != "x" {
+		t.Fatalf("Expect method body to be parsed after error. got=%s", def.BlockStatement.Statements[1].String())
+	}
+
+	testAssignStatement(t, program.Statements[4], "e", 5)
+}
+
+func testErrorStatement(t *testing.T, s ast.Statement, line, endLine int, err string) {
+	es, ok := s.(*ast.ErrorStatement)
+	if !ok {
+		t.Fatalf("Expect statement to be an ErrorStatement. got=%T", s)
+	}
+
+	if es.Line() != line || es.EndLine != endLine {
+		t.Fatalf("Expect error statement to cover line %d-%d. got=%d-%d", line, endLine, es.Line(), es.EndLine)
+	}
+
+	if len(es.Errors) != 1 || !strings.HasPrefix(es.Errors[0], err) {
+		t.Fatalf("Expect error statement to have error %q. got=%q", err, es.Errors)
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"def foo", true},
+		{"def foo\n  1", true},
+		{"class Foo\n  def bar\n  end", true},
+		{"[1, 2].each do |i|", true},
+		{"if x\n  1", true},
+		{"1 +", true},
+		{"def foo\nend", false},
+		{"[1, 2].each do |i|\n  i\nend", false},
+		{"1 + 2", false},
+		{"1 + )", false},
+	}
+
+	for i, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		if p.Incomplete() != tt.expected {
+			t.Fatalf("at test case %d: expect Incomplete() to be %t. got=%t", i, tt.expected, p.Incomplete())
+		}
+	}
+}
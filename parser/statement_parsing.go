@@ -1,18 +1,24 @@
 package parser
 
 import (
+	"fmt"
 	"github.com/st0012/Rooby/ast"
 	"github.com/st0012/Rooby/token"
 )
 
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
-	case token.INSTANCE_VARIABLE, token.IDENT, token.CONSTANT:
+	case token.INSTANCE_VARIABLE, token.GLOBAL_VARIABLE, token.IDENT, token.CONSTANT:
 		if p.curToken.Literal == "class" {
 			p.curToken.Type = token.CLASS
 			return p.parseStatement()
 		}
 
+		if p.curToken.Literal == "module" && p.peekTokenIs(token.CONSTANT) {
+			p.curToken.Type = token.MODULE
+			return p.parseStatement()
+		}
+
 		if p.peekTokenIs(token.ASSIGN) {
 			return p.parseAssignStatement()
 		} else {
@@ -24,6 +30,8 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseDefMethodStatement()
 	case token.CLASS:
 		return p.parseClassStatement()
+	case token.MODULE:
+		return p.parseModuleStatement()
 	case token.COMMENT:
 		return nil
 	case token.WHILE:
@@ -50,6 +58,25 @@ func (p *Parser) parseDefMethodStatement() *ast.DefStatement {
 		} else {
 			stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 		}
+	case token.CONSTANT:
+		stmt.Receiver = &ast.Constant{Token: p.curToken, Value: p.curToken.Literal}
+		if !p.expectPeek(token.DOT) {
+			return nil
+		}
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	case token.PLUS, token.MINUS, token.ASTERISK, token.SLASH, token.PERCENT, token.POW, token.EQ, token.NOT_EQ, token.MATCH,
+		token.LT, token.GT, token.LTE, token.GTE, token.LSHIFT, token.RSHIFT, token.AMPERSAND, token.BAR, token.CARET, token.TILDE:
+		// operator methods like def +(other)
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	case token.LBRACKET:
+		// def [](key), and def []=(key, value) whose = is added like other setters' below
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: "[]"}
+		if !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
 	case token.SELF:
 		stmt.Receiver = &ast.SelfExpression{Token: p.curToken}
 		p.nextToken() // .
@@ -103,6 +130,19 @@ func (p *Parser) parseClassStatement() *ast.ClassStatement {
 	return stmt
 }
 
+func (p *Parser) parseModuleStatement() *ast.ModuleStatement {
+	stmt := &ast.ModuleStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.CONSTANT) {
+		return nil
+	}
+
+	stmt.Name = &ast.Constant{Token: p.curToken, Value: p.curToken.Literal}
+	stmt.Body = p.parseBlockStatement()
+
+	return stmt
+}
+
 func (p *Parser) parseParameters() []*ast.Identifier {
 	identifiers := []*ast.Identifier{}
 
@@ -140,6 +180,8 @@ func (p *Parser) parseAssignStatement() *ast.AssignStatement {
 		stmt.Name = &ast.Constant{Token: p.curToken, Value: p.curToken.Literal}
 	case token.INSTANCE_VARIABLE:
 		stmt.Name = &ast.InstanceVariable{Token: p.curToken, Value: p.curToken.Literal}
+	case token.GLOBAL_VARIABLE:
+		stmt.Name = &ast.GlobalVariable{Token: p.curToken, Value: p.curToken.Literal}
 	}
 
 	if !p.expectPeek(token.ASSIGN) {
@@ -189,8 +231,14 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 
 	p.nextToken()
 
-	for !p.curTokenIs(token.END) && !p.curTokenIs(token.ELSE) {
-		stmt := p.parseStatement()
+	for !p.curTokenIs(token.END) && !p.curTokenIs(token.ELSE) && !p.curTokenIs(token.RESCUE) && !p.curTokenIs(token.ENSURE) {
+		if p.curTokenIs(token.EOF) {
+			p.unexpectedEOF = true
+			p.errors = append(p.errors, fmt.Sprintf("unexpected end of input, expect end. Line: %d", p.curToken.Line))
+			return bs
+		}
+
+		stmt := p.parseStatementOrError()
 		if stmt != nil {
 			bs.Statements = append(bs.Statements, stmt)
 		}
@@ -98,6 +98,37 @@ func TestInstanceVariableAssignment(t *testing.T) {
 	}
 }
 
+func TestGlobalVariableAssignment(t *testing.T) {
+	input := `
+	$foo = 5;
+	$foo;
+	`
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	// First statement
+	testAssignStatement(t, program.Statements[0], "$foo", 5)
+
+	// Second statement
+	expStmt, ok := program.Statements[1].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("expect second statement to be ExpressionStatement. got=%T", program.Statements[1])
+	}
+
+	variable, ok := expStmt.Expression.(*ast.GlobalVariable)
+	if !ok {
+		t.Fatalf("expect expression to be a global variable. got=%T", expStmt.Expression)
+	}
+
+	if variable.Value != "$foo" {
+		t.Fatalf("expect variable's name to be %s. got=%s", "$foo", variable.Value)
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		input         string
@@ -170,6 +201,46 @@ func TestClassStatement(t *testing.T) {
 	}
 }
 
+func TestModuleStatement(t *testing.T) {
+	input := `
+	module Foo
+	  def bar
+	    1
+	  end
+	end
+
+	module = 1
+	`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ModuleStatement)
+
+	if !ok {
+		t.Fatalf("expect statement to be a ModuleStatement. got=%T", program.Statements[0])
+	}
+
+	if stmt.Token.Type != token.MODULE {
+		t.Fatalf("expect token to be MODULE. got=%s", stmt.Token.Type)
+	}
+
+	testConstant(t, stmt.Name, "Foo")
+	defStmt := stmt.Body.Statements[0].(*ast.DefStatement)
+	testIdentifier(t, defStmt.Name, "bar")
+
+	// module is only a keyword before a constant
+	assign, ok := program.Statements[1].(*ast.AssignStatement)
+
+	if !ok {
+		t.Fatalf("expect statement to be an AssignStatement. got=%T", program.Statements[1])
+	}
+
+	testIdentifier(t, assign.Name.(*ast.Identifier), "module")
+}
+
 func TestClassStatementWithInheritance(t *testing.T) {
 	input := `
 	class Foo < Bar
@@ -248,6 +319,69 @@ func TestDefStatement(t *testing.T) {
 	testIntegerLiteral(t, secondExpressionStmt.Expression, 123)
 }
 
+func TestDefStatementWithReceiver(t *testing.T) {
+	input := `
+	def foo.bar
+	  1
+	end
+
+	def Foo.baz
+	  2
+	end
+	`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	firstStmt := program.Statements[0].(*ast.DefStatement)
+	testIdentifier(t, firstStmt.Receiver, "foo")
+
+	if firstStmt.Name.Value != "bar" {
+		t.Fatalf("expect method name to be 'bar'. got=%s", firstStmt.Name.Value)
+	}
+
+	secondStmt := program.Statements[1].(*ast.DefStatement)
+	testConstant(t, secondStmt.Receiver, "Foo")
+
+	if secondStmt.Name.Value != "baz" {
+		t.Fatalf("expect method name to be 'baz'. got=%s", secondStmt.Name.Value)
+	}
+}
+
+func TestOperatorDefStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		name     string
+		paramLen int
+	}{
+		{"def +(other)\nend", "+", 1},
+		{"def ==(other)\nend", "==", 1},
+		{"def <(other)\nend", "<", 1},
+		{"def <<(other)\nend", "<<", 1},
+		{"def [](key)\nend", "[]", 1},
+		{"def []=(key, value)\nend", "[]=", 2},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.DefStatement)
+
+		if stmt.Name.Value != tt.name {
+			t.Fatalf("expect method name to be %q. got=%q", tt.name, stmt.Name.Value)
+		}
+
+		if len(stmt.Parameters) != tt.paramLen {
+			t.Fatalf("expect %s to have %d parameters. got=%d", tt.name, tt.paramLen, len(stmt.Parameters))
+		}
+	}
+}
+
 func TestDefStatementWithYield(t *testing.T) {
 	input := `
 	def foo
@@ -281,6 +415,47 @@ func TestDefStatementWithYield(t *testing.T) {
 	}
 }
 
+func TestDefStatementWithSuper(t *testing.T) {
+	input := `
+	def foo(a)
+	  super(1, a)
+	  super()
+	  super
+	end
+	`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	block := program.Statements[0].(*ast.DefStatement).BlockStatement
+	tests := []struct {
+		argCount int
+		explicit bool
+		source   string
+	}{
+		{2, true, "super(1, a)"},
+		{0, true, "super()"},
+		{0, false, "super"},
+	}
+
+	for i, tt := range tests {
+		se, ok := block.Statements[i].(*ast.ExpressionStatement).Expression.(*ast.SuperExpression)
+
+		if !ok {
+			t.Fatalf("Expect statement %d to be a SuperExpression. got=%T", i, block.Statements[i])
+		}
+
+		if len(se.Arguments) != tt.argCount || se.Explicit != tt.explicit {
+			t.Fatalf("Expect statement %d to have %d arguments and Explicit=%t. got %d and %t", i, tt.argCount, tt.explicit, len(se.Arguments), se.Explicit)
+		}
+
+		if se.String() != tt.source {
+			t.Fatalf("Expect statement %d to be %q. got=%q", i, tt.source, se.String())
+		}
+	}
+}
+
 func TestWhileStatement(t *testing.T) {
 	input := `
 	while i < a.length
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/st0012/Rooby/bytecode"
+	"github.com/st0012/Rooby/lexer"
+	"github.com/st0012/Rooby/parser"
+	"github.com/st0012/Rooby/vm"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// defaultReduceInstructions is how many instructions a program can execute while it's being reduced. Removing lines
+// can turn it into one that loops forever, like by removing the line that ends a loop.
+const defaultReduceInstructions = 10000000
+
+// reduce implements `rooby reduce [-o output] file.ro`. It removes as many lines from a program that crashes the VM
+// as it can while the program still crashes with the same internal error, so bug reports come with a minimal reproduction.
+func reduce(args []string) {
+	fs := flag.NewFlagSet("reduce", flag.ExitOnError)
+	output := fs.String("o", "", "Write the reduced program to this file instead of stdout")
+	maxInstructions := fs.Int("max-instructions", defaultReduceInstructions, "Count each run of the program that executes more instructions than this as not crashing")
+	fs.Parse(args)
+
+	filepath := fs.Arg(0)
+	file, err := ioutil.ReadFile(filepath)
+	check(err)
+
+	lines := strings.Split(string(file), "\n")
+	signature, crashed := internalError(filepath, lines, *maxInstructions)
+
+	if !crashed {
+		fmt.Printf("%s doesn't crash the VM, there's nothing to reduce.\n", filepath)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "Reducing %s (%d lines), which fails with: %s\n", filepath, len(lines), signature)
+
+	reduced := reduceLines(lines, func(candidate []string) bool {
+		s, ok := internalError(filepath, candidate, *maxInstructions)
+		return ok && s == signature
+	})
+
+	fmt.Fprintf(os.Stderr, "Reduced to %d lines\n", len(reduced))
+	result := strings.Join(reduced, "\n") + "\n"
+
+	if *output == "" {
+		fmt.Print(result)
+		return
+	}
+
+	check(ioutil.WriteFile(*output, []byte(result), 0644))
+}
+
+// reduceLines is delta debugging (ddmin): it tries removing chunks of lines, and halves the chunk size whenever no chunk can
+// be removed without making failing return false. The result is 1-minimal, removing any single line of it makes it pass.
+func reduceLines(lines []string, failing func([]string) bool) []string {
+	n := 2
+
+	for len(lines) >= 2 {
+		size := (len(lines) + n - 1) / n
+		reduced := false
+
+		for start := 0; start < len(lines); start += size {
+			end := start + size
+
+			if end > len(lines) {
+				end = len(lines)
+			}
+
+			complement := append(append([]string{}, lines[:start]...), lines[end:]...)
+
+			if failing(complement) {
+				lines = complement
+				reduced = true
+
+				if n > 2 {
+					n--
+				}
+
+				break
+			}
+		}
+
+		if reduced {
+			continue
+		}
+
+		if n >= len(lines) {
+			break
+		}
+
+		n *= 2
+
+		if n > len(lines) {
+			n = len(lines)
+		}
+	}
+
+	return lines
+}
+
+// internalError runs the program and returns the message of the internal error it fails with, if any.
+// Programs that don't parse or compile, or that execute more than maxInstructions, count as not failing, and their
+// output is discarded.
+func internalError(filepath string, lines []string, maxInstructions int) (message string, crashed bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			message, crashed = "", false
+		}
+	}()
+
+	l := lexer.New(strings.Join(lines, "\n"))
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		return "", false
+	}
+
+	g := bytecode.NewGenerator(program)
+	g.SetFileName(filepath)
+	bytecodes := g.GenerateByteCode(program)
+
+	v := vm.New()
+	v.SetInstructionLimit(maxInstructions)
+	v.SetStdout(ioutil.Discard)
+	v.SetStderr(ioutil.Discard)
+	loadProgram(v, bytecodes, filepath)
+
+	err := v.Exec()
+
+	if err == nil || !vm.IsInternalError(err) {
+		return "", false
+	}
+
+	return err.Error(), true
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/st0012/Rooby/vm"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	replPrompt             = "rooby> "
+	replContinuationPrompt = "rooby* "
+)
+
+// repl implements `rooby` without a program. It evaluates what's typed into one VM and prints the inspected value
+// of each input. Lines are collected until they complete their statements, so def, class and do blocks can span lines.
+// The session ends at the end of input or when the program calls `exit`. When in is a terminal, lines can be edited,
+// recalled from the history of earlier sessions and completed with tab.
+func repl(in io.Reader, out io.Writer, options execOptions) {
+	v := vm.New()
+	v.Tracer = options.tracer
+	v.SetStdout(out)
+	v.SetInstructionLimit(options.maxInstructions)
+	v.SetFrozenStringLiterals(options.frozenStringLiterals)
+	v.SetArgs(options.args)
+
+	if options.trackObjects {
+		v.TrackObjects()
+	}
+
+	for _, dir := range options.loadPaths {
+		v.AddLoadPath(dir)
+	}
+
+	session := vm.NewREPL(v)
+	var reader lineReader = &lineScanner{scanner: bufio.NewScanner(in), out: out}
+
+	if f, ok := in.(*os.File); ok && isTerminal(f.Fd()) {
+		reader = newLineEditor(f, out, loadHistory(historyPath()), session.Complete)
+	}
+
+	var lines []string
+
+	for {
+		prompt := replPrompt
+
+		if len(lines) > 0 {
+			prompt = replContinuationPrompt
+		}
+
+		line, err := reader.readLine(prompt)
+
+		if err == errInterrupted {
+			lines = nil
+			continue
+		}
+
+		if err != nil {
+			fmt.Fprintln(out)
+			break
+		}
+
+		lines = append(lines, line)
+		source := strings.Join(lines, "\n")
+
+		if strings.TrimSpace(source) == "" || session.Incomplete(source) {
+			if strings.TrimSpace(source) == "" {
+				lines = nil
+			}
+
+			continue
+		}
+
+		lines = nil
+		result, err := session.Eval(source)
+
+		if _, ok := err.(*vm.ExitError); ok {
+			exitREPL(v, err)
+		}
+
+		if err != nil {
+			fmt.Fprintln(out, vm.ErrorReport(err))
+			continue
+		}
+
+		fmt.Fprintln(out, "=> "+result)
+	}
+
+	exitREPL(v, nil)
+}
+
+// exitREPL runs the handlers registered by at_exit and exits with the status of the session, which is err of the last input.
+func exitREPL(v *vm.VM, err error) {
+	err = v.RunExitHandlers(err)
+
+	if _, ok := err.(*vm.ExitError); err != nil && !ok {
+		fmt.Println(vm.ErrorReport(err))
+	}
+
+	if err != nil {
+		os.Exit(vm.ExitStatus(err))
+	}
+
+	os.Exit(0)
+}
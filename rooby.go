@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/st0012/Rooby/ast"
+	"github.com/st0012/Rooby/ast/astutil"
 	"github.com/st0012/Rooby/bytecode"
 	"github.com/st0012/Rooby/lexer"
 	"github.com/st0012/Rooby/parser"
@@ -11,15 +12,56 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 )
 
 func main() {
 	compileOptionPtr := flag.Bool("c", false, "Compile to bytecode")
+	statsOptionPtr := flag.Bool("stats", false, "Print instruction statistics at exit")
+	profileOptionPtr := flag.Bool("profile", false, "Print a profile of method calls and instructions at exit")
+	maxInstructionsPtr := flag.Int("max-instructions", 0, "Abort the program after executing this many instructions")
+	traceOptionPtr := flag.Bool("trace", false, "Trace executed instructions to stderr")
+	traceMethodsPtr := flag.String("trace-methods", "", "Only trace these comma separated methods")
+	frozenStringLiteralsPtr := flag.Bool("frozen-string-literals", false, "Freeze every string literal")
+	trackObjectsPtr := flag.Bool("track-objects", false, "Count live objects for ObjectSpace.count_objects and GC.stat")
+	var defines stringFlags
+	flag.Var(&defines, "define", "Define a compile-time constant as NAME=value (can be repeated)")
+	var loadPaths stringFlags
+	flag.Var(&loadPaths, "I", "Add a directory to $LOAD_PATH for require (can be repeated)")
 
 	flag.Parse()
 
+	switch flag.Arg(0) {
+	case "reduce":
+		reduce(flag.Args()[1:])
+		return
+	case "serve":
+		serve(flag.Args()[1:])
+		return
+	}
+
 	filepath := flag.Arg(0)
+	var args []string
+
+	if flag.NArg() > 1 {
+		args = flag.Args()[1:]
+	}
+
+	options := execOptions{stats: *statsOptionPtr, profile: *profileOptionPtr, maxInstructions: *maxInstructionsPtr, frozenStringLiterals: *frozenStringLiteralsPtr, trackObjects: *trackObjectsPtr, loadPaths: loadPaths, args: args}
+
+	if *traceOptionPtr || *traceMethodsPtr != "" {
+		options.tracer = vm.NewTracer(os.Stderr)
+
+		if *traceMethodsPtr != "" {
+			options.tracer.Methods = strings.Split(*traceMethodsPtr, ",")
+		}
+	}
+
+	if filepath == "" {
+		repl(os.Stdin, os.Stdout, options)
+		return
+	}
 
 	var fileExt string
 	dir, filename := path.Split(filepath)
@@ -41,18 +83,40 @@ func main() {
 		program := buildAST(file)
 
 		g := bytecode.NewGenerator(program)
+		g.SetFileName(filepath)
+
+		for _, d := range defines {
+			name, value, err := parseDefine(d)
+
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			g.Define(name, value)
+		}
+
 		bytecodes := g.GenerateByteCode(program)
+		options.frozenStringLiterals = options.frozenStringLiterals || hasFrozenStringLiteralComment(string(file))
 
 		if !*compileOptionPtr {
-			execBytecode(bytecodes)
+			execBytecode(bytecodes, string(file), filepath, options)
 			return
 		}
 
-		writeByteCode(bytecodes, dir, filename)
+		writeByteCode(bytecode.AddHeader(bytecodes, string(file)), dir, filename)
 
 	case "robc":
-		bytecodes := string(file)
-		execBytecode(bytecodes)
+		_, bytecodes, err := bytecode.ReadHeader(string(file))
+
+		if err != nil {
+			fmt.Printf("Can't execute %s: %s\n", filepath, err.Error())
+			os.Exit(1)
+		}
+
+		// __FILE__ is resolved when compiling, so program name should be the source file it's compiled from.
+		// The source itself isn't stored in bytecode files, crash reports will only have the bytecodes.
+		execBytecode(bytecodes, "", dir+filename+".ro", options)
 	default:
 		fmt.Printf("Unknown file extension: %s", fileExt)
 	}
@@ -69,15 +133,160 @@ func writeByteCode(bytecodes, dir, filename string) {
 	f.WriteString(bytecodes)
 }
 
-func execBytecode(bytecodes string) {
-	p := vm.NewBytecodeParser()
+// execOptions are the command line options that change how programs are executed.
+type execOptions struct {
+	stats           bool
+	profile         bool
+	maxInstructions int
+	tracer          *vm.Tracer
+	// frozenStringLiterals is set by the flag or the source's magic comment
+	frozenStringLiterals bool
+	trackObjects         bool
+	loadPaths            []string
+	// args are the command line arguments after the program's file name, they're ARGV of the program
+	args []string
+}
+
+func execBytecode(bytecodes, source, programName string, options execOptions) {
 	v := vm.New()
+	v.Tracer = options.tracer
+	v.SetInstructionLimit(options.maxInstructions)
+	v.SetFrozenStringLiterals(options.frozenStringLiterals)
+	v.SetArgs(options.args)
+
+	if options.trackObjects {
+		v.TrackObjects()
+	}
+
+	for _, dir := range options.loadPaths {
+		v.AddLoadPath(dir)
+	}
+
+	if options.stats {
+		v.Stats = vm.NewStats()
+	}
+
+	var profiler *vm.Profiler
+
+	if options.profile {
+		profiler = vm.NewProfiler(v)
+	}
+
+	// reports are printed when the program exits, even if it fails
+	report := func() {
+		if v.Stats != nil {
+			fmt.Fprint(os.Stderr, v.Stats.Report())
+		}
+
+		if profiler != nil {
+			fmt.Fprint(os.Stderr, profiler.Report())
+		}
+	}
+
+	loadProgram(v, bytecodes, programName)
+
+	err := v.RunExitHandlers(v.Exec())
+
+	if _, ok := err.(*vm.ExitError); err != nil && !ok {
+		fmt.Println(vm.ErrorReport(err))
+	}
+
+	if ie, ok := err.(*vm.InternalError); ok {
+		writeCrashReport(ie, source, bytecodes)
+	}
+
+	report()
+
+	if err != nil {
+		os.Exit(vm.ExitStatus(err))
+	}
+}
+
+// hasFrozenStringLiteralComment returns true if the comments at the top of source have the
+// `# frozen_string_literal: true` magic comment.
+func hasFrozenStringLiteralComment(source string) bool {
+	for _, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(line, "#") {
+			return false
+		}
+
+		if strings.TrimSpace(strings.TrimPrefix(line, "#")) == "frozen_string_literal: true" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadProgram loads bytecodes into v and pushes the program's call frame, so it's ready for v.Exec.
+func loadProgram(v *vm.VM, bytecodes, programName string) {
+	p := vm.NewBytecodeParser()
 	p.VM = v
+	v.SetGlobal("$PROGRAM_NAME", vm.InitializeString(programName))
 	p.Parse(bytecodes)
 	cf := vm.NewCallFrame(v.LabelTable[vm.PROGRAM]["ProgramStart"][0])
-	cf.Self = vm.MainObj
+	cf.Self = v.MainObj
 	v.CallFrameStack.Push(cf)
-	v.Exec()
+}
+
+// writeCrashReport saves the diagnostic bundle of a VM failure to a temporary file, so it can be attached to a bug report.
+func writeCrashReport(err *vm.InternalError, source, bytecodes string) {
+	f, e := ioutil.TempFile("", "rooby-crash-")
+
+	if e != nil {
+		fmt.Printf("Can't write crash report: %s\n", e.Error())
+		return
+	}
+
+	defer f.Close()
+
+	f.WriteString(err.CrashReport(source, bytecodes))
+	fmt.Printf("This is a bug in Rooby. A crash report is written to %s\n", f.Name())
+	fmt.Println("Run `rooby reduce` on the program to minimize it before reporting.")
+}
+
+// stringFlags collects every value of a flag that can be repeated, like --define and -I.
+type stringFlags []string
+
+func (d *stringFlags) String() string {
+	return strings.Join(*d, ", ")
+}
+
+func (d *stringFlags) Set(value string) error {
+	*d = append(*d, value)
+	return nil
+}
+
+// parseDefine parses NAME=value into a constant name and its value.
+// Integers and booleans are converted, any other value is a string.
+func parseDefine(define string) (string, ast.Expression, error) {
+	i := strings.Index(define, "=")
+
+	if i <= 0 {
+		return "", nil, fmt.Errorf("Invalid define %q, expect NAME=value", define)
+	}
+
+	name, value := define[:i], define[i+1:]
+
+	if name[0] < 'A' || name[0] > 'Z' {
+		return "", nil, fmt.Errorf("Invalid define %q, %s isn't a constant name", define, name)
+	}
+
+	if n, err := strconv.Atoi(value); err == nil {
+		return name, astutil.Int(n), nil
+	}
+
+	if value == "true" || value == "false" {
+		return name, astutil.Bool(value == "true"), nil
+	}
+
+	return name, astutil.Str(value), nil
 }
 
 func buildAST(file []byte) *ast.Program {
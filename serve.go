@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/st0012/Rooby/bytecode"
+	"github.com/st0012/Rooby/lexer"
+	"github.com/st0012/Rooby/parser"
+	"github.com/st0012/Rooby/vm"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// serve implements `rooby serve [-addr address] app.ro`. It runs the script and keeps its VM alive afterwards,
+// serves instruction counts, memory usage and uptime on /health, and runs the script again in a new VM on SIGHUP,
+// stopping the previous run if it's still going. Uncaught exceptions are logged instead of stopping the process.
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "Address of the health endpoint")
+	fs.Parse(args)
+
+	s := &server{file: fs.Arg(0), started: time.Now(), logger: log.New(os.Stderr, "", log.LstdFlags)}
+
+	if s.file == "" {
+		fmt.Println("Usage: rooby serve [-addr address] app.ro")
+		os.Exit(1)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", s.health)
+
+	go func() {
+		s.log("info", "serving health endpoint", "addr", *addr)
+
+		if err := http.ListenAndServe(*addr, mux); err != nil {
+			s.log("error", "health endpoint stopped", "error", err.Error())
+			os.Exit(1)
+		}
+	}()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	// scripts run on their own goroutines, so reloads are handled while a script is running
+	s.load()
+
+	for range hup {
+		s.log("info", "reloading", "file", s.file)
+		s.load()
+	}
+}
+
+// server runs each load of the script on a goroutine of its own. Fields guarded by mu are shared with those goroutines
+// and the health endpoint, which reads instruction counts through Stats since the VM may be executing.
+type server struct {
+	file    string
+	started time.Time
+	logger  *log.Logger
+
+	mu sync.Mutex
+	// vm is the VM of the last script that was loaded, it's kept alive until the next reload.
+	vm *vm.VM
+	// stop is closed to stop the script running in vm
+	stop      chan struct{}
+	running   bool
+	runs      int
+	lastError string
+}
+
+type healthReport struct {
+	Status        string         `json:"status"`
+	UptimeSeconds float64        `json:"uptime_seconds"`
+	Runs          int            `json:"runs"`
+	Instructions  int            `json:"instructions"`
+	Opcodes       map[string]int `json:"opcodes"`
+	LastError     string         `json:"last_error,omitempty"`
+	Memory        memoryReport   `json:"memory"`
+}
+
+type memoryReport struct {
+	Alloc      uint64 `json:"alloc"`
+	TotalAlloc uint64 `json:"total_alloc"`
+	Sys        uint64 `json:"sys"`
+	NumGC      uint32 `json:"num_gc"`
+}
+
+// load compiles the script and starts running it in a new VM, stopping the script that's running. If the script
+// doesn't compile, the previous VM is kept.
+func (s *server) load() {
+	bytecodes, err := compileFile(s.file)
+
+	if err != nil {
+		s.log("error", "can't compile script", "file", s.file, "error", err.Error())
+		s.mu.Lock()
+		s.runs++
+		s.lastError = err.Error()
+		s.mu.Unlock()
+		return
+	}
+
+	v := vm.New()
+	v.Stats = vm.NewStats()
+	loadProgram(v, bytecodes, s.file)
+	stop := make(chan struct{})
+
+	s.mu.Lock()
+
+	// a script stops between instructions, so a builtin it's blocked in doesn't hold up the new one
+	if s.running {
+		close(s.stop)
+	}
+
+	s.vm = v
+	s.stop = stop
+	s.running = true
+	s.mu.Unlock()
+
+	go s.run(v, stop)
+}
+
+// run executes the script loaded in v. Once it's done, the server's state is updated unless another script was loaded meanwhile.
+func (s *server) run(v *vm.VM, stop chan struct{}) {
+	start := time.Now()
+	err := v.ExecUntil(stop)
+
+	switch {
+	case err == vm.ErrStopped:
+		s.log("info", "script stopped", "file", s.file, "duration", time.Since(start).String())
+		return
+	case err != nil:
+		s.log("error", "uncaught exception", "file", s.file, "error", vm.ErrorReport(err))
+	default:
+		s.log("info", "script finished", "file", s.file, "duration", time.Since(start).String())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.vm != v {
+		return
+	}
+
+	s.running = false
+	s.runs++
+	s.lastError = ""
+
+	if err != nil {
+		s.lastError = err.Error()
+	}
+}
+
+func (s *server) health(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	s.mu.Lock()
+	report := healthReport{
+		Status:        "ok",
+		UptimeSeconds: time.Since(s.started).Seconds(),
+		Runs:          s.runs,
+		LastError:     s.lastError,
+		Memory:        memoryReport{Alloc: mem.Alloc, TotalAlloc: mem.TotalAlloc, Sys: mem.Sys, NumGC: mem.NumGC},
+	}
+
+	if s.vm != nil {
+		report.Opcodes = s.vm.Stats.OpcodeCounts()
+	}
+
+	if s.running {
+		report.Status = "running"
+	} else if s.lastError != "" {
+		report.Status = "failed"
+	}
+
+	for _, count := range report.Opcodes {
+		report.Instructions += count
+	}
+
+	body, err := json.Marshal(report)
+	s.mu.Unlock()
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// log writes a line of space separated key=value pairs, so the log can be parsed by log processors.
+func (s *server) log(level, msg string, pairs ...string) {
+	fields := []string{"level=" + level, fmt.Sprintf("msg=%q", msg)}
+
+	for i := 0; i+1 < len(pairs); i += 2 {
+		fields = append(fields, fmt.Sprintf("%s=%q", pairs[i], pairs[i+1]))
+	}
+
+	s.logger.Println(strings.Join(fields, " "))
+}
+
+// compileFile compiles a source file to bytecodes, returning syntax errors as error rather than exiting.
+func compileFile(filepath string) (bytecodes string, err error) {
+	file, err := ioutil.ReadFile(filepath)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	l := lexer.New(string(file))
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	if errors := p.Errors(); len(errors) > 0 {
+		return "", fmt.Errorf("%s", strings.Join(errors, "\n"))
+	}
+
+	g := bytecode.NewGenerator(program)
+	g.SetFileName(filepath)
+
+	return g.GenerateByteCode(program), nil
+}
@@ -0,0 +1,46 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// isTerminal returns true if fd is a terminal.
+func isTerminal(fd uintptr) bool {
+	var t syscall.Termios
+
+	return ioctl(fd, getTermios, &t) == nil
+}
+
+// makeRaw puts the terminal fd in raw mode, so keys are read as they're typed without being echoed, and returns
+// a function that restores its previous mode. Output processing is left on so "\n" still starts a new line.
+func makeRaw(fd uintptr) (func(), error) {
+	var old syscall.Termios
+
+	if err := ioctl(fd, getTermios, &old); err != nil {
+		return nil, err
+	}
+
+	raw := old
+	raw.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+
+	if err := ioctl(fd, setTermios, &raw); err != nil {
+		return nil, err
+	}
+
+	return func() { ioctl(fd, setTermios, &old) }, nil
+}
+
+func ioctl(fd, request uintptr, t *syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(t))); errno != 0 {
+		return errno
+	}
+
+	return nil
+}
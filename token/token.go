@@ -15,8 +15,12 @@ const (
 	CONSTANT          = "CONSTANT"
 	IDENT             = "IDENT"
 	INSTANCE_VARIABLE = "INSTANCE_VAR"
+	GLOBAL_VARIABLE   = "GLOBAL_VAR"
 	INT               = "INT"
+	FLOAT             = "FLOAT"
+	RATIONAL          = "RATIONAL"
 	STRING            = "STRING"
+	COMMAND           = "COMMAND"
 	COMMENT           = "COMMENT"
 
 	ASSIGN   = "="
@@ -25,12 +29,26 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
+	POW      = "**"
 	DOT      = "."
 	INCR     = "++"
 	DECR     = "--"
 
-	LT = "<"
-	GT = ">"
+	// RANGE and EXCLUSIVE_RANGE are the operators of range literals, like 1..5 and 1...5
+	RANGE           = ".."
+	EXCLUSIVE_RANGE = "..."
+
+	LT     = "<"
+	GT     = ">"
+	LTE    = "<="
+	GTE    = ">="
+	LSHIFT = "<<"
+	RSHIFT = ">>"
+
+	AMPERSAND = "&"
+	CARET     = "^"
+	TILDE     = "~"
 
 	COMMA     = ","
 	SEMICOLON = ";"
@@ -45,9 +63,12 @@ const (
 	RBRACKET = "]"
 
 	EQ     = "=="
+	MATCH  = "=~"
 	NOT_EQ = "!="
+	ARROW  = "=>"
 
 	CLASS  = "CLASS"
+	MODULE = "MODULE"
 	TRUE   = "TRUE"
 	FALSE  = "FALSE"
 	IF     = "IF"
@@ -59,6 +80,11 @@ const (
 	WHILE  = "WHILE"
 	DO     = "DO"
 	YIELD  = "YIELD"
+	SUPER  = "SUPER"
+	BEGIN  = "BEGIN"
+	RESCUE = "RESCUE"
+	ENSURE = "ENSURE"
+	FILE   = "FILE"
 )
 
 var keyworkds = map[string]TokenType{
@@ -73,6 +99,12 @@ var keyworkds = map[string]TokenType{
 	"while":  WHILE,
 	"do":     DO,
 	"yield":  YIELD,
+	"super":  SUPER,
+	"begin":  BEGIN,
+	"rescue": RESCUE,
+	"ensure": ENSURE,
+
+	"__FILE__": FILE,
 }
 
 func LookupIdent(ident string) TokenType {
@@ -16,6 +16,9 @@ type RArray struct {
 type ArrayObject struct {
 	Class    *RArray
 	Elements []Object
+	// trackedSize is the size the VM's object limits count the array as, 0 if it isn't counted
+	trackedSize int64
+	frozenFlag
 }
 
 func (a *ArrayObject) Type() ObjectType {
@@ -23,11 +26,16 @@ func (a *ArrayObject) Type() ObjectType {
 }
 
 func (a *ArrayObject) Inspect() string {
+	return a.inspect(Object.Inspect)
+}
+
+// inspect formats the array with elements formatted by inspectElement
+func (a *ArrayObject) inspect(inspectElement func(Object) string) string {
 	var out bytes.Buffer
 
 	elements := []string{}
 	for _, e := range a.Elements {
-		elements = append(elements, e.Inspect())
+		elements = append(elements, inspectElement(e))
 	}
 
 	out.WriteString("Array:")
@@ -61,7 +69,7 @@ func InitializeArray(elements []Object) *ArrayObject {
 	return &ArrayObject{Elements: elements, Class: ArrayClass}
 }
 
-func init() {
+func initArray() {
 	methods := NewEnvironment()
 
 	for _, m := range builtinArrayMethods {
@@ -73,94 +81,521 @@ func init() {
 	ArrayClass = ac
 }
 
-var builtinArrayMethods = []*BuiltInMethod{
-	{
+// arrayMapper returns a built in method of Array that collects what the block returns for each element.
+// The result is a new array, or replaces the elements of the receiver if inPlace is true.
+func arrayMapper(name string, inPlace bool) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (%s)", name)
+				}
+
+				arr := receiver.(*ArrayObject)
+
+				if inPlace {
+					vm.checkFrozen(arr)
+				}
+
+				elems := []Object{}
+
+				// the block can change the array, only elements it has when map starts are mapped
+				for _, obj := range append([]Object{}, arr.Elements...) {
+					elems = append(elems, vm.builtInMethodYield(blockFrame, obj).Target)
+				}
+
+				if inPlace {
+					vm.checkFrozen(arr)
+					arr.Elements = elems
+					return arr
+				}
+
+				result := InitializeArray(elems)
+				vm.track(result)
+
+				return result
+			}
+		},
+		Name: name,
+	}
+}
+
+// arrayWindower returns a built in method of Array that yields arrays of n consecutive elements starting at every
+// step(n)-th element, or returns an Enumerator of them without a block. The last array can be shorter if partial is true.
+func arrayWindower(name string, step func(n int) int, partial bool, sizeError string) *BuiltInMethod {
+	return &BuiltInMethod{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				if len(args) != 1 {
-					return newError("Expect 1 arguments. got=%d", len(args))
+					return newError("Expect 1 argument. got=%d", len(args))
 				}
 
-				i := args[0]
-				index, ok := i.(*IntegerObject)
+				arr := receiver.(*ArrayObject)
+				n := vm.integerArgument(args[0])
 
-				if !ok {
-					return newError("Expect index argument to be Integer. got=%T", i)
+				if n <= 0 {
+					vm.raise(ArgumentErrorClass, "%s", sizeError)
+				}
+
+				windows := []Object{}
+				elems := append([]Object{}, arr.Elements...)
+
+				for i := 0; i < len(elems); i += step(n) {
+					end := i + n
+
+					if end > len(elems) {
+						if !partial {
+							break
+						}
+
+						end = len(elems)
+					}
+
+					window := InitializeArray(append([]Object{}, elems[i:end]...))
+					vm.track(window)
+					windows = append(windows, window)
+				}
+
+				if blockFrame == nil {
+					return InitializeEnumerator(sliceIterator(windows))
+				}
+
+				for _, window := range windows {
+					vm.builtInMethodYield(blockFrame, window)
+				}
+
+				return arr
+			}
+		},
+		Name: name,
+	}
+}
+
+// arrayFilter returns a built in method of Array that returns a new array of the elements the block returns
+// a truthy value for, or of the elements it doesn't if keep is false.
+func arrayFilter(name string, keep bool) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (%s)", name)
+				}
+
+				elems := []Object{}
+
+				for _, obj := range append([]Object{}, receiver.(*ArrayObject).Elements...) {
+					if isTruthy(vm.builtInMethodYield(blockFrame, obj).Target) == keep {
+						elems = append(elems, obj)
+					}
+				}
+
+				result := InitializeArray(elems)
+				vm.track(result)
+
+				return result
+			}
+		},
+		Name: name,
+	}
+}
+
+// arrayFinder returns a built in method of Array that returns the first element the block returns a truthy value for,
+// or nil if there's no such element.
+func arrayFinder(name string) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (%s)", name)
+				}
+
+				for _, obj := range append([]Object{}, receiver.(*ArrayObject).Elements...) {
+					if isTruthy(vm.builtInMethodYield(blockFrame, obj).Target) {
+						return obj
+					}
+				}
+
+				return NULL
+			}
+		},
+		Name: name,
+	}
+}
+
+// arrayQuantifier returns a built in method of Array that checks elements with the block, or checks the elements
+// themselves without a block. It returns stopResult as soon as an element's truthiness is stopOn, otherwise !stopResult.
+func arrayQuantifier(name string, stopOn, stopResult bool) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				for _, obj := range append([]Object{}, receiver.(*ArrayObject).Elements...) {
+					result := obj
+
+					if blockFrame != nil {
+						result = vm.builtInMethodYield(blockFrame, obj).Target
+					}
+
+					if isTruthy(result) == stopOn {
+						return toBooleanObject(stopResult)
+					}
+				}
+
+				return toBooleanObject(!stopResult)
+			}
+		},
+		Name: name,
+	}
+}
+
+// arrayReducer returns a built in method of Array that combines elements with the block, or with the method
+// a symbol names, like reduce(0) do |sum, i| sum + i end or reduce("+".to_sym). Without an initial value,
+// the first element is the initial value.
+func arrayReducer(name string) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 2 {
+					return newError("Expect 0 to 2 arguments. got=%d", len(args))
+				}
+
+				elems := append([]Object{}, receiver.(*ArrayObject).Elements...)
+				operator := ""
+
+				if blockFrame == nil {
+					if len(args) == 0 {
+						vm.raise(LocalJumpErrorClass, "no block given (%s)", name)
+					}
+
+					operator = vm.nameArgument(args[len(args)-1])
+					args = args[:len(args)-1]
+				}
+
+				if len(args) == 0 {
+					if len(elems) == 0 {
+						return NULL
+					}
+
+					args, elems = elems[:1], elems[1:]
+				}
+
+				acc := args[0]
+
+				for _, obj := range elems {
+					if blockFrame != nil {
+						acc = vm.builtInMethodYield(blockFrame, acc, obj).Target
+					} else {
+						acc = vm.send(acc, operator, obj)
+					}
+				}
+
+				return acc
+			}
+		},
+		Name: name,
+	}
+}
+
+// equal returns true if a == b, using the == method of a.
+func (vm *VM) equal(a, b Object) bool {
+	return isTruthy(vm.send(a, "==", b))
+}
+
+// arrayIndexFinder returns a built in method of Array that returns the index of the first element that's == its
+// argument, or that the block returns a truthy value for. It returns nil if there's no such element.
+func arrayIndexFinder(name string) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 || (len(args) == 0 && blockFrame == nil) {
+					return newError("Expect 1 argument or a block. got=%d", len(args))
+				}
+
+				for i, obj := range append([]Object{}, receiver.(*ArrayObject).Elements...) {
+					var found bool
+
+					if len(args) == 1 {
+						found = vm.equal(obj, args[0])
+					} else {
+						found = isTruthy(vm.builtInMethodYield(blockFrame, obj).Target)
+					}
+
+					if found {
+						return vm.initInteger(i)
+					}
+				}
+
+				return NULL
+			}
+		},
+		Name: name,
+	}
+}
+
+// arrayEnd returns a built in method of Array that returns its first or last element, or an array of the first
+// or last n elements if it's given n.
+func arrayEnd(name string, first bool) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				elems := receiver.(*ArrayObject).Elements
+
+				if len(args) == 0 {
+					if len(elems) == 0 {
+						return NULL
+					}
+
+					if first {
+						return elems[0]
+					}
+
+					return elems[len(elems)-1]
+				}
+
+				n := vm.integerArgument(args[0])
+
+				if n < 0 {
+					vm.raise(ArgumentErrorClass, "negative array size")
+				}
+
+				if n > len(elems) {
+					n = len(elems)
+				}
+
+				var result *ArrayObject
+
+				if first {
+					result = InitializeArray(append([]Object{}, elems[:n]...))
+				} else {
+					result = InitializeArray(append([]Object{}, elems[len(elems)-n:]...))
+				}
+
+				vm.track(result)
+
+				return result
+			}
+		},
+		Name: name,
+	}
+}
+
+// flatten appends the elements of elems to result, elements that are arrays are flattened depth levels deep.
+// A negative depth flattens every level. parents are the arrays being flattened, to detect recursive arrays.
+func (vm *VM) flatten(result, elems []Object, depth int, parents []*ArrayObject) []Object {
+	for _, obj := range elems {
+		arr, ok := obj.(*ArrayObject)
+
+		if !ok || depth == 0 {
+			result = append(result, obj)
+			continue
+		}
+
+		for _, parent := range parents {
+			if parent == arr {
+				vm.raise(ArgumentErrorClass, "tried to flatten recursive array")
+			}
+		}
+
+		result = vm.flatten(result, arr.Elements, depth-1, append(parents, arr))
+	}
+
+	return result
+}
+
+// assignedSlice returns where the part of arr that arr[args] = value replaces starts and how long it is.
+// Unlike reading, the start can be after the end of arr, the array is expanded with nils to it.
+func (vm *VM) assignedSlice(arr *ArrayObject, args []Object) (start, length int) {
+	n := len(arr.Elements)
+
+	if r, isRange := args[0].(*RangeObject); isRange && len(args) == 1 {
+		start, end := r.Start, vm.integerRange(r).End
+
+		if start < 0 {
+			start += n
+		}
+
+		if end < 0 {
+			end += n
+		}
+
+		if !r.Exclusive {
+			end++
+		}
+
+		if start < 0 {
+			vm.raise(RangeErrorClass, "%s out of range", r.Inspect())
+		}
+
+		if end < start {
+			end = start
+		}
+
+		return start, end - start
+	}
+
+	start = vm.integerArgument(args[0])
+	length = 1
+
+	if len(args) == 2 {
+		length = vm.integerArgument(args[1])
+
+		if length < 0 {
+			vm.raise(IndexErrorClass, "negative length (%d)", length)
+		}
+	}
+
+	if start < 0 {
+		if start+n < 0 {
+			vm.raise(IndexErrorClass, "index %d too small for array; minimum: -%d", start, n)
+		}
+
+		start += n
+	}
+
+	return start, length
+}
+
+var builtinArrayMethods = []*BuiltInMethod{
+	digMethod,
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) < 1 || len(args) > 2 {
+					return newError("Expect 1 or 2 arguments. got=%d", len(args))
 				}
 
 				arr := receiver.(*ArrayObject)
+				start, length, ok := vm.slice(len(arr.Elements), args)
 
-				if len(arr.Elements) == 0 {
+				if !ok {
 					return NULL
 				}
 
-				if int(index.Value) >= len(arr.Elements) {
-					return newError("Index out of range")
+				if _, isIndex := args[0].(*IntegerObject); isIndex && len(args) == 1 {
+					return arr.Elements[start]
 				}
 
-				return arr.Elements[index.Value]
+				result := InitializeArray(append([]Object{}, arr.Elements[start:start+length]...))
+				vm.track(result)
 
+				return result
 			}
 		},
 		Name: "[]",
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
-				// First arg is index
-				// Second arg is assigned value
-				if len(args) != 2 {
-					return newError("Expect 2 arguments. got=%d", len(args))
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				// the last argument is the assigned value, the others are an index, a start and a length, or a range
+				if len(args) < 2 || len(args) > 3 {
+					return newError("Expect 2 or 3 arguments. got=%d", len(args))
 				}
 
-				i := args[0]
-				index, ok := i.(*IntegerObject)
-				indexValue := index.Value
+				arr := receiver.(*ArrayObject)
+				vm.checkFrozen(arr)
+
+				value := args[len(args)-1]
+				start, length := vm.assignedSlice(arr, args[:len(args)-1])
 
-				if !ok {
-					return newError("Expect index argument to be Integer. got=%T", i)
+				// an index past the end expands the array with nils
+				n := len(arr.Elements)
+
+				if n < start {
+					n = start
 				}
 
-				arr := receiver.(*ArrayObject)
+				if _, isIndex := args[0].(*IntegerObject); isIndex && len(args) == 2 {
+					if start == n {
+						n++
+					}
+
+					vm.trackGrowth(arr, arrayElementSize*int64(n-len(arr.Elements)))
 
-				// Expand the array
-				if len(arr.Elements) < (indexValue + 1) {
-					newArr := make([]Object, indexValue+1)
-					copy(newArr, arr.Elements)
-					for i := range newArr[len(arr.Elements):] {
-						newArr[i] = NULL
+					for len(arr.Elements) < start {
+						arr.Elements = append(arr.Elements, NULL)
 					}
-					arr.Elements = newArr
+
+					if start == len(arr.Elements) {
+						arr.Elements = append(arr.Elements, value)
+					} else {
+						arr.Elements[start] = value
+					}
+
+					return value
 				}
 
-				arr.Elements[indexValue] = args[1]
+				if start+length > n {
+					length = n - start
+				}
+
+				// an array replaces the slice with its elements
+				replacement := []Object{value}
+
+				if a, ok := value.(*ArrayObject); ok {
+					replacement = a.Elements
+				}
+
+				vm.trackGrowth(arr, arrayElementSize*int64(n-length+len(replacement)-len(arr.Elements)))
 
-				return arr.Elements[indexValue]
+				for len(arr.Elements) < start {
+					arr.Elements = append(arr.Elements, NULL)
+				}
+
+				elems := append([]Object{}, arr.Elements[:start]...)
+				elems = append(elems, replacement...)
+				arr.Elements = append(elems, arr.Elements[start+length:]...)
+
+				return value
 			}
 		},
 		Name: "[]=",
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				if len(args) != 0 {
 					return newError("Expect 0 argument. got=%d", len(args))
 				}
 
 				arr := receiver.(*ArrayObject)
-				return InitilaizeInteger(arr.Length())
+				return vm.initInteger(arr.Length())
 			}
 		},
 		Name: "length",
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				if len(args) != 0 {
 					return newError("Expect 0 argument. got=%d", len(args))
 				}
 
 				arr := receiver.(*ArrayObject)
+				vm.checkFrozen(arr)
+
+				if len(arr.Elements) > 0 {
+					vm.trackGrowth(arr, -arrayElementSize)
+				}
+
 				return arr.Pop()
 			}
 		},
@@ -168,23 +603,312 @@ var builtinArrayMethods = []*BuiltInMethod{
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				arr := receiver.(*ArrayObject)
+				vm.checkFrozen(arr)
+				vm.trackGrowth(arr, arrayElementSize*int64(len(args)))
 				return arr.Push(args)
 			}
 		},
 		Name: "push",
 	},
-	//{
-	//	Fn: func(receiver Object) BuiltinMethodBody {
-	//		return func(args []Object, block *Method) Object {
-	//			arr := receiver.(*ArrayObject)
-	//			for _, obj := range arr.Elements {
-	//				evalMethodObject(block.Scope.Self, block, []Object{obj}, nil)
-	//			}
-	//			return arr
-	//		}
-	//	},
-	//	Name: "each",
-	//},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				arr := receiver.(*ArrayObject)
+				vm.checkFrozen(arr)
+				vm.trackGrowth(arr, arrayElementSize)
+				return arr.Push(args)
+			}
+		},
+		Name: "<<",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				arr := receiver.(*ArrayObject)
+
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (yield)")
+				}
+
+				for _, obj := range arr.Elements {
+					vm.builtInMethodYield(blockFrame, obj)
+				}
+
+				return arr
+			}
+		},
+		Name: "each",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (each_with_index)")
+				}
+
+				arr := receiver.(*ArrayObject)
+
+				for i, obj := range append([]Object{}, arr.Elements...) {
+					vm.builtInMethodYield(blockFrame, obj, vm.initInteger(i))
+				}
+
+				return arr
+			}
+		},
+		Name: "each_with_index",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (each_index)")
+				}
+
+				arr := receiver.(*ArrayObject)
+
+				for i := range append([]Object{}, arr.Elements...) {
+					vm.builtInMethodYield(blockFrame, vm.initInteger(i))
+				}
+
+				return arr
+			}
+		},
+		Name: "each_index",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (each_with_object)")
+				}
+
+				arr := receiver.(*ArrayObject)
+
+				for _, obj := range append([]Object{}, arr.Elements...) {
+					vm.builtInMethodYield(blockFrame, obj, args[0])
+				}
+
+				return args[0]
+			}
+		},
+		Name: "each_with_object",
+	},
+	arrayWindower("each_slice", func(n int) int { return n }, true, "invalid slice size"),
+	arrayWindower("each_cons", func(n int) int { return 1 }, false, "invalid size"),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				others := []*ArrayObject{}
+
+				for _, arg := range args {
+					other, ok := arg.(*ArrayObject)
+
+					if !ok {
+						vm.raise(TypeErrorClass, "wrong argument type %s (must respond to :each)", vm.inspectForError(arg))
+					}
+
+					others = append(others, other)
+				}
+
+				// the result is as long as the receiver, other arrays that are shorter are padded with nil
+				tuples := []Object{}
+
+				for i, obj := range append([]Object{}, receiver.(*ArrayObject).Elements...) {
+					tuple := []Object{obj}
+
+					for _, other := range others {
+						if i < len(other.Elements) {
+							tuple = append(tuple, other.Elements[i])
+						} else {
+							tuple = append(tuple, NULL)
+						}
+					}
+
+					arr := InitializeArray(tuple)
+					vm.track(arr)
+					tuples = append(tuples, arr)
+				}
+
+				if blockFrame != nil {
+					for _, tuple := range tuples {
+						vm.builtInMethodYield(blockFrame, tuple)
+					}
+
+					return NULL
+				}
+
+				result := InitializeArray(tuples)
+				vm.track(result)
+
+				return result
+			}
+		},
+		Name: "zip",
+	},
+	arrayMapper("map", false),
+	arrayMapper("collect", false),
+	arrayMapper("map!", true),
+	arrayMapper("collect!", true),
+	arrayFilter("select", true),
+	arrayFilter("reject", false),
+	arrayFinder("find"),
+	arrayFinder("detect"),
+	arrayQuantifier("any?", true, true),
+	arrayQuantifier("all?", false, false),
+	arrayQuantifier("none?", true, false),
+	arrayReducer("reduce"),
+	arrayReducer("inject"),
+	arrayIndexFinder("index"),
+	arrayIndexFinder("find_index"),
+	arrayEnd("first", true),
+	arrayEnd("last", false),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				for _, obj := range append([]Object{}, receiver.(*ArrayObject).Elements...) {
+					if vm.equal(obj, args[0]) {
+						return TRUE
+					}
+				}
+
+				return FALSE
+			}
+		},
+		Name: "include?",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				elems := append([]Object{}, receiver.(*ArrayObject).Elements...)
+
+				if len(args) == 0 && blockFrame == nil {
+					return vm.initInteger(len(elems))
+				}
+
+				count := 0
+
+				for _, obj := range elems {
+					var counted bool
+
+					if len(args) == 1 {
+						counted = vm.equal(obj, args[0])
+					} else {
+						counted = isTruthy(vm.builtInMethodYield(blockFrame, obj).Target)
+					}
+
+					if counted {
+						count++
+					}
+				}
+
+				return vm.initInteger(count)
+			}
+		},
+		Name: "count",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				depth := -1
+
+				if len(args) == 1 {
+					depth = vm.integerArgument(args[0])
+				}
+
+				arr := receiver.(*ArrayObject)
+				result := InitializeArray(vm.flatten([]Object{}, arr.Elements, depth, []*ArrayObject{arr}))
+				vm.track(result)
+
+				return result
+			}
+		},
+		Name: "flatten",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				elems := []Object{}
+
+				for _, obj := range receiver.(*ArrayObject).Elements {
+					if obj != NULL {
+						elems = append(elems, obj)
+					}
+				}
+
+				result := InitializeArray(elems)
+				vm.track(result)
+
+				return result
+			}
+		},
+		Name: "compact",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				// elements are compared like hash keys, by their block's result if there's a block
+				seen := InitializeHash(map[string]Object{})
+				elems := []Object{}
+
+				for _, obj := range append([]Object{}, receiver.(*ArrayObject).Elements...) {
+					key := obj
+
+					if blockFrame != nil {
+						key = vm.builtInMethodYield(blockFrame, obj).Target
+					}
+
+					if _, ok := seen.Get(vm, key); ok {
+						continue
+					}
+
+					seen.set(vm, key, TRUE)
+					elems = append(elems, obj)
+				}
+
+				result := InitializeArray(elems)
+				vm.track(result)
+
+				return result
+			}
+		},
+		Name: "uniq",
+	},
 }
@@ -13,7 +13,7 @@ func TestLengthMethod(t *testing.T) {
 	array := generateArray(expected)
 	m := getBuiltInMethod(t, array, "length")
 
-	result := m(nil, nil).(*IntegerObject).Value
+	result := m(nil, nil, nil).(*IntegerObject).Value
 
 	if int(result) != expected {
 		t.Fatalf("Expect length method returns array's length: %d. got=%d", expected, result)
@@ -23,7 +23,7 @@ func TestLengthMethod(t *testing.T) {
 func TestPopMethod(t *testing.T) {
 	array := generateArray(5)
 	m := getBuiltInMethod(t, array, "pop")
-	last := m(nil, nil).(*IntegerObject).Value
+	last := m(nil, nil, nil).(*IntegerObject).Value
 
 	if int(last) != 5 {
 		t.Fatalf("Expect pop to return array's last  got=%d", last)
@@ -40,7 +40,7 @@ func TestPushMethod(t *testing.T) {
 
 	six := InitilaizeInteger(6)
 	seven := InitilaizeInteger(7)
-	m([]Object{six, seven}, nil)
+	m(nil, []Object{six, seven}, nil)
 
 	if array.Length() != 7 {
 		t.Fatalf("Expect array's length to be 7(5 + 2). got=%d", array.Length())
@@ -132,28 +132,701 @@ func TestEvalArrayIndex(t *testing.T) {
 	}
 }
 
-//func TestEachMethod(t *testing.T) {
-//	tests := []struct {
-//		input    string
-//		expected int
-//	}{
-//		{`
-//		sum = 0
-//		puts(self)
-//		[1, 2, 3, 4, 5].each do |i|
-//		  puts(self)
-//		  puts(sum)
-//		  sum = sum + i
-//		end
-//		sum
-//		`, 15},
-//	}
-//
-//	for _, tt := range tests {
-//		evaluated := testEval(t, tt.input)
-//		testIntegerObject(t, evaluated, tt.expected)
-//	}
-//}
+func TestArraySlicing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []int
+	}{
+		{`[1, 2, 3, 4, 5][1..3]`, []int{2, 3, 4}},
+		{`[1, 2, 3, 4, 5][1...3]`, []int{2, 3}},
+		{`[1, 2, 3, 4, 5][0..-2]`, []int{1, 2, 3, 4}},
+		{`[1, 2, 3, 4, 5][-2..-1]`, []int{4, 5}},
+		{`[1, 2, 3, 4, 5][3..10]`, []int{4, 5}},
+		{`[1, 2, 3, 4, 5][5..6]`, []int{}},
+		{`[1, 2, 3, 4, 5][2, 3]`, []int{3, 4, 5}},
+		{`[1, 2, 3, 4, 5][-3, 2]`, []int{3, 4}},
+		{`[1, 2, 3][1, 10]`, []int{2, 3}},
+		{`a = [1, 2, 3]; a[0..1] = 9; a`, []int{9, 3}},
+		{`a = [1, 2, 3]; a[1..1] = [7, 8]; a`, []int{1, 7, 8, 3}},
+		{`a = [1, 2, 3]; a[0, 2] = [5]; a`, []int{5, 3}},
+		{`a = [1, 2, 3]; a[1, 0] = [4, 5]; a`, []int{1, 4, 5, 2, 3}},
+		{`a = [1, 2, 3]; a[-1] = 4; a`, []int{1, 2, 4}},
+		{`a = [1, 2, 3]; a[3] = 4; a`, []int{1, 2, 3, 4}},
+		{`a = [1, 2, 3]; a[-2..-1] = [0]; a`, []int{1, 0}},
+		{`a = [1, 2, 3]; a[3..4] = [4, 5]; a`, []int{1, 2, 3, 4, 5}},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		arr, ok := evaluated.(*ArrayObject)
+
+		if !ok {
+			t.Fatalf("at test case %d: expect result to be an array. got=%T", i, evaluated)
+		}
+
+		if len(arr.Elements) != len(tt.expected) {
+			t.Fatalf("at test case %d: expect %d elements. got=%d", i, len(tt.expected), len(arr.Elements))
+		}
+
+		for j, expected := range tt.expected {
+			if !testIntegerObject(t, arr.Elements[j], expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestArrayNegativeIndex(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[1, 2, 3][-1]`, 3},
+		{`[1, 2, 3][-3]`, 1},
+		{`[1, 2, 3][-4]`, nil},
+		{`[1, 2, 3][3]`, nil},
+		{`[1, 2, 3][4, 1]`, nil},
+		{`[1, 2, 3][5..6]`, nil},
+		{`a = [1]; a[3] = 5; a[0]`, 1},
+		{`a = [1]; a[3] = 5; a[2]`, nil},
+		{`a = [1]; a[3, 1] = 5; a[3]`, 5},
+		{`a = [1, 2]; a[0, 1] = 5`, 5},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			if !testIntegerObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		default:
+			if !testNullObject(t, evaluated) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestArraySlicingErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`a = [1, 2]; a[-3] = 1`, "IndexError: index -3 too small for array; minimum: -2"},
+		{`a = [1, 2]; a[0, 0 - 1] = 1`, "IndexError: negative length (-1)"},
+		{`a = [1, 2]; a[-5..1] = 1`, "RangeError: -5..1 out of range"},
+		{`[1]["a"]`, "TypeError: wrong argument type a (expected Integer)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestEachMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		sum = 0
+		[1, 2, 3, 4, 5].each do |i|
+		  sum = sum + i
+		end
+		sum
+		`, 15},
+		{`
+		sum = 0
+		[].each do |i|
+		  sum = sum + i
+		end
+		sum
+		`, 0},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestMapMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []int
+	}{
+		{`
+		[1, 2, 3].map do |i|
+		  i * 2
+		end
+		`, []int{2, 4, 6}},
+		{`
+		[1, 2, 3].collect do |i|
+		  i + 1
+		end
+		`, []int{2, 3, 4}},
+		{`
+		a = [1, 2, 3]
+		b = a.map do |i|
+		  i * 2
+		end
+		a
+		`, []int{1, 2, 3}},
+		{`
+		a = [1, 2, 3]
+		a.map! do |i|
+		  i * 10
+		end
+		a
+		`, []int{10, 20, 30}},
+		{`
+		a = [1, 2]
+		a.collect! do |i|
+		  a.push(i)
+		  i
+		end
+		`, []int{1, 2}},
+		{`
+		[].map do |i|
+		  i
+		end
+		`, []int{}},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		arr, ok := evaluated.(*ArrayObject)
+
+		if !ok {
+			t.Fatalf("at test case %d: expect result to be an array. got=%T", i, evaluated)
+		}
+
+		if len(arr.Elements) != len(tt.expected) {
+			t.Fatalf("at test case %d: expect %d elements. got=%d", i, len(tt.expected), len(arr.Elements))
+		}
+
+		for j, expected := range tt.expected {
+			if !testIntegerObject(t, arr.Elements[j], expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestMapMethodErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1].map`, "LocalJumpError: no block given (map)"},
+		{`[1].collect!`, "LocalJumpError: no block given (collect!)"},
+		{`
+		a = [1].freeze
+		a.map! do |i|
+		  i
+		end
+		`, "FrozenError: can't modify frozen Array: Array:[1]"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestFilterMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []int
+	}{
+		{`
+		[1, 2, 3, 4].select do |i|
+		  i % 2 == 0
+		end
+		`, []int{2, 4}},
+		{`
+		[1, 2, 3, 4].reject do |i|
+		  i % 2 == 0
+		end
+		`, []int{1, 3}},
+		{`
+		[1, 2, 3].select do |i|
+		  i > 5
+		end
+		`, []int{}},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		arr, ok := evaluated.(*ArrayObject)
+
+		if !ok {
+			t.Fatalf("at test case %d: expect result to be an array. got=%T", i, evaluated)
+		}
+
+		if len(arr.Elements) != len(tt.expected) {
+			t.Fatalf("at test case %d: expect %d elements. got=%d", i, len(tt.expected), len(arr.Elements))
+		}
+
+		for j, expected := range tt.expected {
+			if !testIntegerObject(t, arr.Elements[j], expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestFindMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		[1, 2, 3, 4].find do |i|
+		  i > 2
+		end
+		`, 3},
+		{`
+		[1, 2, 3, 4].detect do |i|
+		  i % 2 == 0
+		end
+		`, 2},
+		{`
+		[1, 2].find do |i|
+		  i > 2
+		end
+		`, nil},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			if !testIntegerObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		default:
+			if !testNullObject(t, evaluated) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestPredicateMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`[1, 2, 3].any? do |i| i > 2 end`, true},
+		{`[1, 2, 3].any? do |i| i > 3 end`, false},
+		{`[].any? do |i| true end`, false},
+		{`[false, 1].any?`, true},
+		{`[false].any?`, false},
+		{`[1, 2, 3].all? do |i| i > 0 end`, true},
+		{`[1, 2, 3].all? do |i| i > 1 end`, false},
+		{`[].all? do |i| false end`, true},
+		{`[1, false].all?`, false},
+		{`[1, 2].all?`, true},
+		{`[1, 2, 3].none? do |i| i > 3 end`, true},
+		{`[1, 2, 3].none? do |i| i > 2 end`, false},
+		{`[false].none?`, true},
+		{`[].none?`, true},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testBooleanObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestFilterMethodErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1].select`, "LocalJumpError: no block given (select)"},
+		{`[1].reject`, "LocalJumpError: no block given (reject)"},
+		{`[1].find`, "LocalJumpError: no block given (find)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestReduceMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		[1, 2, 3, 4].reduce(0) do |sum, i|
+		  sum + i
+		end
+		`, 10},
+		{`
+		[1, 2, 3, 4].reduce do |sum, i|
+		  sum * i
+		end
+		`, 24},
+		{`
+		[1, 2, 3].inject(10) do |sum, i|
+		  sum - i
+		end
+		`, 4},
+		{`[1, 2, 3, 4].reduce("+".to_sym)`, 10},
+		{`[1, 2, 3, 4].inject("*")`, 24},
+		{`[1, 2, 3].reduce(10, "+".to_sym)`, 16},
+		{`["a", "b", "c"].reduce("+".to_sym)`, "abc"},
+		{`[].reduce(5, "+".to_sym)`, 5},
+		{`[].reduce("+".to_sym)`, nil},
+		{`
+		class Money
+		  def initialize(cents)
+		    @cents = cents
+		  end
+
+		  def cents
+		    @cents
+		  end
+
+		  def add(other)
+		    Money.new(@cents + other.cents)
+		  end
+		end
+
+		[Money.new(1), Money.new(2)].reduce(Money.new(3), "add".to_sym).cents
+		`, 6},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			if !testIntegerObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		case string:
+			if !testStringObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		default:
+			if !testNullObject(t, evaluated) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestReduceMethodErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1].reduce`, "LocalJumpError: no block given (reduce)"},
+		{`[1, 2].reduce("foo".to_sym)`, "NoMethodError: undefined method `foo' for 1"},
+		{`[1, 2].reduce(1)`, "TypeError: 1 is not a symbol nor a string"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestIndexedIteration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		sum = 0
+		[10, 20, 30].each_with_index do |x, i|
+		  sum = sum + x * i
+		end
+		sum
+		`, 80},
+		{`
+		sum = 0
+		[10, 20, 30].each_index do |i|
+		  sum = sum + i
+		end
+		sum
+		`, 3},
+		{`
+		[1, 2, 3].each_with_index do |x, i|
+		end.length
+		`, 3},
+		{`
+		[1, 2, 3].each_with_object([]) do |x, memo|
+		  memo.push(x * 2)
+		end[2]
+		`, 6},
+		{`
+		h = {}
+		["a", "b"].each_with_object(h) do |x, memo|
+		  memo[x] = 1
+		end
+		h.length
+		`, 2},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestIndexedIterationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1].each_with_index`, "LocalJumpError: no block given (each_with_index)"},
+		{`[1].each_index`, "LocalJumpError: no block given (each_index)"},
+		{`[1].each_with_object([])`, "LocalJumpError: no block given (each_with_object)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestSearchMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[1, 2, 3].include?(2)`, true},
+		{`[1, 2, 3].include?(4)`, false},
+		{`[1, "a", true].include?("a")`, true},
+		{`[1, "a", true].include?(false)`, false},
+		{`[1.0, 2].include?(1)`, true},
+		{`["a", "b", "c"].index("b")`, 1},
+		{`["a", "b", "c"].index("z")`, nil},
+		{`[1, 2, 3, 4].find_index do |i| i > 2 end`, 2},
+		{`[1, 2, 3].find_index(3)`, 2},
+		{`[1, 2, 1, 1].count`, 4},
+		{`[1, 2, 1, 1].count(1)`, 3},
+		{`[1, 2, 3, 4].count do |i| i % 2 == 0 end`, 2},
+		{`[1, 2, 3].first`, 1},
+		{`[1, 2, 3].last`, 3},
+		{`[].first`, nil},
+		{`[].last`, nil},
+		{`[1, 2, 3].first(2)[1]`, 2},
+		{`[1, 2, 3].last(2)[0]`, 2},
+		{`[1, 2, 3].first(10).length`, 3},
+		{`[1, 2, 3].last(0).length`, 0},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			if !testIntegerObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		case bool:
+			if !testBooleanObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		default:
+			if !testNullObject(t, evaluated) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestSearchMethodErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1].first(0 - 1)`, "ArgumentError: negative array size"},
+		{`[1].last("a")`, "TypeError: wrong argument type a (expected Integer)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestCleanupMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []interface{}
+	}{
+		{`[1, [2, [3, [4]]]].flatten`, []interface{}{1, 2, 3, 4}},
+		{`[1, [].first, 2, [].last].compact`, []interface{}{1, 2}},
+		{`[1, 2, 1, 3, 2].uniq`, []interface{}{1, 2, 3}},
+		{`["a", "b", "a"].uniq`, []interface{}{"a", "b"}},
+		{`
+		[1, 2, 3, 4, 5].uniq do |i|
+		  i % 3
+		end
+		`, []interface{}{1, 2, 3}},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		arr, ok := evaluated.(*ArrayObject)
+
+		if !ok {
+			t.Fatalf("at test case %d: expect result to be an array. got=%T", i, evaluated)
+		}
+
+		if len(arr.Elements) != len(tt.expected) {
+			t.Fatalf("at test case %d: expect %d elements. got=%d", i, len(tt.expected), len(arr.Elements))
+		}
+
+		for j, e := range tt.expected {
+			switch expected := e.(type) {
+			case int:
+				if !testIntegerObject(t, arr.Elements[j], expected) {
+					t.Fatalf("at test case %d", i)
+				}
+			case string:
+				if !testStringObject(t, arr.Elements[j], expected) {
+					t.Fatalf("at test case %d", i)
+				}
+			}
+		}
+	}
+}
+
+func TestCleanupMethodsResultLength(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`[1, [2, [3, [4]]]].flatten(1).length`, 3},
+		{`[1, [2, [3, [4]]]].flatten(2)[2]`, 3},
+		{`[[1, 2], [3]].flatten(0).length`, 2},
+		{`[[], [1], []].flatten.length`, 1},
+		{`[1.0, 1, 1.0].uniq.length`, 2},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestFlattenRecursiveArray(t *testing.T) {
+	err := testEvalError(t, New(), "", `
+	a = [1]
+	a.push(a)
+	a.flatten
+	`)
+	expected := "ArgumentError: tried to flatten recursive array"
+
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expect error %q. got=%v", expected, err)
+	}
+}
+
+func TestCombinationIterators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1, 2, 3].zip([4, 5, 6])`, "Array:[Array:[1, 4], Array:[2, 5], Array:[3, 6]]"},
+		{`[1, 2, 3].zip([4], ["a", "b", "c", "d"])`, "Array:[Array:[1, 4, a], Array:[2, null, b], Array:[3, null, c]]"},
+		{`[1, 2].zip`, "Array:[Array:[1], Array:[2]]"},
+		{`
+		sums = []
+		r = [1, 2].zip([10, 20]) do |(a, b)|
+		  sums.push(a + b)
+		end
+		[r, sums]
+		`, "Array:[null, Array:[11, 22]]"},
+		{`
+		slices = []
+		[1, 2, 3, 4, 5].each_slice(2) do |s|
+		  slices.push(s)
+		end
+		slices
+		`, "Array:[Array:[1, 2], Array:[3, 4], Array:[5]]"},
+		{`[1, 2, 3].each_slice(5).to_a`, "Array:[Array:[1, 2, 3]]"},
+		{`[].each_slice(2).to_a`, "Array:[]"},
+		{`[1, 2, 3].each_slice(1) do |s| s end`, "Array:[1, 2, 3]"},
+		{`
+		sums = []
+		[1, 2, 3, 4].each_cons(2) do |(a, b)|
+		  sums.push(a + b)
+		end
+		sums
+		`, "Array:[3, 5, 7]"},
+		{`[1, 2, 3, 4].each_cons(3).to_a`, "Array:[Array:[1, 2, 3], Array:[2, 3, 4]]"},
+		{`[1, 2].each_cons(3).to_a`, "Array:[]"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Fatalf("at test case %d: expect %s. got=%s", i, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestCombinationIteratorErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1].zip(1)`, "TypeError: wrong argument type 1 (must respond to :each)"},
+		{`[1].each_slice(0)`, "ArgumentError: invalid slice size"},
+		{`[1].each_cons(0 - 1)`, "ArgumentError: invalid size"},
+		{`[1].each_slice("2")`, "TypeError: wrong argument type 2 (expected Integer)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
 
 func generateArray(length int) *ArrayObject {
 	var elements []Object
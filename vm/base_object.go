@@ -12,6 +12,10 @@ type RObject struct {
 	InstanceVariables *Environment
 	Scope             *Scope
 	InitializeMethod  *Method
+	main              bool
+	// singletonClass holds methods defined only for this object, it's created by the first one
+	singletonClass *RClass
+	frozenFlag
 }
 
 func (ro *RObject) Type() ObjectType {
@@ -19,6 +23,10 @@ func (ro *RObject) Type() ObjectType {
 }
 
 func (ro *RObject) Inspect() string {
+	if ro.main {
+		return "main"
+	}
+
 	return "<Instance of: " + ro.Class.Name + ">"
 }
 
@@ -29,6 +37,17 @@ func (ro *RObject) ReturnClass() Class {
 	return ro.Class
 }
 
+// instanceVariableName returns the name arg refers to, raising NameError if it isn't an instance variable name like "@foo"
+func (vm *VM) instanceVariableName(arg Object) string {
+	name := vm.nameArgument(arg)
+
+	if len(name) < 2 || name[0] != '@' || name[1] == '@' {
+		vm.raise(NameErrorClass, "`%s' is not allowed as an instance variable name", name)
+	}
+
+	return name
+}
+
 func InitializeInstance(c *RClass) *RObject {
 	instance := &RObject{Class: c, InstanceVariables: NewEnvironment()}
 
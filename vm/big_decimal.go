@@ -0,0 +1,696 @@
+package vm
+
+import (
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	BigDecimalClass *RBigDecimal
+)
+
+type RBigDecimal struct {
+	*BaseClass
+}
+
+// BigDecimalObject is an exact decimal number like Ruby's BigDecimal, its value is unscaled / 10 ** scale.
+// Trailing zeros after the decimal point are removed, so equal values are represented the same way.
+type BigDecimalObject struct {
+	Class    *RBigDecimal
+	unscaled *big.Int
+	scale    int
+}
+
+func (d *BigDecimalObject) Type() ObjectType {
+	return BIG_DECIMAL_OBJ
+}
+
+// Inspect formats decimals in scientific notation like Ruby does, so 12.5 is 0.125e2.
+func (d *BigDecimalObject) Inspect() string {
+	if d.unscaled.Sign() == 0 {
+		return "0.0"
+	}
+
+	sign := ""
+
+	if d.unscaled.Sign() < 0 {
+		sign = "-"
+	}
+
+	digits := new(big.Int).Abs(d.unscaled).String()
+	exponent := len(digits) - d.scale
+
+	return sign + "0." + strings.TrimRight(digits, "0") + "e" + strconv.Itoa(exponent)
+}
+
+func (d *BigDecimalObject) ReturnClass() Class {
+	return d.Class
+}
+
+// plain formats d with a decimal point and without an exponent, like to_s("F").
+func (d *BigDecimalObject) plain() string {
+	sign := ""
+
+	if d.unscaled.Sign() < 0 {
+		sign = "-"
+	}
+
+	digits := new(big.Int).Abs(d.unscaled).String()
+
+	if d.scale == 0 {
+		return sign + digits + ".0"
+	}
+
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+
+	point := len(digits) - d.scale
+
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// rat returns the exact value of d.
+func (d *BigDecimalObject) rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.unscaled, pow10(d.scale))
+}
+
+// pow10 returns 10 ** n.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// initBigDecimal returns the decimal unscaled / 10 ** scale, scale can be negative.
+func (vm *VM) initBigDecimal(unscaled *big.Int, scale int) *BigDecimalObject {
+	if scale < 0 {
+		unscaled, scale = new(big.Int).Mul(unscaled, pow10(-scale)), 0
+	}
+
+	ten := big.NewInt(10)
+	q, r := new(big.Int), new(big.Int)
+
+	for scale > 0 {
+		if q.QuoRem(unscaled, ten, r); r.Sign() != 0 {
+			break
+		}
+
+		unscaled, scale = new(big.Int).Set(q), scale-1
+	}
+
+	return &BigDecimalObject{Class: BigDecimalClass, unscaled: unscaled, scale: scale}
+}
+
+// Rounding modes of BigDecimal, their values are the ones of Ruby's BigDecimal::ROUND_* constants.
+const (
+	roundUp       = 1
+	roundDown     = 2
+	roundHalfUp   = 3
+	roundHalfDown = 4
+	roundCeiling  = 5
+	roundFloor    = 6
+	roundHalfEven = 7
+	// roundModeOption is BigDecimal::ROUND_MODE, the option BigDecimal.mode reads and sets
+	roundModeOption = 256
+)
+
+// bigDecimalConstants are the constants of BigDecimal
+var bigDecimalConstants = map[string]int{
+	"ROUND_UP":        roundUp,
+	"ROUND_DOWN":      roundDown,
+	"ROUND_HALF_UP":   roundHalfUp,
+	"ROUND_HALF_DOWN": roundHalfDown,
+	"ROUND_CEILING":   roundCeiling,
+	"ROUND_FLOOR":     roundFloor,
+	"ROUND_HALF_EVEN": roundHalfEven,
+	"ROUND_MODE":      roundModeOption,
+}
+
+// roundingModeNames are the names rounding modes can also be given by, as a string or a symbol.
+var roundingModeNames = map[string]int{
+	"up":        roundUp,
+	"down":      roundDown,
+	"truncate":  roundDown,
+	"half_up":   roundHalfUp,
+	"default":   roundHalfUp,
+	"half_down": roundHalfDown,
+	"ceiling":   roundCeiling,
+	"ceil":      roundCeiling,
+	"floor":     roundFloor,
+	"half_even": roundHalfEven,
+	"banker":    roundHalfEven,
+}
+
+// bigDecimalDivisionDigits is how many significant digits `/` keeps of quotients that don't terminate, like 1 / 3.
+const bigDecimalDivisionDigits = 20
+
+// roundingMode returns the rounding mode o refers to, which is one of the BigDecimal::ROUND_* constants or its name.
+func (vm *VM) roundingMode(o Object) int {
+	var name string
+
+	switch o := o.(type) {
+	case *IntegerObject:
+		if o.big == nil && o.Value >= roundUp && o.Value <= roundHalfEven {
+			return o.Value
+		}
+	case *SymbolObject:
+		name = o.Name
+	case *StringObject:
+		name = o.Value
+	}
+
+	mode, ok := roundingModeNames[name]
+
+	if !ok {
+		vm.raise(ArgumentErrorClass, "invalid rounding mode %s", vm.inspectForError(o))
+	}
+
+	return mode
+}
+
+// decimalRoundingMode returns the rounding mode set by BigDecimal.mode, which is ROUND_HALF_UP by default.
+func (vm *VM) decimalRoundingMode() int {
+	if vm.bigDecimalRoundingMode == 0 {
+		return roundHalfUp
+	}
+
+	return vm.bigDecimalRoundingMode
+}
+
+// roundRat rounds r to scale digits after the decimal point with mode, and returns it as a decimal.
+// A negative scale rounds to tens, hundreds and so on.
+func (vm *VM) roundRat(r *big.Rat, scale int, mode int) *BigDecimalObject {
+	num, den := new(big.Int).Set(r.Num()), new(big.Int).Set(r.Denom())
+
+	if scale > 0 {
+		num.Mul(num, pow10(scale))
+	} else {
+		den.Mul(den, pow10(-scale))
+	}
+
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	if rem.Sign() != 0 {
+		// away is whether the result is rounded away from zero, comparing twice the remainder with the
+		// denominator tells if the digits that are dropped are less than, exactly or more than half
+		half := new(big.Int).Lsh(new(big.Int).Abs(rem), 1).Cmp(den)
+		away := false
+
+		switch mode {
+		case roundUp:
+			away = true
+		case roundHalfUp:
+			away = half >= 0
+		case roundHalfDown:
+			away = half > 0
+		case roundHalfEven:
+			away = half > 0 || (half == 0 && q.Bit(0) == 1)
+		case roundCeiling:
+			away = num.Sign() > 0
+		case roundFloor:
+			away = num.Sign() < 0
+		}
+
+		if away {
+			q.Add(q, big.NewInt(int64(num.Sign())))
+		}
+	}
+
+	return vm.initBigDecimal(q, scale)
+}
+
+// decimalDigits returns how many digits the integer part of |r| has, which is 0 or less if |r| < 1,
+// so r has digits - decimalDigits(r) digits after the decimal point when rounded to digits significant digits.
+func decimalDigits(r *big.Rat) int {
+	abs := new(big.Rat).Abs(r)
+	n := len(new(big.Int).Abs(r.Num()).String()) - len(r.Denom().String())
+
+	// n is off by at most one, 10 ** (n - 1) <= |r| < 10 ** n must hold
+	switch {
+	case abs.Cmp(ratPow10(n)) >= 0:
+		n++
+	case abs.Cmp(ratPow10(n-1)) < 0:
+		n--
+	}
+
+	return n
+}
+
+// ratPow10 returns 10 ** n, n can be negative.
+func ratPow10(n int) *big.Rat {
+	if n < 0 {
+		return new(big.Rat).SetFrac(big.NewInt(1), pow10(-n))
+	}
+
+	return new(big.Rat).SetInt(pow10(n))
+}
+
+// quoDecimal returns x / y rounded to digits significant digits with the VM's rounding mode, and raises ZeroDivisionError if y is 0.
+func (vm *VM) quoDecimal(x, y *BigDecimalObject, digits int) *BigDecimalObject {
+	if y.unscaled.Sign() == 0 {
+		vm.raise(ZeroDivisionErrorClass, "divided by 0")
+	}
+
+	q := new(big.Rat).Quo(x.rat(), y.rat())
+
+	if q.Sign() == 0 {
+		return vm.initBigDecimal(new(big.Int), 0)
+	}
+
+	return vm.roundRat(q, digits-decimalDigits(q), vm.decimalRoundingMode())
+}
+
+// decimalPattern matches the strings BigDecimal() accepts, like "1", "-0.25", ".5" and "1_000.5e-3".
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+(_\d+)*)?(\.\d+(_\d+)*)?([eE][+-]?\d+)?$`)
+
+// parseDecimal parses s like BigDecimal() does, surrounding whitespace is ignored.
+func (vm *VM) parseDecimal(s string) (*BigDecimalObject, bool) {
+	s = strings.TrimSpace(s)
+
+	if !decimalPattern.MatchString(s) {
+		return nil, false
+	}
+
+	s = strings.Replace(s, "_", "", -1)
+
+	exponent := 0
+
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+
+		if err != nil {
+			return nil, false
+		}
+
+		s, exponent = s[:i], e
+	}
+
+	// a mantissa without digits, like "+" or the one of "e5", isn't a number
+	if strings.IndexAny(s, "0123456789") < 0 {
+		return nil, false
+	}
+
+	scale := 0
+
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		scale = len(s) - i - 1
+		s = s[:i] + s[i+1:]
+	}
+
+	unscaled, ok := new(big.Int).SetString(strings.TrimPrefix(s, "+"), 10)
+
+	if !ok {
+		return nil, false
+	}
+
+	return vm.initBigDecimal(unscaled, scale-exponent), true
+}
+
+// floatToDecimal returns the decimal of f with digits significant digits, or the shortest decimal that is f if digits is 0.
+func (vm *VM) floatToDecimal(f float64, digits int) *BigDecimalObject {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		vm.raise(FloatDomainErrorClass, "%s", InitializeFloat(f).Inspect())
+	}
+
+	d, _ := vm.parseDecimal(strconv.FormatFloat(f, 'e', digits-1, 64))
+
+	return d
+}
+
+// toDecimal returns the value of an Integer, a Float or a BigDecimal as a decimal.
+func (vm *VM) toDecimal(o Object) (*BigDecimalObject, bool) {
+	switch o := o.(type) {
+	case *BigDecimalObject:
+		return o, true
+	case *IntegerObject:
+		return vm.initBigDecimal(o.bigValue(), 0), true
+	case *FloatObject:
+		return vm.floatToDecimal(o.Value, 0), true
+	}
+
+	return nil, false
+}
+
+// decimalOperator returns a built in method of BigDecimal that combines the receiver with an Integer, a Float or a BigDecimal.
+func decimalOperator(name string, operate func(vm *VM, x, y *BigDecimalObject) *BigDecimalObject) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, BigDecimalClass, name)
+
+				if err != nil {
+					return err
+				}
+
+				right, ok := vm.toDecimal(args[0])
+
+				if !ok {
+					return wrongTypeError(BigDecimalClass)
+				}
+
+				return operate(vm, receiver.(*BigDecimalObject), right)
+			}
+		},
+		Name: name,
+	}
+}
+
+func addDecimal(vm *VM, x, y *BigDecimalObject) *BigDecimalObject {
+	x, y = alignDecimals(x, y)
+	return vm.initBigDecimal(new(big.Int).Add(x.unscaled, y.unscaled), x.scale)
+}
+
+func subDecimal(vm *VM, x, y *BigDecimalObject) *BigDecimalObject {
+	x, y = alignDecimals(x, y)
+	return vm.initBigDecimal(new(big.Int).Sub(x.unscaled, y.unscaled), x.scale)
+}
+
+func mulDecimal(vm *VM, x, y *BigDecimalObject) *BigDecimalObject {
+	return vm.initBigDecimal(new(big.Int).Mul(x.unscaled, y.unscaled), x.scale+y.scale)
+}
+
+func quoDecimal(vm *VM, x, y *BigDecimalObject) *BigDecimalObject {
+	return vm.quoDecimal(x, y, bigDecimalDivisionDigits)
+}
+
+// alignDecimals returns x and y with the same scale, so their unscaled values can be added and subtracted.
+func alignDecimals(x, y *BigDecimalObject) (*BigDecimalObject, *BigDecimalObject) {
+	switch {
+	case x.scale < y.scale:
+		x = &BigDecimalObject{Class: x.Class, unscaled: new(big.Int).Mul(x.unscaled, pow10(y.scale-x.scale)), scale: y.scale}
+	case y.scale < x.scale:
+		y = &BigDecimalObject{Class: y.Class, unscaled: new(big.Int).Mul(y.unscaled, pow10(x.scale-y.scale)), scale: x.scale}
+	}
+
+	return x, y
+}
+
+// decimalComparison returns a built in method of BigDecimal that compares the receiver with a number,
+// compare reports whether the comparison holds for the result of big.Rat's Cmp.
+func decimalComparison(name string, compare func(c int) bool) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, BigDecimalClass, name)
+
+				if err != nil {
+					return err
+				}
+
+				right, ok := vm.toRational(args[0], false)
+
+				if !ok {
+					return wrongTypeError(BigDecimalClass)
+				}
+
+				return toBooleanObject(compare(receiver.(*BigDecimalObject).rat().Cmp(right)))
+			}
+		},
+		Name: name,
+	}
+}
+
+// decimalRounding returns a built in method of BigDecimal that rounds the receiver with mode. Without an argument
+// it rounds to an Integer, otherwise to the given number of digits after the decimal point.
+func decimalRounding(name string, mode int) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				return vm.roundDecimal(receiver.(*BigDecimalObject), args, mode)
+			}
+		},
+		Name: name,
+	}
+}
+
+// roundDecimal rounds d with mode to the digits after the decimal point args give, or to an Integer if there are none.
+func (vm *VM) roundDecimal(d *BigDecimalObject, args []Object, mode int) Object {
+	if len(args) == 0 {
+		return vm.initBigInteger(vm.roundRat(d.rat(), 0, mode).unscaled)
+	}
+
+	return vm.roundRat(d.rat(), vm.integerArgument(args[0]), mode)
+}
+
+var builtinBigDecimalMethods = []*BuiltInMethod{
+	decimalOperator("+", addDecimal),
+	decimalOperator("-", subDecimal),
+	decimalOperator("*", mulDecimal),
+	decimalOperator("/", quoDecimal),
+	decimalComparison(">", func(c int) bool { return c > 0 }),
+	decimalComparison("<", func(c int) bool { return c < 0 }),
+	decimalComparison(">=", func(c int) bool { return c >= 0 }),
+	decimalComparison("<=", func(c int) bool { return c <= 0 }),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, BigDecimalClass, "==")
+
+				if err != nil {
+					return err
+				}
+
+				right, ok := vm.toRational(args[0], false)
+
+				// decimals are never equal to objects that aren't numbers
+				return toBooleanObject(ok && receiver.(*BigDecimalObject).rat().Cmp(right) == 0)
+			}
+		},
+		Name: "==",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, BigDecimalClass, "!=")
+
+				if err != nil {
+					return err
+				}
+
+				right, ok := vm.toRational(args[0], false)
+
+				return toBooleanObject(!ok || receiver.(*BigDecimalObject).rat().Cmp(right) != 0)
+			}
+		},
+		Name: "!=",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, BigDecimalClass, "**")
+
+				if err != nil {
+					return err
+				}
+
+				d := receiver.(*BigDecimalObject)
+				n := vm.integerArgument(args[0])
+
+				if n < 0 {
+					return vm.quoDecimal(vm.initBigDecimal(big.NewInt(1), 0), vm.decimalPower(d, -n), bigDecimalDivisionDigits)
+				}
+
+				return vm.decimalPower(d, n)
+			}
+		},
+		Name: "**",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 2 {
+					return newError("Expect 2 arguments. got=%d", len(args))
+				}
+
+				right, ok := vm.toDecimal(args[0])
+
+				if !ok {
+					return wrongTypeError(BigDecimalClass)
+				}
+
+				digits := vm.integerArgument(args[1])
+
+				if digits <= 0 {
+					vm.raise(ArgumentErrorClass, "negative or zero digits: %d", digits)
+				}
+
+				return vm.quoDecimal(receiver.(*BigDecimalObject), right, digits)
+			}
+		},
+		Name: "div",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 2 {
+					return newError("Expect 0 to 2 arguments. got=%d", len(args))
+				}
+
+				mode := vm.decimalRoundingMode()
+
+				if len(args) == 2 {
+					mode, args = vm.roundingMode(args[1]), args[:1]
+				}
+
+				return vm.roundDecimal(receiver.(*BigDecimalObject), args, mode)
+			}
+		},
+		Name: "round",
+	},
+	decimalRounding("floor", roundFloor),
+	decimalRounding("ceil", roundCeiling),
+	decimalRounding("truncate", roundDown),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				d := receiver.(*BigDecimalObject)
+
+				if d.unscaled.Sign() >= 0 {
+					return d
+				}
+
+				return vm.initBigDecimal(new(big.Int).Neg(d.unscaled), d.scale)
+			}
+		},
+		Name: "abs",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return toBooleanObject(receiver.(*BigDecimalObject).unscaled.Sign() == 0)
+			}
+		},
+		Name: "zero?",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initInteger(receiver.(*BigDecimalObject).scale)
+			}
+		},
+		Name: "scale",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.roundDecimal(receiver.(*BigDecimalObject), args, roundDown)
+			}
+		},
+		Name: "to_i",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				f, _ := strconv.ParseFloat(receiver.(*BigDecimalObject).plain(), 64)
+
+				return InitializeFloat(f)
+			}
+		},
+		Name: "to_f",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initRational(receiver.(*BigDecimalObject).rat())
+			}
+		},
+		Name: "to_r",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				d := receiver.(*BigDecimalObject)
+
+				// "F" formats the number with a decimal point instead of an exponent, like 12.5 instead of 0.125e2
+				if len(args) == 1 && strings.ContainsAny(vm.stringArgument(args[0]), "Ff") {
+					return InitializeString(d.plain())
+				}
+
+				return InitializeString(d.Inspect())
+			}
+		},
+		Name: "to_s",
+	},
+}
+
+// decimalPower returns d ** n, n mustn't be negative.
+func (vm *VM) decimalPower(d *BigDecimalObject, n int) *BigDecimalObject {
+	e := big.NewInt(int64(n))
+
+	return vm.initBigDecimal(new(big.Int).Exp(d.unscaled, e, nil), d.scale*n)
+}
+
+var builtinBigDecimalClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 && len(args) != 2 {
+					return newError("Expect 1 or 2 arguments. got=%d", len(args))
+				}
+
+				if option, ok := args[0].(*IntegerObject); !ok || option.big != nil || option.Value != roundModeOption {
+					vm.raise(ArgumentErrorClass, "unsupported mode option %s", vm.inspectForError(args[0]))
+				}
+
+				// with a mode, it changes how BigDecimals round from then on
+				if len(args) == 2 {
+					vm.bigDecimalRoundingMode = vm.roundingMode(args[1])
+				}
+
+				return vm.initInteger(vm.decimalRoundingMode())
+			}
+		},
+		Name: "mode",
+	},
+}
+
+func initBigDecimal() {
+	methods := NewEnvironment()
+
+	for _, m := range builtinBigDecimalMethods {
+		methods.Set(m.Name, m)
+	}
+
+	classMethods := NewEnvironment()
+
+	for _, m := range builtinBigDecimalClassMethods {
+		classMethods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "BigDecimal", Methods: methods, ClassMethods: classMethods, Class: ClassClass, SuperClass: ObjectClass}
+	BigDecimalClass = &RBigDecimal{BaseClass: bc}
+}
@@ -0,0 +1,158 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestBigDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`BigDecimal("12.5")`, "0.125e2"},
+		{`BigDecimal("1.50")`, "0.15e1"},
+		{`BigDecimal("100")`, "0.1e3"},
+		{`BigDecimal("0")`, "0.0"},
+		{`BigDecimal("  -0.00123 ")`, "-0.123e-2"},
+		{`BigDecimal(".5")`, "0.5e0"},
+		{`BigDecimal("1_000.5")`, "0.10005e4"},
+		{`BigDecimal("1.5e3")`, "0.15e4"},
+		{`BigDecimal(42)`, "0.42e2"},
+		{`BigDecimal(0.1, 3)`, "0.1e0"},
+		{`BigDecimal(BigDecimal("1.5"))`, "0.15e1"},
+		{`BigDecimal("0.1") + BigDecimal("0.2")`, "0.3e0"},
+		{`BigDecimal("1") - BigDecimal("0.01")`, "0.99e0"},
+		{`BigDecimal("1.1") * 3`, "0.33e1"},
+		{`3 * BigDecimal("1.1")`, "0.33e1"},
+		{`1 + BigDecimal("0.5")`, "0.15e1"},
+		{`1 - BigDecimal("0.5")`, "0.5e0"},
+		{`BigDecimal("1.1") + 0.1`, "0.12e1"},
+		{`BigDecimal("1") / 4`, "0.25e0"},
+		{`BigDecimal("1") / 3`, "0.33333333333333333333e0"},
+		{`BigDecimal("2") / 3`, "0.66666666666666666667e0"},
+		{`1 / BigDecimal("8")`, "0.125e0"},
+		{`BigDecimal("1").div(3, 5)`, "0.33333e0"},
+		{`BigDecimal("1000").div(3, 2)`, "0.33e3"},
+		{`BigDecimal("1.2") ** 2`, "0.144e1"},
+		{`BigDecimal("2") ** (0 - 2)`, "0.25e0"},
+		{`BigDecimal("12.5").to_s("F")`, "12.5"},
+		{`BigDecimal("-0.00123").to_s("F")`, "-0.00123"},
+		{`BigDecimal("1e3").to_s("F")`, "1000.0"},
+		{`BigDecimal("12.5").to_s`, "0.125e2"},
+		{`BigDecimal("2.675").round(2)`, "0.268e1"},
+		{`BigDecimal("2.665").round(2, "half_even")`, "0.266e1"},
+		{`BigDecimal("2.675").round(2, "half_even")`, "0.268e1"},
+		{`BigDecimal("2.665").round(2, "half_down")`, "0.266e1"},
+		{`BigDecimal("2.661").round(2, BigDecimal::ROUND_UP)`, "0.267e1"},
+		{`BigDecimal("2.669").round(2, BigDecimal::ROUND_DOWN)`, "0.266e1"},
+		{`BigDecimal("-2.661").round(2, "ceiling")`, "-0.266e1"},
+		{`BigDecimal("-2.661").round(2, "floor")`, "-0.267e1"},
+		{`BigDecimal("123.456").round(0 - 1)`, "0.12e3"},
+		{`BigDecimal("1.55").ceil(1)`, "0.16e1"},
+		{`BigDecimal("1.55").floor(1)`, "0.15e1"},
+		{`BigDecimal("-1.55").truncate(1)`, "-0.15e1"},
+		{`BigDecimal("-1.5").abs`, "0.15e1"},
+		{`BigDecimal("1.5").to_r`, "(3/2)"},
+		{`BigDecimal("1.5").to_f`, "1.5"},
+		{`BigDecimal("1.25").scale`, "2"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Fatalf("at test case %d: expect %s. got=%s", i, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestBigDecimalRoundingToIntegers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`BigDecimal("2.5").round`, 3},
+		{`BigDecimal("-2.5").round`, -3},
+		{`BigDecimal("2.4").round`, 2},
+		{`BigDecimal("1.9").floor`, 1},
+		{`BigDecimal("-1.1").floor`, -2},
+		{`BigDecimal("1.1").ceil`, 2},
+		{`BigDecimal("-1.9").truncate`, -1},
+		{`BigDecimal("-1.9").to_i`, -1},
+		{`BigDecimal.mode(BigDecimal::ROUND_MODE)`, 3},
+		{`BigDecimal.mode(BigDecimal::ROUND_MODE, "half_even")
+		BigDecimal("2.5").round`, 2},
+		{`BigDecimal.mode(BigDecimal::ROUND_MODE, BigDecimal::ROUND_FLOOR)
+		BigDecimal.mode(BigDecimal::ROUND_MODE)`, 6},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestBigDecimalComparisons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`BigDecimal("0.1") + BigDecimal("0.2") == BigDecimal("0.3")`, true},
+		{`BigDecimal("1.0") == 1`, true},
+		{`1 == BigDecimal("1.0")`, true},
+		{`BigDecimal("0.5") == 0.5`, true},
+		{`BigDecimal("0.5") == Rational(1, 2)`, true},
+		{`BigDecimal("0.5") == "0.5"`, false},
+		{`BigDecimal("0.5") != "0.5"`, true},
+		{`BigDecimal("1.01") > 1`, true},
+		{`BigDecimal("1.01") < BigDecimal("1.1")`, true},
+		{`2 >= BigDecimal("2.00")`, true},
+		{`BigDecimal("0.00").zero?`, true},
+		{`h = { a: 1 }
+		h[BigDecimal("1.50")] = 2
+		h[BigDecimal("1.5")] == 2`, true},
+		{`Marshal.load(Marshal.dump(BigDecimal("1.25"))) == BigDecimal("1.25")`, true},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if !testBooleanObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestBigDecimalRoundingModeIsReset(t *testing.T) {
+	v := New()
+	testEvalWithVM(t, v, `BigDecimal.mode(BigDecimal::ROUND_MODE, "floor")`)
+	v.Reset()
+
+	testIntegerObject(t, testEvalWithVM(t, v, `BigDecimal("2.5").round`), 3)
+}
+
+func TestBigDecimalErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`BigDecimal("abc")`, `ArgumentError: invalid value for BigDecimal(): "abc"`},
+		{`BigDecimal("1__0")`, `ArgumentError: invalid value for BigDecimal(): "1__0"`},
+		{`BigDecimal("e5")`, `ArgumentError: invalid value for BigDecimal(): "e5"`},
+		{`BigDecimal(0.1)`, "ArgumentError: can't omit precision for a Float."},
+		{`BigDecimal([1])`, "TypeError: can't convert Array:[1] into BigDecimal"},
+		{`BigDecimal("1") / 0`, "ZeroDivisionError: divided by 0"},
+		{`BigDecimal("1").div(3, 0)`, "ArgumentError: negative or zero digits: 0"},
+		{`BigDecimal("1").round(1, "sideways")`, "ArgumentError: invalid rounding mode sideways"},
+		{`BigDecimal.mode(1)`, "ArgumentError: unsupported mode option 1"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
@@ -34,7 +34,7 @@ func (b *BooleanObject) ReturnClass() Class {
 var builtinBooleanMethods = []*BuiltInMethod{
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				err := checkArgumentLen(args, BooleanClass, "==")
 
 				if err != nil {
@@ -45,7 +45,7 @@ var builtinBooleanMethods = []*BuiltInMethod{
 				right, ok := args[0].(*BooleanObject)
 
 				if !ok {
-					return wrongTypeError(BooleanClass)
+					return FALSE
 				}
 
 				rightValue := right.Value
@@ -61,7 +61,7 @@ var builtinBooleanMethods = []*BuiltInMethod{
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				err := checkArgumentLen(args, BooleanClass, "!=")
 
 				if err != nil {
@@ -72,7 +72,7 @@ var builtinBooleanMethods = []*BuiltInMethod{
 				right, ok := args[0].(*BooleanObject)
 
 				if !ok {
-					return wrongTypeError(BooleanClass)
+					return TRUE
 				}
 
 				rightValue := right.Value
@@ -88,7 +88,7 @@ var builtinBooleanMethods = []*BuiltInMethod{
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				rightValue := receiver.(*BooleanObject).Value
 
 				if rightValue {
@@ -116,3 +116,23 @@ func initBool() {
 	TRUE = &BooleanObject{Value: true, Class: BooleanClass}
 	FALSE = &BooleanObject{Value: false, Class: BooleanClass}
 }
+
+func toBooleanObject(value bool) *BooleanObject {
+	if value {
+		return TRUE
+	}
+
+	return FALSE
+}
+
+// isTruthy returns false for false and nil, and true for every other object
+func isTruthy(o Object) bool {
+	switch o := o.(type) {
+	case *BooleanObject:
+		return o.Value
+	case *Null:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,121 @@
+package vm
+
+import "strings"
+
+// Breakpoint pauses execution when the program reaches Line of File.
+type Breakpoint struct {
+	File string
+	Line int
+}
+
+// Break is what a breakpoint handler gets when execution reaches a breakpoint. Its Stack, Frames and Backtrace
+// describe the VM as it is, so they should be used before the handler returns.
+type Break struct {
+	Breakpoint *Breakpoint
+	// Frame is the call frame that reached the breakpoint.
+	Frame *CallFrame
+	vm    *VM
+}
+
+// Locals returns local variables of the frame that reached the breakpoint, in the order they're defined.
+func (b *Break) Locals() []Object {
+	locals := []Object{}
+
+	for i := 0; i < b.Frame.LPr; i++ {
+		if p := b.Frame.Local[i]; p != nil {
+			locals = append(locals, p.Target)
+		} else {
+			locals = append(locals, nil)
+		}
+	}
+
+	return locals
+}
+
+// Stack returns values on the stack, from the bottom.
+func (b *Break) Stack() []Object {
+	stack := []Object{}
+
+	for i := 0; i < b.vm.SP; i++ {
+		stack = append(stack, b.vm.Stack.Data[i].Target)
+	}
+
+	return stack
+}
+
+// Frames returns the call frame chain, starting from the innermost frame.
+func (b *Break) Frames() []*CallFrame {
+	frames := []*CallFrame{}
+
+	for i := b.vm.CFP - 1; i >= 0; i-- {
+		if cf := b.vm.CallFrameStack.CallFrames[i]; cf != nil {
+			frames = append(frames, cf)
+		}
+	}
+
+	return frames
+}
+
+// Backtrace returns locations of the frame chain like an exception's backtrace.
+func (b *Break) Backtrace() []string {
+	return b.vm.CallFrameStack.backtrace()
+}
+
+// SetBreakpoint pauses execution whenever the first instruction compiled from line of file is about to be executed,
+// and calls the handler registered with OnBreakpoint. The file can be given by its path or just its base name.
+func (vm *VM) SetBreakpoint(file string, line int) *Breakpoint {
+	if vm.breakpoints == nil {
+		vm.OnInstruction(vm.checkBreakpoints)
+	}
+
+	bp := &Breakpoint{File: file, Line: line}
+	vm.breakpoints = append(vm.breakpoints, bp)
+
+	return bp
+}
+
+// ClearBreakpoint removes a breakpoint returned by SetBreakpoint.
+func (vm *VM) ClearBreakpoint(bp *Breakpoint) {
+	for i, b := range vm.breakpoints {
+		if b == bp {
+			vm.breakpoints = append(vm.breakpoints[:i], vm.breakpoints[i+1:]...)
+			return
+		}
+	}
+}
+
+// OnBreakpoint sets the handler that's called when execution reaches a breakpoint.
+// Execution resumes when the handler returns.
+func (vm *VM) OnBreakpoint(handler func(b *Break)) {
+	vm.breakpointHandler = handler
+}
+
+func (vm *VM) checkBreakpoints(cf *CallFrame, i *Instruction) {
+	if len(vm.breakpoints) == 0 || vm.breakpointHandler == nil {
+		return
+	}
+
+	pc := cf.PC - 1
+	is := cf.InstructionSet
+
+	for _, entry := range is.LineTable {
+		if entry.PC > pc {
+			return
+		}
+
+		if entry.PC < pc {
+			continue
+		}
+
+		for _, bp := range vm.breakpoints {
+			if bp.Line == entry.Line && bp.matchesFile(is.File) {
+				vm.breakpointHandler(&Break{Breakpoint: bp, Frame: cf, vm: vm})
+				return
+			}
+		}
+	}
+}
+
+func (bp *Breakpoint) matchesFile(file string) bool {
+	return file == bp.File || strings.HasSuffix(file, "/"+bp.File)
+}
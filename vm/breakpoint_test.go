@@ -0,0 +1,89 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestBreakpoint(t *testing.T) {
+	input := `
+	def foo(a, b)
+	  c = a + b
+	  c * 2
+	end
+
+	foo(1, 2)
+	foo(3, 4)
+	`
+
+	v := New()
+	bp := v.SetBreakpoint("app.ro", 4)
+	breaks := []*Break{}
+	locals := [][]Object{}
+	frames := []int{}
+	backtraces := [][]string{}
+
+	v.OnBreakpoint(func(b *Break) {
+		breaks = append(breaks, b)
+		locals = append(locals, b.Locals())
+		frames = append(frames, len(b.Frames()))
+		backtraces = append(backtraces, b.Backtrace())
+	})
+
+	evaluated := testEvalFileWithVM(t, v, "/path/to/app.ro", input)
+	testIntegerObject(t, evaluated, 14)
+
+	if len(breaks) != 2 {
+		t.Fatalf("Expect breakpoint to be hit twice. got=%d", len(breaks))
+	}
+
+	if breaks[0].Breakpoint != bp {
+		t.Fatalf("Expect break to have its breakpoint")
+	}
+
+	if frames[0] != 2 || breaks[0].Frame.MethodName() != "foo" {
+		t.Fatalf("Expect break to be in foo called from main. got=%d frames", frames[0])
+	}
+
+	if loc := backtraces[0][0]; loc != "/path/to/app.ro:4:in 'foo'" {
+		t.Fatalf("Expect break to be at app.ro:4. got=%s", loc)
+	}
+
+	expected := [][]int{{1, 2, 3}, {3, 4, 7}}
+
+	for i, values := range expected {
+		if len(locals[i]) != len(values) {
+			t.Fatalf("Expect %d locals. got=%d", len(values), len(locals[i]))
+		}
+
+		for j, value := range values {
+			testIntegerObject(t, locals[i][j], value)
+		}
+	}
+}
+
+func TestClearBreakpoint(t *testing.T) {
+	input := `
+	a = 1
+	b = [a, 2]
+	b
+	`
+
+	v := New()
+	hits := 0
+	v.OnBreakpoint(func(b *Break) {
+		hits++
+
+		if len(b.Stack()) != 0 {
+			t.Fatalf("Expect stack to be empty at the start of a statement. got=%d", len(b.Stack()))
+		}
+	})
+
+	bp := v.SetBreakpoint("app.ro", 2)
+	v.SetBreakpoint("app.ro", 3)
+	v.ClearBreakpoint(bp)
+	testEvalFileWithVM(t, v, "app.ro", input)
+
+	if hits != 1 {
+		t.Fatalf("Expect only the remaining breakpoint to be hit. got=%d", hits)
+	}
+}
@@ -0,0 +1,75 @@
+package vm
+
+// instructionBudget limits how many instructions a block invoked through YieldWithBudget, or the whole VM, can execute.
+type instructionBudget struct {
+	limit     int
+	remaining int
+}
+
+// consume is called before every instruction while a budget is active.
+// Once the budget runs out every instruction raises, so rescue or ensure clauses in the block can't keep it running.
+func (b *instructionBudget) consume(vm *VM) {
+	b.remaining--
+
+	if b.remaining < 0 {
+		vm.raise(BudgetExceededErrorClass, "instruction budget of %d exceeded", b.limit)
+	}
+}
+
+// SetInstructionLimit caps how many more instructions the VM can execute, so hosts can run untrusted scripts without
+// them looping forever. Once the limit is exceeded every instruction raises BudgetExceededError, which Exec returns
+// as error. A limit of 0 or less removes the cap.
+func (vm *VM) SetInstructionLimit(limit int) {
+	if limit <= 0 {
+		vm.budget = nil
+		return
+	}
+
+	vm.budget = &instructionBudget{limit: limit, remaining: limit}
+}
+
+// YieldWithBudget calls the block with args like `yield` does, but aborts it with BudgetExceededError once it executes
+// more than budget instructions. It lets Go hosts run user supplied callbacks without trusting them to terminate.
+// Exceptions that escape the block are returned as error and the VM's stack is restored, so the host can keep using the VM.
+// Nested calls never get more budget than their caller has left.
+func (vm *VM) YieldWithBudget(blockFrame *CallFrame, budget int, args ...Object) (result Object, err error) {
+	cfp, sp := vm.CFP, vm.SP
+	outer := vm.budget
+
+	if outer != nil && outer.remaining < budget {
+		budget = outer.remaining
+	}
+
+	b := &instructionBudget{limit: budget, remaining: budget}
+	vm.budget = b
+
+	defer func() {
+		vm.budget = outer
+
+		if outer != nil {
+			outer.remaining -= b.limit - b.remaining
+		}
+
+		if r := recover(); r != nil {
+			re, ok := r.(*raisedException)
+
+			if !ok {
+				panic(r)
+			}
+
+			vm.unwindTo(cfp, sp)
+			result, err = nil, re
+		}
+	}()
+
+	result = vm.builtInMethodYield(blockFrame, args...).Target
+	vm.Stack.truncate(sp)
+
+	return result, nil
+}
+
+// IsBudgetExceeded reports whether err is returned by YieldWithBudget because the block ran out of its budget.
+func IsBudgetExceeded(err error) bool {
+	re, ok := err.(*raisedException)
+	return ok && re.exception.Class.inheritsFrom(BudgetExceededErrorClass.BaseClass)
+}
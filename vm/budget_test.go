@@ -0,0 +1,174 @@
+package vm
+
+import (
+	"testing"
+)
+
+// newBudgetHost returns a VM with a `Host.run` class method, which calls its block with a budget of 50 instructions
+// and returns "aborted" if the block runs out of it.
+func newBudgetHost() *VM {
+	v := New()
+	host := InitializeClass("Host")
+	host.ClassMethods.Set("run", &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				result, err := vm.YieldWithBudget(blockFrame, 50)
+
+				if err != nil {
+					if IsBudgetExceeded(err) {
+						return InitializeString("aborted")
+					}
+
+					return InitializeString(err.Error())
+				}
+
+				return result
+			}
+		},
+		Name: "run",
+	})
+	v.Constants["Host"] = &Pointer{Target: host}
+
+	return v
+}
+
+func TestYieldWithBudget(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		Host.run do
+		  10 + 1
+		end
+		`, 11},
+		{`
+		def forever
+		  forever
+		end
+
+		Host.run do
+		  forever
+		end
+		`, "aborted"},
+		{`
+		def forever
+		  begin
+		    forever
+		  rescue Exception
+		    forever
+		  ensure
+		    forever
+		  end
+		end
+
+		Host.run do
+		  forever
+		end
+		`, "aborted"},
+		{`
+		Host.run do
+		  raise(TypeError, "foo")
+		end
+		`, "TypeError: foo"},
+		{`
+		def forever
+		  forever
+		end
+
+		a = Host.run do
+		  forever
+		end
+		b = Host.run do
+		  1
+		end
+		a + b.to_s
+		`, "aborted1"},
+		{`
+		def forever
+		  forever
+		end
+
+		Host.run do
+		  Host.run do
+		    1
+		  end
+		  forever
+		end
+		`, "aborted"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWithVM(t, newBudgetHost(), tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestBudgetExceededErrorIsNotStandardError(t *testing.T) {
+	input := `
+	def forever
+	  forever
+	end
+
+	Host.run do
+	  begin
+	    forever
+	  rescue
+	    "rescued"
+	  end
+	end
+	`
+
+	evaluated := testEvalWithVM(t, newBudgetHost(), input)
+	testStringObject(t, evaluated, "aborted")
+}
+
+func TestSetInstructionLimit(t *testing.T) {
+	tests := []string{`
+	def f
+	  f
+	end
+
+	f
+	`, `
+	def f
+	  begin
+	    f
+	  rescue BudgetExceededError => e
+	    f
+	  end
+	end
+
+	f
+	`}
+
+	for _, input := range tests {
+		v := New()
+		v.MaxCallDepth = 0
+		v.SetInstructionLimit(1000)
+		testLoadBytecodes(v, testCompile(t, "limit.ro", input))
+		err := v.Exec()
+
+		if !IsBudgetExceeded(err) {
+			t.Fatalf("Expect Exec to return BudgetExceededError. got=%v", err)
+		}
+	}
+
+	v := New()
+	v.SetInstructionLimit(1000)
+	v.SetInstructionLimit(0)
+	evaluated := testEvalWithVM(t, v, `
+	a = 0
+	[1, 2, 3, 4, 5, 6, 7, 8, 9, 10].each do |i|
+	  a = a + i
+	end
+	a
+	`)
+	testIntegerObject(t, evaluated, 55)
+}
@@ -2,6 +2,7 @@ package vm
 
 import (
 	"fmt"
+	"math/big"
 	"regexp"
 	"strconv"
 	"strings"
@@ -11,6 +12,9 @@ type Parser struct {
 	Line       int
 	LabelCount int
 	VM         *VM
+	File       string
+	// labels is where parsed instruction sets are loaded, it's the VM's own label table if it isn't set
+	labels *labelTable
 }
 
 func NewBytecodeParser() *Parser {
@@ -21,6 +25,12 @@ func (p *Parser) Parse(bytecodes string) []*InstructionSet {
 	iss := []*InstructionSet{}
 	bytecodes = removeEmptyLine(strings.TrimSpace(bytecodes))
 	bytecodesByLine := strings.Split(bytecodes, "\n")
+
+	if strings.HasPrefix(bytecodesByLine[0], "file ") {
+		p.File = strings.TrimPrefix(bytecodesByLine[0], "file ")
+		bytecodesByLine = bytecodesByLine[1:]
+	}
+
 	p.parseSection(iss, bytecodesByLine)
 
 	return iss
@@ -32,6 +42,7 @@ func (p *Parser) parseSection(iss []*InstructionSet, bytecodesByLine []string) {
 
 	// First line is label
 	p.parseLabel(is, bytecodesByLine[0])
+	is.File = p.File
 
 	for _, text := range bytecodesByLine[1:] {
 		count += 1
@@ -39,6 +50,14 @@ func (p *Parser) parseSection(iss []*InstructionSet, bytecodesByLine []string) {
 		if strings.HasPrefix(l, "<") {
 			p.parseSection(iss, bytecodesByLine[count:])
 			break
+		} else if strings.HasPrefix(l, "catch ") {
+			p.parseCatchEntry(is, l)
+		} else if strings.HasPrefix(l, "line ") {
+			p.parseLineEntry(is, l)
+		} else if strings.HasPrefix(l, "arity ") {
+			p.parseArity(is, l)
+		} else if strings.HasPrefix(l, "locals ") {
+			is.locals = strings.Split(strings.TrimPrefix(l, "locals "), " ")
 		} else {
 			p.parseInstruction(is, l)
 		}
@@ -48,9 +67,54 @@ func (p *Parser) parseSection(iss []*InstructionSet, bytecodesByLine []string) {
 }
 
 func (p *Parser) parseLabel(is *InstructionSet, line string) {
-	line = strings.Trim(line, "<")
-	line = strings.Trim(line, ">")
-	p.VM.setLabel(is, line)
+	// labels of operator methods like <Def:<<> have angle brackets in their names
+	line = strings.TrimPrefix(line, "<")
+	line = strings.TrimSuffix(line, ">")
+	labels := p.labels
+
+	if labels == nil {
+		labels = &p.VM.labelTable
+	}
+
+	labels.setLabel(is, line)
+}
+
+func (p *Parser) parseCatchEntry(is *InstructionSet, line string) {
+	tokens := strings.Split(line, " ")
+
+	if len(tokens) != 4 {
+		panic(fmt.Sprintf("Invalid catch entry: %s", line))
+	}
+
+	start, _ := strconv.ParseInt(tokens[1], 0, 64)
+	end, _ := strconv.ParseInt(tokens[2], 0, 64)
+	handler, _ := strconv.ParseInt(tokens[3], 0, 64)
+
+	is.CatchTable = append(is.CatchTable, &CatchEntry{Start: int(start), End: int(end), Handler: int(handler)})
+}
+
+func (p *Parser) parseLineEntry(is *InstructionSet, line string) {
+	tokens := strings.Split(line, " ")
+
+	if len(tokens) != 3 {
+		panic(fmt.Sprintf("Invalid line entry: %s", line))
+	}
+
+	pc, _ := strconv.ParseInt(tokens[1], 0, 64)
+	sourceLine, _ := strconv.ParseInt(tokens[2], 0, 64)
+
+	is.LineTable = append(is.LineTable, &LineEntry{PC: int(pc), Line: int(sourceLine)})
+}
+
+func (p *Parser) parseArity(is *InstructionSet, line string) {
+	tokens := strings.Split(line, " ")
+
+	if len(tokens) != 2 {
+		panic(fmt.Sprintf("Invalid arity: %s", line))
+	}
+
+	arity, _ := strconv.ParseInt(tokens[1], 0, 64)
+	is.Arity = int(arity)
 }
 
 func (p *Parser) parseInstruction(is *InstructionSet, line string) {
@@ -62,17 +126,40 @@ func (p *Parser) parseInstruction(is *InstructionSet, line string) {
 	ln, _ := strconv.ParseInt(lineNum, 0, 64)
 	action := BuiltInActions[OperationType(act)]
 
+	if action == nil {
+		panic(fmt.Sprintf("Unknown command: %s. Line: %d", act, ln))
+	}
+
 	if act == "putstring" {
 		text := strings.Split(line, "\"")[1]
 		params = append(params, text)
+	} else if act == "putfloat" {
+		f, err := strconv.ParseFloat(tokens[2], 64)
+
+		if err != nil {
+			panic(fmt.Sprintf("Invalid float: %s. Line: %d", tokens[2], ln))
+		}
+
+		params = append(params, f)
+	} else if act == "putrational" {
+		r, ok := new(big.Rat).SetString(tokens[2])
+
+		if !ok {
+			panic(fmt.Sprintf("Invalid rational: %s. Line: %d", tokens[2], ln))
+		}
+
+		params = append(params, r)
 	} else if len(tokens) > 2 {
 		rawParams = tokens[2:]
 
 		for _, param := range rawParams {
 			params = append(params, p.parseParam(param))
 		}
-	} else if action == nil {
-		panic(fmt.Sprintf("Unknown command: %s. Line: %d", act, ln))
+	}
+
+	// every getconstant instruction caches its own lookup result
+	if act == GET_CONSTANT {
+		params = append(params, &constantCache{})
 	}
 
 	is.Define(int(ln), action, params...)
@@ -80,6 +167,13 @@ func (p *Parser) parseInstruction(is *InstructionSet, line string) {
 
 func (p *Parser) parseParam(param string) interface{} {
 	integer, e := strconv.ParseInt(param, 0, 64)
+	if e, ok := e.(*strconv.NumError); ok && e.Err == strconv.ErrRange {
+		// integers too large for an int are big integers
+		if n, ok := new(big.Int).SetString(param, 10); ok {
+			return n
+		}
+	}
+
 	if e != nil {
 		return param
 	}
@@ -3,6 +3,7 @@ package vm
 import (
 	"bytes"
 	"fmt"
+	"strings"
 )
 
 type CallFrameStack struct {
@@ -19,58 +20,162 @@ type CallFrame struct {
 	LPr            int
 	IsBlock        bool
 	BlockFrame     *CallFrame
+	// Method is the method the frame is running, it's nil for blocks, class bodies and the program.
+	Method  *Method
+	catchSP map[*CatchEntry]int
+	// locals are the names of the frame's local variables once eval has added some, they're the instruction set's before
+	locals []string
 }
 
+// enterCatchEntries records stack pointer for catch entries that start at current PC,
+// so the stack can be restored to that point if an exception gets rescued.
+func (cf *CallFrame) enterCatchEntries(sp int) {
+	for _, entry := range cf.InstructionSet.CatchTable {
+		if entry.Start != cf.PC {
+			continue
+		}
+
+		if cf.catchSP == nil {
+			cf.catchSP = make(map[*CatchEntry]int)
+		}
+
+		cf.catchSP[entry] = sp
+	}
+}
+
+// insertLCL sets the local variable at index of the frame depth levels up cf's environment chain.
+// Blocks share local variables of the frames they're created in, so a block assigning to an outer variable
+// updates the variable itself, and the change is seen by everyone that captured it.
 func (cf *CallFrame) insertLCL(index, depth int, value Object) {
-	existedLCL := cf.getLCL(index, depth)
+	env := cf.environment(depth)
 
-	if existedLCL != nil {
+	if existedLCL := env.getLCL(index, 0); existedLCL != nil {
 		existedLCL.Target = value
 		return
 	}
 
-	cf.Local = append(cf.Local, nil)
-	copy(cf.Local[index:], cf.Local[index:])
-	cf.Local[index] = &Pointer{Target: value}
+	for len(env.Local) <= index {
+		env.Local = append(env.Local, nil)
+	}
+
+	env.Local[index] = &Pointer{Target: value}
 
-	if index >= cf.LPr {
-		cf.LPr = index + 1
+	if index >= env.LPr {
+		env.LPr = index + 1
 	}
 }
 
+// getLCL returns the local variable at index of the frame depth levels up cf's environment chain, or nil if it isn't set.
 func (cf *CallFrame) getLCL(index, depth int) *Pointer {
-	if depth == 0 {
-		return cf.Local[index]
+	env := cf.environment(depth)
+
+	if index >= len(env.Local) {
+		return nil
 	}
 
-	return cf.BlockFrame.EP.getLCL(index, depth-1)
+	return env.Local[index]
 }
 
-func (cf *CallFrame) inspect() string {
-	if cf.EP != nil {
-		return fmt.Sprintf("Name: %s. is block: %t. EP: %d", cf.InstructionSet.Label.Name, cf.IsBlock, len(cf.EP.Local))
+// environment returns the frame that owns local variables depth levels up from cf.
+// A block's EP is the frame it was created in, so the chain is followed through EP for each level of nesting.
+func (cf *CallFrame) environment(depth int) *CallFrame {
+	env := cf
+
+	for ; depth > 0; depth-- {
+		if env.EP == nil {
+			panic(fmt.Sprintf("Can't find local variable environment of depth %d. Callframe: %s", depth, cf.InstructionSet.Label.Name))
+		}
+
+		env = env.EP
 	}
-	return fmt.Sprintf("Name: %s. is block: %t", cf.InstructionSet.Label.Name, cf.IsBlock)
+
+	return env
 }
 
-func getLCLFromEP(cf *CallFrame, index int) *Pointer {
-	var v *Pointer
+// MethodName returns how cf is named in a backtrace, like `bar`, `<class:Foo>` or `block in bar`.
+func (cf *CallFrame) MethodName() string {
+	label := cf.InstructionSet.Label
+	name := label.Name
 
-	if cf.EP == nil {
-		return nil
+	if i := strings.Index(name, ":"); i >= 0 {
+		name = name[i+1:]
 	}
 
-	v = cf.EP.Local[index]
+	switch label.Type {
+	case LABEL_DEF:
+		return name
+	case LABEL_DEFCLASS:
+		if c, ok := cf.Self.(*RClass); ok && c.IsModule {
+			return fmt.Sprintf("<module:%s>", name)
+		}
+
+		return fmt.Sprintf("<class:%s>", name)
+	case BLOCK:
+		if cf.EP == nil {
+			return "block"
+		}
 
-	if v != nil {
-		return v
+		return "block in " + strings.TrimPrefix(cf.EP.MethodName(), "block in ")
+	default:
+		return "<main>"
 	}
+}
 
-	if cf.EP != nil {
-		return getLCLFromEP(cf.EP, index)
+// Location describes where cf currently is, like `foo.ro:12:in 'bar'`.
+func (cf *CallFrame) Location() string {
+	name := cf.MethodName()
+	file := cf.InstructionSet.File
+
+	if file == "" {
+		return fmt.Sprintf("in '%s'", name)
 	}
 
-	return nil
+	pc := cf.PC - 1
+
+	if pc < 0 {
+		pc = 0
+	}
+
+	if line := cf.InstructionSet.sourceLine(pc); line > 0 {
+		return fmt.Sprintf("%s:%d:in '%s'", file, line, name)
+	}
+
+	return fmt.Sprintf("%s:in '%s'", file, name)
+}
+
+// backtraceEdge is how many innermost and outermost locations are kept when a backtrace gets truncated.
+const backtraceEdge = 50
+
+// backtrace returns locations of every call frame on the stack, starting from the innermost one.
+// Deep stacks, like the ones that raise SystemStackError, only keep their innermost and outermost locations.
+func (cfs *CallFrameStack) backtrace() []string {
+	locations := []string{}
+
+	for i := cfs.VM.CFP - 1; i >= 0; i-- {
+		cf := cfs.CallFrames[i]
+
+		if cf == nil {
+			continue
+		}
+
+		locations = append(locations, cf.Location())
+	}
+
+	if len(locations) > backtraceEdge*2 {
+		skipped := len(locations) - backtraceEdge*2
+		truncated := append([]string{}, locations[:backtraceEdge]...)
+		truncated = append(truncated, fmt.Sprintf("... %d levels...", skipped))
+		locations = append(truncated, locations[len(locations)-backtraceEdge:]...)
+	}
+
+	return locations
+}
+
+func (cf *CallFrame) inspect() string {
+	if cf.EP != nil {
+		return fmt.Sprintf("Name: %s. is block: %t. EP: %d", cf.InstructionSet.Label.Name, cf.IsBlock, len(cf.EP.Local))
+	}
+	return fmt.Sprintf("Name: %s. is block: %t", cf.InstructionSet.Label.Name, cf.IsBlock)
 }
 
 func (cfs *CallFrameStack) Push(cf *CallFrame) {
@@ -78,6 +183,10 @@ func (cfs *CallFrameStack) Push(cf *CallFrame) {
 		panic("Callfame can't be nil!")
 	}
 
+	if max := cfs.VM.MaxCallDepth; max > 0 && cfs.VM.CFP >= max {
+		cfs.VM.raise(SystemStackErrorClass, "stack level too deep")
+	}
+
 	if len(cfs.CallFrames) <= cfs.VM.CFP {
 		cfs.CallFrames = append(cfs.CallFrames, cf)
 	} else {
@@ -85,6 +194,7 @@ func (cfs *CallFrameStack) Push(cf *CallFrame) {
 	}
 
 	cfs.VM.CFP += 1
+	cfs.VM.callHooks(cfs.VM.hooks.call, cf)
 }
 
 func (cfs *CallFrameStack) Pop() *CallFrame {
@@ -98,6 +208,7 @@ func (cfs *CallFrameStack) Pop() *CallFrame {
 
 	cf := cfs.CallFrames[cfs.VM.CFP]
 	cfs.CallFrames[cfs.VM.CFP] = nil
+	cfs.VM.callHooks(cfs.VM.hooks.ret, cf)
 	return cf
 }
 
@@ -1,7 +1,9 @@
 package vm
 
 import (
+	"bytes"
 	"fmt"
+	"math/big"
 )
 
 var (
@@ -10,6 +12,12 @@ var (
 )
 
 func initTopLevelClasses() {
+	ClassClass, ObjectClass = newTopLevelClasses()
+}
+
+// newTopLevelClasses returns a new pair of Class and Object classes. Built in classes inherit the shared pair
+// created by init, while every VM creates its own pair so top level methods don't leak between VMs.
+func newTopLevelClasses() (classClass, objectClass *RClass) {
 	globalMethods := NewEnvironment()
 	classMethods := NewEnvironment()
 
@@ -21,12 +29,23 @@ func initTopLevelClasses() {
 		classMethods.Set(m.Name, m)
 	}
 
-	ClassClass = &RClass{BaseClass: &BaseClass{Name: "Class", Methods: globalMethods, ClassMethods: classMethods}}
-	ObjectClass = &RClass{BaseClass: &BaseClass{Name: "Object", Class: ClassClass, Methods: globalMethods, ClassMethods: NewEnvironment()}}
+	classClass = &RClass{BaseClass: &BaseClass{Name: "Class", Methods: globalMethods, ClassMethods: classMethods}}
+	objectClass = &RClass{BaseClass: &BaseClass{Name: "Object", Class: classClass, Methods: globalMethods, ClassMethods: NewEnvironment()}}
+
+	return
 }
 
 func InitializeClass(name string) *RClass {
-	class := &RClass{BaseClass: &BaseClass{Name: name, Methods: NewEnvironment(), ClassMethods: NewEnvironment(), Class: ClassClass, SuperClass: ObjectClass}}
+	return newClass(name, ClassClass, ObjectClass)
+}
+
+// initializeClass returns a class that inherits the VM's own Object class
+func (vm *VM) initializeClass(name string) *RClass {
+	return newClass(name, vm.classClass, vm.objectClass)
+}
+
+func newClass(name string, classClass, superClass *RClass) *RClass {
+	class := &RClass{BaseClass: &BaseClass{Name: name, Methods: NewEnvironment(), ClassMethods: NewEnvironment(), Class: classClass, SuperClass: superClass}}
 	//classScope := &Scope{Self: class, Env: NewClosedEnvironment(scope.Env)}
 	//class.Scope = classScope
 
@@ -53,6 +72,14 @@ type BaseClass struct {
 	SuperClass   *RClass
 	Class        *RClass
 	Singleton    bool
+	IsModule     bool
+	// origin is the module a class inserted into ancestors by include, or extend if extended is true, stands for
+	origin   *RClass
+	extended bool
+	// singletonClass is created when it's first needed, its methods are the class's ClassMethods
+	singletonClass *RClass
+	// structMembers are the members of a class created by Struct.new
+	structMembers []string
 }
 
 func (c *BaseClass) Type() ObjectType {
@@ -60,6 +87,10 @@ func (c *BaseClass) Type() ObjectType {
 }
 
 func (c *BaseClass) Inspect() string {
+	if c.IsModule {
+		return "<Module:" + c.Name + ">"
+	}
+
 	return "<Class:" + c.Name + ">"
 }
 
@@ -71,7 +102,14 @@ func (c *BaseClass) LookupClassMethod(method_name string) Object {
 			return c.SuperClass.LookupClassMethod(method_name)
 		} else {
 			if c.Class != nil {
-				return c.Class.LookupClassMethod(method_name)
+				method := c.Class.LookupClassMethod(method_name)
+
+				// Classes are objects too, so they respond to Object's methods like top level methods.
+				if method == nil {
+					method = c.Class.LookupInstanceMethod(method_name)
+				}
+
+				return method
 			}
 			return nil
 		}
@@ -97,17 +135,28 @@ func (c *BaseClass) LookupInstanceMethod(method_name string) Object {
 	return method
 }
 
+// SetSingletonMethod defines a class method. It's stored in the class's ClassMethods,
+// which are the methods of its singleton class.
 func (c *BaseClass) SetSingletonMethod(name string, method *Method) {
-	if c.SuperClass.Singleton {
-		c.SuperClass.ClassMethods.Set(name, method)
+	c.ClassMethods.Set(name, method)
+	method.Owner = c.ensureSingletonClass()
+}
+
+// inheritsFrom returns true if c is class or one of its subclasses
+func (c *BaseClass) inheritsFrom(class *BaseClass) bool {
+	for current := c; current != nil; {
+		if current == class || current.origin != nil && !current.extended && current.origin.BaseClass == class {
+			return true
+		}
+
+		if current.SuperClass == nil {
+			return false
+		}
+
+		current = current.SuperClass.BaseClass
 	}
 
-	class := InitializeClass(fmt.Sprintf("%s:singleton", c.Name))
-	class.Singleton = true
-	class.ClassMethods.Set(name, method)
-	class.SuperClass = c.SuperClass
-	class.Class = ClassClass
-	c.SuperClass = class
+	return false
 }
 
 func (c *BaseClass) ReturnClass() Class {
@@ -121,19 +170,94 @@ func (c *BaseClass) ReturnName() string {
 var BuiltinGlobalMethods = []*BuiltInMethod{
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				vm.puts(vm.stdout, args)
+				return NULL
+			}
+		},
+		Name: "puts",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				vm.print(vm.stdout, args)
+				return NULL
+			}
+		},
+		Name: "print",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				vm.printf(vm.stdout, args)
+				return NULL
+			}
+		},
+		Name: "printf",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				for _, arg := range args {
-					fmt.Println(arg.Inspect())
+					vm.writeString(vm.stdout, vm.inspect(arg)+"\n")
+				}
+
+				return vm.printedArguments(args)
+			}
+		},
+		Name: "p",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				for _, arg := range args {
+					vm.writeString(vm.stdout, vm.prettyInspect(arg, 0, 0)+"\n")
+				}
+
+				return vm.printedArguments(args)
+			}
+		},
+		Name: "pp",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) == 0 {
+					vm.raise(RuntimeErrorClass, "unhandled exception")
+				}
+
+				switch e := args[0].(type) {
+				case *StringObject:
+					vm.raise(RuntimeErrorClass, "%s", e.Value)
+				case *RClass:
+					if !e.inheritsFrom(ExceptionClass.BaseClass) {
+						vm.raise(TypeErrorClass, "exception class/object expected")
+					}
+
+					message := e.Name
+
+					if len(args) > 1 {
+						message = vm.toS(args[1])
+					}
+
+					vm.raise(e, "%s", message)
+				case *RObject:
+					if !e.Class.inheritsFrom(ExceptionClass.BaseClass) {
+						vm.raise(TypeErrorClass, "exception class/object expected")
+					}
+
+					vm.raiseException(e)
 				}
 
+				vm.raise(TypeErrorClass, "exception class/object expected")
 				return NULL
 			}
 		},
-		Name: "puts",
+		Name: "raise",
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				switch r := receiver.(type) {
 				case BaseObject:
 					return r.ReturnClass()
@@ -148,21 +272,510 @@ var BuiltinGlobalMethods = []*BuiltInMethod{
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				return FALSE
 			}
 		},
 		Name: "!",
 	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return InitializeString(vm.defaultInspect(receiver))
+			}
+		},
+		Name: "to_s",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return InitializeString(vm.defaultInspect(receiver))
+			}
+		},
+		Name: "inspect",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return vm.newProc(blockFrame)
+			}
+		},
+		Name: "proc",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return vm.evalSource(args[0], receiver.(BaseObject))
+			}
+		},
+		Name: "eval",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				self := receiver.(BaseObject)
+
+				if len(args) > 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				if len(args) == 1 {
+					return vm.evalSource(args[0], self)
+				}
+
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (instance_eval)")
+				}
+
+				return vm.yieldWithSelf(blockFrame, self, self).Target
+			}
+		},
+		Name: "instance_eval",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return toBooleanObject(vm.require(vm.stringArgument(args[0])))
+			}
+		},
+		Name: "require",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return toBooleanObject(vm.requireRelative(vm.stringArgument(args[0]), vm.CallFrameStack.Top()))
+			}
+		},
+		Name: "require_relative",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) < 1 {
+					return newError("Expect at least 1 argument. got=%d", len(args))
+				}
+
+				return InitializeString(vm.format(vm.stringArgument(args[0]), args[1:]))
+			}
+		},
+		Name: "format",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) < 1 {
+					return newError("Expect at least 1 argument. got=%d", len(args))
+				}
+
+				return InitializeString(vm.format(vm.stringArgument(args[0]), args[1:]))
+			}
+		},
+		Name: "sprintf",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return vm.random(vm.defaultRandom, args, true)
+			}
+		},
+		Name: "rand",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				// srand returns the previous seed, so a program can restore it later
+				previous := vm.defaultRandom.seed
+				vm.defaultRandom = InitializeRandom(vm.seedArgument(args))
+
+				return vm.initInteger(int(previous))
+			}
+		},
+		Name: "srand",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				// exit raises SystemExit, so ensure clauses run and the program can rescue it
+				e := InitializeException(SystemExitClass, "exit")
+				e.InstanceVariables.Set("@status", vm.initInteger(vm.exitStatusArgument(args, 0)))
+				vm.raiseException(e)
+
+				return NULL
+			}
+		},
+		Name: "exit",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				panic(&exitNow{status: vm.exitStatusArgument(args, 1)})
+			}
+		},
+		Name: "exit!",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				proc := vm.newProc(blockFrame)
+				vm.exitHandlers = append(vm.exitHandlers, blockFrame)
+
+				return proc
+			}
+		},
+		Name: "at_exit",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				status, err := vm.runCommand(vm.command(args))
+
+				// like Ruby, system returns nil if the command can't be run
+				if err != nil {
+					return NULL
+				}
+
+				return toBooleanObject(status.exitStatus == 0)
+			}
+		},
+		Name: "system",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				var out bytes.Buffer
+				cmd := vm.command(args)
+				cmd.Stdout = &out
+
+				if _, err := vm.runCommand(cmd); err != nil {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				return InitializeString(out.String())
+			}
+		},
+		// `cmd` calls this method to capture what cmd writes to stdout
+		Name: "`",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				switch arg := args[0].(type) {
+				case *IntegerObject:
+					return arg
+				case *FloatObject:
+					return vm.floatToInteger(arg.Value)
+				case *RationalObject:
+					return vm.initBigInteger(new(big.Int).Quo(arg.value.Num(), arg.value.Denom()))
+				case *StringObject:
+					n, ok := parseInteger(arg.Value)
+
+					if !ok {
+						vm.raise(ArgumentErrorClass, "invalid value for Integer(): %q", arg.Value)
+					}
+
+					return vm.initBigInteger(n)
+				}
+
+				vm.raise(TypeErrorClass, "can't convert %s into Integer", vm.inspectForError(args[0]))
+				return NULL
+			}
+		},
+		Name: "Integer",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				if f, ok := toFloat(args[0]); ok {
+					return InitializeFloat(f)
+				}
+
+				s, ok := args[0].(*StringObject)
+
+				if !ok {
+					vm.raise(TypeErrorClass, "can't convert %s into Float", vm.inspectForError(args[0]))
+				}
+
+				f, ok := parseFloat(s.Value)
+
+				if !ok {
+					vm.raise(ArgumentErrorClass, "invalid value for Float(): %q", s.Value)
+				}
+
+				return InitializeFloat(f)
+			}
+		},
+		Name: "Float",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 && len(args) != 2 {
+					return newError("Expect 1 or 2 arguments. got=%d", len(args))
+				}
+
+				// Rational(a, b) is a / b, where a and b can also be floats, rationals or strings like "1/3"
+				value := big.NewRat(1, 1)
+
+				for i, arg := range args {
+					r, ok := vm.toRational(arg, true)
+
+					if !ok {
+						vm.raise(TypeErrorClass, "can't convert %s into Rational", vm.inspectForError(arg))
+					}
+
+					if i == 0 {
+						value = r
+					} else {
+						value = vm.quoRational(value, r)
+					}
+				}
+
+				return vm.initRational(value)
+			}
+		},
+		Name: "Rational",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 && len(args) != 2 {
+					return newError("Expect 1 or 2 arguments. got=%d", len(args))
+				}
+
+				// floats aren't exact, so how many significant digits of them to keep has to be given
+				if f, ok := args[0].(*FloatObject); ok {
+					if len(args) == 1 {
+						vm.raise(ArgumentErrorClass, "can't omit precision for a Float.")
+					}
+
+					return vm.floatToDecimal(f.Value, vm.integerArgument(args[1]))
+				}
+
+				switch arg := args[0].(type) {
+				case *BigDecimalObject, *IntegerObject:
+					d, _ := vm.toDecimal(arg)
+					return d
+				case *StringObject:
+					d, ok := vm.parseDecimal(arg.Value)
+
+					if !ok {
+						vm.raise(ArgumentErrorClass, "invalid value for BigDecimal(): %q", arg.Value)
+					}
+
+					return d
+				}
+
+				vm.raise(TypeErrorClass, "can't convert %s into BigDecimal", vm.inspectForError(args[0]))
+				return NULL
+			}
+		},
+		Name: "BigDecimal",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (define_singleton_method)")
+				}
+
+				name := vm.nameArgument(args[0])
+				method := &Method{Name: name, InstructionSet: blockFrame.InstructionSet, block: blockFrame}
+				vm.defineSingletonMethod(receiver, name, method)
+
+				return InternSymbol(name)
+			}
+		},
+		Name: "define_singleton_method",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				names := []Object{}
+
+				if ro, ok := receiver.(*RObject); ok {
+					for _, name := range ro.InstanceVariables.Names() {
+						names = append(names, InternSymbol(name))
+					}
+				}
+
+				return InitializeArray(names)
+			}
+		},
+		Name: "instance_variables",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				name := vm.instanceVariableName(args[0])
+
+				if ro, ok := receiver.(*RObject); ok {
+					if v, ok := ro.InstanceVariables.Get(name); ok {
+						return v
+					}
+				}
+
+				return NULL
+			}
+		},
+		Name: "instance_variable_get",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 2 {
+					return newError("Expect 2 arguments. got=%d", len(args))
+				}
+
+				name := vm.instanceVariableName(args[0])
+				ro, ok := receiver.(*RObject)
+
+				if !ok {
+					vm.raise(TypeErrorClass, "can't modify instance variables of %s", receiver.Inspect())
+				}
+
+				vm.checkFrozen(ro)
+
+				return ro.InstanceVariables.Set(name, args[1])
+			}
+		},
+		Name: "instance_variable_set",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if f, ok := receiver.(freezable); ok {
+					f.freeze()
+				}
+
+				return receiver
+			}
+		},
+		Name: "freeze",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return toBooleanObject(isFrozen(receiver))
+			}
+		},
+		Name: "frozen?",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return vm.initInteger(vm.hashValue(receiver))
+			}
+		},
+		Name: "hash",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return toBooleanObject(vm.eql(receiver, args[0]))
+			}
+		},
+		Name: "eql?",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return toBooleanObject(receiver == args[0])
+			}
+		},
+		Name: "==",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				// != is the opposite of ==, so classes only need to define ==
+				r := receiver.(BaseObject)
+				equal := receiver == args[0]
+
+				if m := vm.lookupMethod(r, "=="); m != nil {
+					equal = isTruthy(vm.callMethod(r, m, args[0]))
+				}
+
+				return toBooleanObject(!equal)
+			}
+		},
+		Name: "!=",
+	},
 }
 
 var BuiltinClassMethods = []*BuiltInMethod{
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
-				class := receiver.(*RClass)
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				class, ok := receiver.(*RClass)
+
+				// built in classes like Integer have their own constructors or none at all
+				if !ok {
+					vm.raise(NoMethodErrorClass, "undefined method `new' for %s", vm.inspectForError(receiver))
+				}
+
+				if class.IsModule {
+					vm.raise(NoMethodErrorClass, "undefined method `new' for module %s", class.Name)
+				}
+
 				instance := InitializeInstance(class)
-				initMethod := class.LookupInstanceMethod("initialize")
+				vm.track(instance)
+				initMethod := vm.lookupInstanceMethod(class, "initialize")
 
 				if initMethod != nil {
 					instance.InitializeMethod = initMethod.(*Method)
@@ -175,7 +788,7 @@ var BuiltinClassMethods = []*BuiltInMethod{
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				name := receiver.(Class).ReturnName()
 				nameString := InitializeString(name)
 				return nameString
@@ -183,4 +796,97 @@ var BuiltinClassMethods = []*BuiltInMethod{
 		},
 		Name: "name",
 	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				name, ok := args[0].(*StringObject)
+
+				if !ok {
+					return wrongTypeError(StringClass)
+				}
+
+				constant, ok := vm.lookupConstant(name.Value, nil)
+
+				if !ok {
+					vm.raise(NameErrorClass, "uninitialized constant %s", name.Value)
+				}
+
+				return constant.Target
+			}
+		},
+		Name: "const_get",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				class := vm.includableClass(receiver.(Class))
+
+				for _, arg := range args {
+					vm.include(class, vm.moduleArgument(arg))
+				}
+
+				return class
+			}
+		},
+		Name: "include",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				class := vm.includableClass(receiver.(Class))
+
+				for _, arg := range args {
+					vm.extend(class, vm.moduleArgument(arg))
+				}
+
+				return class
+			}
+		},
+		Name: "extend",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) < 1 || len(args) > 2 {
+					return newError("Expect 1 or 2 arguments. got=%d", len(args))
+				}
+
+				class := receiver.(Class)
+				name := vm.nameArgument(args[0])
+
+				// the method's body can be given as a block or a Proc
+				if len(args) == 2 {
+					p, ok := args[1].(*ProcObject)
+
+					if !ok {
+						vm.raise(TypeErrorClass, "wrong argument type %s (expected Proc)", vm.inspectForError(args[1]))
+					}
+
+					blockFrame = p.blockFrame
+				}
+
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (define_method)")
+				}
+
+				method := &Method{Name: name, Argc: blockFrame.InstructionSet.Arity, InstructionSet: blockFrame.InstructionSet, block: blockFrame}
+				vm.defineMethod(class, method)
+
+				return InternSymbol(name)
+			}
+		},
+		Name: "define_method",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return InitializeArray(vm.ancestors(receiver.(Class)))
+			}
+		},
+		Name: "ancestors",
+	},
 }
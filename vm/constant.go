@@ -0,0 +1,44 @@
+package vm
+
+// constantCache remembers what a getconstant instruction resolved to.
+// It's valid as long as serial equals the VM's constantSerial, which is bumped whenever a constant is (re)defined.
+type constantCache struct {
+	serial int
+	value  *Pointer
+}
+
+func (c *constantCache) String() string {
+	return "<constant cache>"
+}
+
+// SetConstant defines or redefines constant name. Hosts should use it instead of writing to Constants
+// once the program is running, so constant lookups cached by getconstant instructions are invalidated.
+func (vm *VM) SetConstant(name string, value Object) {
+	vm.setConstant(name, &Pointer{Target: value})
+}
+
+func (vm *VM) setConstant(name string, p *Pointer) {
+	// anonymous classes, like the ones Struct.new creates, are named after the first constant they're assigned to
+	if c, ok := p.Target.(*RClass); ok && c.Name == "" {
+		c.Name = name
+	}
+
+	vm.Constants[name] = p
+	vm.constantSerial++
+}
+
+// lookupConstant resolves constant name and caches the result in cache if it's not nil.
+func (vm *VM) lookupConstant(name string, cache *constantCache) (*Pointer, bool) {
+	if cache != nil && cache.value != nil && cache.serial == vm.constantSerial {
+		return cache.value, true
+	}
+
+	constant, ok := vm.Constants[name]
+
+	if ok && cache != nil {
+		cache.serial = vm.constantSerial
+		cache.value = constant
+	}
+
+	return constant, ok
+}
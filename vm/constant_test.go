@@ -0,0 +1,79 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestConstantCacheInvalidation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		def foo
+		  Value
+		end
+
+		Value = 1
+		a = foo
+		Value = 10
+		a + foo
+		`, 11},
+		{`
+		class Foo
+		  def value
+		    1
+		  end
+		end
+
+		def build
+		  Foo.new
+		end
+
+		a = build.value
+
+		class Foo
+		  def value
+		    100
+		  end
+		end
+
+		a + build.value
+		`, 101},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestConstantCacheIsFilled(t *testing.T) {
+	input := `
+	def foo
+	  Value
+	end
+
+	Value = 1
+	foo
+	foo
+	`
+
+	v := New()
+	evaluated := testEvalWithVM(t, v, input)
+	testIntegerObject(t, evaluated, 1)
+
+	is := v.LabelTable[LABEL_DEF]["foo"][0]
+	cache := is.Instructions[0].Params[1].(*constantCache)
+
+	if cache.serial != v.constantSerial || cache.value != v.Constants["Value"] {
+		t.Fatalf("Expect getconstant's cache to hold the current value of Value")
+	}
+
+	// constants changed by the host invalidate caches too
+	v.SetConstant("Value", InitilaizeInteger(2))
+
+	if p, _ := v.lookupConstant("Value", cache); p.Target.(*IntegerObject).Value != 2 {
+		t.Fatalf("Expect SetConstant to invalidate constant caches. got=%s", p.Target.Inspect())
+	}
+}
@@ -0,0 +1,28 @@
+//go:build go1.7
+// +build go1.7
+
+package vm
+
+import "context"
+
+// ExecContext is like Exec, but stops the program once ctx is cancelled or its deadline passes and returns ctx.Err().
+// Cancellation is checked between instructions, so a builtin method blocking on something like Mutex#lock
+// isn't interrupted until it returns. The program can't rescue it, but ensure clauses aren't run either.
+func (vm *VM) ExecContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := ctx.Done()
+
+	// contexts like context.Background() can never be cancelled
+	if done == nil {
+		return vm.Exec()
+	}
+
+	outer := vm.interrupter
+	vm.interrupter = &interrupter{done: done, err: ctx.Err, countdown: interruptCheckInterval}
+	defer func() { vm.interrupter = outer }()
+
+	return vm.Exec()
+}
@@ -0,0 +1,104 @@
+//go:build go1.7
+// +build go1.7
+
+package vm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// runawayProgram takes much longer than any test would wait and tries to rescue everything
+const runawayProgram = `
+a = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]
+i = 0
+
+begin
+  a.each do |x1|
+    a.each do |x2|
+      a.each do |x3|
+        a.each do |x4|
+          a.each do |x5|
+            a.each do |x6|
+              a.each do |x7|
+                i = i + 1
+              end
+            end
+          end
+        end
+      end
+    end
+  end
+rescue Exception
+  -1
+end
+`
+
+func TestExecContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	v := New()
+	testLoadBytecodes(v, testCompile(t, "", runawayProgram))
+
+	if err := v.ExecContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Expect ExecContext to return %v. got=%v", context.DeadlineExceeded, err)
+	}
+
+	if v.CFP != 0 {
+		t.Fatalf("Expect call frames to be unwound. got CFP=%d", v.CFP)
+	}
+}
+
+func TestExecContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	v := New()
+	testLoadBytecodes(v, testCompile(t, "", runawayProgram))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	if err := v.ExecContext(ctx); err != context.Canceled {
+		t.Fatalf("Expect ExecContext to return %v. got=%v", context.Canceled, err)
+	}
+
+	if v.interrupter != nil {
+		t.Fatalf("Expect the interrupter to be removed after ExecContext returns")
+	}
+}
+
+func TestExecContextCancelledBeforeExec(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	v := New()
+	testLoadBytecodes(v, testCompile(t, "", "10"))
+
+	if err := v.ExecContext(ctx); err != context.Canceled {
+		t.Fatalf("Expect ExecContext to return %v. got=%v", context.Canceled, err)
+	}
+}
+
+func TestExecContextFinishes(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	v := New()
+	testLoadBytecodes(v, testCompile(t, "", `
+	def foo(n)
+	  n * 2
+	end
+
+	foo(21)
+	`))
+
+	if err := v.ExecContext(ctx); err != nil {
+		t.Fatalf("Expect ExecContext to finish. got=%v", err)
+	}
+
+	testIntegerObject(t, v.Stack.Top().Target, 42)
+}
@@ -0,0 +1,152 @@
+package vm
+
+import (
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// floatPattern matches decimal floats like "1", "-1.5", ".5" and "1_000.25e-3". Unlike Go, Ruby doesn't accept
+// "Inf", "NaN" or hexadecimal floats, and needs digits after the point.
+var floatPattern = regexp.MustCompile(`^[+-]?(\d+(_\d+)*(\.\d+(_\d+)*)?|\.\d+(_\d+)*)([eE][+-]?\d+)?`)
+
+// radixPrefixes are the prefixes numbers in bases other than 10 can be written with.
+var radixPrefixes = map[int]string{2: "0b", 8: "0o", 16: "0x"}
+
+// radixArgument returns the base given by the optional argument of String#to_i and Integer#to_s, which is 10 without
+// one. It raises ArgumentError unless the base is between 2 and 36.
+func (vm *VM) radixArgument(args []Object) int {
+	if len(args) == 0 {
+		return 10
+	}
+
+	base := vm.integerArgument(args[0])
+
+	if base < 2 || base > 36 {
+		vm.raise(ArgumentErrorClass, "invalid radix %d", base)
+	}
+
+	return base
+}
+
+// leadingInteger parses the integer s starts with, ignoring leading whitespace and anything after the integer.
+// It's 0 if s doesn't start with one, like String#to_i. The integer can have the prefix of base, like "0x" in base 16.
+func leadingInteger(s string, base int) *big.Int {
+	s = strings.TrimLeft(s, " \t\n\v\f\r")
+	sign := ""
+
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+
+	if prefix, ok := radixPrefixes[base]; ok && len(s) > 2 && strings.ToLower(s[:2]) == prefix && isDigit(s[2], base) {
+		s = s[2:]
+	}
+
+	var digits []byte
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '_' && len(digits) > 0 && i+1 < len(s) && isDigit(s[i+1], base) {
+			continue
+		}
+
+		if !isDigit(s[i], base) {
+			break
+		}
+
+		digits = append(digits, s[i])
+	}
+
+	n, ok := new(big.Int).SetString(sign+string(digits), base)
+
+	if !ok {
+		return new(big.Int)
+	}
+
+	return n
+}
+
+// leadingFloat parses the float s starts with like leadingInteger, it's what String#to_f returns.
+func leadingFloat(s string) float64 {
+	literal := floatPattern.FindString(strings.TrimLeft(s, " \t\n\v\f\r"))
+	f, _ := strconv.ParseFloat(strings.Replace(literal, "_", "", -1), 64)
+
+	return f
+}
+
+// parseInteger parses s strictly like Kernel#Integer. Besides decimals, it accepts "0x", "0b" and "0o" prefixes and
+// octals starting with "0", and underscores between digits. Surrounding whitespace is ignored.
+func parseInteger(s string) (*big.Int, bool) {
+	s = strings.TrimSpace(s)
+	sign := ""
+
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+
+	base := 10
+	lower := strings.ToLower(s)
+
+	switch {
+	case strings.HasPrefix(lower, "0x"):
+		base, s = 16, s[2:]
+	case strings.HasPrefix(lower, "0b"):
+		base, s = 2, s[2:]
+	case strings.HasPrefix(lower, "0o"):
+		base, s = 8, s[2:]
+	case len(s) > 1 && s[0] == '0':
+		base, s = 8, s[1:]
+	}
+
+	if !validDigits(s, base) {
+		return nil, false
+	}
+
+	return new(big.Int).SetString(sign+strings.Replace(s, "_", "", -1), base)
+}
+
+// parseFloat parses s strictly like Kernel#Float.
+func parseFloat(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+
+	if floatPattern.FindString(s) != s {
+		return 0, false
+	}
+
+	f, err := strconv.ParseFloat(strings.Replace(s, "_", "", -1), 64)
+
+	return f, err == nil
+}
+
+// validDigits reports whether s is made of digits of base, with single underscores only between digits.
+func validDigits(s string, base int) bool {
+	if s == "" || s[0] == '_' || s[len(s)-1] == '_' || strings.Contains(s, "__") {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '_' && !isDigit(s[i], base) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isDigit(c byte, base int) bool {
+	var n int
+
+	switch {
+	case c >= '0' && c <= '9':
+		n = int(c - '0')
+	case c >= 'a' && c <= 'z':
+		n = int(c-'a') + 10
+	case c >= 'A' && c <= 'Z':
+		n = int(c-'A') + 10
+	default:
+		return false
+	}
+
+	return n < base
+}
@@ -0,0 +1,131 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestStringToInteger(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`"42".to_i`, 42},
+		{`"  -42abc".to_i`, -42},
+		{`"+7".to_i`, 7},
+		{`"1_000".to_i`, 1000},
+		{`"1__000".to_i`, 1},
+		{`"abc".to_i`, 0},
+		{`"12.5".to_i`, 12},
+		{`"ff".to_i(16)`, 255},
+		{`"101".to_i(2)`, 5},
+		{`"z".to_i(36)`, 35},
+		{`"0x1f".to_i(16)`, 31},
+		{`"-0b101".to_i(2)`, -5},
+		{`"0o17".to_i(8)`, 15},
+		{`"0x1f".to_i`, 0},
+		{`"0xg".to_i(16)`, 0},
+		{`Integer("42")`, 42},
+		{`Integer(" -42 ")`, -42},
+		{`Integer("0x1A")`, 26},
+		{`Integer("0b101")`, 5},
+		{`Integer("0o17")`, 15},
+		{`Integer("017")`, 15},
+		{`Integer("1_000")`, 1000},
+		{`Integer(3.9)`, 3},
+		{`Integer(5)`, 5},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestIntegerToStringWithBase(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`255.to_s`, "255"},
+		{`255.to_s(16)`, "ff"},
+		{`5.to_s(2)`, "101"},
+		{`(0 - 255).to_s(16)`, "-ff"},
+		{`35.to_s(36)`, "z"},
+		{`0.to_s(2)`, "0"},
+		{`"ff".to_i(16).to_s(2)`, "11111111"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testStringObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestStringToFloat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{`"1.5".to_f`, 1.5},
+		{`"  -1.5e3xyz".to_f`, -1500},
+		{`"1_000.5".to_f`, 1000.5},
+		{`"3".to_f`, 3},
+		{`"abc".to_f`, 0},
+		{`"1.".to_f`, 1},
+		{`".5".to_f`, 0.5},
+		{`"-.5".to_f`, -0.5},
+		{`"+.25e2".to_f`, 25},
+		{`".".to_f`, 0},
+		{`Float(".5")`, 0.5},
+		{`Float("-.5")`, -0.5},
+		{`Float("1.5")`, 1.5},
+		{`Float(" 2e-2 ")`, 0.02},
+		{`Float("10")`, 10},
+		{`Float(3)`, 3},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testFloatObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestConversionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Integer("12abc")`, `ArgumentError: invalid value for Integer(): "12abc"`},
+		{`Integer("1__0")`, `ArgumentError: invalid value for Integer(): "1__0"`},
+		{`Integer("089")`, `ArgumentError: invalid value for Integer(): "089"`},
+		{`Integer("abc")`, `ArgumentError: invalid value for Integer(): "abc"`},
+		{`Integer(1.0 / 0)`, "FloatDomainError: Infinity"},
+		{`Integer(true)`, "TypeError: can't convert true into Integer"},
+		{`Float("1.5x")`, `ArgumentError: invalid value for Float(): "1.5x"`},
+		{`Float("Inf")`, `ArgumentError: invalid value for Float(): "Inf"`},
+		{`Float("1.")`, `ArgumentError: invalid value for Float(): "1."`},
+		{`Float(".")`, `ArgumentError: invalid value for Float(): "."`},
+		{`Float(true)`, "TypeError: can't convert true into Float"},
+		{`"1".to_i(1)`, "ArgumentError: invalid radix 1"},
+		{`1.to_s(37)`, "ArgumentError: invalid radix 37"},
+		{`1.to_s("2")`, "TypeError: wrong argument type 2 (expected Integer)"},
+		{`"1".to_i("a")`, "TypeError: wrong argument type a (expected Integer)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"runtime/debug"
+)
+
+// InternalError is returned by Exec when the VM itself fails, like popping an empty stack or running malformed bytecode,
+// rather than the program raising an exception. It keeps what's needed to report the failure as a bug.
+type InternalError struct {
+	Value interface{}
+	// State is a snapshot of the VM's call frames and stack at the moment of the failure.
+	State   string
+	GoStack []byte
+}
+
+func (e *InternalError) Error() string {
+	if err, ok := e.Value.(error); ok {
+		return fmt.Sprintf("internal error: %s", err.Error())
+	}
+
+	return fmt.Sprintf("internal error: %v", e.Value)
+}
+
+// CrashReport returns a diagnostic bundle of the failure, the program's source and bytecode, the VM's state and the Go stack.
+// Attach it to bug reports, it has everything needed to reproduce the failure.
+func (e *InternalError) CrashReport(source, bytecodes string) string {
+	var out bytes.Buffer
+
+	sections := []struct {
+		title   string
+		content string
+	}{
+		{"Error", e.Error()},
+		{"Source", source},
+		{"Bytecode", bytecodes},
+		{"VM state", e.State},
+		{"Go stack", string(e.GoStack)},
+	}
+
+	for _, s := range sections {
+		if s.content == "" {
+			s.content = "(not available)"
+		}
+
+		out.WriteString(fmt.Sprintf("==== %s ====\n", s.title))
+		out.WriteString(s.content)
+
+		if s.content[len(s.content)-1] != '\n' {
+			out.WriteString("\n")
+		}
+
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+// IsInternalError reports whether err is a failure of the VM rather than an exception raised by the program.
+func IsInternalError(err error) bool {
+	_, ok := err.(*InternalError)
+	return ok
+}
+
+// newInternalError must be called while the VM is still in the state it failed in, before the stack gets unwound.
+func (vm *VM) newInternalError(r interface{}) *InternalError {
+	return &InternalError{Value: r, State: vm.snapshot(), GoStack: debug.Stack()}
+}
+
+// snapshot describes every call frame with its current instruction, followed by the values on the stack.
+func (vm *VM) snapshot() string {
+	var out bytes.Buffer
+
+	out.WriteString(fmt.Sprintf("Call frames (CFP: %d):\n", vm.CFP))
+
+	for i := vm.CFP - 1; i >= 0; i-- {
+		cf := vm.CallFrameStack.CallFrames[i]
+
+		if cf == nil {
+			out.WriteString(fmt.Sprintf("  %d: nil\n", i))
+			continue
+		}
+
+		out.WriteString(fmt.Sprintf("  %d: %s PC: %d self: %s\n", i, cf.Location(), cf.PC, inspectObject(cf.Self)))
+
+		if pc := cf.PC - 1; pc >= 0 && pc < len(cf.InstructionSet.Instructions) {
+			out.WriteString(fmt.Sprintf("     at %d %s", pc, cf.InstructionSet.Instructions[pc].Inspect()))
+		}
+	}
+
+	out.WriteString(fmt.Sprintf("Stack (SP: %d):\n", vm.SP))
+
+	for i := len(vm.Stack.Data) - 1; i >= 0; i-- {
+		var value string
+
+		if p := vm.Stack.Data[i]; p != nil {
+			value = fmt.Sprintf("%s (%T)", inspectObject(p.Target), p.Target)
+		} else {
+			value = "nil"
+		}
+
+		if i == vm.SP-1 {
+			value += " <- top"
+		}
+
+		out.WriteString(fmt.Sprintf("  %d: %s\n", i, value))
+	}
+
+	return out.String()
+}
+
+// inspectObject doesn't trust the object to be valid, since the VM is already in a broken state.
+func inspectObject(o Object) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = fmt.Sprintf("<can't inspect %T: %v>", o, r)
+		}
+	}()
+
+	if o == nil {
+		return "nil"
+	}
+
+	return o.Inspect()
+}
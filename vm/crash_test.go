@@ -0,0 +1,57 @@
+package vm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInternalErrorCrashReport(t *testing.T) {
+	bytecodes := `
+<ProgramStart>
+0 putobject 1
+1 pop
+2 pop
+3 leave
+`
+	v := New()
+	testLoadBytecodes(v, bytecodes)
+	err := v.Exec()
+
+	if !IsInternalError(err) {
+		t.Fatalf("Expect Exec to return an internal error. got=%v", err)
+	}
+
+	ie := err.(*InternalError)
+
+	if !strings.Contains(ie.State, "at 2 pop") {
+		t.Fatalf("Expect VM state to have the failing instruction. got:\n%s", ie.State)
+	}
+
+	report := ie.CrashReport("", bytecodes)
+	sections := []string{
+		"==== Error ====\ninternal error: Nothing to pop!\n",
+		"==== Source ====\n(not available)\n",
+		"==== Bytecode ====\n" + bytecodes,
+		"==== VM state ====\nCall frames (CFP: 1):\n",
+		"==== Go stack ====\ngoroutine",
+	}
+
+	for _, section := range sections {
+		if !strings.Contains(report, section) {
+			t.Fatalf("Expect crash report to contain %q. got:\n%s", section, report)
+		}
+	}
+}
+
+func TestRaisedExceptionIsNotInternalError(t *testing.T) {
+	v := New()
+	testLoadBytecodes(v, `
+<ProgramStart>
+0 getconstant Foo
+1 leave
+`)
+
+	if err := v.Exec(); err == nil || IsInternalError(err) {
+		t.Fatalf("Expect an exception raised by the program. got=%v", err)
+	}
+}
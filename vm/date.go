@@ -0,0 +1,464 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	DateClass *RDate
+)
+
+type RDate struct {
+	*BaseClass
+}
+
+// DateObject is a calendar day without a time or a time zone, like Ruby's Date.
+// day counts days since 1970-01-01, so dates can be compared and moved by days with integer arithmetic.
+type DateObject struct {
+	Class *RDate
+	day   int
+}
+
+func (d *DateObject) Type() ObjectType {
+	return DATE_OBJ
+}
+
+func (d *DateObject) Inspect() string {
+	return fmt.Sprintf("#<Date: %s>", d.iso8601())
+}
+
+func (d *DateObject) ReturnClass() Class {
+	return d.Class
+}
+
+const secondsPerDay = 24 * 60 * 60
+
+// time returns midnight of d in UTC.
+func (d *DateObject) time() time.Time {
+	return time.Unix(int64(d.day)*secondsPerDay, 0).UTC()
+}
+
+func (d *DateObject) iso8601() string {
+	return d.time().Format("2006-01-02")
+}
+
+// initDate returns the date of day, which counts days since 1970-01-01.
+func (vm *VM) initDate(day int) *DateObject {
+	return &DateObject{Class: DateClass, day: day}
+}
+
+// dateOf returns the date t is on, in t's location.
+func (vm *VM) dateOf(t time.Time) *DateObject {
+	utc := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return vm.initDate(int(utc.Unix() / secondsPerDay))
+}
+
+// civilDate returns the date of year, month and day, or false if there's no such day, like February 30.
+func (vm *VM) civilDate(year, month, day int) (*DateObject, bool) {
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+
+	// time.Date normalizes days that don't exist into the next month
+	if t.Year() != year || int(t.Month()) != month || t.Day() != day {
+		return nil, false
+	}
+
+	return vm.dateOf(t), true
+}
+
+// addMonths returns d moved by n months. Days that don't exist in the new month become its last day,
+// so a month after January 31 is the end of February.
+func (vm *VM) addMonths(d *DateObject, n int) *DateObject {
+	t := d.time()
+	months := t.Year()*12 + int(t.Month()) - 1 + n
+	year, month := months/12, months%12+1
+
+	if month <= 0 {
+		year, month = year-1, month+12
+	}
+
+	lastDay := time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	day := t.Day()
+
+	if day > lastDay {
+		day = lastDay
+	}
+
+	date, _ := vm.civilDate(year, month, day)
+
+	return date
+}
+
+// isoDatePattern matches ISO 8601 dates like "2024-01-31" and "20240131".
+var isoDatePattern = regexp.MustCompile(`^\s*([-+]?\d{4,})-?(\d{2})-?(\d{2})\s*$`)
+
+// parseDate parses the ISO 8601 date s, or raises Date::Error if it isn't one.
+func (vm *VM) parseDate(s string) *DateObject {
+	m := isoDatePattern.FindStringSubmatch(s)
+
+	if m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		day, _ := strconv.Atoi(m[3])
+
+		if d, ok := vm.civilDate(year, month, day); ok {
+			return d
+		}
+	}
+
+	vm.raise(DateErrorClass, "invalid date")
+	return nil
+}
+
+// strftime formats d like Ruby's Date#strftime, directives for times of the day format midnight.
+func (d *DateObject) strftime(format string) string {
+	t := d.time()
+	var out bytes.Buffer
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			out.WriteByte(format[i])
+			continue
+		}
+
+		i++
+
+		switch format[i] {
+		case 'Y':
+			out.WriteString(strconv.Itoa(t.Year()))
+		case 'y':
+			fmt.Fprintf(&out, "%02d", t.Year()%100)
+		case 'm':
+			fmt.Fprintf(&out, "%02d", int(t.Month()))
+		case 'd':
+			fmt.Fprintf(&out, "%02d", t.Day())
+		case 'e':
+			fmt.Fprintf(&out, "%2d", t.Day())
+		case 'j':
+			fmt.Fprintf(&out, "%03d", t.YearDay())
+		case 'B':
+			out.WriteString(t.Month().String())
+		case 'b':
+			out.WriteString(t.Month().String()[:3])
+		case 'A':
+			out.WriteString(t.Weekday().String())
+		case 'a':
+			out.WriteString(t.Weekday().String()[:3])
+		case 'u':
+			// ISO weekdays go from 1 on Monday to 7 on Sunday
+			out.WriteString(strconv.Itoa((int(t.Weekday())+6)%7 + 1))
+		case 'w':
+			out.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'F':
+			out.WriteString(d.iso8601())
+		case 'H', 'M', 'S':
+			out.WriteString("00")
+		case '%':
+			out.WriteByte('%')
+		default:
+			out.WriteByte('%')
+			out.WriteByte(format[i])
+		}
+	}
+
+	return out.String()
+}
+
+// dateComparison returns a built in method of Date that compares the receiver with another date.
+func dateComparison(name string, compare func(a, b int) bool) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, DateClass, name)
+
+				if err != nil {
+					return err
+				}
+
+				right, ok := args[0].(*DateObject)
+
+				if !ok {
+					vm.raise(ArgumentErrorClass, "comparison of Date with %s failed", vm.inspectForError(args[0]))
+				}
+
+				return toBooleanObject(compare(receiver.(*DateObject).day, right.day))
+			}
+		},
+		Name: name,
+	}
+}
+
+// dateEquality returns == or != of Date, dates are never equal to objects that aren't dates.
+func dateEquality(name string, equal bool) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, DateClass, name)
+
+				if err != nil {
+					return err
+				}
+
+				right, ok := args[0].(*DateObject)
+
+				return toBooleanObject((ok && receiver.(*DateObject).day == right.day) == equal)
+			}
+		},
+		Name: name,
+	}
+}
+
+// dateMover returns a built in method of Date that returns the date n units later, or earlier if sign is negative.
+// n is 1 unless it's given.
+func dateMover(name string, sign int, move func(vm *VM, d *DateObject, n int) *DateObject) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				n := 1
+
+				if len(args) == 1 {
+					n = vm.integerArgument(args[0])
+				}
+
+				return move(vm, receiver.(*DateObject), sign*n)
+			}
+		},
+		Name: name,
+	}
+}
+
+// dateField returns a built in method of Date that returns a part of the date.
+func dateField(name string, field func(t time.Time) int) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initInteger(field(receiver.(*DateObject).time()))
+			}
+		},
+		Name: name,
+	}
+}
+
+func addDays(vm *VM, d *DateObject, n int) *DateObject {
+	return vm.initDate(d.day + n)
+}
+
+func addYears(vm *VM, d *DateObject, n int) *DateObject {
+	return vm.addMonths(d, n*12)
+}
+
+var builtinDateMethods = []*BuiltInMethod{
+	dateField("year", func(t time.Time) int { return t.Year() }),
+	dateField("month", func(t time.Time) int { return int(t.Month()) }),
+	dateField("mon", func(t time.Time) int { return int(t.Month()) }),
+	dateField("day", func(t time.Time) int { return t.Day() }),
+	dateField("mday", func(t time.Time) int { return t.Day() }),
+	dateField("wday", func(t time.Time) int { return int(t.Weekday()) }),
+	dateField("yday", func(t time.Time) int { return t.YearDay() }),
+	dateEquality("==", true),
+	dateEquality("!=", false),
+	dateComparison("<", func(a, b int) bool { return a < b }),
+	dateComparison(">", func(a, b int) bool { return a > b }),
+	dateComparison("<=", func(a, b int) bool { return a <= b }),
+	dateComparison(">=", func(a, b int) bool { return a >= b }),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, DateClass, "+")
+
+				if err != nil {
+					return err
+				}
+
+				return vm.initDate(receiver.(*DateObject).day + vm.integerArgument(args[0]))
+			}
+		},
+		Name: "+",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, DateClass, "-")
+
+				if err != nil {
+					return err
+				}
+
+				d := receiver.(*DateObject)
+
+				// the difference between two dates is a number of days, which is a Rational like in Ruby
+				if other, ok := args[0].(*DateObject); ok {
+					return vm.initRational(new(big.Rat).SetInt64(int64(d.day - other.day)))
+				}
+
+				return vm.initDate(d.day - vm.integerArgument(args[0]))
+			}
+		},
+		Name: "-",
+	},
+	dateMover(">>", 1, (*VM).addMonths),
+	dateMover("<<", -1, (*VM).addMonths),
+	dateMover("next_day", 1, addDays),
+	dateMover("prev_day", -1, addDays),
+	dateMover("next_month", 1, (*VM).addMonths),
+	dateMover("prev_month", -1, (*VM).addMonths),
+	dateMover("next_year", 1, addYears),
+	dateMover("prev_year", -1, addYears),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				year := receiver.(*DateObject).time().Year()
+
+				return toBooleanObject(year%4 == 0 && (year%100 != 0 || year%400 == 0))
+			}
+		},
+		Name: "leap?",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return InitializeString(receiver.(*DateObject).strftime(vm.stringArgument(args[0])))
+			}
+		},
+		Name: "strftime",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return InitializeString(receiver.(*DateObject).iso8601())
+			}
+		},
+		Name: "to_s",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return InitializeString(receiver.(*DateObject).iso8601())
+			}
+		},
+		Name: "iso8601",
+	},
+}
+
+var builtinDateClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.dateOf(time.Now())
+			}
+		},
+		Name: "today",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 3 {
+					return newError("Expect 0 to 3 arguments. got=%d", len(args))
+				}
+
+				// like Ruby, the month and the day default to 1, and the year to -4712
+				parts := []int{-4712, 1, 1}
+
+				for i, arg := range args {
+					parts[i] = vm.integerArgument(arg)
+				}
+
+				d, ok := vm.civilDate(parts[0], parts[1], parts[2])
+
+				if !ok {
+					vm.raise(DateErrorClass, "invalid date")
+				}
+
+				return d
+			}
+		},
+		Name: "new",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return vm.parseDate(vm.stringArgument(args[0]))
+			}
+		},
+		Name: "parse",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return vm.parseDate(vm.stringArgument(args[0]))
+			}
+		},
+		Name: "iso8601",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 3 {
+					return newError("Expect 3 arguments. got=%d", len(args))
+				}
+
+				_, ok := vm.civilDate(vm.integerArgument(args[0]), vm.integerArgument(args[1]), vm.integerArgument(args[2]))
+
+				return toBooleanObject(ok)
+			}
+		},
+		Name: "valid_date?",
+	},
+}
+
+func initDate() {
+	methods := NewEnvironment()
+
+	for _, m := range builtinDateMethods {
+		methods.Set(m.Name, m)
+	}
+
+	classMethods := NewEnvironment()
+
+	for _, m := range builtinDateClassMethods {
+		classMethods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "Date", Methods: methods, ClassMethods: classMethods, Class: ClassClass, SuperClass: ObjectClass}
+	DateClass = &RDate{BaseClass: bc}
+}
@@ -0,0 +1,121 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestDate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Date.new(2024, 1, 31)`, "#<Date: 2024-01-31>"},
+		{`Date.new(2024)`, "#<Date: 2024-01-01>"},
+		{`Date.parse("2024-02-29")`, "#<Date: 2024-02-29>"},
+		{`Date.parse("20240229")`, "#<Date: 2024-02-29>"},
+		{`Date.iso8601("1969-12-31")`, "#<Date: 1969-12-31>"},
+		{`Date.new(2024, 1, 31).to_s`, "2024-01-31"},
+		{`Date.new(2024, 1, 31).year`, "2024"},
+		{`Date.new(2024, 1, 31).month`, "1"},
+		{`Date.new(2024, 1, 31).day`, "31"},
+		{`Date.new(2024, 1, 31).wday`, "3"},
+		{`Date.new(1970, 1, 1).wday`, "4"},
+		{`Date.new(2024, 12, 31).yday`, "366"},
+		{`Date.new(2024, 2, 28) + 1`, "#<Date: 2024-02-29>"},
+		{`Date.new(2024, 3, 1) - 1`, "#<Date: 2024-02-29>"},
+		{`Date.new(2024, 3, 1) - Date.new(2024, 1, 1)`, "(60/1)"},
+		{`Date.new(2024, 1, 1) - Date.new(2024, 3, 1)`, "(-60/1)"},
+		{`Date.new(2023, 12, 31).next_day`, "#<Date: 2024-01-01>"},
+		{`Date.new(2024, 1, 1).prev_day(2)`, "#<Date: 2023-12-30>"},
+		{`Date.new(2024, 1, 31) >> 1`, "#<Date: 2024-02-29>"},
+		{`Date.new(2024, 3, 31) << 1`, "#<Date: 2024-02-29>"},
+		{`Date.new(2024, 1, 15) << 13`, "#<Date: 2022-12-15>"},
+		{`Date.new(2024, 11, 30).next_month(2)`, "#<Date: 2025-01-30>"},
+		{`Date.new(2024, 2, 29).next_year`, "#<Date: 2025-02-28>"},
+		{`Date.new(2024, 2, 29).prev_year(4)`, "#<Date: 2020-02-29>"},
+		{`Date.new(2024, 3, 1).strftime("%a %A %b %B %d %e %j %m %u %w %y %Y %F %%")`, "Fri Friday Mar March 01  1 061 03 5 5 24 2024 2024-03-01 %"},
+		{`Date.new(2024, 1, 30)..Date.new(2024, 2, 2)`, "#<Date: 2024-01-30>..#<Date: 2024-02-02>"},
+		{`(Date.new(2024, 2, 27)...Date.new(2024, 3, 1)).to_a`, "Array:[#<Date: 2024-02-27>, #<Date: 2024-02-28>, #<Date: 2024-02-29>]"},
+		{`(Date.new(2024, 1, 1)..Date.new(2024, 1, 31)).step(10).to_a`, "Array:[#<Date: 2024-01-01>, #<Date: 2024-01-11>, #<Date: 2024-01-21>, #<Date: 2024-01-31>]"},
+		{`(Date.new(2024, 1, 1)..Date.new(2024, 1, 7)).map do |d| d.wday end`, "Array:[1, 2, 3, 4, 5, 6, 0]"},
+		{`(Date.new(2024, 1, 1)..Date.new(2024, 1, 7)).size`, "7"},
+		{`days = []
+		(Date.new(2023, 12, 30)..Date.new(2024, 1, 2)).each do |d|
+		  days.push(d.day)
+		end
+		days`, "Array:[30, 31, 1, 2]"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Fatalf("at test case %d: expect %s. got=%s", i, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestDateComparisons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`Date.new(2024, 1, 1) == Date.parse("2024-01-01")`, true},
+		{`Date.new(2024, 1, 1) != Date.new(2024, 1, 2)`, true},
+		{`Date.new(2024, 1, 1) == "2024-01-01"`, false},
+		{`Date.new(2024, 1, 1) != "2024-01-01"`, true},
+		{`Date.new(2024, 1, 1) < Date.new(2024, 1, 2)`, true},
+		{`Date.new(2024, 1, 1) > Date.new(2023, 12, 31)`, true},
+		{`Date.new(2024, 1, 1) <= Date.new(2024, 1, 1)`, true},
+		{`Date.new(2024, 1, 1) >= Date.new(2024, 1, 2)`, false},
+		{`Date.today == Date.today`, true},
+		{`Date.new(2024, 1, 1).leap?`, true},
+		{`Date.new(1900, 1, 1).leap?`, false},
+		{`Date.new(2000, 1, 1).leap?`, true},
+		{`Date.valid_date?(2024, 2, 29)`, true},
+		{`Date.valid_date?(2023, 2, 29)`, false},
+		{`Date.valid_date?(2024, 13, 1)`, false},
+		{`(Date.new(2024, 1, 1)..Date.new(2024, 1, 31)).include?(Date.new(2024, 1, 15))`, true},
+		{`(Date.new(2024, 1, 1)...Date.new(2024, 1, 31)).include?(Date.new(2024, 1, 31))`, false},
+		{`(Date.new(2024, 1, 1)..Date.new(2024, 1, 31)).include?(15)`, false},
+		{`(1..20000).include?(Date.new(1970, 1, 2))`, false},
+		{`h = {}
+		h[Date.new(2024, 1, 1)] = 1
+		h[Date.parse("2024-01-01")] == 1`, true},
+		{`Marshal.load(Marshal.dump(Date.new(2024, 1, 1))) == Date.new(2024, 1, 1)`, true},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if !testBooleanObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestDateErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Date.new(2023, 2, 29)`, "Date::Error: invalid date"},
+		{`Date.parse("2024-13-01")`, "Date::Error: invalid date"},
+		{`Date.parse("tomorrow")`, "Date::Error: invalid date"},
+		{`Date.new(2024, 1, 1) < 1`, "ArgumentError: comparison of Date with 1 failed"},
+		{`Date.new(2024, 1, 1)..1`, "ArgumentError: bad value for range"},
+		{`[1, 2][Date.new(2024, 1, 1)..Date.new(2024, 1, 2)]`, "TypeError: no implicit conversion of Date into Integer"},
+		{`begin
+		  Date.new(2024, 2, 30)
+		rescue ArgumentError => e
+		  raise(e.class.name)
+		end`, "RuntimeError: Date::Error"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
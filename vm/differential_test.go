@@ -0,0 +1,131 @@
+package vm
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The differential tests run every program in testdata/differential under Rooby and compare its stdout with CRuby's.
+// Programs should only use the subset of Ruby that Rooby supports, so any difference is a semantic divergence.
+// When ruby isn't installed, Rooby is compared with the recorded CRuby output in the program's .out file.
+// Run `go test ./vm -run Differential -update-ruby-output` with ruby installed to record the output of new programs.
+var (
+	rubyBin          = flag.String("ruby", "ruby", "ruby binary the differential tests compare Rooby with")
+	updateRubyOutput = flag.Bool("update-ruby-output", false, "record ruby's output of differential test programs in .out files")
+)
+
+const differentialDir = "testdata/differential"
+
+func TestDifferentialAgainstRuby(t *testing.T) {
+	programs, err := filepath.Glob(filepath.Join(differentialDir, "*.ro"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ruby, err := exec.LookPath(*rubyBin)
+
+	if err != nil {
+		ruby = ""
+
+		if *updateRubyOutput {
+			t.Fatalf("Can't record ruby's output: %s", err.Error())
+		}
+	}
+
+	for _, program := range programs {
+		outFile := strings.TrimSuffix(program, ".ro") + ".out"
+		var expected string
+
+		if ruby != "" {
+			out, err := exec.Command(ruby, program).Output()
+
+			if err != nil {
+				t.Errorf("%s: ruby failed: %s", program, err.Error())
+				continue
+			}
+
+			expected = string(out)
+
+			if *updateRubyOutput {
+				if err := ioutil.WriteFile(outFile, out, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+		} else {
+			out, err := ioutil.ReadFile(outFile)
+
+			if err != nil {
+				t.Errorf("%s: no recorded ruby output, run the test with ruby installed and -update-ruby-output", program)
+				continue
+			}
+
+			expected = string(out)
+		}
+
+		actual, err := runRoobyProgram(t, program)
+
+		if err != nil {
+			t.Errorf("%s: Rooby failed:\n%s", program, ErrorReport(err))
+			continue
+		}
+
+		if actual != expected {
+			t.Errorf("%s: Rooby's output diverges from ruby's.\n--- ruby\n%s--- rooby\n%s", program, expected, actual)
+		}
+	}
+}
+
+// runRoobyProgram executes the program in file and returns what it writes to stdout.
+func runRoobyProgram(t *testing.T, file string) (string, error) {
+	source, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := New()
+	testLoadBytecodes(v, testCompile(t, file, string(source)))
+
+	var execErr error
+	output := captureStdout(t, func() {
+		execErr = v.Exec()
+	})
+
+	return output, execErr
+}
+
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	out := make(chan string)
+
+	// read while f is running so it doesn't block on a full pipe
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		out <- buf.String()
+	}()
+
+	defer func() {
+		os.Stdout = stdout
+	}()
+
+	f()
+	w.Close()
+
+	return <-out
+}
@@ -0,0 +1,162 @@
+package vm
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"strings"
+)
+
+var (
+	// Base64Module encodes and decodes strings in base64, and the digest modules hash them.
+	Base64Module       *RClass
+	DigestMD5Module    *RClass
+	DigestSHA1Module   *RClass
+	DigestSHA256Module *RClass
+)
+
+// base64LineLength is how long lines of Base64.encode64 are.
+const base64LineLength = 60
+
+const base64Alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// encode64 encodes s like Ruby's Base64.encode64, which breaks the result into lines of 60 characters.
+func encode64(s string) string {
+	encoded := base64.StdEncoding.EncodeToString([]byte(s))
+	var lines []string
+
+	for len(encoded) > base64LineLength {
+		lines = append(lines, encoded[:base64LineLength])
+		encoded = encoded[base64LineLength:]
+	}
+
+	if encoded != "" {
+		lines = append(lines, encoded)
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// decode64 decodes s like Ruby's Base64.decode64, which ignores characters that aren't base64, like line breaks,
+// and whatever follows the padding.
+func decode64(s string) string {
+	var b []byte
+
+	for i := 0; i < len(s) && s[i] != '='; i++ {
+		if strings.IndexByte(base64Alphabet, s[i]) >= 0 {
+			b = append(b, s[i])
+		}
+	}
+
+	// a single character left over can't be decoded
+	if len(b)%4 == 1 {
+		b = b[:len(b)-1]
+	}
+
+	decoded, _ := base64.RawStdEncoding.DecodeString(string(b))
+	return string(decoded)
+}
+
+// base64Decoder returns a built in method that decodes its argument with decode and raises ArgumentError if it's invalid.
+func base64Decoder(name string, decode func(s string) ([]byte, error)) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				decoded, err := decode(vm.stringArgument(args[0]))
+
+				if err != nil {
+					vm.raise(ArgumentErrorClass, "invalid base64")
+				}
+
+				return InitializeString(string(decoded))
+			}
+		},
+		Name: name,
+	}
+}
+
+// base64Encoder returns a built in method that encodes its argument with encode.
+func base64Encoder(name string, encode func(s string) string) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return InitializeString(encode(vm.stringArgument(args[0])))
+			}
+		},
+		Name: name,
+	}
+}
+
+var builtinBase64ModuleMethods = []*BuiltInMethod{
+	base64Encoder("encode64", encode64),
+	base64Encoder("strict_encode64", func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}),
+	base64Encoder("urlsafe_encode64", func(s string) string {
+		return base64.URLEncoding.EncodeToString([]byte(s))
+	}),
+	base64Encoder("decode64", decode64),
+	base64Decoder("strict_decode64", base64.StdEncoding.DecodeString),
+	// like Ruby, padding is optional for URL safe base64
+	base64Decoder("urlsafe_decode64", func(s string) ([]byte, error) {
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	}),
+}
+
+// digestMethods returns hexdigest and digest methods of a digest module, which hash strings with hash functions newHash returns.
+func digestMethods(newHash func() hash.Hash) []*BuiltInMethod {
+	sum := func(vm *VM, args []Object) []byte {
+		h := newHash()
+		h.Write([]byte(vm.stringArgument(args[0])))
+		return h.Sum(nil)
+	}
+
+	return []*BuiltInMethod{
+		{
+			Fn: func(receiver Object) BuiltinMethodBody {
+				return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+					if len(args) != 1 {
+						return newError("Expect 1 argument. got=%d", len(args))
+					}
+
+					return InitializeString(hex.EncodeToString(sum(vm, args)))
+				}
+			},
+			Name: "hexdigest",
+		},
+		{
+			Fn: func(receiver Object) BuiltinMethodBody {
+				return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+					if len(args) != 1 {
+						return newError("Expect 1 argument. got=%d", len(args))
+					}
+
+					return InitializeString(string(sum(vm, args)))
+				}
+			},
+			Name: "digest",
+		},
+	}
+}
+
+func initDigest() {
+	Base64Module = initializeBuiltinModule("Base64", builtinBase64ModuleMethods)
+	DigestMD5Module = initializeBuiltinModule("Digest::MD5", digestMethods(md5.New))
+	DigestSHA1Module = initializeBuiltinModule("Digest::SHA1", digestMethods(sha1.New))
+	DigestSHA256Module = initializeBuiltinModule("Digest::SHA256", digestMethods(sha256.New))
+}
@@ -0,0 +1,78 @@
+package vm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBase64(t *testing.T) {
+	long := `"` + strings.Repeat("a", 50) + `"`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Base64.encode64("hello")`, "aGVsbG8=\n"},
+		{`Base64.encode64(` + long + `)`, "YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFh\nYWFhYWE=\n"},
+		{`Base64.strict_encode64(` + long + `)`, "YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE="},
+		{`Base64.decode64("aGVsbG8=")`, "hello"},
+		{`Base64.decode64("aGVsbG8")`, "hello"},
+		{`Base64.decode64("aGVs bG8=ignored")`, "hello"},
+		{`Base64.decode64(Base64.encode64(` + long + `))`, strings.Repeat("a", 50)},
+		{`Base64.strict_decode64("aGVsbG8=")`, "hello"},
+		{`Base64.urlsafe_encode64("??>>")`, "Pz8-Pg=="},
+		{`Base64.urlsafe_decode64("Pz8-Pg==")`, "??>>"},
+		{`Base64.urlsafe_decode64("Pz8-Pg")`, "??>>"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testStringObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Digest::MD5.hexdigest("hello")`, "5d41402abc4b2a76b9719d911017c592"},
+		{`Digest::SHA1.hexdigest("hello")`, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"},
+		{`Digest::SHA256.hexdigest("hello")`, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{`Digest::SHA256.digest("hello").bytesize`, 32},
+		{`Base64.strict_encode64(Digest::MD5.digest("hello"))`, "XUFAKrxLKna5cZ2REBfFkg=="},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		default:
+			t.Fatalf("at test case %d: unexpected expectation %v", i, expected)
+		}
+	}
+}
+
+func TestBase64AndDigestErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Base64.strict_decode64("aGVsbG8")`, "ArgumentError: invalid base64"},
+		{`Base64.urlsafe_decode64("Pz8+Pg")`, "ArgumentError: invalid base64"},
+		{`Base64.encode64(1)`, "TypeError: wrong argument type 1 (expected String)"},
+		{`Digest::MD5.hexdigest(1)`, "TypeError: wrong argument type 1 (expected String)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	EncodingClass *REncoding
+)
+
+type REncoding struct {
+	*BaseClass
+}
+
+// EncodingObject is a character encoding like Ruby's Encoding. It knows how the bytes of a string
+// make up its characters, so strings use it for their length, indexes and conversions.
+type EncodingObject struct {
+	Class *REncoding
+	name  string
+	// next returns the first character of s as a rune and its length in bytes, ok is false if s doesn't
+	// start with a valid character. Invalid characters are one byte long unless the encoding says otherwise.
+	next func(s string) (r rune, size int, ok bool)
+	// encode returns r in the encoding, ok is false if the encoding has no character for r.
+	encode func(r rune) (s string, ok bool)
+}
+
+func (e *EncodingObject) Type() ObjectType {
+	return ENCODING_OBJ
+}
+
+func (e *EncodingObject) Inspect() string {
+	return "#<Encoding:" + e.name + ">"
+}
+
+func (e *EncodingObject) ReturnClass() Class {
+	return e.Class
+}
+
+// unmapped is the rune of bytes that are valid characters but don't stand for any Unicode character,
+// like bytes above 127 in ASCII-8BIT.
+const unmapped = -1
+
+var (
+	utf8Encoding = &EncodingObject{
+		name: "UTF-8",
+		next: func(s string) (rune, int, bool) {
+			r, size := utf8.DecodeRuneInString(s)
+			return r, size, r != utf8.RuneError || size > 1
+		},
+		encode: func(r rune) (string, bool) {
+			return string(r), true
+		},
+	}
+	binaryEncoding = &EncodingObject{
+		name: "ASCII-8BIT",
+		next: func(s string) (rune, int, bool) {
+			if s[0] >= utf8.RuneSelf {
+				return unmapped, 1, true
+			}
+
+			return rune(s[0]), 1, true
+		},
+		encode: asciiEncode,
+	}
+	asciiEncoding = &EncodingObject{
+		name: "US-ASCII",
+		next: func(s string) (rune, int, bool) {
+			return rune(s[0]), 1, s[0] < utf8.RuneSelf
+		},
+		encode: asciiEncode,
+	}
+	latin1Encoding = &EncodingObject{
+		name: "ISO-8859-1",
+		next: func(s string) (rune, int, bool) {
+			return rune(s[0]), 1, true
+		},
+		encode: func(r rune) (string, bool) {
+			return string([]byte{byte(r)}), r <= 0xFF
+		},
+	}
+	utf16LEEncoding = utf16Encoding("UTF-16LE", func(b []byte) uint16 {
+		return uint16(b[0]) | uint16(b[1])<<8
+	}, func(u uint16) []byte {
+		return []byte{byte(u), byte(u >> 8)}
+	})
+	utf16BEEncoding = utf16Encoding("UTF-16BE", func(b []byte) uint16 {
+		return uint16(b[0])<<8 | uint16(b[1])
+	}, func(u uint16) []byte {
+		return []byte{byte(u >> 8), byte(u)}
+	})
+)
+
+// encodings are the encodings strings can be in, by their upper case names and aliases.
+var encodings = map[string]*EncodingObject{
+	"UTF-8":      utf8Encoding,
+	"ASCII-8BIT": binaryEncoding,
+	"BINARY":     binaryEncoding,
+	"US-ASCII":   asciiEncoding,
+	"ASCII":      asciiEncoding,
+	"ISO-8859-1": latin1Encoding,
+	"ISO8859-1":  latin1Encoding,
+	"UTF-16LE":   utf16LEEncoding,
+	"UTF-16BE":   utf16BEEncoding,
+}
+
+func asciiEncode(r rune) (string, bool) {
+	return string([]byte{byte(r)}), r < utf8.RuneSelf
+}
+
+// utf16Encoding returns a UTF-16 encoding whose code units are read from two bytes by decode and written by encode.
+func utf16Encoding(name string, decode func(b []byte) uint16, encode func(u uint16) []byte) *EncodingObject {
+	return &EncodingObject{
+		name: name,
+		next: func(s string) (rune, int, bool) {
+			if len(s) < 2 {
+				return utf8.RuneError, len(s), false
+			}
+
+			u := decode([]byte(s[:2]))
+
+			if !utf16.IsSurrogate(rune(u)) {
+				return rune(u), 2, true
+			}
+
+			// a surrogate pair is one character, the high surrogate has to come first
+			if u < 0xDC00 && len(s) >= 4 {
+				if r := utf16.DecodeRune(rune(u), rune(decode([]byte(s[2:4])))); r != utf8.RuneError {
+					return r, 4, true
+				}
+			}
+
+			return utf8.RuneError, 2, false
+		},
+		encode: func(r rune) (string, bool) {
+			var b []byte
+
+			if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+				b = append(encode(uint16(r1)), encode(uint16(r2))...)
+			} else {
+				b = encode(uint16(r))
+			}
+
+			return string(b), true
+		},
+	}
+}
+
+// encoding returns the encoding of s, strings are UTF-8 unless their encoding is forced.
+func (s *StringObject) encoding() *EncodingObject {
+	if s.encodingObject == nil {
+		return utf8Encoding
+	}
+
+	return s.encodingObject
+}
+
+// setEncoding changes the encoding of s in place.
+func (s *StringObject) setEncoding(e *EncodingObject) {
+	s.encodingObject = e
+}
+
+// characters returns every character of s in its encoding, bytes that aren't valid characters are characters of their own.
+func (s *StringObject) characters() []string {
+	e := s.encoding()
+	value := s.Value
+	chars := []string{}
+
+	for len(value) > 0 {
+		_, size, _ := e.next(value)
+		chars = append(chars, value[:size])
+		value = value[size:]
+	}
+
+	return chars
+}
+
+// validEncoding returns true if every character of s is valid in its encoding.
+func (s *StringObject) validEncoding() bool {
+	e := s.encoding()
+
+	for value := s.Value; len(value) > 0; {
+		_, size, ok := e.next(value)
+
+		if !ok {
+			return false
+		}
+
+		value = value[size:]
+	}
+
+	return true
+}
+
+// initEncodedString returns a string of value in encoding e. Only UTF-8 strings are shared, like literals.
+func initEncodedString(value string, e *EncodingObject) *StringObject {
+	if e == utf8Encoding {
+		return InitializeString(value)
+	}
+
+	return &StringObject{Value: value, Class: StringClass, encodingObject: e}
+}
+
+// encodingArgument returns the encoding arg is or names, or raises ArgumentError if there's no such encoding.
+func (vm *VM) encodingArgument(arg Object) *EncodingObject {
+	if e, ok := arg.(*EncodingObject); ok {
+		return e
+	}
+
+	name := vm.stringArgument(arg)
+	e, ok := encodings[strings.ToUpper(name)]
+
+	if !ok {
+		vm.raise(ArgumentErrorClass, "unknown encoding name - %s", name)
+	}
+
+	return e
+}
+
+// transcode converts s from one encoding to another. It raises Encoding::InvalidByteSequenceError
+// for bytes that aren't valid in from, and Encoding::UndefinedConversionError for characters to can't represent.
+func (vm *VM) transcode(s string, from, to *EncodingObject) string {
+	if from == to {
+		return s
+	}
+
+	var out bytes.Buffer
+
+	for len(s) > 0 {
+		r, size, ok := from.next(s)
+
+		if !ok {
+			vm.raise(InvalidByteSequenceErrorClass, "%s on %s", escapeBytes(s[:size]), from.name)
+		}
+
+		if r == unmapped {
+			vm.raise(UndefinedConversionErrorClass, "%s from %s to %s", escapeBytes(s[:size]), from.name, to.name)
+		}
+
+		encoded, ok := to.encode(r)
+
+		if !ok {
+			vm.raise(UndefinedConversionErrorClass, "U+%04X from %s to %s", r, from.name, to.name)
+		}
+
+		out.WriteString(encoded)
+		s = s[size:]
+	}
+
+	return out.String()
+}
+
+// escapeBytes quotes b with every byte escaped, like Ruby shows bytes in encoding errors.
+func escapeBytes(b string) string {
+	var out bytes.Buffer
+
+	for i := 0; i < len(b); i++ {
+		fmt.Fprintf(&out, `\x%02X`, b[i])
+	}
+
+	return `"` + out.String() + `"`
+}
+
+var builtinEncodingMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return InitializeString(receiver.(*EncodingObject).name)
+			}
+		},
+		Name: "name",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return InitializeString(receiver.(*EncodingObject).name)
+			}
+		},
+		Name: "to_s",
+	},
+}
+
+var builtinEncodingClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return vm.encodingArgument(args[0])
+			}
+		},
+		Name: "find",
+	},
+}
+
+func initEncoding() {
+	methods := NewEnvironment()
+
+	for _, m := range builtinEncodingMethods {
+		methods.Set(m.Name, m)
+	}
+
+	classMethods := NewEnvironment()
+
+	for _, m := range builtinEncodingClassMethods {
+		classMethods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "Encoding", Methods: methods, ClassMethods: classMethods, Class: ClassClass, SuperClass: ObjectClass}
+	EncodingClass = &REncoding{BaseClass: bc}
+
+	for _, e := range encodings {
+		e.Class = EncodingClass
+	}
+}
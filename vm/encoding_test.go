@@ -0,0 +1,104 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestStringEncoding(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"héllo".encoding`, "#<Encoding:UTF-8>"},
+		{`"héllo".encoding.name`, "UTF-8"},
+		{`"héllo".length`, "5"},
+		{`"héllo".bytesize`, "6"},
+		{`"héllo"[1]`, "é"},
+		{`"héllo"[1, 2]`, "él"},
+		{`"héllo".force_encoding("BINARY").encoding`, "#<Encoding:ASCII-8BIT>"},
+		{`"héllo".force_encoding(Encoding::ASCII_8BIT).length`, "6"},
+		{`"héllo".force_encoding("ascii-8bit")[1].bytes`, "Array:[195]"},
+		{`"héllo".force_encoding("BINARY").chars.length`, "6"},
+		{`"héllo".force_encoding("BINARY")[0].encoding`, "#<Encoding:ASCII-8BIT>"},
+		{`"é!".force_encoding("BINARY")[0].force_encoding("UTF-8").length`, "1"},
+		{`a = "xy"; b = "xy"; a.force_encoding("ASCII-8BIT"); b.encoding`, "#<Encoding:UTF-8>"},
+		{`"héllo".encode("ISO-8859-1").bytes`, "Array:[104, 233, 108, 108, 111]"},
+		{`"héllo".encode("ISO-8859-1").encoding`, "#<Encoding:ISO-8859-1>"},
+		{`"héllo".encode("UTF-16LE").bytesize`, "10"},
+		{`"héllo".encode("UTF-16LE").length`, "5"},
+		{`"héllo".encode("UTF-16LE")[1].encode("UTF-8")`, "é"},
+		{`"😀".encode("UTF-16BE").bytes`, "Array:[216, 61, 222, 0]"},
+		{`"😀".encode("UTF-16BE").length`, "1"},
+		{`"😀".encode("UTF-16LE").encode("UTF-8")`, "😀"},
+		{`"abc".encode("US-ASCII").encoding`, "#<Encoding:US-ASCII>"},
+		{`"hé".encode("UTF-8", "ISO-8859-1").length`, "3"},
+		{`"héllo".force_encoding("BINARY") + "!"`, "héllo!"},
+		{`("héllo".force_encoding("BINARY") + "!").length`, "7"},
+		{`s = "héllo".encode("ISO-8859-1")
+		s[1] = "e"
+		s.encode("UTF-8")`, "hello"},
+		{`Encoding.find("binary")`, "#<Encoding:ASCII-8BIT>"},
+		{`Encoding::UTF_16LE.to_s`, "UTF-16LE"},
+		{`Marshal.load(Marshal.dump("héllo".encode("UTF-16BE"))).encoding`, "#<Encoding:UTF-16BE>"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Fatalf("at test case %d: expect %s. got=%s", i, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestStringValidEncoding(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"héllo".valid_encoding?`, true},
+		{`"héllo".force_encoding("BINARY").valid_encoding?`, true},
+		{`"héllo".force_encoding("US-ASCII").valid_encoding?`, false},
+		{`"é!".force_encoding("BINARY")[0].force_encoding("UTF-8").valid_encoding?`, false},
+		{`"héllo".encode("UTF-16LE").valid_encoding?`, true},
+		{`"abc".force_encoding("UTF-16LE").valid_encoding?`, false},
+		{`"😀".encode("UTF-16LE")[0].valid_encoding?`, true},
+		{`Encoding::BINARY == Encoding::ASCII_8BIT`, true},
+		{`"x".encoding == Encoding::UTF_8`, true},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if !testBooleanObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestStringEncodingErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"é".encode("US-ASCII")`, "Encoding::UndefinedConversionError: U+00E9 from UTF-8 to US-ASCII"},
+		{`"😀".encode("ISO-8859-1")`, "Encoding::UndefinedConversionError: U+1F600 from UTF-8 to ISO-8859-1"},
+		{`"é".force_encoding("BINARY").encode("UTF-8")`, `Encoding::UndefinedConversionError: "\xC3" from ASCII-8BIT to UTF-8`},
+		{`"é!".force_encoding("BINARY")[0].force_encoding("UTF-8").encode("UTF-16LE")`, `Encoding::InvalidByteSequenceError: "\xC3" on UTF-8`},
+		{`"abc".force_encoding("UTF-16LE").encode("UTF-8")`, `Encoding::InvalidByteSequenceError: "\x63" on UTF-16LE`},
+		{`"abc".force_encoding("EBCDIC")`, "ArgumentError: unknown encoding name - EBCDIC"},
+		{`"abc".encode(1)`, "TypeError: wrong argument type 1 (expected String)"},
+		{`begin
+		  "é".encode("US-ASCII")
+		rescue EncodingError => e
+		  raise(e.class.name)
+		end`, "RuntimeError: Encoding::UndefinedConversionError"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package vm
+
+var (
+	EnumeratorClass *REnumerator
+)
+
+// Iterator is the interface a Go host implements to feed values into Rooby lazily.
+// Next returns false once the underlying source is exhausted.
+type Iterator interface {
+	Next() (Object, bool)
+}
+
+// IteratorFunc adapts an ordinary function into an Iterator.
+type IteratorFunc func() (Object, bool)
+
+// Next calls f()
+func (f IteratorFunc) Next() (Object, bool) {
+	return f()
+}
+
+type channelIterator struct {
+	ch <-chan Object
+}
+
+func (ci *channelIterator) Next() (Object, bool) {
+	obj, ok := <-ci.ch
+	return obj, ok
+}
+
+// sliceIterator returns an Iterator that yields objects in order.
+func sliceIterator(objects []Object) Iterator {
+	i := 0
+
+	return IteratorFunc(func() (Object, bool) {
+		if i >= len(objects) {
+			return nil, false
+		}
+
+		i++
+		return objects[i-1], true
+	})
+}
+
+type REnumerator struct {
+	*BaseClass
+}
+
+// EnumeratorObject exposes a Go Iterator to Rooby programs.
+// Values are pulled from the iterator when they're needed, so an enumerator
+// backed by a channel blocks until the channel sends a value or is closed.
+type EnumeratorObject struct {
+	Class    *REnumerator
+	Iterator Iterator
+}
+
+func (e *EnumeratorObject) Type() ObjectType {
+	return ENUMERATOR_OBJ
+}
+
+func (e *EnumeratorObject) Inspect() string {
+	return "<Enumerator>"
+}
+
+func (e *EnumeratorObject) ReturnClass() Class {
+	return e.Class
+}
+
+// InitializeEnumerator wraps an Iterator into an Enumerator object.
+func InitializeEnumerator(it Iterator) *EnumeratorObject {
+	return &EnumeratorObject{Iterator: it, Class: EnumeratorClass}
+}
+
+// InitializeChannelEnumerator returns an Enumerator that yields every value received from ch until ch is closed.
+func InitializeChannelEnumerator(ch <-chan Object) *EnumeratorObject {
+	return InitializeEnumerator(&channelIterator{ch: ch})
+}
+
+var builtinEnumeratorMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				enum := receiver.(*EnumeratorObject)
+
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (yield)")
+				}
+
+				for {
+					obj, ok := enum.Iterator.Next()
+
+					if !ok {
+						break
+					}
+
+					vm.builtInMethodYield(blockFrame, obj)
+				}
+
+				return enum
+			}
+		},
+		Name: "each",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				enum := receiver.(*EnumeratorObject)
+				obj, ok := enum.Iterator.Next()
+
+				if !ok {
+					return NULL
+				}
+
+				return obj
+			}
+		},
+		Name: "next",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				enum := receiver.(*EnumeratorObject)
+				elems := []Object{}
+
+				for {
+					obj, ok := enum.Iterator.Next()
+
+					if !ok {
+						break
+					}
+
+					elems = append(elems, obj)
+				}
+
+				arr := InitializeArray(elems)
+				vm.track(arr)
+
+				return arr
+			}
+		},
+		Name: "to_a",
+	},
+}
+
+func initEnumerator() {
+	methods := NewEnvironment()
+
+	for _, m := range builtinEnumeratorMethods {
+		methods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "Enumerator", Methods: methods, ClassMethods: NewEnvironment(), Class: ClassClass, SuperClass: ObjectClass}
+	ec := &REnumerator{BaseClass: bc}
+	EnumeratorClass = ec
+}
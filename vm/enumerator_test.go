@@ -0,0 +1,65 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestChannelEnumeratorEach(t *testing.T) {
+	input := `
+	sum = 0
+	Numbers.each do |n|
+	  sum = sum + n
+	end
+	sum
+	`
+
+	ch := make(chan Object)
+	go func() {
+		for i := 1; i <= 10; i++ {
+			ch <- InitilaizeInteger(i)
+		}
+		close(ch)
+	}()
+
+	v := New()
+	v.Constants["Numbers"] = &Pointer{InitializeChannelEnumerator(ch)}
+
+	evaluated := testEvalWithVM(t, v, input)
+	testIntegerObject(t, evaluated, 55)
+}
+
+func TestIteratorEnumeratorNextAndToA(t *testing.T) {
+	input := `
+	first = Letters.next
+	rest = Letters.to_a
+	first + rest[0] + rest[1] + rest.length.to_s
+	`
+
+	letters := []string{"a", "b", "c"}
+	i := 0
+	it := IteratorFunc(func() (Object, bool) {
+		if i >= len(letters) {
+			return nil, false
+		}
+
+		i++
+		return InitializeString(letters[i-1]), true
+	})
+
+	v := New()
+	v.Constants["Letters"] = &Pointer{InitializeEnumerator(it)}
+
+	evaluated := testEvalWithVM(t, v, input)
+	testStringObject(t, evaluated, "abc2")
+}
+
+func TestEnumeratorNextReturnsNullWhenExhausted(t *testing.T) {
+	ch := make(chan Object)
+	close(ch)
+
+	v := New()
+	v.Constants["Empty"] = &Pointer{InitializeChannelEnumerator(ch)}
+
+	evaluated := testEvalWithVM(t, v, "Empty.next")
+	testNullObject(t, evaluated)
+}
@@ -0,0 +1,320 @@
+package vm
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+var (
+	EnvClass *REnv
+)
+
+type REnv struct {
+	*BaseClass
+}
+
+// EnvObject is ENV, the environment variables of the program. It reads and changes the process's environment,
+// unless the host gives the program its own variables with SetEnv.
+type EnvObject struct {
+	Class *REnv
+	// vars holds the variables set by SetEnv, the process's environment is used if it's nil
+	vars map[string]string
+}
+
+func (e *EnvObject) Type() ObjectType {
+	return ENV_OBJ
+}
+
+func (e *EnvObject) Inspect() string {
+	return "ENV"
+}
+
+func (e *EnvObject) ReturnClass() Class {
+	return e.Class
+}
+
+func (e *EnvObject) get(name string) (string, bool) {
+	if e.vars == nil {
+		return os.LookupEnv(name)
+	}
+
+	value, ok := e.vars[name]
+	return value, ok
+}
+
+func (e *EnvObject) set(name, value string) error {
+	if e.vars == nil {
+		return os.Setenv(name, value)
+	}
+
+	e.vars[name] = value
+	return nil
+}
+
+func (e *EnvObject) unset(name string) error {
+	if e.vars == nil {
+		return os.Unsetenv(name)
+	}
+
+	delete(e.vars, name)
+	return nil
+}
+
+// names returns the names of the variables in order.
+func (e *EnvObject) names() []string {
+	names := []string{}
+
+	if e.vars == nil {
+		for _, kv := range os.Environ() {
+			names = append(names, strings.SplitN(kv, "=", 2)[0])
+		}
+	} else {
+		for name := range e.vars {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// SetEnv makes ENV hold vars instead of the process's environment, so programs can't read or change it.
+// vars is copied, changes the program makes aren't seen by the host.
+func (vm *VM) SetEnv(vars map[string]string) {
+	vm.env.vars = make(map[string]string)
+
+	for name, value := range vars {
+		vm.env.vars[name] = value
+	}
+}
+
+// SetArgs sets ARGV, the command line arguments given to the program. They're kept when the VM is reset.
+func (vm *VM) SetArgs(args []string) {
+	vm.args = append([]string{}, args...)
+	vm.Constants["ARGV"] = &Pointer{Target: vm.argv()}
+}
+
+// argv returns a new ARGV array of the arguments set by SetArgs.
+func (vm *VM) argv() *ArrayObject {
+	elements := []Object{}
+
+	for _, arg := range vm.args {
+		elements = append(elements, InitializeString(arg))
+	}
+
+	return InitializeArray(elements)
+}
+
+// envName returns the variable name given by arg, names can't be empty or contain "=".
+func (vm *VM) envName(arg Object) string {
+	name := vm.stringArgument(arg)
+
+	if name == "" || strings.Contains(name, "=") {
+		vm.raise(ArgumentErrorClass, "invalid environment variable name %s", vm.inspectForError(arg))
+	}
+
+	return name
+}
+
+// envFetch returns the value of variable arg as a string object, and false if it isn't set.
+func (vm *VM) envFetch(env *EnvObject, arg Object) (Object, bool) {
+	value, ok := env.get(vm.envName(arg))
+
+	if !ok {
+		return NULL, false
+	}
+
+	return InitializeString(value), true
+}
+
+// envIterator returns a built in method of ENV that yields each variable's name and value to the block.
+func envIterator(name string) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (%s)", name)
+				}
+
+				env := receiver.(*EnvObject)
+
+				for _, n := range env.names() {
+					if value, ok := env.get(n); ok {
+						vm.builtInMethodYield(blockFrame, InitializeString(n), InitializeString(value))
+					}
+				}
+
+				return env
+			}
+		},
+		Name: name,
+	}
+}
+
+// envKeyChecker returns a built in method of ENV that returns whether a variable is set.
+func envKeyChecker(name string) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				_, ok := vm.envFetch(receiver.(*EnvObject), args[0])
+				return toBooleanObject(ok)
+			}
+		},
+		Name: name,
+	}
+}
+
+var builtinEnvMethods = []*BuiltInMethod{
+	envIterator("each"),
+	envIterator("each_pair"),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				value, _ := vm.envFetch(receiver.(*EnvObject), args[0])
+				return value
+			}
+		},
+		Name: "[]",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 2 {
+					return newError("Expect 2 arguments. got=%d", len(args))
+				}
+
+				env := receiver.(*EnvObject)
+				name := vm.envName(args[0])
+				var err error
+
+				// like Ruby, assigning nil removes the variable
+				if args[1] == NULL {
+					err = env.unset(name)
+				} else {
+					err = env.set(name, vm.stringArgument(args[1]))
+				}
+
+				if err != nil {
+					vm.raise(ArgumentErrorClass, "%s", err.Error())
+				}
+
+				return args[1]
+			}
+		},
+		Name: "[]=",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				env := receiver.(*EnvObject)
+				value, ok := vm.envFetch(env, args[0])
+
+				if !ok {
+					return NULL
+				}
+
+				if err := env.unset(vm.envName(args[0])); err != nil {
+					vm.raise(ArgumentErrorClass, "%s", err.Error())
+				}
+
+				return value
+			}
+		},
+		Name: "delete",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 && len(args) != 2 {
+					return newError("Expect 1 or 2 arguments. got=%d", len(args))
+				}
+
+				value, ok := vm.envFetch(receiver.(*EnvObject), args[0])
+
+				switch {
+				case ok:
+					return value
+				case blockFrame != nil:
+					return vm.builtInMethodYield(blockFrame, args[0]).Target
+				case len(args) == 2:
+					return args[1]
+				}
+
+				vm.raise(KeyErrorClass, "key not found: %s", vm.inspectForError(args[0]))
+				return nil
+			}
+		},
+		Name: "fetch",
+	},
+	envKeyChecker("key?"),
+	envKeyChecker("has_key?"),
+	envKeyChecker("include?"),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				keys := []Object{}
+
+				for _, name := range receiver.(*EnvObject).names() {
+					keys = append(keys, InitializeString(name))
+				}
+
+				return InitializeArray(keys)
+			}
+		},
+		Name: "keys",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				env := receiver.(*EnvObject)
+				hash := InitializeHash(map[string]Object{})
+
+				for _, name := range env.names() {
+					if value, ok := env.get(name); ok {
+						hash.set(vm, InitializeString(name), InitializeString(value))
+					}
+				}
+
+				return hash
+			}
+		},
+		Name: "to_h",
+	},
+}
+
+func initEnv() {
+	methods := NewEnvironment()
+
+	for _, m := range builtinEnvMethods {
+		methods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "Env", Methods: methods, ClassMethods: NewEnvironment(), Class: ClassClass, SuperClass: ObjectClass}
+	EnvClass = &REnv{BaseClass: bc}
+}
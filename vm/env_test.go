@@ -0,0 +1,138 @@
+package vm
+
+import (
+	"os"
+	"testing"
+)
+
+func TestARGV(t *testing.T) {
+	evaluated := testEvalWithVM(t, New(), `ARGV.length`)
+	testIntegerObject(t, evaluated, 0)
+
+	v := New()
+	v.SetArgs([]string{"a", "-b"})
+	evaluated = testEvalWithVM(t, v, `ARGV`)
+
+	if evaluated.Inspect() != "Array:[a, -b]" {
+		t.Fatalf("Expect ARGV to be the arguments. got=%s", evaluated.Inspect())
+	}
+
+	// programs can change ARGV, but the arguments are set again when the VM is reset
+	v.Reset()
+	testIntegerObject(t, testEvalWithVM(t, v, `ARGV.push("c").length`), 3)
+	v.Reset()
+	evaluated = testEvalWithVM(t, v, `ARGV.length`)
+	testIntegerObject(t, evaluated, 2)
+}
+
+func TestENV(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`ENV["HOME"]`, "/home/rooby"},
+		{`ENV["NOPE"]`, nil},
+		{`ENV.fetch("HOME")`, "/home/rooby"},
+		{`ENV.fetch("NOPE", "default")`, "default"},
+		{`ENV.fetch("NOPE") do |name| name + "!" end`, "NOPE!"},
+		{`ENV.key?("LANG")`, true},
+		{`ENV.has_key?("NOPE")`, false},
+		{`ENV.include?("HOME")`, true},
+		{`ENV.keys[1]`, "LANG"},
+		{`ENV.to_h["LANG"]`, "C"},
+		{`
+		ENV["NEW"] = "value"
+		ENV["NEW"]
+		`, "value"},
+		{`
+		ENV["HOME"] = ENV["NOPE"]
+		ENV.key?("HOME")
+		`, false},
+		{`
+		ENV.delete("HOME")
+		`, "/home/rooby"},
+		{`
+		ENV.delete("HOME")
+		ENV.delete("HOME")
+		`, nil},
+		{`
+		s = ">"
+		ENV.each do |name, value|
+		  s = s + name + "=" + value + ";"
+		end
+		s
+		`, ">HOME=/home/rooby;LANG=C;"},
+		{`
+		s = ">"
+		ENV.each_pair do |name, value|
+		  s = s + name + ";"
+		end
+		s
+		`, ">HOME;LANG;"},
+	}
+
+	for i, tt := range tests {
+		v := New()
+		v.SetEnv(map[string]string{"HOME": "/home/rooby", "LANG": "C"})
+		evaluated := testEvalWithVM(t, v, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case string:
+			testStringObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		default:
+			t.Fatalf("at test case %d: unexpected expectation %v", i, expected)
+		}
+	}
+}
+
+func TestENVDoesNotChangeHostVariables(t *testing.T) {
+	vars := map[string]string{"HOME": "/home/rooby"}
+	v := New()
+	v.SetEnv(vars)
+	testEvalWithVM(t, v, `ENV["HOME"] = "/tmp"`)
+
+	if vars["HOME"] != "/home/rooby" {
+		t.Fatalf("Expect the host's variables to be unchanged. got=%s", vars["HOME"])
+	}
+}
+
+func TestENVOfProcess(t *testing.T) {
+	os.Setenv("ROOBY_ENV_TEST", "from host")
+	defer os.Unsetenv("ROOBY_ENV_TEST")
+
+	v := New()
+	testStringObject(t, testEvalWithVM(t, v, `ENV["ROOBY_ENV_TEST"]`), "from host")
+	v.Reset()
+	testEvalWithVM(t, v, `ENV["ROOBY_ENV_TEST"] = "from program"`)
+
+	if os.Getenv("ROOBY_ENV_TEST") != "from program" {
+		t.Fatalf("Expect ENV to change the process's environment. got=%s", os.Getenv("ROOBY_ENV_TEST"))
+	}
+}
+
+func TestENVErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`ENV.fetch("NOPE")`, "KeyError: key not found: NOPE"},
+		{`ENV["A=B"]`, "ArgumentError: invalid environment variable name A=B"},
+		{`ENV["A"] = 1`, "TypeError: wrong argument type 1 (expected String)"},
+		{`ENV[1]`, "TypeError: wrong argument type 1 (expected String)"},
+		{`ENV.each`, "LocalJumpError: no block given (each)"},
+	}
+
+	for i, tt := range tests {
+		v := New()
+		v.SetEnv(map[string]string{})
+		err := testEvalError(t, v, "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
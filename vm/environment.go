@@ -14,6 +14,8 @@ func NewClosedEnvironment(outer *Environment) *Environment {
 type Environment struct {
 	store map[string]Object
 	outer *Environment
+	// names keeps the order names are first set in
+	names []string
 }
 
 type Scope struct {
@@ -44,6 +46,15 @@ func (e *Environment) Get(name string) (Object, bool) {
 }
 
 func (e *Environment) Set(name string, val Object) Object {
+	if _, ok := e.store[name]; !ok {
+		e.names = append(e.names, name)
+	}
+
 	e.store[name] = val
 	return val
 }
+
+// Names returns names set in the environment, not including its outer environments, in the order they're first set.
+func (e *Environment) Names() []string {
+	return append([]string{}, e.names...)
+}
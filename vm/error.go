@@ -4,9 +4,12 @@ type ErrorClass struct {
 	*BaseClass
 }
 
+// Error is what a built in method returns when it's called wrongly, like with a wrong number of arguments.
+// The method call that returned it raises it as an exception of exceptionClass, which is ArgumentError unless it's set.
 type Error struct {
-	Class   *ErrorClass
-	Message string
+	Class          *ErrorClass
+	Message        string
+	exceptionClass *RClass
 }
 
 func (e *Error) Type() ObjectType {
@@ -20,3 +23,11 @@ func (e *Error) Inspect() string {
 func (e *Error) ReturnClass() Class {
 	return e.Class
 }
+
+func (e *Error) exception() *RClass {
+	if e.exceptionClass == nil {
+		return ArgumentErrorClass
+	}
+
+	return e.exceptionClass
+}
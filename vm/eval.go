@@ -0,0 +1,106 @@
+package vm
+
+import (
+	"strings"
+
+	"github.com/st0012/Rooby/bytecode"
+	"github.com/st0012/Rooby/lexer"
+	"github.com/st0012/Rooby/parser"
+)
+
+// evalFileName is the file name of code compiled by eval, it's what backtraces and `__FILE__` show.
+const evalFileName = "(eval)"
+
+// compile compiles source of file into instruction sets and returns the one of its top level.
+// The instruction sets are loaded into their own label table, so blocks, methods and classes in source
+// don't get mixed up with the ones of the program that's running.
+func (vm *VM) compile(file, source string) *InstructionSet {
+	return vm.compileWithLocals(file, source, nil)
+}
+
+// compileWithLocals is compile with the names of the local variables around source, innermost scope first.
+// The first scope is source's top level, the others are the blocks and the method or program it's run in.
+func (vm *VM) compileWithLocals(file, source string, locals [][]string) *InstructionSet {
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+
+	if errors := p.Errors(); len(errors) > 0 {
+		vm.raise(SyntaxErrorClass, "%s", strings.Join(errors, "\n"))
+	}
+
+	g := bytecode.NewGenerator(program)
+	g.SetFileName(file)
+
+	if len(locals) > 0 {
+		g.SetLocals(locals[0])
+		g.SetOuterLocals(locals[1:])
+	}
+
+	bp := NewBytecodeParser()
+	bp.VM = vm
+	bp.labels = newLabelTable()
+	bp.Parse(g.GenerateByteCode(program))
+
+	is := bp.labels.LabelTable[PROGRAM]["ProgramStart"][0]
+	is.locals = g.Locals()
+
+	return is
+}
+
+// evalSource compiles and runs source with self, and returns the value of its last expression.
+// The code runs with the local variables of its caller, the ones it assigns first are kept for later evals in the
+// same frame, but the caller's own code doesn't see them.
+func (vm *VM) evalSource(source Object, self BaseObject) Object {
+	s, ok := source.(*StringObject)
+
+	if !ok {
+		vm.raise(TypeErrorClass, "wrong argument type %s (expected String)", vm.inspectForError(source))
+	}
+
+	caller := vm.CallFrameStack.Top()
+	locals := callerLocals(caller)
+	is := vm.compileWithLocals(evalFileName, s.Value, locals)
+	sp := vm.SP
+
+	c := NewCallFrame(is)
+	c.Self = self
+
+	if len(locals) > 0 {
+		c.Local, c.LPr, c.EP = caller.Local, caller.LPr, caller.EP
+
+		defer func() {
+			caller.Local, caller.LPr, caller.locals = c.Local, c.LPr, is.locals
+		}()
+	}
+
+	vm.CallFrameStack.Push(c)
+	vm.startFromTopFrame()
+
+	if vm.SP <= sp {
+		return NULL
+	}
+
+	return vm.Stack.Top().Target
+}
+
+// callerLocals returns the names of the local variables of c and the frames around it, innermost first. It stops at
+// the first frame whose names aren't in its bytecode, which happens when the program doesn't call eval by name.
+func callerLocals(c *CallFrame) [][]string {
+	var locals [][]string
+
+	for ; c != nil; c = c.EP {
+		names := c.locals
+
+		if names == nil && c.InstructionSet != nil {
+			names = c.InstructionSet.locals
+		}
+
+		if len(names) < c.LPr {
+			break
+		}
+
+		locals = append(locals, names)
+	}
+
+	return locals
+}
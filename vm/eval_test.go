@@ -0,0 +1,184 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`eval("1 + 2")`, 3},
+		{`eval("2 * 3; 4 + 1")`, 5},
+		{`
+		eval("def foo(); 10; end")
+		foo
+		`, 10},
+		{`
+		eval("class Foo; def bar(); Proc.new do |x| x * 2 end.call(2); end; end")
+		double = Proc.new do |x|
+		  x * 3
+		end
+		Foo.new.bar + double.call(0)
+		`, 4},
+		{`
+		class Foo
+		  def initialize
+		    @value = 5
+		  end
+
+		  def run(code)
+		    eval(code)
+		  end
+		end
+
+		Foo.new.run("@value * 2")
+		`, 10},
+		{`
+		x = 1
+		eval("x + 1")
+		`, 2},
+		{`
+		x = 1
+		eval("x = x + 10")
+		x
+		`, 11},
+		{`
+		eval("y = 3")
+		eval("y * 2")
+		`, 6},
+		{`
+		x = 4
+		sum = 0
+		[1, 2].each do |i|
+		  sum = sum + eval("x * i")
+		end
+		sum
+		`, 12},
+		{`
+		def add(a, b)
+		  c = a + b
+		  eval("c * 2")
+		end
+
+		add(2, 3)
+		`, 10},
+		{`
+		x = 1
+		[1].each do |i|
+		  y = 2
+		end
+		x
+		`, 1},
+		{`
+		begin
+		  eval("raise(RuntimeError)")
+		rescue RuntimeError
+		  7
+		end
+		`, 7},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestInstanceEval(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		class Foo
+		  def initialize
+		    @secret = 42
+		  end
+		end
+
+		Foo.new.instance_eval do
+		  @secret
+		end
+		`, 42},
+		{`
+		class Foo
+		  def initialize
+		    @secret = 42
+		  end
+		end
+
+		Foo.new.instance_eval("@secret + 1")
+		`, 43},
+		{`
+		class Foo
+		  def initialize
+		    @value = 1
+		  end
+
+		  def value
+		    @value
+		  end
+		end
+
+		n = 10
+		foo = Foo.new
+		foo.instance_eval do
+		  @value = n
+		end
+		foo.value
+		`, 10},
+		{`
+		class Foo
+		  def secret
+		    3
+		  end
+		end
+
+		Foo.new.instance_eval do |obj|
+		  Proc.new do |x|
+		    x * secret
+		  end.call(2) + obj.secret
+		end
+		`, 9},
+		{`[5.instance_eval do @x end].compact.length`, 0},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`eval(1)`, "TypeError: wrong argument type 1 (expected String)"},
+		{`eval("1 +")`, "SyntaxError: no prefix function for EOF. Line: 0"},
+		{`
+		class Foo
+		end
+
+		Foo.new.instance_eval
+		`, "LocalJumpError: no block given (instance_eval)"},
+		{`5.instance_eval do @x = 1 end`, "TypeError: can't modify instance variables of 5"},
+		{`"ivar".instance_eval("@x = 1")`, "TypeError: can't modify instance variables of ivar"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
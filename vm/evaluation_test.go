@@ -551,6 +551,492 @@ func TestEvalClassInheritance(t *testing.T) {
 	}
 }
 
+func TestEvalSuper(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		class Foo
+		  def add(x, y)
+		    x + y
+		  end
+		end
+
+		class Bar < Foo
+		  def add(x, y)
+		    super(x, y) * 10
+		  end
+		end
+
+		Bar.new.add(1, 2)
+		`, 30},
+		{`
+		class Foo
+		  def add(x, y)
+		    x + y
+		  end
+		end
+
+		class Bar < Foo
+		  def add(x, y)
+		    x = x * 2
+		    super
+		  end
+		end
+
+		Bar.new.add(1, 2)
+		`, 4},
+		{`
+		class Foo
+		  def initialize(x)
+		    @x = x
+		  end
+		end
+
+		class Bar < Foo
+		  def initialize(x, y)
+		    super(x)
+		    @y = y
+		  end
+
+		  def sum
+		    @x + @y
+		  end
+		end
+
+		Bar.new(3, 4).sum
+		`, 7},
+		{`
+		class Foo
+		  def value
+		    1
+		  end
+		end
+
+		class Bar < Foo
+		  def value
+		    super + 10
+		  end
+		end
+
+		class Baz < Bar
+		  def value
+		    result = 0
+		    [1, 2].each do |i|
+		      result = result + super
+		    end
+		    result
+		  end
+		end
+
+		Baz.new.value
+		`, 22},
+		{`
+		class Foo
+		  def self.create
+		    5
+		  end
+		end
+
+		class Bar < Foo
+		  def self.create
+		    super * 2
+		  end
+		end
+
+		Bar.create
+		`, 10},
+		{`
+		class Foo
+		  def each_value
+		    yield(1)
+		  end
+		end
+
+		class Bar < Foo
+		  def each_value
+		    super
+		  end
+		end
+
+		result = 0
+		Bar.new.each_value do |v|
+		  result = v + 1
+		end
+		result
+		`, 2},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestEvalSuperErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		def foo
+		  super
+		end
+
+		foo
+		`, "NoMethodError: super: no superclass method `foo' for main"},
+		{`
+		super
+		`, "RuntimeError: super called outside of method"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestEvalMethodMissing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		class Finder
+		  def method_missing(name, x, y)
+		    if name == "add".to_sym
+		      x + y
+		    else
+		      0
+		    end
+		  end
+		end
+
+		Finder.new.add(1, 2)
+		`, 3},
+		{`
+		class Finder
+		  def self.method_missing(name)
+		    if name.to_s == "find_all"
+		      8
+		    else
+		      0
+		    end
+		  end
+		end
+
+		Finder.find_all
+		`, 8},
+		{`
+		class Base
+		  def method_missing(name)
+		    1
+		  end
+		end
+
+		class Sub < Base
+		  def foo
+		    2
+		  end
+		end
+
+		Sub.new.foo + Sub.new.bar
+		`, 3},
+		{`
+		class Recorder
+		  def initialize
+		    @count = 0
+		  end
+
+		  def count
+		    @count
+		  end
+
+		  def method_missing(name)
+		    @count = @count + 1
+		    yield(@count)
+		  end
+		end
+
+		r = Recorder.new
+		result = 0
+		r.foo do |c|
+		  result = result + c
+		end
+		r.bar do |c|
+		  result = result + c * 10
+		end
+		result
+		`, 21},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestEvalMethodMissingSuper(t *testing.T) {
+	input := `
+	class Proxy
+	  def method_missing(name)
+	    super
+	  end
+	end
+
+	Proxy.new.foo
+	`
+
+	err := testEvalError(t, New(), "", input)
+	expected := "NoMethodError: undefined method `foo' for <Instance of: Proxy>"
+
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expect error %q. got=%v", expected, err)
+	}
+}
+
+func TestEvalOperatorMethods(t *testing.T) {
+	vector := `
+	class Vector
+	  def initialize(x, y)
+	    @x = x
+	    @y = y
+	  end
+
+	  def x
+	    @x
+	  end
+
+	  def y
+	    @y
+	  end
+
+	  def +(other)
+	    Vector.new(@x + other.x, @y + other.y)
+	  end
+
+	  def -(other)
+	    Vector.new(@x - other.x, @y - other.y)
+	  end
+
+	  def *(n)
+	    Vector.new(@x * n, @y * n)
+	  end
+
+	  def ==(other)
+	    if @x == other.x
+	      @y == other.y
+	    else
+	      false
+	    end
+	  end
+
+	  def <(other)
+	    @x * @x + @y * @y < other.x * other.x + other.y * other.y
+	  end
+
+	  def [](i)
+	    if i == 0
+	      @x
+	    else
+	      @y
+	    end
+	  end
+
+	  def []=(i, v)
+	    if i == 0
+	      @x = v
+	    else
+	      @y = v
+	    end
+	  end
+
+	  def <<(n)
+	    @x = @x + n
+	    @y = @y + n
+	    self
+	  end
+	end
+	`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`(Vector.new(1, 2) + Vector.new(3, 4)).y`, 6},
+		{`(Vector.new(5, 5) - Vector.new(3, 4)).x`, 2},
+		{`(Vector.new(1, 2) * 3).y`, 6},
+		{`Vector.new(1, 2) == Vector.new(1, 2)`, true},
+		{`Vector.new(1, 2) != Vector.new(1, 2)`, false},
+		{`Vector.new(1, 2) != Vector.new(2, 1)`, true},
+		{`Vector.new(1, 2) < Vector.new(2, 2)`, true},
+		{`Vector.new(1, 2)[1]`, 2},
+		{`
+		v = Vector.new(1, 2)
+		v[0] = 10
+		v.x
+		`, 10},
+		{`
+		v = Vector.new(1, 2)
+		v << 1 << 2
+		v.y
+		`, 5},
+		{`
+		a = [1]
+		a << 2 << 3
+		a.length
+		`, 3},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, vector+tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+
+		if t.Failed() {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestEvalObjectEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`
+		o = Object.new
+		o == o
+		`, true},
+		{`Object.new == Object.new`, false},
+		{`Object.new != Object.new`, true},
+		{`Object != Integer`, true},
+	}
+
+	for i, tt := range tests {
+		if !testBooleanObject(t, testEval(t, tt.input), tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestEvalSend(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		class Calculator
+		  def add(x, y)
+		    x + y
+		  end
+		end
+
+		Calculator.new.send("add".to_sym, 1, 2)
+		`, 3},
+		{`
+		class Calculator
+		  def double(x)
+		    x * 2
+		  end
+		end
+
+		name = "dou" + "ble"
+		Calculator.new.send(name, 4)
+		`, 8},
+		{`
+		class Foo
+		  def self.bar
+		    5
+		  end
+		end
+
+		Foo.__send__("bar")
+		`, 5},
+		{`
+		def ten
+		  10
+		end
+
+		Object.new.send("ten")
+		`, 10},
+		{`
+		class Foo
+		  def each_value
+		    yield(3)
+		  end
+		end
+
+		Foo.new.send("send", "each_value") do |v|
+		  v + 1
+		end
+		`, 4},
+		{`
+		class Finder
+		  def method_missing(name, x)
+		    x
+		  end
+		end
+
+		Finder.new.send("find", 7)
+		`, 7},
+		{`
+		class Message
+		  def send(to)
+		    to + 1
+		  end
+		end
+
+		Message.new.send(1)
+		`, 2},
+		{`
+		[1, 2, 3].send("length")
+		`, 3},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestEvalSendErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Object.new.send`, "RuntimeError: no method name given"},
+		{`Object.new.send(1)`, "TypeError: 1 is not a symbol nor a string"},
+		{`Object.new.send("foo")`, "NoMethodError: undefined method `foo' for <Instance of: Object>"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
 func TestEvalIfExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -620,6 +1106,22 @@ func TestEvalPostfix(t *testing.T) {
 		{`
 		(1 + 2 * 3)++
 		`, 8},
+		{`
+		a = 1
+		b = a
+		a++
+		b
+		`, 1},
+		{`
+		@a = 5
+		@a++
+		@a++
+		`, 7},
+		{`
+		$a = 5
+		$a--
+		$a
+		`, 4},
 	}
 
 	for _, tt := range tests {
@@ -786,6 +1288,59 @@ func TestMethodCallWithBlockArgument(t *testing.T) {
 	}
 }
 
+func TestMethodCallWithDestructuredBlockArgument(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+				class Foo
+				  def bar
+				    yield([1, 3], 5)
+				  end
+				end
+
+				Foo.new.bar do |(first, second), third|
+				  first + second * third
+				end
+				`, 16},
+		{`
+				sum = 0
+
+				[[1, 2], [3, 4]].each_with_index do |(a, b), i|
+				  sum = sum + a * b * i
+				end
+
+				sum
+				`, 12},
+		{`
+				x = [[1, 2, 3]].map do |(a, b)|
+				  a + b
+				end
+				x.first
+				`, 3},
+		{`
+				x = [[1]].map do |(a, b)|
+				  [b].compact.length
+				end
+				x.first
+				`, 0},
+		{`
+				x = [10].map do |(a, b)|
+				  a
+				end
+				x.first
+				`, 10},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
 func TestMethodCallWithNestedBlock(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -842,3 +1397,84 @@ func TestMethodCallWithNestedBlock(t *testing.T) {
 		testIntegerObject(t, evaluated, tt.expected)
 	}
 }
+
+func TestTopLevelMethodCallableFromAnywhere(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		def hello(name)
+		  "Hello, " + name
+		end
+
+		class Foo
+		  def self.bar
+		    hello("class")
+		  end
+
+		  def baz
+		    hello("instance")
+		  end
+		end
+
+		Foo.bar + " " + Foo.new.baz
+		`, "Hello, class Hello, instance"},
+		{`
+		def ten
+		  10
+		end
+
+		[1, 2].each do |i|
+		  ten
+		end
+		ten + self.ten
+		`, 20},
+		{`
+		def ten
+		  10
+		end
+
+		begin
+		  Object.new.ten
+		rescue NoMethodError => e
+		  e.message
+		end
+		`, "private method `ten' called for <Instance of: Object>"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestMainObject(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`self.to_s`, "main"},
+		{`self.class.name`, "Object"},
+		{`
+		Foo = 10
+		class Bar
+		  def self.foo
+		    Object.const_get("Foo").to_s
+		  end
+		end
+		Bar.foo
+		`, "10"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testStringObject(t, evaluated, tt.expected)
+	}
+}
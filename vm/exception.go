@@ -0,0 +1,231 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+)
+
+var (
+	ExceptionClass         *RClass
+	StandardErrorClass     *RClass
+	RuntimeErrorClass      *RClass
+	TypeErrorClass         *RClass
+	ArgumentErrorClass     *RClass
+	IndexErrorClass        *RClass
+	KeyErrorClass          *RClass
+	RangeErrorClass        *RClass
+	RegexpErrorClass       *RClass
+	IOErrorClass           *RClass
+	NameErrorClass         *RClass
+	NoMethodErrorClass     *RClass
+	LocalJumpErrorClass    *RClass
+	ThreadErrorClass       *RClass
+	FrozenErrorClass       *RClass
+	ZeroDivisionErrorClass *RClass
+	FloatDomainErrorClass  *RClass
+	// YAMLSyntaxErrorClass is raised by YAML.load for documents it can't parse
+	YAMLSyntaxErrorClass *RClass
+	// DateErrorClass is raised for dates that don't exist, like Date.new(2023, 2, 29)
+	DateErrorClass *RClass
+	// EncodingErrorClass is the superclass of the errors String#encode raises for strings it can't convert
+	EncodingErrorClass            *RClass
+	UndefinedConversionErrorClass *RClass
+	InvalidByteSequenceErrorClass *RClass
+	// BudgetExceededErrorClass, ResourceLimitErrorClass, SystemStackErrorClass, SyntaxErrorClass, LoadErrorClass
+	// and SystemExitClass aren't StandardErrors, so bare `rescue` clauses won't catch them.
+	BudgetExceededErrorClass *RClass
+	ResourceLimitErrorClass  *RClass
+	SystemStackErrorClass    *RClass
+	SyntaxErrorClass         *RClass
+	LoadErrorClass           *RClass
+	SystemExitClass          *RClass
+)
+
+// raisedException carries a Rooby exception object through Go's call stack
+// until a call frame whose catch table protects the failed instruction rescues it.
+type raisedException struct {
+	exception *RObject
+}
+
+func (re *raisedException) Error() string {
+	return fmt.Sprintf("%s: %s", re.exception.Class.Name, exceptionMessage(re.exception))
+}
+
+// ErrorReport formats an error returned from Exec. Uncaught exceptions are reported like Ruby does:
+// the innermost location, message and class, followed by the rest of backtrace.
+func ErrorReport(err error) string {
+	re, ok := err.(*raisedException)
+
+	if !ok {
+		return err.Error()
+	}
+
+	var out bytes.Buffer
+	backtrace := exceptionBacktrace(re.exception)
+
+	if len(backtrace) > 0 {
+		out.WriteString(backtrace[0] + ": ")
+	}
+
+	out.WriteString(fmt.Sprintf("%s (%s)", exceptionMessage(re.exception), re.exception.Class.Name))
+
+	for i := 1; i < len(backtrace); i++ {
+		out.WriteString("\n\tfrom " + backtrace[i])
+	}
+
+	return out.String()
+}
+
+// raise initializes an exception of the given class and unwinds the stack with it.
+func (vm *VM) raise(class *RClass, format string, args ...interface{}) {
+	vm.raiseException(InitializeException(class, fmt.Sprintf(format, args...)))
+}
+
+// raiseException unwinds the stack with e. The backtrace is recorded when e is raised for the first time,
+// so re-raising an exception from a rescue or ensure clause keeps its original location.
+func (vm *VM) raiseException(e *RObject) {
+	if _, ok := e.InstanceVariables.Get("@backtrace"); !ok {
+		locations := []Object{}
+
+		for _, l := range vm.CallFrameStack.backtrace() {
+			locations = append(locations, InitializeString(l))
+		}
+
+		e.InstanceVariables.Set("@backtrace", InitializeArray(locations))
+	}
+
+	panic(&raisedException{exception: e})
+}
+
+// InitializeException returns an instance of the exception class with given message.
+func InitializeException(class *RClass, message string) *RObject {
+	e := InitializeInstance(class)
+	e.InstanceVariables.Set("@message", InitializeString(message))
+	return e
+}
+
+func exceptionMessage(e *RObject) string {
+	message, ok := e.InstanceVariables.Get("@message")
+
+	if !ok {
+		return e.Class.Name
+	}
+
+	return message.Inspect()
+}
+
+func exceptionBacktrace(e *RObject) []string {
+	backtrace, ok := e.InstanceVariables.Get("@backtrace")
+
+	if !ok {
+		return nil
+	}
+
+	locations := []string{}
+
+	for _, l := range backtrace.(*ArrayObject).Elements {
+		locations = append(locations, l.(*StringObject).Value)
+	}
+
+	return locations
+}
+
+var builtinExceptionClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				class := receiver.(*RClass)
+				message := class.Name
+
+				if len(args) > 0 {
+					message = args[0].Inspect()
+				}
+
+				e := InitializeException(class, message)
+				initMethod := vm.lookupInstanceMethod(class, "initialize")
+
+				if m, ok := initMethod.(*Method); ok {
+					e.InitializeMethod = m
+				}
+
+				return e
+			}
+		},
+		Name: "new",
+	},
+}
+
+var builtinExceptionMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return InitializeString(exceptionMessage(receiver.(*RObject)))
+			}
+		},
+		Name: "message",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				backtrace, ok := receiver.(*RObject).InstanceVariables.Get("@backtrace")
+
+				if !ok {
+					return NULL
+				}
+
+				return backtrace
+			}
+		},
+		Name: "backtrace",
+	},
+}
+
+func initException() {
+	ExceptionClass = InitializeClass("Exception")
+
+	for _, m := range builtinExceptionClassMethods {
+		ExceptionClass.ClassMethods.Set(m.Name, m)
+	}
+
+	for _, m := range builtinExceptionMethods {
+		ExceptionClass.Methods.Set(m.Name, m)
+	}
+
+	StandardErrorClass = initializeExceptionClass("StandardError", ExceptionClass)
+	RuntimeErrorClass = initializeExceptionClass("RuntimeError", StandardErrorClass)
+	TypeErrorClass = initializeExceptionClass("TypeError", StandardErrorClass)
+	ArgumentErrorClass = initializeExceptionClass("ArgumentError", StandardErrorClass)
+	IndexErrorClass = initializeExceptionClass("IndexError", StandardErrorClass)
+	KeyErrorClass = initializeExceptionClass("KeyError", IndexErrorClass)
+	RangeErrorClass = initializeExceptionClass("RangeError", StandardErrorClass)
+	RegexpErrorClass = initializeExceptionClass("RegexpError", StandardErrorClass)
+	IOErrorClass = initializeExceptionClass("IOError", StandardErrorClass)
+	NameErrorClass = initializeExceptionClass("NameError", StandardErrorClass)
+	NoMethodErrorClass = initializeExceptionClass("NoMethodError", NameErrorClass)
+	LocalJumpErrorClass = initializeExceptionClass("LocalJumpError", StandardErrorClass)
+	ThreadErrorClass = initializeExceptionClass("ThreadError", StandardErrorClass)
+	FrozenErrorClass = initializeExceptionClass("FrozenError", RuntimeErrorClass)
+	ZeroDivisionErrorClass = initializeExceptionClass("ZeroDivisionError", StandardErrorClass)
+	FloatDomainErrorClass = initializeExceptionClass("FloatDomainError", StandardErrorClass)
+	YAMLSyntaxErrorClass = initializeExceptionClass("YAML::SyntaxError", RuntimeErrorClass)
+	DateErrorClass = initializeExceptionClass("Date::Error", ArgumentErrorClass)
+	EncodingErrorClass = initializeExceptionClass("EncodingError", StandardErrorClass)
+	UndefinedConversionErrorClass = initializeExceptionClass("Encoding::UndefinedConversionError", EncodingErrorClass)
+	InvalidByteSequenceErrorClass = initializeExceptionClass("Encoding::InvalidByteSequenceError", EncodingErrorClass)
+	BudgetExceededErrorClass = initializeExceptionClass("BudgetExceededError", ExceptionClass)
+	ResourceLimitErrorClass = initializeExceptionClass("ResourceLimitError", ExceptionClass)
+	SystemStackErrorClass = initializeExceptionClass("SystemStackError", ExceptionClass)
+	SyntaxErrorClass = initializeExceptionClass("SyntaxError", ExceptionClass)
+	LoadErrorClass = initializeExceptionClass("LoadError", ExceptionClass)
+	SystemExitClass = initializeExceptionClass("SystemExit", ExceptionClass)
+
+	for _, m := range builtinSystemExitMethods {
+		SystemExitClass.Methods.Set(m.Name, m)
+	}
+}
+
+func initializeExceptionClass(name string, superClass *RClass) *RClass {
+	class := InitializeClass(name)
+	class.SuperClass = superClass
+	return class
+}
@@ -0,0 +1,576 @@
+package vm
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRescueException(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		begin
+		  raise("foo")
+		  10
+		rescue
+		  20
+		end
+		`, 20},
+		{`
+		begin
+		  10
+		rescue
+		  20
+		end
+		`, 10},
+		{`
+		begin
+		  raise(TypeError, "wrong type")
+		rescue RuntimeError
+		  "runtime"
+		rescue TypeError, RuntimeError => e
+		  e.message
+		end
+		`, "wrong type"},
+		{`
+		begin
+		  raise(RuntimeError)
+		rescue Exception => e
+		  e.class.name + ": " + e.message
+		end
+		`, "RuntimeError: RuntimeError"},
+		{`
+		e = RuntimeError.new("foo")
+
+		begin
+		  raise(e)
+		rescue StandardError => err
+		  err.message + "bar"
+		end
+		`, "foobar"},
+		{`
+		class MyError < StandardError
+		end
+
+		begin
+		  raise(MyError, "custom")
+		rescue MyError => e
+		  e.message
+		end
+		`, "custom"},
+		{`
+		begin
+		  begin
+		    raise(TypeError, "inner")
+		  rescue RuntimeError
+		    "wrong"
+		  end
+		rescue TypeError => e
+		  e.message + " rescued by outer"
+		end
+		`, "inner rescued by outer"},
+		{`
+		begin
+		  raise(10)
+		rescue TypeError => e
+		  e.message
+		end
+		`, "exception class/object expected"},
+		{`
+		begin
+		  raise("foo")
+		rescue
+		end
+		`, nil},
+		{`
+		begin
+		  raise("100% done")
+		rescue => e
+		  e.message
+		end
+		`, "100% done"},
+		{`
+		begin
+		  raise(ArgumentError, "50% off")
+		rescue ArgumentError => e
+		  e.message
+		end
+		`, "50% off"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		case nil:
+			if !testNullObject(t, evaluated) {
+				t.Fatalf("tests[%d] failed", i)
+			}
+		}
+	}
+}
+
+func TestRescueExceptionAcrossCallFrames(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		class Foo
+		  def bar(x)
+		    baz(x) + 1
+		  end
+
+		  def baz(x)
+		    if x > 10
+		      raise("too big")
+		    end
+		    x
+		  end
+		end
+
+		f = Foo.new
+		a = 100 + begin
+		  f.bar(20)
+		rescue
+		  10
+		end
+		a + f.bar(1)
+		`, 112},
+		{`
+		sum = 0
+		begin
+		  [1, 2, 3].each do |i|
+		    if i == 3
+		      raise("stop")
+		    end
+		    sum = sum + i
+		  end
+		rescue
+		  sum = sum * 10
+		end
+		sum
+		`, 30},
+		{`
+		class Foo
+		  def self.run
+		    yield
+		  end
+		end
+
+		begin
+		  Foo.run do
+		    raise(TypeError)
+		  end
+		  1
+		rescue TypeError
+		  2
+		end
+		`, 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEnsure(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		a = 0
+		b = begin
+		  10
+		ensure
+		  a = 5
+		end
+		a + b
+		`, 15},
+		{`
+		a = 0
+		b = begin
+		  raise("foo")
+		rescue
+		  20
+		ensure
+		  a = 5
+		end
+		a + b
+		`, 25},
+		{`
+		a = 0
+		begin
+		  begin
+		    raise("foo")
+		  ensure
+		    a = a + 1
+		  end
+		rescue
+		  a = a + 10
+		end
+		a
+		`, 11},
+		{`
+		a = 0
+		begin
+		  begin
+		    raise("foo")
+		  rescue
+		    raise(TypeError, "bar")
+		  ensure
+		    a = a + 1
+		  end
+		rescue TypeError
+		  a = a + 10
+		end
+		a
+		`, 11},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEnsureRunsOnReturn(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		def f
+		  begin
+		    return 1
+		  ensure
+		    puts("ensure ran")
+		  end
+		end
+		puts(f)
+		`, "ensure ran\n1\n"},
+		{`
+		def f
+		  begin
+		    begin
+		      return 1
+		    ensure
+		      puts("inner")
+		    end
+		  ensure
+		    puts("outer")
+		  end
+		  2
+		end
+		puts(f)
+		`, "inner\nouter\n1\n"},
+		{`
+		def f
+		  begin
+		    raise("foo")
+		  rescue
+		    return 1
+		  ensure
+		    puts("ensure ran")
+		  end
+		end
+		puts(f)
+		`, "ensure ran\n1\n"},
+		{`
+		def f
+		  begin
+		    return 1
+		  ensure
+		    begin
+		      puts("ensure ran")
+		    ensure
+		      return 2
+		    end
+		  end
+		end
+		puts(f)
+		`, "ensure ran\n2\n"},
+	}
+
+	for i, tt := range tests {
+		var buf bytes.Buffer
+		v := New()
+		v.SetStdout(&buf)
+		testEvalWithVM(t, v, tt.input)
+
+		if buf.String() != tt.expected {
+			t.Fatalf("at test case %d: expect %q. got=%q", i, tt.expected, buf.String())
+		}
+	}
+}
+
+func TestUncaughtException(t *testing.T) {
+	input := `
+	begin
+	  raise(TypeError, "foo")
+	rescue RuntimeError
+	  10
+	end
+	`
+
+	err := testEvalError(t, New(), "", input)
+	e, ok := err.(*raisedException)
+
+	if !ok {
+		t.Fatalf("Expect uncaught exception to be a raisedException. got=%T", err)
+	}
+
+	if e.Error() != "TypeError: foo" {
+		t.Fatalf("Expect error message to be %q. got=%q", "TypeError: foo", e.Error())
+	}
+}
+
+func TestExceptionBacktrace(t *testing.T) {
+	input := `
+def foo
+  raise("boom")
+end
+
+class Bar
+  def self.bar
+    [1].each do |i|
+      foo
+    end
+  end
+end
+
+Bar.bar
+`
+
+	expected := `foo.ro:3:in 'foo': boom (RuntimeError)
+	from foo.ro:9:in 'block in bar'
+	from foo.ro:8:in 'bar'
+	from foo.ro:14:in '<main>'`
+
+	err := testEvalError(t, New(), "foo.ro", input)
+
+	if err == nil {
+		t.Fatalf("Expect uncaught exception to be returned")
+	}
+
+	if report := ErrorReport(err); report != expected {
+		t.Fatalf("Expect error report to be:\n%s\ngot:\n%s", expected, report)
+	}
+}
+
+func TestRescuedExceptionBacktrace(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		def foo
+		  raise("boom")
+		end
+
+		begin
+		  foo
+		rescue => e
+		  e.backtrace[1]
+		end
+		`, "foo.ro:7:in '<main>'"},
+		{`
+		def foo
+		  begin
+		    raise("boom")
+		  ensure
+		    10
+		  end
+		end
+
+		begin
+		  foo
+		rescue => e
+		  e.backtrace[0]
+		end
+		`, "foo.ro:4:in 'foo'"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalFileWithVM(t, New(), "foo.ro", tt.input)
+		testStringObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestRuntimeErrorsAreRaised(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		begin
+		  Foo
+		rescue NameError => e
+		  e.message
+		end
+		`, "uninitialized constant Foo"},
+		{`
+		begin
+		  1.foo
+		rescue NameError => e
+		  e.class.name
+		end
+		`, "NoMethodError"},
+		{`
+		def foo
+		  yield
+		end
+
+		begin
+		  foo
+		rescue LocalJumpError => e
+		  e.message
+		end
+		`, "no block given (yield)"},
+		{`
+		Foo = 1
+
+		begin
+		  class Bar < Foo
+		  end
+		rescue TypeError => e
+		  e.message
+		end
+		`, "superclass must be a Class (1 given)"},
+		{`
+		begin
+		  Integer.new
+		rescue NoMethodError => e
+		  e.message
+		end
+		`, "undefined method `new' for <Class:Integer>"},
+		{`
+		begin
+		  x = [1, 2].first(1, 2)
+		  "not raised"
+		rescue ArgumentError => e
+		  e.message
+		end
+		`, "Expect 0 or 1 argument. got=2"},
+		{`
+		begin
+		  Object.const_get(1)
+		rescue TypeError => e
+		  e.message
+		end
+		`, "expect argument to be String type"},
+		{`
+		begin
+		  [1].each
+		rescue LocalJumpError => e
+		  e.message
+		end
+		`, "no block given (yield)"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testStringObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestExecReturnsError(t *testing.T) {
+	tests := []struct {
+		bytecodes string
+		expected  string
+	}{
+		{`
+<ProgramStart>
+0 getconstant Foo
+1 leave
+`, "NameError: uninitialized constant Foo"},
+		{`
+<ProgramStart>
+0 pop
+1 leave
+`, "internal error: Nothing to pop!"},
+	}
+
+	for _, tt := range tests {
+		v := New()
+		testLoadBytecodes(v, tt.bytecodes)
+		err := v.Exec()
+
+		if err == nil {
+			t.Fatalf("Expect Exec to return an error")
+		}
+
+		if err.Error() != tt.expected {
+			t.Fatalf("Expect error to be %q. got=%q", tt.expected, err.Error())
+		}
+
+		if v.CFP != 0 || v.SP != 0 {
+			t.Fatalf("Expect VM's stack to be restored. got CFP=%d, SP=%d", v.CFP, v.SP)
+		}
+	}
+}
+
+func TestSystemStackError(t *testing.T) {
+	input := `
+	def foo
+	  foo
+	end
+
+	begin
+	  foo
+	rescue SystemStackError => e
+	  e.message + " " + e.backtrace.length.to_s + " " + e.backtrace[50]
+	end
+	`
+
+	v := New()
+	v.MaxCallDepth = 200
+	evaluated := testEvalWithVM(t, v, input)
+	testStringObject(t, evaluated, "stack level too deep 101 ... 100 levels...")
+}
+
+func TestSystemStackErrorIsNotStandardError(t *testing.T) {
+	input := `
+	def foo
+	  begin
+	    foo
+	  rescue
+	    10
+	  end
+	end
+
+	foo
+	`
+
+	v := New()
+	v.MaxCallDepth = 100
+	err := testEvalError(t, v, "", input)
+
+	if err == nil || err.Error() != "SystemStackError: stack level too deep" {
+		t.Fatalf("Expect SystemStackError to be returned. got=%v", err)
+	}
+}
+
+func TestDefaultMaxCallDepth(t *testing.T) {
+	input := `
+	def foo
+	  foo
+	end
+
+	foo
+	`
+
+	err := testEvalError(t, New(), "", input)
+
+	if err == nil || err.Error() != "SystemStackError: stack level too deep" {
+		t.Fatalf("Expect SystemStackError to be returned. got=%v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package vm
+
+import (
+	"fmt"
+)
+
+// ExitError is returned from Exec and RunExitHandlers when the program calls `exit` or `exit!`.
+type ExitError struct {
+	Status int
+	// immediate is set by `exit!`, which skips at_exit handlers
+	immediate bool
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Status)
+}
+
+// exitNow is panicked with by `exit!`. Unlike SystemExit it can't be rescued and doesn't run ensure clauses.
+type exitNow struct {
+	status int
+}
+
+// ExitStatus returns the status a process should exit with after the program returned err: 0 if it finished,
+// the status it called `exit` with, or 1 if it failed.
+func ExitStatus(err error) int {
+	switch err := err.(type) {
+	case nil:
+		return 0
+	case *ExitError:
+		return err.Status
+	}
+
+	return 1
+}
+
+// RunExitHandlers runs the blocks registered by at_exit in reverse order, after the program returned err from Exec.
+// It returns err, or the error of the last handler that failed or called `exit`, like Ruby. Nothing is run if the
+// program called `exit!`.
+func (vm *VM) RunExitHandlers(err error) error {
+	if e, ok := err.(*ExitError); ok && e.immediate {
+		return err
+	}
+
+	for len(vm.exitHandlers) > 0 {
+		last := len(vm.exitHandlers) - 1
+		handler := vm.exitHandlers[last]
+		vm.exitHandlers = vm.exitHandlers[:last]
+
+		if handlerErr := vm.runExitHandler(handler); handlerErr != nil {
+			err = handlerErr
+
+			if e, ok := err.(*ExitError); ok && e.immediate {
+				return err
+			}
+		}
+	}
+
+	return err
+}
+
+func (vm *VM) runExitHandler(blockFrame *CallFrame) (err error) {
+	cfp, sp := vm.CFP, vm.SP
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = vm.errorFromPanic(r)
+			vm.unwindTo(cfp, sp)
+		}
+	}()
+
+	vm.builtInMethodYield(blockFrame)
+
+	return nil
+}
+
+// exitStatusArgument returns the status given to `exit` or `exit!`, which is an integer, or true for 0 and false for 1.
+func (vm *VM) exitStatusArgument(args []Object, status int) int {
+	if len(args) == 0 {
+		return status
+	}
+
+	switch arg := args[0].(type) {
+	case *BooleanObject:
+		if arg.Value {
+			return 0
+		}
+
+		return 1
+	case *IntegerObject:
+		return vm.integerArgument(arg)
+	}
+
+	vm.raise(TypeErrorClass, "wrong argument type %s (expected Integer or Boolean)", vm.inspectForError(args[0]))
+	return 0
+}
+
+// exitStatusOf returns the status of a SystemExit exception.
+func exitStatusOf(e *RObject) int {
+	if status, ok := e.InstanceVariables.Get("@status"); ok {
+		if i, ok := status.(*IntegerObject); ok {
+			return i.Value
+		}
+	}
+
+	return 0
+}
+
+var builtinSystemExitMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return vm.initInteger(exitStatusOf(receiver.(*RObject)))
+			}
+		},
+		Name: "status",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return toBooleanObject(exitStatusOf(receiver.(*RObject)) == 0)
+			}
+		},
+		Name: "success?",
+	},
+}
@@ -0,0 +1,163 @@
+package vm
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestExit(t *testing.T) {
+	tests := []struct {
+		input  string
+		status int
+		output string
+	}{
+		{`
+		puts("a")
+		exit
+		puts("b")
+		`, 0, "a\n"},
+		{`exit(3)`, 3, ""},
+		{`exit(true)`, 0, ""},
+		{`exit(false)`, 1, ""},
+		{`exit!`, 1, ""},
+		{`exit!(0)`, 0, ""},
+		{`
+		begin
+		  exit(2)
+		rescue => e
+		  puts("rescued")
+		ensure
+		  puts("ensure")
+		end
+		`, 2, "ensure\n"},
+		{`
+		begin
+		  exit!(2)
+		ensure
+		  puts("ensure")
+		end
+		`, 2, ""},
+		{`
+		begin
+		  exit(2)
+		rescue SystemExit => e
+		  puts(e.status)
+		  puts(e.success?)
+		end
+		exit(e.status + 1)
+		`, 3, "2\nfalse\n"},
+	}
+
+	for i, tt := range tests {
+		var stdout bytes.Buffer
+		v := New()
+		v.SetStdout(&stdout)
+
+		err := v.RunExitHandlers(testEvalError(t, v, "", tt.input))
+
+		if _, ok := err.(*ExitError); !ok {
+			t.Fatalf("at test case %d: expect an ExitError. got=%v", i, err)
+		}
+
+		if ExitStatus(err) != tt.status {
+			t.Fatalf("at test case %d: expect exit status %d. got=%d", i, tt.status, ExitStatus(err))
+		}
+
+		if stdout.String() != tt.output {
+			t.Fatalf("at test case %d: expect output %q. got=%q", i, tt.output, stdout.String())
+		}
+	}
+}
+
+func TestExitHandlers(t *testing.T) {
+	tests := []struct {
+		input  string
+		status int
+		output string
+	}{
+		{`
+		at_exit() do
+		  puts("first")
+		end
+		at_exit() do
+		  puts("second")
+		end
+		puts("main")
+		`, 0, "main\nsecond\nfirst\n"},
+		{`
+		at_exit() do
+		  puts("handler")
+		end
+		exit(5)
+		`, 5, "handler\n"},
+		{`
+		at_exit() do
+		  puts("handler")
+		end
+		exit!(5)
+		`, 5, ""},
+		{`
+		at_exit() do
+		  puts("handler")
+		end
+		raise("boom")
+		`, 1, "handler\n"},
+		{`
+		at_exit() do
+		  puts("first")
+		end
+		at_exit() do
+		  exit(7)
+		end
+		`, 7, "first\n"},
+		{`
+		at_exit() do
+		  puts("first")
+		end
+		at_exit() do
+		  raise("boom")
+		end
+		`, 1, "first\n"},
+	}
+
+	for i, tt := range tests {
+		var stdout bytes.Buffer
+		v := New()
+		v.SetStdout(&stdout)
+
+		err := v.RunExitHandlers(testEvalError(t, v, "", tt.input))
+
+		if ExitStatus(err) != tt.status {
+			t.Fatalf("at test case %d: expect exit status %d. got=%d (%v)", i, tt.status, ExitStatus(err), err)
+		}
+
+		if stdout.String() != tt.output {
+			t.Fatalf("at test case %d: expect output %q. got=%q", i, tt.output, stdout.String())
+		}
+	}
+}
+
+func TestExitHandlersAreClearedByReset(t *testing.T) {
+	var stdout bytes.Buffer
+	v := New()
+	v.SetStdout(&stdout)
+
+	testEvalError(t, v, "", `
+	at_exit() do
+	  puts("handler")
+	end
+	`)
+	v.Reset()
+
+	if err := v.RunExitHandlers(nil); err != nil || stdout.String() != "" {
+		t.Fatalf("Expect no handler to run. got output %q and error %v", stdout.String(), err)
+	}
+}
+
+func TestExitErrors(t *testing.T) {
+	err := testEvalError(t, New(), "", `exit("1")`)
+
+	if err == nil || err.Error() != "TypeError: wrong argument type 1 (expected Integer or Boolean)" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,343 @@
+package vm
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+var (
+	FileClass *RFile
+)
+
+type RFile struct {
+	*BaseClass
+}
+
+// FileObject is a file opened by File.open, it has to be closed by the program unless File.open is given a block.
+type FileObject struct {
+	Class  *RFile
+	File   *os.File
+	path   string
+	reader *bufio.Reader
+	closed bool
+}
+
+func (f *FileObject) Type() ObjectType {
+	return FILE_OBJ
+}
+
+func (f *FileObject) Inspect() string {
+	if f.closed {
+		return "#<File:" + f.path + " (closed)>"
+	}
+
+	return "#<File:" + f.path + ">"
+}
+
+func (f *FileObject) ReturnClass() Class {
+	return f.Class
+}
+
+// fileModes are the modes File.open accepts, and the flags they open files with.
+var fileModes = map[string]int{
+	"r":  os.O_RDONLY,
+	"r+": os.O_RDWR,
+	"w":  os.O_WRONLY | os.O_CREATE | os.O_TRUNC,
+	"w+": os.O_RDWR | os.O_CREATE | os.O_TRUNC,
+	"a":  os.O_WRONLY | os.O_CREATE | os.O_APPEND,
+	"a+": os.O_RDWR | os.O_CREATE | os.O_APPEND,
+}
+
+// openFile opens the file at path with a mode like "r" or "w", and raises IOError if it can't be opened.
+func (vm *VM) openFile(path, mode string) *FileObject {
+	flag, ok := fileModes[mode]
+
+	if !ok {
+		vm.raise(ArgumentErrorClass, "invalid access mode %s", mode)
+	}
+
+	f, err := os.OpenFile(path, flag, 0666)
+
+	if err != nil {
+		vm.raise(IOErrorClass, "%s", err.Error())
+	}
+
+	return &FileObject{Class: FileClass, File: f, path: path}
+}
+
+// checkOpen raises IOError if f is closed.
+func (vm *VM) checkOpen(f *FileObject) {
+	if f.closed {
+		vm.raise(IOErrorClass, "closed stream")
+	}
+}
+
+func (f *FileObject) bufferedReader() *bufio.Reader {
+	if f.reader == nil {
+		f.reader = bufio.NewReader(f.File)
+	}
+
+	return f.reader
+}
+
+func (f *FileObject) close() {
+	if !f.closed {
+		f.File.Close()
+		f.closed = true
+	}
+}
+
+var builtinFileClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				content, err := ioutil.ReadFile(vm.stringArgument(args[0]))
+
+				if err != nil {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				return InitializeString(string(content))
+			}
+		},
+		Name: "read",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 2 {
+					return newError("Expect 2 arguments. got=%d", len(args))
+				}
+
+				path := vm.stringArgument(args[0])
+				content := vm.stringArgument(args[1])
+
+				if err := ioutil.WriteFile(path, []byte(content), 0666); err != nil {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				return vm.initInteger(len(content))
+			}
+		},
+		Name: "write",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) < 1 || len(args) > 2 {
+					return newError("Expect 1 or 2 arguments. got=%d", len(args))
+				}
+
+				mode := "r"
+
+				if len(args) == 2 {
+					mode = vm.stringArgument(args[1])
+				}
+
+				f := vm.openFile(vm.stringArgument(args[0]), mode)
+
+				if blockFrame == nil {
+					return f
+				}
+
+				// the file is closed even if the block raises an error
+				defer f.close()
+
+				return vm.builtInMethodYield(blockFrame, f).Target
+			}
+		},
+		Name: "open",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				_, err := os.Stat(vm.stringArgument(args[0]))
+				return toBooleanObject(err == nil)
+			}
+		},
+		Name: "exist?",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				info, err := os.Stat(vm.stringArgument(args[0]))
+
+				if err != nil {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				return vm.initInteger(int(info.Size()))
+			}
+		},
+		Name: "size",
+	},
+}
+
+var builtinFileMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				f := receiver.(*FileObject)
+				vm.checkOpen(f)
+
+				content, err := ioutil.ReadAll(f.bufferedReader())
+
+				if err != nil {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				return InitializeString(string(content))
+			}
+		},
+		Name: "read",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				f := receiver.(*FileObject)
+				vm.checkOpen(f)
+
+				n, err := f.File.WriteString(vm.stringArgument(args[0]))
+
+				if err != nil {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				return vm.initInteger(n)
+			}
+		},
+		Name: "write",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (each_line)")
+				}
+
+				f := receiver.(*FileObject)
+				vm.checkOpen(f)
+				r := f.bufferedReader()
+
+				for {
+					line, err := r.ReadString('\n')
+
+					if line != "" {
+						vm.builtInMethodYield(blockFrame, InitializeString(line))
+					}
+
+					if err == io.EOF {
+						break
+					}
+
+					if err != nil {
+						vm.raise(IOErrorClass, "%s", err.Error())
+					}
+				}
+
+				return f
+			}
+		},
+		Name: "each_line",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				f := receiver.(*FileObject)
+				vm.checkOpen(f)
+
+				info, err := f.File.Stat()
+
+				if err != nil {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				return vm.initInteger(int(info.Size()))
+			}
+		},
+		Name: "size",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return InitializeString(receiver.(*FileObject).path)
+			}
+		},
+		Name: "path",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				receiver.(*FileObject).close()
+				return NULL
+			}
+		},
+		Name: "close",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return toBooleanObject(receiver.(*FileObject).closed)
+			}
+		},
+		Name: "closed?",
+	},
+}
+
+func initFile() {
+	methods := NewEnvironment()
+	classMethods := NewEnvironment()
+
+	for _, m := range builtinFileMethods {
+		methods.Set(m.Name, m)
+	}
+
+	for _, m := range builtinFileClassMethods {
+		classMethods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "File", Methods: methods, ClassMethods: classMethods, Class: ClassClass, SuperClass: ObjectClass}
+	FileClass = &RFile{BaseClass: bc}
+}
@@ -0,0 +1,191 @@
+package vm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileClassMethods(t *testing.T) {
+	dir := testLibrary(t, map[string]string{
+		"hello.txt": "Hello\nWorld\n",
+	})
+	defer os.RemoveAll(dir)
+
+	hello := filepath.Join(dir, "hello.txt")
+	output := filepath.Join(dir, "output.txt")
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{fmt.Sprintf(`File.read("%s")`, hello), "Hello\nWorld\n"},
+		{fmt.Sprintf(`File.exist?("%s")`, hello), true},
+		{fmt.Sprintf(`File.exist?("%s")`, output), false},
+		{fmt.Sprintf(`File.size("%s")`, hello), 12},
+		{fmt.Sprintf(`File.write("%s", "foo bar")`, output), 7},
+		{fmt.Sprintf(`
+		File.write("%s", "foo")
+		File.read("%s")
+		`, output, output), "foo"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case string:
+			if !testStringObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		case int:
+			if !testIntegerObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		case bool:
+			if !testBooleanObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestFileOpen(t *testing.T) {
+	dir := testLibrary(t, map[string]string{
+		"lines.txt": "one\ntwo\nthree",
+	})
+	defer os.RemoveAll(dir)
+
+	lines := filepath.Join(dir, "lines.txt")
+	output := filepath.Join(dir, "output.txt")
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{fmt.Sprintf(`
+		count = 0
+		File.open("%s") do |f|
+		  f.each_line do |line|
+		    count = count + 1
+		  end
+		end
+		count
+		`, lines), 3},
+		{fmt.Sprintf(`
+		result = []
+		File.open("%s") do |f|
+		  f.each_line do |line|
+		    result.push(line)
+		  end
+		end
+		result[2]
+		`, lines), "three"},
+		{fmt.Sprintf(`
+		File.open("%s", "r") do |f|
+		  f.read
+		end
+		`, lines), "one\ntwo\nthree"},
+		{fmt.Sprintf(`
+		file = File.open("%s")
+		File.open("%s") do |f|
+		  file = f
+		end
+		file.closed?
+		`, lines, lines), true},
+		{fmt.Sprintf(`
+		f = File.open("%s")
+		s = f.size
+		f.close
+		s
+		`, lines), 13},
+		{fmt.Sprintf(`
+		File.open("%s", "w") do |f|
+		  f.write("a")
+		  f.write("b")
+		end
+		File.open("%s", "a") do |f|
+		  f.write("c")
+		end
+		File.read("%s")
+		`, output, output, output), "abc"},
+		{fmt.Sprintf(`File.open("%s").path`, lines), lines},
+		{fmt.Sprintf(`File.open("%s").closed?`, lines), false},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case string:
+			if !testStringObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		case int:
+			if !testIntegerObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		case bool:
+			if !testBooleanObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestFileIsClosedWhenBlockRaises(t *testing.T) {
+	dir := testLibrary(t, map[string]string{
+		"a.txt": "a",
+	})
+	defer os.RemoveAll(dir)
+
+	evaluated := testEval(t, fmt.Sprintf(`
+	file = File.open("%s")
+	begin
+	  File.open("%s") do |f|
+	    file = f
+	    raise(RuntimeError, "boom")
+	  end
+	rescue RuntimeError
+	end
+	file.closed?
+	`, filepath.Join(dir, "a.txt"), filepath.Join(dir, "a.txt")))
+
+	testBooleanObject(t, evaluated, true)
+}
+
+func TestFileErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rooby-file")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	missing := filepath.Join(dir, "missing.txt")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{fmt.Sprintf(`File.read("%s")`, missing), fmt.Sprintf("IOError: open %s: no such file or directory", missing)},
+		{fmt.Sprintf(`File.open("%s", "x")`, missing), "ArgumentError: invalid access mode x"},
+		{fmt.Sprintf(`File.write("%s", 1)`, missing), "TypeError: wrong argument type 1 (expected String)"},
+		{fmt.Sprintf(`
+		f = File.open("%s", "w")
+		f.close
+		f.write("a")
+		`, missing), "IOError: closed stream"},
+		{fmt.Sprintf(`File.open("%s", "w").each_line`, missing), "LocalJumpError: no block given (each_line)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
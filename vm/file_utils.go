@@ -0,0 +1,186 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// FileUtilsModule creates, copies, moves and removes files and directories.
+var FileUtilsModule *RClass
+
+// paths returns the paths arg refers to, which is a path or an array of paths.
+func (vm *VM) paths(arg Object) []string {
+	arr, ok := arg.(*ArrayObject)
+
+	if !ok {
+		return []string{vm.stringArgument(arg)}
+	}
+
+	paths := []string{}
+
+	for _, el := range arr.Elements {
+		paths = append(paths, vm.stringArgument(el))
+	}
+
+	return paths
+}
+
+// copyFile copies the content and the permissions of the file src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	info, err := in.Stat()
+
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return &os.PathError{Op: "cp", Path: src, Err: syscall.EISDIR}
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// copyTree copies src to dst, with everything in it if it's a directory.
+func copyTree(src, dst string) error {
+	// copying a directory into itself would never end
+	if rel, err := filepath.Rel(src, dst); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("cannot copy directory %s to itself %s", src, dst)
+	}
+
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+
+		return copyFile(path, target)
+	})
+}
+
+// touch creates the file path if it doesn't exist, or sets its modification time to now.
+func touch(path string) error {
+	now := time.Now()
+
+	if err := os.Chtimes(path, now, now); !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0666)
+
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// fileUtilsPathsMethod returns a built in method that calls fn with each path of its argument, and returns the argument.
+func fileUtilsPathsMethod(name string, fn func(path string) error) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				for _, path := range vm.paths(args[0]) {
+					if err := fn(path); err != nil {
+						vm.raise(IOErrorClass, "%s", err.Error())
+					}
+				}
+
+				return args[0]
+			}
+		},
+		Name: name,
+	}
+}
+
+// fileUtilsCopyMethod returns a built in method that calls fn with each source path and its destination.
+// Like the cp command, sources are put into the destination if it's a directory, which it has to be if there are many sources.
+func fileUtilsCopyMethod(name string, fn func(src, dst string) error) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 2 {
+					return newError("Expect 2 arguments. got=%d", len(args))
+				}
+
+				sources := vm.paths(args[0])
+				dst := vm.stringArgument(args[1])
+				info, err := os.Stat(dst)
+				isDir := err == nil && info.IsDir()
+
+				if _, ok := args[0].(*ArrayObject); ok && !isDir {
+					vm.raise(IOErrorClass, "not a directory - %s", dst)
+				}
+
+				for _, src := range sources {
+					target := dst
+
+					if isDir {
+						target = filepath.Join(dst, filepath.Base(src))
+					}
+
+					if err := fn(src, target); err != nil {
+						vm.raise(IOErrorClass, "%s", err.Error())
+					}
+				}
+
+				return NULL
+			}
+		},
+		Name: name,
+	}
+}
+
+// builtinFileUtilsModuleMethods take a path or an array of paths. `rm` raises IOError if a file doesn't exist,
+// while `rm_rf` ignores it and removes directories with everything in them.
+var builtinFileUtilsModuleMethods = []*BuiltInMethod{
+	fileUtilsPathsMethod("mkdir_p", func(path string) error { return os.MkdirAll(path, 0777) }),
+	fileUtilsPathsMethod("rm", os.Remove),
+	fileUtilsPathsMethod("rm_rf", os.RemoveAll),
+	fileUtilsPathsMethod("touch", touch),
+	fileUtilsCopyMethod("cp", copyFile),
+	fileUtilsCopyMethod("cp_r", copyTree),
+	fileUtilsCopyMethod("mv", os.Rename),
+}
+
+func initFileUtils() {
+	FileUtilsModule = initializeBuiltinModule("FileUtils", builtinFileUtilsModuleMethods)
+}
@@ -0,0 +1,120 @@
+package vm
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestFileUtils(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		FileUtils.mkdir_p("%[1]s/a/b/c")
+		File.exist?("%[1]s/a/b/c")
+		`, true},
+		{`
+		FileUtils.mkdir_p(["%[1]s/x", "%[1]s/y"])
+		File.exist?("%[1]s/y")
+		`, true},
+		{`FileUtils.mkdir_p(["%[1]s/lib"]).length`, 1},
+		{`
+		FileUtils.touch("%[1]s/new.txt")
+		File.size("%[1]s/new.txt")
+		`, 0},
+		{`
+		FileUtils.touch("%[1]s/hello.txt")
+		File.read("%[1]s/hello.txt")
+		`, "Hello"},
+		{`
+		FileUtils.cp("%[1]s/hello.txt", "%[1]s/copy.txt")
+		File.read("%[1]s/copy.txt")
+		`, "Hello"},
+		{`
+		FileUtils.cp("%[1]s/hello.txt", "%[1]s/lib")
+		File.read("%[1]s/lib/hello.txt")
+		`, "Hello"},
+		{`
+		FileUtils.cp(["%[1]s/hello.txt", "%[1]s/lib/util.ro"], "%[1]s/lib/nested")
+		File.read("%[1]s/lib/nested/util.ro")
+		`, "util"},
+		{`
+		FileUtils.cp_r("%[1]s/lib", "%[1]s/lib2")
+		File.read("%[1]s/lib2/nested/util.ro")
+		`, "nested"},
+		{`
+		FileUtils.mkdir_p("%[1]s/out")
+		FileUtils.cp_r("%[1]s/lib", "%[1]s/out")
+		File.read("%[1]s/out/lib/nested/util.ro")
+		`, "nested"},
+		{`
+		FileUtils.mv("%[1]s/hello.txt", "%[1]s/moved.txt")
+		File.exist?("%[1]s/hello.txt")
+		`, false},
+		{`
+		FileUtils.mv("%[1]s/hello.txt", "%[1]s/lib")
+		File.read("%[1]s/lib/hello.txt")
+		`, "Hello"},
+		{`
+		FileUtils.rm("%[1]s/hello.txt")
+		File.exist?("%[1]s/hello.txt")
+		`, false},
+		{`
+		FileUtils.rm_rf(["%[1]s/lib", "%[1]s/nope"])
+		File.exist?("%[1]s/lib/nested/util.ro")
+		`, false},
+	}
+
+	for i, tt := range tests {
+		dir := testLibrary(t, map[string]string{
+			"hello.txt":          "Hello",
+			"lib/util.ro":        "util",
+			"lib/nested/util.ro": "nested",
+		})
+
+		evaluated := testEval(t, fmt.Sprintf(tt.input, dir))
+		os.RemoveAll(dir)
+
+		switch expected := tt.expected.(type) {
+		case string:
+			testStringObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		default:
+			t.Fatalf("at test case %d: unexpected expectation %v", i, expected)
+		}
+	}
+}
+
+func TestFileUtilsErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`FileUtils.rm("%[1]s/nope")`, "IOError: remove %[1]s/nope: no such file or directory"},
+		{`FileUtils.cp("%[1]s/nope", "%[1]s/copy")`, "IOError: open %[1]s/nope: no such file or directory"},
+		{`FileUtils.cp("%[1]s/lib", "%[1]s/copy")`, "IOError: cp %[1]s/lib: is a directory"},
+		{`FileUtils.cp(["%[1]s/hello.txt"], "%[1]s/copy")`, "IOError: not a directory - %[1]s/copy"},
+		{`FileUtils.mkdir_p("%[1]s/hello.txt/a")`, "IOError: mkdir %[1]s/hello.txt: not a directory"},
+		{`FileUtils.touch(["%[1]s/a", 1])`, "TypeError: wrong argument type 1 (expected String)%.0[1]s"},
+		{`FileUtils.cp_r("%[1]s/lib", "%[1]s/lib/nested")`, "IOError: cannot copy directory %[1]s/lib to itself %[1]s/lib/nested"},
+	}
+
+	for i, tt := range tests {
+		dir := testLibrary(t, map[string]string{
+			"hello.txt":   "Hello",
+			"lib/util.ro": "util",
+		})
+
+		err := testEvalError(t, New(), "", fmt.Sprintf(tt.input, dir))
+		os.RemoveAll(dir)
+
+		if err == nil || err.Error() != fmt.Sprintf(tt.expected, dir) {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, fmt.Sprintf(tt.expected, dir), err)
+		}
+	}
+}
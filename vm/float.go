@@ -0,0 +1,329 @@
+package vm
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	FloatClass *RFloat
+)
+
+type RFloat struct {
+	*BaseClass
+}
+
+type FloatObject struct {
+	Class *RFloat
+	Value float64
+}
+
+func (f *FloatObject) Type() ObjectType {
+	return FLOAT_OBJ
+}
+
+// Inspect formats floats like Ruby does, they always have a decimal point, and very large or small ones have an exponent.
+func (f *FloatObject) Inspect() string {
+	v := f.Value
+
+	switch {
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	format := byte('f')
+
+	if abs := math.Abs(v); abs >= 1e16 || (abs != 0 && abs < 1e-4) {
+		format = 'e'
+	}
+
+	s := strconv.FormatFloat(v, format, -1, 64)
+	mantissa, exponent := s, ""
+
+	if i := strings.IndexByte(s, 'e'); i >= 0 {
+		mantissa, exponent = s[:i], s[i:]
+	}
+
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+
+	return mantissa + exponent
+}
+
+func (f *FloatObject) ReturnClass() Class {
+	return f.Class
+}
+
+func InitializeFloat(value float64) *FloatObject {
+	return &FloatObject{Value: value, Class: FloatClass}
+}
+
+// toFloat returns the value of an Integer, a Float, a Rational or a BigDecimal as a float.
+func toFloat(o Object) (float64, bool) {
+	switch o := o.(type) {
+	case *IntegerObject:
+		return o.float(), true
+	case *FloatObject:
+		return o.Value, true
+	case *RationalObject:
+		f, _ := o.value.Float64()
+		return f, true
+	case *BigDecimalObject:
+		f, _ := strconv.ParseFloat(o.plain(), 64)
+		return f, true
+	}
+
+	return 0, false
+}
+
+// floatToInteger converts value to an Integer, infinite numbers and NaN can't be converted.
+func (vm *VM) floatToInteger(value float64) *IntegerObject {
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		vm.raise(FloatDomainErrorClass, "%s", InitializeFloat(value).Inspect())
+	}
+
+	if value < float64(minInt) || value >= float64(maxInt) {
+		n, _ := new(big.Float).SetFloat64(value).Int(nil)
+		return vm.initBigInteger(n)
+	}
+
+	return vm.initInteger(int(value))
+}
+
+// floatModulo returns the remainder of x / y, whose sign is the same as y's like Ruby's modulo.
+func floatModulo(x, y float64) float64 {
+	r := math.Mod(x, y)
+
+	if r != 0 && (r < 0) != (y < 0) {
+		r += y
+	}
+
+	return r
+}
+
+// floatOperator returns a built in method of Float that applies fn to the receiver and its argument,
+// which can be an Integer or a Float.
+func floatOperator(name string, fn func(left, right float64) Object) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, FloatClass, name)
+
+				if err != nil {
+					return err
+				}
+
+				leftValue := receiver.(*FloatObject).Value
+				rightValue, ok := toFloat(args[0])
+
+				if !ok {
+					return wrongTypeError(FloatClass)
+				}
+
+				return fn(leftValue, rightValue)
+			}
+		},
+		Name: name,
+	}
+}
+
+var builtinFloatMethods = []*BuiltInMethod{
+	floatOperator("+", func(left, right float64) Object {
+		return InitializeFloat(left + right)
+	}),
+	floatOperator("-", func(left, right float64) Object {
+		return InitializeFloat(left - right)
+	}),
+	floatOperator("*", func(left, right float64) Object {
+		return InitializeFloat(left * right)
+	}),
+	floatOperator("/", func(left, right float64) Object {
+		return InitializeFloat(left / right)
+	}),
+	floatOperator("**", func(left, right float64) Object {
+		return InitializeFloat(math.Pow(left, right))
+	}),
+	floatOperator("%", func(left, right float64) Object {
+		return InitializeFloat(floatModulo(left, right))
+	}),
+	floatOperator(">", func(left, right float64) Object {
+		return toBooleanObject(left > right)
+	}),
+	floatOperator("<", func(left, right float64) Object {
+		return toBooleanObject(left < right)
+	}),
+	floatOperator(">=", func(left, right float64) Object {
+		return toBooleanObject(left >= right)
+	}),
+	floatOperator("<=", func(left, right float64) Object {
+		return toBooleanObject(left <= right)
+	}),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, FloatClass, "==")
+
+				if err != nil {
+					return err
+				}
+
+				right, ok := toFloat(args[0])
+
+				// floats are never equal to objects that aren't numbers
+				if !ok {
+					return toBooleanObject(false)
+				}
+
+				return toBooleanObject(receiver.(*FloatObject).Value == right)
+			}
+		},
+		Name: "==",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, FloatClass, "!=")
+
+				if err != nil {
+					return err
+				}
+
+				right, ok := toFloat(args[0])
+
+				// floats are never equal to objects that aren't numbers
+				if !ok {
+					return toBooleanObject(true)
+				}
+
+				return toBooleanObject(receiver.(*FloatObject).Value != right)
+			}
+		},
+		Name: "!=",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				value := receiver.(*FloatObject).Value
+
+				if len(args) == 0 {
+					return vm.floatToInteger(math.Trunc(value + 0.5*sign(value)))
+				}
+
+				digits := vm.integerArgument(args[0])
+
+				// round half away from zero at the given decimal digit, like Ruby's Float#round
+				scale := math.Pow(10, float64(digits))
+				rounded := math.Floor(math.Abs(value)*scale+0.5) / scale
+
+				return InitializeFloat(math.Copysign(rounded, value))
+			}
+		},
+		Name: "round",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.floatToInteger(math.Floor(receiver.(*FloatObject).Value))
+			}
+		},
+		Name: "floor",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.floatToInteger(math.Ceil(receiver.(*FloatObject).Value))
+			}
+		},
+		Name: "ceil",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.floatToInteger(math.Trunc(receiver.(*FloatObject).Value))
+			}
+		},
+		Name: "to_i",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return receiver
+			}
+		},
+		Name: "to_f",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				// the exact value of the float, so 0.1.to_r isn't 1/10
+				r, _ := vm.toRational(receiver, false)
+
+				return vm.initRational(r)
+			}
+		},
+		Name: "to_r",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return InitializeString(receiver.(*FloatObject).Inspect())
+			}
+		},
+		Name: "to_s",
+	},
+}
+
+// sign returns 1 for positive numbers and -1 for negative ones, so rounding can go away from zero.
+func sign(value float64) float64 {
+	if value < 0 {
+		return -1
+	}
+
+	return 1
+}
+
+func initFloat() {
+	methods := NewEnvironment()
+
+	for _, m := range builtinFloatMethods {
+		methods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "Float", Methods: methods, ClassMethods: NewEnvironment(), Class: ClassClass, SuperClass: ObjectClass}
+	FloatClass = &RFloat{BaseClass: bc}
+}
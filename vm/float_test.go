@@ -0,0 +1,175 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestFloatArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{`1.5 + 2.25`, 3.75},
+		{`1.5 - 2`, -0.5},
+		{`2 - 1.5`, 0.5},
+		{`1.5 * 4`, 6.0},
+		{`3 * 0.5`, 1.5},
+		{`7.0 / 2`, 3.5},
+		{`7 / 2.0`, 3.5},
+		{`2.0 ** 3`, 8.0},
+		{`4 ** 0.5`, 2.0},
+		{`2 ** -1`, 0.5},
+		{`5.5 % 2`, 1.5},
+		{`-5.5 % 2`, 0.5},
+		{`7 % 2.5`, 2.0},
+		{`-1.5`, -1.5},
+		{`3.to_f`, 3.0},
+		{`2.5.round(0) + 1.25.round(1)`, 4.3},
+		{`1.005.round(2)`, 1.0},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testFloatObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestFloatConversions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`2.5.round`, 3},
+		{`2.4.round`, 2},
+		{`(0 - 2.5).round`, -3},
+		{`(0 - 2.4).round`, -2},
+		{`2.7.floor`, 2},
+		{`(0 - 2.2).floor`, -3},
+		{`2.2.ceil`, 3},
+		{`(0 - 2.7).ceil`, -2},
+		{`2.9.to_i`, 2},
+		{`(0 - 2.9).to_i`, -2},
+		{`2 ** 10`, 1024},
+		{`-7 % 3`, 2},
+		{`7 % -3`, -2},
+		{`-7 / 2`, -4},
+		{`7 / -2`, -4},
+		{`-7 / -2`, 3},
+		{`-6 / 2`, -3},
+		{`-1 / 3`, -1},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestFloatComparisons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`1.5 > 1`, true},
+		{`1 > 1.5`, false},
+		{`1.5 < 2`, true},
+		{`2 < 1.5`, false},
+		{`1.5 >= 1.5`, true},
+		{`1 >= 1.5`, false},
+		{`1.5 <= 1`, false},
+		{`1 <= 1.0`, true},
+		{`2 >= 2`, true},
+		{`3 <= 2`, false},
+		{`1.0 == 1`, true},
+		{`1 == 1.0`, true},
+		{`1.5 != 1.5`, false},
+		{`2 != 2.5`, true},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testBooleanObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`1.5.to_s`, "1.5"},
+		{`2.0.to_s`, "2.0"},
+		{`(0.1 + 0.2).to_s`, "0.30000000000000004"},
+		{`(10.0 ** 20).to_s`, "1.0e+20"},
+		{`(1.0 / 100000).to_s`, "1.0e-05"},
+		{`(1.0 / 0).to_s`, "Infinity"},
+		{`(-1.0 / 0).to_s`, "-Infinity"},
+		{`1234567.0.to_s`, "1234567.0"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testStringObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestFloatErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`1 / 0`, "ZeroDivisionError: divided by 0"},
+		{`1 % 0`, "ZeroDivisionError: divided by 0"},
+		{`(1.0 / 0).to_i`, "FloatDomainError: Infinity"},
+		{`(0.0 / 0).round`, "FloatDomainError: NaN"},
+		{`1.5.round("a")`, "TypeError: wrong argument type a (expected Integer)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestFloatHashKeys(t *testing.T) {
+	evaluated := testEval(t, `
+	h = {}
+	h[1.5] = 1
+	h[1.5] = 2
+	h[1] = 3
+	h[1.5] + h.length
+	`)
+
+	testIntegerObject(t, evaluated, 4)
+}
+
+func testFloatObject(t *testing.T, obj Object, expected float64) bool {
+	result, ok := obj.(*FloatObject)
+
+	if !ok {
+		t.Errorf("object is not Float. got=%T (%+v).", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value. expect=%g, got=%g", expected, result.Value)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,177 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// format returns f with its directives replaced by args, like Ruby's format. A directive is
+// %[flags][width][.precision]verb, flags are "-", "+", " ", "0" and "#", and width and precision can be "*"
+// to take them from args. Supported verbs are d, i, u, f, e, E, g, G, s, p, x, X, o, b, c and %.
+func (vm *VM) format(f string, args []Object) string {
+	var out bytes.Buffer
+	next := 0
+
+	arg := func() Object {
+		if next >= len(args) {
+			vm.raise(ArgumentErrorClass, "too few arguments")
+		}
+
+		next++
+		return args[next-1]
+	}
+
+	for i := 0; i < len(f); i++ {
+		if f[i] != '%' {
+			out.WriteByte(f[i])
+			continue
+		}
+
+		start := i
+		spec := []byte{'%'}
+		i++
+
+		for i < len(f) && isFormatFlag(f[i]) {
+			spec = append(spec, f[i])
+			i++
+		}
+
+		spec, i = vm.formatNumber(f, i, spec, arg)
+
+		if i < len(f) && f[i] == '.' {
+			spec, i = vm.formatNumber(f, i+1, append(spec, '.'), arg)
+		}
+
+		if i == len(f) {
+			vm.raise(ArgumentErrorClass, "incomplete format specifier")
+		}
+
+		verb := f[i]
+
+		switch verb {
+		case '%':
+			if i != start+1 {
+				vm.raise(ArgumentErrorClass, "malformed format string - %s", f[start:i+1])
+			}
+
+			out.WriteByte('%')
+		case 'd', 'i', 'u':
+			out.WriteString(fmt.Sprintf(string(append(spec, 'd')), vm.formatInteger(arg())))
+		case 'x', 'X', 'o', 'b':
+			out.WriteString(fmt.Sprintf(string(append(spec, verb)), vm.formatInteger(arg())))
+		case 'f', 'e', 'E', 'g', 'G':
+			out.WriteString(vm.formatFloat(spec, verb, arg()))
+		case 's':
+			out.WriteString(fmt.Sprintf(string(append(spec, 's')), vm.toS(arg())))
+		case 'p':
+			out.WriteString(fmt.Sprintf(string(append(spec, 's')), vm.inspect(arg())))
+		case 'c':
+			out.WriteString(fmt.Sprintf(string(append(spec, 'c')), vm.formatChar(arg())))
+		default:
+			vm.raise(ArgumentErrorClass, "malformed format string - %s", f[start:i+1])
+		}
+	}
+
+	return out.String()
+}
+
+func isFormatFlag(c byte) bool {
+	switch c {
+	case '-', '+', ' ', '0', '#':
+		return true
+	}
+
+	return false
+}
+
+// formatNumber appends the width or precision that starts at f[i] to spec, a "*" is replaced by the next argument.
+func (vm *VM) formatNumber(f string, i int, spec []byte, arg func() Object) ([]byte, int) {
+	if i < len(f) && f[i] == '*' {
+		n, ok := arg().(*IntegerObject)
+
+		if !ok {
+			vm.raise(TypeErrorClass, "width and precision must be Integer")
+		}
+
+		return append(spec, fmt.Sprint(vm.integerArgument(n))...), i + 1
+	}
+
+	for i < len(f) && f[i] >= '0' && f[i] <= '9' {
+		spec = append(spec, f[i])
+		i++
+	}
+
+	return spec, i
+}
+
+// formatInteger returns the value of the integer o to format, a *big.Int for big integers, which fmt formats the same way.
+func (vm *VM) formatInteger(o Object) interface{} {
+	switch o := o.(type) {
+	case *IntegerObject:
+		if o.big != nil {
+			return o.big
+		}
+
+		return o.Value
+	case *FloatObject:
+		return vm.formatInteger(vm.floatToInteger(o.Value))
+	}
+
+	vm.raise(TypeErrorClass, "wrong argument type %s (expected Integer)", vm.inspectForError(o))
+	return 0
+}
+
+func (vm *VM) formatFloat(spec []byte, verb byte, o Object) string {
+	value, ok := toFloat(o)
+
+	if !ok {
+		vm.raise(TypeErrorClass, "wrong argument type %s (expected Float)", vm.inspectForError(o))
+	}
+
+	// Go writes infinity as "+Inf" and pads it with zeros, Ruby writes "Inf" and pads it with spaces
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		name := "NaN"
+
+		switch {
+		case math.IsInf(value, -1):
+			name = "-Inf"
+		case math.IsInf(value, 1):
+			name = "Inf"
+		}
+
+		flags := []byte{'%'}
+		i := 1
+
+		for ; i < len(spec) && isFormatFlag(spec[i]); i++ {
+			if spec[i] == '-' {
+				flags = append(flags, '-')
+			}
+		}
+
+		width := spec[i:]
+
+		if j := bytes.IndexByte(width, '.'); j >= 0 {
+			width = width[:j]
+		}
+
+		return fmt.Sprintf(string(append(append(flags, width...), 's')), name)
+	}
+
+	return fmt.Sprintf(string(append(spec, verb)), value)
+}
+
+// formatChar returns the character %c prints, which is the character of an Integer code point or the first character of a String.
+func (vm *VM) formatChar(o Object) rune {
+	switch o := o.(type) {
+	case *IntegerObject:
+		return rune(vm.integerArgument(o))
+	case *StringObject:
+		r, _ := utf8.DecodeRuneInString(o.Value)
+		return r
+	}
+
+	vm.raise(TypeErrorClass, "wrong argument type %s (expected Integer or String)", vm.inspectForError(o))
+	return 0
+}
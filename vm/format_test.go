@@ -0,0 +1,65 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`format("%d apples", 3)`, "3 apples"},
+		{`sprintf("%d-%s", 1, "a")`, "1-a"},
+		{`format("%5d|%-5d|%05d", 42, 42, 42)`, "   42|42   |00042"},
+		{`format("%+d % d", 5, 5)`, "+5  5"},
+		{`format("%i %u", 7, 3.9)`, "7 3"},
+		{`format("%.2f", 3.14159)`, "3.14"},
+		{`format("%05.2f", 1.5)`, "01.50"},
+		{`format("%8.3f|", 2)`, "   2.000|"},
+		{`format("%e", 12345.678)`, "1.234568e+04"},
+		{`format("%g", 0.0001)`, "0.0001"},
+		{`format("%x %X %o %b", 255, 255, 8, 5)`, "ff FF 10 101"},
+		{`format("%#x %#o", 255, 8)`, "0xff 010"},
+		{`format("%s and %p", "str", 1.5)`, "str and 1.5"},
+		{`format("%-6s|%6s", "ab", "cd")`, "ab    |    cd"},
+		{`format("%.3s", "abcdef")`, "abc"},
+		{`format("%c%c", 97, "bc")`, "ab"},
+		{`format("%*d|%-*d", 4, 1, 3, 2)`, "   1|2  "},
+		{`format("100%%")`, "100%"},
+		{`format("%f %5.1f", 1.0 / 0, 0 - 1.0 / 0)`, "Inf  -Inf"},
+		{`"%s is %d" % ["age", 10]`, "age is 10"},
+		{`"%05.1f" % 3.14159`, "003.1"},
+		{`"%s" % "x"`, "x"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testStringObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`format("%d %d", 1)`, "ArgumentError: too few arguments"},
+		{`format("%z", 1)`, "ArgumentError: malformed format string - %z"},
+		{`format("%5", 1)`, "ArgumentError: incomplete format specifier"},
+		{`format("%d", "a")`, "TypeError: wrong argument type a (expected Integer)"},
+		{`format("%f", "a")`, "TypeError: wrong argument type a (expected Float)"},
+		{`format(1)`, "TypeError: wrong argument type 1 (expected String)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
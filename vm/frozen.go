@@ -0,0 +1,55 @@
+package vm
+
+// frozenFlag is embedded in objects that can be frozen. Built in methods that modify an object
+// check it with vm.checkFrozen before changing anything.
+type frozenFlag struct {
+	frozen bool
+}
+
+func (f *frozenFlag) freeze() {
+	f.frozen = true
+}
+
+func (f *frozenFlag) isFrozen() bool {
+	return f.frozen
+}
+
+type freezable interface {
+	freeze()
+	isFrozen() bool
+}
+
+// checkFrozen raises FrozenError if o is frozen
+func (vm *VM) checkFrozen(o Object) {
+	f, ok := o.(freezable)
+
+	if !ok || !f.isFrozen() {
+		return
+	}
+
+	name := ""
+
+	if b, ok := o.(BaseObject); ok {
+		name = b.ReturnClass().ReturnName()
+	}
+
+	vm.raise(FrozenErrorClass, "can't modify frozen %s: %s", name, o.Inspect())
+}
+
+// isFrozen returns true if o can't be modified. Integers, floats, ranges, symbols, booleans and nil are always frozen.
+func isFrozen(o Object) bool {
+	switch o := o.(type) {
+	case freezable:
+		return o.isFrozen()
+	case *IntegerObject, *FloatObject, *RationalObject, *BigDecimalObject, *DateObject, *RangeObject, *SymbolObject, *BooleanObject, *Null:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetFrozenStringLiterals makes string literals evaluate to frozen strings, like Ruby's
+// `# frozen_string_literal: true` magic comment.
+func (vm *VM) SetFrozenStringLiterals(frozen bool) {
+	vm.frozenStringLiterals = frozen
+}
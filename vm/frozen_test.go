@@ -0,0 +1,141 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"foo".frozen?`, false},
+		{`"foo".freeze.frozen?`, true},
+		{`[1].freeze.frozen?`, true},
+		{`{ a: 1 }.freeze.frozen?`, true},
+		{`Object.new.freeze.frozen?`, true},
+		{`Object.new.frozen?`, false},
+		{`1.frozen?`, true},
+		{`true.frozen?`, true},
+		{`
+		s = "foo".freeze
+		t = s + "bar"
+		t.frozen?
+		`, false},
+		{`
+		c = "qq"
+		c.freeze
+		"qq".frozen?
+		`, false},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testBooleanObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestFrozenError(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		a = [1, 2].freeze
+		a.push(3)
+		`, "FrozenError: can't modify frozen Array: Array:[1, 2]"},
+		{`
+		a = [1, 2].freeze
+		a.pop
+		`, "FrozenError: can't modify frozen Array: Array:[1, 2]"},
+		{`
+		a = [1, 2].freeze
+		a[0] = 3
+		`, "FrozenError: can't modify frozen Array: Array:[1, 2]"},
+		{`
+		h = { a: 1 }.freeze
+		h["b"] = 2
+		`, "FrozenError: can't modify frozen Hash: { a: 1 }"},
+		{`
+		h = { a: 1 }.freeze
+		h.delete("a")
+		`, "FrozenError: can't modify frozen Hash: { a: 1 }"},
+		{`
+		class Foo
+		  def set(v)
+		    @v = v
+		  end
+		end
+
+		Foo.new.freeze.set(1)
+		`, "FrozenError: can't modify frozen Foo: <Instance of: Foo>"},
+		{`
+		Object.new.freeze.instance_variable_set("@a", 1)
+		`, "FrozenError: can't modify frozen Object: <Instance of: Object>"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestFrozenErrorIsRuntimeError(t *testing.T) {
+	input := `
+	begin
+	  [].freeze.push(1)
+	rescue RuntimeError => e
+	  e.class.name
+	end
+	`
+
+	testStringObject(t, testEval(t, input), "FrozenError")
+}
+
+func TestFreezeDoesNotLeakToOtherStrings(t *testing.T) {
+	testStringObject(t, testEval(t, `c = "qq"; c.freeze; d = "qq"; d << "z"; d`), "qqz")
+
+	testEval(t, `"config".freeze`)
+	testStringObject(t, testEvalWithVM(t, New(), `t = "config"; t << "!"; t`), "config!")
+}
+
+func TestFrozenStringLiterals(t *testing.T) {
+	v := New()
+	v.SetFrozenStringLiterals(true)
+
+	testBooleanObject(t, testEvalWithVM(t, v, `"foo".frozen?`), true)
+}
+
+func TestSnapshotWithFrozenObjects(t *testing.T) {
+	bytecodes := testCompile(t, "frozen.ro", `
+	$a = [1].freeze
+	1
+	`)
+
+	v := New()
+	testExecWithVM(v, bytecodes)
+	data, err := v.Snapshot()
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	restored := New()
+	p := NewBytecodeParser()
+	p.VM = restored
+	p.Parse(bytecodes)
+
+	if err := restored.Restore(data); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	if !isFrozen(restored.GetGlobal("$a")) {
+		t.Fatalf("Expect $a to be restored frozen")
+	}
+}
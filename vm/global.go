@@ -0,0 +1,30 @@
+package vm
+
+// globalAliases maps alternative global variable names to the name their value is stored under.
+var globalAliases = map[string]string{
+	"$0": "$PROGRAM_NAME",
+}
+
+func globalName(name string) string {
+	if n, ok := globalAliases[name]; ok {
+		return n
+	}
+
+	return name
+}
+
+// GetGlobal returns the value of global variable name, or nil if it's never been assigned.
+func (vm *VM) GetGlobal(name string) Object {
+	p, ok := vm.Globals[globalName(name)]
+
+	if !ok {
+		return NULL
+	}
+
+	return p.Target
+}
+
+// SetGlobal assigns a global variable. Hosts use it to expose values like `$PROGRAM_NAME` before running a program.
+func (vm *VM) SetGlobal(name string, value Object) {
+	vm.Globals[globalName(name)] = &Pointer{Target: value}
+}
@@ -0,0 +1,83 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestGlobalVariable(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`$foo`, nil},
+		{`
+		$foo = 10
+		$foo
+		`, 10},
+		{`
+		$foo = 10
+		class Bar
+		  def self.foo
+		    $foo = $foo + 1
+		  end
+		end
+		Bar.foo
+		$foo
+		`, 11},
+		{`
+		$0 = "bar.ro"
+		$PROGRAM_NAME
+		`, "bar.ro"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		case nil:
+			if !testNullObject(t, evaluated) {
+				t.Fatalf("tests[%d] - expect global variable to be nil", i)
+			}
+		}
+	}
+}
+
+func TestProgramEntryGuard(t *testing.T) {
+	input := `
+	def greet
+	  "hello"
+	end
+
+	result = "required"
+
+	if __FILE__ == $PROGRAM_NAME
+	  result = greet
+	end
+
+	result
+	`
+
+	tests := []struct {
+		programName interface{}
+		expected    string
+	}{
+		{"foo.ro", "hello"},
+		{"main.ro", "required"},
+		{nil, "required"},
+	}
+
+	for _, tt := range tests {
+		v := New()
+
+		if name, ok := tt.programName.(string); ok {
+			v.SetGlobal("$0", InitializeString(name))
+		}
+
+		evaluated := testEvalFileWithVM(t, v, "foo.ro", input)
+		testStringObject(t, evaluated, tt.expected)
+	}
+}
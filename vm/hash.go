@@ -3,6 +3,9 @@ package vm
 import (
 	"bytes"
 	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -14,9 +17,28 @@ type RHash struct {
 	*BaseClass
 }
 
+// HashObject keeps its pairs in insertion order, which is the order they're inspected and iterated in.
+// Assigning an existing key keeps its position, while a deleted key goes to the end when it's assigned again.
+//
+// Keys can be any object. Pairs are found by their key's hash key and then compared with eql?, built in classes
+// like Integer, String, Symbol and Array compare by value and other objects by identity, unless their class
+// defines its own `hash` and `eql?` methods.
 type HashObject struct {
 	Class *RHash
-	Pairs map[string]Object
+	pairs []*hashPair
+	// index maps hash keys to pairs, a bucket has more than one pair when `hash` methods of different keys collide
+	index map[string][]*hashPair
+	// defaultValue is what `[]` returns for missing keys, it's set by Hash.new and nil means nil
+	defaultValue Object
+	// trackedSize is the size the VM's object limits count the hash as, 0 if it isn't counted
+	trackedSize int64
+	frozenFlag
+}
+
+type hashPair struct {
+	key     Object
+	value   Object
+	hashKey string
 }
 
 func (h *HashObject) Type() ObjectType {
@@ -24,11 +46,20 @@ func (h *HashObject) Type() ObjectType {
 }
 
 func (h *HashObject) Inspect() string {
+	return h.inspect(Object.Inspect)
+}
+
+// inspect formats the hash with keys and values formatted by inspectElement
+func (h *HashObject) inspect(inspectElement func(Object) string) string {
 	var out bytes.Buffer
 	var pairs []string
 
-	for key, value := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", key, value.Inspect()))
+	for _, p := range h.pairs {
+		if key, ok := p.key.(*StringObject); ok {
+			pairs = append(pairs, fmt.Sprintf("%s: %s", key.Value, inspectElement(p.value)))
+		} else {
+			pairs = append(pairs, fmt.Sprintf("%s => %s", inspectElement(p.key), inspectElement(p.value)))
+		}
 	}
 
 	out.WriteString("{ ")
@@ -43,38 +74,392 @@ func (h *HashObject) ReturnClass() Class {
 }
 
 func (h *HashObject) Length() int {
-	return len(h.Pairs)
+	return len(h.pairs)
+}
+
+// Keys returns the hash's keys in insertion order.
+func (h *HashObject) Keys() []Object {
+	keys := []Object{}
+
+	for _, p := range h.pairs {
+		keys = append(keys, p.key)
+	}
+
+	return keys
+}
+
+// Values returns the hash's values in the order of their keys.
+func (h *HashObject) Values() []Object {
+	values := []Object{}
+
+	for _, p := range h.pairs {
+		values = append(values, p.value)
+	}
+
+	return values
+}
+
+// Get returns the value of key. vm is needed to call `hash` and `eql?` methods classes define,
+// it can be nil when keys are built in objects.
+func (h *HashObject) Get(vm *VM, key Object) (Object, bool) {
+	p, _ := h.find(vm, key)
+
+	if p == nil {
+		return nil, false
+	}
+
+	return p.value, true
+}
+
+func (h *HashObject) find(vm *VM, key Object) (*hashPair, string) {
+	hashKey := vm.hashKey(key)
+
+	for _, p := range h.index[hashKey] {
+		if p.key == key || vm.eql(p.key, key) {
+			return p, hashKey
+		}
+	}
+
+	return nil, hashKey
+}
+
+// set assigns value to key. Like Ruby, a string key is copied and frozen, so changing the string doesn't change the key.
+func (h *HashObject) set(vm *VM, key Object, value Object) {
+	p, hashKey := h.find(vm, key)
+
+	if p != nil {
+		p.value = value
+		return
+	}
+
+	vm.trackGrowth(h, hashPairSize)
+
+	if s, ok := key.(*StringObject); ok && !s.isFrozen() {
+		s = InitializeString(s.Value)
+		s.freeze()
+		key = s
+	}
+
+	p = &hashPair{key: key, value: value, hashKey: hashKey}
+	h.pairs = append(h.pairs, p)
+	h.index[hashKey] = append(h.index[hashKey], p)
+}
+
+func (h *HashObject) delete(vm *VM, key Object) (Object, bool) {
+	p, hashKey := h.find(vm, key)
+
+	if p == nil {
+		return nil, false
+	}
+
+	vm.trackGrowth(h, -hashPairSize)
+	h.pairs = removeHashPair(h.pairs, p)
+	h.index[hashKey] = removeHashPair(h.index[hashKey], p)
+
+	if len(h.index[hashKey]) == 0 {
+		delete(h.index, hashKey)
+	}
+
+	return p.value, true
+}
+
+func removeHashPair(pairs []*hashPair, pair *hashPair) []*hashPair {
+	for i, p := range pairs {
+		if p == pair {
+			return append(pairs[:i], pairs[i+1:]...)
+		}
+	}
+
+	return pairs
+}
+
+// hashKey returns the key pairs with key are indexed by, keys that are eql? have the same hash key.
+// Objects whose class defines `hash` are indexed by what it returns, other objects by their identity.
+func (vm *VM) hashKey(key Object) string {
+	switch key := key.(type) {
+	case *StringObject:
+		return "s" + key.Value
+	case *IntegerObject:
+		return "i" + key.Inspect()
+	case *FloatObject:
+		return "f" + strconv.FormatFloat(key.Value, 'g', -1, 64)
+	case *RationalObject:
+		return "q" + key.value.String()
+	case *BigDecimalObject:
+		return "d" + key.plain()
+	case *DateObject:
+		return "t" + key.iso8601()
+	case *RangeObject:
+		return "r" + key.Inspect()
+	case *SymbolObject:
+		return "y" + key.Name
+	case *BooleanObject:
+		return "b" + strconv.FormatBool(key.Value)
+	case *Null:
+		return "n"
+	case *ArrayObject:
+		keys := []string{}
+
+		for _, e := range key.Elements {
+			keys = append(keys, strconv.Quote(vm.hashKey(e)))
+		}
+
+		return "a" + strings.Join(keys, ",")
+	case *RObject:
+		if m, ok := vm.lookupMethod(key, "hash").(*Method); ok {
+			hash, ok := vm.callMethod(key, m).(*IntegerObject)
+
+			if !ok {
+				vm.raise(TypeErrorClass, "hash of %s must be an Integer", key.Inspect())
+			}
+
+			return "h" + hash.Inspect()
+		}
+	}
+
+	return fmt.Sprintf("o%p", key)
+}
+
+// hashValue returns the Integer value of `hash` built in method, keys that are eql? have the same value
+func (vm *VM) hashValue(o Object) int {
+	h := fnv.New32a()
+	h.Write([]byte(vm.hashKey(o)))
+
+	return int(h.Sum32())
+}
+
+// eql returns true if a and b are the same hash key, the way Ruby's eql? compares them
+func (vm *VM) eql(a, b Object) bool {
+	switch a := a.(type) {
+	case *StringObject, *IntegerObject, *FloatObject, *RationalObject, *BigDecimalObject, *DateObject, *RangeObject, *SymbolObject, *BooleanObject, *Null:
+		return vm.hashKey(a) == vm.hashKey(b)
+	case *ArrayObject:
+		other, ok := b.(*ArrayObject)
+
+		if !ok || len(a.Elements) != len(other.Elements) {
+			return false
+		}
+
+		for i := range a.Elements {
+			if !vm.eql(a.Elements[i], other.Elements[i]) {
+				return false
+			}
+		}
+
+		return true
+	case *RObject:
+		if m, ok := vm.lookupMethod(a, "eql?").(*Method); ok {
+			return isTruthy(vm.callMethod(a, m, b))
+		}
+	}
+
+	return a == b
+}
+
+// InitializeHash returns a hash with given pairs. Go maps aren't ordered, so pairs are ordered by their keys.
+// defaultOf returns the value of missing keys.
+func (h *HashObject) defaultOf() Object {
+	if h.defaultValue == nil {
+		return NULL
+	}
+
+	return h.defaultValue
 }
 
 func InitializeHash(pairs map[string]Object) *HashObject {
-	return &HashObject{Pairs: pairs, Class: HashClass}
+	h := &HashObject{Class: HashClass, index: make(map[string][]*hashPair)}
+	keys := []string{}
+
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		h.set(nil, InitializeString(key), pairs[key])
+	}
+
+	return h
 }
 
-var builtinHashMethods = []*BuiltInMethod{
-	{
+// hashIterator returns a built in method of Hash that yields what yielded returns for each pair, in insertion order.
+func hashIterator(name string, yielded func(p *hashPair) []Object) *BuiltInMethod {
+	return &BuiltInMethod{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
-				if len(args) != 1 {
-					return newError("Expect 1 arguments. got=%d", len(args))
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
 				}
 
-				i := args[0]
-				key, ok := i.(*StringObject)
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (%s)", name)
+				}
 
-				if !ok {
-					return newError("Expect index argument to be String. got=%T", i)
+				hash := receiver.(*HashObject)
+
+				// the block can change the hash, so pairs are copied first
+				for _, p := range append([]*hashPair{}, hash.pairs...) {
+					vm.builtInMethodYield(blockFrame, yielded(p)...)
+				}
+
+				return hash
+			}
+		},
+		Name: name,
+	}
+}
+
+// hashMerger returns a built in method of Hash that merges the pairs of other hashes into a copy of the receiver,
+// or into the receiver itself if inPlace is true. Later pairs win, unless a block is given to be called with the key,
+// the old value and the new value of each key in both, and return the value to keep.
+func hashMerger(name string, inPlace bool) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				others := []*HashObject{}
+
+				for _, arg := range args {
+					other, ok := arg.(*HashObject)
+
+					if !ok {
+						vm.raise(TypeErrorClass, "wrong argument type %s (expected Hash)", vm.inspectForError(arg))
+					}
+
+					others = append(others, other)
 				}
 
 				hash := receiver.(*HashObject)
+				merged := hash
 
-				if len(hash.Pairs) == 0 {
-					return NULL
+				if inPlace {
+					vm.checkFrozen(hash)
+				} else {
+					merged = InitializeHash(map[string]Object{})
+					merged.defaultValue = hash.defaultValue
+
+					for _, p := range hash.pairs {
+						merged.set(vm, p.key, p.value)
+					}
+
+					vm.track(merged)
 				}
 
-				value, ok := hash.Pairs[key.Value]
+				for _, other := range others {
+					// the block can change the other hash, so pairs are copied first
+					for _, p := range append([]*hashPair{}, other.pairs...) {
+						value := p.value
+
+						if old, ok := merged.Get(vm, p.key); ok && blockFrame != nil {
+							value = vm.builtInMethodYield(blockFrame, p.key, old, p.value).Target
+						}
+
+						merged.set(vm, p.key, value)
+					}
+				}
+
+				return merged
+			}
+		},
+		Name: name,
+	}
+}
+
+// digMethod is Hash#dig and Array#dig, which look up nested values with `[]` and return nil once one is missing.
+var digMethod = &BuiltInMethod{
+	Fn: func(receiver Object) BuiltinMethodBody {
+		return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+			if len(args) == 0 {
+				vm.raise(ArgumentErrorClass, "wrong number of arguments (given 0, expected 1+)")
+			}
+
+			return vm.dig(receiver, args)
+		}
+	},
+	Name: "dig",
+}
+
+// dig returns receiver[keys[0]], then calls `dig` on it with the rest of keys, so objects that define `dig` can be
+// dug into. It raises TypeError if a value in the middle can't be dug into.
+func (vm *VM) dig(receiver Object, keys []Object) Object {
+	value := vm.send(receiver, "[]", keys[0])
+
+	if len(keys) == 1 || value == NULL {
+		return value
+	}
+
+	if b := value.(BaseObject); vm.lookupMethod(b, "dig") == nil {
+		vm.raise(TypeErrorClass, "%s does not have #dig method", b.ReturnClass().ReturnName())
+	}
+
+	return vm.send(value, "dig", keys[1:]...)
+}
+
+func hashPairArguments(p *hashPair) []Object {
+	return []Object{p.key, p.value}
+}
+
+var builtinHashClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				hash := InitializeHash(map[string]Object{})
+
+				if len(args) == 1 {
+					hash.defaultValue = args[0]
+				}
+
+				vm.track(hash)
+
+				return hash
+			}
+		},
+		Name: "new",
+	},
+}
+
+var builtinHashMethods = []*BuiltInMethod{
+	digMethod,
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 && len(args) != 2 {
+					return newError("Expect 1 or 2 arguments. got=%d", len(args))
+				}
+
+				value, ok := receiver.(*HashObject).Get(vm, args[0])
+
+				switch {
+				case ok:
+					return value
+				case blockFrame != nil:
+					return vm.builtInMethodYield(blockFrame, args[0]).Target
+				case len(args) == 2:
+					return args[1]
+				}
+
+				vm.raise(KeyErrorClass, "key not found: %s", vm.inspectForError(args[0]))
+				return nil
+			}
+		},
+		Name: "fetch",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 arguments. got=%d", len(args))
+				}
+
+				hash := receiver.(*HashObject)
+				value, ok := hash.Get(vm, args[0])
 
 				if !ok {
-					return NULL
+					return hash.defaultOf()
 				}
 
 				return value
@@ -85,22 +470,16 @@ var builtinHashMethods = []*BuiltInMethod{
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
-				// First arg is index
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				// First arg is key
 				// Second arg is assigned value
 				if len(args) != 2 {
 					return newError("Expect 2 arguments. got=%d", len(args))
 				}
 
-				k := args[0]
-				key, ok := k.(*StringObject)
-
-				if !ok {
-					return newError("Expect index argument to be String. got=%T", k)
-				}
-
 				hash := receiver.(*HashObject)
-				hash.Pairs[key.Value] = args[1]
+				vm.checkFrozen(hash)
+				hash.set(vm, args[0], args[1])
 
 				return args[1]
 			}
@@ -109,27 +488,93 @@ var builtinHashMethods = []*BuiltInMethod{
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				if len(args) != 0 {
 					return newError("Expect 0 argument. got=%d", len(args))
 				}
 
 				hash := receiver.(*HashObject)
-				return InitilaizeInteger(hash.Length())
+				return vm.initInteger(hash.Length())
 			}
 		},
 		Name: "length",
 	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				vm.checkFrozen(receiver)
+				value, ok := receiver.(*HashObject).delete(vm, args[0])
+
+				// a block gives the value of a missing key
+				if !ok && blockFrame != nil {
+					return vm.builtInMethodYield(blockFrame, args[0]).Target
+				}
+
+				if !ok {
+					return NULL
+				}
+
+				return value
+			}
+		},
+		Name: "delete",
+	},
+	hashMerger("merge", false),
+	hashMerger("merge!", true),
+	hashMerger("update", true),
+	hashIterator("each", hashPairArguments),
+	hashIterator("each_pair", hashPairArguments),
+	hashIterator("each_key", func(p *hashPair) []Object { return []Object{p.key} }),
+	hashIterator("each_value", func(p *hashPair) []Object { return []Object{p.value} }),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				arr := InitializeArray(receiver.(*HashObject).Keys())
+				vm.track(arr)
+
+				return arr
+			}
+		},
+		Name: "keys",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				arr := InitializeArray(receiver.(*HashObject).Values())
+				vm.track(arr)
+
+				return arr
+			}
+		},
+		Name: "values",
+	},
 }
 
-func init() {
+func initHash() {
 	methods := NewEnvironment()
+	classMethods := NewEnvironment()
 
 	for _, m := range builtinHashMethods {
 		methods.Set(m.Name, m)
 	}
 
-	bc := &BaseClass{Name: "Hash", Methods: methods, ClassMethods: NewEnvironment(), Class: ClassClass, SuperClass: ObjectClass}
+	for _, m := range builtinHashClassMethods {
+		classMethods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "Hash", Methods: methods, ClassMethods: classMethods, Class: ClassClass, SuperClass: ObjectClass}
 	hc := &RHash{BaseClass: bc}
 	HashClass = hc
 }
@@ -16,8 +16,10 @@ func TestEvalHashExpression(t *testing.T) {
 		t.Fatalf("Expect evaluated value to be a hash. got=%T", evaluated)
 	}
 
-	for key, value := range h.Pairs {
-		switch key {
+	for _, key := range h.Keys() {
+		value, _ := h.Get(nil, key)
+
+		switch key.(*StringObject).Value {
 		case "foo":
 			testIntegerObject(t, value, 123)
 		case "bar":
@@ -89,3 +91,608 @@ func TestEvalHashAccess(t *testing.T) {
 		}
 	}
 }
+
+func TestHashOrdering(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		{ foo: 1, bar: 2, baz: 3, a: 4 }.to_s
+		`, "{ foo: 1, bar: 2, baz: 3, a: 4 }"},
+		{`
+		{ foo: 1, bar: 2, foo: 3 }.to_s
+		`, "{ foo: 3, bar: 2 }"},
+		{`
+		h = { foo: 1, bar: 2 }
+		h["baz"] = 3
+		h["foo"] = 4
+		h.to_s
+		`, "{ foo: 4, bar: 2, baz: 3 }"},
+		{`
+		h = { foo: 1, bar: 2, baz: 3 }
+		h.delete("foo")
+		h["foo"] = 4
+		h.to_s
+		`, "{ bar: 2, baz: 3, foo: 4 }"},
+		{`
+		h = { foo: 1, bar: 2 }
+		h.merge({ baz: 3, foo: 4 }).to_s
+		`, "{ foo: 4, bar: 2, baz: 3 }"},
+		{`
+		h = { foo: 1, bar: 2 }
+		h.merge({ baz: 3 })
+		h.to_s
+		`, "{ foo: 1, bar: 2 }"},
+		{`
+		h = { c: 1, a: 2, b: 3 }
+		s = ">"
+		h.each do |k, v|
+		  s = s + k + v.to_s
+		end
+		s
+		`, ">c1a2b3"},
+		{`
+		h = { c: 1, a: 2, b: 3 }
+		h.delete("a")
+		h.keys.to_s + h.values.to_s
+		`, "Array:[c, b]Array:[1, 3]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testStringObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestInitializeHashOrdering(t *testing.T) {
+	h := InitializeHash(map[string]Object{"c": InitilaizeInteger(1), "a": InitilaizeInteger(2), "b": InitilaizeInteger(3)})
+	h.set(nil, InitializeString("0"), InitilaizeInteger(4))
+
+	if h.Inspect() != "{ a: 2, b: 3, c: 1, 0: 4 }" {
+		t.Fatalf("Expect hash to be inspected in sorted order. got=%s", h.Inspect())
+	}
+}
+
+func TestHashDeleteReturnValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		h = { foo: 1 }
+		h.delete("foo")
+		`, 1},
+		{`
+		h = { foo: 1 }
+		h.delete("bar")
+		`, nil},
+		{`
+		h = { foo: 1, bar: 2 }
+		h.delete("foo")
+		h.length
+		`, 1},
+		{`
+		h = { foo: 1 }
+		h.delete("bar") do |k|
+		  k.length
+		end
+		`, 3},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestHashMerge(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		h = { a: 1, b: 2 }
+		h.merge({ b: 3 }, { c: 4 }).to_s
+		`, "{ a: 1, b: 3, c: 4 }"},
+		{`
+		h = { a: 1, b: 2 }
+		m = h.merge({ b: 3, c: 4 }) do |k, old, new|
+		  old + new
+		end
+		m.to_s + h.to_s
+		`, "{ a: 1, b: 5, c: 4 }{ a: 1, b: 2 }"},
+		{`
+		h = { a: 1, b: 2 }
+		h.merge!({ b: 3, c: 4 })
+		h.to_s
+		`, "{ a: 1, b: 3, c: 4 }"},
+		{`
+		h = { a: 1, b: 2 }
+		h.update({ b: 3 }) do |k, old, new|
+		  k + (old * new).to_s
+		end
+		h.to_s
+		`, "{ a: 1, b: b6 }"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if !testStringObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestHashMergeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{ a: 1 }.freeze.merge!({ b: 2 })`, "FrozenError: can't modify frozen Hash: { a: 1 }"},
+		{`{ a: 1 }.merge(1)`, "TypeError: wrong argument type 1 (expected Hash)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestHashIteration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		h = { c: 1, a: 2 }
+		s = ">"
+		h.each_pair do |k, v|
+		  s = s + k + v.to_s
+		end
+		s
+		`, ">c1a2"},
+		{`
+		h = { c: 1, a: 2 }
+		s = ">"
+		h.each_key do |k|
+		  s = s + k
+		end
+		s
+		`, ">ca"},
+		{`
+		h = { c: 1, a: 2 }
+		s = ">"
+		h.each_value do |v|
+		  s = s + v.to_s
+		end
+		s
+		`, ">12"},
+		{`
+		h = { c: 1, a: 2 }
+		h.each_key do |k|
+		  h[k + k] = 0
+		end
+		h.to_s
+		`, "{ c: 1, a: 2, cc: 0, aa: 0 }"},
+		{`
+		h = { c: 1 }
+		h.each do |k, v|
+		  v
+		end.to_s
+		`, "{ c: 1 }"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if !testStringObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestHashIterationWithoutBlock(t *testing.T) {
+	for _, name := range []string{"each", "each_pair", "each_key", "each_value"} {
+		err := testEvalError(t, New(), "", "{ a: 1 }."+name)
+		expected := "LocalJumpError: no block given (" + name + ")"
+
+		if err == nil || err.Error() != expected {
+			t.Fatalf("expect error %q. got=%v", expected, err)
+		}
+	}
+}
+
+func TestHashFetchAndDefault(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		h = { a: 1 }
+		h.fetch("a")
+		`, 1},
+		{`
+		h = { a: 1 }
+		h.fetch("b", 2)
+		`, 2},
+		{`
+		h = { a: 1 }
+		h.fetch("abc") do |k|
+		  k.length
+		end
+		`, 3},
+		{`
+		h = { a: 1 }
+		h.fetch("a") do |k|
+		  0
+		end
+		`, 1},
+		{`
+		h = Hash.new(0)
+		h["a"] = h["a"] + 1
+		h["a"] + h["b"]
+		`, 1},
+		{`
+		h = Hash.new(0)
+		h.merge({ a: 1 })["b"]
+		`, 0},
+		{`
+		h = Hash.new
+		h["a"]
+		`, nil},
+		{`
+		h = Hash.new(0)
+		h["a"]
+		h.length
+		`, 0},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			if !testIntegerObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		case nil:
+			if !testNullObject(t, evaluated) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestHashFetchErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{ a: 1 }.fetch("b")`, "KeyError: key not found: b"},
+		{`Hash.new(0).fetch("b")`, "KeyError: key not found: b"},
+		{`
+		begin
+		  { a: 1 }.fetch("b")
+		rescue IndexError => e
+		  raise(e.message)
+		end
+		`, "RuntimeError: key not found: b"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestDig(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		c = { db: { ports: [5432, 5433] } }
+		c.dig("db", "ports", -1)
+		`, 5433},
+		{`
+		c = { db: { ports: [5432, 5433] } }
+		c.dig("web", "ports", 0)
+		`, nil},
+		{`
+		c = { db: { ports: [5432, 5433] } }
+		c.dig("db", "ports", 2)
+		`, nil},
+		{`
+		m = [[1, 2], [3, { a: 4 }]]
+		m.dig(1, 1, "a")
+		`, 4},
+		{`
+		m = [[1, 2], [3, 4]]
+		m.dig(2, 0)
+		`, nil},
+		{`
+		h = Hash.new(0)
+		h.dig("a")
+		`, 0},
+		{`
+		class Box
+		  def dig(key)
+		    key * 2
+		  end
+		end
+
+		h = { box: Box.new }
+		h.dig("box", 21)
+		`, 42},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			if !testIntegerObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		case nil:
+			if !testNullObject(t, evaluated) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestDigErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[[1, 2]].dig(0, 0, 1)`, "TypeError: Integer does not have #dig method"},
+		{`{ a: "b" }.dig("a", 0)`, "TypeError: String does not have #dig method"},
+		{`[1].dig`, "ArgumentError: wrong number of arguments (given 0, expected 1+)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestHashWithObjectKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		h = {}
+		h[1] = "one"
+		h[1]
+		`, "one"},
+		{`
+		h = {}
+		h["a".to_sym] = 1
+		h["a"] = 2
+		h["a".to_sym] + h["a"]
+		`, 3},
+		{`
+		h = {}
+		h[[1, "a"]] = 10
+		h[[1, "a"]]
+		`, 10},
+		{`
+		h = {}
+		h[true] = 1
+		h[1] = 2
+		h[true]
+		`, 1},
+		{`
+		class Point
+		  def initialize(x, y)
+		    @x = x
+		    @y = y
+		  end
+
+		  def x
+		    @x
+		  end
+
+		  def y
+		    @y
+		  end
+
+		  def hash
+		    [@x, @y].hash
+		  end
+
+		  def eql?(other)
+		    if @x == other.x
+		      @y == other.y
+		    else
+		      false
+		    end
+		  end
+		end
+
+		h = {}
+		h[Point.new(1, 2)] = "a"
+		h[Point.new(1, 2)] = "b"
+		h[Point.new(1, 2)] + h.length.to_s
+		`, "b1"},
+		{`
+		class Foo
+		end
+
+		foo = Foo.new
+		h = {}
+		h[foo] = 1
+		h[Foo.new] = 2
+		h[foo] + h.length
+		`, 3},
+		{`
+		class Foo
+		end
+
+		h = {}
+		h[Foo.new] = 1
+		h[Foo.new]
+		`, nil},
+		{`
+		class Key
+		  def hash
+		    1
+		  end
+
+		  def eql?(other)
+		    false
+		  end
+		end
+
+		h = {}
+		h[Key.new] = 1
+		h[Key.new] = 2
+		h.length
+		`, 2},
+		{`
+		h = {}
+		h[1] = 1
+		h[2] = 2
+		h.delete(1)
+		h.keys.to_s + h.values.to_s
+		`, "Array:[2]Array:[2]"},
+		{`
+		h = { a: 1 }
+		h[2] = "b"
+		h.to_s
+		`, "{ a: 1, 2 => b }"},
+		{`
+		s = "a"
+		h = {}
+		h[s] = 1
+		h.keys[0].frozen?
+		`, true},
+		{`
+		other = {}
+		other[2] = 3
+		h = { a: 1 }.merge(other)
+		h.keys.to_s + h.values.to_s
+		`, "Array:[a, 2]Array:[1, 3]"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		}
+
+		if t.Failed() {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestHashAndEql(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`1.hash == 1.hash`, true},
+		{`"a".hash == "a".hash`, true},
+		{`[1, "a"].hash == [1, "a"].hash`, true},
+		{`[1, "a"].eql?([1, "a"])`, true},
+		{`1.eql?("1")`, false},
+		{`Object.new.eql?(Object.new)`, false},
+		{`
+		o = Object.new
+		o.eql?(o)
+		`, true},
+	}
+
+	for i, tt := range tests {
+		if !testBooleanObject(t, testEval(t, tt.input), tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestSnapshotWithObjectKeys(t *testing.T) {
+	bytecodes := testCompile(t, "hash.ro", `
+	class Key
+	  def initialize(id)
+	    @id = id
+	  end
+
+	  def id
+	    @id
+	  end
+
+	  def hash
+	    @id
+	  end
+
+	  def eql?(other)
+	    @id == other.id
+	  end
+	end
+
+	$h = {}
+	$h[Key.new(1)] = "a"
+	$h[[1, 2]] = "b"
+	$d = Hash.new(0)
+	1
+	`)
+
+	v := New()
+	testExecWithVM(v, bytecodes)
+	data, err := v.Snapshot()
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	restored := New()
+	p := NewBytecodeParser()
+	p.VM = restored
+	p.Parse(bytecodes)
+
+	if err := restored.Restore(data); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	h := restored.GetGlobal("$h").(*HashObject)
+	key := h.Keys()[0].(*RObject)
+
+	if value, ok := h.Get(restored, InitializeArray([]Object{InitilaizeInteger(1), InitilaizeInteger(2)})); !ok || value.Inspect() != "b" {
+		t.Fatalf("Expect array key to be restored")
+	}
+
+	if value, ok := h.Get(restored, key); !ok || value.Inspect() != "a" {
+		t.Fatalf("Expect object key to be restored")
+	}
+
+	if value := restored.GetGlobal("$d").(*HashObject).defaultOf(); value.Inspect() != "0" {
+		t.Fatalf("Expect default value to be restored. got=%s", value.Inspect())
+	}
+}
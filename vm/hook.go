@@ -0,0 +1,37 @@
+package vm
+
+// InstructionHook is called before every instruction is executed.
+type InstructionHook func(cf *CallFrame, i *Instruction)
+
+// CallFrameHook is called with a call frame when it's entered or left.
+type CallFrameHook func(cf *CallFrame)
+
+// hooks are registered by debuggers, profilers and tracers to observe execution.
+type hooks struct {
+	instruction []InstructionHook
+	call        []CallFrameHook
+	ret         []CallFrameHook
+}
+
+// OnInstruction registers a hook that's called before every instruction is executed.
+func (vm *VM) OnInstruction(hook InstructionHook) {
+	vm.hooks.instruction = append(vm.hooks.instruction, hook)
+}
+
+// OnCall registers a hook that's called after a call frame is pushed, which happens when the program starts
+// and when a method, a block or a class body is entered. Built in methods don't have call frames.
+func (vm *VM) OnCall(hook CallFrameHook) {
+	vm.hooks.call = append(vm.hooks.call, hook)
+}
+
+// OnReturn registers a hook that's called when a call frame is left. Frames discarded by an exception are reported too,
+// from the innermost one, so every OnCall has a matching OnReturn.
+func (vm *VM) OnReturn(hook CallFrameHook) {
+	vm.hooks.ret = append(vm.hooks.ret, hook)
+}
+
+func (vm *VM) callHooks(hooks []CallFrameHook, cf *CallFrame) {
+	for _, hook := range hooks {
+		hook(cf)
+	}
+}
@@ -0,0 +1,64 @@
+package vm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	input := `
+	def foo(a)
+	  [a].each do |x|
+	    x + 1
+	  end
+	end
+
+	def bar
+	  raise(RuntimeError, "bar")
+	end
+
+	foo(1)
+
+	begin
+	  bar
+	rescue RuntimeError => e
+	  10
+	end
+	`
+
+	v := New()
+	events := []string{}
+	instructions := 0
+
+	v.OnInstruction(func(cf *CallFrame, i *Instruction) {
+		instructions++
+	})
+	v.OnCall(func(cf *CallFrame) {
+		events = append(events, "call "+cf.MethodName())
+	})
+	v.OnReturn(func(cf *CallFrame) {
+		events = append(events, "return "+cf.MethodName())
+	})
+
+	evaluated := testEvalWithVM(t, v, input)
+	testIntegerObject(t, evaluated, 10)
+
+	expected := []string{
+		"call <main>",
+		"call foo",
+		"call block in foo",
+		"return block in foo",
+		"return foo",
+		"call bar",
+		"return bar",
+		"return <main>",
+	}
+
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("Expect hooks to be called with %v. got=%v", expected, events)
+	}
+
+	if instructions == 0 {
+		t.Fatalf("Expect instruction hook to be called")
+	}
+}
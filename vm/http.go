@@ -0,0 +1,230 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	HTTPClass         *RHTTP
+	HTTPResponseClass *RHTTPResponse
+)
+
+// DefaultHTTPTimeout is the HTTPTimeout of VMs returned by New.
+const DefaultHTTPTimeout = 30 * time.Second
+
+type RHTTP struct {
+	*BaseClass
+}
+
+type RHTTPResponse struct {
+	*BaseClass
+}
+
+// HTTPResponseObject is the response of a request made with Net::HTTP.
+type HTTPResponseObject struct {
+	Class   *RHTTPResponse
+	status  int
+	body    string
+	headers http.Header
+}
+
+func (r *HTTPResponseObject) Type() ObjectType {
+	return HTTP_RESPONSE_OBJ
+}
+
+func (r *HTTPResponseObject) Inspect() string {
+	return fmt.Sprintf("#<Net::HTTPResponse %d>", r.status)
+}
+
+func (r *HTTPResponseObject) ReturnClass() Class {
+	return r.Class
+}
+
+// httpRequest sends a request to the URL rawURL refers to and returns its response. rawURL is a string or a URI
+// returned by URI.parse, and headers is a hash of header names and values, or nil.
+// Requests that can't be sent or don't finish in vm.HTTPTimeout raise IOError.
+func (vm *VM) httpRequest(method string, rawURL Object, body io.Reader, headers Object) *HTTPResponseObject {
+	var address string
+
+	if u, ok := rawURL.(*URIObject); ok {
+		address = u.url.String()
+	} else {
+		address = vm.stringArgument(rawURL)
+	}
+
+	u, err := url.Parse(address)
+
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		vm.raise(ArgumentErrorClass, "invalid URL %s", vm.inspectForError(rawURL))
+	}
+
+	req, err := http.NewRequest(method, address, body)
+
+	if err != nil {
+		vm.raise(ArgumentErrorClass, "%s", err.Error())
+	}
+
+	switch headers := headers.(type) {
+	case *HashObject:
+		for _, p := range headers.pairs {
+			req.Header.Set(vm.stringArgument(p.key), vm.toS(p.value))
+		}
+	case *Null:
+	default:
+		vm.raise(TypeErrorClass, "wrong argument type %s (expected Hash)", vm.inspectForError(headers))
+	}
+
+	client := &http.Client{Timeout: vm.HTTPTimeout}
+	resp, err := client.Do(req)
+
+	if err != nil {
+		vm.raise(IOErrorClass, "%s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+
+	if err != nil {
+		vm.raise(IOErrorClass, "%s", err.Error())
+	}
+
+	return &HTTPResponseObject{Class: HTTPResponseClass, status: resp.StatusCode, body: string(b), headers: resp.Header}
+}
+
+// optionalHeaders returns the headers argument at index i of args, or nil if it's not given.
+func optionalHeaders(args []Object, i int) Object {
+	if len(args) > i {
+		return args[i]
+	}
+
+	return NULL
+}
+
+var builtinHTTPClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 && len(args) != 2 {
+					return newError("Expect 1 or 2 arguments. got=%d", len(args))
+				}
+
+				return vm.httpRequest("GET", args[0], nil, optionalHeaders(args, 1))
+			}
+		},
+		Name: "get",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 2 && len(args) != 3 {
+					return newError("Expect 2 or 3 arguments. got=%d", len(args))
+				}
+
+				body := strings.NewReader(vm.stringArgument(args[1]))
+				return vm.httpRequest("POST", args[0], body, optionalHeaders(args, 2))
+			}
+		},
+		Name: "post",
+	},
+}
+
+// builtinHTTPResponseMethods are methods of responses. `headers` returns a hash of lowercased header names, and `[]`
+// looks up a header regardless of its case. Values of a header that's repeated are joined with ", ".
+var builtinHTTPResponseMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initInteger(receiver.(*HTTPResponseObject).status)
+			}
+		},
+		Name: "status",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return InitializeString(receiver.(*HTTPResponseObject).body)
+			}
+		},
+		Name: "body",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				headers := receiver.(*HTTPResponseObject).headers
+				names := []string{}
+
+				for name := range headers {
+					names = append(names, name)
+				}
+
+				sort.Strings(names)
+				hash := InitializeHash(map[string]Object{})
+
+				for _, name := range names {
+					hash.set(vm, InitializeString(strings.ToLower(name)), InitializeString(strings.Join(headers[name], ", ")))
+				}
+
+				return hash
+			}
+		},
+		Name: "headers",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				values, ok := receiver.(*HTTPResponseObject).headers[http.CanonicalHeaderKey(vm.stringArgument(args[0]))]
+
+				if !ok {
+					return NULL
+				}
+
+				return InitializeString(strings.Join(values, ", "))
+			}
+		},
+		Name: "[]",
+	},
+}
+
+func initHTTP() {
+	classMethods := NewEnvironment()
+
+	for _, m := range builtinHTTPClassMethods {
+		classMethods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "Net::HTTP", Methods: NewEnvironment(), ClassMethods: classMethods, Class: ClassClass, SuperClass: ObjectClass}
+	HTTPClass = &RHTTP{BaseClass: bc}
+
+	methods := NewEnvironment()
+
+	for _, m := range builtinHTTPResponseMethods {
+		methods.Set(m.Name, m)
+	}
+
+	bc = &BaseClass{Name: "Net::HTTPResponse", Methods: methods, ClassMethods: NewEnvironment(), Class: ClassClass, SuperClass: ObjectClass}
+	HTTPResponseClass = &RHTTPResponse{BaseClass: bc}
+}
@@ -0,0 +1,120 @@
+package vm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestHTTPServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/echo":
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Header().Set("X-Method", r.Method)
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprintf(w, "%s %s %s", r.Method, r.Header.Get("X-Token"), body)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "not found")
+		}
+	}))
+}
+
+func TestHTTP(t *testing.T) {
+	server := newTestHTTPServer()
+	defer server.Close()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Net::HTTP.get(URL + "/echo").status`, 200},
+		{`Net::HTTP.get(URL + "/echo").body`, "GET  "},
+		{`
+		headers = Hash.new
+		headers["X-Token"] = "abc"
+		Net::HTTP.get(URL + "/echo", headers).body
+		`, "GET abc "},
+		{`Net::HTTP.get(URL + "/missing").status`, 404},
+		{`Net::HTTP.get(URL + "/missing").body`, "not found"},
+		{`Net::HTTP.post(URL + "/echo", "data").body`, "POST  data"},
+		{`
+		headers = Hash.new
+		headers["X-Token"] = 1
+		Net::HTTP.post(URL + "/echo", "data", headers).body
+		`, "POST 1 data"},
+		{`Net::HTTP.post(URL + "/echo", "data").headers["x-method"]`, "POST"},
+		{`Net::HTTP.get(URL + "/echo")["content-type"]`, "text/plain"},
+		{`Net::HTTP.get(URL + "/echo")["X-Nope"]`, nil},
+		{`Net::HTTP.get(URL + "/echo").class.name`, "Net::HTTPResponse"},
+		{`Net::HTTP.get(URI.parse(URL + "/echo?a=1")).status`, 200},
+	}
+
+	for i, tt := range tests {
+		v := New()
+		v.SetConstant("URL", InitializeString(server.URL))
+		evaluated := testEvalWithVM(t, v, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		default:
+			t.Fatalf("at test case %d: unexpected expectation %v", i, expected)
+		}
+	}
+}
+
+func TestHTTPErrors(t *testing.T) {
+	server := newTestHTTPServer()
+	defer server.Close()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Net::HTTP.get("ftp://example.com")`, "ArgumentError: invalid URL ftp://example.com"},
+		{`Net::HTTP.get("example.com")`, "ArgumentError: invalid URL example.com"},
+		{`Net::HTTP.get(1)`, "TypeError: wrong argument type 1 (expected String)"},
+		{`Net::HTTP.get(URL, 1)`, "TypeError: wrong argument type 1 (expected Hash)"},
+		{`Net::HTTP.post(URL, 1)`, "TypeError: wrong argument type 1 (expected String)"},
+	}
+
+	for i, tt := range tests {
+		v := New()
+		v.SetConstant("URL", InitializeString(server.URL))
+		err := testEvalError(t, v, "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestHTTPTimeout(t *testing.T) {
+	server := newTestHTTPServer()
+	defer server.Close()
+
+	v := New()
+	v.HTTPTimeout = 50 * time.Millisecond
+	v.SetConstant("URL", InitializeString(server.URL))
+	evaluated := testEvalWithVM(t, v, `
+	begin
+	  Net::HTTP.get(URL + "/slow")
+	  "finished"
+	rescue IOError => e
+	  "timed out"
+	end
+	`)
+
+	testStringObject(t, evaluated, "timed out")
+}
@@ -0,0 +1,138 @@
+package vm
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// toS returns how o is printed by puts, which is what its to_s method returns if its class defines one
+func (vm *VM) toS(o Object) string {
+	if s, ok := vm.callFormatMethod(o, "to_s"); ok {
+		return s
+	}
+
+	return vm.defaultInspect(o)
+}
+
+// inspect returns what o's inspect method returns if its class defines one, arrays and hashes inspect their elements with it
+func (vm *VM) inspect(o Object) string {
+	if s, ok := vm.callFormatMethod(o, "inspect"); ok {
+		return s
+	}
+
+	return vm.defaultInspect(o)
+}
+
+// inspectForError is inspect for error messages. If a class's inspect fails, inspecting it again for that error
+// would fail the same way, so objects are described by Inspect while an error message is being formatted.
+func (vm *VM) inspectForError(o Object) string {
+	if vm.formattingError {
+		return o.Inspect()
+	}
+
+	vm.formattingError = true
+	defer func() { vm.formattingError = false }()
+
+	return vm.inspect(o)
+}
+
+// callFormatMethod calls o's to_s or inspect method if it's defined in Rooby and returns a string.
+// Only objects and classes programs define can have such methods.
+func (vm *VM) callFormatMethod(o Object, name string) (string, bool) {
+	var receiver BaseObject
+
+	switch o := o.(type) {
+	case *RObject:
+		receiver = o
+	case *RClass:
+		receiver = o
+	default:
+		return "", false
+	}
+
+	m, ok := vm.lookupMethod(receiver, name).(*Method)
+
+	if !ok {
+		return "", false
+	}
+
+	s, ok := vm.callMethod(receiver, m).(*StringObject)
+
+	if !ok {
+		return "", false
+	}
+
+	return s.Value, true
+}
+
+// defaultInspect is what built in to_s and inspect methods return
+func (vm *VM) defaultInspect(o Object) string {
+	switch o := o.(type) {
+	case *ArrayObject:
+		return o.inspect(vm.inspect)
+	case *HashObject:
+		return o.inspect(vm.inspect)
+	case *RObject:
+		if members, ok := structMembers(o.Class); ok {
+			return vm.inspectStruct(o, members)
+		}
+
+		return o.Inspect()
+	default:
+		return o.Inspect()
+	}
+}
+
+// prettyWidth is how many columns pp fits inspected objects in before it breaks them into lines.
+const prettyWidth = 80
+
+// prettyInspect is inspect for pp. Arrays and hashes that don't fit in prettyWidth when they start at column
+// are broken into one element per line, elements are indented by two more spaces than the line o is on.
+func (vm *VM) prettyInspect(o Object, indent, column int) string {
+	s := vm.inspect(o)
+
+	if column+utf8.RuneCountInString(s) <= prettyWidth {
+		return s
+	}
+
+	padding := strings.Repeat(" ", indent+2)
+	var lines []string
+
+	switch o := o.(type) {
+	case *ArrayObject:
+		for _, e := range o.Elements {
+			lines = append(lines, padding+vm.prettyInspect(e, indent+2, indent+2))
+		}
+
+		return "Array:[\n" + strings.Join(lines, ",\n") + "\n" + padding[2:] + "]"
+	case *HashObject:
+		for _, p := range o.pairs {
+			key := vm.inspect(p.key) + " => "
+
+			if k, ok := p.key.(*StringObject); ok {
+				key = k.Value + ": "
+			}
+
+			lines = append(lines, padding+key+vm.prettyInspect(p.value, indent+2, indent+2+utf8.RuneCountInString(key)))
+		}
+
+		return "{\n" + strings.Join(lines, ",\n") + "\n" + padding[2:] + "}"
+	}
+
+	return s
+}
+
+// printedArguments returns what p and pp return, which is nil, their only argument or an array of their arguments.
+func (vm *VM) printedArguments(args []Object) Object {
+	switch len(args) {
+	case 0:
+		return NULL
+	case 1:
+		return args[0]
+	}
+
+	arr := InitializeArray(args)
+	vm.track(arr)
+
+	return arr
+}
@@ -0,0 +1,184 @@
+package vm
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestToSAndInspect(t *testing.T) {
+	point := `
+	class Point
+	  def initialize(x, y)
+	    @x = x
+	    @y = y
+	  end
+
+	  def to_s
+	    "(" + @x.to_s + ", " + @y.to_s + ")"
+	  end
+
+	  def inspect
+	    "#<Point " + to_s + ">"
+	  end
+	end
+	`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Point.new(1, 2).to_s`, "(1, 2)"},
+		{`Point.new(1, 2).inspect`, "#<Point (1, 2)>"},
+		{`[Point.new(1, 2), 3].to_s`, "Array:[#<Point (1, 2)>, 3]"},
+		{`[Point.new(1, 2)].inspect`, "Array:[#<Point (1, 2)>]"},
+		{`{ a: Point.new(1, 2) }.to_s`, "{ a: #<Point (1, 2)> }"},
+		{`Object.new.inspect`, "<Instance of: Object>"},
+		{`
+		class Foo
+		end
+
+		Foo.new.to_s
+		`, "<Instance of: Foo>"},
+		{`
+		class Foo
+		  def self.to_s
+		    "Foo!"
+		  end
+		end
+
+		[Foo].to_s
+		`, "Array:[<Class:Foo>]"},
+		{`
+		begin
+		  Point.new(1, 2).foo
+		rescue NoMethodError => e
+		  e.message
+		end
+		`, "undefined method `foo' for #<Point (1, 2)>"},
+		{`
+		begin
+		  raise(StandardError, Point.new(1, 2))
+		rescue StandardError => e
+		  e.message
+		end
+		`, "(1, 2)"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, point+tt.input)
+
+		if !testStringObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestFailingInspectInErrorMessage(t *testing.T) {
+	input := `
+	class Foo
+	  def inspect
+	    bar
+	  end
+	end
+
+	Foo.new.baz
+	`
+
+	err := testEvalError(t, New(), "", input)
+
+	if err == nil || err.Error() != "NoMethodError: undefined method `bar' for <Instance of: Foo>" {
+		t.Fatalf("Expect the error of inspect. got=%v", err)
+	}
+}
+
+func TestPutsCallsToS(t *testing.T) {
+	r, w, err := os.Pipe()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+
+	testEval(t, `
+	class Foo
+	  def to_s
+	    "a foo"
+	  end
+	end
+
+	puts(Foo.new)
+	1
+	`)
+
+	os.Stdout = stdout
+	w.Close()
+
+	var out bytes.Buffer
+	io.Copy(&out, r)
+
+	if out.String() != "a foo\n" {
+		t.Fatalf("Expect puts to print what to_s returns. got=%q", out.String())
+	}
+}
+
+func TestPAndPP(t *testing.T) {
+	tests := []struct {
+		input  string
+		stdout string
+	}{
+		{`p(1, "a", [1, "b"])`, "1\na\nArray:[1, b]\n"},
+		{`
+		class Foo
+		  def inspect
+		    "#<Foo>"
+		  end
+		end
+
+		p(Foo.new)
+		`, "#<Foo>\n"},
+		{`pp({ a: [1, 2] })`, "{ a: Array:[1, 2] }\n"},
+		{`pp({ name: "rooby", tags: ["aaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbb", "cccccccccccccccc"], nested: { a: 1 } })`,
+			"{\n  name: rooby,\n  tags: Array:[aaaaaaaaaaaaaaaa, bbbbbbbbbbbbbbbb, cccccccccccccccc],\n  nested: { a: 1 }\n}\n"},
+		{`pp([1, ["aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"]])`,
+			"Array:[\n  1,\n  Array:[\n    aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa,\n    bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb\n  ]\n]\n"},
+	}
+
+	for i, tt := range tests {
+		var stdout bytes.Buffer
+		v := New()
+		v.SetStdout(&stdout)
+
+		testEvalWithVM(t, v, tt.input)
+
+		if stdout.String() != tt.stdout {
+			t.Fatalf("at test case %d: expect stdout %q. got=%q", i, tt.stdout, stdout.String())
+		}
+	}
+}
+
+func TestPAndPPReturnTheirArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`p(1) + 1`, "2"},
+		{`pp("a")`, "a"},
+		{`p(1, 2)`, "Array:[1, 2]"},
+		{`p()`, "null"},
+	}
+
+	for i, tt := range tests {
+		v := New()
+		v.SetStdout(&bytes.Buffer{})
+
+		evaluated := testEvalWithVM(t, v, tt.input)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Fatalf("at test case %d: expect %s. got=%s", i, tt.expected, evaluated.Inspect())
+		}
+	}
+}
@@ -3,14 +3,50 @@ package vm
 import (
 	"bytes"
 	"fmt"
+	"math/big"
 	"strings"
 )
 
-type Operation func(vm *VM, cf *CallFrame, args ...interface{})
+// Opcode identifies what an instruction does. VM.execInstruction dispatches instructions by switching on it.
+type Opcode int
+
+const (
+	OP_POP Opcode = iota
+	OP_PUT_OBJECT
+	OP_GET_CONSTANT
+	OP_GET_LOCAL
+	OP_GET_INSTANCE_VARIABLE
+	OP_SET_INSTANCE_VARIABLE
+	OP_GET_GLOBAL
+	OP_SET_GLOBAL
+	OP_SET_LOCAL
+	OP_SET_CONSTANT
+	OP_NEW_ARRAY
+	OP_EXPAND_ARRAY
+	OP_NEW_HASH
+	OP_NEW_RANGE
+	OP_BRANCH_UNLESS
+	OP_JUMP
+	OP_PUT_SELF
+	OP_PUT_STRING
+	OP_PUT_NULL
+	OP_PUT_FLOAT
+	OP_PUT_RATIONAL
+	OP_DEF_METHOD
+	OP_DEF_SINGLETON_METHOD
+	OP_DEF_CLASS
+	OP_DEF_MODULE
+	OP_SEND
+	OP_INVOKE_BLOCK
+	OP_INVOKE_SUPER
+	OP_CHECK_MATCH
+	OP_THROW
+	OP_LEAVE
+)
 
 type Action struct {
-	Name      string
-	Operation Operation
+	Name   string
+	Opcode Opcode
 }
 
 type Instruction struct {
@@ -36,6 +72,29 @@ var labelTypes = map[string]LabelType{
 type InstructionSet struct {
 	Label        *Label
 	Instructions []*Instruction
+	CatchTable   []*CatchEntry
+	LineTable    []*LineEntry
+	File         string
+	// Arity is the number of parameters of a block
+	Arity int
+	// locals are the names of the local variables by index, they're only in the bytecode of programs that call eval
+	locals []string
+	// labels is the label table the instruction set is loaded into
+	labels *labelTable
+}
+
+// LineEntry maps instructions from PC until next entry to a line of File.
+type LineEntry struct {
+	PC   int
+	Line int
+}
+
+// CatchEntry protects instructions in [Start, End). If one of them raises an exception,
+// the exception is pushed onto the stack and execution continues from Handler.
+type CatchEntry struct {
+	Start   int
+	End     int
+	Handler int
 }
 
 type OperationType string
@@ -51,15 +110,21 @@ const (
 	GET_LOCAL             = "getlocal"
 	GET_CONSTANT          = "getconstant"
 	GET_INSTANCE_VARIABLE = "getinstancevariable"
+	GET_GLOBAL            = "getglobal"
 	SET_LOCAL             = "setlocal"
 	SET_CONSTANT          = "setconstant"
 	SET_INSTANCE_VARIABLE = "setinstancevariable"
+	SET_GLOBAL            = "setglobal"
 	PUT_STRING            = "putstring"
 	PUT_SELF              = "putself"
 	PUT_OBJECT            = "putobject"
 	PUT_NULL              = "putnil"
+	PUT_FLOAT             = "putfloat"
+	PUT_RATIONAL          = "putrational"
 	NEW_ARRAY             = "newarray"
+	EXPAND_ARRAY          = "expandarray"
 	NEW_HASH              = "newhash"
+	NEW_RANGE             = "newrange"
 	PLUS                  = "opt_plus"
 	MINUS                 = "opt_minus"
 	MULT                  = "opt_mult"
@@ -73,352 +138,590 @@ const (
 	DEF_METHOD            = "def_method"
 	DEF_SINGLETON_METHOD  = "def_singleton_method"
 	DEF_CLASS             = "def_class"
+	DEF_MODULE            = "def_module"
 	SEND                  = "send"
 	INVOKE_BLOCK          = "invokeblock"
+	INVOKE_SUPER          = "invokesuper"
+	CHECK_MATCH           = "checkmatch"
+	THROW                 = "throw"
 	POP                   = "pop"
 	LEAVE                 = "leave"
 )
 
 var BuiltInActions = map[OperationType]*Action{
-	POP: {
-		Name: POP,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			vm.Stack.pop()
-		},
-	},
-	PUT_OBJECT: {
-		Name: PUT_OBJECT,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			object := initializeObject(args[0])
-			vm.Stack.push(&Pointer{Target: object})
-		},
-	},
-	GET_CONSTANT: {
-		Name: GET_CONSTANT,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			constName := args[0].(string)
-			constant, ok := vm.Constants[constName]
-
-			if !ok {
-				panic(fmt.Sprintf("Can't find constant: %s", constName))
-			}
-			vm.Stack.push(constant)
-		},
-	},
-	GET_LOCAL: {
-		Name: GET_LOCAL,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			index := args[0].(int)
-			depth := 0
-
-			if len(args) >= 2 {
-				depth = args[1].(int)
-			}
+	POP:                   {Name: POP, Opcode: OP_POP},
+	PUT_OBJECT:            {Name: PUT_OBJECT, Opcode: OP_PUT_OBJECT},
+	GET_CONSTANT:          {Name: GET_CONSTANT, Opcode: OP_GET_CONSTANT},
+	GET_LOCAL:             {Name: GET_LOCAL, Opcode: OP_GET_LOCAL},
+	GET_INSTANCE_VARIABLE: {Name: GET_INSTANCE_VARIABLE, Opcode: OP_GET_INSTANCE_VARIABLE},
+	SET_INSTANCE_VARIABLE: {Name: SET_INSTANCE_VARIABLE, Opcode: OP_SET_INSTANCE_VARIABLE},
+	GET_GLOBAL:            {Name: GET_GLOBAL, Opcode: OP_GET_GLOBAL},
+	SET_GLOBAL:            {Name: SET_GLOBAL, Opcode: OP_SET_GLOBAL},
+	SET_LOCAL:             {Name: SET_LOCAL, Opcode: OP_SET_LOCAL},
+	SET_CONSTANT:          {Name: SET_CONSTANT, Opcode: OP_SET_CONSTANT},
+	NEW_ARRAY:             {Name: NEW_ARRAY, Opcode: OP_NEW_ARRAY},
+	EXPAND_ARRAY:          {Name: EXPAND_ARRAY, Opcode: OP_EXPAND_ARRAY},
+	NEW_HASH:              {Name: NEW_HASH, Opcode: OP_NEW_HASH},
+	NEW_RANGE:             {Name: NEW_RANGE, Opcode: OP_NEW_RANGE},
+	BRANCH_UNLESS:         {Name: BRANCH_UNLESS, Opcode: OP_BRANCH_UNLESS},
+	JUMP:                  {Name: JUMP, Opcode: OP_JUMP},
+	PUT_SELF:              {Name: PUT_SELF, Opcode: OP_PUT_SELF},
+	PUT_STRING:            {Name: PUT_STRING, Opcode: OP_PUT_STRING},
+	PUT_NULL:              {Name: PUT_NULL, Opcode: OP_PUT_NULL},
+	PUT_FLOAT:             {Name: PUT_FLOAT, Opcode: OP_PUT_FLOAT},
+	PUT_RATIONAL:          {Name: PUT_RATIONAL, Opcode: OP_PUT_RATIONAL},
+	DEF_METHOD:            {Name: DEF_METHOD, Opcode: OP_DEF_METHOD},
+	DEF_SINGLETON_METHOD:  {Name: DEF_SINGLETON_METHOD, Opcode: OP_DEF_SINGLETON_METHOD},
+	DEF_CLASS:             {Name: DEF_CLASS, Opcode: OP_DEF_CLASS},
+	DEF_MODULE:            {Name: DEF_MODULE, Opcode: OP_DEF_MODULE},
+	SEND:                  {Name: SEND, Opcode: OP_SEND},
+	INVOKE_BLOCK:          {Name: INVOKE_BLOCK, Opcode: OP_INVOKE_BLOCK},
+	INVOKE_SUPER:          {Name: INVOKE_SUPER, Opcode: OP_INVOKE_SUPER},
+	CHECK_MATCH:           {Name: CHECK_MATCH, Opcode: OP_CHECK_MATCH},
+	THROW:                 {Name: THROW, Opcode: OP_THROW},
+	LEAVE:                 {Name: LEAVE, Opcode: OP_LEAVE},
+}
 
-			p := cf.getLCL(index, depth)
+func (vm *VM) opPutObject(cf *CallFrame, args []interface{}) {
+	var object Object
 
-			if p == nil {
-				panic(fmt.Sprintf("Local index: %d is nil. Callframe: %s", index, cf.InstructionSet.Label.Name))
-			}
-			vm.Stack.push(p)
-		},
-	},
-	GET_INSTANCE_VARIABLE: {
-		Name: GET_INSTANCE_VARIABLE,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			variableName := args[0].(string)
-			v, ok := cf.Self.(*RObject).InstanceVariables.Get(variableName)
-			if !ok {
-				vm.Stack.push(&Pointer{Target: NULL})
-				return
-			}
+	if i, ok := args[0].(int); ok {
+		object = vm.initInteger(i)
+	} else if i, ok := args[0].(*big.Int); ok {
+		object = vm.initBigInteger(i)
+	} else {
+		object = initializeObject(args[0])
+	}
 
-			p := &Pointer{Target: v}
-			vm.Stack.push(p)
-		},
-	},
-	SET_INSTANCE_VARIABLE: {
-		Name: SET_INSTANCE_VARIABLE,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			variableName := args[0].(string)
-			p := vm.Stack.pop()
-			cf.Self.(*RObject).InstanceVariables.Set(variableName, p.Target)
-		},
-	},
-	SET_LOCAL: {
-		Name: SET_LOCAL,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			v := vm.Stack.pop()
-			depth := 0
-
-			if len(args) >= 2 {
-				depth = args[1].(int)
-			}
-			cf.insertLCL(args[0].(int), depth, v.Target)
-		},
-	},
-	SET_CONSTANT: {
-		Name: SET_CONSTANT,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			constName := args[0].(string)
-			v := vm.Stack.pop()
-			vm.Constants[constName] = v
-		},
-	},
-	NEW_ARRAY: {
-		Name: NEW_ARRAY,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			argCount := args[0].(int)
-			elems := []Object{}
-
-			for i := 0; i < argCount; i++ {
-				v := vm.Stack.pop()
-				elems = append([]Object{v.Target}, elems...)
-			}
+	vm.Stack.push(&Pointer{Target: object})
+}
 
-			arr := InitializeArray(elems)
-			vm.Stack.push(&Pointer{arr})
-		},
-	},
-	NEW_HASH: {
-		Name: NEW_HASH,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			argCount := args[0].(int)
-			pairs := map[string]Object{}
-
-			for i := 0; i < argCount/2; i++ {
-				v := vm.Stack.pop()
-				k := vm.Stack.pop()
-				pairs[k.Target.(*StringObject).Value] = v.Target
-			}
+func (vm *VM) opGetConstant(cf *CallFrame, args []interface{}) {
+	constName := args[0].(string)
+	var cache *constantCache
 
-			hash := InitializeHash(pairs)
-			vm.Stack.push(&Pointer{hash})
-		},
-	},
-	BRANCH_UNLESS: {
-		Name: BRANCH_UNLESS,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			v := vm.Stack.pop()
-			bool, isBool := v.Target.(*BooleanObject)
-
-			if isBool {
-				if bool.Value {
-					return
-				}
-
-				line := args[0].(int)
-				cf.PC = line
-				return
-			}
+	if len(args) >= 2 {
+		cache = args[1].(*constantCache)
+	}
 
-			_, isNull := v.Target.(*Null)
+	constant, ok := vm.lookupConstant(constName, cache)
 
-			if isNull {
-				line := args[0].(int)
-				cf.PC = line
-				return
-			}
-		},
-	},
-	JUMP: {
-		Name: JUMP,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			cf.PC = args[0].(int)
-		},
-	},
-	PUT_SELF: {
-		Name: PUT_SELF,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			vm.Stack.push(&Pointer{cf.Self})
-		},
-	},
-	PUT_STRING: {
-		Name: PUT_STRING,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			object := initializeObject(args[0])
-			vm.Stack.push(&Pointer{object})
-		},
-	},
-	PUT_NULL: {
-		Name: PUT_NULL,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
+	if !ok {
+		vm.raise(NameErrorClass, "uninitialized constant %s", constName)
+	}
+	vm.Stack.push(constant)
+}
+
+func (vm *VM) opGetLocal(cf *CallFrame, args []interface{}) {
+	index := args[0].(int)
+	depth := 0
+
+	if len(args) >= 2 {
+		depth = args[1].(int)
+	}
+
+	p := cf.getLCL(index, depth)
+
+	if p == nil {
+		panic(fmt.Sprintf("Local index: %d is nil. Callframe: %s", index, cf.InstructionSet.Label.Name))
+	}
+	vm.Stack.push(p)
+}
+
+// opGetInstanceVariable pushes nil for selves that can't have instance variables, like instance_variable_get returns.
+func (vm *VM) opGetInstanceVariable(cf *CallFrame, args []interface{}) {
+	variableName := args[0].(string)
+	ro, ok := cf.Self.(*RObject)
+
+	if !ok {
+		vm.Stack.push(&Pointer{Target: NULL})
+		return
+	}
+
+	v, ok := ro.InstanceVariables.Get(variableName)
+	if !ok {
+		vm.Stack.push(&Pointer{Target: NULL})
+		return
+	}
+
+	p := &Pointer{Target: v}
+	vm.Stack.push(p)
+}
+
+func (vm *VM) opSetInstanceVariable(cf *CallFrame, args []interface{}) {
+	variableName := args[0].(string)
+	p := vm.Stack.pop()
+	ro, ok := cf.Self.(*RObject)
+
+	if !ok {
+		vm.raise(TypeErrorClass, "can't modify instance variables of %s", cf.Self.Inspect())
+	}
+
+	vm.checkFrozen(ro)
+	ro.InstanceVariables.Set(variableName, p.Target)
+}
+
+func (vm *VM) opGetGlobal(cf *CallFrame, args []interface{}) {
+	vm.Stack.push(&Pointer{Target: vm.GetGlobal(args[0].(string))})
+}
+
+func (vm *VM) opSetGlobal(cf *CallFrame, args []interface{}) {
+	p := vm.Stack.pop()
+	vm.SetGlobal(args[0].(string), p.Target)
+}
+
+func (vm *VM) opSetLocal(cf *CallFrame, args []interface{}) {
+	v := vm.Stack.pop()
+	depth := 0
+
+	if len(args) >= 2 {
+		depth = args[1].(int)
+	}
+	cf.insertLCL(args[0].(int), depth, v.Target)
+}
+
+func (vm *VM) opSetConstant(cf *CallFrame, args []interface{}) {
+	constName := args[0].(string)
+	v := vm.Stack.pop()
+	vm.setConstant(constName, v)
+}
+
+func (vm *VM) opNewArray(cf *CallFrame, args []interface{}) {
+	argCount := args[0].(int)
+	elems := []Object{}
+
+	for i := 0; i < argCount; i++ {
+		v := vm.Stack.pop()
+		elems = append([]Object{v.Target}, elems...)
+	}
+
+	arr := InitializeArray(elems)
+	vm.track(arr)
+	vm.Stack.push(&Pointer{arr})
+}
+
+// opExpandArray replaces the array on top of the stack with its first n elements, padded with nil, and pushes them
+// in reverse so the first element is on top. Any other object is expanded like an array of itself.
+func (vm *VM) opExpandArray(cf *CallFrame, args []interface{}) {
+	n := args[0].(int)
+	v := vm.Stack.pop()
+	elems := []Object{v.Target}
+
+	if arr, ok := v.Target.(*ArrayObject); ok {
+		elems = arr.Elements
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		if i < len(elems) {
+			vm.Stack.push(&Pointer{elems[i]})
+		} else {
 			vm.Stack.push(&Pointer{NULL})
-		},
-	},
-	DEF_METHOD: {
-		Name: DEF_METHOD,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			argCount := args[0].(int)
-			methodName := vm.Stack.pop().Target.(*StringObject).Value
-			is, _ := vm.getMethodIS(methodName)
-			method := &Method{Name: methodName, Argc: argCount, InstructionSet: is}
-
-			v := vm.Stack.pop().Target
-			switch self := v.(type) {
-			case *RClass:
-				self.Methods.Set(methodName, method)
-			case BaseObject:
-				self.ReturnClass().(*RClass).Methods.Set(methodName, method)
-			default:
-				panic(fmt.Sprintf("Can't define method on %T", self))
-			}
-		},
-	},
-	DEF_SINGLETON_METHOD: {
-		Name: DEF_SINGLETON_METHOD,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			argCount := args[0].(int)
-			methodName := vm.Stack.pop().Target.(*StringObject).Value
-			is, _ := vm.getMethodIS(methodName)
-			method := &Method{Name: methodName, Argc: argCount, InstructionSet: is}
-
-			v := vm.Stack.pop().Target
-
-			switch self := v.(type) {
-			case *RClass:
-				self.SetSingletonMethod(methodName, method)
-			case BaseObject:
-				self.ReturnClass().(*RClass).SetSingletonMethod(methodName, method)
-			default:
-				panic(fmt.Sprintf("Can't define singleton method on %T", self))
-			}
-		},
-	},
-	DEF_CLASS: {
-		Name: DEF_CLASS,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			class := InitializeClass(args[0].(string))
-			classPr := &Pointer{Target: class}
-			vm.Constants[class.Name] = classPr
-
-			is, ok := vm.getClassIS(class.Name)
-
-			if !ok {
-				panic(fmt.Sprintf("Can't find class %s's instructions", class.Name))
-			}
+		}
+	}
+}
 
-			if len(args) >= 2 {
-				constantName := args[1].(string)
-				constant := vm.Constants[constantName]
-				inheritedClass, ok := constant.Target.(*RClass)
-				if !ok {
-					newError("Constant %s is not a class. got=%T", constantName, constant)
-				}
+func (vm *VM) opNewHash(cf *CallFrame, args []interface{}) {
+	argCount := args[0].(int)
+	hash := InitializeHash(map[string]Object{})
+	pairs := make([]*Pointer, argCount)
 
-				class.SuperClass = inheritedClass
-			}
+	for i := argCount - 1; i >= 0; i-- {
+		pairs[i] = vm.Stack.pop()
+	}
 
-			vm.Stack.pop()
-			c := NewCallFrame(is)
-			c.Self = class
-			vm.CallFrameStack.Push(c)
-			vm.Exec()
-
-			vm.Stack.push(classPr)
-		},
-	},
-	SEND: {
-		Name: SEND,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			methodName := args[0].(string)
-			argCount := args[1].(int)
-			var blockName string
-			var hasBlock bool
-
-			if len(args) > 2 {
-				hasBlock = true
-				blockFlag := args[2].(string)
-				blockName = strings.Split(blockFlag, ":")[1]
-			} else {
-				hasBlock = false
-			}
+	for i := 0; i < argCount; i += 2 {
+		hash.set(vm, pairs[i].Target, pairs[i+1].Target)
+	}
 
-			argPr := vm.SP - argCount
-			receiverPr := argPr - 1
-			receiver := vm.Stack.Data[receiverPr].Target.(BaseObject)
+	vm.track(hash)
 
-			error := newError("undefined method `%s' for %s", methodName, receiver.Inspect())
+	vm.Stack.push(&Pointer{hash})
+}
 
-			var method Object
+func (vm *VM) opNewRange(cf *CallFrame, args []interface{}) {
+	end := vm.Stack.pop().Target
+	start := vm.Stack.pop().Target
 
-			switch receiver := receiver.(type) {
-			case Class:
-				method = receiver.LookupClassMethod(methodName)
-			case BaseObject:
-				method = receiver.ReturnClass().LookupInstanceMethod(methodName)
-			case *Error:
-				panic(receiver.Inspect())
-			default:
-				panic(fmt.Sprintf("not a valid receiver: %s", receiver.Inspect()))
-			}
+	vm.Stack.push(&Pointer{vm.newRange(start, end, args[0].(int) == 1)})
+}
 
-			if method == nil {
-				panic(error.Message)
-			}
+func (vm *VM) opBranchUnless(cf *CallFrame, args []interface{}) {
+	v := vm.Stack.pop()
+	bool, isBool := v.Target.(*BooleanObject)
 
-			var blockFrame *CallFrame
+	if isBool {
+		if bool.Value {
+			return
+		}
 
-			if hasBlock {
-				block, ok := vm.getBlock(blockName)
+		line := args[0].(int)
+		cf.PC = line
+		return
+	}
 
-				if !ok {
-					panic(fmt.Sprintf("Can't find block %s", blockName))
-				}
+	_, isNull := v.Target.(*Null)
 
-				c := NewCallFrame(block)
-				c.IsBlock = true
-				c.EP = cf
-				vm.CallFrameStack.Push(c)
-				blockFrame = c
-			}
+	if isNull {
+		line := args[0].(int)
+		cf.PC = line
+		return
+	}
+}
 
-			switch m := method.(type) {
-			case *Method:
-				evalMethodObject(vm, receiver, m, receiverPr, argCount, argPr, blockFrame)
-			case *BuiltInMethod:
-				evalBuiltInMethod(vm, receiver, m, receiverPr, argCount, argPr, blockFrame)
-			case *Error:
-				panic(m.Inspect())
-			default:
-				panic(fmt.Sprintf("unknown instance method type: %T", m))
-			}
-		},
-	},
-	INVOKE_BLOCK: {
-		Name: INVOKE_BLOCK,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			argCount := args[0].(int)
-			argPr := vm.SP - argCount
-			receiverPr := argPr - 1
-			receiver := vm.Stack.Data[receiverPr].Target.(BaseObject)
-
-			if cf.BlockFrame == nil {
-				panic("Can't yield without a block")
-			}
+func (vm *VM) opPutString(cf *CallFrame, args []interface{}) {
+	object := initializeObject(args[0])
+
+	if s, ok := object.(*StringObject); ok && vm.frozenStringLiterals {
+		s.freeze()
+	}
+
+	vm.Stack.push(&Pointer{object})
+}
+
+func (vm *VM) opDefMethod(cf *CallFrame, args []interface{}) {
+	argCount := args[0].(int)
+	methodName := vm.Stack.pop().Target.(*StringObject).Value
+	is, _ := vm.labelsOf(cf.InstructionSet).getMethodIS(methodName)
+	method := &Method{Name: methodName, Argc: argCount, InstructionSet: is}
+
+	v := vm.Stack.pop().Target
+	switch self := v.(type) {
+	case Class:
+		vm.defineMethod(self, method)
+	case BaseObject:
+		// Methods defined at top level become private methods of Object
+		if self == vm.MainObj {
+			method.Private = true
+		}
+
+		vm.defineMethod(self.ReturnClass(), method)
+	default:
+		vm.raise(TypeErrorClass, "can't define method on %s", self.Inspect())
+	}
+}
+
+func (vm *VM) opDefSingletonMethod(cf *CallFrame, args []interface{}) {
+	argCount := args[0].(int)
+	methodName := vm.Stack.pop().Target.(*StringObject).Value
+	is, _ := vm.labelsOf(cf.InstructionSet).getMethodIS(methodName)
+	method := &Method{Name: methodName, Argc: argCount, InstructionSet: is}
+
+	v := vm.Stack.pop().Target
+	vm.defineSingletonMethod(v, methodName, method)
+}
 
-			c := NewCallFrame(cf.BlockFrame.InstructionSet)
-			c.BlockFrame = cf.BlockFrame
-			c.EP = cf.BlockFrame.EP
-			c.Self = receiver
+// includableClass returns c if modules can be included into it or extend it. Built in classes are shared by every VM,
+// so their ancestors can't be changed, though methods can be defined on them.
+func (vm *VM) includableClass(c Class) *RClass {
+	class, ok := c.(*RClass)
+
+	if !ok || isSharedClass(class) {
+		vm.raise(TypeErrorClass, "can't change ancestors of built in class %s", c.ReturnName())
+	}
+
+	return class
+}
+
+func (vm *VM) opDefClass(cf *CallFrame, args []interface{}) {
+	name := args[0].(string)
+	is, ok := vm.labelsOf(cf.InstructionSet).getClassIS(name)
+
+	if !ok {
+		panic(fmt.Sprintf("Can't find class %s's instructions", name))
+	}
 
-			for i := 0; i < argCount; i++ {
-				c.Local[i] = vm.Stack.Data[argPr+i]
+	// a class that's already defined is reopened, its body adds to it
+	if classPr, ok := vm.Constants[name]; ok {
+		vm.reopenClass(cf, is, classPr, args)
+		return
+	}
+
+	class := vm.initializeClass(name)
+	classPr := &Pointer{Target: class}
+	vm.setConstant(class.Name, classPr)
+
+	if len(args) >= 2 {
+		constantName := args[1].(string)
+		constant, ok := vm.lookupConstant(constantName, nil)
+
+		if !ok {
+			vm.raise(NameErrorClass, "uninitialized constant %s", constantName)
+		}
+
+		inheritedClass, ok := constant.Target.(*RClass)
+
+		if !ok || inheritedClass.IsModule {
+			vm.raise(TypeErrorClass, "superclass must be a Class (%s given)", constant.Target.Inspect())
+		}
+
+		class.SuperClass = inheritedClass
+	}
+
+	vm.Stack.pop()
+	c := NewCallFrame(is)
+	c.Self = class
+	vm.CallFrameStack.Push(c)
+	vm.startFromTopFrame()
+
+	vm.Stack.push(classPr)
+}
+
+// reopenClass runs the class body is with the class classPr points to, which can be a built in class.
+func (vm *VM) reopenClass(cf *CallFrame, is *InstructionSet, classPr *Pointer, args []interface{}) {
+	name := args[0].(string)
+	class, ok := classPr.Target.(Class)
+	base, hasBase := classBase(class)
+
+	if !ok || !hasBase || base.IsModule {
+		vm.raise(TypeErrorClass, "%s is not a class", name)
+	}
+
+	if len(args) >= 2 {
+		constant, ok := vm.lookupConstant(args[1].(string), nil)
+
+		if !ok || base.SuperClass == nil || constant.Target != Object(base.SuperClass) {
+			vm.raise(TypeErrorClass, "superclass mismatch for class %s", name)
+		}
+	}
+
+	vm.Stack.pop()
+	c := NewCallFrame(is)
+	c.Self = class
+	vm.CallFrameStack.Push(c)
+	vm.startFromTopFrame()
+
+	vm.Stack.push(classPr)
+}
+
+func (vm *VM) opSend(cf *CallFrame, args []interface{}) {
+	methodName := args[0].(string)
+	argCount := args[1].(int)
+	var blockName string
+	var hasBlock bool
+
+	if len(args) > 2 {
+		hasBlock = true
+		blockFlag := args[2].(string)
+		blockName = strings.Split(blockFlag, ":")[1]
+	} else {
+		hasBlock = false
+	}
+
+	argPr := vm.SP - argCount
+	receiverPr := argPr - 1
+	receiver := vm.Stack.Data[receiverPr].Target.(BaseObject)
+	sent := false
+
+	// Unless the receiver defines its own send, send calls the method its first argument names.
+	// Like method_missing, it can call private methods.
+	for (methodName == "send" || methodName == "__send__") && vm.lookupMethod(receiver, methodName) == nil {
+		if argCount == 0 {
+			vm.raise(RuntimeErrorClass, "no method name given")
+		}
+
+		methodName = vm.nameArgument(vm.Stack.Data[argPr].Target)
+		vm.removeArgument(argPr)
+		argCount--
+		sent = true
+	}
+
+	method := vm.lookupMethod(receiver, methodName)
+	missing := false
+
+	// Calls to undefined methods are passed to method_missing, with the method name as first argument
+	if method == nil {
+		if method = vm.lookupMethod(receiver, "method_missing"); method != nil {
+			vm.insertArgument(argPr, InternSymbol(methodName))
+			argCount++
+			missing = true
+		}
+	}
+
+	if method == nil {
+		vm.raise(NoMethodErrorClass, "undefined method `%s' for %s", methodName, vm.inspectForError(receiver))
+	}
+
+	var blockFrame *CallFrame
+
+	if hasBlock {
+		block, ok := vm.labelsOf(cf.InstructionSet).getBlock(blockName)
+
+		if !ok {
+			panic(fmt.Sprintf("Can't find block %s", blockName))
+		}
+
+		c := NewCallFrame(block)
+		c.IsBlock = true
+		c.EP = cf
+		c.Self = cf.Self
+		blockFrame = c
+	}
+
+	if m, ok := method.(*Method); ok && m.Private && !missing && !sent && receiver != cf.Self {
+		vm.raise(NoMethodErrorClass, "private method `%s' called for %s", methodName, vm.inspectForError(receiver))
+	}
+
+	vm.evalMethod(receiver, method, receiverPr, argCount, argPr, blockFrame)
+}
+
+// lookupMethod returns receiver's method of methodName, or nil if it doesn't have one.
+func (vm *VM) lookupMethod(receiver BaseObject, methodName string) Object {
+	var method Object
+
+	switch receiver := receiver.(type) {
+	case Class:
+		method = vm.lookupClassMethod(receiver, methodName)
+	case *RObject:
+		method = vm.lookupInstanceMethod(receiver.methodClass(), methodName)
+	case BaseObject:
+		method = vm.lookupInstanceMethod(receiver.ReturnClass(), methodName)
+	default:
+		vm.raise(TypeErrorClass, "not a valid receiver: %s", receiver.Inspect())
+	}
+
+	// Built in classes inherit the shared Object class, but top level methods are defined on the VM's own one
+	if method == nil {
+		method, _ = vm.objectClass.Methods.GetCurrent(methodName)
+	}
+
+	return method
+}
+
+// insertArgument inserts value into the arguments on the stack at argPr, moving the arguments after it up by one.
+func (vm *VM) insertArgument(argPr int, value Object) {
+	vm.Stack.push(&Pointer{Target: NULL})
+	copy(vm.Stack.Data[argPr+1:vm.SP], vm.Stack.Data[argPr:vm.SP-1])
+	vm.Stack.Data[argPr] = &Pointer{Target: value}
+}
+
+// removeArgument removes the argument at argPr from the stack, moving the arguments after it down by one.
+func (vm *VM) removeArgument(argPr int) {
+	copy(vm.Stack.Data[argPr:vm.SP-1], vm.Stack.Data[argPr+1:vm.SP])
+	vm.Stack.pop()
+}
+
+// evalMethod calls method with receiver and the arguments on the stack, and replaces the receiver with the return value.
+func (vm *VM) evalMethod(receiver BaseObject, method Object, receiverPr, argCount, argPr int, blockFrame *CallFrame) {
+	switch m := method.(type) {
+	case *Method:
+		evalMethodObject(vm, receiver, m, receiverPr, argCount, argPr, blockFrame)
+	case *BuiltInMethod:
+		evalBuiltInMethod(vm, receiver, m, receiverPr, argCount, argPr, blockFrame)
+	default:
+		panic(fmt.Sprintf("unknown instance method type: %T", m))
+	}
+}
+
+func (vm *VM) opInvokeBlock(cf *CallFrame, args []interface{}) {
+	argCount := args[0].(int)
+	argPr := vm.SP - argCount
+	receiverPr := argPr - 1
+
+	if cf.BlockFrame == nil {
+		vm.raise(LocalJumpErrorClass, "no block given (yield)")
+	}
+
+	c := NewCallFrame(cf.BlockFrame.InstructionSet)
+	c.BlockFrame = cf.BlockFrame
+	c.EP = cf.BlockFrame.EP
+	c.Self = cf.BlockFrame.Self
+
+	// arguments are copied, so assigning to a block parameter doesn't change the variable passed to yield
+	for i := 0; i < argCount; i++ {
+		c.insertLCL(i, 0, vm.Stack.Data[argPr+i].Target)
+	}
+
+	vm.CallFrameStack.Push(c)
+	vm.startFromTopFrame()
+
+	setReturnValueAndSP(vm, receiverPr, vm.Stack.Top())
+}
+
+func (vm *VM) opInvokeSuper(cf *CallFrame, args []interface{}) {
+	argCount := args[0].(int)
+	argPr := vm.SP - argCount
+	receiverPr := argPr - 1
+
+	// super in a block calls the superclass method of the method the block is in
+	mf := cf
+
+	for mf.Method == nil && mf.EP != nil {
+		mf = mf.EP
+	}
+
+	if mf.Method == nil || mf.Method.Owner == nil {
+		vm.raise(RuntimeErrorClass, "super called outside of method")
+	}
+
+	current := mf.Method
+	var method Object
+
+	// lookup continues from the class after the method's owner in self's ancestors, since the owner can be a module
+	switch self := mf.Self.(type) {
+	case *RClass:
+		if superClass := self.superClassAfter(current.Owner); superClass != nil {
+			method = vm.lookupClassMethod(superClass, current.Name)
+		}
+	case *RObject:
+		if superClass := self.methodClass().superClassAfter(current.Owner); superClass != nil {
+			method = vm.lookupInstanceMethod(superClass, current.Name)
+		}
+	case BaseObject:
+		if class, ok := self.ReturnClass().(*RClass); ok {
+			if superClass := class.superClassAfter(current.Owner); superClass != nil {
+				method = vm.lookupInstanceMethod(superClass, current.Name)
 			}
+		}
+	}
 
-			vm.CallFrameStack.Push(c)
-			vm.Exec()
+	// when method_missing has nothing to fall back to, the call is undefined after all
+	if method == nil && current.Name == "method_missing" && argCount > 0 {
+		if name, ok := vm.Stack.Data[argPr].Target.(*SymbolObject); ok {
+			vm.raise(NoMethodErrorClass, "undefined method `%s' for %s", name.Name, vm.inspectForError(mf.Self))
+		}
+	}
 
-			setReturnValueAndSP(vm, receiverPr, vm.Stack.Top())
-		},
-	},
-	LEAVE: {
-		Name: LEAVE,
-		Operation: func(vm *VM, cf *CallFrame, args ...interface{}) {
-			cf = vm.CallFrameStack.Pop()
-			cf.PC = len(cf.InstructionSet.Instructions)
-		},
-	},
+	if method == nil {
+		vm.raise(NoMethodErrorClass, "super: no superclass method `%s' for %s", current.Name, vm.inspectForError(mf.Self))
+	}
+
+	vm.evalMethod(mf.Self, method, receiverPr, argCount, argPr, mf.BlockFrame)
+}
+
+func (vm *VM) opCheckMatch(cf *CallFrame, args []interface{}) {
+	classCount := args[0].(int)
+	classes := []*RClass{}
+
+	for i := 0; i < classCount; i++ {
+		class, ok := vm.Stack.pop().Target.(*RClass)
+
+		if !ok {
+			vm.raise(TypeErrorClass, "class or module required for rescue clause")
+		}
+
+		classes = append(classes, class)
+	}
+
+	exception := vm.Stack.Top().Target.(*RObject)
+	matched := false
+
+	for _, class := range classes {
+		if exception.Class.inheritsFrom(class.BaseClass) {
+			matched = true
+		}
+	}
+
+	if matched {
+		vm.Stack.push(&Pointer{TRUE})
+		return
+	}
+
+	vm.Stack.push(&Pointer{FALSE})
+}
+
+func (vm *VM) opThrow(cf *CallFrame, args []interface{}) {
+	exception := vm.Stack.pop().Target.(*RObject)
+	vm.raiseException(exception)
 }
 
 func evalBuiltInMethod(vm *VM, receiver BaseObject, method *BuiltInMethod, receiverPr, argCount, argPr int, blockFrame *CallFrame) {
@@ -429,11 +732,21 @@ func evalBuiltInMethod(vm *VM, receiver BaseObject, method *BuiltInMethod, recei
 		args = append(args, vm.Stack.Data[argPr+i].Target)
 	}
 
-	evaluated := methodBody(args, nil)
+	evaluated := methodBody(vm, args, blockFrame)
 
+	if err, ok := evaluated.(*Error); ok {
+		vm.raise(err.exception(), "%s", err.Message)
+	}
+
+	// built in methods track the arrays and hashes they allocate, strings and big integers are tracked once they're returned
+	switch evaluated.(type) {
+	case *StringObject, *IntegerObject:
+		vm.track(evaluated)
+	}
+
+	// built in `new` methods can return classes too, like Struct.new
 	_, ok := receiver.(*RClass)
-	if method.Name == "new" && ok {
-		instance := evaluated.(*RObject)
+	if instance, isInstance := evaluated.(*RObject); method.Name == "new" && ok && isInstance {
 		if instance.InitializeMethod != nil {
 			evalMethodObject(vm, instance, instance.InitializeMethod, receiverPr, argCount, argPr, blockFrame)
 		}
@@ -444,21 +757,28 @@ func evalBuiltInMethod(vm *VM, receiver BaseObject, method *BuiltInMethod, recei
 func evalMethodObject(vm *VM, receiver BaseObject, method *Method, receiverPr, argC, argPr int, blockFrame *CallFrame) {
 	c := NewCallFrame(method.InstructionSet)
 	c.Self = receiver
+	c.Method = method
+
+	c.BlockFrame = blockFrame
+
+	if method.block != nil {
+		c.BlockFrame = method.block
+		c.EP = method.block.EP
+	}
 
 	for i := 0; i < argC; i++ {
 		c.insertLCL(i, 0, vm.Stack.Data[argPr+i].Target)
 	}
 
-	c.BlockFrame = blockFrame
 	vm.CallFrameStack.Push(c)
-	vm.Exec()
+	vm.startFromTopFrame()
 
 	setReturnValueAndSP(vm, receiverPr, vm.Stack.Top())
 }
 
 func setReturnValueAndSP(vm *VM, receiverPr int, value *Pointer) {
 	vm.Stack.Data[receiverPr] = value
-	vm.SP = receiverPr + 1
+	vm.Stack.truncate(receiverPr + 1)
 }
 
 func (is *InstructionSet) Define(line int, action *Action, params ...interface{}) {
@@ -466,6 +786,32 @@ func (is *InstructionSet) Define(line int, action *Action, params ...interface{}
 	is.Instructions = append(is.Instructions, i)
 }
 
+// catchEntryFor returns the innermost catch entry that protects instruction at pc
+func (is *InstructionSet) catchEntryFor(pc int) *CatchEntry {
+	for _, entry := range is.CatchTable {
+		if entry.Start <= pc && pc < entry.End {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// sourceLine returns the source line that instruction at pc was compiled from, or 0 if it's unknown.
+func (is *InstructionSet) sourceLine(pc int) int {
+	line := 0
+
+	for _, entry := range is.LineTable {
+		if entry.PC > pc {
+			break
+		}
+
+		line = entry.Line
+	}
+
+	return line
+}
+
 func (is *InstructionSet) Inspect() string {
 	var out bytes.Buffer
 
@@ -493,6 +839,8 @@ func initializeObject(value interface{}) Object {
 		return InitilaizeInteger(int(v))
 	case int64:
 		return InitilaizeInteger(int(v))
+	case *big.Int:
+		return (*VM)(nil).initBigInteger(v)
 	case string:
 		switch v {
 		case "true":
@@ -1,7 +1,9 @@
 package vm
 
 import (
-	"fmt"
+	"math"
+	"math/big"
+	"strconv"
 )
 
 var (
@@ -12,9 +14,14 @@ type RInteger struct {
 	*BaseClass
 }
 
+// IntegerObject is an integer. Integers that don't fit in Value are kept in big instead, like Ruby's Bignum,
+// so arithmetic that overflows an int returns a big integer rather than wrapping around.
 type IntegerObject struct {
 	Class *RInteger
 	Value int
+	big   *big.Int
+	// tracked is set once a big integer is counted by the VM's object limits
+	tracked bool
 }
 
 func (i *IntegerObject) Type() ObjectType {
@@ -22,7 +29,11 @@ func (i *IntegerObject) Type() ObjectType {
 }
 
 func (i *IntegerObject) Inspect() string {
-	return fmt.Sprintf("%d", i.Value)
+	if i.big != nil {
+		return i.big.String()
+	}
+
+	return strconv.Itoa(i.Value)
 }
 
 func (i *IntegerObject) ReturnClass() Class {
@@ -33,242 +44,752 @@ func InitilaizeInteger(value int) *IntegerObject {
 	return &IntegerObject{Value: value, Class: IntegerClass}
 }
 
+const (
+	// DefaultSmallIntegerMin and DefaultSmallIntegerMax are the range of integers New preallocates.
+	DefaultSmallIntegerMin = -128
+	DefaultSmallIntegerMax = 1024
+)
+
+// SetSmallIntegerRange preallocates integers from min to max, which are then reused instead of allocating a new
+// object every time they're created by the program. Passing max < min disables preallocation.
+func (vm *VM) SetSmallIntegerRange(min, max int) {
+	vm.smallIntegerMin = min
+	vm.smallIntegers = nil
+
+	for i := min; i <= max; i++ {
+		vm.smallIntegers = append(vm.smallIntegers, InitilaizeInteger(i))
+	}
+}
+
+// initInteger returns the preallocated integer of value if there is one, otherwise a new integer.
+// Integers are immutable, so the same object can be shared by every reference to it.
+// Built in methods can be called without a VM, in which case a new integer is returned.
+func (vm *VM) initInteger(value int) *IntegerObject {
+	if vm == nil {
+		return InitilaizeInteger(value)
+	}
+
+	if i := value - vm.smallIntegerMin; i >= 0 && i < len(vm.smallIntegers) {
+		return vm.smallIntegers[i]
+	}
+
+	return InitilaizeInteger(value)
+}
+
+const (
+	maxInt = int(^uint(0) >> 1)
+	minInt = -maxInt - 1
+)
+
+var (
+	bigMaxInt = big.NewInt(int64(maxInt))
+	bigMinInt = big.NewInt(int64(minInt))
+)
+
+// initBigInteger returns the integer of value. value is only kept as a big integer if it doesn't fit in an int,
+// so results of big integer arithmetic that are small again are ordinary integers.
+func (vm *VM) initBigInteger(value *big.Int) *IntegerObject {
+	if value.Cmp(bigMinInt) >= 0 && value.Cmp(bigMaxInt) <= 0 {
+		return vm.initInteger(int(value.Int64()))
+	}
+
+	return &IntegerObject{Class: IntegerClass, big: value}
+}
+
+// bigValue returns the value of i as a big.Int, which must not be modified.
+func (i *IntegerObject) bigValue() *big.Int {
+	if i.big != nil {
+		return i.big
+	}
+
+	return big.NewInt(int64(i.Value))
+}
+
+// float returns the value of i as a float, big integers too large for a float are infinite.
+func (i *IntegerObject) float() float64 {
+	if i.big != nil {
+		f, _ := new(big.Float).SetInt(i.big).Float64()
+		return f
+	}
+
+	return float64(i.Value)
+}
+
+// cmp compares i with other and returns -1, 0 or 1 like big.Int's Cmp.
+func (i *IntegerObject) cmp(other *IntegerObject) int {
+	if i.big == nil && other.big == nil {
+		switch {
+		case i.Value < other.Value:
+			return -1
+		case i.Value > other.Value:
+			return 1
+		}
+
+		return 0
+	}
+
+	return i.bigValue().Cmp(other.bigValue())
+}
+
+// isZero reports whether i is 0, which big integers never are.
+func (i *IntegerObject) isZero() bool {
+	return i.big == nil && i.Value == 0
+}
+
+// integerOperation returns the result of an operation on left and right. small computes it if both are ints,
+// and reports false if the result overflows, in which case large computes it with big integers instead.
+func (vm *VM) integerOperation(left, right *IntegerObject, small func(a, b int) (int, bool), large func(z, a, b *big.Int) *big.Int) *IntegerObject {
+	if left.big == nil && right.big == nil {
+		if n, ok := small(left.Value, right.Value); ok {
+			return vm.initInteger(n)
+		}
+	}
+
+	return vm.initBigInteger(large(new(big.Int), left.bigValue(), right.bigValue()))
+}
+
+// addInt, subInt and mulInt return the result of an operation and whether it fits in an int.
+func addInt(a, b int) (int, bool) {
+	c := a + b
+	return c, (b >= 0) == (c >= a)
+}
+
+func subInt(a, b int) (int, bool) {
+	c := a - b
+	return c, (b >= 0) == (c <= a)
+}
+
+func mulInt(a, b int) (int, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+
+	c := a * b
+	return c, c/b == a && !(a == minInt && b == -1)
+}
+
+// quoInt returns a / b rounded towards negative infinity like Ruby's division, so it agrees with modInt.
+func quoInt(a, b int) (int, bool) {
+	q := a / b
+
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+
+	return q, !(a == minInt && b == -1)
+}
+
+// modInt returns the remainder of a / b, which has the same sign as b like Ruby's modulo.
+func modInt(a, b int) (int, bool) {
+	r := a % b
+
+	if r != 0 && (r < 0) != (b < 0) {
+		r += b
+	}
+
+	return r, true
+}
+
+// quoBig rounds towards negative infinity like quoInt. big.Int's Div doesn't, its remainder is never negative.
+func quoBig(z, a, b *big.Int) *big.Int {
+	r := new(big.Int)
+	z.QuoRem(a, b, r)
+
+	if r.Sign() != 0 && r.Sign() != b.Sign() {
+		z.Sub(z, big.NewInt(1))
+	}
+
+	return z
+}
+
+func modBig(z, a, b *big.Int) *big.Int {
+	z.Rem(a, b)
+
+	if z.Sign() != 0 && z.Sign() != b.Sign() {
+		z.Add(z, b)
+	}
+
+	return z
+}
+
+func powInt(a, b int) (int, bool) {
+	result := 1
+
+	for i := 0; i < b; i++ {
+		var ok bool
+
+		if result, ok = mulInt(result, a); !ok {
+			return 0, false
+		}
+	}
+
+	return result, true
+}
+
+func powBig(z, a, b *big.Int) *big.Int {
+	return z.Exp(a, b, nil)
+}
+
+// integerBitOperator returns a built in method of Integer that combines the receiver with an integer argument.
+// Big integers are combined by operateBig, which treats negative numbers as two's complement like operate does.
+func integerBitOperator(name string, operate func(left, right int) int, operateBig func(z, a, b *big.Int) *big.Int) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				right, ok := args[0].(*IntegerObject)
+
+				if !ok {
+					vm.raise(TypeErrorClass, "wrong argument type %s (expected Integer)", vm.inspectForError(args[0]))
+				}
+
+				small := func(a, b int) (int, bool) { return operate(a, b), true }
+				return vm.integerOperation(receiver.(*IntegerObject), right, small, operateBig)
+			}
+		},
+		Name: name,
+	}
+}
+
+// integerShift returns a built in method of Integer that shifts the receiver left by count bits, or right if count is negative.
+// Shifting left never loses bits, an int that overflows becomes a big integer.
+func integerShift(name string, sign int) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				n := receiver.(*IntegerObject)
+				count := sign * vm.integerArgument(args[0])
+
+				switch {
+				case count < 0 && n.big == nil:
+					return vm.initInteger(shiftLeft(n.Value, count))
+				case count < 0:
+					return vm.initBigInteger(new(big.Int).Rsh(n.big, uint(-count)))
+				case n.big == nil && count < 63 && shiftLeft(n.Value, count)>>uint(count) == n.Value:
+					return vm.initInteger(shiftLeft(n.Value, count))
+				}
+
+				return vm.initBigInteger(new(big.Int).Lsh(n.bigValue(), uint(count)))
+			}
+		},
+		Name: name,
+	}
+}
+
+// shiftLeft shifts n left by count bits, or right if count is negative. Right shifts keep the sign,
+// so a negative number shifted right far enough is -1.
+func shiftLeft(n, count int) int {
+	if count < 0 {
+		if count < -63 {
+			count = -63
+		}
+
+		return n >> uint(-count)
+	}
+
+	if count > 63 {
+		return 0
+	}
+
+	return n << uint(count)
+}
+
 var builtinIntegerMethods = []*BuiltInMethod{
+	integerBitOperator("&", func(left, right int) int { return left & right }, (*big.Int).And),
+	integerBitOperator("|", func(left, right int) int { return left | right }, (*big.Int).Or),
+	integerBitOperator("^", func(left, right int) int { return left ^ right }, (*big.Int).Xor),
+	integerShift("<<", 1),
+	integerShift(">>", -1),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				n := receiver.(*IntegerObject)
+
+				if n.big != nil {
+					return vm.initBigInteger(new(big.Int).Not(n.big))
+				}
+
+				return vm.initInteger(^n.Value)
+			}
+		},
+		Name: "~",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				if b := receiver.(*IntegerObject).big; b != nil {
+					if b.Sign() < 0 {
+						b = new(big.Int).Not(b)
+					}
+
+					return vm.initInteger(b.BitLen())
+				}
+
+				n := receiver.(*IntegerObject).Value
+
+				// a negative number has as many bits as its complement, not counting the sign
+				if n < 0 {
+					n = ^n
+				}
+
+				length := 0
+
+				for ; n > 0; n >>= 1 {
+					length++
+				}
+
+				return vm.initInteger(length)
+			}
+		},
+		Name: "bit_length",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				i := vm.integerArgument(args[0])
+
+				if i < 0 {
+					return vm.initInteger(0)
+				}
+
+				if b := receiver.(*IntegerObject).big; b != nil {
+					return vm.initInteger(int(b.Bit(i)))
+				}
+
+				return vm.initInteger(shiftLeft(receiver.(*IntegerObject).Value, -i) & 1)
+			}
+		},
+		Name: "[]",
+	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				err := checkArgumentLen(args, IntegerClass, "+")
 
 				if err != nil {
 					return err
 				}
 
-				leftValue := receiver.(*IntegerObject).Value
-				right, ok := args[0].(*IntegerObject)
-
-				if !ok {
-					return wrongTypeError(IntegerClass)
+				left := receiver.(*IntegerObject)
+
+				switch right := args[0].(type) {
+				case *IntegerObject:
+					return vm.integerOperation(left, right, addInt, (*big.Int).Add)
+				case *FloatObject:
+					return InitializeFloat(left.float() + right.Value)
+				case *RationalObject:
+					return vm.initRational(new(big.Rat).Add(left.rat(), right.value))
+				case *BigDecimalObject:
+					d, _ := vm.toDecimal(left)
+					return addDecimal(vm, d, right)
 				}
 
-				rightValue := right.Value
-				return &IntegerObject{Value: leftValue + rightValue, Class: IntegerClass}
+				return wrongTypeError(IntegerClass)
 			}
 		},
 		Name: "+",
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				err := checkArgumentLen(args, IntegerClass, "-")
 
 				if err != nil {
 					return err
 				}
 
-				leftValue := receiver.(*IntegerObject).Value
-				right, ok := args[0].(*IntegerObject)
-
-				if !ok {
-					return wrongTypeError(IntegerClass)
+				left := receiver.(*IntegerObject)
+
+				switch right := args[0].(type) {
+				case *IntegerObject:
+					return vm.integerOperation(left, right, subInt, (*big.Int).Sub)
+				case *FloatObject:
+					return InitializeFloat(left.float() - right.Value)
+				case *RationalObject:
+					return vm.initRational(new(big.Rat).Sub(left.rat(), right.value))
+				case *BigDecimalObject:
+					d, _ := vm.toDecimal(left)
+					return subDecimal(vm, d, right)
 				}
 
-				rightValue := right.Value
-				return &IntegerObject{Value: leftValue - rightValue, Class: IntegerClass}
+				return wrongTypeError(IntegerClass)
 			}
 		},
 		Name: "-",
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				err := checkArgumentLen(args, IntegerClass, "+")
 
 				if err != nil {
 					return err
 				}
 
-				leftValue := receiver.(*IntegerObject).Value
-				right, ok := args[0].(*IntegerObject)
-
-				if !ok {
-					return wrongTypeError(IntegerClass)
+				left := receiver.(*IntegerObject)
+
+				switch right := args[0].(type) {
+				case *IntegerObject:
+					return vm.integerOperation(left, right, mulInt, (*big.Int).Mul)
+				case *FloatObject:
+					return InitializeFloat(left.float() * right.Value)
+				case *RationalObject:
+					return vm.initRational(new(big.Rat).Mul(left.rat(), right.value))
+				case *BigDecimalObject:
+					d, _ := vm.toDecimal(left)
+					return mulDecimal(vm, d, right)
 				}
 
-				rightValue := right.Value
-				return &IntegerObject{Value: leftValue * rightValue, Class: IntegerClass}
+				return wrongTypeError(IntegerClass)
 			}
 		},
 		Name: "*",
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				err := checkArgumentLen(args, IntegerClass, "+")
 
 				if err != nil {
 					return err
 				}
 
-				leftValue := receiver.(*IntegerObject).Value
-				right, ok := args[0].(*IntegerObject)
-
-				if !ok {
-					return wrongTypeError(IntegerClass)
+				left := receiver.(*IntegerObject)
+
+				switch right := args[0].(type) {
+				case *IntegerObject:
+					if right.isZero() {
+						vm.raise(ZeroDivisionErrorClass, "divided by 0")
+					}
+
+					return vm.integerOperation(left, right, quoInt, quoBig)
+				case *FloatObject:
+					return InitializeFloat(left.float() / right.Value)
+				case *RationalObject:
+					return vm.initRational(vm.quoRational(left.rat(), right.value))
+				case *BigDecimalObject:
+					d, _ := vm.toDecimal(left)
+					return quoDecimal(vm, d, right)
 				}
 
-				rightValue := right.Value
-				return &IntegerObject{Value: leftValue / rightValue, Class: IntegerClass}
+				return wrongTypeError(IntegerClass)
 			}
 		},
 		Name: "/",
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				err := checkArgumentLen(args, IntegerClass, ">")
 				if err != nil {
 					return err
 				}
 
-				leftValue := receiver.(*IntegerObject).Value
-				right, ok := args[0].(*IntegerObject)
-
-				if !ok {
-					return wrongTypeError(IntegerClass)
-				}
-
-				rightValue := right.Value
+				left := receiver.(*IntegerObject)
 
-				if leftValue > rightValue {
-					return TRUE
+				switch right := args[0].(type) {
+				case *IntegerObject:
+					return toBooleanObject(left.cmp(right) > 0)
+				case *FloatObject:
+					return toBooleanObject(left.float() > right.Value)
+				case *RationalObject, *BigDecimalObject:
+					r, _ := vm.toRational(right, false)
+					return toBooleanObject(left.rat().Cmp(r) > 0)
 				}
 
-				return FALSE
+				return wrongTypeError(IntegerClass)
 			}
 		},
 		Name: ">",
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				err := checkArgumentLen(args, IntegerClass, "<")
 				if err != nil {
 					return err
 				}
 
-				leftValue := receiver.(*IntegerObject).Value
-				right, ok := args[0].(*IntegerObject)
+				left := receiver.(*IntegerObject)
 
-				if !ok {
-					return wrongTypeError(IntegerClass)
+				switch right := args[0].(type) {
+				case *IntegerObject:
+					return toBooleanObject(left.cmp(right) < 0)
+				case *FloatObject:
+					return toBooleanObject(left.float() < right.Value)
+				case *RationalObject, *BigDecimalObject:
+					r, _ := vm.toRational(right, false)
+					return toBooleanObject(left.rat().Cmp(r) < 0)
+				}
+
+				return wrongTypeError(IntegerClass)
+			}
+		},
+		Name: "<",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, IntegerClass, "==")
+
+				if err != nil {
+					return err
 				}
 
-				rightValue := right.Value
+				left := receiver.(*IntegerObject)
 
-				if leftValue < rightValue {
-					return TRUE
+				switch right := args[0].(type) {
+				case *IntegerObject:
+					return toBooleanObject(left.cmp(right) == 0)
+				case *FloatObject:
+					return toBooleanObject(left.float() == right.Value)
+				case *RationalObject, *BigDecimalObject:
+					r, _ := vm.toRational(right, false)
+					return toBooleanObject(left.rat().Cmp(r) == 0)
 				}
 
+				// integers are never equal to objects that aren't numbers
 				return FALSE
 			}
 		},
-		Name: "<",
+		Name: "==",
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
-				err := checkArgumentLen(args, IntegerClass, "==")
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, IntegerClass, "!=")
 
 				if err != nil {
 					return err
 				}
 
-				leftValue := receiver.(*IntegerObject).Value
-				right, ok := args[0].(*IntegerObject)
+				left := receiver.(*IntegerObject)
 
-				if !ok {
-					return wrongTypeError(IntegerClass)
+				switch right := args[0].(type) {
+				case *IntegerObject:
+					return toBooleanObject(left.cmp(right) != 0)
+				case *FloatObject:
+					return toBooleanObject(left.float() != right.Value)
+				case *RationalObject, *BigDecimalObject:
+					r, _ := vm.toRational(right, false)
+					return toBooleanObject(left.rat().Cmp(r) != 0)
+				}
+
+				// integers are never equal to objects that aren't numbers
+				return TRUE
+			}
+		},
+		Name: "!=",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, IntegerClass, ">=")
+
+				if err != nil {
+					return err
 				}
 
-				rightValue := right.Value
+				left := receiver.(*IntegerObject)
 
-				if leftValue == rightValue {
-					return TRUE
+				switch right := args[0].(type) {
+				case *IntegerObject:
+					return toBooleanObject(left.cmp(right) >= 0)
+				case *FloatObject:
+					return toBooleanObject(left.float() >= right.Value)
+				case *RationalObject, *BigDecimalObject:
+					r, _ := vm.toRational(right, false)
+					return toBooleanObject(left.rat().Cmp(r) >= 0)
 				}
 
-				return FALSE
+				return wrongTypeError(IntegerClass)
 			}
 		},
-		Name: "==",
+		Name: ">=",
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
-				err := checkArgumentLen(args, IntegerClass, "!=")
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, IntegerClass, "<=")
 
 				if err != nil {
 					return err
 				}
 
-				leftValue := receiver.(*IntegerObject).Value
-				right, ok := args[0].(*IntegerObject)
+				left := receiver.(*IntegerObject)
 
-				if !ok {
-					return wrongTypeError(IntegerClass)
+				switch right := args[0].(type) {
+				case *IntegerObject:
+					return toBooleanObject(left.cmp(right) <= 0)
+				case *FloatObject:
+					return toBooleanObject(left.float() <= right.Value)
+				case *RationalObject, *BigDecimalObject:
+					r, _ := vm.toRational(right, false)
+					return toBooleanObject(left.rat().Cmp(r) <= 0)
 				}
 
-				rightValue := right.Value
+				return wrongTypeError(IntegerClass)
+			}
+		},
+		Name: "<=",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, IntegerClass, "%")
 
-				if leftValue != rightValue {
-					return TRUE
+				if err != nil {
+					return err
 				}
 
-				return FALSE
+				left := receiver.(*IntegerObject)
+
+				switch right := args[0].(type) {
+				case *IntegerObject:
+					if right.isZero() {
+						vm.raise(ZeroDivisionErrorClass, "divided by 0")
+					}
+
+					return vm.integerOperation(left, right, modInt, modBig)
+				case *FloatObject:
+					return InitializeFloat(floatModulo(left.float(), right.Value))
+				}
+
+				return wrongTypeError(IntegerClass)
 			}
 		},
-		Name: "!=",
+		Name: "%",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, IntegerClass, "**")
+
+				if err != nil {
+					return err
+				}
+
+				left := receiver.(*IntegerObject)
+
+				switch right := args[0].(type) {
+				case *IntegerObject:
+					// negative exponents make fractions, which only floats can represent
+					if right.cmp(vm.initInteger(0)) < 0 {
+						return InitializeFloat(math.Pow(left.float(), right.float()))
+					}
+
+					if right.big != nil {
+						vm.raise(ArgumentErrorClass, "exponent is too large")
+					}
+
+					return vm.integerOperation(left, right, powInt, powBig)
+				case *FloatObject:
+					return InitializeFloat(math.Pow(left.float(), right.Value))
+				}
+
+				return wrongTypeError(IntegerClass)
+			}
+		},
+		Name: "**",
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				if len(args) > 0 {
 					return &Error{Message: "Too many arguments for Integer#++"}
 				}
 
-				int := receiver.(*IntegerObject)
-				int.Value += 1
-				return int
+				return vm.integerOperation(receiver.(*IntegerObject), vm.initInteger(1), addInt, (*big.Int).Add)
 			}
 		},
 		Name: "++",
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				if len(args) > 0 {
 					return &Error{Message: "Too many arguments for Integer#--"}
 				}
 
-				int := receiver.(*IntegerObject)
-				int.Value -= 1
-				return int
+				return vm.integerOperation(receiver.(*IntegerObject), vm.initInteger(1), subInt, (*big.Int).Sub)
 			}
 		},
 		Name: "--",
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
-				if len(args) > 0 {
-					return &Error{Message: "Too many arguments for Integer#--"}
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
 				}
 
 				int := receiver.(*IntegerObject)
-				return InitializeString(fmt.Sprint(int.Value))
+				base := vm.radixArgument(args)
+
+				if int.big != nil {
+					return InitializeString(int.big.Text(base))
+				}
+
+				return InitializeString(strconv.FormatInt(int64(int.Value), base))
 			}
 		},
 		Name: "to_s",
 	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return receiver
+			}
+		},
+		Name: "to_i",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return InitializeFloat(receiver.(*IntegerObject).float())
+			}
+		},
+		Name: "to_f",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initRational(receiver.(*IntegerObject).rat())
+			}
+		},
+		Name: "to_r",
+	},
 }
 
 func initInteger() {
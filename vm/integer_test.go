@@ -0,0 +1,235 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestSmallIntegersAreShared(t *testing.T) {
+	v := New()
+	evaluated := testEvalWithVM(t, v, `[1 + 2, 3, 2000 + 1, 2001]`)
+	elems := evaluated.(*ArrayObject).Elements
+
+	if elems[0] != elems[1] || elems[0] != v.initInteger(3) {
+		t.Fatalf("Expect small integers to be the same object")
+	}
+
+	if elems[2] == elems[3] {
+		t.Fatalf("Expect integers out of the preallocated range to be different objects")
+	}
+
+	testIntegerObject(t, elems[2], 2001)
+	testIntegerObject(t, elems[3], 2001)
+}
+
+func TestSetSmallIntegerRange(t *testing.T) {
+	v := New()
+	v.SetSmallIntegerRange(-5, 5)
+
+	if v.initInteger(-5) != v.initInteger(-5) || v.initInteger(5) != v.initInteger(5) {
+		t.Fatalf("Expect integers in range to be preallocated")
+	}
+
+	if v.initInteger(6) == v.initInteger(6) || v.initInteger(-6) == v.initInteger(-6) {
+		t.Fatalf("Expect integers out of range not to be preallocated")
+	}
+
+	v.SetSmallIntegerRange(1, 0)
+
+	if v.initInteger(0) == v.initInteger(0) {
+		t.Fatalf("Expect preallocation to be disabled")
+	}
+
+	testIntegerObject(t, v.initInteger(0), 0)
+}
+
+func TestIntegerEqualityWithOtherTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`1 == "1"`, false},
+		{`1 != "1"`, true},
+		{`1.5 == "1.5"`, false},
+		{`1.5 != true`, true},
+		{`true == 1`, false},
+		{`false != 1`, true},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testBooleanObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestIntegerBitwiseOperations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`6 & 3`, 2},
+		{`6 | 3`, 7},
+		{`6 ^ 3`, 5},
+		{`~5`, -6},
+		{`~(0 - 1)`, 0},
+		{`1 << 4`, 16},
+		{`1 << 2 + 1`, 8},
+		{`16 >> 2`, 4},
+		{`(0 - 16) >> 2`, -4},
+		{`(0 - 1) >> 100`, -1},
+		{`(1 << 64) >> 60`, 16},
+		{`8 << (0 - 2)`, 2},
+		{`8 >> (0 - 2)`, 32},
+		{`1 | 2 & 3`, 3},
+		{`flags = 0
+		flags = flags | 4
+		flags = flags | 1
+		flags & 4`, 4},
+		{`0.bit_length`, 0},
+		{`255.bit_length`, 8},
+		{`256.bit_length`, 9},
+		{`(0 - 1).bit_length`, 0},
+		{`(0 - 256).bit_length`, 8},
+		{`5[0]`, 1},
+		{`5[1]`, 0},
+		{`5[2]`, 1},
+		{`5[100]`, 0},
+		{`5[0 - 1]`, 0},
+		{`(0 - 1)[100]`, 1},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestIntegerBitwiseOperationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`1 & 1.5`, "TypeError: wrong argument type 1.5 (expected Integer)"},
+		{`1 << "a"`, "TypeError: wrong argument type a (expected Integer)"},
+		{`1[1.0]`, "TypeError: wrong argument type 1.0 (expected Integer)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestBigIntegers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`9223372036854775807 + 1`, "9223372036854775808"},
+		{`(0 - 9223372036854775807) - 2`, "-9223372036854775809"},
+		{`9223372036854775807 * 9223372036854775807`, "85070591730234615847396907784232501249"},
+		{`2 ** 100`, "1267650600228229401496703205376"},
+		{`123456789012345678901234567890`, "123456789012345678901234567890"},
+		{`123456789012345678901234567890 / 10`, "12345678901234567890123456789"},
+		{`(0 - 123456789012345678901234567891) / 10`, "-12345678901234567890123456790"},
+		{`123456789012345678901234567891 / (0 - 10)`, "-12345678901234567890123456790"},
+		{`(0 - 123456789012345678901234567891) / (0 - 10)`, "12345678901234567890123456789"},
+		{`(0 - 2 ** 64) / 3`, "-6148914691236517206"},
+		{`(0 - 2 ** 64) / (2 ** 64 + 1)`, "-1"},
+		{`(0 - 123456789012345678901234567890) % 7`, "0"},
+		{`(0 - 123456789012345678901234567891) % 7`, "6"},
+		{`(2 ** 64) % (0 - 3)`, "-2"},
+		{`a = 9223372036854775807
+		a++
+		a`, "9223372036854775808"},
+		{`1 << 70`, "1180591620717411303424"},
+		{`(2 ** 64) | 1`, "18446744073709551617"},
+		{`~(2 ** 64)`, "-18446744073709551617"},
+		{`(2 ** 64).to_s(16)`, "10000000000000000"},
+		{`(2 ** 64).bit_length`, "65"},
+		{`(2 ** 64)[64]`, "1"},
+		{`(2 ** 64).to_f`, "1.8446744073709552e+19"},
+		{`"99999999999999999999".to_i`, "99999999999999999999"},
+		{`Integer("0x10000000000000000")`, "18446744073709551616"},
+		{`100000000000000000000.0.to_i`, "100000000000000000000"},
+		{`format("%d %x", 2 ** 64, 2 ** 64)`, "18446744073709551616 10000000000000000"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Fatalf("at test case %d: expect %s. got=%s", i, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestBigIntegersBecomeSmallAgain(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`9223372036854775807 + 1 - 1`, 9223372036854775807},
+		{`(2 ** 100) / (2 ** 98)`, 4},
+		{`(1 << 70) >> 69`, 2},
+		{`(2 ** 64) & 255`, 0},
+		{`"0x100".to_i(16)`, 256},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestBigIntegerComparisons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`9223372036854775808 == 2 ** 63`, true},
+		{`9223372036854775808 != 2 ** 63`, false},
+		{`2 ** 64 > 9223372036854775807`, true},
+		{`0 - 2 ** 64 < 1`, true},
+		{`2 ** 64 >= 2 ** 64`, true},
+		{`2 ** 64 == 18446744073709551616.0`, true},
+		{`h = { a: 1 }
+		h[2 ** 64] = 2
+		h[18446744073709551616] == 2`, true},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if !testBooleanObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestBigIntegerErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1, 2][2 ** 64]`, "RangeError: bignum too big to convert into 'long'"},
+		{`2 ** (2 ** 64)`, "ArgumentError: exponent is too large"},
+		{`(2 ** 64) / 0`, "ZeroDivisionError: divided by 0"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
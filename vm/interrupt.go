@@ -0,0 +1,55 @@
+package vm
+
+import "errors"
+
+// interruptCheckInterval is how many instructions are executed between checks for cancellation.
+const interruptCheckInterval = 1024
+
+// interrupter stops the VM once done is closed. Checking a channel on every instruction is too slow,
+// so it's only checked every interruptCheckInterval instructions.
+type interrupter struct {
+	done      <-chan struct{}
+	err       func() error
+	countdown int
+}
+
+// interruption is panicked with to stop the VM. Unlike raised exceptions it can't be rescued by the program,
+// Exec returns its err as is.
+type interruption struct {
+	err error
+}
+
+func (i *interrupter) tick() {
+	i.countdown--
+
+	if i.countdown > 0 {
+		return
+	}
+
+	i.countdown = interruptCheckInterval
+
+	select {
+	case <-i.done:
+		panic(&interruption{err: i.err()})
+	default:
+	}
+}
+
+// ErrStopped is returned by ExecUntil when the program is stopped.
+var ErrStopped = errors.New("program stopped")
+
+// ExecUntil is like Exec, but stops the program once stop is closed and returns ErrStopped.
+// It's ExecContext for hosts that can't use the context package, and stops programs the same way.
+func (vm *VM) ExecUntil(stop <-chan struct{}) error {
+	select {
+	case <-stop:
+		return ErrStopped
+	default:
+	}
+
+	outer := vm.interrupter
+	vm.interrupter = &interrupter{done: stop, err: func() error { return ErrStopped }, countdown: interruptCheckInterval}
+	defer func() { vm.interrupter = outer }()
+
+	return vm.Exec()
+}
@@ -0,0 +1,56 @@
+package vm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExecUntil(t *testing.T) {
+	input := `
+	a = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]
+
+	begin
+	  a.each do |x1|
+	    a.each do |x2|
+	      a.each do |x3|
+	        a.each do |x4|
+	          a.each do |x5|
+	            a.each do |x6|
+	            end
+	          end
+	        end
+	      end
+	    end
+	  end
+	rescue Exception
+	  -1
+	end
+	`
+
+	v := New()
+	v.Stats = NewStats()
+	testLoadBytecodes(v, testCompile(t, "", input))
+	stop := make(chan struct{})
+	done := make(chan error)
+
+	go func() { done <- v.ExecUntil(stop) }()
+
+	// instruction counts can be read while the program is running
+	for len(v.Stats.OpcodeCounts()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	close(stop)
+
+	if err := <-done; err != ErrStopped {
+		t.Fatalf("Expect ExecUntil to return %v. got=%v", ErrStopped, err)
+	}
+
+	if v.CFP != 0 {
+		t.Fatalf("Expect call frames to be unwound. got CFP=%d", v.CFP)
+	}
+
+	if err := v.ExecUntil(stop); err != ErrStopped {
+		t.Fatalf("Expect ExecUntil to return %v when it's already stopped. got=%v", ErrStopped, err)
+	}
+}
@@ -0,0 +1,217 @@
+package vm
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+var (
+	IOClass *RIO
+)
+
+type RIO struct {
+	*BaseClass
+}
+
+// IOObject is one of the standard streams, STDIN, STDOUT or STDERR. Hosts can redirect them with SetStdin, SetStdout and SetStderr.
+type IOObject struct {
+	Class  *RIO
+	name   string
+	reader *bufio.Reader
+	writer io.Writer
+}
+
+func (o *IOObject) Type() ObjectType {
+	return IO_OBJ
+}
+
+func (o *IOObject) Inspect() string {
+	return "#<IO:" + o.name + ">"
+}
+
+func (o *IOObject) ReturnClass() Class {
+	return o.Class
+}
+
+// stdFile reads from and writes to the file it returns. The file is looked up on every call,
+// so replacing os.Stdout after the VM is created still redirects the program's output.
+type stdFile func() *os.File
+
+func (f stdFile) Read(p []byte) (int, error) {
+	return f().Read(p)
+}
+
+func (f stdFile) Write(p []byte) (int, error) {
+	return f().Write(p)
+}
+
+func (vm *VM) initStandardStreams() {
+	vm.stdin = &IOObject{Class: IOClass, name: "<STDIN>", reader: bufio.NewReader(stdFile(func() *os.File { return os.Stdin }))}
+	vm.stdout = &IOObject{Class: IOClass, name: "<STDOUT>", writer: stdFile(func() *os.File { return os.Stdout })}
+	vm.stderr = &IOObject{Class: IOClass, name: "<STDERR>", writer: stdFile(func() *os.File { return os.Stderr })}
+}
+
+// standardStreams returns STDIN, STDOUT and STDERR by the names of their constants.
+func (vm *VM) standardStreams() map[string]*IOObject {
+	return map[string]*IOObject{"STDIN": vm.stdin, "STDOUT": vm.stdout, "STDERR": vm.stderr}
+}
+
+// SetStdin makes STDIN read from r.
+func (vm *VM) SetStdin(r io.Reader) {
+	vm.stdin.reader = bufio.NewReader(r)
+}
+
+// SetStdout makes STDOUT, and so `puts`, write to w.
+func (vm *VM) SetStdout(w io.Writer) {
+	vm.stdout.writer = w
+}
+
+// SetStderr makes STDERR write to w.
+func (vm *VM) SetStderr(w io.Writer) {
+	vm.stderr.writer = w
+}
+
+// writeString writes s to o and returns how many bytes are written, it raises IOError if o isn't opened for writing.
+func (vm *VM) writeString(o *IOObject, s string) int {
+	if o.writer == nil {
+		vm.raise(IOErrorClass, "not opened for writing")
+	}
+
+	n, err := io.WriteString(o.writer, s)
+
+	if err != nil {
+		vm.raise(IOErrorClass, "%s", err.Error())
+	}
+
+	return n
+}
+
+// puts writes each object's to_s on its own line, like the global `puts`.
+func (vm *VM) puts(o *IOObject, args []Object) {
+	for _, arg := range args {
+		vm.writeString(o, vm.toS(arg)+"\n")
+	}
+}
+
+// print writes each object's to_s without newlines, like the global `print`.
+func (vm *VM) print(o *IOObject, args []Object) {
+	for _, arg := range args {
+		vm.writeString(o, vm.toS(arg))
+	}
+}
+
+// printf writes args formatted by the format string that's their first element, it writes nothing if there are no args.
+func (vm *VM) printf(o *IOObject, args []Object) {
+	if len(args) > 0 {
+		vm.writeString(o, vm.format(vm.stringArgument(args[0]), args[1:]))
+	}
+}
+
+// checkReadable raises IOError if o isn't opened for reading.
+func (vm *VM) checkReadable(o *IOObject) {
+	if o.reader == nil {
+		vm.raise(IOErrorClass, "not opened for reading")
+	}
+}
+
+var builtinIOMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				vm.puts(receiver.(*IOObject), args)
+				return NULL
+			}
+		},
+		Name: "puts",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				vm.print(receiver.(*IOObject), args)
+				return NULL
+			}
+		},
+		Name: "print",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				vm.printf(receiver.(*IOObject), args)
+				return NULL
+			}
+		},
+		Name: "printf",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return vm.initInteger(vm.writeString(receiver.(*IOObject), vm.stringArgument(args[0])))
+			}
+		},
+		Name: "write",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				o := receiver.(*IOObject)
+				vm.checkReadable(o)
+
+				line, err := o.reader.ReadString('\n')
+
+				if err != nil && err != io.EOF {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				// gets returns nil at the end of the stream
+				if line == "" {
+					return NULL
+				}
+
+				return InitializeString(line)
+			}
+		},
+		Name: "gets",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				o := receiver.(*IOObject)
+				vm.checkReadable(o)
+
+				content, err := ioutil.ReadAll(o.reader)
+
+				if err != nil {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				return InitializeString(string(content))
+			}
+		},
+		Name: "read",
+	},
+}
+
+func initIO() {
+	methods := NewEnvironment()
+
+	for _, m := range builtinIOMethods {
+		methods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "IO", Methods: methods, ClassMethods: NewEnvironment(), Class: ClassClass, SuperClass: ObjectClass}
+	IOClass = &RIO{BaseClass: bc}
+}
@@ -0,0 +1,119 @@
+package vm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestIOWrite(t *testing.T) {
+	tests := []struct {
+		input  string
+		stdout string
+		stderr string
+	}{
+		{`puts("a", 1)`, "a\n1\n", ""},
+		{`STDOUT.puts("a", 1)`, "a\n1\n", ""},
+		{`STDOUT.print("a", 1)`, "a1", ""},
+		{`print("a", 1, [2])`, "a1Array:[2]", ""},
+		{`print()`, "", ""},
+		{`printf("%-5s|%3d|", "ab", 7)`, "ab   |  7|", ""},
+		{`printf()`, "", ""},
+		{`STDERR.printf("%05.1f", 3.14159)`, "", "003.1"},
+		{`STDOUT.write("a")`, "a", ""},
+		{`STDERR.puts("oops")`, "", "oops\n"},
+		{`
+		STDOUT.print("out")
+		STDERR.print("err")
+		`, "out", "err"},
+	}
+
+	for i, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		v := New()
+		v.SetStdout(&stdout)
+		v.SetStderr(&stderr)
+
+		testEvalWithVM(t, v, tt.input)
+
+		if stdout.String() != tt.stdout {
+			t.Fatalf("at test case %d: expect stdout %q. got=%q", i, tt.stdout, stdout.String())
+		}
+
+		if stderr.String() != tt.stderr {
+			t.Fatalf("at test case %d: expect stderr %q. got=%q", i, tt.stderr, stderr.String())
+		}
+	}
+}
+
+func TestIOWriteReturnsLength(t *testing.T) {
+	v := New()
+	v.SetStdout(&bytes.Buffer{})
+
+	evaluated := testEvalWithVM(t, v, `STDOUT.write("hello")`)
+	testIntegerObject(t, evaluated, 5)
+}
+
+func TestIORead(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`STDIN.gets`, "one\n"},
+		{`STDIN.gets; STDIN.gets`, "two"},
+		{`STDIN.gets; STDIN.gets; STDIN.gets`, nil},
+		{`STDIN.read`, "one\ntwo"},
+		{`STDIN.gets; STDIN.read`, "two"},
+	}
+
+	for i, tt := range tests {
+		v := New()
+		v.SetStdin(strings.NewReader("one\ntwo"))
+
+		evaluated := testEvalWithVM(t, v, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case string:
+			if !testStringObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		default:
+			if !testNullObject(t, evaluated) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestIOErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`STDIN.puts("a")`, "IOError: not opened for writing"},
+		{`STDOUT.gets`, "IOError: not opened for reading"},
+		{`STDERR.read`, "IOError: not opened for reading"},
+		{`STDOUT.write(1)`, "TypeError: wrong argument type 1 (expected String)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestIOStreamsAreKeptAfterReset(t *testing.T) {
+	var stdout bytes.Buffer
+	v := New()
+	v.SetStdout(&stdout)
+	v.Reset()
+
+	testEvalWithVM(t, v, `STDOUT.print(STDOUT.to_s)`)
+
+	if stdout.String() != "#<IO:<STDOUT>>" {
+		t.Fatalf("expect output %q. got=%q", "#<IO:<STDOUT>>", stdout.String())
+	}
+}
@@ -0,0 +1,366 @@
+package vm
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestVMsDontShareTopLevelDefinitions(t *testing.T) {
+	first := New()
+	second := New()
+
+	testIntegerObject(t, testEvalWithVM(t, first, `
+	def foo
+	  1
+	end
+
+	@bar = 10
+	foo + @bar
+	`), 11)
+
+	testIntegerObject(t, testEvalWithVM(t, second, `
+	def foo
+	  2
+	end
+
+	if @bar
+	  0
+	else
+	  foo
+	end
+	`), 2)
+
+	if first.MainObj.Class.LookupInstanceMethod("foo") == second.MainObj.Class.LookupInstanceMethod("foo") {
+		t.Fatalf("Expect every VM to have its own top level method foo")
+	}
+}
+
+func TestVMsDontShareClasses(t *testing.T) {
+	first := New()
+	second := New()
+
+	testEvalWithVM(t, first, `
+	class Foo
+	  def bar
+	    1
+	  end
+	end
+
+	Foo
+	`)
+
+	err := testEvalError(t, second, "", `
+	Foo.new
+	`)
+
+	if err == nil || !strings.Contains(err.Error(), "uninitialized constant Foo") {
+		t.Fatalf("Expect Foo to be undefined in another VM. got=%v", err)
+	}
+
+	if first.Constants["Object"].Target == second.Constants["Object"].Target {
+		t.Fatalf("Expect every VM to have its own Object class")
+	}
+
+	if first.MainObj == second.MainObj {
+		t.Fatalf("Expect every VM to have its own main object")
+	}
+}
+
+func TestTopLevelMethodsAreVisibleToBuiltInClasses(t *testing.T) {
+	input := `
+	def double(n)
+	  n * 2
+	end
+
+	class MyError < StandardError
+	  def code
+	    double(21)
+	  end
+	end
+
+	MyError.new("boom").code
+	`
+
+	testIntegerObject(t, testEval(t, input), 42)
+}
+
+func TestDefineMethodOnBuiltInClass(t *testing.T) {
+	input := `
+<Def:foo>
+0 putobject 1
+1 leave
+<ProgramStart>
+0 getconstant StandardError
+1 putstring "foo"
+2 def_method 0
+3 getconstant StandardError
+4 putstring "boom"
+5 send new 1
+6 send foo 0
+7 leave
+`
+	v := New()
+	testLoadBytecodes(v, input)
+	v.Exec()
+	testIntegerObject(t, v.Stack.Top().Target, 1)
+
+	if StandardErrorClass.LookupInstanceMethod("foo") != nil {
+		t.Fatalf("Expect methods defined on built in classes to be kept by the VM")
+	}
+}
+
+func TestVMsDontShareMethodsOfBuiltInClasses(t *testing.T) {
+	testStringObject(t, testEvalWithVM(t, New(), `
+	class String
+	  def shout
+	    upcase + "!"
+	  end
+	end
+
+	class Integer
+	  def self.answer
+	    42
+	  end
+	end
+
+	StandardError.define_method("code") do
+	  500
+	end
+
+	class MyError < StandardError
+	end
+
+	"hi".shout + Integer.answer.to_s + MyError.new("boom").code.to_s
+	`), "HI!42500")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"hi".shout`, "NoMethodError: undefined method `shout' for hi"},
+		{`Integer.answer`, "NoMethodError: undefined method `answer' for <Class:Integer>"},
+		{`StandardError.new("boom").code`, "NoMethodError: undefined method `code' for <Instance of: StandardError>"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestReopenClasses(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		class Foo
+		  def a
+		    1
+		  end
+		end
+
+		class Foo
+		  def b
+		    2
+		  end
+		end
+
+		Foo.new.a + Foo.new.b
+		`, 3},
+		{`
+		class Foo
+		end
+
+		class Bar < Foo
+		  def a
+		    1
+		  end
+		end
+
+		class Bar < Foo
+		  def b
+		    2
+		  end
+		end
+
+		Bar.new.a + Bar.new.b
+		`, 3},
+		{`
+		class Integer
+		  def double
+		    self * 2
+		  end
+
+		  def to_s
+		    "overridden"
+		  end
+		end
+
+		21.double.to_s
+		`, "overridden"},
+		{`
+		class Foo
+		  def to_s
+		    "foo"
+		  end
+		end
+
+		class Object
+		  def to_s
+		    "object"
+		  end
+		end
+
+		Foo.new.to_s
+		`, "foo"},
+		{`
+		class StandardError
+		  def message
+		    "reopened " + super
+		  end
+		end
+
+		class MyError < StandardError
+		end
+
+		MyError.new("boom").message
+		`, "reopened boom"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			if !testIntegerObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		case string:
+			if !testStringObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestReopenClassErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		class Foo
+		end
+
+		class Bar
+		end
+
+		class Foo < Bar
+		end
+		`, "TypeError: superclass mismatch for class Foo"},
+		{`
+		module Foo
+		end
+
+		class Foo
+		end
+		`, "TypeError: Foo is not a class"},
+		{`
+		class Foo
+		end
+
+		module Foo
+		end
+		`, "TypeError: Foo is not a module"},
+		{`
+		Foo = 1
+
+		class Foo
+		end
+		`, "TypeError: Foo is not a class"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestVMsDontShareStrings(t *testing.T) {
+	v := New()
+	testEvalWithVM(t, v, `
+	a = "settings"
+	a << "!"
+	a.force_encoding("ASCII-8BIT")
+	a.freeze
+	`)
+
+	other := New()
+	evaluated := testEvalWithVM(t, other, `
+	b = "settings"
+	b << "?"
+	b.encoding.name + " " + b
+	`)
+
+	testStringObject(t, evaluated, "UTF-8 settings?")
+}
+
+func TestConcurrentVMs(t *testing.T) {
+	var wg sync.WaitGroup
+	results := make([]Object, 8)
+
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			v := New()
+			v.SetGlobal("$n", v.initInteger(i))
+			results[i] = testEvalWithVM(t, v, `
+			def id
+			  $n
+			end
+
+			class Box
+			  def initialize(v)
+			    @v = v
+			  end
+
+			  def value
+			    @v
+			  end
+			end
+
+			class String
+			  def twice
+			    self + self
+			  end
+			end
+
+			s = "shared"
+			s << "!"
+			s.freeze
+			t = "shared"
+			t[0] = "S"
+			t.force_encoding("ASCII-8BIT")
+
+			a = ["x", "y"]
+			Box.new(id).value + a[0].twice.length + t.length - 6
+			`)
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i, result := range results {
+		testIntegerObject(t, result, i+2)
+	}
+}
@@ -0,0 +1,145 @@
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+var (
+	// MarshalModule dumps objects to strings and loads them back, see marshalDump.
+	MarshalModule *RClass
+)
+
+// marshalData is what Marshal.dump returns, encoded in JSON. Objects are encoded like they are in snapshots,
+// except classes are only referred to by name, so the data can be loaded by later runs of the program.
+type marshalData struct {
+	Version int               `json:"version"`
+	Objects []*snapshotObject `json:"objects"`
+	Root    int               `json:"root"`
+}
+
+// marshalOnlyKinds are the kinds of snapshot objects that can't be in data Marshal.dump returns.
+var marshalOnlyKinds = map[string]bool{
+	snapshotMain:    true,
+	snapshotClass:   true,
+	snapshotBuiltIn: true,
+	snapshotIO:      true,
+	snapshotEnv:     true,
+}
+
+// checkMarshalable returns an error if o can't be dumped by Marshal.dump. Instances can be dumped as long as their
+// class can be found by its name when they're loaded.
+func (vm *VM) checkMarshalable(o Object) error {
+	switch o := o.(type) {
+	case *IntegerObject, *FloatObject, *RationalObject, *BigDecimalObject, *DateObject, *StringObject, *SymbolObject, *BooleanObject, *Null, *ArrayObject, *HashObject:
+		return nil
+	case *RObject:
+		if o == vm.MainObj {
+			return fmt.Errorf("can't dump main")
+		}
+
+		if o.singletonClass != nil {
+			return fmt.Errorf("singleton can't be dumped")
+		}
+
+		return nil
+	case Class:
+		if o.ReturnName() == "" {
+			return fmt.Errorf("can't dump anonymous class")
+		}
+
+		if p, ok := vm.Constants[o.ReturnName()]; !ok || p.Target != o {
+			return fmt.Errorf("can't dump anonymous class %s", o.ReturnName())
+		}
+
+		return nil
+	}
+
+	name := o.Inspect()
+
+	if b, ok := o.(BaseObject); ok {
+		name = b.ReturnClass().ReturnName()
+	}
+
+	return fmt.Errorf("no _dump_data is defined for class %s", name)
+}
+
+// marshalDump encodes o and everything it refers to, and raises TypeError if any of them can't be dumped.
+func (vm *VM) marshalDump(o Object) string {
+	w := &snapshotWriter{vm: vm, snapshot: &snapshot{}, ids: make(map[Object]int), marshal: true}
+	root, err := w.writeObject(o)
+
+	if err != nil {
+		vm.raise(TypeErrorClass, "%s", err.Error())
+	}
+
+	data, err := json.Marshal(&marshalData{Version: snapshotVersion, Objects: w.snapshot.Objects, Root: root})
+
+	if err != nil {
+		vm.raise(TypeErrorClass, "%s", err.Error())
+	}
+
+	return string(data)
+}
+
+// marshalLoad decodes objects dumped by marshalDump.
+func (vm *VM) marshalLoad(s string) Object {
+	data := &marshalData{}
+
+	if err := json.Unmarshal([]byte(s), data); err != nil {
+		vm.raise(ArgumentErrorClass, "marshal data is invalid")
+	}
+
+	if data.Version != snapshotVersion {
+		vm.raise(TypeErrorClass, "incompatible marshal data version %d, expect version %d", data.Version, snapshotVersion)
+	}
+
+	if data.Root < 1 || data.Root > len(data.Objects) {
+		vm.raise(ArgumentErrorClass, "marshal data is invalid")
+	}
+
+	for _, so := range data.Objects {
+		if so == nil || marshalOnlyKinds[so.Kind] {
+			vm.raise(ArgumentErrorClass, "marshal data is invalid")
+		}
+	}
+
+	r := &snapshotReader{vm: vm, snapshot: &snapshot{Objects: data.Objects}, marshal: true}
+
+	if err := r.readObjects(); err != nil {
+		vm.raise(ArgumentErrorClass, "%s", err.Error())
+	}
+
+	return r.object(data.Root)
+}
+
+var builtinMarshalModuleMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return InitializeString(vm.marshalDump(args[0]))
+			}
+		},
+		Name: "dump",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return vm.marshalLoad(vm.stringArgument(args[0]))
+			}
+		},
+		Name: "load",
+	},
+}
+
+func initMarshal() {
+	MarshalModule = initializeBuiltinModule("Marshal", builtinMarshalModuleMethods)
+}
@@ -0,0 +1,161 @@
+package vm
+
+import "testing"
+
+const marshalPointClass = `
+class Point
+  def initialize(x, y)
+    @x = x
+    @y = y
+  end
+
+  def x
+    @x
+  end
+
+  def y
+    @y
+  end
+end
+`
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Marshal.load(Marshal.dump(10))`, 10},
+		{`Marshal.load(Marshal.dump(1.5)) == 1.5`, true},
+		{`Marshal.load(Marshal.dump(2 ** 100)) == 2 ** 100`, true},
+		{`Marshal.load(Marshal.dump("Rooby"))`, "Rooby"},
+		{`Marshal.load(Marshal.dump("a".to_sym)) == "a".to_sym`, true},
+		{`Marshal.load(Marshal.dump(true))`, true},
+		{`Marshal.load(Marshal.dump(ENV["NOPE"]))`, nil},
+		{`Marshal.load(Marshal.dump([1, "a", [2, 3]]))[2][1]`, 3},
+		{`Marshal.load(Marshal.dump({ a: 1, b: [2] }))["b"][0]`, 2},
+		{`Marshal.load(Marshal.dump(Hash.new(5)))["missing"]`, 5},
+		{`Marshal.load(Marshal.dump(Integer)) == Integer`, true},
+		{marshalPointClass + `
+		p = Marshal.load(Marshal.dump(Point.new(1, [2])))
+		p.x + p.y[0]
+		`, 3},
+		{marshalPointClass + `
+		Marshal.load(Marshal.dump(Point.new(1, 2))).class == Point
+		`, true},
+		{`
+		Pair = Struct.new("left", "right")
+		Marshal.load(Marshal.dump(Pair.new(1, 2))) == Pair.new(1, 2)
+		`, true},
+		{`
+		a = [1]
+		copied = Marshal.load(Marshal.dump([a, a]))
+		copied[0].push(2)
+		copied[1].length
+		`, 2},
+		{`
+		a = [1]
+		a.push(a)
+		copied = Marshal.load(Marshal.dump(a))
+		copied[1][1][0]
+		`, 1},
+		{`
+		original = { list: [1] }
+		copied = Marshal.load(Marshal.dump(original))
+		copied["list"].push(2)
+		original["list"].length
+		`, 1},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		default:
+			t.Fatalf("at test case %d: unexpected expectation %v", i, expected)
+		}
+	}
+}
+
+func TestMarshalAcrossVMs(t *testing.T) {
+	dumped := testEval(t, marshalPointClass+`Marshal.dump([Point.new(3, 4), "s"])`)
+
+	v := New()
+	v.SetConstant("DUMPED", dumped)
+	evaluated := testEvalWithVM(t, v, marshalPointClass+`
+	p = Marshal.load(DUMPED)[0]
+	p.x * p.y
+	`)
+	testIntegerObject(t, evaluated, 12)
+
+	v = New()
+	v.SetConstant("DUMPED", dumped)
+	err := testEvalError(t, v, "", `Marshal.load(DUMPED)`)
+
+	if err == nil || err.Error() != "ArgumentError: undefined class/module Point" {
+		t.Fatalf("Expect loading an undefined class to fail. got=%v", err)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		p = Proc.new do
+		  1
+		end
+		Marshal.dump(p)
+		`, "TypeError: no _dump_data is defined for class Proc"},
+		{`Marshal.dump([STDOUT])`, "TypeError: no _dump_data is defined for class IO"},
+		{`Marshal.dump(self)`, "TypeError: can't dump main"},
+		{`
+		o = Object.new
+		o.define_singleton_method("a") do
+		  1
+		end
+		Marshal.dump(o)
+		`, "TypeError: singleton can't be dumped"},
+		{`Marshal.dump(Struct.new("a"))`, "TypeError: can't dump anonymous class"},
+		{`Marshal.load("junk")`, "ArgumentError: marshal data is invalid"},
+		{`Marshal.load(1)`, "TypeError: wrong argument type 1 (expected String)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestMarshalLoadRejectsSnapshotObjects(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected string
+	}{
+		{`{"version":2,"objects":[{"kind":"main"}],"root":1}`, "ArgumentError: marshal data is invalid"},
+		{`{"version":2,"objects":[{"kind":"io","string":"STDOUT"}],"root":1}`, "ArgumentError: marshal data is invalid"},
+		{`{"version":2,"objects":[{"kind":"integer","int":1}],"root":2}`, "ArgumentError: marshal data is invalid"},
+		{`{"version":1,"objects":[{"kind":"integer","int":1}],"root":1}`, "TypeError: incompatible marshal data version 1, expect version 2"},
+	}
+
+	for i, tt := range tests {
+		v := New()
+		v.SetConstant("DATA", InitializeString(tt.data))
+		err := testEvalError(t, v, "", `Marshal.load(DATA)`)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
@@ -13,6 +13,12 @@ type Method struct {
 	Parameters     []*ast.Identifier
 	Body           *ast.BlockStatement
 	Scope          *Scope
+	// Private methods can only be called with self as receiver, like methods defined at top level.
+	Private bool
+	// Owner is the class the method is defined in, super looks up the method from its superclass.
+	Owner *RClass
+	// block is the block a method is defined with. The method is evaluated like the block, so it can see the block's outer variables.
+	block *CallFrame
 }
 
 func (m *Method) Type() ObjectType {
@@ -48,7 +54,7 @@ func (m *Method) ExtendEnv(args []Object) *Environment {
 	return e
 }
 
-type BuiltinMethodBody func([]Object, *Method) Object
+type BuiltinMethodBody func(*VM, []Object, *CallFrame) Object
 
 type BuiltInMethod struct {
 	Fn   func(receiver Object) BuiltinMethodBody
@@ -0,0 +1,127 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestDefineMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		class Foo
+		  define_method("answer") do
+		    42
+		  end
+		end
+
+		Foo.new.answer
+		`, 42},
+		{`
+		class Foo
+		  define_method("add".to_sym) do |a, b|
+		    a + b
+		  end
+		end
+
+		Foo.new.add(1, 2)
+		`, 3},
+		{`
+		class Point
+		  def initialize(x, y)
+		    @x = x
+		    @y = y
+		  end
+
+		  ["x", "y"].each do |name|
+		    define_method(name) do
+		      instance_variable_get("@" + name)
+		    end
+		  end
+		end
+
+		p = Point.new(3, 4)
+		p.x * 10 + p.y
+		`, 34},
+		{`
+		class Foo
+		end
+
+		n = 10
+		Foo.define_method("value") do
+		  n
+		end
+		n = 20
+
+		Foo.new.value
+		`, 20},
+		{`
+		class Foo
+		end
+
+		double = Proc.new do |x|
+		  x * 2
+		end
+		Foo.define_method("double", double)
+
+		Foo.new.double(21)
+		`, 42},
+		{`
+		class Base
+		  def value
+		    1
+		  end
+		end
+
+		class Foo < Base
+		  define_method("value") do
+		    super + 10
+		  end
+		end
+
+		Foo.new.value
+		`, 11},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestDefineMethodErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		class Foo
+		  define_method("bar")
+		end
+		`, "LocalJumpError: no block given (define_method)"},
+		{`
+		class Foo
+		  define_method("bar", 1)
+		end
+		`, "TypeError: wrong argument type 1 (expected Proc)"},
+		{`
+		class Foo
+		  define_method(1) do
+		    1
+		  end
+		end
+		`, "TypeError: 1 is not a symbol nor a string"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package vm
+
+import "fmt"
+
+// initializeModule returns a module of the VM. Modules are classes that can't be instantiated or inherited,
+// their methods are added to classes with include and extend.
+func (vm *VM) initializeModule(name string) *RClass {
+	module := newClass(name, vm.classClass, nil)
+	module.IsModule = true
+
+	return module
+}
+
+func (vm *VM) opDefModule(cf *CallFrame, args []interface{}) {
+	name := args[0].(string)
+	is, ok := vm.labelsOf(cf.InstructionSet).getClassIS(name)
+
+	if !ok {
+		panic(fmt.Sprintf("Can't find module %s's instructions", name))
+	}
+
+	// like classes, a module that's already defined is reopened
+	modulePr, ok := vm.Constants[name]
+	var module *RClass
+
+	if ok {
+		if module, ok = modulePr.Target.(*RClass); !ok || !module.IsModule {
+			vm.raise(TypeErrorClass, "%s is not a module", name)
+		}
+	} else {
+		module = vm.initializeModule(name)
+		modulePr = &Pointer{Target: module}
+		vm.setConstant(name, modulePr)
+	}
+
+	vm.Stack.pop()
+	c := NewCallFrame(is)
+	c.Self = module
+	vm.CallFrameStack.Push(c)
+	vm.startFromTopFrame()
+
+	vm.Stack.push(modulePr)
+}
+
+// moduleArgument returns arg if it's a module, otherwise it raises TypeError
+func (vm *VM) moduleArgument(arg Object) *RClass {
+	module, ok := arg.(*RClass)
+
+	if !ok || !module.IsModule {
+		vm.raise(TypeErrorClass, "wrong argument type %s (expected Module)", arg.Inspect())
+	}
+
+	return module
+}
+
+// include inserts module and the modules it includes right above class in its ancestors, so their methods
+// are looked up after class's own methods but before its superclass's. Modules class already has are skipped.
+func (vm *VM) include(class *RClass, module *RClass) {
+	vm.insertModule(class, module, false)
+}
+
+// extend makes module's methods class methods of class. Like include, they're looked up from a class inserted
+// above class, but through its ClassMethods.
+func (vm *VM) extend(class *RClass, module *RClass) {
+	vm.insertModule(class, module, true)
+}
+
+func (vm *VM) insertModule(class *RClass, module *RClass, extended bool) {
+	modules := module.includedModules()
+
+	for i := len(modules) - 1; i >= 0; i-- {
+		if class.hasModule(modules[i], extended) {
+			continue
+		}
+
+		proxy := newClass(modules[i].Name, class.Class, class.SuperClass)
+		proxy.origin = modules[i]
+		proxy.extended = extended
+
+		if extended {
+			proxy.ClassMethods = modules[i].Methods
+		} else {
+			proxy.Methods = modules[i].Methods
+		}
+
+		class.SuperClass = proxy
+	}
+}
+
+// includedModules returns the module itself followed by modules it includes
+func (c *RClass) includedModules() []*RClass {
+	modules := []*RClass{c}
+
+	for current := c.SuperClass; current != nil; current = current.SuperClass {
+		if current.origin != nil && !current.extended {
+			modules = append(modules, current.origin)
+		}
+	}
+
+	return modules
+}
+
+// hasModule returns true if module is already included in, or extended by, c
+func (c *RClass) hasModule(module *RClass, extended bool) bool {
+	for current := c.SuperClass; current != nil; current = current.SuperClass {
+		if current.origin == module && current.extended == extended {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ancestors returns class, its included modules and superclasses in the order methods are looked up.
+// Singleton classes and the classes modules are included with are internal, so they're skipped or shown as their modules.
+func (vm *VM) ancestors(class Class) []Object {
+	ancestors := []Object{class}
+	base, ok := class.(interface {
+		baseClass() *BaseClass
+	})
+
+	if !ok {
+		return ancestors
+	}
+
+	for current := base.baseClass().SuperClass; current != nil; current = current.SuperClass {
+		switch {
+		case current.Singleton, current.extended:
+			continue
+		case current.origin != nil:
+			ancestors = append(ancestors, current.origin)
+		case current == ObjectClass:
+			// built in classes inherit the shared Object class, but programs know the VM's own one
+			ancestors = append(ancestors, vm.objectClass)
+		default:
+			ancestors = append(ancestors, current)
+		}
+	}
+
+	return ancestors
+}
+
+// superClassAfter returns the class where lookup of owner's methods continues for c, which is the superclass of owner,
+// of the class owner is included with, or of the class owner is the singleton class of, in c's ancestors.
+func (c *RClass) superClassAfter(owner *RClass) *RClass {
+	for current := c; current != nil; current = current.SuperClass {
+		if current == owner || current.origin == owner || current.singletonClass == owner {
+			return current.SuperClass
+		}
+	}
+
+	return nil
+}
+
+func (c *BaseClass) baseClass() *BaseClass {
+	return c
+}
@@ -0,0 +1,315 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestModuleInclude(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		module Addable
+		  def add(x)
+		    value + x
+		  end
+		end
+
+		class Foo
+		  include(Addable)
+
+		  def value
+		    10
+		  end
+		end
+
+		Foo.new.add(5)
+		`, 15},
+		{`
+		module Valuable
+		  def value
+		    1
+		  end
+		end
+
+		class Foo
+		  include(Valuable)
+
+		  def value
+		    2
+		  end
+		end
+
+		Foo.new.value
+		`, 2},
+		{`
+		module Valuable
+		  def value
+		    1
+		  end
+		end
+
+		class Base
+		  def value
+		    100
+		  end
+		end
+
+		class Foo < Base
+		  include(Valuable)
+		end
+
+		Foo.new.value
+		`, 1},
+		{`
+		module A
+		  def value
+		    1
+		  end
+		end
+
+		module B
+		  def value
+		    super + 10
+		  end
+		end
+
+		class Foo
+		  include(A)
+		  include(B)
+
+		  def value
+		    super + 100
+		  end
+		end
+
+		Foo.new.value
+		`, 111},
+		{`
+		module A
+		  def a
+		    1
+		  end
+		end
+
+		module B
+		  include(A)
+
+		  def b
+		    a + 1
+		  end
+		end
+
+		class Foo
+		  include(B)
+		end
+
+		Foo.new.b
+		`, 2},
+		{`
+		module Helper
+		  def self.twice(x)
+		    x * 2
+		  end
+		end
+
+		Helper.twice(4)
+		`, 8},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestModuleExtend(t *testing.T) {
+	input := `
+	module Finders
+	  def find(id)
+	    id * 10
+	  end
+	end
+
+	class User
+	  extend(Finders)
+	end
+
+	class Admin < User
+	end
+
+	User.find(1) + Admin.find(2)
+	`
+
+	testIntegerObject(t, testEval(t, input), 30)
+}
+
+func TestModuleAncestors(t *testing.T) {
+	input := `
+	module A
+	end
+
+	module B
+	  include(A)
+	end
+
+	module C
+	end
+
+	class Base
+	end
+
+	class Foo < Base
+	  include(B)
+	  include(A)
+	  extend(C)
+
+	  def self.bar
+	    1
+	  end
+	end
+
+	Foo.ancestors
+	`
+
+	v := New()
+	evaluated := testEvalWithVM(t, v, input)
+	ancestors, ok := evaluated.(*ArrayObject)
+
+	if !ok {
+		t.Fatalf("Expect ancestors to be an array. got=%s", evaluated.Inspect())
+	}
+
+	expected := "Array:[<Class:Foo>, <Module:B>, <Module:A>, <Class:Base>, <Class:Object>]"
+
+	if ancestors.Inspect() != expected {
+		t.Fatalf("Expect ancestors to be %s. got=%s", expected, ancestors.Inspect())
+	}
+
+	if ancestors.Elements[4] != v.Constants["Object"].Target {
+		t.Fatalf("Expect ancestors to end with the VM's Object class")
+	}
+}
+
+func TestModuleRescue(t *testing.T) {
+	input := `
+	module Retryable
+	end
+
+	class TimeoutError < StandardError
+	  include(Retryable)
+	end
+
+	begin
+	  raise(TimeoutError, "timeout")
+	rescue Retryable
+	  1
+	end
+	`
+
+	testIntegerObject(t, testEval(t, input), 1)
+}
+
+func TestModuleErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		module Foo
+		end
+
+		Foo.new
+		`, "NoMethodError: undefined method `new' for module Foo"},
+		{`
+		module Foo
+		end
+
+		class Bar < Foo
+		end
+		`, "TypeError: superclass must be a Class (<Module:Foo> given)"},
+		{`
+		class Foo
+		end
+
+		class Bar
+		  include(Foo)
+		end
+		`, "TypeError: wrong argument type <Class:Foo> (expected Module)"},
+		{`
+		module Foo
+		end
+
+		Integer.include(Foo)
+		`, "TypeError: can't change ancestors of built in class Integer"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestSnapshotWithModules(t *testing.T) {
+	bytecodes := testCompile(t, "module.ro", `
+	module Greet
+	  def greet
+	    "hi"
+	  end
+	end
+
+	module Count
+	  def count
+	    2
+	  end
+	end
+
+	class Foo
+	  include(Greet)
+	  extend(Count)
+	end
+
+	1
+	`)
+
+	v := New()
+	testExecWithVM(v, bytecodes)
+	data, err := v.Snapshot()
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	restored := New()
+	p := NewBytecodeParser()
+	p.VM = restored
+	p.Parse(bytecodes)
+
+	if err := restored.Restore(data); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	foo := restored.Constants["Foo"].Target.(*RClass)
+	greet := restored.Constants["Greet"].Target.(*RClass)
+
+	if !greet.IsModule {
+		t.Fatalf("Expect Greet to be restored as a module")
+	}
+
+	if foo.LookupInstanceMethod("greet") == nil || foo.LookupClassMethod("count") == nil {
+		t.Fatalf("Expect Foo to have methods of its modules")
+	}
+
+	greet.Methods.Set("added", &Method{Name: "added"})
+
+	if foo.LookupInstanceMethod("added") == nil {
+		t.Fatalf("Expect Foo to share methods with the restored module")
+	}
+}
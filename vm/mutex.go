@@ -0,0 +1,330 @@
+package vm
+
+import (
+	"sync"
+)
+
+var (
+	MutexClass             *RMutex
+	ConditionVariableClass *RConditionVariable
+)
+
+type RMutex struct {
+	*BaseClass
+}
+
+// MutexObject is a lock that can be shared by Rooby programs running on different VMs.
+// A locked mutex is owned by the VM that locked it, and only that VM can unlock it.
+type MutexObject struct {
+	Class *RMutex
+	mu    sync.Mutex
+	// released is signaled whenever the mutex is unlocked
+	released *sync.Cond
+	owner    *VM
+}
+
+func (m *MutexObject) Type() ObjectType {
+	return MUTEX_OBJ
+}
+
+func (m *MutexObject) Inspect() string {
+	return "<Mutex>"
+}
+
+func (m *MutexObject) ReturnClass() Class {
+	return m.Class
+}
+
+// InitializeMutex returns an unlocked Mutex object.
+func InitializeMutex() *MutexObject {
+	m := &MutexObject{Class: MutexClass}
+	m.released = sync.NewCond(&m.mu)
+	return m
+}
+
+// lock blocks until the mutex is available and makes vm its owner.
+func (m *MutexObject) lock(vm *VM) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.owner == vm {
+		vm.raise(ThreadErrorClass, "deadlock; recursive locking")
+	}
+
+	for m.owner != nil {
+		m.released.Wait()
+	}
+
+	m.owner = vm
+}
+
+func (m *MutexObject) tryLock(vm *VM) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.owner != nil {
+		return false
+	}
+
+	m.owner = vm
+	return true
+}
+
+func (m *MutexObject) unlock(vm *VM) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.owner == nil {
+		vm.raise(ThreadErrorClass, "Attempt to unlock a mutex which is not locked")
+	}
+
+	if m.owner != vm {
+		vm.raise(ThreadErrorClass, "Attempt to unlock a mutex which is locked by another thread")
+	}
+
+	m.owner = nil
+	m.released.Signal()
+}
+
+func (m *MutexObject) ownedBy(vm *VM) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.owner == vm
+}
+
+func (m *MutexObject) locked() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.owner != nil
+}
+
+var builtinMutexClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return InitializeMutex()
+			}
+		},
+		Name: "new",
+	},
+}
+
+var builtinMutexMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				m := receiver.(*MutexObject)
+				m.lock(vm)
+				return m
+			}
+		},
+		Name: "lock",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				m := receiver.(*MutexObject)
+				m.unlock(vm)
+				return m
+			}
+		},
+		Name: "unlock",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return toBooleanObject(receiver.(*MutexObject).tryLock(vm))
+			}
+		},
+		Name: "try_lock",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return toBooleanObject(receiver.(*MutexObject).locked())
+			}
+		},
+		Name: "locked?",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return toBooleanObject(receiver.(*MutexObject).ownedBy(vm))
+			}
+		},
+		Name: "owned?",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				m := receiver.(*MutexObject)
+
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (yield)")
+				}
+
+				m.lock(vm)
+				// the mutex is released even if the block raises an exception
+				defer m.unlock(vm)
+
+				return vm.builtInMethodYield(blockFrame).Target
+			}
+		},
+		Name: "synchronize",
+	},
+}
+
+type RConditionVariable struct {
+	*BaseClass
+}
+
+// ConditionVariableObject lets a VM holding a Mutex wait until another VM signals it.
+type ConditionVariableObject struct {
+	Class   *RConditionVariable
+	mu      sync.Mutex
+	waiters []chan struct{}
+}
+
+func (cv *ConditionVariableObject) Type() ObjectType {
+	return CONDITION_VARIABLE_OBJ
+}
+
+func (cv *ConditionVariableObject) Inspect() string {
+	return "<ConditionVariable>"
+}
+
+func (cv *ConditionVariableObject) ReturnClass() Class {
+	return cv.Class
+}
+
+// InitializeConditionVariable returns a ConditionVariable object without waiters.
+func InitializeConditionVariable() *ConditionVariableObject {
+	return &ConditionVariableObject{Class: ConditionVariableClass}
+}
+
+// wait releases m, blocks until the condition variable is signaled and then locks m again.
+func (cv *ConditionVariableObject) wait(vm *VM, m *MutexObject) {
+	// only the owner of m can wait, unlock raises ThreadError for others before they're registered as waiters
+	if !m.ownedBy(vm) {
+		m.unlock(vm)
+	}
+
+	ch := make(chan struct{})
+
+	// register before releasing the mutex so a signal sent right after can't be missed
+	cv.mu.Lock()
+	cv.waiters = append(cv.waiters, ch)
+	cv.mu.Unlock()
+
+	m.unlock(vm)
+	<-ch
+	m.lock(vm)
+}
+
+// signal wakes up the longest waiting VM, if any.
+func (cv *ConditionVariableObject) signal() {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	if len(cv.waiters) > 0 {
+		close(cv.waiters[0])
+		cv.waiters = cv.waiters[1:]
+	}
+}
+
+func (cv *ConditionVariableObject) broadcast() {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	for _, ch := range cv.waiters {
+		close(ch)
+	}
+
+	cv.waiters = nil
+}
+
+var builtinConditionVariableClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return InitializeConditionVariable()
+			}
+		},
+		Name: "new",
+	},
+}
+
+var builtinConditionVariableMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				m, ok := args[0].(*MutexObject)
+
+				if !ok {
+					vm.raise(TypeErrorClass, "Expect argument to be a Mutex. got=%s", args[0].Inspect())
+				}
+
+				cv := receiver.(*ConditionVariableObject)
+				cv.wait(vm, m)
+				return cv
+			}
+		},
+		Name: "wait",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				cv := receiver.(*ConditionVariableObject)
+				cv.signal()
+				return cv
+			}
+		},
+		Name: "signal",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				cv := receiver.(*ConditionVariableObject)
+				cv.broadcast()
+				return cv
+			}
+		},
+		Name: "broadcast",
+	},
+}
+
+func initMutex() {
+	methods := NewEnvironment()
+	classMethods := NewEnvironment()
+
+	for _, m := range builtinMutexMethods {
+		methods.Set(m.Name, m)
+	}
+
+	for _, m := range builtinMutexClassMethods {
+		classMethods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "Mutex", Methods: methods, ClassMethods: classMethods, Class: ClassClass, SuperClass: ObjectClass}
+	MutexClass = &RMutex{BaseClass: bc}
+
+	methods = NewEnvironment()
+	classMethods = NewEnvironment()
+
+	for _, m := range builtinConditionVariableMethods {
+		methods.Set(m.Name, m)
+	}
+
+	for _, m := range builtinConditionVariableClassMethods {
+		classMethods.Set(m.Name, m)
+	}
+
+	bc = &BaseClass{Name: "ConditionVariable", Methods: methods, ClassMethods: classMethods, Class: ClassClass, SuperClass: ObjectClass}
+	ConditionVariableClass = &RConditionVariable{BaseClass: bc}
+}
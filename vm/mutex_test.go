@@ -0,0 +1,262 @@
+package vm
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestMutexMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		m = Mutex.new
+		m.synchronize do
+		  10
+		end
+		`, 10},
+		{`
+		m = Mutex.new
+		locked = false
+		m.synchronize do
+		  locked = m.locked?
+		end
+		locked
+		`, true},
+		{`
+		m = Mutex.new
+		m.synchronize do
+		  1
+		end
+		m.locked?
+		`, false},
+		{`
+		m = Mutex.new
+		m.lock
+		m.owned?
+		`, true},
+		{`
+		m = Mutex.new
+		m.lock
+		m.unlock
+		m.locked?
+		`, false},
+		{`
+		m = Mutex.new
+		m.try_lock
+		`, true},
+		{`
+		m = Mutex.new
+		m.lock
+		m.try_lock
+		`, false},
+		{`
+		m = Mutex.new
+		begin
+		  m.synchronize do
+		    raise("foo")
+		  end
+		rescue
+		  m.locked?
+		end
+		`, false},
+		{`
+		m = Mutex.new
+		begin
+		  m.synchronize do
+		    m.lock
+		  end
+		rescue ThreadError => e
+		  e.message
+		end
+		`, "deadlock; recursive locking"},
+		{`
+		m = Mutex.new
+		begin
+		  m.unlock
+		rescue ThreadError => e
+		  e.message
+		end
+		`, "Attempt to unlock a mutex which is not locked"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		default:
+			t.Fatalf("test %d: unexpected expected value %v", i, tt.expected)
+		}
+	}
+}
+
+func TestMutexOwnedByAnotherVM(t *testing.T) {
+	m := InitializeMutex()
+	m.lock(New())
+
+	v := New()
+	v.Constants["M"] = &Pointer{m}
+
+	input := `
+	begin
+	  M.unlock
+	rescue ThreadError => e
+	  M.owned?.to_s + " " + M.try_lock.to_s + " " + e.message
+	end
+	`
+
+	evaluated := testEvalWithVM(t, v, input)
+	testStringObject(t, evaluated, "false false Attempt to unlock a mutex which is locked by another thread")
+}
+
+func TestMutexSharedBetweenVMs(t *testing.T) {
+	input := `
+	M.synchronize do
+	  Counter.push(Counter.length)
+	end
+	`
+
+	m := InitializeMutex()
+	counter := InitializeArray([]Object{})
+	bytecodes := testCompile(t, "", input)
+	done := make(chan Object)
+
+	for i := 0; i < 10; i++ {
+		go func() {
+			v := New()
+			v.Constants["M"] = &Pointer{m}
+			v.Constants["Counter"] = &Pointer{counter}
+			done <- testExecWithVM(v, bytecodes)
+		}()
+	}
+
+	for i := 0; i < 10; i++ {
+		if err, ok := (<-done).(*Error); ok {
+			t.Fatalf("Unexpected error: %s", err.Message)
+		}
+	}
+
+	if len(counter.Elements) != 10 {
+		t.Fatalf("Expect counter to have 10 elements. got=%d", len(counter.Elements))
+	}
+
+	for i, elem := range counter.Elements {
+		testIntegerObject(t, elem, i)
+	}
+
+	if m.locked() {
+		t.Fatalf("Expect mutex to be unlocked")
+	}
+}
+
+func TestConditionVariableWaitAndSignal(t *testing.T) {
+	waiter := `
+	M.synchronize do
+	  if Ready.length == 0
+	    CV.wait(M)
+	  end
+
+	  Ready[0]
+	end
+	`
+	signaler := `
+	M.synchronize do
+	  Ready.push(42)
+	  CV.signal
+	end
+	`
+
+	m := InitializeMutex()
+	cv := InitializeConditionVariable()
+	ready := InitializeArray([]Object{})
+	newVM := func() *VM {
+		v := New()
+		v.Constants["M"] = &Pointer{m}
+		v.Constants["CV"] = &Pointer{cv}
+		v.Constants["Ready"] = &Pointer{ready}
+		return v
+	}
+
+	waiterBytecodes := testCompile(t, "", waiter)
+	signalerBytecodes := testCompile(t, "", signaler)
+	result := make(chan Object)
+
+	go func() {
+		result <- testExecWithVM(newVM(), waiterBytecodes)
+	}()
+
+	testExecWithVM(newVM(), signalerBytecodes)
+
+	testIntegerObject(t, <-result, 42)
+
+	if m.locked() {
+		t.Fatalf("Expect mutex to be unlocked")
+	}
+}
+
+func TestConditionVariableBroadcast(t *testing.T) {
+	m := InitializeMutex()
+	cv := InitializeConditionVariable()
+	waiters := make(chan bool)
+
+	for i := 0; i < 3; i++ {
+		go func() {
+			v := New()
+			m.lock(v)
+			cv.wait(v, m)
+			waiters <- m.ownedBy(v)
+			m.unlock(v)
+		}()
+	}
+
+	// wait until every goroutine is waiting on cv
+	for {
+		cv.mu.Lock()
+		n := len(cv.waiters)
+		cv.mu.Unlock()
+
+		if n == 3 {
+			break
+		}
+
+		runtime.Gosched()
+	}
+
+	v := New()
+	v.Constants["CV"] = &Pointer{cv}
+	testEvalWithVM(t, v, `CV.broadcast`)
+
+	for i := 0; i < 3; i++ {
+		if !<-waiters {
+			t.Fatalf("Expect waiter to own the mutex after waking up")
+		}
+	}
+}
+
+func TestConditionVariableWaitWithoutOwningMutex(t *testing.T) {
+	v := New()
+	v.Constants["M"] = &Pointer{InitializeMutex()}
+	v.Constants["CV"] = &Pointer{InitializeConditionVariable()}
+
+	input := `
+	begin
+	  CV.wait(M)
+	rescue ThreadError => e
+	  e.message
+	end
+	`
+
+	evaluated := testEvalWithVM(t, v, input)
+	testStringObject(t, evaluated, "Attempt to unlock a mutex which is not locked")
+
+	if cv := v.Constants["CV"].Target.(*ConditionVariableObject); len(cv.waiters) != 0 {
+		t.Fatalf("Expect VM not to be left waiting on the condition variable. got=%d waiters", len(cv.waiters))
+	}
+}
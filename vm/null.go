@@ -41,7 +41,7 @@ func initNull() {
 var builtInNullMethods = []*BuiltInMethod{
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				return TRUE
 			}
 		},
@@ -4,23 +4,43 @@ import (
 	"fmt"
 )
 
-var MainObj *RObject
-
 type ObjectType string
 
 const (
-	INTEGER_OBJ         = "INTEGER"
-	ARRAY_OBJ           = "ARRAY"
-	HASH_OBJ            = "HASH"
-	STRING_OBJ          = "STRING"
-	BOOLEAN_OBJ         = "BOOLEAN"
-	NULL_OBJ            = "NULL"
-	RETURN_VALUE_OBJ    = "RETURN_VALUE"
-	ERROR_OBJ           = "ERROR"
-	METHOD_OBJ          = "METHOD"
-	CLASS_OBJ           = "CLASS"
-	BASE_OBJECT_OBJ     = "BASE_OBJECT"
-	BUILD_IN_METHOD_OBJ = "BUILD_IN_METHOD"
+	INTEGER_OBJ            = "INTEGER"
+	FLOAT_OBJ              = "FLOAT"
+	RATIONAL_OBJ           = "RATIONAL"
+	BIG_DECIMAL_OBJ        = "BIG_DECIMAL"
+	RANGE_OBJ              = "RANGE"
+	DATE_OBJ               = "DATE"
+	REGEXP_OBJ             = "REGEXP"
+	MATCH_DATA_OBJ         = "MATCH_DATA"
+	FILE_OBJ               = "FILE"
+	IO_OBJ                 = "IO"
+	ENV_OBJ                = "ENV"
+	HTTP_RESPONSE_OBJ      = "HTTP_RESPONSE"
+	TCP_SERVER_OBJ         = "TCP_SERVER"
+	TCP_SOCKET_OBJ         = "TCP_SOCKET"
+	URI_OBJ                = "URI"
+	PROCESS_STATUS_OBJ     = "PROCESS_STATUS"
+	ARRAY_OBJ              = "ARRAY"
+	HASH_OBJ               = "HASH"
+	ENUMERATOR_OBJ         = "ENUMERATOR"
+	PROC_OBJ               = "PROC"
+	MUTEX_OBJ              = "MUTEX"
+	RANDOM_OBJ             = "RANDOM"
+	CONDITION_VARIABLE_OBJ = "CONDITION_VARIABLE"
+	STRING_OBJ             = "STRING"
+	ENCODING_OBJ           = "ENCODING"
+	SYMBOL_OBJ             = "SYMBOL"
+	BOOLEAN_OBJ            = "BOOLEAN"
+	NULL_OBJ               = "NULL"
+	RETURN_VALUE_OBJ       = "RETURN_VALUE"
+	ERROR_OBJ              = "ERROR"
+	METHOD_OBJ             = "METHOD"
+	CLASS_OBJ              = "CLASS"
+	BASE_OBJECT_OBJ        = "BASE_OBJECT"
+	BUILD_IN_METHOD_OBJ    = "BUILD_IN_METHOD"
 )
 
 func init() {
@@ -28,14 +48,42 @@ func init() {
 	initNull()
 	initBool()
 	initInteger()
+	initFloat()
+	initRational()
+	initBigDecimal()
 	initString()
-	initMainObj()
+	initEncoding()
+	initSymbol()
+	initArray()
+	initRange()
+	initDate()
+	initRegexp()
+	initFile()
+	initFileUtils()
+	initIO()
+	initEnv()
+	initHTTP()
+	initSocket()
+	initURI()
+	initDigest()
+	initMarshal()
+	initYAML()
+	initProcess()
+	initHash()
+	initEnumerator()
+	initProc()
+	initMutex()
+	initRandom()
+	initStruct()
+	initException()
+	initObjectSpace()
 }
 
-func initMainObj() {
-	builtInClasses := []Class{StringClass, BooleanClass, IntegerClass}
+// newMainObject returns the top level object of a VM, an instance of the VM's own Object class
+func newMainObject(objectClass *RClass) *RObject {
+	builtInClasses := []Class{StringClass, BooleanClass, IntegerClass, FloatClass}
 
-	obj := &RObject{Class: ObjectClass, InstanceVariables: NewEnvironment()}
+	obj := &RObject{Class: objectClass, InstanceVariables: NewEnvironment(), main: true}
 	scope := &Scope{Self: obj, Env: NewEnvironment()}
 
 	for _, class := range builtInClasses {
@@ -43,7 +91,7 @@ func initMainObj() {
 	}
 
 	obj.Scope = scope
-	MainObj = obj
+	return obj
 }
 
 type Object interface {
@@ -76,5 +124,5 @@ func checkArgumentLen(args []Object, class Class, method_name string) *Error {
 }
 
 func wrongTypeError(c Class) *Error {
-	return &Error{Message: fmt.Sprintf("expect argument to be %s type", c.ReturnName())}
+	return &Error{Message: fmt.Sprintf("expect argument to be %s type", c.ReturnName()), exceptionClass: TypeErrorClass}
 }
@@ -0,0 +1,124 @@
+package vm
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+var (
+	// ObjectSpaceModule and GCModule report objects counted by the VM's object tracker, which is enabled by
+	// VM.TrackObjects or by setting an object or memory limit.
+	ObjectSpaceModule *RClass
+	GCModule          *RClass
+)
+
+// countObjects returns a hash of live tracked objects per class name, with their total under "TOTAL".
+func (vm *VM) countObjects() *HashObject {
+	counts := map[string]Object{"TOTAL": vm.initInteger(0)}
+
+	if vm.objects == nil {
+		return InitializeHash(counts)
+	}
+
+	byName := map[string]int{}
+	total := 0
+
+	for class, count := range vm.objects.liveObjectsByClass() {
+		byName[class.ReturnName()] += count
+		total += count
+	}
+
+	for name, count := range byName {
+		counts[name] = vm.initInteger(count)
+	}
+
+	counts["TOTAL"] = vm.initInteger(total)
+
+	return InitializeHash(counts)
+}
+
+// gcStat returns a report of the garbage collector and objects counted by the object tracker.
+// Numbers of the garbage collector and heap are of the whole Go process, which may run other VMs.
+func (vm *VM) gcStat() *HashObject {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	var live, liveBytes, allocated, freed int64
+
+	if t := vm.objects; t != nil {
+		live = atomic.LoadInt64(&t.objects)
+		liveBytes = atomic.LoadInt64(&t.bytes)
+		allocated = atomic.LoadInt64(&t.allocated)
+		freed = atomic.LoadInt64(&t.freed)
+	}
+
+	return InitializeHash(map[string]Object{
+		"count":                   vm.initInteger(int(m.NumGC)),
+		"heap_allocated_bytes":    vm.initInteger(int(m.HeapAlloc)),
+		"heap_live_objects":       vm.initInteger(int(live)),
+		"heap_live_bytes":         vm.initInteger(int(liveBytes)),
+		"total_allocated_objects": vm.initInteger(int(allocated)),
+		"total_freed_objects":     vm.initInteger(int(freed)),
+		"tracking":                toBooleanObject(vm.objects != nil),
+	})
+}
+
+var builtinObjectSpaceClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.countObjects()
+			}
+		},
+		Name: "count_objects",
+	},
+}
+
+var builtinGCClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.gcStat()
+			}
+		},
+		Name: "stat",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				vm.CollectGarbage()
+				return NULL
+			}
+		},
+		Name: "start",
+	},
+}
+
+func initObjectSpace() {
+	ObjectSpaceModule = initializeBuiltinModule("ObjectSpace", builtinObjectSpaceClassMethods)
+	GCModule = initializeBuiltinModule("GC", builtinGCClassMethods)
+}
+
+// initializeBuiltinModule returns a module shared by every VM, whose methods are called on the module itself.
+func initializeBuiltinModule(name string, methods []*BuiltInMethod) *RClass {
+	module := newClass(name, ClassClass, nil)
+	module.IsModule = true
+
+	for _, m := range methods {
+		module.ClassMethods.Set(m.Name, m)
+	}
+
+	return module
+}
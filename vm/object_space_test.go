@@ -0,0 +1,94 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestCountObjects(t *testing.T) {
+	v := New()
+	v.TrackObjects()
+
+	evaluated := testEvalWithVM(t, v, `
+	class Foo
+	end
+
+	a = [Foo.new, Foo.new]
+	h = { a: 1 }
+	ObjectSpace.count_objects
+	`)
+
+	counts, ok := evaluated.(*HashObject)
+
+	if !ok {
+		t.Fatalf("Expect count_objects to return a hash. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := "{ Array: 1, Foo: 2, Hash: 1, TOTAL: 4 }"
+
+	if counts.Inspect() != expected {
+		t.Fatalf("Expect object counts to be %s. got=%s", expected, counts.Inspect())
+	}
+}
+
+func TestCountObjectsWithoutTracking(t *testing.T) {
+	evaluated := testEval(t, `
+	a = [[1], [2]]
+	ObjectSpace.count_objects["TOTAL"]
+	`)
+
+	testIntegerObject(t, evaluated, 0)
+}
+
+func TestGCStat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		a = [[1], [2]]
+		GC.stat["heap_live_objects"]
+		`, 3},
+		{`
+		a = [[1], [2]]
+		GC.stat["total_allocated_objects"]
+		`, 3},
+		{`
+		a = [[1], [2]]
+		GC.start
+		GC.stat["heap_live_objects"]
+		`, 3},
+	}
+
+	for i, tt := range tests {
+		v := New()
+		v.TrackObjects()
+		evaluated := testEvalWithVM(t, v, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+
+	evaluated := testEval(t, `GC.stat["tracking"]`)
+	testBooleanObject(t, evaluated, false)
+}
+
+func TestObjectSpaceIsBuiltIn(t *testing.T) {
+	evaluated := testEvalWithVM(t, New(), `
+	module ObjectSpace
+	  def self.foo
+	    1
+	  end
+	end
+
+	ObjectSpace.foo
+	`)
+	testIntegerObject(t, evaluated, 1)
+
+	err := testEvalError(t, New(), "", `ObjectSpace.foo`)
+	expected := "NoMethodError: undefined method `foo' for <Module:ObjectSpace>"
+
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expect error %q. got=%v", expected, err)
+	}
+}
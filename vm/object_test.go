@@ -14,3 +14,78 @@ func getBuiltInMethod(t *testing.T, receiver BaseObject, method_name string) Bui
 	method := m.(*BuiltInMethod)
 	return method.Fn(receiver)
 }
+
+func TestInstanceVariableReflection(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		class Foo
+		  def initialize
+		    @b = 1
+		    @a = 2
+		    @b = 3
+		  end
+		end
+
+		Foo.new.instance_variables
+		`, "Array:[:@b, :@a]"},
+		{`
+		class Foo
+		end
+
+		Foo.new.instance_variables
+		`, "Array:[]"},
+		{`1.instance_variables`, "Array:[]"},
+		{`
+		class Foo
+		  def initialize
+		    @a = 10
+		  end
+		end
+
+		foo = Foo.new
+		[foo.instance_variable_get("@a"), foo.instance_variable_get("@b".to_sym)]
+		`, "Array:[10, null]"},
+		{`
+		class Foo
+		  def a
+		    @a
+		  end
+		end
+
+		foo = Foo.new
+		foo.instance_variable_set("@a", 5)
+		[foo.a, foo.instance_variables]
+		`, "Array:[5, Array:[:@a]]"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Fatalf("at test case %d: expect %s. got=%s", i, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestInstanceVariableReflectionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Object.new.instance_variable_get("a")`, "NameError: `a' is not allowed as an instance variable name"},
+		{`Object.new.instance_variable_get("@@a")`, "NameError: `@@a' is not allowed as an instance variable name"},
+		{`Object.new.instance_variable_set(1, 2)`, "TypeError: 1 is not a symbol nor a string"},
+		{`1.instance_variable_set("@a", 2)`, "TypeError: can't modify instance variables of 1"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
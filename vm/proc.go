@@ -0,0 +1,112 @@
+package vm
+
+var (
+	ProcClass *RProc
+)
+
+type RProc struct {
+	*BaseClass
+}
+
+// ProcObject is a block turned into an object, so it can be stored in variables, passed to methods and returned from them.
+// It keeps the block's environment, which means it can still read and write local variables of the scope it was created in.
+type ProcObject struct {
+	Class      *RProc
+	blockFrame *CallFrame
+}
+
+func (p *ProcObject) Type() ObjectType {
+	return PROC_OBJ
+}
+
+func (p *ProcObject) Inspect() string {
+	return "<Proc>"
+}
+
+func (p *ProcObject) ReturnClass() Class {
+	return p.Class
+}
+
+// InitializeProc wraps the given block frame into a Proc object.
+func InitializeProc(blockFrame *CallFrame) *ProcObject {
+	return &ProcObject{Class: ProcClass, blockFrame: blockFrame}
+}
+
+// newProc wraps the block a method is called with into a Proc, it raises LocalJumpError if there isn't one.
+func (vm *VM) newProc(blockFrame *CallFrame) *ProcObject {
+	if blockFrame == nil {
+		vm.raise(LocalJumpErrorClass, "tried to create Proc object without a block")
+	}
+
+	return InitializeProc(blockFrame)
+}
+
+// Arity returns the number of parameters the proc's block takes.
+func (p *ProcObject) Arity() int {
+	return p.blockFrame.InstructionSet.Arity
+}
+
+// call runs the proc's block. Like Ruby's procs, extra arguments are dropped and missing ones are nil.
+func (p *ProcObject) call(vm *VM, args []Object) Object {
+	params := make([]Object, p.Arity())
+
+	for i := range params {
+		if i < len(args) {
+			params[i] = args[i]
+		} else {
+			params[i] = NULL
+		}
+	}
+
+	return vm.builtInMethodYield(p.blockFrame, params...).Target
+}
+
+var builtinProcClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return vm.newProc(blockFrame)
+			}
+		},
+		Name: "new",
+	},
+}
+
+var builtinProcMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return receiver.(*ProcObject).call(vm, args)
+			}
+		},
+		Name: "call",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initInteger(receiver.(*ProcObject).Arity())
+			}
+		},
+		Name: "arity",
+	},
+}
+
+func initProc() {
+	methods := NewEnvironment()
+	classMethods := NewEnvironment()
+
+	for _, m := range builtinProcMethods {
+		methods.Set(m.Name, m)
+	}
+
+	for _, m := range builtinProcClassMethods {
+		classMethods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "Proc", Methods: methods, ClassMethods: classMethods, Class: ClassClass, SuperClass: ObjectClass}
+	ProcClass = &RProc{BaseClass: bc}
+}
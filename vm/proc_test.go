@@ -0,0 +1,180 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestProcs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		add = Proc.new do |a, b|
+		  a + b
+		end
+
+		add.call(1, 2)
+		`, 3},
+		{`
+		def make_counter
+		  count = 0
+		  Proc.new do
+		    count = count + 1
+		    count
+		  end
+		end
+
+		counter = make_counter
+		counter.call
+		counter.call
+		counter.call
+		`, 3},
+		{`
+		def apply(f, x)
+		  f.call(x)
+		end
+
+		triple = proc() do |x|
+		  x * 3
+		end
+
+		apply(triple, 4)
+		`, 12},
+		{`
+		n = 1
+		set = proc() do |x|
+		  n = x
+		end
+
+		set.call(10)
+		n
+		`, 10},
+		{`
+		add = Proc.new do |a, b|
+		  a + b
+		end
+
+		add.call(1, 2, 3)
+		`, 3},
+		{`
+		Proc.new do |a, b|
+		  a + b
+		end.arity
+		`, 2},
+		{`
+		Proc.new do
+		  1
+		end.arity
+		`, 0},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestClosures(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		count = 0
+		[1, 2, 3].each do |i|
+		  count = count + i
+		end
+		count
+		`, 6},
+		{`
+		n = 1
+		get = proc() do
+		  n
+		end
+		n = 5
+		get.call
+		`, 5},
+		{`
+		def counters
+		  count = 0
+		  inc = Proc.new do
+		    count = count + 1
+		    count
+		  end
+		  get = Proc.new do
+		    count
+		  end
+		  [inc, get]
+		end
+
+		pair = counters
+		pair[0].call
+		pair[0].call
+		pair[1].call
+		`, 2},
+		{`
+		sum = 0
+		[1, 2].each do |i|
+		  [10, 20].each do |j|
+		    sum = sum + i * j
+		  end
+		end
+		sum
+		`, 90},
+		{`
+		if false
+		  x = 1
+		end
+		set = proc() do
+		  x = 7
+		end
+		set.call
+		x
+		`, 7},
+		{`
+		def foo
+		  x = 1
+		  yield(x)
+		  x
+		end
+
+		foo() do |v|
+		  v = 5
+		end
+		`, 1},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestProcMissingArguments(t *testing.T) {
+	input := `
+	first = Proc.new do |a, b|
+	  b
+	end
+
+	first.call(1)
+	`
+
+	evaluated := testEval(t, input)
+	testNullObject(t, evaluated)
+}
+
+func TestProcWithoutBlock(t *testing.T) {
+	err := testEvalError(t, New(), "", "Proc.new")
+	expected := "LocalJumpError: tried to create Proc object without a block"
+
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expect error %q. got=%v", expected, err)
+	}
+}
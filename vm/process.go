@@ -0,0 +1,226 @@
+package vm
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+var (
+	// ProcessModule spawns and waits for other programs, the status of the last one that finished is `$?`.
+	ProcessModule      *RClass
+	ProcessStatusClass *RProcessStatus
+)
+
+// commandNotFoundStatus is the exit status of commands that can't be started, like shells report.
+const commandNotFoundStatus = 127
+
+type RProcessStatus struct {
+	*BaseClass
+}
+
+// ProcessStatusObject is how a program run by system, backticks or Process.spawn finished.
+type ProcessStatusObject struct {
+	Class      *RProcessStatus
+	pid        int
+	exitStatus int
+}
+
+func (s *ProcessStatusObject) Type() ObjectType {
+	return PROCESS_STATUS_OBJ
+}
+
+func (s *ProcessStatusObject) Inspect() string {
+	return fmt.Sprintf("#<Process::Status: pid %d exit %d>", s.pid, s.exitStatus)
+}
+
+func (s *ProcessStatusObject) ReturnClass() Class {
+	return s.Class
+}
+
+// command returns the command args refer to. A single string is run by the shell, so it can use pipes and redirects,
+// while more arguments are the program and its arguments. Its output goes to the VM's STDOUT and STDERR, and its environment is ENV.
+func (vm *VM) command(args []Object) *exec.Cmd {
+	if len(args) == 0 {
+		vm.raise(ArgumentErrorClass, "wrong number of arguments (given 0, expected 1+)")
+	}
+
+	var cmd *exec.Cmd
+
+	if len(args) == 1 {
+		cmd = exec.Command("/bin/sh", "-c", vm.stringArgument(args[0]))
+	} else {
+		arguments := []string{}
+
+		for _, arg := range args[1:] {
+			arguments = append(arguments, vm.stringArgument(arg))
+		}
+
+		cmd = exec.Command(vm.stringArgument(args[0]), arguments...)
+	}
+
+	cmd.Stdout = vm.stdout.writer
+	cmd.Stderr = vm.stderr.writer
+
+	// commands see the variables set by the host with SetEnv instead of the process's
+	if vm.env.vars != nil {
+		cmd.Env = []string{}
+
+		for name, value := range vm.env.vars {
+			cmd.Env = append(cmd.Env, name+"="+value)
+		}
+	}
+
+	return cmd
+}
+
+// runCommand runs cmd until it finishes and returns its status. The error is only returned if cmd can't be started.
+func (vm *VM) runCommand(cmd *exec.Cmd) (*ProcessStatusObject, error) {
+	err := cmd.Run()
+	status := vm.setProcessStatus(cmd, err)
+
+	if _, ok := err.(*exec.ExitError); err != nil && !ok {
+		return status, err
+	}
+
+	return status, nil
+}
+
+// setProcessStatus assigns `$?` the status of cmd, which finished with err, and returns it.
+func (vm *VM) setProcessStatus(cmd *exec.Cmd, err error) *ProcessStatusObject {
+	status := &ProcessStatusObject{Class: ProcessStatusClass}
+
+	if cmd.Process != nil {
+		status.pid = cmd.Process.Pid
+	}
+
+	switch err := err.(type) {
+	case nil:
+	case *exec.ExitError:
+		status.exitStatus = err.Sys().(syscall.WaitStatus).ExitStatus()
+	default:
+		status.exitStatus = commandNotFoundStatus
+	}
+
+	vm.SetGlobal("$?", status)
+
+	return status
+}
+
+var builtinProcessModuleMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				cmd := vm.command(args)
+
+				if err := cmd.Start(); err != nil {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				vm.children = append(vm.children, cmd)
+
+				return vm.initInteger(cmd.Process.Pid)
+			}
+		},
+		Name: "spawn",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				if len(vm.children) == 0 {
+					vm.raise(ArgumentErrorClass, "no child processes")
+				}
+
+				// without a pid, wait for the child that's spawned first
+				i := 0
+
+				if len(args) == 1 {
+					pid := vm.integerArgument(args[0])
+
+					for i = 0; i < len(vm.children) && vm.children[i].Process.Pid != pid; i++ {
+					}
+
+					if i == len(vm.children) {
+						vm.raise(ArgumentErrorClass, "no child process %d", pid)
+					}
+				}
+
+				cmd := vm.children[i]
+				vm.children = append(vm.children[:i], vm.children[i+1:]...)
+				status := vm.setProcessStatus(cmd, cmd.Wait())
+
+				return vm.initInteger(status.pid)
+			}
+		},
+		Name: "wait",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initInteger(os.Getpid())
+			}
+		},
+		Name: "pid",
+	},
+}
+
+var builtinProcessStatusMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initInteger(receiver.(*ProcessStatusObject).exitStatus)
+			}
+		},
+		Name: "exitstatus",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return toBooleanObject(receiver.(*ProcessStatusObject).exitStatus == 0)
+			}
+		},
+		Name: "success?",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initInteger(receiver.(*ProcessStatusObject).pid)
+			}
+		},
+		Name: "pid",
+	},
+}
+
+func initProcess() {
+	ProcessModule = initializeBuiltinModule("Process", builtinProcessModuleMethods)
+
+	methods := NewEnvironment()
+
+	for _, m := range builtinProcessStatusMethods {
+		methods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "Process::Status", Methods: methods, ClassMethods: NewEnvironment(), Class: ClassClass, SuperClass: ObjectClass}
+	ProcessStatusClass = &RProcessStatus{BaseClass: bc}
+}
@@ -0,0 +1,173 @@
+package vm
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSystem(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		output   string
+	}{
+		{`system("echo hello")`, true, "hello\n"},
+		{`system("echo", "a  b", "c")`, true, "a  b c\n"},
+		{`system("exit 3")`, false, ""},
+		{`system("/nonexistent/command", "arg")`, nil, ""},
+		{`
+		system("exit 3")
+		$?.exitstatus
+		`, 3, ""},
+		{`
+		system("exit 0")
+		$?.success?
+		`, true, ""},
+		{`
+		system("/nonexistent/command", "arg")
+		$?.exitstatus
+		`, 127, ""},
+		{`
+		system("echo $ROOBY_VAR")
+		`, true, "from ENV\n"},
+	}
+
+	for i, tt := range tests {
+		var stdout bytes.Buffer
+		v := New()
+		v.SetStdout(&stdout)
+		v.SetEnv(map[string]string{"ROOBY_VAR": "from ENV"})
+		evaluated := testEvalWithVM(t, v, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		default:
+			t.Fatalf("at test case %d: unexpected expectation %v", i, expected)
+		}
+
+		if stdout.String() != tt.output {
+			t.Fatalf("at test case %d: expect output %q. got=%q", i, tt.output, stdout.String())
+		}
+	}
+}
+
+func TestBackticks(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"`echo hello`", "hello\n"},
+		{"`echo hello | tr a-z A-Z`", "HELLO\n"},
+		{"`exit 2`", ""},
+		{"`exit 2`\n$?.exitstatus", 2},
+		{"`echo a`\n$?.success?", true},
+		{"`echo a`.length", 2},
+		{"`/nonexistent/command 2>/dev/null`\n$?.exitstatus", 127},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case string:
+			testStringObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		default:
+			t.Fatalf("at test case %d: unexpected expectation %v", i, expected)
+		}
+	}
+}
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Process.pid`, os.Getpid()},
+		{`
+		pid = Process.spawn("sh", "-c", "exit 4")
+		Process.wait(pid) == pid
+		`, true},
+		{`
+		pid = Process.spawn("exit 4")
+		Process.wait
+		$?.exitstatus
+		`, 4},
+		{`
+		pid = Process.spawn("exit 0")
+		Process.wait
+		$?.pid == pid
+		`, true},
+		{`
+		a = Process.spawn("exit 1")
+		b = Process.spawn("exit 2")
+		Process.wait(b)
+		s = $?.exitstatus
+		Process.wait(a)
+		s * 10 + $?.exitstatus
+		`, 21},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		default:
+			t.Fatalf("at test case %d: unexpected expectation %v", i, expected)
+		}
+	}
+}
+
+func TestProcessStatusInspect(t *testing.T) {
+	evaluated := testEval(t, `
+	system("exit 5")
+	$?
+	`)
+
+	if !strings.HasPrefix(evaluated.Inspect(), "#<Process::Status: pid ") || !strings.HasSuffix(evaluated.Inspect(), " exit 5>") {
+		t.Fatalf("Expect status to be inspected with its pid and exit status. got=%s", evaluated.Inspect())
+	}
+}
+
+func TestProcessErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`system`, "ArgumentError: wrong number of arguments (given 0, expected 1+)"},
+		{`system(1)`, "TypeError: wrong argument type 1 (expected String)"},
+		{`Process.spawn("/nonexistent/command", "arg")`, "IOError: fork/exec /nonexistent/command: no such file or directory"},
+		{`Process.wait`, "ArgumentError: no child processes"},
+		{`
+		Process.spawn("exit 0")
+		Process.wait(0)
+		`, "ArgumentError: no child process 0"},
+	}
+
+	for i, tt := range tests {
+		v := New()
+		err := testEvalError(t, v, "", tt.input)
+
+		if err == nil {
+			t.Fatalf("at test case %d: expect an error", i)
+		}
+
+		if err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%q", i, tt.expected, err.Error())
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Profiler records how many times each method is called, the time spent in it and how many instructions are executed.
+// Create it with NewProfiler before running the program and get the result with Report.
+type Profiler struct {
+	methods      map[string]*MethodProfile
+	instructions map[string]int
+	frames       []*profiledFrame
+	// active counts frames of each method on the stack, so recursive calls don't count inclusive time twice.
+	active map[string]int
+	now    func() time.Time
+}
+
+// MethodProfile is the profile of a method, or of a block or class body. Inclusive time includes the methods it calls,
+// exclusive time doesn't.
+type MethodProfile struct {
+	Name      string
+	Calls     int
+	Inclusive time.Duration
+	Exclusive time.Duration
+}
+
+// ProfileReport is what a Profiler recorded. Methods are sorted by exclusive time, so hot spots come first.
+type ProfileReport struct {
+	Methods      []*MethodProfile
+	Instructions map[string]int
+}
+
+type profiledFrame struct {
+	name     string
+	start    time.Time
+	children time.Duration
+}
+
+// NewProfiler starts profiling programs executed by vm.
+func NewProfiler(vm *VM) *Profiler {
+	p := &Profiler{
+		methods:      make(map[string]*MethodProfile),
+		instructions: make(map[string]int),
+		active:       make(map[string]int),
+		now:          time.Now,
+	}
+
+	vm.OnInstruction(func(cf *CallFrame, i *Instruction) {
+		p.instructions[i.Action.Name]++
+	})
+	vm.OnCall(p.enter)
+	vm.OnReturn(p.leave)
+
+	return p
+}
+
+func (p *Profiler) enter(cf *CallFrame) {
+	name := profiledName(cf)
+	m, ok := p.methods[name]
+
+	if !ok {
+		m = &MethodProfile{Name: name}
+		p.methods[name] = m
+	}
+
+	m.Calls++
+	p.active[name]++
+	p.frames = append(p.frames, &profiledFrame{name: name, start: p.now()})
+}
+
+func (p *Profiler) leave(cf *CallFrame) {
+	if len(p.frames) == 0 {
+		return
+	}
+
+	f := p.frames[len(p.frames)-1]
+	p.frames = p.frames[:len(p.frames)-1]
+	elapsed := p.now().Sub(f.start)
+	m := p.methods[f.name]
+
+	p.active[f.name]--
+
+	if p.active[f.name] == 0 {
+		m.Inclusive += elapsed
+	}
+
+	m.Exclusive += elapsed - f.children
+
+	if len(p.frames) > 0 {
+		p.frames[len(p.frames)-1].children += elapsed
+	}
+}
+
+// profiledName qualifies method names with their class, like `Foo#bar` and `Foo.bar` for class methods.
+func profiledName(cf *CallFrame) string {
+	name := cf.MethodName()
+
+	if cf.InstructionSet.Label.Type != LABEL_DEF {
+		return name
+	}
+
+	switch self := cf.Self.(type) {
+	case Class:
+		return self.ReturnName() + "." + name
+	case BaseObject:
+		return self.ReturnClass().ReturnName() + "#" + name
+	default:
+		return name
+	}
+}
+
+// Report returns what the profiler has recorded so far.
+func (p *Profiler) Report() *ProfileReport {
+	r := &ProfileReport{Instructions: make(map[string]int)}
+
+	for _, m := range p.methods {
+		copied := *m
+		r.Methods = append(r.Methods, &copied)
+	}
+
+	for name, count := range p.instructions {
+		r.Instructions[name] = count
+	}
+
+	sort.Sort(byExclusiveTime(r.Methods))
+
+	return r
+}
+
+// Method returns the profile of the method with the given name, or nil if it's never called.
+func (r *ProfileReport) Method(name string) *MethodProfile {
+	for _, m := range r.Methods {
+		if m.Name == name {
+			return m
+		}
+	}
+
+	return nil
+}
+
+func (r *ProfileReport) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(fmt.Sprintf("  %-30s %8s %14s %14s\n", "Method", "Calls", "Inclusive", "Exclusive"))
+
+	for _, m := range r.Methods {
+		out.WriteString(fmt.Sprintf("  %-30s %8d %14s %14s\n", m.Name, m.Calls, m.Inclusive, m.Exclusive))
+	}
+
+	out.WriteString("Instructions:\n")
+
+	for _, c := range sortCounts(r.Instructions) {
+		out.WriteString(fmt.Sprintf("  %-22s %8d\n", c.name, c.count))
+	}
+
+	return out.String()
+}
+
+type byExclusiveTime []*MethodProfile
+
+func (m byExclusiveTime) Len() int      { return len(m) }
+func (m byExclusiveTime) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m byExclusiveTime) Less(i, j int) bool {
+	if m[i].Exclusive != m[j].Exclusive {
+		return m[i].Exclusive > m[j].Exclusive
+	}
+
+	return m[i].Name < m[j].Name
+}
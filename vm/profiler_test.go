@@ -0,0 +1,105 @@
+package vm
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// testProfiler returns a profiler whose clock advances a millisecond every time it's read.
+func testProfiler(v *VM) *Profiler {
+	p := NewProfiler(v)
+	var now time.Time
+	p.now = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+
+	return p
+}
+
+func TestProfiler(t *testing.T) {
+	input := `
+	def foo
+	  bar
+	end
+
+	def bar
+	  1
+	end
+
+	class Foo
+	  def self.baz
+	    2
+	  end
+	end
+
+	foo
+	foo
+	Foo.baz
+	`
+
+	v := New()
+	p := testProfiler(v)
+	testEvalWithVM(t, v, input)
+	r := p.Report()
+
+	tests := []struct {
+		name      string
+		calls     int
+		inclusive time.Duration
+		exclusive time.Duration
+	}{
+		{"Object#foo", 2, 6 * time.Millisecond, 4 * time.Millisecond},
+		{"Object#bar", 2, 2 * time.Millisecond, 2 * time.Millisecond},
+		{"Foo.baz", 1, time.Millisecond, time.Millisecond},
+		{"<class:Foo>", 1, time.Millisecond, time.Millisecond},
+		{"<main>", 1, 13 * time.Millisecond, 5 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		m := r.Method(tt.name)
+
+		if m == nil {
+			t.Fatalf("Expect %s to be profiled. got=%s", tt.name, r)
+		}
+
+		if m.Calls != tt.calls || m.Inclusive != tt.inclusive || m.Exclusive != tt.exclusive {
+			t.Fatalf("Expect %s to be called %d times taking %s (%s exclusive). got=%d times taking %s (%s exclusive)",
+				tt.name, tt.calls, tt.inclusive, tt.exclusive, m.Calls, m.Inclusive, m.Exclusive)
+		}
+	}
+
+	if r.Methods[0].Name != "<main>" {
+		t.Fatalf("Expect methods to be sorted by exclusive time. got=%s", r.Methods[0].Name)
+	}
+
+	if r.Instructions[SEND] != 5 {
+		t.Fatalf("Expect 5 send instructions to be counted. got=%d", r.Instructions[SEND])
+	}
+
+	if !strings.Contains(r.String(), "Object#foo") {
+		t.Fatalf("Expect report to list methods. got:\n%s", r)
+	}
+}
+
+func TestProfilerRecursion(t *testing.T) {
+	input := `
+	def f(n)
+	  if n > 0
+	    f(n - 1)
+	  end
+	end
+
+	f(2)
+	`
+
+	v := New()
+	p := testProfiler(v)
+	testEvalWithVM(t, v, input)
+	m := p.Report().Method("Object#f")
+
+	if m.Calls != 3 || m.Inclusive != 5*time.Millisecond || m.Exclusive != 5*time.Millisecond {
+		t.Fatalf("Expect recursive calls to be counted once in inclusive time. got=%d calls, %s inclusive, %s exclusive", m.Calls, m.Inclusive, m.Exclusive)
+	}
+}
@@ -0,0 +1,180 @@
+package vm
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+var (
+	RandomClass *RRandom
+)
+
+type RRandom struct {
+	*BaseClass
+}
+
+// RandomObject is a pseudo random number generator. Generators created with the same seed generate the same numbers,
+// and each generator is independent of the others and of the one `rand` and `srand` use.
+type RandomObject struct {
+	Class     *RRandom
+	seed      int64
+	generator *rand.Rand
+}
+
+func (r *RandomObject) Type() ObjectType {
+	return RANDOM_OBJ
+}
+
+func (r *RandomObject) Inspect() string {
+	return fmt.Sprintf("#<Random:%d>", r.seed)
+}
+
+func (r *RandomObject) ReturnClass() Class {
+	return r.Class
+}
+
+// InitializeRandom returns a generator seeded with seed.
+func InitializeRandom(seed int64) *RandomObject {
+	return &RandomObject{Class: RandomClass, seed: seed, generator: rand.New(rand.NewSource(seed))}
+}
+
+// newSeed returns a seed for generators that aren't given one.
+func newSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// random returns a random number like `rand` and Random#rand: a float between 0 and 1 without an argument,
+// an integer between 0 and n with an integer n, a float between 0 and f with a float f, or an integer in a range.
+// Kernel#rand is lenient and uses the absolute value of n, treats 0 as no argument and returns nil for empty ranges,
+// while Random#rand raises ArgumentError for them.
+func (vm *VM) random(r *RandomObject, args []Object, lenient bool) Object {
+	if len(args) > 1 {
+		return newError("Expect 0 or 1 argument. got=%d", len(args))
+	}
+
+	if len(args) == 0 {
+		return InitializeFloat(r.generator.Float64())
+	}
+
+	switch limit := args[0].(type) {
+	case *IntegerObject:
+		n := vm.integerArgument(limit)
+
+		if lenient && n < 0 {
+			n = -n
+		}
+
+		if lenient && n == 0 {
+			return InitializeFloat(r.generator.Float64())
+		}
+
+		if n <= 0 {
+			vm.raise(ArgumentErrorClass, "invalid argument - %d", limit.Value)
+		}
+
+		return vm.initInteger(r.generator.Intn(n))
+	case *FloatObject:
+		f := limit.Value
+
+		if lenient && f < 0 {
+			f = -f
+		}
+
+		if f <= 0 {
+			vm.raise(ArgumentErrorClass, "invalid argument - %s", limit.Inspect())
+		}
+
+		return InitializeFloat(r.generator.Float64() * f)
+	case *RangeObject:
+		end := vm.integerRange(limit).End
+
+		if limit.Exclusive {
+			end--
+		}
+
+		if end < limit.Start {
+			if lenient {
+				return NULL
+			}
+
+			vm.raise(ArgumentErrorClass, "invalid argument - %s", limit.Inspect())
+		}
+
+		return vm.initInteger(limit.Start + r.generator.Intn(end-limit.Start+1))
+	}
+
+	vm.raise(TypeErrorClass, "wrong argument type %s (expected Integer, Float or Range)", vm.inspectForError(args[0]))
+	return nil
+}
+
+// seedArgument returns the seed given by the optional argument of Random.new and srand, or a new seed without one.
+func (vm *VM) seedArgument(args []Object) int64 {
+	if len(args) == 0 {
+		return newSeed()
+	}
+
+	return int64(vm.integerArgument(args[0]))
+}
+
+var builtinRandomClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				return InitializeRandom(vm.seedArgument(args))
+			}
+		},
+		Name: "new",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return vm.random(vm.defaultRandom, args, false)
+			}
+		},
+		Name: "rand",
+	},
+}
+
+var builtinRandomMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return vm.random(receiver.(*RandomObject), args, false)
+			}
+		},
+		Name: "rand",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initInteger(int(receiver.(*RandomObject).seed))
+			}
+		},
+		Name: "seed",
+	},
+}
+
+func initRandom() {
+	methods := NewEnvironment()
+	classMethods := NewEnvironment()
+
+	for _, m := range builtinRandomMethods {
+		methods.Set(m.Name, m)
+	}
+
+	for _, m := range builtinRandomClassMethods {
+		classMethods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "Random", Methods: methods, ClassMethods: classMethods, Class: ClassClass, SuperClass: ObjectClass}
+	RandomClass = &RRandom{BaseClass: bc}
+}
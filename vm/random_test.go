@@ -0,0 +1,118 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestRandomIsReproducible(t *testing.T) {
+	tests := []string{
+		`
+		srand(42)
+		a = rand(100)
+		b = rand
+		srand(42)
+		if a == rand(100)
+		  b == rand
+		else
+		  false
+		end
+		`,
+		`
+		srand(42)
+		a = rand(1000)
+		srand(42)
+		r = Random.new(42)
+		a == r.rand(1000)
+		`,
+		`
+		r1 = Random.new(7)
+		r2 = Random.new(7)
+		r1.rand(1000) == r2.rand(1000)
+		`,
+		`
+		r1 = Random.new(7)
+		a = r1.rand(1000)
+		rand(1000)
+		r2 = Random.new(7)
+		a == r2.rand(1000)
+		`,
+		`
+		srand(1)
+		srand(2) == 1
+		`,
+		`
+		Random.new(5).seed == 5
+		`,
+	}
+
+	for i, input := range tests {
+		evaluated := testEval(t, input)
+
+		if !testBooleanObject(t, evaluated, true) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestRandomRanges(t *testing.T) {
+	tests := []struct {
+		input string
+		min   float64
+		max   float64
+	}{
+		{`rand`, 0, 1},
+		{`rand(0)`, 0, 1},
+		{`rand(10)`, 0, 9},
+		{`rand(0 - 10)`, 0, 9},
+		{`rand(2.5)`, 0, 2.5},
+		{`rand(3..5)`, 3, 5},
+		{`rand(3...5)`, 3, 4},
+		{`Random.new.rand(10)`, 0, 9},
+		{`Random.rand(10)`, 0, 9},
+	}
+
+	for i, tt := range tests {
+		for j := 0; j < 20; j++ {
+			var value float64
+
+			switch evaluated := testEval(t, tt.input).(type) {
+			case *IntegerObject:
+				value = float64(evaluated.Value)
+			case *FloatObject:
+				value = evaluated.Value
+			default:
+				t.Fatalf("at test case %d: expect a number. got=%T", i, evaluated)
+			}
+
+			if value < tt.min || value > tt.max {
+				t.Fatalf("at test case %d: expect a number between %v and %v. got=%v", i, tt.min, tt.max, value)
+			}
+		}
+	}
+}
+
+func TestRandWithEmptyRange(t *testing.T) {
+	evaluated := testEval(t, `rand(5...5)`)
+	testNullObject(t, evaluated)
+}
+
+func TestRandomErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Random.new(1).rand(0)`, "ArgumentError: invalid argument - 0"},
+		{`Random.new(1).rand(0 - 1)`, "ArgumentError: invalid argument - -1"},
+		{`Random.new(1).rand(5...5)`, "ArgumentError: invalid argument - 5...5"},
+		{`rand("a")`, "TypeError: wrong argument type a (expected Integer, Float or Range)"},
+		{`Random.new("a")`, "TypeError: wrong argument type a (expected Integer)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
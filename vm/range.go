@@ -0,0 +1,302 @@
+package vm
+
+import (
+	"fmt"
+)
+
+var (
+	RangeClass *RRange
+)
+
+type RRange struct {
+	*BaseClass
+}
+
+// RangeObject is a range of integers from Start to End, End is excluded if Exclusive is set.
+// Ranges of dates keep the days of their ends since 1970-01-01 and have Dates set.
+type RangeObject struct {
+	Class     *RRange
+	Start     int
+	End       int
+	Exclusive bool
+	Dates     bool
+}
+
+func (r *RangeObject) Type() ObjectType {
+	return RANGE_OBJ
+}
+
+func (r *RangeObject) Inspect() string {
+	dots := ".."
+
+	if r.Exclusive {
+		dots = "..."
+	}
+
+	if r.Dates {
+		return (&DateObject{day: r.Start}).Inspect() + dots + (&DateObject{day: r.End}).Inspect()
+	}
+
+	return fmt.Sprintf("%d%s%d", r.Start, dots, r.End)
+}
+
+func (r *RangeObject) ReturnClass() Class {
+	return r.Class
+}
+
+func InitializeRange(start, end int, exclusive bool) *RangeObject {
+	return &RangeObject{Start: start, End: end, Exclusive: exclusive, Class: RangeClass}
+}
+
+// last returns the last integer in the range, which is less than Start if the range is empty.
+func (r *RangeObject) last() int {
+	if r.Exclusive {
+		return r.End - 1
+	}
+
+	return r.End
+}
+
+func (r *RangeObject) size() int {
+	if n := r.last() - r.Start + 1; n > 0 {
+		return n
+	}
+
+	return 0
+}
+
+// rangeElement returns the Integer i of the range, or the Date i days after 1970-01-01 if it's a range of dates.
+func (vm *VM) rangeElement(r *RangeObject, i int) Object {
+	if r.Dates {
+		return vm.initDate(i)
+	}
+
+	return vm.initInteger(i)
+}
+
+// integerRange raises TypeError if r isn't a range of integers, which is what indexes and random numbers need.
+func (vm *VM) integerRange(r *RangeObject) *RangeObject {
+	if r.Dates {
+		vm.raise(TypeErrorClass, "no implicit conversion of Date into Integer")
+	}
+
+	return r
+}
+
+// each calls fn with every step-th integer of the range in order.
+func (r *RangeObject) each(step int, fn func(int)) {
+	for i := r.Start; i <= r.last(); i += step {
+		fn(i)
+	}
+}
+
+// rangeIterator returns an Iterator of every step-th integer of the range, which is how each and step work without a block.
+func (vm *VM) rangeIterator(r *RangeObject, step int) Iterator {
+	i := r.Start
+
+	return IteratorFunc(func() (Object, bool) {
+		if i > r.last() {
+			return nil, false
+		}
+
+		n := vm.rangeElement(r, i)
+		i += step
+
+		return n, true
+	})
+}
+
+// newRange returns the range of the values of a range literal, whose ends have to be both integers or both dates.
+func (vm *VM) newRange(start, end Object, exclusive bool) *RangeObject {
+	if s, ok := start.(*DateObject); ok {
+		e, ok := end.(*DateObject)
+
+		if !ok {
+			vm.raise(ArgumentErrorClass, "bad value for range")
+		}
+
+		r := InitializeRange(s.day, e.day, exclusive)
+		r.Dates = true
+
+		return r
+	}
+
+	s, ok := start.(*IntegerObject)
+	e, ok2 := end.(*IntegerObject)
+
+	if !ok || !ok2 {
+		vm.raise(ArgumentErrorClass, "bad value for range")
+	}
+
+	return InitializeRange(vm.integerArgument(s), vm.integerArgument(e), exclusive)
+}
+
+var builtinRangeMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				r := receiver.(*RangeObject)
+
+				if blockFrame == nil {
+					return InitializeEnumerator(vm.rangeIterator(r, 1))
+				}
+
+				r.each(1, func(i int) {
+					vm.builtInMethodYield(blockFrame, vm.rangeElement(r, i))
+				})
+
+				return r
+			}
+		},
+		Name: "each",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				r := receiver.(*RangeObject)
+				step := vm.integerArgument(args[0])
+
+				if step <= 0 {
+					vm.raise(ArgumentErrorClass, "step must be positive. got=%d", step)
+				}
+
+				if blockFrame == nil {
+					return InitializeEnumerator(vm.rangeIterator(r, step))
+				}
+
+				r.each(step, func(i int) {
+					vm.builtInMethodYield(blockFrame, vm.rangeElement(r, i))
+				})
+
+				return r
+			}
+		},
+		Name: "step",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				if blockFrame == nil {
+					vm.raise(LocalJumpErrorClass, "no block given (map)")
+				}
+
+				elems := []Object{}
+
+				r := receiver.(*RangeObject)
+
+				r.each(1, func(i int) {
+					elems = append(elems, vm.builtInMethodYield(blockFrame, vm.rangeElement(r, i)).Target)
+				})
+
+				arr := InitializeArray(elems)
+				vm.track(arr)
+
+				return arr
+			}
+		},
+		Name: "map",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				elems := []Object{}
+
+				r := receiver.(*RangeObject)
+
+				r.each(1, func(i int) {
+					elems = append(elems, vm.rangeElement(r, i))
+				})
+
+				arr := InitializeArray(elems)
+				vm.track(arr)
+
+				return arr
+			}
+		},
+		Name: "to_a",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				r := receiver.(*RangeObject)
+				value, ok := toFloat(args[0])
+
+				if r.Dates {
+					d, isDate := args[0].(*DateObject)
+
+					if !isDate {
+						return FALSE
+					}
+
+					value, ok = float64(d.day), true
+				}
+
+				if !ok {
+					return FALSE
+				}
+
+				if r.Exclusive {
+					return toBooleanObject(float64(r.Start) <= value && value < float64(r.End))
+				}
+
+				return toBooleanObject(float64(r.Start) <= value && value <= float64(r.End))
+			}
+		},
+		Name: "include?",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initInteger(receiver.(*RangeObject).size())
+			}
+		},
+		Name: "size",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return InitializeString(receiver.(*RangeObject).Inspect())
+			}
+		},
+		Name: "to_s",
+	},
+}
+
+func initRange() {
+	methods := NewEnvironment()
+
+	for _, m := range builtinRangeMethods {
+		methods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "Range", Methods: methods, ClassMethods: NewEnvironment(), Class: ClassClass, SuperClass: ObjectClass}
+	RangeClass = &RRange{BaseClass: bc}
+}
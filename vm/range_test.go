@@ -0,0 +1,117 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestRangeIteration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		sum = 0
+		(1..4).each do |i|
+		  sum = sum + i
+		end
+		sum
+		`, 10},
+		{`
+		sum = 0
+		(1...4).each do |i|
+		  sum = sum + i
+		end
+		sum
+		`, 6},
+		{`
+		sum = 0
+		(0..10).step(3) do |i|
+		  sum = sum + i
+		end
+		sum
+		`, 18},
+		{`
+		n = 3
+		(1..n).map do |i|
+		  i * i
+		end[2]
+		`, 9},
+		{`(1..5).to_a[4]`, 5},
+		{`(1..5).to_a.length`, 5},
+		{`(5..1).to_a.length`, 0},
+		{`(1..5).size`, 5},
+		{`(1...5).size`, 4},
+		{`(3..1).size`, 0},
+		{`(1..5).each.to_a[1]`, 2},
+		{`(0..10).step(5).to_a[2]`, 10},
+		{`(1..3).each do |i| i end.size`, 3},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestRangeInclude(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`(1..5).include?(5)`, true},
+		{`(1...5).include?(5)`, false},
+		{`(1..5).include?(0)`, false},
+		{`(1..5).include?(2.5)`, true},
+		{`(1...5).include?(4.5)`, true},
+		{`(1..5).include?("a")`, false},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testBooleanObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestRangeInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(1..5).to_s`, "1..5"},
+		{`a = 2; (a - 1...a + 3).to_s`, "1...5"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testStringObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestRangeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`1.."a"`, "ArgumentError: bad value for range"},
+		{`(1..5).step(0) do |i| i end`, "ArgumentError: step must be positive. got=0"},
+		{`(1..5).step("a")`, "TypeError: wrong argument type a (expected Integer)"},
+		{`(1..5).map`, "LocalJumpError: no block given (map)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package vm
+
+import (
+	"math"
+	"math/big"
+)
+
+var (
+	RationalClass *RRational
+)
+
+type RRational struct {
+	*BaseClass
+}
+
+// RationalObject is an exact fraction like Ruby's Rational. Its value is always reduced, and only the numerator is negative.
+type RationalObject struct {
+	Class *RRational
+	value *big.Rat
+}
+
+func (r *RationalObject) Type() ObjectType {
+	return RATIONAL_OBJ
+}
+
+func (r *RationalObject) Inspect() string {
+	return "(" + r.value.String() + ")"
+}
+
+func (r *RationalObject) ReturnClass() Class {
+	return r.Class
+}
+
+// initRational returns the rational of value, which mustn't be modified afterwards.
+func (vm *VM) initRational(value *big.Rat) *RationalObject {
+	return &RationalObject{Class: RationalClass, value: value}
+}
+
+// rat returns the value of i as a big.Rat.
+func (i *IntegerObject) rat() *big.Rat {
+	return new(big.Rat).SetInt(i.bigValue())
+}
+
+// toRational returns the exact value of an Integer, a Float, a Rational or a BigDecimal. Strings like "1/3" and "0.75" are parsed
+// if parseStrings is set, like Kernel#Rational does.
+func (vm *VM) toRational(o Object, parseStrings bool) (*big.Rat, bool) {
+	switch o := o.(type) {
+	case *IntegerObject:
+		return o.rat(), true
+	case *RationalObject:
+		return o.value, true
+	case *BigDecimalObject:
+		return o.rat(), true
+	case *FloatObject:
+		r := new(big.Rat)
+
+		if r.SetFloat64(o.Value) == nil {
+			vm.raise(FloatDomainErrorClass, "%s", o.Inspect())
+		}
+
+		return r, true
+	case *StringObject:
+		if parseStrings {
+			r, ok := new(big.Rat).SetString(o.Value)
+
+			if !ok {
+				vm.raise(ArgumentErrorClass, "invalid value for convert(): %q", o.Value)
+			}
+
+			return r, true
+		}
+	}
+
+	return nil, false
+}
+
+// quoRational returns x / y, or raises ZeroDivisionError if y is 0.
+func (vm *VM) quoRational(x, y *big.Rat) *big.Rat {
+	if y.Sign() == 0 {
+		vm.raise(ZeroDivisionErrorClass, "divided by 0")
+	}
+
+	return new(big.Rat).Quo(x, y)
+}
+
+// rationalOperator returns a built in method of Rational that combines the receiver with its argument.
+// Integers and Rationals give an exact Rational, while Floats aren't exact so they give a Float.
+func rationalOperator(name string, operate func(vm *VM, x, y *big.Rat) *big.Rat, operateFloat func(x, y float64) float64) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, RationalClass, name)
+
+				if err != nil {
+					return err
+				}
+
+				left := receiver.(*RationalObject).value
+
+				if right, ok := args[0].(*FloatObject); ok {
+					f, _ := left.Float64()
+					return InitializeFloat(operateFloat(f, right.Value))
+				}
+
+				right, ok := vm.toRational(args[0], false)
+
+				if !ok {
+					return wrongTypeError(RationalClass)
+				}
+
+				return vm.initRational(operate(vm, left, right))
+			}
+		},
+		Name: name,
+	}
+}
+
+// rationalComparison returns a built in method of Rational that compares the receiver with a number,
+// compare reports whether the comparison holds for the result of big.Rat's Cmp.
+func rationalComparison(name string, compare func(c int) bool) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, RationalClass, name)
+
+				if err != nil {
+					return err
+				}
+
+				left := receiver.(*RationalObject).value
+
+				// comparisons with NaN are always false
+				if right, ok := args[0].(*FloatObject); ok {
+					f, _ := left.Float64()
+					return toBooleanObject(!math.IsNaN(right.Value) && compare(new(big.Float).SetFloat64(f).Cmp(big.NewFloat(right.Value))))
+				}
+
+				right, ok := vm.toRational(args[0], false)
+
+				if !ok {
+					return wrongTypeError(RationalClass)
+				}
+
+				return toBooleanObject(compare(left.Cmp(right)))
+			}
+		},
+		Name: name,
+	}
+}
+
+// rationalEquality returns == or != of Rational, rationals are never equal to objects that aren't numbers.
+func rationalEquality(name string, equal bool) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, RationalClass, name)
+
+				if err != nil {
+					return err
+				}
+
+				left := receiver.(*RationalObject).value
+
+				if right, ok := args[0].(*FloatObject); ok {
+					f, _ := left.Float64()
+					return toBooleanObject((f == right.Value) == equal)
+				}
+
+				right, ok := vm.toRational(args[0], false)
+
+				return toBooleanObject((ok && left.Cmp(right) == 0) == equal)
+			}
+		},
+		Name: name,
+	}
+}
+
+// rationalRounding returns a built in method of Rational that rounds the receiver to an Integer with round,
+// which is given the quotient and the remainder of the numerator divided by the denominator.
+func rationalRounding(name string, round func(q, r, d *big.Int) *big.Int) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				value := receiver.(*RationalObject).value
+				q, r := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+
+				return vm.initBigInteger(round(q, r, value.Denom()))
+			}
+		},
+		Name: name,
+	}
+}
+
+var builtinRationalMethods = []*BuiltInMethod{
+	rationalOperator("+", func(vm *VM, x, y *big.Rat) *big.Rat {
+		return new(big.Rat).Add(x, y)
+	}, func(x, y float64) float64 { return x + y }),
+	rationalOperator("-", func(vm *VM, x, y *big.Rat) *big.Rat {
+		return new(big.Rat).Sub(x, y)
+	}, func(x, y float64) float64 { return x - y }),
+	rationalOperator("*", func(vm *VM, x, y *big.Rat) *big.Rat {
+		return new(big.Rat).Mul(x, y)
+	}, func(x, y float64) float64 { return x * y }),
+	rationalOperator("/", (*VM).quoRational, func(x, y float64) float64 { return x / y }),
+	rationalComparison(">", func(c int) bool { return c > 0 }),
+	rationalComparison("<", func(c int) bool { return c < 0 }),
+	rationalComparison(">=", func(c int) bool { return c >= 0 }),
+	rationalComparison("<=", func(c int) bool { return c <= 0 }),
+	rationalEquality("==", true),
+	rationalEquality("!=", false),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, RationalClass, "**")
+
+				if err != nil {
+					return err
+				}
+
+				value := receiver.(*RationalObject).value
+				exponent, ok := args[0].(*IntegerObject)
+
+				// only integer powers of a fraction are always fractions
+				if !ok {
+					f, ok := toFloat(args[0])
+
+					if !ok {
+						return wrongTypeError(RationalClass)
+					}
+
+					base, _ := value.Float64()
+
+					return InitializeFloat(math.Pow(base, f))
+				}
+
+				n := vm.integerArgument(exponent)
+
+				if n < 0 {
+					value, n = vm.quoRational(big.NewRat(1, 1), value), -n
+				}
+
+				e := big.NewInt(int64(n))
+				num := new(big.Int).Exp(value.Num(), e, nil)
+				denom := new(big.Int).Exp(value.Denom(), e, nil)
+
+				return vm.initRational(new(big.Rat).SetFrac(num, denom))
+			}
+		},
+		Name: "**",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initBigInteger(new(big.Int).Set(receiver.(*RationalObject).value.Num()))
+			}
+		},
+		Name: "numerator",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initBigInteger(new(big.Int).Set(receiver.(*RationalObject).value.Denom()))
+			}
+		},
+		Name: "denominator",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				value := receiver.(*RationalObject).value
+
+				if value.Sign() >= 0 {
+					return receiver
+				}
+
+				return vm.initRational(new(big.Rat).Neg(value))
+			}
+		},
+		Name: "abs",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return toBooleanObject(receiver.(*RationalObject).value.Sign() == 0)
+			}
+		},
+		Name: "zero?",
+	},
+	// to_i truncates towards zero, floor and ceil round down and up, and round rounds halves away from zero
+	rationalRounding("to_i", func(q, r, d *big.Int) *big.Int {
+		return q
+	}),
+	rationalRounding("floor", func(q, r, d *big.Int) *big.Int {
+		if r.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		}
+
+		return q
+	}),
+	rationalRounding("ceil", func(q, r, d *big.Int) *big.Int {
+		if r.Sign() > 0 {
+			q.Add(q, big.NewInt(1))
+		}
+
+		return q
+	}),
+	rationalRounding("round", func(q, r, d *big.Int) *big.Int {
+		twice := new(big.Int).Lsh(new(big.Int).Abs(r), 1)
+
+		if twice.Cmp(d) >= 0 {
+			q.Add(q, big.NewInt(int64(r.Sign())))
+		}
+
+		return q
+	}),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				f, _ := receiver.(*RationalObject).value.Float64()
+
+				return InitializeFloat(f)
+			}
+		},
+		Name: "to_f",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return receiver
+			}
+		},
+		Name: "to_r",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return InitializeString(receiver.(*RationalObject).value.String())
+			}
+		},
+		Name: "to_s",
+	},
+}
+
+func initRational() {
+	methods := NewEnvironment()
+
+	for _, m := range builtinRationalMethods {
+		methods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "Rational", Methods: methods, ClassMethods: NewEnvironment(), Class: ClassClass, SuperClass: ObjectClass}
+	RationalClass = &RRational{BaseClass: bc}
+}
@@ -0,0 +1,118 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestRational(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Rational(1, 3)`, "(1/3)"},
+		{`Rational(2, 4)`, "(1/2)"},
+		{`Rational(3, 0 - 6)`, "(-1/2)"},
+		{`Rational(5)`, "(5/1)"},
+		{`Rational("1/3")`, "(1/3)"},
+		{`Rational("0.75")`, "(3/4)"},
+		{`Rational(0.5)`, "(1/2)"},
+		{`Rational(1.5, Rational(1, 2))`, "(3/1)"},
+		{`3r`, "(3/1)"},
+		{`1.5r`, "(3/2)"},
+		{`Rational(1, 3) + Rational(1, 6)`, "(1/2)"},
+		{`1r / 3 + 2r / 3`, "(1/1)"},
+		{`Rational(1, 2) - 1`, "(-1/2)"},
+		{`Rational(2, 3) * 3`, "(2/1)"},
+		{`Rational(1, 2) / Rational(1, 4)`, "(2/1)"},
+		{`Rational(2, 3) ** 2`, "(4/9)"},
+		{`Rational(2, 3) ** (0 - 2)`, "(9/4)"},
+		{`1 + Rational(1, 2)`, "(3/2)"},
+		{`1 - Rational(1, 2)`, "(1/2)"},
+		{`2 * Rational(1, 3)`, "(2/3)"},
+		{`1 / Rational(1, 3)`, "(3/1)"},
+		{`3.to_r`, "(3/1)"},
+		{`0.25.to_r`, "(1/4)"},
+		{`Rational(0 - 3, 2).abs`, "(3/2)"},
+		{`Rational(1, 3).to_r`, "(1/3)"},
+		{`Rational(2 ** 64, 3) * 3`, "(18446744073709551616/1)"},
+		{`Rational(1, 3).to_s`, "1/3"},
+		{`Rational(1, 4).to_f`, "0.25"},
+		{`Rational(1, 2) + 0.25`, "0.75"},
+		{`Rational(1, 4) ** 0.5`, "0.5"},
+		{`0.5 + Rational(1, 4)`, "0.75"},
+		{`Rational(1, 4).numerator`, "1"},
+		{`Rational(1, 4).denominator`, "4"},
+		{`Rational(7, 2).to_i`, "3"},
+		{`Rational(0 - 7, 2).to_i`, "-3"},
+		{`Rational(0 - 7, 2).floor`, "-4"},
+		{`Rational(7, 2).ceil`, "4"},
+		{`Rational(5, 2).round`, "3"},
+		{`Rational(0 - 5, 2).round`, "-3"},
+		{`Rational(7, 3).round`, "2"},
+		{`Integer(Rational(7, 2))`, "3"},
+		{`Float(Rational(1, 2))`, "0.5"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Fatalf("at test case %d: expect %s. got=%s", i, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestRationalComparisons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`0.1r + 0.2r == 0.3r`, true},
+		{`Rational(1, 2) == Rational(2, 4)`, true},
+		{`Rational(1, 2) != Rational(1, 3)`, true},
+		{`Rational(4, 2) == 2`, true},
+		{`2 == Rational(4, 2)`, true},
+		{`Rational(1, 2) == 0.5`, true},
+		{`Rational(1, 2) == "1/2"`, false},
+		{`Rational(1, 2) != "1/2"`, true},
+		{`Rational(1, 3) < Rational(1, 2)`, true},
+		{`Rational(1, 3) > 0.3`, true},
+		{`Rational(1, 3) >= 1`, false},
+		{`3 > Rational(5, 2)`, true},
+		{`1 <= Rational(1, 1)`, true},
+		{`Rational(0, 5).zero?`, true},
+		{`h = { a: 1 }
+		h[Rational(1, 2)] = 2
+		h[Rational(2, 4)] == 2`, true},
+		{`Marshal.load(Marshal.dump(Rational(1, 3))) == Rational(1, 3)`, true},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if !testBooleanObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestRationalErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Rational(1, 0)`, "ZeroDivisionError: divided by 0"},
+		{`Rational(1, 2) / 0`, "ZeroDivisionError: divided by 0"},
+		{`1 / Rational(0, 1)`, "ZeroDivisionError: divided by 0"},
+		{`Rational(0, 1) ** (0 - 1)`, "ZeroDivisionError: divided by 0"},
+		{`Rational("one")`, `ArgumentError: invalid value for convert(): "one"`},
+		{`Rational([1])`, "TypeError: can't convert Array:[1] into Rational"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
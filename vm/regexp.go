@@ -0,0 +1,517 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	RegexpClass    *RRegexp
+	MatchDataClass *RMatchData
+)
+
+type RRegexp struct {
+	*BaseClass
+}
+
+// RegexpObject is a compiled regular expression. Patterns use Go's RE2 syntax, plus Ruby's (?<name>...) named groups.
+type RegexpObject struct {
+	Class  *RRegexp
+	Source string
+	regexp *regexp.Regexp
+}
+
+func (r *RegexpObject) Type() ObjectType {
+	return REGEXP_OBJ
+}
+
+func (r *RegexpObject) Inspect() string {
+	return "/" + r.Source + "/"
+}
+
+func (r *RegexpObject) ReturnClass() Class {
+	return r.Class
+}
+
+type RMatchData struct {
+	*BaseClass
+}
+
+// MatchDataObject is the result of a successful match, it holds the matched string and its capture groups.
+type MatchDataObject struct {
+	Class  *RMatchData
+	regexp *RegexpObject
+	target string
+	// indexes are byte offsets of the match and each group in target, like regexp.FindStringSubmatchIndex returns
+	indexes []int
+}
+
+func (m *MatchDataObject) Type() ObjectType {
+	return MATCH_DATA_OBJ
+}
+
+func (m *MatchDataObject) Inspect() string {
+	var out bytes.Buffer
+	names := m.regexp.regexp.SubexpNames()
+
+	out.WriteString("#<MatchData ")
+	out.WriteString(inspectGroup(m.group(0)))
+
+	for i := 1; i < len(names); i++ {
+		name := names[i]
+
+		if name == "" {
+			name = fmt.Sprint(i)
+		}
+
+		out.WriteString(" " + name + ":" + inspectGroup(m.group(i)))
+	}
+
+	out.WriteString(">")
+
+	return out.String()
+}
+
+func (m *MatchDataObject) ReturnClass() Class {
+	return m.Class
+}
+
+func inspectGroup(o Object) string {
+	if s, ok := o.(*StringObject); ok {
+		return fmt.Sprintf("%q", s.Value)
+	}
+
+	return "nil"
+}
+
+// group returns the string group i matched, or nil if the group didn't participate in the match.
+func (m *MatchDataObject) group(i int) Object {
+	if i < 0 || 2*i >= len(m.indexes) || m.indexes[2*i] < 0 {
+		return NULL
+	}
+
+	return InitializeString(m.target[m.indexes[2*i]:m.indexes[2*i+1]])
+}
+
+// groups returns every group the regexp has, starting from the whole match if from is 0.
+func (m *MatchDataObject) groups(from int) []Object {
+	groups := []Object{}
+
+	for i := from; 2*i < len(m.indexes); i++ {
+		groups = append(groups, m.group(i))
+	}
+
+	return groups
+}
+
+// namedGroup returns the string the group named name matched.
+func (vm *VM) namedGroup(m *MatchDataObject, name string) Object {
+	for i, n := range m.regexp.regexp.SubexpNames() {
+		if i > 0 && n == name {
+			return m.group(i)
+		}
+	}
+
+	vm.raise(IndexErrorClass, "undefined group name reference: %s", name)
+	return NULL
+}
+
+// newRegexp compiles pattern, it raises RegexpError if pattern is invalid.
+func (vm *VM) newRegexp(pattern string) *RegexpObject {
+	// Go writes named groups as (?P<name>...)
+	re, err := regexp.Compile(strings.Replace(pattern, "(?<", "(?P<", -1))
+
+	if err != nil {
+		vm.raise(RegexpErrorClass, "%s", err.Error())
+	}
+
+	return &RegexpObject{Class: RegexpClass, Source: pattern, regexp: re}
+}
+
+// toRegexp returns pattern as a regexp, strings are compiled into regexps that match them literally.
+func (vm *VM) toRegexp(pattern Object) *RegexpObject {
+	switch p := pattern.(type) {
+	case *RegexpObject:
+		return p
+	case *StringObject:
+		return vm.newRegexp(regexp.QuoteMeta(p.Value))
+	}
+
+	vm.raise(TypeErrorClass, "wrong argument type %s (expected Regexp)", vm.inspectForError(pattern))
+	return nil
+}
+
+// match returns the first match of r in s, or nil if r doesn't match.
+func (r *RegexpObject) match(s string) Object {
+	indexes := r.regexp.FindStringSubmatchIndex(s)
+
+	if indexes == nil {
+		return NULL
+	}
+
+	return &MatchDataObject{Class: MatchDataClass, regexp: r, target: s, indexes: indexes}
+}
+
+// matchIndex returns the index of the character the first match of r in s starts at, or nil if r doesn't match.
+func (vm *VM) matchIndex(r *RegexpObject, s string) Object {
+	loc := r.regexp.FindStringIndex(s)
+
+	if loc == nil {
+		return NULL
+	}
+
+	return vm.initInteger(utf8.RuneCountInString(s[:loc[0]]))
+}
+
+// scan returns every match of r in s. If r has groups, each match is an array of its groups instead.
+func (vm *VM) scan(r *RegexpObject, s string) *ArrayObject {
+	results := []Object{}
+
+	for _, indexes := range r.regexp.FindAllStringSubmatchIndex(s, -1) {
+		m := &MatchDataObject{regexp: r, target: s, indexes: indexes}
+
+		if len(indexes) == 2 {
+			results = append(results, m.group(0))
+			continue
+		}
+
+		groups := InitializeArray(m.groups(1))
+		vm.track(groups)
+		results = append(results, groups)
+	}
+
+	arr := InitializeArray(results)
+	vm.track(arr)
+
+	return arr
+}
+
+// substitute replaces the first match of r in s, or every match if global is true. Each match is replaced with
+// replacement, or with what the block returns for the matched string if blockFrame isn't nil.
+func (vm *VM) substitute(s string, r *RegexpObject, replacement Object, blockFrame *CallFrame, global bool) *StringObject {
+	n := 1
+
+	if global {
+		n = -1
+	}
+
+	var repl string
+
+	if blockFrame == nil {
+		repl = vm.stringArgument(replacement)
+	}
+
+	var out bytes.Buffer
+	last := 0
+
+	for _, indexes := range r.regexp.FindAllStringSubmatchIndex(s, n) {
+		m := &MatchDataObject{Class: MatchDataClass, regexp: r, target: s, indexes: indexes}
+		out.WriteString(s[last:indexes[0]])
+
+		if blockFrame != nil {
+			out.WriteString(vm.toS(vm.builtInMethodYield(blockFrame, m.group(0)).Target))
+		} else {
+			out.WriteString(m.expand(repl))
+		}
+
+		last = indexes[1]
+	}
+
+	out.WriteString(s[last:])
+
+	return InitializeString(out.String())
+}
+
+// expand returns repl with Ruby's backreferences replaced by groups of m: \0 and \& are the whole match,
+// \1 to \9 are numbered groups, \k<name> is a named group and \\ is a backslash.
+func (m *MatchDataObject) expand(repl string) string {
+	var out bytes.Buffer
+
+	for i := 0; i < len(repl); i++ {
+		c := repl[i]
+
+		if c != '\\' || i+1 == len(repl) {
+			out.WriteByte(c)
+			continue
+		}
+
+		next := repl[i+1]
+
+		switch {
+		case next >= '0' && next <= '9':
+			out.WriteString(groupString(m.group(int(next - '0'))))
+			i++
+		case next == '&':
+			out.WriteString(groupString(m.group(0)))
+			i++
+		case next == '\\':
+			out.WriteByte('\\')
+			i++
+		case next == 'k' && strings.HasPrefix(repl[i+2:], "<") && strings.Contains(repl[i+2:], ">"):
+			end := i + 2 + strings.Index(repl[i+2:], ">")
+			name := repl[i+3 : end]
+
+			for j, n := range m.regexp.regexp.SubexpNames() {
+				if j > 0 && n == name {
+					out.WriteString(groupString(m.group(j)))
+				}
+			}
+
+			i = end
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	return out.String()
+}
+
+// groupString returns the string a group matched, groups that didn't participate in the match are empty.
+func groupString(o Object) string {
+	if s, ok := o.(*StringObject); ok {
+		return s.Value
+	}
+
+	return ""
+}
+
+var builtinRegexpClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				if r, ok := args[0].(*RegexpObject); ok {
+					return vm.newRegexp(r.Source)
+				}
+
+				return vm.newRegexp(vm.stringArgument(args[0]))
+			}
+		},
+		Name: "new",
+	},
+}
+
+var builtinRegexpMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return receiver.(*RegexpObject).match(vm.stringArgument(args[0]))
+			}
+		},
+		Name: "match",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return toBooleanObject(receiver.(*RegexpObject).regexp.MatchString(vm.stringArgument(args[0])))
+			}
+		},
+		Name: "match?",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return vm.matchIndex(receiver.(*RegexpObject), vm.stringArgument(args[0]))
+			}
+		},
+		Name: "=~",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return InitializeString(receiver.(*RegexpObject).Source)
+			}
+		},
+		Name: "source",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return InitializeString(receiver.(*RegexpObject).Inspect())
+			}
+		},
+		Name: "to_s",
+	},
+}
+
+var builtinMatchDataMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				m := receiver.(*MatchDataObject)
+
+				switch key := args[0].(type) {
+				case *IntegerObject:
+					return m.group(vm.integerArgument(key))
+				case *StringObject:
+					return vm.namedGroup(m, key.Value)
+				case *SymbolObject:
+					return vm.namedGroup(m, key.Name)
+				}
+
+				vm.raise(TypeErrorClass, "wrong argument type %s (expected Integer or String)", vm.inspectForError(args[0]))
+				return NULL
+			}
+		},
+		Name: "[]",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				arr := InitializeArray(receiver.(*MatchDataObject).groups(0))
+				vm.track(arr)
+
+				return arr
+			}
+		},
+		Name: "to_a",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				arr := InitializeArray(receiver.(*MatchDataObject).groups(1))
+				vm.track(arr)
+
+				return arr
+			}
+		},
+		Name: "captures",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				m := receiver.(*MatchDataObject)
+				captures := map[string]Object{}
+
+				for i, name := range m.regexp.regexp.SubexpNames() {
+					if i > 0 && name != "" {
+						captures[name] = m.group(i)
+					}
+				}
+
+				hash := InitializeHash(captures)
+				vm.track(hash)
+
+				return hash
+			}
+		},
+		Name: "named_captures",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				m := receiver.(*MatchDataObject)
+				return InitializeString(m.target[:m.indexes[0]])
+			}
+		},
+		Name: "pre_match",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				m := receiver.(*MatchDataObject)
+				return InitializeString(m.target[m.indexes[1]:])
+			}
+		},
+		Name: "post_match",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initInteger(len(receiver.(*MatchDataObject).indexes) / 2)
+			}
+		},
+		Name: "size",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return receiver.(*MatchDataObject).group(0)
+			}
+		},
+		Name: "to_s",
+	},
+}
+
+func initRegexp() {
+	methods := NewEnvironment()
+	classMethods := NewEnvironment()
+
+	for _, m := range builtinRegexpMethods {
+		methods.Set(m.Name, m)
+	}
+
+	for _, m := range builtinRegexpClassMethods {
+		classMethods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "Regexp", Methods: methods, ClassMethods: classMethods, Class: ClassClass, SuperClass: ObjectClass}
+	RegexpClass = &RRegexp{BaseClass: bc}
+
+	methods = NewEnvironment()
+
+	for _, m := range builtinMatchDataMethods {
+		methods.Set(m.Name, m)
+	}
+
+	bc = &BaseClass{Name: "MatchData", Methods: methods, ClassMethods: NewEnvironment(), Class: ClassClass, SuperClass: ObjectClass}
+	MatchDataClass = &RMatchData{BaseClass: bc}
+}
@@ -0,0 +1,111 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestRegexpMatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Regexp.new("b+").match("abbbc").to_s`, "bbb"},
+		{`Regexp.new("(\d+)-(\d+)").match("tel: 555-1234")[2]`, "1234"},
+		{`Regexp.new("(\d+)-(\d+)").match("tel: 555-1234")[0]`, "555-1234"},
+		{`Regexp.new("(?<year>\d+)/(?<month>\d+)").match("on 2017/07")["month"]`, "07"},
+		{`Regexp.new("(?<year>\d+)/(?<month>\d+)").match("on 2017/07")["year".to_sym]`, "2017"},
+		{`Regexp.new("(?P<year>\d+)").match("on 2017")["year"]`, "2017"},
+		{`Regexp.new("\d+").match("abc 123 def").pre_match`, "abc "},
+		{`Regexp.new("\d+").match("abc 123 def").post_match`, " def"},
+		{`Regexp.new("a(b)?c").match("xac").inspect`, "#<MatchData \"ac\" 1:nil>"},
+		{`Regexp.new("(?<x>a)(b)").match("ab").inspect`, "#<MatchData \"ab\" x:\"a\" 2:\"b\">"},
+		{`Regexp.new("a.c").source`, "a.c"},
+		{`Regexp.new("a.c").to_s`, "/a.c/"},
+		{`Regexp.new(Regexp.new("a+")).source`, "a+"},
+		{`"hello world".match(Regexp.new("o (w)"))[1]`, "w"},
+		{`"1+1=2".match("1+1").to_s`, "1+1"},
+		{`"a1 b22 c333".scan(Regexp.new("\d+"))[2]`, "333"},
+		{`"a1 b22 c333".scan(Regexp.new("([a-z])(\d+)"))[1][1]`, "22"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testStringObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestRegexpMatchIndex(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Regexp.new("c") =~ "abc"`, 2},
+		{`"abc" =~ Regexp.new("b")`, 1},
+		{`"日本語abc" =~ Regexp.new("abc")`, 3},
+		{`Regexp.new("z") =~ "abc"`, nil},
+		{`Regexp.new("z").match("abc")`, nil},
+		{`Regexp.new("a(b)?c").match("ac").captures.length`, 1},
+		{`Regexp.new("(a)(b)").match("ab").to_a.length`, 3},
+		{`Regexp.new("(a)(b)").match("ab").size`, 3},
+		{`Regexp.new("(?<a>x)(?<b>y)?").match("x").named_captures.length`, 2},
+		{`"a.b.c".scan(".").length`, 2},
+		{`"abc".scan(Regexp.new("z")).length`, 0},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			if !testIntegerObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		default:
+			if !testNullObject(t, evaluated) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestRegexpPredicates(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`Regexp.new("^\w+$").match?("hello")`, true},
+		{`Regexp.new("^\w+$").match?("hello world")`, false},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testBooleanObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestRegexpErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Regexp.new("(a")`, "RegexpError: error parsing regexp: missing closing ): `(a`"},
+		{`Regexp.new(1)`, "TypeError: wrong argument type 1 (expected String)"},
+		{`"abc".match(1)`, "TypeError: wrong argument type 1 (expected Regexp)"},
+		{`"abc" =~ "b"`, "TypeError: wrong argument type b (expected Regexp)"},
+		{`Regexp.new("(a)").match("a")["b"]`, "IndexError: undefined group name reference: b"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
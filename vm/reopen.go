@@ -0,0 +1,143 @@
+package vm
+
+// reopenedClass holds the methods a VM's program defines on a built in class, like with
+// `class String; def shout; upcase + "!"; end; end`. Built in classes are shared by every VM, so the methods are
+// kept by the VM instead, and methods it looks up on the class or its subclasses find them before the built in ones.
+type reopenedClass struct {
+	// owner is the class the methods are defined in, super continues from its superclass
+	owner        *RClass
+	methods      *Environment
+	classMethods *Environment
+}
+
+func init() {
+	// singleton classes are the owners of class methods, the shared ones are created here so VMs running at the same
+	// time don't create them while reopening built in classes
+	for _, c := range sharedClasses() {
+		if base, ok := classBase(c); ok {
+			base.ensureSingletonClass()
+		}
+	}
+}
+
+// reopen returns the VM's methods of c if c is a built in class, or nil if it's a class of the VM.
+func (vm *VM) reopen(c Class) *reopenedClass {
+	if !isSharedClass(c) {
+		return nil
+	}
+
+	base, _ := classBase(c)
+
+	if r, ok := vm.reopened[base]; ok {
+		return r
+	}
+
+	owner, ok := c.(*RClass)
+
+	if !ok {
+		owner = &RClass{BaseClass: base}
+	}
+
+	if vm.reopened == nil {
+		vm.reopened = make(map[*BaseClass]*reopenedClass)
+	}
+
+	r := &reopenedClass{owner: owner, methods: NewEnvironment(), classMethods: NewEnvironment()}
+	vm.reopened[base] = r
+
+	return r
+}
+
+// defineMethod makes method an instance method of c
+func (vm *VM) defineMethod(c Class, method *Method) {
+	if r := vm.reopen(c); r != nil {
+		method.Owner = r.owner
+		r.methods.Set(method.Name, method)
+		return
+	}
+
+	class, ok := c.(*RClass)
+
+	if !ok {
+		vm.raise(TypeErrorClass, "can't define method on %s", c.Inspect())
+	}
+
+	method.Owner = class
+	class.Methods.Set(method.Name, method)
+}
+
+// defineClassMethod makes method a class method of c
+func (vm *VM) defineClassMethod(c Class, method *Method) {
+	if r := vm.reopen(c); r != nil {
+		base, _ := classBase(c)
+		method.Owner = base.singletonClass
+		r.classMethods.Set(method.Name, method)
+		return
+	}
+
+	class, ok := c.(*RClass)
+
+	if !ok {
+		vm.raise(TypeErrorClass, "can't define singleton method on %s", c.Inspect())
+	}
+
+	class.SetSingletonMethod(method.Name, method)
+}
+
+// lookupInstanceMethod is c.LookupInstanceMethod, with the methods the VM's program defined on built in classes.
+func (vm *VM) lookupInstanceMethod(c Class, methodName string) Object {
+	if method := vm.reopenedMethod(c, methodName, false); method != nil {
+		return method
+	}
+
+	return c.LookupInstanceMethod(methodName)
+}
+
+// lookupClassMethod is c.LookupClassMethod, with the methods the VM's program defined on built in classes.
+func (vm *VM) lookupClassMethod(c Class, methodName string) Object {
+	if method := vm.reopenedMethod(c, methodName, true); method != nil {
+		return method
+	}
+
+	return c.LookupClassMethod(methodName)
+}
+
+// reopenedMethod returns the method of methodName the VM's program defined on c or a built in class it inherits.
+// It returns nil if there's none, or if a class in between has a method of methodName of its own, which comes first.
+func (vm *VM) reopenedMethod(c Class, methodName string, classMethod bool) Object {
+	if len(vm.reopened) == 0 {
+		return nil
+	}
+
+	base, ok := classBase(c)
+
+	for ok {
+		if r, reopened := vm.reopened[base]; reopened {
+			if method, found := r.table(classMethod).GetCurrent(methodName); found {
+				return method
+			}
+		}
+
+		own := base.Methods
+
+		if classMethod {
+			own = base.ClassMethods
+		}
+
+		if _, found := own.GetCurrent(methodName); found || base.SuperClass == nil {
+			return nil
+		}
+
+		base = base.SuperClass.BaseClass
+	}
+
+	return nil
+}
+
+func (r *reopenedClass) table(classMethods bool) *Environment {
+	if classMethods {
+		return r.classMethods
+	}
+
+	return r.methods
+}
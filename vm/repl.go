@@ -0,0 +1,300 @@
+package vm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/st0012/Rooby/ast"
+	"github.com/st0012/Rooby/bytecode"
+	"github.com/st0012/Rooby/lexer"
+	"github.com/st0012/Rooby/parser"
+)
+
+// replFileName is the file name of code evaluated by a REPL, it's what backtraces and `__FILE__` show.
+const replFileName = "(repl)"
+
+// replResult is the local variable that holds the value of the last piece a REPL evaluated, like irb's.
+const replResult = "_"
+
+// REPL evaluates a program one piece at a time, like an interactive session does. Every piece runs at the top level
+// of the same program in one call frame, so later pieces can use local variables, methods and classes of earlier ones.
+type REPL struct {
+	vm    *VM
+	frame *CallFrame
+	// locals are the names of the top level local variables, in the order of their indexes
+	locals []string
+}
+
+// NewREPL returns a REPL that evaluates pieces of a program in v.
+func NewREPL(v *VM) *REPL {
+	frame := NewCallFrame(nil)
+	frame.Self = v.MainObj
+
+	return &REPL{vm: v, frame: frame}
+}
+
+// Eval runs source and returns what the value of its last expression's inspect method returns. Syntax errors and
+// uncaught exceptions are returned like Exec returns them, variables and definitions made before them are kept.
+// The value is also assigned to `_` unless source fails.
+func (r *REPL) Eval(source string) (inspected string, err error) {
+	v := r.vm
+	cfp, sp := v.CFP, v.SP
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = v.errorFromPanic(p)
+			v.unwindTo(cfp, sp)
+		}
+	}()
+
+	r.frame.InstructionSet = r.compile(source)
+	r.frame.PC = 0
+	r.frame.catchSP = nil
+	v.CallFrameStack.Push(r.frame)
+	v.startFromTopFrame()
+
+	var result Object = NULL
+
+	if v.SP > sp {
+		result = v.Stack.Top().Target
+	}
+
+	v.Stack.truncate(sp)
+	r.frame.insertLCL(r.localIndex(replResult), 0, result)
+
+	return v.inspect(result), nil
+}
+
+// Incomplete returns true if source ends in the middle of a statement, like a def without its end, so more lines
+// should be read before it's evaluated. Input the parser can't handle isn't incomplete, Eval reports its error.
+func (r *REPL) Incomplete(source string) (incomplete bool) {
+	defer func() {
+		if recover() != nil {
+			incomplete = false
+		}
+	}()
+
+	p := parser.New(lexer.New(source))
+	p.ParseProgram()
+
+	return p.Incomplete()
+}
+
+// localIndex returns the index of the top level local variable name, adding it if earlier pieces didn't assign it.
+func (r *REPL) localIndex(name string) int {
+	for i, local := range r.locals {
+		if local == name {
+			return i
+		}
+	}
+
+	r.locals = append(r.locals, name)
+
+	return len(r.locals) - 1
+}
+
+// Complete returns the names that can complete the word at the end of line, and the part of line before that word.
+// A word after a dot is completed with the methods of its receiver, which is only resolved if it's a local variable,
+// a constant or self since completing shouldn't run code. Other words are completed with local variables, constants
+// and the methods of self.
+func (r *REPL) Complete(line string) (prefix string, candidates []string) {
+	start := len(line)
+
+	for start > 0 && isCompletionChar(line[start-1]) {
+		start--
+	}
+
+	word := line[start:]
+	var names []string
+
+	if i := strings.LastIndex(word, "."); i >= 0 {
+		prefix, word = line[:start+i+1], word[i+1:]
+
+		if receiver, ok := r.receiver(line[start : start+i]); ok {
+			names = r.vm.methodNames(receiver, false)
+		}
+	} else {
+		prefix = line[:start]
+
+		for i, local := range r.locals {
+			if r.frame.getLCL(i, 0) != nil {
+				names = append(names, local)
+			}
+		}
+
+		for name := range r.vm.Constants {
+			// scoped constants are only completed once their scope is typed
+			if !strings.Contains(name, "::") || strings.Contains(word, "::") {
+				names = append(names, name)
+			}
+		}
+
+		names = append(names, r.vm.methodNames(r.frame.Self, true)...)
+	}
+
+	seen := make(map[string]bool)
+
+	for _, name := range names {
+		if strings.HasPrefix(name, word) && !seen[name] {
+			seen[name] = true
+			candidates = append(candidates, name)
+		}
+	}
+
+	sort.Strings(candidates)
+
+	return prefix, candidates
+}
+
+// receiver returns the value of name if it's a local variable, a constant or self.
+func (r *REPL) receiver(name string) (BaseObject, bool) {
+	var value Object
+
+	if name == "self" {
+		value = r.frame.Self
+	}
+
+	for i, local := range r.locals {
+		if local == name {
+			if p := r.frame.getLCL(i, 0); p != nil {
+				value = p.Target
+			}
+		}
+	}
+
+	if p, ok := r.vm.Constants[name]; ok && value == nil {
+		value = p.Target
+	}
+
+	receiver, ok := value.(BaseObject)
+
+	return receiver, ok
+}
+
+// isCompletionChar returns true if c can be part of a word Complete completes, which includes scopes and receivers.
+func isCompletionChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("_?!:.", c) >= 0
+}
+
+// methodNames returns the names of the methods receiver responds to, private ones are only included if private is true.
+func (vm *VM) methodNames(receiver BaseObject, private bool) []string {
+	var start *BaseClass
+
+	switch receiver := receiver.(type) {
+	case *RObject:
+		start = receiver.methodClass().BaseClass
+	case Class:
+		start, _ = classBase(receiver)
+	default:
+		start, _ = classBase(receiver.ReturnClass())
+	}
+
+	// Every name defined along the way is a candidate, lookupMethod decides which of them receiver really has
+	visited := make(map[*BaseClass]bool)
+	queue := []*BaseClass{start, vm.objectClass.BaseClass}
+	var candidates []string
+
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+
+		if c == nil || visited[c] {
+			continue
+		}
+
+		visited[c] = true
+
+		for _, env := range []*Environment{c.Methods, c.ClassMethods} {
+			for ; env != nil; env = env.outer {
+				candidates = append(candidates, env.names...)
+			}
+		}
+
+		if r, ok := vm.reopened[c]; ok {
+			candidates = append(candidates, r.methods.names...)
+			candidates = append(candidates, r.classMethods.names...)
+		}
+
+		for _, next := range []*RClass{c.SuperClass, c.Class} {
+			if next != nil {
+				queue = append(queue, next.BaseClass)
+			}
+		}
+	}
+
+	var names []string
+
+	for _, name := range candidates {
+		switch m := vm.lookupMethod(receiver, name).(type) {
+		case nil:
+		case *Method:
+			if !m.Private || private {
+				names = append(names, name)
+			}
+		default:
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// classBase returns the BaseClass of a class, which every built in and defined class has.
+func classBase(c Class) (*BaseClass, bool) {
+	base, ok := c.(interface {
+		baseClass() *BaseClass
+	})
+
+	if !ok {
+		return nil, false
+	}
+
+	return base.baseClass(), true
+}
+
+// parse returns the program in source and its syntax errors. Input the parser panics on is a syntax error too,
+// so it doesn't end the session.
+func parse(source string) (program *ast.Program, errors []string) {
+	defer func() {
+		if e := recover(); e != nil {
+			errors = []string{fmt.Sprintf("can't parse input: %v", e)}
+		}
+	}()
+
+	p := parser.New(lexer.New(source))
+	program = p.ParseProgram()
+
+	return program, p.Errors()
+}
+
+// compile is like VM.compile, but the program's top level starts with the local variables of earlier pieces.
+func (r *REPL) compile(source string) *InstructionSet {
+	program, errors := parse(source)
+
+	if len(errors) > 0 {
+		r.vm.raise(SyntaxErrorClass, "%s", strings.Join(errors, "\n"))
+	}
+
+	// Assignments are statements without values, a piece that ends with one shows the variable it assigned
+	if n := len(program.Statements); n > 0 {
+		if stmt, ok := program.Statements[n-1].(*ast.AssignStatement); ok {
+			if name, ok := stmt.Name.(ast.Expression); ok {
+				program.Statements = append(program.Statements, &ast.ExpressionStatement{Token: stmt.Token, Expression: name})
+			}
+		}
+	}
+
+	g := bytecode.NewGenerator(program)
+	g.SetFileName(replFileName)
+	g.SetLocals(r.locals)
+	bytecodes := g.GenerateByteCode(program)
+	r.locals = g.Locals()
+
+	bp := NewBytecodeParser()
+	bp.VM = r.vm
+	bp.labels = newLabelTable()
+	bp.Parse(bytecodes)
+
+	return bp.labels.LabelTable[PROGRAM]["ProgramStart"][0]
+}
@@ -0,0 +1,197 @@
+package vm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestREPL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`x = 10`, "10"},
+		{`x + 1`, "11"},
+		{`def add(a)
+		  a + x
+		end`, "null"},
+		{`class Counter
+		  def initialize
+		    @count = 0
+		  end
+
+		  def inc
+		    @count = @count + 1
+		    @count
+		  end
+		end`, "null"},
+		{`c = Counter.new`, "<Instance of: Counter>"},
+		{`c.inc
+		c.inc`, "2"},
+		{`y = [1, 2, 3].map do |i|
+		  i * x
+		end`, "Array:[10, 20, 30]"},
+		{`y.length`, "3"},
+		{`f = Proc.new do |i|
+		  i + x
+		end`, "<Proc>"},
+		{`x = 20`, "20"},
+		{`f.call(1)`, "21"},
+		{`"rooby"`, "rooby"},
+		{`__FILE__`, replFileName},
+		{``, "null"},
+	}
+
+	r := NewREPL(New())
+
+	for i, tt := range tests {
+		inspected, err := r.Eval(tt.input)
+
+		if err != nil {
+			t.Fatalf("at test case %d: unexpected error: %s", i, err)
+		}
+
+		if inspected != tt.expected {
+			t.Fatalf("at test case %d: expect %s. got=%s", i, tt.expected, inspected)
+		}
+	}
+}
+
+func TestREPLKeepsStateAfterErrors(t *testing.T) {
+	r := NewREPL(New())
+
+	if _, err := r.Eval(`x = 1`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err := r.Eval(`y = 2
+	undefined_method`)
+
+	if err == nil || err.Error() != "NoMethodError: undefined method `undefined_method' for main" {
+		t.Fatalf("expect NoMethodError. got=%v", err)
+	}
+
+	for _, input := range []string{`1 + )`, `1(2)`} {
+		if _, err = r.Eval(input); err == nil || !strings.HasPrefix(err.Error(), "SyntaxError:") {
+			t.Fatalf("expect SyntaxError. got=%v", err)
+		}
+	}
+
+	inspected, err := r.Eval(`x + y`)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if inspected != "3" {
+		t.Fatalf("expect 3. got=%s", inspected)
+	}
+}
+
+func TestREPLOutput(t *testing.T) {
+	var buf bytes.Buffer
+	v := New()
+	v.SetStdout(&buf)
+	r := NewREPL(v)
+
+	inspected, err := r.Eval(`puts("hello")`)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if buf.String() != "hello\n" || inspected != "null" {
+		t.Fatalf("expect hello to be printed and null to be returned. got=%q and %s", buf.String(), inspected)
+	}
+}
+
+func TestREPLResultVariable(t *testing.T) {
+	r := NewREPL(New())
+
+	for _, input := range []string{`[1, 2, 3]`, `_.length`, `_ * 2`} {
+		if _, err := r.Eval(input); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	// failed pieces don't change it
+	r.Eval(`undefined_method`)
+	inspected, err := r.Eval(`_`)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if inspected != "6" {
+		t.Fatalf("expect 6. got=%s", inspected)
+	}
+}
+
+func TestREPLIncomplete(t *testing.T) {
+	r := NewREPL(New())
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`def foo`, true},
+		{`[1, 2].each do |i|`, true},
+		{`def foo
+		end`, false},
+		{`1 + )`, false},
+		{`1(2)`, false},
+	}
+
+	for i, tt := range tests {
+		if r.Incomplete(tt.input) != tt.expected {
+			t.Fatalf("at test case %d: expect Incomplete to return %t", i, tt.expected)
+		}
+	}
+}
+
+func TestREPLComplete(t *testing.T) {
+	r := NewREPL(New())
+
+	for _, input := range []string{`greeting = "hello"`, `def greet
+	  greeting
+	end`, `class Greeter
+	  def greet_loudly
+	  end
+	  def self.greeter?
+	  end
+	end`, `class String
+	  def upcase_first
+	  end
+	end`} {
+		if _, err := r.Eval(input); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	tests := []struct {
+		line       string
+		prefix     string
+		candidates string
+	}{
+		{`gre`, ``, `greet greeting`},
+		{`puts(gre`, `puts(`, `greet greeting`},
+		{`Gree`, ``, `Greeter`},
+		{`greeting.up`, `greeting.`, `upcase upcase_first`},
+		{`x = greeting.si`, `x = greeting.`, `size`},
+		{`Greeter.gree`, `Greeter.`, `greeter?`},
+		{`Greeter.new.gre`, `Greeter.new.`, ``},
+		{`self.gre`, `self.`, ``},
+		{`Encoding::UTF_16`, ``, `Encoding::UTF_16BE Encoding::UTF_16LE`},
+		{`Encod`, ``, `Encoding EncodingError`},
+		{`_.up`, `_.`, ``},
+		{`nothing_like_this`, ``, ``},
+	}
+
+	for i, tt := range tests {
+		prefix, candidates := r.Complete(tt.line)
+
+		if prefix != tt.prefix || strings.Join(candidates, " ") != tt.candidates {
+			t.Fatalf("at test case %d: expect %q and %q. got=%q and %q", i, tt.prefix, tt.candidates, prefix, strings.Join(candidates, " "))
+		}
+	}
+}
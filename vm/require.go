@@ -0,0 +1,147 @@
+package vm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sourceExtension is added to names passed to require that don't have an extension.
+const sourceExtension = ".ro"
+
+// AddLoadPath appends dir to `$LOAD_PATH`, the directories require searches for files.
+func (vm *VM) AddLoadPath(dir string) {
+	paths := vm.globalArray("$LOAD_PATH")
+	paths.Elements = append(paths.Elements, InitializeString(dir))
+}
+
+// globalArray returns the array global variable name refers to, an empty one is assigned if it isn't set yet.
+func (vm *VM) globalArray(name string) *ArrayObject {
+	p, ok := vm.Globals[name]
+
+	if !ok {
+		arr := InitializeArray([]Object{})
+		vm.SetGlobal(name, arr)
+		return arr
+	}
+
+	arr, ok := p.Target.(*ArrayObject)
+
+	if !ok {
+		vm.raise(TypeErrorClass, "%s must be an array. got=%s", name, vm.inspectForError(p.Target))
+	}
+
+	return arr
+}
+
+// require loads the file name refers to unless it's already loaded, and returns whether it's loaded this time.
+// Paths starting with ./ or ../ are relative to the working directory, other relative paths are searched in `$LOAD_PATH`.
+func (vm *VM) require(name string) bool {
+	file := withSourceExtension(name)
+
+	if filepath.IsAbs(file) || strings.HasPrefix(file, "./") || strings.HasPrefix(file, "../") {
+		return vm.loadFeature(name, file)
+	}
+
+	for _, dir := range vm.globalArray("$LOAD_PATH").Elements {
+		d, ok := dir.(*StringObject)
+
+		if !ok {
+			continue
+		}
+
+		path := filepath.Join(d.Value, file)
+
+		if isFile(path) {
+			return vm.loadFeature(name, path)
+		}
+	}
+
+	vm.raise(LoadErrorClass, "cannot load such file -- %s", name)
+	return false
+}
+
+// requireRelative is like require, but resolves name against the directory of the file cf runs.
+func (vm *VM) requireRelative(name string, cf *CallFrame) bool {
+	file := cf.InstructionSet.File
+
+	if file == "" || file == evalFileName {
+		vm.raise(LoadErrorClass, "cannot infer basepath")
+	}
+
+	path := withSourceExtension(name)
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(file), path)
+	}
+
+	return vm.loadFeature(name, path)
+}
+
+// loadFeature runs the file at path at top level, unless it's in `$LOADED_FEATURES` already.
+// The file is added to `$LOADED_FEATURES` before it runs, so files requiring each other don't load each other forever.
+func (vm *VM) loadFeature(name, path string) bool {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	features := vm.globalArray("$LOADED_FEATURES")
+
+	for _, feature := range features.Elements {
+		if f, ok := feature.(*StringObject); ok && f.Value == path {
+			return false
+		}
+	}
+
+	source, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		vm.raise(LoadErrorClass, "cannot load such file -- %s", name)
+	}
+
+	vm.checkFrozen(features)
+	features.Elements = append(features.Elements, InitializeString(path))
+
+	defer func() {
+		if r := recover(); r != nil {
+			features.Elements = removeFeature(features.Elements, path)
+			panic(r)
+		}
+	}()
+
+	is := vm.compile(path, string(source))
+	sp := vm.SP
+
+	c := NewCallFrame(is)
+	c.Self = vm.MainObj
+	vm.CallFrameStack.Push(c)
+	vm.startFromTopFrame()
+	vm.Stack.truncate(sp)
+
+	return true
+}
+
+// removeFeature removes path from features, so a file that failed to load can be required again.
+func removeFeature(features []Object, path string) []Object {
+	for i, feature := range features {
+		if f, ok := feature.(*StringObject); ok && f.Value == path {
+			return append(features[:i], features[i+1:]...)
+		}
+	}
+
+	return features
+}
+
+func withSourceExtension(name string) string {
+	if filepath.Ext(name) == "" {
+		return name + sourceExtension
+	}
+
+	return name
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,158 @@
+package vm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testLibrary writes files into a temporary directory and returns its path.
+func testLibrary(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "rooby-require")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestRequire(t *testing.T) {
+	dir := testLibrary(t, map[string]string{
+		"counter.ro": `
+		class Counter
+		  def initialize(n)
+		    @n = n
+		  end
+
+		  def next
+		    @n + 1
+		  end
+		end
+		`,
+		"loads.ro": `
+		$loads = $loads + 1
+		`,
+		"math/double.ro": `
+		def double(n)
+		  n * 2
+		end
+		`,
+	})
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		require("counter")
+		Counter.new(1).next
+		`, 2},
+		{`
+		require("math/double.ro")
+		double(4)
+		`, 8},
+		{`
+		$loads = 0
+		require("loads")
+		require("loads")
+		$loads
+		`, 1},
+		{`
+		require("counter")
+		$LOADED_FEATURES.length
+		`, 1},
+	}
+
+	for i, tt := range tests {
+		v := New()
+		v.AddLoadPath(dir)
+		evaluated := testEvalWithVM(t, v, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+
+	v := New()
+	v.AddLoadPath(dir)
+	evaluated := testEvalWithVM(t, v, `require("counter")`)
+	testBooleanObject(t, evaluated, true)
+
+	evaluated = testEvalWithVM(t, v, `require("counter")`)
+	testBooleanObject(t, evaluated, false)
+}
+
+func TestRequireRelative(t *testing.T) {
+	dir := testLibrary(t, map[string]string{
+		"lib/shapes.ro": `
+		require_relative("shapes/square")
+		`,
+		"lib/shapes/square.ro": `
+		class Square
+		  def initialize(side)
+		    @side = side
+		  end
+
+		  def area
+		    @side * @side
+		  end
+		end
+		`,
+	})
+	defer os.RemoveAll(dir)
+
+	evaluated := testEvalFileWithVM(t, New(), filepath.Join(dir, "main.ro"), `
+	require_relative("lib/shapes")
+	Square.new(3).area
+	`)
+	testIntegerObject(t, evaluated, 9)
+}
+
+func TestRequireErrors(t *testing.T) {
+	dir := testLibrary(t, map[string]string{
+		"broken.ro": `raise(RuntimeError, "broken")`,
+	})
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`require("missing")`, "LoadError: cannot load such file -- missing"},
+		{`require(1)`, "TypeError: wrong argument type 1 (expected String)"},
+		{`require_relative("missing")`, "LoadError: cannot infer basepath"},
+		{`
+		begin
+		  require("broken")
+		rescue RuntimeError
+		  10
+		end
+		require("broken")
+		`, "RuntimeError: broken"},
+	}
+
+	for i, tt := range tests {
+		v := New()
+		v.AddLoadPath(dir)
+		err := testEvalError(t, v, "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
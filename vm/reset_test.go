@@ -0,0 +1,92 @@
+package vm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReset(t *testing.T) {
+	v := New()
+	v.MaxCallDepth = 100
+
+	testIntegerObject(t, testEvalWithVM(t, v, `
+	class Foo
+	  def bar
+	    1
+	  end
+	end
+
+	def baz
+	  2
+	end
+
+	$g = 3
+	@i = 4
+	Foo.new.bar + baz + $g + @i
+	`), 10)
+
+	v.Reset()
+
+	if v.SP != 0 || v.CFP != 0 {
+		t.Fatalf("Expect the stack and call frames to be empty. got SP=%d CFP=%d", v.SP, v.CFP)
+	}
+
+	if v.MaxCallDepth != 100 {
+		t.Fatalf("Expect settings to be kept. got MaxCallDepth=%d", v.MaxCallDepth)
+	}
+
+	testIntegerObject(t, testEvalWithVM(t, v, `
+	if $g
+	  0
+	else
+	  if @i
+	    0
+	  else
+	    [1, 2].length
+	  end
+	end
+	`), 2)
+
+	v.Reset()
+
+	err := testEvalError(t, v, "", `
+	Foo.new
+	`)
+
+	if err == nil || !strings.Contains(err.Error(), "uninitialized constant Foo") {
+		t.Fatalf("Expect Foo to be removed by Reset. got=%v", err)
+	}
+
+	v.Reset()
+
+	err = testEvalError(t, v, "", `
+	baz
+	`)
+
+	if err == nil || !strings.Contains(err.Error(), "undefined method `baz'") {
+		t.Fatalf("Expect top level methods to be removed by Reset. got=%v", err)
+	}
+}
+
+func TestResetRestartsInstructionLimit(t *testing.T) {
+	v := New()
+	v.SetInstructionLimit(10)
+
+	err := testEvalError(t, v, "", `
+	a = 1
+	b = 2
+	c = 3
+	d = 4
+	e = 5
+	f = 6
+	`)
+
+	if !IsBudgetExceeded(err) {
+		t.Fatalf("Expect instruction limit to be exceeded. got=%v", err)
+	}
+
+	v.Reset()
+	testIntegerObject(t, testEvalWithVM(t, v, `
+	1 + 2
+	`), 3)
+}
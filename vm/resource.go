@@ -0,0 +1,271 @@
+package vm
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// objectTracker counts arrays, hashes, instances, strings and big integers allocated by a VM that are still alive.
+// Objects are untracked by finalizers, which run on their own goroutine after the garbage collector frees them.
+type objectTracker struct {
+	maxObjects int64
+	maxBytes   int64
+	objects    int64
+	bytes      int64
+	// allocated and freed count every tracked object, including the ones that are already collected
+	allocated int64
+	freed     int64
+	// mu guards classes, the number of live objects of each class
+	mu      sync.Mutex
+	classes map[Class]int
+}
+
+// Approximate sizes of tracked objects, the size of an array or hash grows with its elements, and changes as they're
+// added and removed. Strings and big integers add the size of their bytes and words. Small integers aren't objects of
+// their own here, they're counted as part of the element or pair that holds them.
+const (
+	objectBaseSize   = 48
+	arrayElementSize = 16
+	hashPairSize     = 48
+)
+
+// SetObjectLimit raises ResourceLimitError when the program allocates an array, a hash, an instance, a string
+// or a big integer while maxObjects of them are alive. Only objects allocated after a limit is set are counted. 0 removes the limit.
+func (vm *VM) SetObjectLimit(maxObjects int) {
+	vm.objectTracker().maxObjects = int64(maxObjects)
+}
+
+// SetMemoryLimit is like SetObjectLimit, but limits the approximate size of live objects in bytes.
+func (vm *VM) SetMemoryLimit(maxBytes int) {
+	vm.objectTracker().maxBytes = int64(maxBytes)
+}
+
+// TrackObjects starts counting live objects without limiting them, so programs can inspect them with ObjectSpace and GC.stat.
+// Like limits, only objects allocated after it's called are counted.
+func (vm *VM) TrackObjects() {
+	vm.objectTracker()
+}
+
+// LiveObjects returns how many objects counted by the limits are alive, and their approximate size in bytes.
+func (vm *VM) LiveObjects() (objects, bytes int) {
+	if vm.objects == nil {
+		return 0, 0
+	}
+
+	return int(atomic.LoadInt64(&vm.objects.objects)), int(atomic.LoadInt64(&vm.objects.bytes))
+}
+
+func (vm *VM) objectTracker() *objectTracker {
+	if vm.objects == nil {
+		vm.objects = &objectTracker{classes: make(map[Class]int)}
+	}
+
+	return vm.objects
+}
+
+// track counts o as a live object if limits are set, and raises ResourceLimitError if it exceeds them.
+// Strings, arrays and hashes are counted once, even if they're tracked again.
+func (vm *VM) track(o Object) {
+	vm.trackSize(o, approximateSize(o))
+}
+
+// trackGrowth counts delta more bytes for o before it grows in place, like a string with << or an array with push.
+// It raises ResourceLimitError without counting them if that would exceed the memory limit, so o can be left as it is.
+// A negative delta counts the bytes removed from o. Strings that aren't counted yet are counted from then on,
+// arrays and hashes only if they were counted when they were allocated.
+func (vm *VM) trackGrowth(o Object, delta int64) {
+	if vm == nil || vm.objects == nil {
+		return
+	}
+
+	size := trackedSize(o)
+
+	if atomic.LoadInt64(size) == 0 {
+		if _, ok := o.(*StringObject); ok {
+			vm.trackSize(o, approximateSize(o)+delta)
+		}
+
+		return
+	}
+
+	t := vm.objects
+	vm.checkLimits(0, delta)
+	atomic.AddInt64(size, delta)
+	atomic.AddInt64(&t.bytes, delta)
+}
+
+// trackedSize returns the size o is counted as by the limits, which is 0 if it isn't counted. It's kept by objects
+// that can grow in place, the size of other objects doesn't change.
+func trackedSize(o Object) *int64 {
+	switch o := o.(type) {
+	case *StringObject:
+		return &o.trackedSize
+	case *ArrayObject:
+		return &o.trackedSize
+	case *HashObject:
+		return &o.trackedSize
+	default:
+		return nil
+	}
+}
+
+func (vm *VM) trackSize(o Object, size int64) {
+	if vm == nil || vm.objects == nil {
+		return
+	}
+
+	if i, ok := o.(*IntegerObject); ok && (i.big == nil || i.tracked) {
+		return
+	}
+
+	t := vm.objects
+	vm.checkLimits(1, size)
+
+	if tracked := trackedSize(o); tracked != nil && !atomic.CompareAndSwapInt64(tracked, 0, size) {
+		return
+	}
+
+	atomic.AddInt64(&t.objects, 1)
+	atomic.AddInt64(&t.bytes, size)
+	atomic.AddInt64(&t.allocated, 1)
+
+	class := o.(BaseObject).ReturnClass()
+	t.mu.Lock()
+	t.classes[class]++
+	t.mu.Unlock()
+
+	switch o := o.(type) {
+	case *ArrayObject:
+		runtime.SetFinalizer(o, func(a *ArrayObject) { t.release(atomic.LoadInt64(&a.trackedSize), class) })
+	case *HashObject:
+		runtime.SetFinalizer(o, func(h *HashObject) { t.release(atomic.LoadInt64(&h.trackedSize), class) })
+	case *RObject:
+		runtime.SetFinalizer(o, func(*RObject) { t.release(size, class) })
+	case *StringObject:
+		runtime.SetFinalizer(o, func(s *StringObject) { t.release(atomic.LoadInt64(&s.trackedSize), class) })
+	case *IntegerObject:
+		o.tracked = true
+		runtime.SetFinalizer(o, func(*IntegerObject) { t.release(size, class) })
+	}
+}
+
+// checkLimits raises ResourceLimitError if objects more objects of size bytes in total would exceed the limits.
+func (vm *VM) checkLimits(objects, size int64) {
+	if vm == nil || vm.objects == nil || !vm.objects.exceeds(objects, size) {
+		return
+	}
+
+	t := vm.objects
+
+	// dead objects may not have been collected yet
+	runtime.GC()
+
+	if !t.exceeds(objects, size) {
+		return
+	}
+
+	if t.maxObjects > 0 && atomic.LoadInt64(&t.objects)+objects > t.maxObjects {
+		vm.raise(ResourceLimitErrorClass, "object limit of %d exceeded", t.maxObjects)
+	}
+
+	vm.raise(ResourceLimitErrorClass, "memory limit of %d bytes exceeded", t.maxBytes)
+}
+
+func (t *objectTracker) exceeds(objects, size int64) bool {
+	if t.maxObjects > 0 && atomic.LoadInt64(&t.objects)+objects > t.maxObjects {
+		return true
+	}
+
+	return t.maxBytes > 0 && atomic.LoadInt64(&t.bytes)+size > t.maxBytes
+}
+
+func (t *objectTracker) release(size int64, class Class) {
+	atomic.AddInt64(&t.objects, -1)
+	atomic.AddInt64(&t.bytes, -size)
+	atomic.AddInt64(&t.freed, 1)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.classes[class]--; t.classes[class] == 0 {
+		delete(t.classes, class)
+	}
+}
+
+// liveObjectsByClass returns how many tracked objects of each class are alive
+func (t *objectTracker) liveObjectsByClass() map[Class]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := make(map[Class]int, len(t.classes))
+
+	for class, count := range t.classes {
+		counts[class] = count
+	}
+
+	return counts
+}
+
+func approximateSize(o Object) int64 {
+	switch o := o.(type) {
+	case *ArrayObject:
+		return objectBaseSize + arrayElementSize*int64(len(o.Elements))
+	case *HashObject:
+		return objectBaseSize + hashPairSize*int64(o.Length())
+	case *StringObject:
+		return objectBaseSize + int64(len(o.Value))
+	case *IntegerObject:
+		return objectBaseSize + int64(len(o.bigValue().Bits()))*8
+	default:
+		return objectBaseSize
+	}
+}
+
+// finalizerSentinel is collected to find out when finalizers queued before it have run
+type finalizerSentinel struct {
+	next *finalizerSentinel
+}
+
+// maxCollections is how many times CollectGarbage runs the garbage collector at most. An object that refers to
+// another tracked object keeps it alive until its own finalizer has run, so a chain of them is freed one by one.
+const maxCollections = 10
+
+// CollectGarbage runs the garbage collector until it doesn't free more tracked objects, and waits for their finalizers,
+// so LiveObjects and ObjectSpace don't count objects that are no longer reachable from the VM's stack, call frames,
+// constants and globals.
+func (vm *VM) CollectGarbage() {
+	for i := 0; i < maxCollections; i++ {
+		freed := vm.freedObjects()
+		runtime.GC()
+		waitForFinalizers()
+
+		if vm.freedObjects() == freed {
+			return
+		}
+	}
+}
+
+func (vm *VM) freedObjects() int64 {
+	if vm.objects == nil {
+		return 0
+	}
+
+	return atomic.LoadInt64(&vm.objects.freed)
+}
+
+// waitForFinalizers collects a sentinel and waits for its finalizer. A single goroutine runs finalizers one by one
+// in the order they're queued, so the ones queued by previous collections have finished when it runs.
+func waitForFinalizers() {
+	done := make(chan struct{})
+	sentinel := &finalizerSentinel{}
+	runtime.SetFinalizer(sentinel, func(*finalizerSentinel) { close(done) })
+	sentinel = nil
+	runtime.GC()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+	}
+}
@@ -0,0 +1,234 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestObjectLimits(t *testing.T) {
+	tests := []struct {
+		setLimit func(v *VM)
+		input    string
+		expected string
+	}{
+		{func(v *VM) { v.SetObjectLimit(5) }, `
+		a = []
+		[1, 2, 3, 4, 5, 6].each do |i|
+		  a.push([i])
+		end
+		`, "object limit of 5 exceeded"},
+		{func(v *VM) { v.SetObjectLimit(3) }, `
+		class Foo
+		end
+
+		a = [Foo.new, Foo.new, Foo.new]
+		`, "object limit of 3 exceeded"},
+		{func(v *VM) { v.SetMemoryLimit(200) }, `
+		a = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]
+		`, "memory limit of 200 bytes exceeded"},
+		{func(v *VM) { v.SetMemoryLimit(200) }, `
+		begin
+		  a = { a: 1, b: 2, c: 3, d: 4 }
+		rescue
+		  "rescued"
+		end
+		`, "memory limit of 200 bytes exceeded"},
+		{func(v *VM) { v.SetMemoryLimit(1 << 20) }, `
+		s = "a" * (64 * 1024 * 1024)
+		`, "memory limit of 1048576 bytes exceeded"},
+		{func(v *VM) { v.SetMemoryLimit(1 << 20) }, `
+		s = "growing in place"
+		(1..30).each do |i|
+		  s << s
+		end
+		`, "memory limit of 1048576 bytes exceeded"},
+		{func(v *VM) { v.SetMemoryLimit(1 << 20) }, `
+		s = "a"
+		(1..30).each do |i|
+		  s = s + s
+		end
+		`, "memory limit of 1048576 bytes exceeded"},
+		{func(v *VM) { v.SetMemoryLimit(1 << 20) }, `
+		n = 3
+		(1..30).each do |i|
+		  n = n * n
+		end
+		`, "memory limit of 1048576 bytes exceeded"},
+		{func(v *VM) { v.SetMemoryLimit(100000) }, `
+		a = [1]
+		(1..200000).each do |i|
+		  a.push(i)
+		end
+		`, "memory limit of 100000 bytes exceeded"},
+		{func(v *VM) { v.SetMemoryLimit(100000) }, `
+		a = []
+		(1..200000).each do |i|
+		  a[i] = i
+		end
+		`, "memory limit of 100000 bytes exceeded"},
+		{func(v *VM) { v.SetMemoryLimit(100000) }, `
+		h = {}
+		(1..200000).each do |i|
+		  h[i] = i
+		end
+		`, "memory limit of 100000 bytes exceeded"},
+		{func(v *VM) { v.SetObjectLimit(3) }, `
+		a = "a" + "b"
+		b = a + "c"
+		c = b + "d"
+		d = c + "e"
+		`, "object limit of 3 exceeded"},
+	}
+
+	for _, tt := range tests {
+		v := New()
+		tt.setLimit(v)
+		testLoadBytecodes(v, testCompile(t, "limit.ro", tt.input))
+		err := v.Exec()
+
+		re, ok := err.(*raisedException)
+
+		if !ok || re.exception.Class != ResourceLimitErrorClass {
+			t.Fatalf("Expect Exec to return ResourceLimitError. got=%v", err)
+		}
+
+		if msg := exceptionMessage(re.exception); msg != tt.expected {
+			t.Fatalf("Expect error message to be %q. got=%q", tt.expected, msg)
+		}
+	}
+}
+
+func TestLiveObjects(t *testing.T) {
+	v := New()
+
+	if objects, bytes := v.LiveObjects(); objects != 0 || bytes != 0 {
+		t.Fatalf("Expect no objects to be tracked without limits")
+	}
+
+	v.SetObjectLimit(100)
+	evaluated := testEvalWithVM(t, v, `[[1, 2], [3]]`)
+	objects, bytes := v.LiveObjects()
+
+	if objects != 3 || bytes != 3*objectBaseSize+5*arrayElementSize {
+		t.Fatalf("Expect 3 live arrays of %d bytes. got=%d objects of %d bytes", 3*objectBaseSize+5*arrayElementSize, objects, bytes)
+	}
+
+	if arr, ok := evaluated.(*ArrayObject); !ok || len(arr.Elements) != 2 {
+		t.Fatalf("Expect evaluated value to be an array of 2 elements. got=%s", evaluated.Inspect())
+	}
+}
+
+func TestLiveArraysAndHashesChangingInPlace(t *testing.T) {
+	v := New()
+	v.SetObjectLimit(100)
+	testEvalWithVM(t, v, `
+	a = [1]
+	a.push(2, 3)
+	a << 4
+	a[5] = 6
+	b = [7]
+	a[0, 2] = b
+	a.pop
+	h = { a: 1 }
+	h["b"] = 2
+	h["b"] = 3
+	h.delete("a")
+	`)
+
+	// a has 4 elements left, b 1 and h 1 pair
+	objects, bytes := v.LiveObjects()
+	expected := 3*objectBaseSize + 5*arrayElementSize + hashPairSize
+
+	if objects != 3 || bytes != expected {
+		t.Fatalf("Expect 3 live objects of %d bytes. got=%d objects of %d bytes", expected, objects, bytes)
+	}
+}
+
+func TestLiveStrings(t *testing.T) {
+	v := New()
+	v.SetObjectLimit(100)
+	testEvalWithVM(t, v, `
+	s = "ab" * 50
+	s << "cd" * 25
+	`)
+
+	// s, which grew to 150 characters, and the 50 character string appended to it
+	objects, bytes := v.LiveObjects()
+
+	if objects != 2 || bytes != 2*objectBaseSize+200 {
+		t.Fatalf("Expect 2 live strings of %d bytes. got=%d objects of %d bytes", 2*objectBaseSize+200, objects, bytes)
+	}
+}
+
+func TestUnreachableObjectsAreReleased(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		class Foo
+		  def initialize(n)
+		    @n = [n]
+		  end
+		end
+
+		[1, 2, 3, 4, 5].each do |i|
+		  Foo.new(i)
+		  [i]
+		end
+		[6]
+		{ a: [7] }
+		GC.start
+		ObjectSpace.count_objects["TOTAL"]
+		`, 0},
+		{`
+		class Foo
+		  def initialize(n)
+		    @n = [n]
+		  end
+		end
+
+		keep = [Foo.new(1)]
+		Foo.new(2)
+		GC.start
+		ObjectSpace.count_objects["TOTAL"]
+		`, 3},
+		{`
+		def make
+		  [1]
+		  [2]
+		  [3]
+		end
+
+		make
+		GC.start
+		ObjectSpace.count_objects["TOTAL"]
+		`, 0},
+	}
+
+	for i, tt := range tests {
+		v := New()
+		v.TrackObjects()
+		evaluated := testEvalWithVM(t, v, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestBlockValuesDoNotPileUpOnStack(t *testing.T) {
+	v := New()
+	testEvalWithVM(t, v, `
+	[1, 2, 3, 4, 5].each do |i|
+	  [i]
+	  i + 1
+	  [i, i]
+	  i + 2
+	  i
+	end
+	`)
+
+	if len(v.Stack.Data) > 10 {
+		t.Fatalf("Expect values left by the block to be dropped after each iteration. got stack of %d values", len(v.Stack.Data))
+	}
+}
@@ -0,0 +1,54 @@
+package vm
+
+import "fmt"
+
+// ensureSingletonClass returns the class's singleton class. Its Methods are the class's ClassMethods,
+// so it's only used as the owner of class methods, which lets super find the class they're defined in.
+func (c *BaseClass) ensureSingletonClass() *RClass {
+	if c.singletonClass == nil {
+		c.singletonClass = &RClass{BaseClass: &BaseClass{Name: fmt.Sprintf("#<Class:%s>", c.Name), Methods: c.ClassMethods, ClassMethods: NewEnvironment(), Class: c.Class, Singleton: true}}
+	}
+
+	return c.singletonClass
+}
+
+// ensureSingletonClass returns the object's singleton class. It inherits the object's class,
+// so methods are looked up from it once it's created.
+func (ro *RObject) ensureSingletonClass() *RClass {
+	if ro.singletonClass == nil {
+		class := newClass(fmt.Sprintf("#<Class:%s>", ro.Inspect()), ro.Class.Class, ro.Class)
+		class.Singleton = true
+		ro.singletonClass = class
+	}
+
+	return ro.singletonClass
+}
+
+// SetSingletonMethod defines a method only the object responds to.
+func (ro *RObject) SetSingletonMethod(name string, method *Method) {
+	class := ro.ensureSingletonClass()
+	class.Methods.Set(name, method)
+	method.Owner = class
+}
+
+// methodClass returns the class methods of the object are looked up from
+func (ro *RObject) methodClass() *RClass {
+	if ro.singletonClass != nil {
+		return ro.singletonClass
+	}
+
+	return ro.Class
+}
+
+// defineSingletonMethod defines a singleton method of receiver, which can be a class or an object.
+func (vm *VM) defineSingletonMethod(receiver Object, name string, method *Method) {
+	switch r := receiver.(type) {
+	case Class:
+		vm.defineClassMethod(r, method)
+	case *RObject:
+		vm.checkFrozen(r)
+		r.SetSingletonMethod(name, method)
+	default:
+		vm.raise(TypeErrorClass, "can't define singleton method on %s", receiver.Inspect())
+	}
+}
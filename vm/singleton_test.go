@@ -0,0 +1,191 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestSingletonMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		class Foo
+		  def value
+		    1
+		  end
+		end
+
+		foo = Foo.new
+		other = Foo.new
+
+		def foo.value
+		  10
+		end
+
+		foo.value + other.value
+		`, 11},
+		{`
+		class Foo
+		  def value
+		    1
+		  end
+		end
+
+		foo = Foo.new
+
+		def foo.value
+		  super + 10
+		end
+
+		foo.value
+		`, 11},
+		{`
+		class Foo
+		end
+
+		def Foo.bar
+		  5
+		end
+
+		Foo.bar
+		`, 5},
+		{`
+		class Base
+		  def self.value
+		    1
+		  end
+		end
+
+		class Foo < Base
+		  def self.value
+		    super + 10
+		  end
+		end
+
+		Foo.value
+		`, 11},
+		{`
+		class Foo
+		end
+
+		foo = Foo.new
+		n = 3
+		foo.define_singleton_method("triple".to_sym) do |x|
+		  x * n
+		end
+
+		foo.triple(4)
+		`, 12},
+		{`
+		class Foo
+		end
+
+		Foo.define_singleton_method("bar") do
+		  7
+		end
+
+		Foo.bar
+		`, 7},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testIntegerObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestSingletonMethodErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		class Foo
+		end
+
+		foo = Foo.new
+		other = Foo.new
+
+		def foo.bar
+		  1
+		end
+
+		other.bar
+		`, "NoMethodError: undefined method `bar' for <Instance of: Foo>"},
+		{`
+		n = 1
+
+		def n.bar
+		  1
+		end
+		`, "TypeError: can't define singleton method on 1"},
+		{`
+		class Foo
+		end
+
+		Foo.new.define_singleton_method("bar")
+		`, "LocalJumpError: no block given (define_singleton_method)"},
+		{`
+		class Foo
+		end
+
+		Foo.new.define_singleton_method(1) do
+		  1
+		end
+		`, "TypeError: 1 is not a symbol nor a string"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestSnapshotWithSingletonMethods(t *testing.T) {
+	bytecodes := testCompile(t, "singleton.ro", `
+	class Foo
+	  def value
+	    1
+	  end
+	end
+
+	Obj = Foo.new
+
+	def Obj.value
+	  super + 10
+	end
+
+	1
+	`)
+
+	v := New()
+	testExecWithVM(v, bytecodes)
+	data, err := v.Snapshot()
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	restored := New()
+	p := NewBytecodeParser()
+	p.VM = restored
+	p.Parse(bytecodes)
+
+	if err := restored.Restore(data); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	foo := restored.Constants["Obj"].Target.(*RObject)
+	method := foo.methodClass().LookupInstanceMethod("value")
+
+	if method == nil || method.(*Method).Owner != foo.singletonClass {
+		t.Fatalf("Expect Obj to have its singleton method restored")
+	}
+}
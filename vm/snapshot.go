@@ -0,0 +1,920 @@
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// snapshotVersion is bumped whenever the snapshot format changes.
+const snapshotVersion = 2
+
+// Snapshot serializes the VM's constants, globals, stack, call frame and every object reachable from them,
+// so the VM can be checkpointed and restored later with Restore.
+//
+// Methods, blocks and class bodies are evaluated on Go's stack, so a VM can only be snapshotted when it isn't running,
+// before its program starts, or from an instruction hook or breakpoint handler while it runs top level code.
+// Objects only Go hosts can create, like enumerators, mutexes and built in methods defined by the host, aren't supported.
+func (vm *VM) Snapshot() ([]byte, error) {
+	s := &snapshot{
+		Version:   snapshotVersion,
+		Constants: make(map[string]int),
+		Globals:   make(map[string]int),
+		MethodIS:  vm.MethodISTable.Data,
+		ClassIS:   vm.ClassISTable.Data,
+	}
+	w := &snapshotWriter{vm: vm, snapshot: s, ids: make(map[Object]int)}
+
+	err := w.write()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(s)
+}
+
+// Restore replaces the VM's state with a snapshot taken by Snapshot. The VM must have loaded the same bytecodes
+// as the VM the snapshot is taken from, since methods and call frames refer to their instruction sets.
+// If the snapshot has a call frame, Exec resumes it from where the snapshot was taken.
+func (vm *VM) Restore(data []byte) error {
+	s := &snapshot{}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return fmt.Errorf("can't restore snapshot: %s", err.Error())
+	}
+
+	if s.Version != snapshotVersion {
+		return fmt.Errorf("can't restore snapshot of version %d, expect version %d", s.Version, snapshotVersion)
+	}
+
+	r := &snapshotReader{vm: vm, snapshot: s}
+
+	return r.read()
+}
+
+type snapshot struct {
+	Version   int               `json:"version"`
+	Objects   []*snapshotObject `json:"objects"`
+	Constants map[string]int    `json:"constants"`
+	Globals   map[string]int    `json:"globals"`
+	Stack     []int             `json:"stack"`
+	Frame     *snapshotFrame    `json:"frame,omitempty"`
+	MethodIS  map[string]int    `json:"method_is"`
+	ClassIS   map[string]int    `json:"class_is"`
+}
+
+// snapshotObject is one of the objects in a snapshot. Objects refer to each other by their index in Objects plus one,
+// and 0 means there's no object. A range keeps its start in Int.
+type snapshotObject struct {
+	Kind         string                     `json:"kind"`
+	Int          int                        `json:"int,omitempty"`
+	End          int                        `json:"end,omitempty"`
+	Exclusive    bool                       `json:"exclusive,omitempty"`
+	Dates        bool                       `json:"dates,omitempty"`
+	String       string                     `json:"string,omitempty"`
+	Encoding     string                     `json:"encoding,omitempty"`
+	Elements     []int                      `json:"elements,omitempty"`
+	Keys         []int                      `json:"keys,omitempty"`
+	Default      int                        `json:"default,omitempty"`
+	Class        int                        `json:"class,omitempty"`
+	Variables    map[string]int             `json:"variables,omitempty"`
+	SuperClass   int                        `json:"superclass,omitempty"`
+	Singleton    bool                       `json:"singleton,omitempty"`
+	Module       bool                       `json:"module,omitempty"`
+	Origin       int                        `json:"origin,omitempty"`
+	Extended     bool                       `json:"extended,omitempty"`
+	Methods      map[string]*snapshotMethod `json:"methods,omitempty"`
+	ClassMethods map[string]*snapshotMethod `json:"class_methods,omitempty"`
+	// SingletonClass is the class an object's singleton methods are defined in.
+	SingletonClass int  `json:"singleton_class,omitempty"`
+	Frozen         bool `json:"frozen,omitempty"`
+}
+
+type snapshotMethod struct {
+	Argc           int                     `json:"argc"`
+	Private        bool                    `json:"private,omitempty"`
+	InstructionSet *snapshotInstructionSet `json:"is"`
+}
+
+// snapshotInstructionSet refers to an instruction set in the VM's label table.
+type snapshotInstructionSet struct {
+	Type  LabelType `json:"type"`
+	Name  string    `json:"name"`
+	Index int       `json:"index"`
+}
+
+type snapshotFrame struct {
+	InstructionSet *snapshotInstructionSet `json:"is"`
+	PC             int                     `json:"pc"`
+	Self           int                     `json:"self"`
+	Locals         []int                   `json:"locals"`
+	// CatchSP maps indexes of entered catch entries to their stack pointer.
+	CatchSP map[int]int `json:"catch_sp,omitempty"`
+}
+
+const (
+	snapshotInteger  = "integer"
+	snapshotFloat    = "float"
+	snapshotRational = "rational"
+	snapshotDecimal  = "big_decimal"
+	snapshotDate     = "date"
+	snapshotRange    = "range"
+	snapshotEncoding = "encoding"
+	snapshotString   = "string"
+	snapshotSymbol   = "symbol"
+	snapshotTrue     = "true"
+	snapshotFalse    = "false"
+	snapshotNil      = "nil"
+	snapshotArray    = "array"
+	snapshotHash     = "hash"
+	snapshotMain     = "main"
+	snapshotInstance = "instance"
+	snapshotClass    = "class"
+	snapshotBuiltIn  = "builtin_class"
+	snapshotIO       = "io"
+	snapshotEnv      = "env"
+	snapshotClassRef = "class_ref"
+)
+
+type snapshotWriter struct {
+	vm       *VM
+	snapshot *snapshot
+	ids      map[Object]int
+	// marshal is set when objects are written by Marshal.dump, see writeMarshalObject
+	marshal bool
+}
+
+func (w *snapshotWriter) write() error {
+	vm := w.vm
+
+	if vm.CFP > 1 {
+		return fmt.Errorf("can't snapshot a VM while it's running a method, block or class body")
+	}
+
+	if vm.CFP == 1 {
+		cf := vm.CallFrameStack.Top()
+		pc := cf.PC
+
+		// The instruction a hook is called for hasn't been executed yet, so it'll be executed after restoring.
+		if vm.inInstructionHook {
+			pc--
+		} else if pc > 0 {
+			return fmt.Errorf("can't snapshot a running VM outside of instruction hooks")
+		}
+
+		frame, err := w.writeFrame(cf, pc)
+
+		if err != nil {
+			return err
+		}
+
+		w.snapshot.Frame = frame
+	}
+
+	for name, p := range vm.Constants {
+		id, err := w.writeObject(p.Target)
+
+		if err != nil {
+			return fmt.Errorf("can't snapshot constant %s: %s", name, err.Error())
+		}
+
+		w.snapshot.Constants[name] = id
+	}
+
+	for name, p := range vm.Globals {
+		id, err := w.writeObject(p.Target)
+
+		if err != nil {
+			return fmt.Errorf("can't snapshot global %s: %s", name, err.Error())
+		}
+
+		w.snapshot.Globals[name] = id
+	}
+
+	for i := 0; i < vm.SP; i++ {
+		id, err := w.writeObject(vm.Stack.Data[i].Target)
+
+		if err != nil {
+			return fmt.Errorf("can't snapshot stack: %s", err.Error())
+		}
+
+		w.snapshot.Stack = append(w.snapshot.Stack, id)
+	}
+
+	// Top level methods and instance variables of main belong to the VM's Object class and main object,
+	// so they're saved even if nothing refers to them.
+	if _, err := w.writeObject(w.vm.objectClass); err != nil {
+		return err
+	}
+
+	_, err := w.writeObject(w.vm.MainObj)
+
+	return err
+}
+
+func (w *snapshotWriter) writeFrame(cf *CallFrame, pc int) (*snapshotFrame, error) {
+	if cf.EP != nil || cf.BlockFrame != nil || cf.IsBlock {
+		return nil, fmt.Errorf("can't snapshot a VM while it's running a block")
+	}
+
+	is, err := w.writeInstructionSet(cf.InstructionSet)
+
+	if err != nil {
+		return nil, err
+	}
+
+	self, err := w.writeObject(cf.Self)
+
+	if err != nil {
+		return nil, err
+	}
+
+	frame := &snapshotFrame{InstructionSet: is, PC: pc, Self: self, CatchSP: make(map[int]int)}
+
+	for i := 0; i < cf.LPr; i++ {
+		id := 0
+
+		if p := cf.Local[i]; p != nil {
+			id, err = w.writeObject(p.Target)
+
+			if err != nil {
+				return nil, fmt.Errorf("can't snapshot local variable: %s", err.Error())
+			}
+		}
+
+		frame.Locals = append(frame.Locals, id)
+	}
+
+	for i, entry := range cf.InstructionSet.CatchTable {
+		if sp, ok := cf.catchSP[entry]; ok {
+			frame.CatchSP[i] = sp
+		}
+	}
+
+	return frame, nil
+}
+
+func (w *snapshotWriter) writeInstructionSet(is *InstructionSet) (*snapshotInstructionSet, error) {
+	for name, iss := range w.vm.LabelTable[is.Label.Type] {
+		for i, candidate := range iss {
+			if candidate == is {
+				return &snapshotInstructionSet{Type: is.Label.Type, Name: name, Index: i}, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("instruction set %s isn't loaded by the VM", is.Label.Name)
+}
+
+// writeObject adds o and everything it refers to to the snapshot, and returns its id.
+func (w *snapshotWriter) writeObject(o Object) (int, error) {
+	if o == nil {
+		return 0, nil
+	}
+
+	if id, ok := w.ids[o]; ok {
+		return id, nil
+	}
+
+	if w.marshal {
+		if err := w.vm.checkMarshalable(o); err != nil {
+			return 0, err
+		}
+	}
+
+	so := &snapshotObject{}
+	w.snapshot.Objects = append(w.snapshot.Objects, so)
+	id := len(w.snapshot.Objects)
+	// the id is known before the object's content is written, so objects can refer to each other
+	w.ids[o] = id
+
+	var err error
+
+	switch o := o.(type) {
+	case *IntegerObject:
+		so.Kind, so.Int = snapshotInteger, o.Value
+
+		// big integers are kept as strings, JSON numbers can't hold them
+		if o.big != nil {
+			so.String = o.big.String()
+		}
+	case *FloatObject:
+		// floats are kept as strings, JSON numbers can't be infinite or NaN
+		so.Kind, so.String = snapshotFloat, strconv.FormatFloat(o.Value, 'g', -1, 64)
+	case *RationalObject:
+		so.Kind, so.String = snapshotRational, o.value.String()
+	case *BigDecimalObject:
+		so.Kind, so.String = snapshotDecimal, o.plain()
+	case *DateObject:
+		so.Kind, so.Int = snapshotDate, o.day
+	case *RangeObject:
+		so.Kind, so.Int, so.End = snapshotRange, o.Start, o.End
+		so.Exclusive, so.Dates = o.Exclusive, o.Dates
+	case *StringObject:
+		so.Kind, so.String = snapshotString, o.Value
+
+		if o.encodingObject != nil {
+			so.Encoding = o.encodingObject.name
+		}
+	case *EncodingObject:
+		so.Kind, so.String = snapshotEncoding, o.name
+	case *SymbolObject:
+		so.Kind, so.String = snapshotSymbol, o.Name
+	case *BooleanObject:
+		so.Kind = snapshotFalse
+
+		if o.Value {
+			so.Kind = snapshotTrue
+		}
+	case *Null:
+		so.Kind = snapshotNil
+	case *ArrayObject:
+		so.Kind = snapshotArray
+		so.Elements, err = w.writeObjects(o.Elements)
+	case *HashObject:
+		so.Kind = snapshotHash
+		if so.Keys, err = w.writeObjects(o.Keys()); err == nil {
+			so.Elements, err = w.writeObjects(o.Values())
+		}
+
+		if err == nil {
+			so.Default, err = w.writeObject(o.defaultValue)
+		}
+	case *RObject:
+		err = w.writeInstance(so, o)
+	case Class:
+		if w.marshal {
+			err = w.writeClassRef(so, o)
+		} else {
+			err = w.writeClass(so, o)
+		}
+	case *IOObject:
+		// standard streams belong to the host, the restored program uses the streams of the VM it's restored into
+		so.Kind = snapshotIO
+		err = w.writeStream(so, o)
+	case *EnvObject:
+		// like standard streams, the restored program uses ENV of the VM it's restored into
+		so.Kind = snapshotEnv
+	default:
+		err = fmt.Errorf("%s (%T) isn't supported", o.Inspect(), o)
+	}
+
+	if f, ok := o.(freezable); ok && !w.marshal {
+		so.Frozen = f.isFrozen()
+	}
+
+	return id, err
+}
+
+func (w *snapshotWriter) writeStream(so *snapshotObject, o *IOObject) error {
+	for name, stream := range w.vm.standardStreams() {
+		if stream == o {
+			so.String = name
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s isn't supported", o.Inspect())
+}
+
+func (w *snapshotWriter) writeObjects(objects []Object) ([]int, error) {
+	ids := []int{}
+
+	for _, o := range objects {
+		id, err := w.writeObject(o)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (w *snapshotWriter) writeInstance(so *snapshotObject, o *RObject) (err error) {
+	so.Kind = snapshotInstance
+
+	if o == w.vm.MainObj {
+		so.Kind = snapshotMain
+	} else if so.Class, err = w.writeObject(o.Class); err != nil {
+		return err
+	}
+
+	so.Variables = make(map[string]int)
+
+	for name, value := range o.InstanceVariables.store {
+		if so.Variables[name], err = w.writeObject(value); err != nil {
+			return err
+		}
+	}
+
+	if o.singletonClass != nil {
+		so.SingletonClass, err = w.writeObject(o.singletonClass)
+	}
+
+	return err
+}
+
+// writeClassRef writes c by its name, it's looked up when the object is read.
+func (w *snapshotWriter) writeClassRef(so *snapshotObject, c Class) error {
+	so.Kind, so.String = snapshotClassRef, c.ReturnName()
+	return nil
+}
+
+func (w *snapshotWriter) writeClass(so *snapshotObject, c Class) (err error) {
+	so.String = c.ReturnName()
+
+	for _, builtIn := range w.vm.builtInClasses() {
+		if c == builtIn {
+			so.Kind = snapshotBuiltIn
+		}
+	}
+
+	var base *BaseClass
+
+	switch c := c.(type) {
+	case *RClass:
+		base = c.BaseClass
+	default:
+		if so.Kind != snapshotBuiltIn {
+			return fmt.Errorf("class %s (%T) isn't supported", c.ReturnName(), c)
+		}
+
+		base, _ = classBase(c)
+	}
+
+	if base == nil {
+		return nil
+	}
+
+	if so.Kind == snapshotBuiltIn {
+		// built in classes are recreated by the VM, only methods the program adds to them need to be saved. They're in
+		// the VM's own Object and Class classes, or kept by the VM for classes it shares with other VMs.
+		if reopened := w.vm.reopened[base]; reopened != nil {
+			if so.Methods, err = w.writeMethods(base, reopened.methods); err != nil {
+				return err
+			}
+
+			so.ClassMethods, err = w.writeMethods(base, reopened.classMethods)
+		} else if !isSharedClass(c) {
+			so.Methods, err = w.writeMethods(base, base.Methods)
+		}
+
+		return err
+	}
+
+	if so.Methods, err = w.writeMethods(base, base.Methods); err != nil {
+		return err
+	}
+
+	so.Kind = snapshotClass
+	so.Singleton = base.Singleton
+	so.Module = base.IsModule
+
+	if so.ClassMethods, err = w.writeMethods(base, base.ClassMethods); err != nil {
+		return err
+	}
+
+	// classes inserted by include and extend share methods with their modules
+	if base.origin != nil {
+		so.Extended = base.extended
+
+		if so.Extended {
+			so.ClassMethods = nil
+		} else {
+			so.Methods = nil
+		}
+
+		if so.Origin, err = w.writeObject(base.origin); err != nil {
+			return err
+		}
+	}
+
+	if base.SuperClass != nil {
+		if so.SuperClass, err = w.writeObject(base.SuperClass); err != nil {
+			return err
+		}
+	}
+
+	if base.Class != nil {
+		so.Class, err = w.writeObject(base.Class)
+	}
+
+	return err
+}
+
+func (w *snapshotWriter) writeMethods(c *BaseClass, env *Environment) (map[string]*snapshotMethod, error) {
+	methods := make(map[string]*snapshotMethod)
+
+	for name, m := range env.store {
+		switch m := m.(type) {
+		case *Method:
+			if m.block != nil {
+				return nil, fmt.Errorf("method %s#%s defined with a block isn't supported", c.Name, name)
+			}
+
+			is, err := w.writeInstructionSet(m.InstructionSet)
+
+			if err != nil {
+				return nil, err
+			}
+
+			methods[name] = &snapshotMethod{Argc: m.Argc, Private: m.Private, InstructionSet: is}
+		case *BuiltInMethod:
+			// built in methods of built in classes are recreated by the VM, but ones added by hosts can't be saved
+			if !w.isBuiltInClass(c) {
+				return nil, fmt.Errorf("built in method %s#%s isn't supported", c.Name, name)
+			}
+		default:
+			return nil, fmt.Errorf("method %s#%s (%T) isn't supported", c.Name, name, m)
+		}
+	}
+
+	return methods, nil
+}
+
+func (w *snapshotWriter) isBuiltInClass(c *BaseClass) bool {
+	for _, builtIn := range w.vm.builtInClasses() {
+		if rc, ok := builtIn.(*RClass); ok && rc.BaseClass == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+type snapshotReader struct {
+	vm       *VM
+	snapshot *snapshot
+	objects  []Object
+	// marshal is set when objects are read by Marshal.load, which runs in the middle of the program
+	marshal bool
+}
+
+func (r *snapshotReader) read() error {
+	s := r.snapshot
+
+	if err := r.readObjects(); err != nil {
+		return err
+	}
+
+	vm := r.vm
+	vm.Constants = make(map[string]*Pointer)
+
+	for name, id := range s.Constants {
+		vm.Constants[name] = &Pointer{Target: r.object(id)}
+	}
+
+	vm.constantSerial++
+	vm.Globals = make(map[string]*Pointer)
+
+	for name, id := range s.Globals {
+		vm.Globals[name] = &Pointer{Target: r.object(id)}
+	}
+
+	vm.unwindTo(0, 0)
+
+	for _, id := range s.Stack {
+		vm.Stack.push(&Pointer{Target: r.object(id)})
+	}
+
+	vm.MethodISTable.Data = s.MethodIS
+	vm.ClassISTable.Data = s.ClassIS
+
+	if s.Frame == nil {
+		return nil
+	}
+
+	cf, err := r.frame(s.Frame)
+
+	if err != nil {
+		return err
+	}
+
+	vm.CallFrameStack.Push(cf)
+
+	return nil
+}
+
+// readObjects restores the snapshot's objects.
+func (r *snapshotReader) readObjects() error {
+	s := r.snapshot
+
+	// objects are allocated before their content is read, so they can refer to each other
+	for _, so := range s.Objects {
+		o, err := r.allocate(so)
+
+		if err != nil {
+			return err
+		}
+
+		r.objects = append(r.objects, o)
+	}
+
+	for i, so := range s.Objects {
+		if err := r.fill(r.objects[i], so); err != nil {
+			return err
+		}
+	}
+
+	// keys of classes that define `hash` are hashed by calling it, so hashes are filled after the objects it may use
+	for i, so := range s.Objects {
+		if h, ok := r.objects[i].(*HashObject); ok {
+			if err := r.fillHash(h, so); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *snapshotReader) object(id int) Object {
+	if id == 0 {
+		return nil
+	}
+
+	return r.objects[id-1]
+}
+
+func (r *snapshotReader) allocate(so *snapshotObject) (Object, error) {
+	switch so.Kind {
+	case snapshotInteger:
+		if so.String != "" {
+			n, ok := new(big.Int).SetString(so.String, 10)
+
+			if !ok {
+				return nil, fmt.Errorf("invalid integer %q", so.String)
+			}
+
+			return r.vm.initBigInteger(n), nil
+		}
+
+		return r.vm.initInteger(so.Int), nil
+	case snapshotFloat:
+		f, err := strconv.ParseFloat(so.String, 64)
+		return InitializeFloat(f), err
+	case snapshotRational:
+		value, ok := new(big.Rat).SetString(so.String)
+
+		if !ok {
+			return nil, fmt.Errorf("invalid rational %q", so.String)
+		}
+
+		return r.vm.initRational(value), nil
+	case snapshotDecimal:
+		d, ok := r.vm.parseDecimal(so.String)
+
+		if !ok {
+			return nil, fmt.Errorf("invalid decimal %q", so.String)
+		}
+
+		return d, nil
+	case snapshotDate:
+		return r.vm.initDate(so.Int), nil
+	case snapshotRange:
+		rng := InitializeRange(so.Int, so.End, so.Exclusive)
+		rng.Dates = so.Dates
+
+		return rng, nil
+	case snapshotString:
+		if so.Encoding != "" {
+			e, ok := encodings[so.Encoding]
+
+			if !ok {
+				return nil, fmt.Errorf("unknown encoding %q", so.Encoding)
+			}
+
+			return &StringObject{Value: so.String, Class: StringClass, encodingObject: e}, nil
+		}
+
+		return InitializeString(so.String), nil
+	case snapshotEncoding:
+		e, ok := encodings[so.String]
+
+		if !ok {
+			return nil, fmt.Errorf("unknown encoding %q", so.String)
+		}
+
+		return e, nil
+	case snapshotSymbol:
+		return InternSymbol(so.String), nil
+	case snapshotTrue:
+		return TRUE, nil
+	case snapshotFalse:
+		return FALSE, nil
+	case snapshotNil:
+		return NULL, nil
+	case snapshotArray:
+		return InitializeArray([]Object{}), nil
+	case snapshotHash:
+		return InitializeHash(map[string]Object{}), nil
+	case snapshotMain:
+		return r.vm.MainObj, nil
+	case snapshotInstance:
+		return &RObject{InstanceVariables: NewEnvironment()}, nil
+	case snapshotClass:
+		return r.vm.initializeClass(so.String), nil
+	case snapshotIO:
+		if stream, ok := r.vm.standardStreams()[so.String]; ok {
+			return stream, nil
+		}
+
+		return nil, fmt.Errorf("can't restore unknown stream %s", so.String)
+	case snapshotEnv:
+		return r.vm.env, nil
+	case snapshotClassRef:
+		if p, ok := r.vm.Constants[so.String]; ok {
+			if c, ok := p.Target.(Class); ok {
+				return c, nil
+			}
+		}
+
+		return nil, fmt.Errorf("undefined class/module %s", so.String)
+	case snapshotBuiltIn:
+		for _, c := range r.vm.builtInClasses() {
+			if c.ReturnName() == so.String {
+				return c, nil
+			}
+		}
+
+		return nil, fmt.Errorf("can't restore unknown built in class %s", so.String)
+	default:
+		return nil, fmt.Errorf("can't restore object of kind %q", so.Kind)
+	}
+}
+
+func (r *snapshotReader) fill(o Object, so *snapshotObject) error {
+	// classes loaded by Marshal.load are the VM's own classes, they're only referred to by name
+	if so.Kind == snapshotClassRef {
+		return nil
+	}
+
+	if so.Kind == snapshotBuiltIn {
+		return r.fillBuiltInClass(o.(Class), so)
+	}
+
+	switch o := o.(type) {
+	case *ArrayObject:
+		for _, id := range so.Elements {
+			o.Elements = append(o.Elements, r.object(id))
+		}
+	case *RObject:
+		if so.Kind == snapshotInstance {
+			class, ok := r.object(so.Class).(*RClass)
+
+			if !ok {
+				return fmt.Errorf("can't restore instance without class")
+			}
+
+			o.Class = class
+		}
+
+		for name, id := range so.Variables {
+			o.InstanceVariables.Set(name, r.object(id))
+		}
+
+		o.singletonClass, _ = r.object(so.SingletonClass).(*RClass)
+	case *RClass:
+		if err := r.fillMethods(o, o.Methods, so.Methods); err != nil {
+			return err
+		}
+
+		owner := o
+
+		if len(so.ClassMethods) > 0 {
+			owner = o.ensureSingletonClass()
+		}
+
+		if err := r.fillMethods(owner, o.ClassMethods, so.ClassMethods); err != nil {
+			return err
+		}
+
+		o.Singleton = so.Singleton
+		o.IsModule = so.Module
+
+		if origin, ok := r.object(so.Origin).(*RClass); ok {
+			o.origin = origin
+			o.extended = so.Extended
+
+			if o.extended {
+				o.ClassMethods = origin.Methods
+			} else {
+				o.Methods = origin.Methods
+			}
+		}
+		o.SuperClass, _ = r.object(so.SuperClass).(*RClass)
+		o.Class, _ = r.object(so.Class).(*RClass)
+	}
+
+	if f, ok := o.(freezable); ok && so.Frozen {
+		f.freeze()
+	}
+
+	return nil
+}
+
+func (r *snapshotReader) fillHash(h *HashObject, so *snapshotObject) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			// exceptions raised by `hash` methods are raised by Marshal.load as they are
+			if r.marshal {
+				panic(e)
+			}
+
+			err = fmt.Errorf("can't restore hash: %s", r.vm.errorFromPanic(e).Error())
+			r.vm.unwindTo(0, 0)
+		}
+	}()
+
+	for i, key := range so.Keys {
+		h.set(r.vm, r.object(key), r.object(so.Elements[i]))
+	}
+
+	h.defaultValue = r.object(so.Default)
+
+	return nil
+}
+
+// fillBuiltInClass restores the methods the program defined on a built in class
+func (r *snapshotReader) fillBuiltInClass(c Class, so *snapshotObject) error {
+	reopened := r.vm.reopen(c)
+
+	if reopened == nil {
+		class := c.(*RClass)
+		return r.fillMethods(class, class.Methods, so.Methods)
+	}
+
+	if err := r.fillMethods(reopened.owner, reopened.methods, so.Methods); err != nil {
+		return err
+	}
+
+	base, _ := classBase(c)
+	return r.fillMethods(base.singletonClass, reopened.classMethods, so.ClassMethods)
+}
+
+func (r *snapshotReader) fillMethods(owner *RClass, env *Environment, methods map[string]*snapshotMethod) error {
+	for name, m := range methods {
+		is, err := r.instructionSet(m.InstructionSet)
+
+		if err != nil {
+			return err
+		}
+
+		env.Set(name, &Method{Name: name, Argc: m.Argc, Private: m.Private, InstructionSet: is, Owner: owner})
+	}
+
+	return nil
+}
+
+func (r *snapshotReader) instructionSet(ref *snapshotInstructionSet) (*InstructionSet, error) {
+	if ref == nil {
+		return nil, fmt.Errorf("can't restore snapshot without instruction set")
+	}
+
+	iss := r.vm.LabelTable[ref.Type][ref.Name]
+
+	if ref.Index >= len(iss) {
+		return nil, fmt.Errorf("can't find instruction set %s:%s, the VM should load the same bytecodes as the snapshot", ref.Type, ref.Name)
+	}
+
+	return iss[ref.Index], nil
+}
+
+func (r *snapshotReader) frame(sf *snapshotFrame) (*CallFrame, error) {
+	is, err := r.instructionSet(sf.InstructionSet)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cf := NewCallFrame(is)
+	cf.PC = sf.PC
+	cf.Self, _ = r.object(sf.Self).(BaseObject)
+
+	for i, id := range sf.Locals {
+		if id != 0 {
+			cf.insertLCL(i, 0, r.object(id))
+		}
+	}
+
+	cf.LPr = len(sf.Locals)
+
+	for i, sp := range sf.CatchSP {
+		if i >= len(is.CatchTable) {
+			return nil, fmt.Errorf("can't find catch entry %d of %s", i, is.Label.Name)
+		}
+
+		if cf.catchSP == nil {
+			cf.catchSP = make(map[*CatchEntry]int)
+		}
+
+		cf.catchSP[is.CatchTable[i]] = sp
+	}
+
+	return cf, nil
+}
@@ -0,0 +1,221 @@
+package vm
+
+import (
+	"strings"
+	"testing"
+)
+
+const snapshotProgram = `
+class Counter
+  def initialize
+    @n = 0
+    @history = []
+  end
+
+  def inc
+    @n = @n + 1
+    @history.push(@n)
+  end
+
+  def n
+    @n
+  end
+end
+
+def total(c)
+  c.n * 10
+end
+
+$label = "count"
+c = Counter.new
+c.inc
+c.inc
+c.inc
+total(c)
+`
+
+func TestSnapshotAndRestore(t *testing.T) {
+	bytecodes := testCompile(t, "counter.ro", snapshotProgram)
+
+	v := New()
+	v.SetBreakpoint("counter.ro", 26)
+	var snapshot []byte
+	v.OnBreakpoint(func(b *Break) {
+		var err error
+		snapshot, err = v.Snapshot()
+
+		if err != nil {
+			t.Fatalf("Expect VM to be snapshotted. got=%s", err.Error())
+		}
+	})
+
+	evaluated := testExecWithVM(v, bytecodes)
+	testIntegerObject(t, evaluated, 30)
+
+	if snapshot == nil {
+		t.Fatalf("Expect snapshot to be taken at the breakpoint")
+	}
+
+	restored := New()
+	testLoadBytecodes(restored, bytecodes)
+
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Expect snapshot to be restored. got=%s", err.Error())
+	}
+
+	c := restored.CallFrameStack.Top().Local[0].Target.(*RObject)
+	n, _ := c.InstanceVariables.Get("@n")
+	testIntegerObject(t, n, 2)
+
+	if err := restored.Exec(); err != nil {
+		t.Fatalf("Expect restored VM to resume. got=%s", ErrorReport(err))
+	}
+
+	testIntegerObject(t, restored.Stack.Top().Target, 30)
+	testStringObject(t, restored.GetGlobal("$label"), "count")
+
+	history, _ := c.InstanceVariables.Get("@history")
+
+	if history.Inspect() != "Array:[1, 2, 3]" {
+		t.Fatalf("Expect restored array to be updated. got=%s", history.Inspect())
+	}
+}
+
+func TestSnapshotAtRest(t *testing.T) {
+	bytecodes := testCompile(t, "counter.ro", snapshotProgram)
+
+	v := New()
+	testExecWithVM(v, bytecodes)
+	snapshot, err := v.Snapshot()
+
+	if err != nil {
+		t.Fatalf("Expect VM to be snapshotted. got=%s", err.Error())
+	}
+
+	restored := New()
+	testLoadBytecodes(restored, bytecodes)
+
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Expect snapshot to be restored. got=%s", err.Error())
+	}
+
+	if restored.CFP != 0 || restored.SP != v.SP {
+		t.Fatalf("Expect restored VM to be at rest with the same stack. got CFP=%d SP=%d", restored.CFP, restored.SP)
+	}
+
+	class := restored.Constants["Counter"].Target.(*RClass)
+
+	if class == v.Constants["Counter"].Target || class.LookupInstanceMethod("inc") == nil {
+		t.Fatalf("Expect Counter to be restored with its methods")
+	}
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		line     int
+		expected string
+	}{
+		{`
+		def foo
+		  1
+		end
+
+		foo
+		`, 3, "while it's running a method"},
+		{`
+		m = Mutex.new
+		1
+		`, 3, "isn't supported"},
+	}
+
+	for _, tt := range tests {
+		v := New()
+		v.SetBreakpoint("error.ro", tt.line)
+		var err error
+		v.OnBreakpoint(func(b *Break) {
+			_, err = v.Snapshot()
+		})
+
+		testEvalFileWithVM(t, v, "error.ro", tt.input)
+
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Fatalf("Expect snapshot to fail with %q. got=%v", tt.expected, err)
+		}
+	}
+}
+
+func TestSnapshotWithReopenedClasses(t *testing.T) {
+	bytecodes := testCompile(t, "reopen.ro", `
+	class Integer
+	  def double
+	    self * 2
+	  end
+
+	  def self.answer
+	    42
+	  end
+	end
+
+	21.double
+	`)
+
+	v := New()
+	testExecWithVM(v, bytecodes)
+	snapshot, err := v.Snapshot()
+
+	if err != nil {
+		t.Fatalf("Expect VM to be snapshotted. got=%s", err.Error())
+	}
+
+	restored := New()
+	testLoadBytecodes(restored, bytecodes)
+
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Expect snapshot to be restored. got=%s", err.Error())
+	}
+
+	if restored.lookupMethod(restored.initInteger(1), "double") == nil || restored.lookupMethod(IntegerClass, "answer") == nil {
+		t.Fatalf("Expect methods defined on Integer to be restored")
+	}
+
+	if other := New(); other.lookupMethod(other.initInteger(1), "double") != nil {
+		t.Fatalf("Expect methods defined on Integer to be restored only in the restored VM")
+	}
+}
+
+func TestSnapshotWithRanges(t *testing.T) {
+	bytecodes := testCompile(t, "range.ro", `
+	$inclusive = (1..5)
+	$exclusive = (2...4)
+	$dates = (Date.new(2024, 1, 1)..Date.new(2024, 1, 3))
+	1
+	`)
+
+	v := New()
+	testExecWithVM(v, bytecodes)
+	snapshot, err := v.Snapshot()
+
+	if err != nil {
+		t.Fatalf("Expect VM to be snapshotted. got=%s", err.Error())
+	}
+
+	restored := New()
+	testLoadBytecodes(restored, bytecodes)
+
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Expect snapshot to be restored. got=%s", err.Error())
+	}
+
+	for name, expected := range map[string]*RangeObject{
+		"$inclusive": {Class: RangeClass, Start: 1, End: 5},
+		"$exclusive": {Class: RangeClass, Start: 2, End: 4, Exclusive: true},
+		"$dates":     {Class: RangeClass, Start: 19723, End: 19725, Dates: true},
+	} {
+		r, ok := restored.GetGlobal(name).(*RangeObject)
+
+		if !ok || *r != *expected {
+			t.Fatalf("Expect %s to be restored as %s. got=%s", name, expected.Inspect(), restored.GetGlobal(name).Inspect())
+		}
+	}
+}
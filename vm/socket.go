@@ -0,0 +1,381 @@
+package vm
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+)
+
+var (
+	TCPServerClass *RTCPServer
+	TCPSocketClass *RTCPSocket
+)
+
+type RTCPServer struct {
+	*BaseClass
+}
+
+type RTCPSocket struct {
+	*BaseClass
+}
+
+// TCPServerObject listens for TCP connections, which are accepted as TCPSocketObjects.
+type TCPServerObject struct {
+	Class    *RTCPServer
+	listener net.Listener
+	closed   bool
+}
+
+func (s *TCPServerObject) Type() ObjectType {
+	return TCP_SERVER_OBJ
+}
+
+func (s *TCPServerObject) Inspect() string {
+	if s.closed {
+		return "#<TCPServer:" + s.listener.Addr().String() + " (closed)>"
+	}
+
+	return "#<TCPServer:" + s.listener.Addr().String() + ">"
+}
+
+func (s *TCPServerObject) ReturnClass() Class {
+	return s.Class
+}
+
+func (s *TCPServerObject) close() {
+	if !s.closed {
+		s.listener.Close()
+		s.closed = true
+	}
+}
+
+// TCPSocketObject is a TCP connection, opened by TCPSocket.new or accepted by TCPServer#accept.
+// Like files, it has to be closed by the program.
+type TCPSocketObject struct {
+	Class  *RTCPSocket
+	conn   net.Conn
+	reader *bufio.Reader
+	closed bool
+}
+
+func (s *TCPSocketObject) Type() ObjectType {
+	return TCP_SOCKET_OBJ
+}
+
+func (s *TCPSocketObject) Inspect() string {
+	if s.closed {
+		return "#<TCPSocket:" + s.conn.RemoteAddr().String() + " (closed)>"
+	}
+
+	return "#<TCPSocket:" + s.conn.RemoteAddr().String() + ">"
+}
+
+func (s *TCPSocketObject) ReturnClass() Class {
+	return s.Class
+}
+
+func (s *TCPSocketObject) close() {
+	if !s.closed {
+		s.conn.Close()
+		s.closed = true
+	}
+}
+
+func newTCPSocket(conn net.Conn) *TCPSocketObject {
+	return &TCPSocketObject{Class: TCPSocketClass, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// address returns the address host and port refer to, like "localhost:80".
+func (vm *VM) address(host, port Object) string {
+	return net.JoinHostPort(vm.stringArgument(host), strconv.Itoa(vm.integerArgument(port)))
+}
+
+// checkSocketOpen raises IOError if s is closed.
+func (vm *VM) checkSocketOpen(s *TCPSocketObject) {
+	if s.closed {
+		vm.raise(IOErrorClass, "closed stream")
+	}
+}
+
+// builtinTCPServerClassMethods has TCPServer.new. TCPServer.new(port) listens on every interface,
+// and TCPServer.new(host, port) on host only. Port 0 picks a free port, which TCPServer#port returns.
+var builtinTCPServerClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				var address string
+
+				switch len(args) {
+				case 1:
+					address = ":" + strconv.Itoa(vm.integerArgument(args[0]))
+				case 2:
+					address = vm.address(args[0], args[1])
+				default:
+					return newError("Expect 1 or 2 arguments. got=%d", len(args))
+				}
+
+				l, err := net.Listen("tcp", address)
+
+				if err != nil {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				return &TCPServerObject{Class: TCPServerClass, listener: l}
+			}
+		},
+		Name: "new",
+	},
+}
+
+var builtinTCPServerMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				s := receiver.(*TCPServerObject)
+
+				if s.closed {
+					vm.raise(IOErrorClass, "closed stream")
+				}
+
+				conn, err := s.listener.Accept()
+
+				if err != nil {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				return newTCPSocket(conn)
+			}
+		},
+		Name: "accept",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initInteger(receiver.(*TCPServerObject).listener.Addr().(*net.TCPAddr).Port)
+			}
+		},
+		Name: "port",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				receiver.(*TCPServerObject).close()
+				return NULL
+			}
+		},
+		Name: "close",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return toBooleanObject(receiver.(*TCPServerObject).closed)
+			}
+		},
+		Name: "closed?",
+	},
+}
+
+var builtinTCPSocketClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 2 {
+					return newError("Expect 2 arguments. got=%d", len(args))
+				}
+
+				conn, err := net.Dial("tcp", vm.address(args[0], args[1]))
+
+				if err != nil {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				return newTCPSocket(conn)
+			}
+		},
+		Name: "new",
+	},
+}
+
+// builtinTCPSocketMethods are methods of connections. `read` returns everything until the other side closes
+// the connection, or at most length bytes if it's given. Like Ruby, read(length) returns nil once there's nothing left to read.
+var builtinTCPSocketMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				s := receiver.(*TCPSocketObject)
+				vm.checkSocketOpen(s)
+
+				if len(args) == 0 {
+					content, err := ioutil.ReadAll(s.reader)
+
+					if err != nil {
+						vm.raise(IOErrorClass, "%s", err.Error())
+					}
+
+					return InitializeString(string(content))
+				}
+
+				length := vm.integerArgument(args[0])
+
+				if length < 0 {
+					vm.raise(ArgumentErrorClass, "negative length %d given", length)
+				}
+
+				buf := make([]byte, length)
+				n, err := io.ReadFull(s.reader, buf)
+
+				if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				if n == 0 && length > 0 {
+					return NULL
+				}
+
+				return InitializeString(string(buf[:n]))
+			}
+		},
+		Name: "read",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				s := receiver.(*TCPSocketObject)
+				vm.checkSocketOpen(s)
+
+				line, err := s.reader.ReadString('\n')
+
+				if err != nil && err != io.EOF {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				// gets returns nil once the other side closes the connection
+				if line == "" {
+					return NULL
+				}
+
+				return InitializeString(line)
+			}
+		},
+		Name: "gets",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				s := receiver.(*TCPSocketObject)
+				vm.checkSocketOpen(s)
+
+				n, err := io.WriteString(s.conn, vm.stringArgument(args[0]))
+
+				if err != nil {
+					vm.raise(IOErrorClass, "%s", err.Error())
+				}
+
+				return vm.initInteger(n)
+			}
+		},
+		Name: "write",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				s := receiver.(*TCPSocketObject)
+				vm.checkSocketOpen(s)
+
+				for _, arg := range args {
+					if _, err := io.WriteString(s.conn, vm.toS(arg)+"\n"); err != nil {
+						vm.raise(IOErrorClass, "%s", err.Error())
+					}
+				}
+
+				return NULL
+			}
+		},
+		Name: "puts",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				receiver.(*TCPSocketObject).close()
+				return NULL
+			}
+		},
+		Name: "close",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return toBooleanObject(receiver.(*TCPSocketObject).closed)
+			}
+		},
+		Name: "closed?",
+	},
+}
+
+func initSocket() {
+	methods := NewEnvironment()
+	classMethods := NewEnvironment()
+
+	for _, m := range builtinTCPServerMethods {
+		methods.Set(m.Name, m)
+	}
+
+	for _, m := range builtinTCPServerClassMethods {
+		classMethods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "TCPServer", Methods: methods, ClassMethods: classMethods, Class: ClassClass, SuperClass: ObjectClass}
+	TCPServerClass = &RTCPServer{BaseClass: bc}
+
+	methods = NewEnvironment()
+	classMethods = NewEnvironment()
+
+	for _, m := range builtinTCPSocketMethods {
+		methods.Set(m.Name, m)
+	}
+
+	for _, m := range builtinTCPSocketClassMethods {
+		classMethods.Set(m.Name, m)
+	}
+
+	bc = &BaseClass{Name: "TCPSocket", Methods: methods, ClassMethods: classMethods, Class: ClassClass, SuperClass: ObjectClass}
+	TCPSocketClass = &RTCPSocket{BaseClass: bc}
+}
@@ -0,0 +1,192 @@
+package vm
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTCPSocket(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer l.Close()
+
+	// the server answers the first line it reads in upper case and closes the connection
+	go func() {
+		conn, err := l.Accept()
+
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		conn.Write([]byte("got " + line + "bye\n"))
+	}()
+
+	v := New()
+	v.SetConstant("PORT", v.initInteger(l.Addr().(*net.TCPAddr).Port))
+	evaluated := testEvalWithVM(t, v, `
+	s = TCPSocket.new("127.0.0.1", PORT)
+	n = s.write("hi ")
+	s.puts("hello")
+	first = s.gets
+	rest = s.read
+	s.close
+	first + rest + n.to_s + s.closed?.to_s
+	`)
+
+	testStringObject(t, evaluated, "got hi hello\nbye\n3true")
+}
+
+func TestTCPServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	replies := make(chan string)
+
+	// the client retries until the program's server listens
+	go func() {
+		for i := 0; i < 100; i++ {
+			conn, err := net.Dial("tcp", l.Addr().String())
+
+			if err != nil {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+
+			conn.Write([]byte("ping\n12345"))
+			reply, _ := bufio.NewReader(conn).ReadString('\n')
+			conn.Close()
+			replies <- reply
+			return
+		}
+
+		replies <- "can't connect"
+	}()
+
+	v := New()
+	v.SetConstant("PORT", v.initInteger(port))
+	evaluated := testEvalWithVM(t, v, `
+	server = TCPServer.new("127.0.0.1", PORT)
+	client = server.accept
+	line = client.gets
+	bytes = client.read(3)
+	client.puts(line.chomp + " " + bytes)
+	client.close
+	server.close
+	server.port == PORT
+	`)
+
+	testBooleanObject(t, evaluated, true)
+
+	if reply := <-replies; reply != "ping 123\n" {
+		t.Fatalf("Expect the server to reply. got=%q", reply)
+	}
+}
+
+func TestTCPSocketRead(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer l.Close()
+
+	go func() {
+		conn, err := l.Accept()
+
+		if err != nil {
+			return
+		}
+
+		conn.Write([]byte("abcde"))
+		conn.Close()
+	}()
+
+	v := New()
+	v.SetConstant("PORT", v.initInteger(l.Addr().(*net.TCPAddr).Port))
+	evaluated := testEvalWithVM(t, v, `
+	s = TCPSocket.new("127.0.0.1", PORT)
+	a = s.read(2)
+	b = s.read(10)
+	c = s.read(1)
+	s.close
+	[a, b, c]
+	`)
+
+	arr, ok := evaluated.(*ArrayObject)
+
+	if !ok || len(arr.Elements) != 3 {
+		t.Fatalf("Expect an array of 3 elements. got=%s", evaluated.Inspect())
+	}
+
+	testStringObject(t, arr.Elements[0], "ab")
+	testStringObject(t, arr.Elements[1], "cde")
+	testNullObject(t, arr.Elements[2])
+}
+
+func TestSocketErrors(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer l.Close()
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+
+			if err != nil {
+				return
+			}
+
+			conn.Close()
+		}
+	}()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		s = TCPSocket.new("127.0.0.1", PORT)
+		s.close
+		s.gets
+		`, "IOError: closed stream"},
+		{`
+		s = TCPSocket.new("127.0.0.1", PORT)
+		s.read(-1)
+		`, "ArgumentError: negative length -1 given"},
+		{`
+		s = TCPServer.new("127.0.0.1", 0)
+		s.close
+		s.accept
+		`, "IOError: closed stream"},
+		{`TCPSocket.new(1, 80)`, "TypeError: wrong argument type 1 (expected String)"},
+	}
+
+	for i, tt := range tests {
+		v := New()
+		v.SetConstant("PORT", v.initInteger(l.Addr().(*net.TCPAddr).Port))
+		err := testEvalError(t, v, "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
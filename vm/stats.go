@@ -0,0 +1,114 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Stats counts executed instructions per opcode and per call site. Set it to VM.Stats to start collecting.
+// Read the maps only once the program has finished, OpcodeCounts can be used while it's running.
+type Stats struct {
+	Opcodes map[string]int
+	// CallSites counts send and invokeblock instructions by their location and called method.
+	CallSites map[string]int
+	mu        sync.Mutex
+}
+
+// histogramWidth is the width of the longest bar in a Stats report.
+const histogramWidth = 40
+
+// NewStats returns empty Stats.
+func NewStats() *Stats {
+	return &Stats{Opcodes: make(map[string]int), CallSites: make(map[string]int)}
+}
+
+func (s *Stats) record(cf *CallFrame, i *Instruction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := i.Action.Name
+	s.Opcodes[name]++
+
+	switch i.Action.Opcode {
+	case OP_SEND:
+		s.CallSites[fmt.Sprintf("%s send %s", cf.Location(), i.Params[0])]++
+	case OP_INVOKE_BLOCK:
+		s.CallSites[fmt.Sprintf("%s invokeblock", cf.Location())]++
+	case OP_INVOKE_SUPER:
+		s.CallSites[fmt.Sprintf("%s invokesuper", cf.Location())]++
+	}
+}
+
+// OpcodeCounts returns a copy of Opcodes, it's safe to call from other goroutines while the program is running.
+func (s *Stats) OpcodeCounts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]int)
+
+	for name, count := range s.Opcodes {
+		counts[name] = count
+	}
+
+	return counts
+}
+
+// Report returns a histogram of executed opcodes followed by the call sites, both sorted by count.
+func (s *Stats) Report() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out bytes.Buffer
+	opcodes := sortCounts(s.Opcodes)
+	total := 0
+
+	for _, c := range opcodes {
+		total += c.count
+	}
+
+	out.WriteString(fmt.Sprintf("Instructions: %d executed\n", total))
+
+	for _, c := range opcodes {
+		bar := strings.Repeat("#", c.count*histogramWidth/opcodes[0].count)
+		out.WriteString(fmt.Sprintf("  %-22s %8d %6.2f%% %s\n", c.name, c.count, float64(c.count)*100/float64(total), bar))
+	}
+
+	out.WriteString("Call sites:\n")
+
+	for _, c := range sortCounts(s.CallSites) {
+		out.WriteString(fmt.Sprintf("  %8d %s\n", c.count, c.name))
+	}
+
+	return out.String()
+}
+
+type namedCount struct {
+	name  string
+	count int
+}
+
+type byCount []namedCount
+
+func (c byCount) Len() int      { return len(c) }
+func (c byCount) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c byCount) Less(i, j int) bool {
+	if c[i].count != c[j].count {
+		return c[i].count > c[j].count
+	}
+
+	return c[i].name < c[j].name
+}
+
+func sortCounts(counts map[string]int) []namedCount {
+	result := []namedCount{}
+
+	for name, count := range counts {
+		result = append(result, namedCount{name: name, count: count})
+	}
+
+	sort.Sort(byCount(result))
+	return result
+}
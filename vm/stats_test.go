@@ -0,0 +1,75 @@
+package vm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	input := `
+	def foo(a)
+	  a + 1
+	end
+
+	foo(1)
+	foo(2)
+	[1, 2].each do |x|
+	  x
+	end
+	`
+
+	v := New()
+	v.Stats = NewStats()
+	testEvalFileWithVM(t, v, "stats.ro", input)
+
+	opcodes := map[string]int{
+		"def_method": 1,
+		"send":       5,
+		"getlocal":   4,
+		"putobject":  6,
+		"newarray":   1,
+	}
+
+	for name, count := range opcodes {
+		if v.Stats.Opcodes[name] != count {
+			t.Fatalf("Expect %s to be executed %d times. got=%d", name, count, v.Stats.Opcodes[name])
+		}
+	}
+
+	callSites := map[string]int{
+		"stats.ro:3:in 'foo' send +":       2,
+		"stats.ro:6:in '<main>' send foo":  1,
+		"stats.ro:7:in '<main>' send foo":  1,
+		"stats.ro:8:in '<main>' send each": 1,
+	}
+
+	for site, count := range callSites {
+		if v.Stats.CallSites[site] != count {
+			t.Fatalf("Expect call site %q to be counted %d times. got=%v", site, count, v.Stats.CallSites)
+		}
+	}
+
+	report := v.Stats.Report()
+	lines := strings.Split(report, "\n")
+
+	if !strings.HasPrefix(lines[0], "Instructions: ") {
+		t.Fatalf("Expect report to start with total instruction count. got=%q", lines[0])
+	}
+
+	if !strings.Contains(lines[1], "putobject") || !strings.HasSuffix(lines[1], strings.Repeat("#", histogramWidth)) {
+		t.Fatalf("Expect the most executed opcode to be listed first with the longest bar. got=%q", lines[1])
+	}
+
+	if !strings.Contains(report, "Call sites:\n         2 stats.ro:3:in 'foo' send +\n") {
+		t.Fatalf("Expect report to list call sites by count. got:\n%s", report)
+	}
+}
+
+func TestStatsDisabledByDefault(t *testing.T) {
+	v := New()
+	testEvalWithVM(t, v, `1 + 1`)
+
+	if v.Stats != nil {
+		t.Fatalf("Expect stats not to be collected unless enabled")
+	}
+}
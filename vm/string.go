@@ -1,6 +1,11 @@
 package vm
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
 
 var (
 	StringClass *RString
@@ -13,6 +18,11 @@ type RString struct {
 type StringObject struct {
 	Class *RString
 	Value string
+	// encodingObject is nil for UTF-8 strings, use encoding to get it
+	encodingObject *EncodingObject
+	// trackedSize is the size the VM's object limits count the string as, 0 if it isn't counted
+	trackedSize int64
+	frozenFlag
 }
 
 func (s *StringObject) Type() ObjectType {
@@ -31,48 +41,299 @@ func (s *StringObject) ReturnClass() Class {
 	return s.Class
 }
 
-var (
-	stringTable = make(map[string]*StringObject)
-)
-
+// InitializeString returns a new string of value. Strings can be changed in place, so every string, including each
+// evaluation of a literal, is an object of its own.
 func InitializeString(value string) *StringObject {
-	addr, ok := stringTable[value]
+	return &StringObject{Value: value, Class: StringClass}
+}
+
+// setValue changes s in place.
+func (s *StringObject) setValue(value string) {
+	s.Value = value
+}
+
+// slice returns where the part of a string or an array of length n that str[args] or arr[args] refers to starts,
+// and how long it is. args are an index, a start and a length, or a range, and negative indexes count from the end.
+// ok is false if the start is out of the string or array.
+func (vm *VM) slice(n int, args []Object) (start, length int, ok bool) {
+	if r, isRange := args[0].(*RangeObject); isRange && len(args) == 1 {
+		start, end := r.Start, vm.integerRange(r).End
+
+		if start < 0 {
+			start += n
+		}
+
+		if end < 0 {
+			end += n
+		}
+
+		if !r.Exclusive {
+			end++
+		}
+
+		if start < 0 || start > n {
+			return 0, 0, false
+		}
+
+		if end > n {
+			end = n
+		}
+
+		if end < start {
+			end = start
+		}
+
+		return start, end - start, true
+	}
+
+	start = vm.integerArgument(args[0])
+
+	if start < 0 {
+		start += n
+	}
+
+	if len(args) == 1 {
+		return start, 1, start >= 0 && start < n
+	}
+
+	length = vm.integerArgument(args[1])
+
+	if start < 0 || start > n || length < 0 {
+		return 0, 0, false
+	}
+
+	if start+length > n {
+		length = n - start
+	}
+
+	return start, length, true
+}
+
+// chars returns every character of s as a string in the encoding of s.
+func chars(s *StringObject) []Object {
+	objects := []Object{}
+
+	for _, c := range s.characters() {
+		objects = append(objects, initEncodedString(c, s.encoding()))
+	}
+
+	return objects
+}
+
+// lines returns every line of s, lines keep their "\n".
+func lines(s string) []Object {
+	objects := []Object{}
+
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if line != "" {
+			objects = append(objects, InitializeString(line))
+		}
+	}
+
+	return objects
+}
+
+// eachString yields each of objects to the block, or returns an Enumerator of them if there's no block.
+func (vm *VM) eachString(receiver Object, objects []Object, blockFrame *CallFrame) Object {
+	if blockFrame == nil {
+		return InitializeEnumerator(sliceIterator(objects))
+	}
+
+	for _, o := range objects {
+		vm.builtInMethodYield(blockFrame, o)
+	}
+
+	return receiver
+}
+
+// integerArgument returns the value of arg, or raises TypeError if it isn't an integer.
+func (vm *VM) integerArgument(arg Object) int {
+	i, ok := arg.(*IntegerObject)
 
 	if !ok {
-		s := &StringObject{Value: value, Class: StringClass}
-		stringTable[value] = s
+		vm.raise(TypeErrorClass, "wrong argument type %s (expected Integer)", vm.inspectForError(arg))
+	}
+
+	if i.big != nil {
+		vm.raise(RangeErrorClass, "bignum too big to convert into 'long'")
+	}
+
+	return i.Value
+}
+
+// stringArgument returns the value of arg, or raises TypeError if it isn't a string.
+func (vm *VM) stringArgument(arg Object) string {
+	s, ok := arg.(*StringObject)
+
+	if !ok {
+		vm.raise(TypeErrorClass, "wrong argument type %s (expected String)", vm.inspectForError(arg))
+	}
+
+	return s.Value
+}
+
+// split breaks s apart at each match of sep, which is a string or a regexp. Like Ruby, splitting on " " splits
+// on runs of whitespace and ignores leading whitespace, and trailing empty strings are removed.
+func (vm *VM) split(s string, sep Object) *ArrayObject {
+	var parts []string
+
+	switch sep := sep.(type) {
+	case *RegexpObject:
+		parts = sep.regexp.Split(s, -1)
+	case *StringObject:
+		if sep.Value == " " {
+			parts = strings.Fields(s)
+		} else {
+			parts = strings.Split(s, sep.Value)
+		}
+	default:
+		vm.raise(TypeErrorClass, "wrong argument type %s (expected String or Regexp)", vm.inspectForError(sep))
+	}
+
+	for len(parts) > 0 && parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+
+	elems := []Object{}
+
+	for _, part := range parts {
+		elems = append(elems, InitializeString(part))
+	}
+
+	arr := InitializeArray(elems)
+	vm.track(arr)
+
+	return arr
+}
+
+// stringTransformer returns a built in method of String that takes no argument and returns a new string made by fn.
+func stringTransformer(name string, fn func(s string) string) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return InitializeString(fn(receiver.(*StringObject).Value))
+			}
+		},
+		Name: name,
+	}
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+
+	if size == 0 {
 		return s
 	}
 
-	return addr
+	return string(unicode.ToUpper(r)) + strings.ToLower(s[size:])
+}
+
+func swapcase(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+
+		return unicode.ToUpper(r)
+	}, s)
+}
+
+// chomp removes a trailing line separator, which is "\n", "\r\n" or "\r".
+func chomp(s string) string {
+	if strings.HasSuffix(s, "\r\n") {
+		return s[:len(s)-2]
+	}
+
+	if strings.HasSuffix(s, "\n") || strings.HasSuffix(s, "\r") {
+		return s[:len(s)-1]
+	}
+
+	return s
+}
+
+// chop removes the last character, "\r\n" counts as one character.
+func chop(s string) string {
+	if strings.HasSuffix(s, "\r\n") {
+		return s[:len(s)-2]
+	}
+
+	_, size := utf8.DecodeLastRuneInString(s)
+	return s[:len(s)-size]
 }
 
 var builtinStringMethods = []*BuiltInMethod{
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				err := checkArgumentLen(args, StringClass, "+")
 
 				if err != nil {
 					return err
 				}
 
-				leftValue := receiver.(*StringObject).Value
+				left := receiver.(*StringObject)
 				right, ok := args[0].(*StringObject)
 
 				if !ok {
 					return wrongTypeError(StringClass)
 				}
 
-				rightValue := right.Value
-				return &StringObject{Value: leftValue + rightValue, Class: StringClass}
+				return &StringObject{Value: left.Value + right.Value, Class: StringClass, encodingObject: left.encodingObject}
 			}
 		},
 		Name: "+",
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				s := receiver.(*StringObject)
+				times := vm.integerArgument(args[0])
+
+				if times < 0 {
+					vm.raise(ArgumentErrorClass, "negative argument")
+				}
+
+				if times > 0 && len(s.Value) > maxInt/times {
+					vm.raise(ArgumentErrorClass, "argument too big")
+				}
+
+				// the result is checked against the memory limit before it's built, since it can be much larger than s
+				vm.checkLimits(1, objectBaseSize+int64(len(s.Value)*times))
+
+				return &StringObject{Value: strings.Repeat(s.Value, times), Class: StringClass, encodingObject: s.encodingObject}
+			}
+		},
+		Name: "*",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				s := receiver.(*StringObject)
+				vm.checkFrozen(s)
+				other := vm.stringArgument(args[0])
+				vm.trackGrowth(s, int64(len(other)))
+				s.setValue(s.Value + other)
+
+				return s
+			}
+		},
+		Name: "<<",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				err := checkArgumentLen(args, StringClass, ">")
 				if err != nil {
 					return err
@@ -98,7 +359,7 @@ var builtinStringMethods = []*BuiltInMethod{
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				err := checkArgumentLen(args, StringClass, "<")
 				if err != nil {
 					return err
@@ -124,7 +385,7 @@ var builtinStringMethods = []*BuiltInMethod{
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				err := checkArgumentLen(args, StringClass, "==")
 
 				if err != nil {
@@ -135,7 +396,7 @@ var builtinStringMethods = []*BuiltInMethod{
 				right, ok := args[0].(*StringObject)
 
 				if !ok {
-					return wrongTypeError(StringClass)
+					return FALSE
 				}
 
 				rightValue := right.Value
@@ -151,7 +412,7 @@ var builtinStringMethods = []*BuiltInMethod{
 	},
 	{
 		Fn: func(receiver Object) BuiltinMethodBody {
-			return func(args []Object, block *Method) Object {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
 				err := checkArgumentLen(args, StringClass, "!=")
 
 				if err != nil {
@@ -162,7 +423,7 @@ var builtinStringMethods = []*BuiltInMethod{
 				right, ok := args[0].(*StringObject)
 
 				if !ok {
-					return wrongTypeError(StringClass)
+					return TRUE
 				}
 
 				rightValue := right.Value
@@ -176,6 +437,403 @@ var builtinStringMethods = []*BuiltInMethod{
 		},
 		Name: "!=",
 	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return InternSymbol(receiver.(*StringObject).Value)
+			}
+		},
+		Name: "to_sym",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return vm.toRegexp(args[0]).match(receiver.(*StringObject).Value)
+			}
+		},
+		Name: "match",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				r, ok := args[0].(*RegexpObject)
+
+				if !ok {
+					vm.raise(TypeErrorClass, "wrong argument type %s (expected Regexp)", vm.inspectForError(args[0]))
+				}
+
+				return vm.matchIndex(r, receiver.(*StringObject).Value)
+			}
+		},
+		Name: "=~",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return vm.scan(vm.toRegexp(args[0]), receiver.(*StringObject).Value)
+			}
+		},
+		Name: "scan",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				var sep Object = InitializeString(" ")
+
+				if len(args) == 1 {
+					sep = args[0]
+				}
+
+				return vm.split(receiver.(*StringObject).Value, sep)
+			}
+		},
+		Name: "split",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if blockFrame == nil && len(args) != 2 {
+					return newError("Expect 2 arguments. got=%d", len(args))
+				}
+
+				if blockFrame != nil && len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				var replacement Object
+
+				if len(args) == 2 {
+					replacement = args[1]
+				}
+
+				return vm.substitute(receiver.(*StringObject).Value, vm.toRegexp(args[0]), replacement, blockFrame, false)
+			}
+		},
+		Name: "sub",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if blockFrame == nil && len(args) != 2 {
+					return newError("Expect 2 arguments. got=%d", len(args))
+				}
+
+				if blockFrame != nil && len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				var replacement Object
+
+				if len(args) == 2 {
+					replacement = args[1]
+				}
+
+				return vm.substitute(receiver.(*StringObject).Value, vm.toRegexp(args[0]), replacement, blockFrame, true)
+			}
+		},
+		Name: "gsub",
+	},
+	stringTransformer("upcase", strings.ToUpper),
+	stringTransformer("downcase", strings.ToLower),
+	stringTransformer("capitalize", capitalize),
+	stringTransformer("swapcase", swapcase),
+	stringTransformer("strip", strings.TrimSpace),
+	stringTransformer("lstrip", func(s string) string {
+		return strings.TrimLeftFunc(s, unicode.IsSpace)
+	}),
+	stringTransformer("rstrip", func(s string) string {
+		return strings.TrimRightFunc(s, unicode.IsSpace)
+	}),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				s := receiver.(*StringObject).Value
+
+				if len(args) == 1 {
+					return InitializeString(strings.TrimSuffix(s, vm.stringArgument(args[0])))
+				}
+
+				return InitializeString(chomp(s))
+			}
+		},
+		Name: "chomp",
+	},
+	stringTransformer("chop", chop),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				// an array gives an argument for each directive
+				formatArgs := args
+
+				if arr, ok := args[0].(*ArrayObject); ok {
+					formatArgs = arr.Elements
+				}
+
+				return InitializeString(vm.format(receiver.(*StringObject).Value, formatArgs))
+			}
+		},
+		Name: "%",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) > 1 {
+					return newError("Expect 0 or 1 argument. got=%d", len(args))
+				}
+
+				return vm.initBigInteger(leadingInteger(receiver.(*StringObject).Value, vm.radixArgument(args)))
+			}
+		},
+		Name: "to_i",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return InitializeFloat(leadingFloat(receiver.(*StringObject).Value))
+			}
+		},
+		Name: "to_f",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) < 1 || len(args) > 2 {
+					return newError("Expect 1 or 2 arguments. got=%d", len(args))
+				}
+
+				// indexes count characters of the string's encoding, not bytes
+				s := receiver.(*StringObject)
+				chars := s.characters()
+				start, length, ok := vm.slice(len(chars), args)
+
+				if !ok {
+					return NULL
+				}
+
+				return initEncodedString(strings.Join(chars[start:start+length], ""), s.encoding())
+			}
+		},
+		Name: "[]",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) < 2 || len(args) > 3 {
+					return newError("Expect 2 or 3 arguments. got=%d", len(args))
+				}
+
+				s := receiver.(*StringObject)
+				vm.checkFrozen(s)
+
+				value := args[len(args)-1]
+				replacement := vm.stringArgument(value)
+				chars := s.characters()
+				start, length, ok := vm.slice(len(chars), args[:len(args)-1])
+
+				if !ok {
+					if r, isRange := args[0].(*RangeObject); isRange {
+						vm.raise(RangeErrorClass, "%s out of range", r.Inspect())
+					}
+
+					vm.raise(IndexErrorClass, "index %d out of string", vm.integerArgument(args[0]))
+				}
+
+				newValue := strings.Join(chars[:start], "") + replacement + strings.Join(chars[start+length:], "")
+				vm.trackGrowth(s, int64(len(newValue)-len(s.Value)))
+				s.setValue(newValue)
+
+				return value
+			}
+		},
+		Name: "[]=",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.eachString(receiver, chars(receiver.(*StringObject)), blockFrame)
+			}
+		},
+		Name: "each_char",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.eachString(receiver, lines(receiver.(*StringObject).Value), blockFrame)
+			}
+		},
+		Name: "each_line",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				arr := InitializeArray(chars(receiver.(*StringObject)))
+				vm.track(arr)
+
+				return arr
+			}
+		},
+		Name: "chars",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				bytes := []Object{}
+
+				for _, b := range []byte(receiver.(*StringObject).Value) {
+					bytes = append(bytes, vm.initInteger(int(b)))
+				}
+
+				arr := InitializeArray(bytes)
+				vm.track(arr)
+
+				return arr
+			}
+		},
+		Name: "bytes",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initInteger(len(receiver.(*StringObject).characters()))
+			}
+		},
+		Name: "length",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initInteger(len(receiver.(*StringObject).characters()))
+			}
+		},
+		Name: "size",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return vm.initInteger(len(receiver.(*StringObject).Value))
+			}
+		},
+		Name: "bytesize",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return receiver.(*StringObject).encoding()
+			}
+		},
+		Name: "encoding",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				s := receiver.(*StringObject)
+				vm.checkFrozen(s)
+
+				// the bytes stay the same, only how they're read as characters changes
+				s.setEncoding(vm.encodingArgument(args[0]))
+
+				return s
+			}
+		},
+		Name: "force_encoding",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return toBooleanObject(receiver.(*StringObject).validEncoding())
+			}
+		},
+		Name: "valid_encoding?",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) < 1 || len(args) > 2 {
+					return newError("Expect 1 or 2 arguments. got=%d", len(args))
+				}
+
+				s := receiver.(*StringObject)
+				to := vm.encodingArgument(args[0])
+				from := s.encoding()
+
+				// like Ruby, the second argument says what the string is in, whatever its encoding is
+				if len(args) == 2 {
+					from = vm.encodingArgument(args[1])
+				}
+
+				return initEncodedString(vm.transcode(s.Value, from, to), to)
+			}
+		},
+		Name: "encode",
+	},
 }
 
 func initString() {
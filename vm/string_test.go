@@ -1,6 +1,7 @@
 package vm
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -30,6 +31,12 @@ func TestEvalInfixStringExpression(t *testing.T) {
 		{`"1234" > "123"`, true},
 		{`"1234" < "123"`, false},
 		{`"1234" != "123"`, true},
+		{`"ab" * 3`, "ababab"},
+		{`"ab" * 0`, ""},
+		{`s = "Stan"; s << " Lo"; s`, "Stan Lo"},
+		{`s = "Stan"; s << " Lo"; "Stan"`, "Stan"},
+		{`c = "hi"; d = "hi"; c << "!"; d`, "hi"},
+		{`s = "Dog"; s << "&" << "Cat"`, "Dog&Cat"},
 	}
 
 	for _, tt := range tests {
@@ -42,3 +49,328 @@ func TestEvalInfixStringExpression(t *testing.T) {
 		}
 	}
 }
+
+func TestStringSplit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`"a,b,c".split(",")`, []string{"a", "b", "c"}},
+		{`"a,,b,,".split(",")`, []string{"a", "", "b"}},
+		{`"a::b::c".split("::")`, []string{"a", "b", "c"}},
+		{`"  foo bar   baz ".split`, []string{"foo", "bar", "baz"}},
+		{`"foo  bar".split(" ")`, []string{"foo", "bar"}},
+		{`"abc".split(",")`, []string{"abc"}},
+		{`",,".split(",")`, []string{}},
+		{`"a1b22c333".split(Regexp.new("\d+"))`, []string{"a", "b", "c"}},
+		{`"a, b,c".split(Regexp.new(",\s*"))`, []string{"a", "b", "c"}},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		arr, ok := evaluated.(*ArrayObject)
+
+		if !ok {
+			t.Fatalf("at test case %d: expect result to be an array. got=%T", i, evaluated)
+		}
+
+		if len(arr.Elements) != len(tt.expected) {
+			t.Fatalf("at test case %d: expect %d elements. got=%d", i, len(tt.expected), len(arr.Elements))
+		}
+
+		for j, expected := range tt.expected {
+			if !testStringObject(t, arr.Elements[j], expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestStringSplitErrors(t *testing.T) {
+	err := testEvalError(t, New(), "", `"a b".split(1)`)
+	expected := "TypeError: wrong argument type 1 (expected String or Regexp)"
+
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expect error %q. got=%v", expected, err)
+	}
+}
+
+func TestStringSubstitution(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"hello world".sub("o", "0")`, "hell0 world"},
+		{`"hello world".gsub("o", "0")`, "hell0 w0rld"},
+		{`"a.b.c".gsub(".", "-")`, "a-b-c"},
+		{`"abc".gsub("z", "-")`, "abc"},
+		{`"2017-07-01".sub(Regexp.new("(\d+)-(\d+)-(\d+)"), "\3/\2/\1")`, "01/07/2017"},
+		{`"john smith".gsub(Regexp.new("(\w+)"), "<\1>")`, "<john> <smith>"},
+		{`"abc".gsub(Regexp.new("b"), "[\0\&]")`, "a[bb]c"},
+		{`"a-b".sub(Regexp.new("(?<x>a)"), "\k<x>\k<x>")`, "aa-b"},
+		{`"a".sub("a", "\\")`, "\\"},
+		{`"a1b22".gsub(Regexp.new("\d+")) do |m| m + m end`, "a11b2222"},
+		{`"hello world".sub(Regexp.new("o")) do |m| "0" end`, "hell0 world"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testStringObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestStringSubstitutionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"abc".sub(1, "a")`, "TypeError: wrong argument type 1 (expected Regexp)"},
+		{`"abc".gsub("a", 1)`, "TypeError: wrong argument type 1 (expected String)"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestStringCaseAndWhitespace(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"Hello World".upcase`, "HELLO WORLD"},
+		{`"Hello World".downcase`, "hello world"},
+		{`"héllo".upcase`, "HÉLLO"},
+		{`"ÉCOLE".downcase`, "école"},
+		{`"hELLO wORLD".capitalize`, "Hello world"},
+		{`"élan".capitalize`, "Élan"},
+		{`"Hello World".swapcase`, "hELLO wORLD"},
+		{`"ÀbÇ".swapcase`, "àBç"},
+		{`"  foo  ".strip`, "foo"},
+		{`"  foo  ".lstrip`, "foo  "},
+		{`"  foo  ".rstrip`, "  foo"},
+		{`"foo".chomp`, "foo"},
+		{`"hello.rb".chomp(".rb")`, "hello"},
+		{`"foo".chop`, "fo"},
+		{`"日本語".chop`, "日本"},
+		{`"a".chop.chop`, ""},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testStringObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestStringChompLines(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`STDIN.gets.chomp`, "foo"},
+		{`STDIN.gets; STDIN.gets.chomp`, "bar"},
+		{`STDIN.gets; STDIN.gets; STDIN.read.chomp`, "baz\n"},
+		{`STDIN.gets.chop`, "foo"},
+		{`STDIN.gets; STDIN.gets.chop`, "bar"},
+	}
+
+	for i, tt := range tests {
+		// string literals can't contain line breaks yet, so lines are read from STDIN
+		v := New()
+		v.SetStdin(strings.NewReader("foo\nbar\r\nbaz\n\n"))
+
+		evaluated := testEvalWithVM(t, v, tt.input)
+
+		if !testStringObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestStringIndex(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"hello"[0]`, "h"},
+		{`"hello"[4]`, "o"},
+		{`"hello"[-1]`, "o"},
+		{`"hello"[-5]`, "h"},
+		{`"hello"[5]`, nil},
+		{`"hello"[-6]`, nil},
+		{`"日本語"[1]`, "本"},
+		{`"hello"[1, 3]`, "ell"},
+		{`"hello"[-3, 2]`, "ll"},
+		{`"hello"[3, 10]`, "lo"},
+		{`"hello"[5, 1]`, ""},
+		{`"hello"[6, 1]`, nil},
+		{`"hello"[1, -1]`, nil},
+		{`"hello"[1..3]`, "ell"},
+		{`"hello"[1...3]`, "el"},
+		{`"hello"[1..-1]`, "ello"},
+		{`"hello"[-3..-2]`, "ll"},
+		{`"hello"[3..1]`, ""},
+		{`"hello"[2..10]`, "llo"},
+		{`"hello"[6..7]`, nil},
+		{`"日本語です"[1..2]`, "本語"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case string:
+			if !testStringObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		default:
+			if !testNullObject(t, evaluated) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestStringIndexAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`s = "hello"; s[0] = "J"; s`, "Jello"},
+		{`s = "hello"; s[-1] = "!!"; s`, "hell!!"},
+		{`s = "hello"; s[1, 3] = "ipp"; s`, "hippo"},
+		{`s = "hello"; s[5, 0] = " world"; s`, "hello world"},
+		{`s = "hello"; s[1..3] = "a"; s`, "hao"},
+		{`s = "日本語"; s[1] = "x"; s`, "日x語"},
+		{`s = "hello"; s[0] = "J"; "hello"`, "hello"},
+		{`a = "abc"; b = "abc"; a[0] = "X"; b`, "abc"},
+		{`s = "hello"; s[0] = "J"`, "J"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if !testStringObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d", i)
+		}
+	}
+}
+
+func TestStringIndexErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"hello"["a"]`, "TypeError: wrong argument type a (expected Integer)"},
+		{`s = "hello"; s[5] = "a"`, "IndexError: index 5 out of string"},
+		{`s = "hello"; s[-6, 1] = "a"`, "IndexError: index -6 out of string"},
+		{`s = "hello"; s[6..7] = "a"`, "RangeError: 6..7 out of range"},
+		{`s = "hello"; s[0] = 1`, "TypeError: wrong argument type 1 (expected String)"},
+		{`s = "hello"; s.freeze; s[0] = "a"`, "FrozenError: can't modify frozen String: hello"},
+		{`"ab" * -1`, "ArgumentError: negative argument"},
+		{`"ab" * "a"`, "TypeError: wrong argument type a (expected Integer)"},
+		{`s = "Stan"; s << 1`, "TypeError: wrong argument type 1 (expected String)"},
+		{`s = "frozen"; s.freeze; s << "a"`, "FrozenError: can't modify frozen String: frozen"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestStringCharactersAndBytes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"hello".length`, 5},
+		{`"日本語".length`, 3},
+		{`"日本語".size`, 3},
+		{`"日本語".bytesize`, 9},
+		{`"hello".bytesize`, 5},
+		{`"日本語".chars.length`, 3},
+		{`"日本語".chars[2]`, "語"},
+		{`"abc".bytes[1]`, 98},
+		{`"é".bytes.length`, 2},
+		{`"é".bytes[0]`, 195},
+		{`
+		result = "<"
+		"日本語".each_char do |c|
+		  result = c + result
+		end
+		result
+		`, "語本日<"},
+		{`"abc".each_char.to_a[2]`, "c"},
+		{`
+		count = 0
+		"abc".each_char do |c|
+		  count = count + 1
+		end.length
+		`, 3},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			if !testIntegerObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		case string:
+			if !testStringObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestStringEachLine(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		count = 0
+		STDIN.read.each_line do |line|
+		  count = count + 1
+		end
+		count
+		`, 3},
+		{`STDIN.read.each_line.to_a[0]`, "one\n"},
+		{`STDIN.read.each_line.to_a[2]`, "three"},
+	}
+
+	for i, tt := range tests {
+		// string literals can't contain line breaks yet, so lines are read from STDIN
+		v := New()
+		v.SetStdin(strings.NewReader("one\ntwo\nthree"))
+
+		evaluated := testEvalWithVM(t, v, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			if !testIntegerObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		case string:
+			if !testStringObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package vm
+
+import (
+	"bytes"
+)
+
+var (
+	StructClass *RClass
+)
+
+// structMembers returns the members of a class Struct.new created, or of its superclass if c inherits one.
+func structMembers(c *RClass) ([]string, bool) {
+	for ; c != nil; c = c.SuperClass {
+		if c.structMembers != nil {
+			return c.structMembers, true
+		}
+	}
+
+	return nil, false
+}
+
+// newStructClass returns a class with an accessor for each of members, its instances keep their members in
+// instance variables of the same names. The class is anonymous until it's assigned to a constant.
+func (vm *VM) newStructClass(members []string) *RClass {
+	class := newClass("", vm.classClass, StructClass)
+	class.structMembers = members
+
+	for _, m := range members {
+		class.Methods.Set(m, structReader(m))
+		class.Methods.Set(m+"=", structWriter(m))
+	}
+
+	for _, m := range builtinStructClassMethods {
+		class.ClassMethods.Set(m.Name, m)
+	}
+
+	return class
+}
+
+// structReader returns the built in reader method of member.
+func structReader(member string) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				value, _ := receiver.(*RObject).InstanceVariables.Get("@" + member)
+				return value
+			}
+		},
+		Name: member,
+	}
+}
+
+// structWriter returns the built in writer method of member.
+func structWriter(member string) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				vm.checkFrozen(receiver)
+				return receiver.(*RObject).InstanceVariables.Set("@"+member, args[0])
+			}
+		},
+		Name: member + "=",
+	}
+}
+
+// structValues returns the values of o's members in order.
+func structValues(o *RObject, members []string) []Object {
+	values := []Object{}
+
+	for _, m := range members {
+		value, _ := o.InstanceVariables.Get("@" + m)
+		values = append(values, value)
+	}
+
+	return values
+}
+
+// isKeywordArguments reports whether args is a single hash whose keys all name members, which initializes
+// a struct by name like Point.new({ x: 1, y: 2 }).
+func isKeywordArguments(args []Object, members []string) bool {
+	if len(args) != 1 {
+		return false
+	}
+
+	hash, ok := args[0].(*HashObject)
+
+	if !ok || hash.Length() == 0 {
+		return false
+	}
+
+	for _, key := range hash.Keys() {
+		name := ""
+
+		switch key := key.(type) {
+		case *StringObject:
+			name = key.Value
+		case *SymbolObject:
+			name = key.Name
+		}
+
+		if !containsString(members, name) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsString(strings []string, s string) bool {
+	for _, str := range strings {
+		if str == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inspectStruct returns how a struct is inspected, like "#<struct Point x=1, y=2>".
+func (vm *VM) inspectStruct(o *RObject, members []string) string {
+	var out bytes.Buffer
+	out.WriteString("#<struct ")
+
+	if o.Class.Name != "" {
+		out.WriteString(o.Class.Name + " ")
+	}
+
+	for i, value := range structValues(o, members) {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+
+		out.WriteString(members[i] + "=" + vm.inspect(value))
+	}
+
+	out.WriteString(">")
+
+	return out.String()
+}
+
+// builtinStructClassMethods are the class methods of classes Struct.new creates.
+var builtinStructClassMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				class := receiver.(*RClass)
+				members, _ := structMembers(class)
+
+				if len(args) > len(members) {
+					vm.raise(ArgumentErrorClass, "struct size differs")
+				}
+
+				instance := InitializeInstance(class)
+				vm.track(instance)
+
+				for _, m := range members {
+					instance.InstanceVariables.Set("@"+m, NULL)
+				}
+
+				if isKeywordArguments(args, members) {
+					hash := args[0].(*HashObject)
+
+					for i, key := range hash.Keys() {
+						instance.InstanceVariables.Set("@"+vm.nameArgument(key), hash.Values()[i])
+					}
+
+					return instance
+				}
+
+				for i, arg := range args {
+					instance.InstanceVariables.Set("@"+members[i], arg)
+				}
+
+				return instance
+			}
+		},
+		Name: "new",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				members, _ := structMembers(receiver.(*RClass))
+				return vm.symbolArray(members)
+			}
+		},
+		Name: "members",
+	},
+}
+
+// symbolArray returns an array of the symbols of names.
+func (vm *VM) symbolArray(names []string) *ArrayObject {
+	symbols := []Object{}
+
+	for _, name := range names {
+		symbols = append(symbols, InternSymbol(name))
+	}
+
+	arr := InitializeArray(symbols)
+	vm.track(arr)
+
+	return arr
+}
+
+// builtinStructMethods are the instance methods every struct shares.
+var builtinStructMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				// structs are equal if they're of the same class and their members are ==
+				o := receiver.(*RObject)
+				other, ok := args[0].(*RObject)
+
+				if !ok || other.Class != o.Class {
+					return FALSE
+				}
+
+				members, _ := structMembers(o.Class)
+				otherValues := structValues(other, members)
+
+				for i, value := range structValues(o, members) {
+					if !vm.equal(value, otherValues[i]) {
+						return FALSE
+					}
+				}
+
+				return TRUE
+			}
+		},
+		Name: "==",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				o := receiver.(*RObject)
+				members, _ := structMembers(o.Class)
+				hash := InitializeHash(map[string]Object{})
+
+				for i, value := range structValues(o, members) {
+					hash.set(vm, InitializeString(members[i]), value)
+				}
+
+				vm.track(hash)
+
+				return hash
+			}
+		},
+		Name: "to_h",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				o := receiver.(*RObject)
+				members, _ := structMembers(o.Class)
+				arr := InitializeArray(structValues(o, members))
+				vm.track(arr)
+
+				return arr
+			}
+		},
+		Name: "to_a",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				members, _ := structMembers(receiver.(*RObject).Class)
+				return vm.symbolArray(members)
+			}
+		},
+		Name: "members",
+	},
+}
+
+// builtinStructGeneratorMethods are the class methods of Struct itself.
+var builtinStructGeneratorMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) == 0 {
+					vm.raise(ArgumentErrorClass, "wrong number of arguments (given 0, expected 1+)")
+				}
+
+				members := []string{}
+
+				for _, arg := range args {
+					name := vm.nameArgument(arg)
+
+					if containsString(members, name) {
+						vm.raise(ArgumentErrorClass, "duplicate member: %s", name)
+					}
+
+					members = append(members, name)
+				}
+
+				return vm.newStructClass(members)
+			}
+		},
+		Name: "new",
+	},
+}
+
+func initStruct() {
+	StructClass = InitializeClass("Struct")
+
+	for _, m := range builtinStructMethods {
+		StructClass.Methods.Set(m.Name, m)
+	}
+
+	for _, m := range builtinStructGeneratorMethods {
+		StructClass.ClassMethods.Set(m.Name, m)
+	}
+}
@@ -0,0 +1,128 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		Point = Struct.new("x", "y")
+		p = Point.new(1, 2)
+		p.x + p.y
+		`, 3},
+		{`
+		Point = Struct.new("x", "y")
+		p = Point.new(1, 2)
+		p.x = 10
+		p.x
+		`, 10},
+		{`
+		Point = Struct.new("x", "y")
+		Point.new(1).y
+		`, nil},
+		{`
+		Point = Struct.new("x", "y")
+		p = Point.new({ y: 2, x: 1 })
+		p.x - p.y
+		`, -1},
+		{`
+		Point = Struct.new("x".to_sym, "y".to_sym)
+		Point.new(1, 2) == Point.new(1, 2)
+		`, true},
+		{`
+		Point = Struct.new("x", "y")
+		Point.new(1, 2) == Point.new(1, 3)
+		`, false},
+		{`
+		Point = Struct.new("x", "y")
+		Size = Struct.new("x", "y")
+		Point.new(1, 2) == Size.new(1, 2)
+		`, false},
+		{`
+		Point = Struct.new("x", "y")
+		Point.new(1, 2) != Point.new(1, 2)
+		`, false},
+		{`
+		Point = Struct.new("x", "y")
+		Point.new(1, 2).to_h.to_s
+		`, "{ x: 1, y: 2 }"},
+		{`
+		Point = Struct.new("x", "y")
+		Point.new(1, 2).to_a.to_s
+		`, "Array:[1, 2]"},
+		{`
+		Point = Struct.new("x", "y")
+		Point.new(1, "a").to_s
+		`, "#<struct Point x=1, y=a>"},
+		{`
+		Point = Struct.new("x", "y")
+		Point.to_s
+		`, "<Class:Point>"},
+		{`
+		Point = Struct.new("x", "y")
+
+		class Point3 < Point
+		  def sum
+		    x + y
+		  end
+		end
+
+		Point3.new(3, 4).sum
+		`, 7},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			if !testIntegerObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		case bool:
+			if !testBooleanObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		case string:
+			if !testStringObject(t, evaluated, expected) {
+				t.Fatalf("at test case %d", i)
+			}
+		case nil:
+			if !testNullObject(t, evaluated) {
+				t.Fatalf("at test case %d", i)
+			}
+		}
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Struct.new`, "ArgumentError: wrong number of arguments (given 0, expected 1+)"},
+		{`Struct.new("x", "x")`, "ArgumentError: duplicate member: x"},
+		{`Struct.new(1)`, "TypeError: 1 is not a symbol nor a string"},
+		{`
+		Point = Struct.new("x", "y")
+		Point.new(1, 2, 3)
+		`, "ArgumentError: struct size differs"},
+		{`
+		Point = Struct.new("x", "y")
+		p = Point.new(1, 2).freeze
+		p.x = 3
+		`, "FrozenError: can't modify frozen Point: <Instance of: Point>"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package vm
+
+import "sync"
+
+var (
+	SymbolClass *RSymbol
+)
+
+type RSymbol struct {
+	*BaseClass
+}
+
+// SymbolObject is an interned name. There's only one SymbolObject for each name, shared by every VM,
+// so symbols can be compared by pointer.
+type SymbolObject struct {
+	Class *RSymbol
+	Name  string
+}
+
+func (s *SymbolObject) Type() ObjectType {
+	return SYMBOL_OBJ
+}
+
+func (s *SymbolObject) Inspect() string {
+	return ":" + s.Name
+}
+
+func (s *SymbolObject) ReturnClass() Class {
+	return s.Class
+}
+
+// symbolTable is guarded by a lock since VMs running on different goroutines intern into the same table.
+var symbolTable = struct {
+	sync.Mutex
+	symbols map[string]*SymbolObject
+}{symbols: make(map[string]*SymbolObject)}
+
+// InternSymbol returns the symbol of name, creating it the first time name is interned.
+func InternSymbol(name string) *SymbolObject {
+	symbolTable.Lock()
+	defer symbolTable.Unlock()
+
+	s, ok := symbolTable.symbols[name]
+
+	if !ok {
+		s = &SymbolObject{Class: SymbolClass, Name: name}
+		symbolTable.symbols[name] = s
+	}
+
+	return s
+}
+
+// nameArgument returns the name arg holds, which can be a string or a symbol, or raises TypeError
+func (vm *VM) nameArgument(arg Object) string {
+	switch n := arg.(type) {
+	case *StringObject:
+		return n.Value
+	case *SymbolObject:
+		return n.Name
+	}
+
+	vm.raise(TypeErrorClass, "%s is not a symbol nor a string", arg.Inspect())
+	return ""
+}
+
+var builtinSymbolMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, SymbolClass, "==")
+
+				if err != nil {
+					return err
+				}
+
+				return toBooleanObject(receiver == args[0])
+			}
+		},
+		Name: "==",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				err := checkArgumentLen(args, SymbolClass, "!=")
+
+				if err != nil {
+					return err
+				}
+
+				return toBooleanObject(receiver != args[0])
+			}
+		},
+		Name: "!=",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return InitializeString(receiver.(*SymbolObject).Name)
+			}
+		},
+		Name: "to_s",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				return receiver
+			}
+		},
+		Name: "to_sym",
+	},
+}
+
+func initSymbol() {
+	methods := NewEnvironment()
+
+	for _, m := range builtinSymbolMethods {
+		methods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "Symbol", Methods: methods, ClassMethods: NewEnvironment(), Class: ClassClass, SuperClass: ObjectClass}
+	SymbolClass = &RSymbol{BaseClass: bc}
+}
@@ -0,0 +1,64 @@
+package vm
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestEvalSymbolExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"foo".to_sym == "foo".to_sym`, true},
+		{`"foo".to_sym == "bar".to_sym`, false},
+		{`"foo".to_sym != "bar".to_sym`, true},
+		{`"foo".to_sym == "foo"`, false},
+		{`"foo".to_sym.to_s`, "foo"},
+		{`"foo".to_sym.to_sym.to_s`, "foo"},
+		{`"foo".to_sym.class.name`, "Symbol"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestInternSymbol(t *testing.T) {
+	if InternSymbol("foo") != InternSymbol("foo") {
+		t.Fatalf("Expect symbols of the same name to be the same object")
+	}
+
+	if InternSymbol("foo") == InternSymbol("bar") {
+		t.Fatalf("Expect symbols of different names to be different objects")
+	}
+
+	if s := InternSymbol("foo").Inspect(); s != ":foo" {
+		t.Fatalf("Expect symbol to be inspected as :foo. got=%s", s)
+	}
+
+	symbols := make([]*SymbolObject, 10)
+	var wg sync.WaitGroup
+
+	for i := range symbols {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			symbols[i] = InternSymbol("shared_between_goroutines")
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, s := range symbols {
+		if s != symbols[0] {
+			t.Fatalf("Expect symbols interned concurrently to be the same object")
+		}
+	}
+}
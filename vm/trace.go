@@ -0,0 +1,62 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Tracer writes every executed instruction along with the stack top and the call frame it runs in.
+// Set it to VM.Tracer to start tracing.
+type Tracer struct {
+	Out io.Writer
+	// Methods limits tracing to instructions of these methods and the blocks inside them. Empty means trace everything.
+	Methods []string
+}
+
+// NewTracer returns a Tracer that writes to out, tracing only the given methods if there are any.
+func NewTracer(out io.Writer, methods ...string) *Tracer {
+	return &Tracer{Out: out, Methods: methods}
+}
+
+func (t *Tracer) trace(vm *VM, cf *CallFrame, i *Instruction) {
+	if !t.traces(cf) {
+		return
+	}
+
+	params := []string{}
+
+	for _, param := range i.Params {
+		if _, ok := param.(*constantCache); ok {
+			continue
+		}
+
+		params = append(params, fmt.Sprint(param))
+	}
+
+	top := "(empty)"
+
+	if vm.SP > 0 {
+		if p := vm.Stack.Data[vm.SP-1]; p != nil {
+			top = inspectObject(p.Target)
+		}
+	}
+
+	fmt.Fprintf(t.Out, "%s [%d] %04d %s | top: %s\n", cf.Location(), vm.CFP, cf.PC-1, strings.TrimSpace(i.Action.Name+" "+strings.Join(params, " ")), top)
+}
+
+func (t *Tracer) traces(cf *CallFrame) bool {
+	if len(t.Methods) == 0 {
+		return true
+	}
+
+	name := strings.TrimPrefix(cf.MethodName(), "block in ")
+
+	for _, m := range t.Methods {
+		if m == name {
+			return true
+		}
+	}
+
+	return false
+}
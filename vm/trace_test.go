@@ -0,0 +1,68 @@
+package vm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTracer(t *testing.T) {
+	input := `
+	def foo(a)
+	  a + 1
+	end
+
+	foo(1)
+	`
+
+	var out bytes.Buffer
+	v := New()
+	v.Tracer = NewTracer(&out)
+	testEvalFileWithVM(t, v, "trace.ro", input)
+
+	expected := []string{
+		"trace.ro:6:in '<main>' [1] 0004 putobject 1 | top: main",
+		"trace.ro:6:in '<main>' [1] 0005 send foo 1 | top: 1",
+		"trace.ro:3:in 'foo' [2] 0000 getlocal 0 0 | top: 1",
+		"trace.ro:3:in 'foo' [2] 0002 send + 1 | top: 1",
+		"trace.ro:3:in 'foo' [2] 0003 leave | top: 2",
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Fatalf("Expect trace to contain %q. got:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestTracerMethodFilter(t *testing.T) {
+	input := `
+	def foo
+	  [1].each do |x|
+	    x
+	  end
+	end
+
+	def bar
+	  2
+	end
+
+	foo
+	bar
+	`
+
+	var out bytes.Buffer
+	v := New()
+	v.Tracer = NewTracer(&out, "foo")
+	testEvalFileWithVM(t, v, "trace.ro", input)
+
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if !strings.Contains(line, "in 'foo'") && !strings.Contains(line, "in 'block in foo'") {
+			t.Fatalf("Expect only foo and its blocks to be traced. got=%q", line)
+		}
+	}
+
+	if !strings.Contains(out.String(), "in 'block in foo'") {
+		t.Fatalf("Expect blocks in foo to be traced. got:\n%s", out.String())
+	}
+}
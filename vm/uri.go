@@ -0,0 +1,281 @@
+package vm
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	// URIModule parses URIs and encodes and decodes query strings, parsed URIs are URIObjects of URIClass.
+	URIModule *RClass
+	URIClass  *RURI
+)
+
+type RURI struct {
+	*BaseClass
+}
+
+// URIObject is a URI returned by URI.parse.
+type URIObject struct {
+	Class *RURI
+	url   *url.URL
+}
+
+func (u *URIObject) Type() ObjectType {
+	return URI_OBJ
+}
+
+// Inspect returns the URI itself, so it can be printed and passed to Net::HTTP as it is.
+func (u *URIObject) Inspect() string {
+	return u.url.String()
+}
+
+func (u *URIObject) ReturnClass() Class {
+	return u.Class
+}
+
+// defaultPorts are the ports of schemes URIs without a port use.
+var defaultPorts = map[string]int{
+	"http":  80,
+	"https": 443,
+	"ftp":   21,
+}
+
+// hostAndPort splits the host of u from its port. A bracketed IPv6 host keeps its brackets.
+func (u *URIObject) hostAndPort() (string, string) {
+	host := u.url.Host
+	i := strings.LastIndex(host, ":")
+
+	if i < 0 || i < strings.LastIndex(host, "]") {
+		return host, ""
+	}
+
+	return host[:i], host[i+1:]
+}
+
+// parseURI parses s and raises ArgumentError if it isn't a valid URI.
+func (vm *VM) parseURI(s string) *URIObject {
+	u, err := url.Parse(s)
+
+	if err != nil {
+		vm.raise(ArgumentErrorClass, "bad URI(is not URI?): %s", s)
+	}
+
+	return &URIObject{Class: URIClass, url: u}
+}
+
+// wwwFormPairs returns the pairs of a hash, or of an array of [key, value] arrays, to be encoded by URI.encode_www_form.
+func (vm *VM) wwwFormPairs(form Object) [][2]Object {
+	pairs := [][2]Object{}
+
+	switch form := form.(type) {
+	case *HashObject:
+		for _, p := range form.pairs {
+			pairs = append(pairs, [2]Object{p.key, p.value})
+		}
+	case *ArrayObject:
+		for _, el := range form.Elements {
+			pair, ok := el.(*ArrayObject)
+
+			if !ok || len(pair.Elements) != 2 {
+				vm.raise(TypeErrorClass, "wrong argument type %s (expected [key, value])", vm.inspectForError(el))
+			}
+
+			pairs = append(pairs, [2]Object{pair.Elements[0], pair.Elements[1]})
+		}
+	default:
+		vm.raise(TypeErrorClass, "wrong argument type %s (expected Hash or Array)", vm.inspectForError(form))
+	}
+
+	return pairs
+}
+
+// decodeWWWFormComponent decodes s like it's in a query string, "+" is decoded as a space.
+func (vm *VM) decodeWWWFormComponent(s string) string {
+	decoded, err := url.QueryUnescape(s)
+
+	if err != nil {
+		vm.raise(ArgumentErrorClass, "invalid %%-encoding (%s)", s)
+	}
+
+	return decoded
+}
+
+var builtinURIModuleMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return vm.parseURI(vm.stringArgument(args[0]))
+			}
+		},
+		Name: "parse",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				params := []string{}
+
+				for _, p := range vm.wwwFormPairs(args[0]) {
+					params = append(params, url.QueryEscape(vm.toS(p[0]))+"="+url.QueryEscape(vm.toS(p[1])))
+				}
+
+				return InitializeString(strings.Join(params, "&"))
+			}
+		},
+		Name: "encode_www_form",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				pairs := []Object{}
+
+				for _, param := range strings.FieldsFunc(vm.stringArgument(args[0]), func(r rune) bool { return r == '&' || r == ';' }) {
+					kv := strings.SplitN(param, "=", 2)
+					value := ""
+
+					if len(kv) == 2 {
+						value = kv[1]
+					}
+
+					key := InitializeString(vm.decodeWWWFormComponent(kv[0]))
+					pairs = append(pairs, InitializeArray([]Object{key, InitializeString(vm.decodeWWWFormComponent(value))}))
+				}
+
+				return InitializeArray(pairs)
+			}
+		},
+		Name: "decode_www_form",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return InitializeString(url.QueryEscape(vm.toS(args[0])))
+			}
+		},
+		Name: "encode_www_form_component",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return InitializeString(vm.decodeWWWFormComponent(vm.stringArgument(args[0])))
+			}
+		},
+		Name: "decode_www_form_component",
+	},
+}
+
+// uriComponent returns a built in method of URIs that returns one of their components, or nil if it's empty.
+func uriComponent(name string, component func(u *URIObject) string) *BuiltInMethod {
+	return &BuiltInMethod{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				s := component(receiver.(*URIObject))
+
+				if s == "" {
+					return NULL
+				}
+
+				return InitializeString(s)
+			}
+		},
+		Name: name,
+	}
+}
+
+// builtinURIMethods are methods of parsed URIs. `path` of a URI without a path is "", like Ruby,
+// while other missing components are nil. `port` is the default port of the scheme if the URI doesn't have one.
+var builtinURIMethods = []*BuiltInMethod{
+	uriComponent("scheme", func(u *URIObject) string { return u.url.Scheme }),
+	uriComponent("host", func(u *URIObject) string {
+		host, _ := u.hostAndPort()
+		return host
+	}),
+	uriComponent("query", func(u *URIObject) string { return u.url.RawQuery }),
+	uriComponent("fragment", func(u *URIObject) string { return u.url.Fragment }),
+	uriComponent("user", func(u *URIObject) string {
+		if u.url.User == nil {
+			return ""
+		}
+
+		return u.url.User.Username()
+	}),
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				return InitializeString(receiver.(*URIObject).url.EscapedPath())
+			}
+		},
+		Name: "path",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 0 {
+					return newError("Expect 0 argument. got=%d", len(args))
+				}
+
+				u := receiver.(*URIObject)
+				_, port := u.hostAndPort()
+
+				if port == "" {
+					if p, ok := defaultPorts[u.url.Scheme]; ok {
+						return vm.initInteger(p)
+					}
+
+					return NULL
+				}
+
+				n, err := strconv.Atoi(port)
+
+				if err != nil {
+					return NULL
+				}
+
+				return vm.initInteger(n)
+			}
+		},
+		Name: "port",
+	},
+}
+
+func initURI() {
+	URIModule = initializeBuiltinModule("URI", builtinURIModuleMethods)
+
+	methods := NewEnvironment()
+
+	for _, m := range builtinURIMethods {
+		methods.Set(m.Name, m)
+	}
+
+	bc := &BaseClass{Name: "URI::Generic", Methods: methods, ClassMethods: NewEnvironment(), Class: ClassClass, SuperClass: ObjectClass}
+	URIClass = &RURI{BaseClass: bc}
+}
@@ -0,0 +1,97 @@
+package vm
+
+import "testing"
+
+func TestURIParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`URI.parse("https://example.com:8443/a/b?x=1&y=2#top").scheme`, "https"},
+		{`URI.parse("https://example.com:8443/a/b?x=1&y=2#top").host`, "example.com"},
+		{`URI.parse("https://example.com:8443/a/b?x=1&y=2#top").port`, 8443},
+		{`URI.parse("https://example.com:8443/a/b?x=1&y=2#top").path`, "/a/b"},
+		{`URI.parse("https://example.com:8443/a/b?x=1&y=2#top").query`, "x=1&y=2"},
+		{`URI.parse("https://example.com:8443/a/b?x=1&y=2#top").fragment`, "top"},
+		{`URI.parse("https://user@example.com").user`, "user"},
+		{`URI.parse("http://example.com").port`, 80},
+		{`URI.parse("https://example.com").port`, 443},
+		{`URI.parse("http://[::1]:3000/").host`, "[::1]"},
+		{`URI.parse("http://[::1]/").port`, 80},
+		{`URI.parse("http://example.com").path`, ""},
+		{`URI.parse("http://example.com").query`, nil},
+		{`URI.parse("mailto:a@example.com").host`, nil},
+		{`URI.parse("foo://example.com").port`, nil},
+		{`URI.parse("http://example.com/a?b=c").to_s`, "http://example.com/a?b=c"},
+		{`URI.parse("http://example.com").class.name`, "URI::Generic"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		default:
+			t.Fatalf("at test case %d: unexpected expectation %v", i, expected)
+		}
+	}
+}
+
+func TestURIWWWForm(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`URI.encode_www_form({ q: "ruby go", page: 2 })`, "q=ruby+go&page=2"},
+		{`URI.encode_www_form([["a", "1&2"], ["a", "3=4"]])`, "a=1%262&a=3%3D4"},
+		{`URI.encode_www_form_component("a b/c")`, "a+b%2Fc"},
+		{`URI.decode_www_form_component("a+b%2Fc")`, "a b/c"},
+		{`URI.decode_www_form("q=ruby+go&page=2")[0][1]`, "ruby go"},
+		{`URI.decode_www_form("q=ruby+go&page=2")[1][0]`, "page"},
+		{`URI.decode_www_form("a&b=1")[0][1]`, ""},
+		{`URI.decode_www_form("a=1;b=2").length`, 2},
+		{`
+		query = URI.parse("http://example.com/search?tag=a%20b&n=1").query
+		URI.decode_www_form(query)[0][1]
+		`, "a b"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		default:
+			t.Fatalf("at test case %d: unexpected expectation %v", i, expected)
+		}
+	}
+}
+
+func TestURIErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`URI.parse("http://a b.com/%zz")`, "ArgumentError: bad URI(is not URI?): http://a b.com/%zz"},
+		{`URI.parse(1)`, "TypeError: wrong argument type 1 (expected String)"},
+		{`URI.decode_www_form_component("%zz")`, "ArgumentError: invalid %-encoding (%zz)"},
+		{`URI.encode_www_form(1)`, "TypeError: wrong argument type 1 (expected Hash or Array)"},
+		{`URI.encode_www_form([1])`, "TypeError: wrong argument type 1 (expected [key, value])"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
@@ -3,7 +3,10 @@ package vm
 import (
 	"bytes"
 	"fmt"
+	"math/big"
+	"os/exec"
 	"strings"
+	"time"
 )
 
 type VM struct {
@@ -12,16 +15,88 @@ type VM struct {
 	SP             int
 	CFP            int
 	Constants      map[string]*Pointer
-	LabelTable     map[LabelType]map[string][]*InstructionSet
-	MethodISTable  *ISIndexTable
-	ClassISTable   *ISIndexTable
+	Globals        map[string]*Pointer
 	BlockList      *ISIndexTable
+	MaxCallDepth   int
+	Stats          *Stats
+	Tracer         *Tracer
+	MainObj        *RObject
+	hooks          hooks
+	budget         *instructionBudget
+	interrupter    *interrupter
+	// constantSerial is bumped on every constant definition to invalidate constant caches
+	constantSerial    int
+	smallIntegers     []*IntegerObject
+	smallIntegerMin   int
+	objects           *objectTracker
+	inInstructionHook bool
+	breakpoints       []*Breakpoint
+	breakpointHandler func(b *Break)
+	// classClass and objectClass are the VM's own Class and Object classes, classes and methods a program
+	// defines go into them, so they aren't shared with other VMs like the rest of built in classes.
+	classClass  *RClass
+	objectClass *RClass
+	// reopened holds the methods the program defines on built in classes, which are shared with other VMs
+	reopened map[*BaseClass]*reopenedClass
+	// frozenStringLiterals is set by SetFrozenStringLiterals
+	frozenStringLiterals bool
+	// formattingError is set while an error message inspects objects, see inspectForError
+	formattingError bool
+	// stdin, stdout and stderr are the objects of STDIN, STDOUT and STDERR, they're kept when the VM is reset
+	stdin  *IOObject
+	stdout *IOObject
+	stderr *IOObject
+	// env is ENV and args are the arguments in ARGV, they're kept when the VM is reset
+	env  *EnvObject
+	args []string
+	// defaultRandom is the generator of `rand` and `srand`, it's seeded again when the VM is reset
+	defaultRandom *RandomObject
+	// bigDecimalRoundingMode is the rounding mode set by BigDecimal.mode, 0 until it's set
+	bigDecimalRoundingMode int
+	// exitHandlers are the blocks registered by at_exit, see RunExitHandlers
+	exitHandlers []*CallFrame
+	// children are the processes started by Process.spawn that haven't been waited for
+	children []*exec.Cmd
+	// HTTPTimeout limits how long a request made with Net::HTTP can take, including reading its response
+	HTTPTimeout time.Duration
+	// labelTable holds instruction sets of the program the VM runs
+	labelTable
 }
 
+// DefaultMaxCallDepth is the MaxCallDepth of VMs returned by New. MaxCallDepth is how many call frames
+// can be on the stack before SystemStackError is raised, setting it to 0 removes the limit.
+const DefaultMaxCallDepth = 10000
+
 type ISIndexTable struct {
 	Data map[string]int
 }
 
+// labelTable holds instruction sets that are compiled together. Instructions refer to blocks, methods and classes
+// by their labels, which are looked up in the table of the instruction set the instruction is in.
+// So code compiled by eval has its own table, and its labels don't get mixed up with labels of the program.
+type labelTable struct {
+	LabelTable    map[LabelType]map[string][]*InstructionSet
+	MethodISTable *ISIndexTable
+	ClassISTable  *ISIndexTable
+}
+
+func newLabelTable() *labelTable {
+	t := &labelTable{}
+	t.reset()
+	return t
+}
+
+func (t *labelTable) reset() {
+	t.MethodISTable = &ISIndexTable{Data: make(map[string]int)}
+	t.ClassISTable = &ISIndexTable{Data: make(map[string]int)}
+	t.LabelTable = map[LabelType]map[string][]*InstructionSet{
+		LABEL_DEF:      make(map[string][]*InstructionSet),
+		LABEL_DEFCLASS: make(map[string][]*InstructionSet),
+		BLOCK:          make(map[string][]*InstructionSet),
+		PROGRAM:        make(map[string][]*InstructionSet),
+	}
+}
+
 type Stack struct {
 	Data []*Pointer
 	VM   *VM
@@ -30,70 +105,466 @@ type Stack struct {
 func New() *VM {
 	s := &Stack{}
 	cfs := &CallFrameStack{CallFrames: []*CallFrame{}}
-	vm := &VM{Stack: s, CallFrameStack: cfs, SP: 0, CFP: 0}
+	vm := &VM{Stack: s, CallFrameStack: cfs, SP: 0, CFP: 0, MaxCallDepth: DefaultMaxCallDepth, HTTPTimeout: DefaultHTTPTimeout}
 	s.VM = vm
 	cfs.VM = vm
 
-	vm.initConstants()
-	vm.MethodISTable = &ISIndexTable{Data: make(map[string]int)}
-	vm.ClassISTable = &ISIndexTable{Data: make(map[string]int)}
-	vm.BlockList = &ISIndexTable{Data: make(map[string]int)}
-	vm.LabelTable = map[LabelType]map[string][]*InstructionSet{
-		LABEL_DEF:      make(map[string][]*InstructionSet),
-		LABEL_DEFCLASS: make(map[string][]*InstructionSet),
-		BLOCK:          make(map[string][]*InstructionSet),
-		PROGRAM:        make(map[string][]*InstructionSet),
-	}
+	vm.SetSmallIntegerRange(DefaultSmallIntegerMin, DefaultSmallIntegerMax)
+	vm.initStandardStreams()
+	vm.env = &EnvObject{Class: EnvClass}
+	vm.Reset()
 
 	return vm
 }
 
+// Reset discards everything programs left in the VM: the stack, call frames, loaded instructions, globals,
+// and classes, constants and top level methods they defined. Built in classes and settings like limits, hooks,
+// breakpoints and the tracer are kept, so a host can run many programs on one VM without creating a new one each time.
+// The instruction limit set by SetInstructionLimit starts over.
+func (vm *VM) Reset() {
+	vm.unwindTo(0, 0)
+
+	for i := range vm.Stack.Data {
+		vm.Stack.Data[i] = nil
+	}
+
+	vm.CallFrameStack.CallFrames = vm.CallFrameStack.CallFrames[:0]
+
+	if vm.budget != nil {
+		vm.budget.remaining = vm.budget.limit
+	}
+
+	vm.classClass, vm.objectClass = newTopLevelClasses()
+	vm.reopened = nil
+	vm.MainObj = newMainObject(vm.objectClass)
+	vm.initConstants()
+	vm.constantSerial++
+	vm.Globals = make(map[string]*Pointer)
+	vm.SetGlobal("$LOAD_PATH", InitializeArray([]Object{}))
+	vm.SetGlobal("$LOADED_FEATURES", InitializeArray([]Object{}))
+	vm.labelTable.reset()
+	vm.BlockList = &ISIndexTable{Data: make(map[string]int)}
+	vm.defaultRandom = InitializeRandom(newSeed())
+	vm.bigDecimalRoundingMode = 0
+	vm.exitHandlers = nil
+	vm.children = nil
+}
+
 func (vm *VM) EvalCallFrame(cf *CallFrame) {
+	cfp := vm.CFP
+
+	for !vm.evalCallFrame(cf, cfp) {
+	}
+}
+
+// evalCallFrame executes cf until it finishes and returns true.
+// If a raised exception is rescued by cf's catch table, it returns false so evaluation can be resumed from the handler.
+func (vm *VM) evalCallFrame(cf *CallFrame, cfp int) (finished bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*raisedException)
+
+			if !ok || !vm.rescue(cf, cfp, e) {
+				panic(r)
+			}
+		}
+	}()
+
 	for cf.PC < len(cf.InstructionSet.Instructions) {
+		if len(cf.InstructionSet.CatchTable) > 0 {
+			cf.enterCatchEntries(vm.SP)
+		}
+
 		i := cf.InstructionSet.Instructions[cf.PC]
 		vm.execInstruction(cf, i)
 	}
+
+	return true
+}
+
+// rescue looks up a catch entry for the instruction that raised the exception.
+// If there is one, it discards call frames and stack values pushed inside the protected instructions and jumps to the handler.
+func (vm *VM) rescue(cf *CallFrame, cfp int, e *raisedException) bool {
+	entry := cf.InstructionSet.catchEntryFor(cf.PC - 1)
+
+	if entry == nil {
+		return false
+	}
+
+	vm.unwindTo(cfp, cf.catchSP[entry])
+	vm.Stack.push(&Pointer{e.exception})
+	cf.PC = entry.Handler
+
+	return true
 }
 
-func (vm *VM) Exec() {
+// Exec runs the call frame on top of the stack. Exceptions the program doesn't rescue and failures inside the VM,
+// as *InternalError, are returned as error, and the stack is restored to where it was, so a misbehaving script never crashes its host.
+func (vm *VM) Exec() (err error) {
+	cfp, sp := vm.CFP-1, vm.SP
+
+	if cfp < 0 {
+		cfp = 0
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = vm.errorFromPanic(r)
+			vm.unwindTo(cfp, sp)
+		}
+	}()
+
+	vm.startFromTopFrame()
+
+	return nil
+}
+
+// startFromTopFrame is what Exec does without recovering errors. Instructions that call methods or blocks use it,
+// so exceptions can keep propagating to the frames that rescue them.
+func (vm *VM) startFromTopFrame() {
 	cf := vm.CallFrameStack.Top()
 	vm.EvalCallFrame(cf)
 }
 
+// unwindTo drops call frames above cfp and resets stack pointer to sp.
+func (vm *VM) unwindTo(cfp, sp int) {
+	for i := vm.CFP - 1; i >= cfp; i-- {
+		if cf := vm.CallFrameStack.CallFrames[i]; cf != nil {
+			vm.callHooks(vm.hooks.ret, cf)
+		}
+
+		vm.CallFrameStack.CallFrames[i] = nil
+	}
+
+	vm.CFP = cfp
+	vm.Stack.truncate(sp)
+}
+
+func (vm *VM) errorFromPanic(r interface{}) error {
+	switch r := r.(type) {
+	case *raisedException:
+		if r.exception.Class.inheritsFrom(SystemExitClass.BaseClass) {
+			return &ExitError{Status: exitStatusOf(r.exception)}
+		}
+
+		return r
+	case *exitNow:
+		return &ExitError{Status: r.status, immediate: true}
+	case *interruption:
+		return r.err
+	}
+
+	return vm.newInternalError(r)
+}
+
+// initConstants sets up built in classes, the standard streams, ENV, ARGV and the rounding modes of BigDecimal. Constants defined at top level are added to the same table since they belong to Object.
 func (vm *VM) initConstants() {
 	constants := make(map[string]*Pointer)
 
-	builtInClasses := []Class{
+	for _, c := range vm.builtInClasses() {
+		p := &Pointer{Target: c}
+		constants[c.ReturnName()] = p
+	}
+
+	for name, stream := range vm.standardStreams() {
+		constants[name] = &Pointer{Target: stream}
+	}
+
+	constants["ENV"] = &Pointer{Target: vm.env}
+	constants["ARGV"] = &Pointer{Target: vm.argv()}
+
+	for name, value := range bigDecimalConstants {
+		constants["BigDecimal::"+name] = &Pointer{Target: vm.initInteger(value)}
+	}
+
+	for name, e := range encodings {
+		constants["Encoding::"+strings.Replace(name, "-", "_", -1)] = &Pointer{Target: e}
+	}
+
+	vm.Constants = constants
+}
+
+// builtInClasses returns the built in classes programs of the VM can see
+func (vm *VM) builtInClasses() []Class {
+	classes := sharedClasses()
+
+	for i, c := range classes {
+		switch c {
+		case ClassClass:
+			classes[i] = vm.classClass
+		case ObjectClass:
+			classes[i] = vm.objectClass
+		}
+	}
+
+	return classes
+}
+
+// sharedClasses returns the built in classes every VM shares. They're never changed by programs, methods programs
+// define on them are kept by their VMs, see reopenedClass.
+func sharedClasses() []Class {
+	return []Class{
 		IntegerClass,
+		FloatClass,
+		RationalClass,
+		BigDecimalClass,
 		StringClass,
+		EncodingClass,
+		SymbolClass,
 		BooleanClass,
 		NullClass,
 		ArrayClass,
+		RangeClass,
+		DateClass,
+		RegexpClass,
+		MatchDataClass,
+		FileClass,
+		FileUtilsModule,
+		IOClass,
+		HTTPClass,
+		HTTPResponseClass,
+		TCPServerClass,
+		TCPSocketClass,
+		URIModule,
+		URIClass,
+		Base64Module,
+		DigestMD5Module,
+		DigestSHA1Module,
+		DigestSHA256Module,
+		MarshalModule,
+		YAMLModule,
+		ProcessModule,
+		ProcessStatusClass,
 		HashClass,
+		EnumeratorClass,
+		ProcClass,
+		MutexClass,
+		ConditionVariableClass,
+		RandomClass,
+		StructClass,
+		ObjectSpaceModule,
+		GCModule,
+		ExceptionClass,
+		StandardErrorClass,
+		RuntimeErrorClass,
+		TypeErrorClass,
+		ArgumentErrorClass,
+		IndexErrorClass,
+		KeyErrorClass,
+		RangeErrorClass,
+		RegexpErrorClass,
+		IOErrorClass,
+		ZeroDivisionErrorClass,
+		FloatDomainErrorClass,
+		YAMLSyntaxErrorClass,
+		DateErrorClass,
+		EncodingErrorClass,
+		UndefinedConversionErrorClass,
+		InvalidByteSequenceErrorClass,
+		NameErrorClass,
+		NoMethodErrorClass,
+		LocalJumpErrorClass,
+		ThreadErrorClass,
+		FrozenErrorClass,
+		SystemStackErrorClass,
+		SyntaxErrorClass,
+		LoadErrorClass,
+		SystemExitClass,
+		BudgetExceededErrorClass,
+		ResourceLimitErrorClass,
 		ClassClass,
 		ObjectClass,
 	}
+}
 
-	for _, c := range builtInClasses {
-		p := &Pointer{Target: c}
-		constants[c.ReturnName()] = p
+func isSharedClass(c Class) bool {
+	for _, shared := range sharedClasses() {
+		if c == shared {
+			return true
+		}
 	}
 
-	vm.Constants = constants
+	return false
 }
 
 func (vm *VM) execInstruction(cf *CallFrame, i *Instruction) {
 	cf.PC += 1
-	//fmt.Print(i.Inspect())
-	i.Action.Operation(vm, cf, i.Params...)
-	//fmt.Println(vm.CallFrameStack.inspect())
-	//fmt.Println(vm.Stack.inspect())
+
+	if vm.budget != nil {
+		vm.budget.consume(vm)
+	}
+
+	if vm.interrupter != nil {
+		vm.interrupter.tick()
+	}
+
+	if vm.Stats != nil {
+		vm.Stats.record(cf, i)
+	}
+
+	if vm.Tracer != nil {
+		vm.Tracer.trace(vm, cf, i)
+	}
+
+	if len(vm.hooks.instruction) > 0 {
+		vm.inInstructionHook = true
+
+		for _, hook := range vm.hooks.instruction {
+			hook(cf, i)
+		}
+
+		vm.inInstructionHook = false
+	}
+
+	args := i.Params
+
+	switch i.Action.Opcode {
+	case OP_POP:
+		vm.Stack.pop()
+	case OP_PUT_OBJECT:
+		vm.opPutObject(cf, args)
+	case OP_GET_CONSTANT:
+		vm.opGetConstant(cf, args)
+	case OP_GET_LOCAL:
+		vm.opGetLocal(cf, args)
+	case OP_GET_INSTANCE_VARIABLE:
+		vm.opGetInstanceVariable(cf, args)
+	case OP_SET_INSTANCE_VARIABLE:
+		vm.opSetInstanceVariable(cf, args)
+	case OP_GET_GLOBAL:
+		vm.opGetGlobal(cf, args)
+	case OP_SET_GLOBAL:
+		vm.opSetGlobal(cf, args)
+	case OP_SET_LOCAL:
+		vm.opSetLocal(cf, args)
+	case OP_SET_CONSTANT:
+		vm.opSetConstant(cf, args)
+	case OP_NEW_ARRAY:
+		vm.opNewArray(cf, args)
+	case OP_EXPAND_ARRAY:
+		vm.opExpandArray(cf, args)
+	case OP_NEW_HASH:
+		vm.opNewHash(cf, args)
+	case OP_BRANCH_UNLESS:
+		vm.opBranchUnless(cf, args)
+	case OP_JUMP:
+		cf.PC = args[0].(int)
+	case OP_PUT_SELF:
+		vm.Stack.push(&Pointer{cf.Self})
+	case OP_PUT_STRING:
+		vm.opPutString(cf, args)
+	case OP_PUT_NULL:
+		vm.Stack.push(&Pointer{NULL})
+	case OP_PUT_FLOAT:
+		vm.Stack.push(&Pointer{InitializeFloat(args[0].(float64))})
+	case OP_PUT_RATIONAL:
+		vm.Stack.push(&Pointer{vm.initRational(args[0].(*big.Rat))})
+	case OP_NEW_RANGE:
+		vm.opNewRange(cf, args)
+	case OP_DEF_METHOD:
+		vm.opDefMethod(cf, args)
+	case OP_DEF_SINGLETON_METHOD:
+		vm.opDefSingletonMethod(cf, args)
+	case OP_DEF_CLASS:
+		vm.opDefClass(cf, args)
+	case OP_DEF_MODULE:
+		vm.opDefModule(cf, args)
+	case OP_SEND:
+		vm.opSend(cf, args)
+	case OP_INVOKE_BLOCK:
+		vm.opInvokeBlock(cf, args)
+	case OP_INVOKE_SUPER:
+		vm.opInvokeSuper(cf, args)
+	case OP_CHECK_MATCH:
+		vm.opCheckMatch(cf, args)
+	case OP_THROW:
+		vm.opThrow(cf, args)
+	case OP_LEAVE:
+		cf = vm.CallFrameStack.Pop()
+		cf.PC = len(cf.InstructionSet.Instructions)
+	default:
+		panic(fmt.Sprintf("Unknown opcode: %d", i.Action.Opcode))
+	}
+}
+
+// builtInMethodYield evaluates the given block frame with args, which lets built in methods like `each` call back into Rooby code.
+func (vm *VM) builtInMethodYield(blockFrame *CallFrame, args ...Object) *Pointer {
+	return vm.yieldWithSelf(blockFrame, blockFrame.Self, args...)
+}
+
+// yieldWithSelf is like builtInMethodYield, but evaluates the block with self, which is how instance_eval changes self of its block.
+func (vm *VM) yieldWithSelf(blockFrame *CallFrame, self BaseObject, args ...Object) *Pointer {
+	c := NewCallFrame(blockFrame.InstructionSet)
+	c.BlockFrame = blockFrame
+	c.EP = blockFrame.EP
+	c.Self = self
+	sp := vm.SP
+
+	for i := 0; i < len(args); i++ {
+		c.insertLCL(i, 0, args[i])
+	}
+
+	vm.CallFrameStack.Push(c)
+	vm.startFromTopFrame()
+
+	// values the block left on the stack are dropped, otherwise they'd pile up while a built in method loops over a block
+	result := &Pointer{Target: NULL}
+
+	if vm.SP > sp {
+		result = vm.Stack.Top()
+	}
+
+	vm.Stack.truncate(sp)
+
+	return result
 }
 
-func (vm *VM) getBlock(name string) (*InstructionSet, bool) {
+// callMethod calls receiver's method with args from built in methods, and returns what it returns.
+func (vm *VM) callMethod(receiver BaseObject, method Object, args ...Object) Object {
+	receiverPr := vm.SP
+	vm.Stack.push(&Pointer{Target: receiver})
+
+	for _, arg := range args {
+		vm.Stack.push(&Pointer{Target: arg})
+	}
+
+	vm.evalMethod(receiver, method, receiverPr, len(args), receiverPr+1, nil)
+
+	return vm.Stack.pop().Target
+}
+
+// send calls receiver's method called name with args from built in methods, like calling it in a program does.
+// If receiver doesn't have the method, method_missing is called instead.
+func (vm *VM) send(receiver Object, name string, args ...Object) Object {
+	r := receiver.(BaseObject)
+	method := vm.lookupMethod(r, name)
+
+	if method == nil {
+		if method = vm.lookupMethod(r, "method_missing"); method != nil {
+			args = append([]Object{InternSymbol(name)}, args...)
+		}
+	}
+
+	if method == nil {
+		vm.raise(NoMethodErrorClass, "undefined method `%s' for %s", name, vm.inspectForError(receiver))
+	}
+
+	return vm.callMethod(r, method, args...)
+}
+
+// labelsOf returns the label table labels in is are looked up in.
+func (vm *VM) labelsOf(is *InstructionSet) *labelTable {
+	if is.labels != nil {
+		return is.labels
+	}
+
+	return &vm.labelTable
+}
+
+func (t *labelTable) getBlock(name string) (*InstructionSet, bool) {
 	// The "name" here is actually an index from label
 	// for example <Block:1>'s name is "1"
-	iss, ok := vm.LabelTable[BLOCK][name]
+	iss, ok := t.LabelTable[BLOCK][name]
 
 	if !ok {
 		return nil, false
@@ -104,33 +575,33 @@ func (vm *VM) getBlock(name string) (*InstructionSet, bool) {
 	return is, ok
 }
 
-func (vm *VM) getMethodIS(name string) (*InstructionSet, bool) {
-	iss, ok := vm.LabelTable[LABEL_DEF][name]
+func (t *labelTable) getMethodIS(name string) (*InstructionSet, bool) {
+	iss, ok := t.LabelTable[LABEL_DEF][name]
 
 	if !ok {
 		return nil, false
 	}
 
-	is := iss[vm.MethodISTable.Data[name]]
+	is := iss[t.MethodISTable.Data[name]]
 
-	vm.MethodISTable.Data[name] += 1
+	t.MethodISTable.Data[name] += 1
 	return is, ok
 }
 
-func (vm *VM) getClassIS(name string) (*InstructionSet, bool) {
-	iss, ok := vm.LabelTable[LABEL_DEFCLASS][name]
+func (t *labelTable) getClassIS(name string) (*InstructionSet, bool) {
+	iss, ok := t.LabelTable[LABEL_DEFCLASS][name]
 
 	if !ok {
 		return nil, false
 	}
 
-	is := iss[vm.ClassISTable.Data[name]]
+	is := iss[t.ClassISTable.Data[name]]
 
-	vm.ClassISTable.Data[name] += 1
+	t.ClassISTable.Data[name] += 1
 	return is, ok
 }
 
-func (vm *VM) setLabel(is *InstructionSet, name string) {
+func (t *labelTable) setLabel(is *InstructionSet, name string) {
 	var l *Label
 	var labelName string
 	var labelType LabelType
@@ -146,7 +617,8 @@ func (vm *VM) setLabel(is *InstructionSet, name string) {
 
 	l = &Label{Name: name, Type: labelType}
 	is.Label = l
-	vm.LabelTable[labelType][labelName] = append(vm.LabelTable[labelType][labelName], is)
+	is.labels = t
+	t.LabelTable[labelType][labelName] = append(t.LabelTable[labelType][labelName], is)
 }
 
 func (s *Stack) push(v *Pointer) {
@@ -160,7 +632,7 @@ func (s *Stack) push(v *Pointer) {
 }
 
 func (s *Stack) pop() *Pointer {
-	if len(s.Data) < 1 {
+	if s.VM.SP < 1 {
 		panic("Nothing to pop!")
 	}
 
@@ -171,6 +643,15 @@ func (s *Stack) pop() *Pointer {
 	return v
 }
 
+// truncate drops values above sp, so the objects they refer to can be garbage collected.
+func (s *Stack) truncate(sp int) {
+	for i := sp; i < s.VM.SP && i < len(s.Data); i++ {
+		s.Data[i] = nil
+	}
+
+	s.VM.SP = sp
+}
+
 func (s *Stack) Top() *Pointer {
 
 	if s.VM.SP > 0 {
@@ -52,6 +52,22 @@ func TestCunstomConstructorAndInstanceVariable(t *testing.T) {
 	}
 }
 
+func TestBuiltInActionsHaveDistinctOpcodes(t *testing.T) {
+	seen := map[Opcode]string{}
+
+	for name, action := range BuiltInActions {
+		if string(name) != action.Name {
+			t.Fatalf("Expect action %s to be named %s. got=%s", name, name, action.Name)
+		}
+
+		if other, ok := seen[action.Opcode]; ok {
+			t.Fatalf("Expect %s and %s to have different opcodes", name, other)
+		}
+
+		seen[action.Opcode] = action.Name
+	}
+}
+
 func TestCodeSectionOverrideIssue(t *testing.T) {
 	input := `
 <Def:foo>
@@ -371,13 +387,32 @@ func TestConditionWithAlternativeCompilation(t *testing.T) {
 }
 
 func testEval(t *testing.T, input string) Object {
+	return testEvalWithVM(t, New(), input)
+}
+
+func testEvalWithVM(t *testing.T, v *VM, input string) Object {
+	return testEvalFileWithVM(t, v, "", input)
+}
+
+// testEvalFileWithVM evaluates input as if it's the content of file.
+func testEvalFileWithVM(t *testing.T, v *VM, file, input string) Object {
+	return testExecWithVM(v, testCompile(t, file, input))
+}
+
+// testEvalError evaluates input as if it's the content of file and returns the error from Exec.
+func testEvalError(t *testing.T, v *VM, file, input string) error {
+	testLoadBytecodes(v, testCompile(t, file, input))
+	return v.Exec()
+}
+
+func testCompile(t *testing.T, file, input string) string {
 	l := lexer.New(input)
 	p := parser.New(l)
 	program := p.ParseProgram()
 	checkParserErrors(t, p)
 	g := bytecode.NewGenerator(program)
-	bytecodes := g.GenerateByteCode(program)
-	return testExec(bytecodes)
+	g.SetFileName(file)
+	return g.GenerateByteCode(program)
 }
 
 func checkParserErrors(t *testing.T, p *parser.Parser) {
@@ -394,16 +429,27 @@ func checkParserErrors(t *testing.T, p *parser.Parser) {
 }
 
 func testExec(bytecodes string) Object {
+	return testExecWithVM(New(), bytecodes)
+}
+
+// testExecWithVM returns the value program evaluates to, or an Error with the report if Exec returns an error.
+func testExecWithVM(v *VM, bytecodes string) Object {
+	testLoadBytecodes(v, bytecodes)
+
+	if err := v.Exec(); err != nil {
+		return newError("%s", ErrorReport(err))
+	}
+
+	return v.Stack.Top().Target
+}
+
+func testLoadBytecodes(v *VM, bytecodes string) {
 	p := NewBytecodeParser()
-	v := New()
 	p.VM = v
 	p.Parse(bytecodes)
 	cf := NewCallFrame(v.LabelTable[PROGRAM]["ProgramStart"][0])
-	cf.Self = MainObj
+	cf.Self = v.MainObj
 	v.CallFrameStack.Push(cf)
-	v.Exec()
-
-	return v.Stack.Top().Target
 }
 
 func testIntegerObject(t *testing.T, obj Object, expected int) bool {
@@ -412,8 +458,8 @@ func testIntegerObject(t *testing.T, obj Object, expected int) bool {
 		t.Errorf("object is not Integer. got=%T (%+v).", obj, obj)
 		return false
 	}
-	if result.Value != expected {
-		t.Errorf("object has wrong value. expect=%d, got=%d", expected, result.Value)
+	if result.big != nil || result.Value != expected {
+		t.Errorf("object has wrong value. expect=%d, got=%s", expected, result.Inspect())
 		return false
 	}
 
@@ -450,7 +496,7 @@ func testBooleanObject(t *testing.T, obj Object, expected bool) bool {
 		return false
 	}
 	if result.Value != expected {
-		t.Errorf("object has wrong value. expect=%b, got=%d", expected, result.Value)
+		t.Errorf("object has wrong value. expect=%t, got=%t", expected, result.Value)
 		return false
 	}
 
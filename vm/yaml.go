@@ -0,0 +1,668 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// YAMLModule loads YAML documents into hashes, arrays and scalars, and dumps them back.
+var YAMLModule *RClass
+
+// yamlLine is a line of a YAML document. text is the line without its indentation and comment,
+// and raw keeps them for block scalars. base is the indentation of the line in the document,
+// which differs from indent for the item of a sequence entry, like `a: 1` in `- a: 1`.
+type yamlLine struct {
+	number int
+	indent int
+	base   int
+	raw    string
+	text   string
+}
+
+// yamlParser parses the block structure of a YAML document, which is given by the indentation of its lines.
+type yamlParser struct {
+	vm    *VM
+	lines []*yamlLine
+	pos   int
+}
+
+var (
+	yamlInteger = regexp.MustCompile(`^[-+]?(0|[1-9][0-9_]*)$`)
+	yamlFloat   = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9][0-9_]*(\.[0-9_]*)?)([eE][-+]?[0-9]+)?$`)
+	yamlSymbol  = regexp.MustCompile(`^:[A-Za-z_][A-Za-z0-9_]*[?!=]?$`)
+)
+
+// yamlBooleans are the scalars YAML 1.1 reads as booleans, which Ruby's YAML follows.
+var yamlBooleans = map[string]bool{
+	"true": true, "True": true, "TRUE": true, "yes": true, "Yes": true, "YES": true, "on": true, "On": true, "ON": true,
+	"false": false, "False": false, "FALSE": false, "no": false, "No": false, "NO": false, "off": false, "Off": false, "OFF": false,
+}
+
+func newYAMLParser(vm *VM, document string) *yamlParser {
+	p := &yamlParser{vm: vm}
+	started := false
+
+	for i, raw := range strings.Split(document, "\n") {
+		raw = strings.TrimRight(raw, "\r")
+		content := strings.TrimLeft(raw, " ")
+		text := strings.TrimSpace(stripYAMLComment(content))
+
+		// documents end at "..." or where the next one starts, and only the first one is loaded
+		if text == "..." || started && isYAMLDocumentStart(text) {
+			break
+		}
+
+		started = started || text != ""
+
+		indent := len(raw) - len(content)
+		p.lines = append(p.lines, &yamlLine{number: i + 1, indent: indent, base: indent, raw: raw, text: text})
+	}
+
+	return p
+}
+
+// stripYAMLComment removes the comment at the end of s, a "#" that's at the start or after a space and not quoted.
+func stripYAMLComment(s string) string {
+	var quote byte
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.IndexByte(" [{,:-", s[i-1]) >= 0 {
+				quote = c
+			}
+		case c == '#' && (i == 0 || s[i-1] == ' '):
+			return s[:i]
+		}
+	}
+
+	return s
+}
+
+func (p *yamlParser) error(line *yamlLine, format string, args ...interface{}) {
+	p.vm.raise(YAMLSyntaxErrorClass, "%s at line %d", fmt.Sprintf(format, args...), line.number)
+}
+
+// current returns the line the parser is at, skipping blank lines and comments, or nil at the end of the document.
+func (p *yamlParser) current() *yamlLine {
+	for ; p.pos < len(p.lines); p.pos++ {
+		if p.lines[p.pos].text != "" {
+			return p.lines[p.pos]
+		}
+	}
+
+	return nil
+}
+
+// parse returns the object of the document, which is nil if it's empty.
+func (p *yamlParser) parse() Object {
+	line := p.current()
+
+	if line != nil && isYAMLDocumentStart(line.text) {
+		if line.text == "---" {
+			p.pos++
+		} else {
+			line.text = strings.TrimSpace(line.text[4:])
+		}
+	}
+
+	value := p.parseNode(0)
+
+	if line := p.current(); line != nil {
+		p.error(line, "unexpected content")
+	}
+
+	return value
+}
+
+// parseNode parses the node at the current line, which has to be indented at least by indent.
+func (p *yamlParser) parseNode(indent int) Object {
+	line := p.current()
+
+	if line == nil || line.indent < indent {
+		return NULL
+	}
+
+	switch {
+	case isYAMLSequenceEntry(line.text):
+		return p.parseSequence(line.indent)
+	case yamlKeyEnd(line.text) >= 0:
+		return p.parseMapping(line.indent)
+	}
+
+	p.pos++
+	return p.parseValue(line.text, line)
+}
+
+func isYAMLDocumentStart(text string) bool {
+	return text == "---" || strings.HasPrefix(text, "--- ")
+}
+
+func isYAMLSequenceEntry(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (p *yamlParser) parseSequence(indent int) Object {
+	elements := []Object{}
+
+	for line := p.current(); line != nil && line.indent == indent && isYAMLSequenceEntry(line.text); line = p.current() {
+		item := strings.TrimLeft(line.text[1:], " ")
+
+		if item == "" {
+			p.pos++
+			elements = append(elements, p.parseNode(indent+1))
+			continue
+		}
+
+		// the item is parsed as if it's on its own line, so the keys of `- a: 1` line up with the lines below it
+		itemIndent := line.indent + len(line.text) - len(item)
+		p.lines[p.pos] = &yamlLine{number: line.number, indent: itemIndent, base: line.base, raw: line.raw, text: item}
+		elements = append(elements, p.parseNode(itemIndent))
+	}
+
+	p.checkIndentation(indent)
+
+	return InitializeArray(elements)
+}
+
+func (p *yamlParser) parseMapping(indent int) Object {
+	hash := InitializeHash(map[string]Object{})
+
+	for line := p.current(); line != nil && line.indent == indent; line = p.current() {
+		end := yamlKeyEnd(line.text)
+
+		if end < 0 {
+			p.error(line, "could not find expected ':'")
+		}
+
+		key := p.parseScalar(strings.TrimSpace(line.text[:end]), line)
+		value := strings.TrimSpace(line.text[end+1:])
+		p.pos++
+
+		if value != "" {
+			hash.set(p.vm, key, p.parseValue(value, line))
+			continue
+		}
+
+		// a sequence can be at the same indentation as its key
+		if next := p.current(); next != nil && next.indent == indent && isYAMLSequenceEntry(next.text) {
+			hash.set(p.vm, key, p.parseSequence(indent))
+		} else {
+			hash.set(p.vm, key, p.parseNode(indent+1))
+		}
+	}
+
+	p.checkIndentation(indent)
+
+	return hash
+}
+
+// checkIndentation raises an error if the line after a collection at indent is indented more than it.
+func (p *yamlParser) checkIndentation(indent int) {
+	if line := p.current(); line != nil && line.indent > indent {
+		p.error(line, "bad indentation")
+	}
+}
+
+// yamlKeyEnd returns the index of the colon that ends the key of a mapping entry in text, or -1 if it isn't one.
+func yamlKeyEnd(text string) int {
+	start := 0
+
+	if text != "" && (text[0] == '"' || text[0] == '\'') {
+		end := strings.IndexByte(text[1:], text[0])
+
+		if end < 0 {
+			return -1
+		}
+
+		start = end + 2
+	} else if text != "" && (text[0] == '[' || text[0] == '{') {
+		return -1
+	}
+
+	for i := start; i < len(text); i++ {
+		if text[i] == ':' && (i == len(text)-1 || text[i+1] == ' ') {
+			// ":sym" is a symbol, not a key
+			if i == 0 {
+				continue
+			}
+
+			return i
+		}
+	}
+
+	return -1
+}
+
+// parseValue parses a value that's on the same line as its key or sequence entry.
+func (p *yamlParser) parseValue(text string, line *yamlLine) Object {
+	switch text[0] {
+	case '|', '>':
+		return p.parseBlockScalar(text, line)
+	case '[', '{':
+		f := &yamlFlowParser{yamlParser: p, line: line, text: text}
+		value := f.parseValue()
+
+		if f.skipSpaces(); f.pos < len(f.text) {
+			p.error(line, "unexpected content after flow collection")
+		}
+
+		return value
+	}
+
+	return p.parseScalar(text, line)
+}
+
+// parseBlockScalar parses a literal (|) or folded (>) block scalar, whose lines are indented more than the line it starts at.
+// Like YAML, the final line break is kept, "-" removes it and "+" keeps the blank lines after it.
+func (p *yamlParser) parseBlockScalar(header string, line *yamlLine) Object {
+	chomping := strings.TrimLeft(header[1:], " ")
+
+	if chomping != "" && chomping != "-" && chomping != "+" {
+		p.error(line, "invalid block scalar header %s", header)
+	}
+
+	lines := []string{}
+	indent := -1
+
+	for ; p.pos < len(p.lines); p.pos++ {
+		l := p.lines[p.pos]
+
+		if strings.TrimSpace(l.raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+
+		if l.indent <= line.base || indent >= 0 && l.indent < indent {
+			break
+		}
+
+		if indent < 0 {
+			indent = l.indent
+		}
+
+		lines = append(lines, l.raw[indent:])
+	}
+
+	content := 0
+
+	for i, l := range lines {
+		if l != "" {
+			content = i + 1
+		}
+	}
+
+	var s string
+
+	if header[0] == '|' {
+		s = strings.Join(lines[:content], "\n")
+	} else {
+		s = foldYAMLLines(lines[:content])
+	}
+
+	switch {
+	case content == 0:
+	case chomping == "":
+		s += "\n"
+	case chomping == "+":
+		s += strings.Repeat("\n", len(lines)-content+1)
+	}
+
+	return InitializeString(s)
+}
+
+// foldYAMLLines joins the lines of a folded block scalar with spaces. A blank line is a line break,
+// and more indented lines are kept as they are.
+func foldYAMLLines(lines []string) string {
+	var b bytes.Buffer
+
+	for i, l := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+
+			switch {
+			case l == "":
+				b.WriteString("\n")
+			case prev == "":
+			case l[0] == ' ' || prev[0] == ' ':
+				b.WriteString("\n")
+			default:
+				b.WriteString(" ")
+			}
+		}
+
+		b.WriteString(l)
+	}
+
+	return b.String()
+}
+
+// parseScalar returns the object a scalar refers to: nil, a boolean, a number, a symbol or a string.
+func (p *yamlParser) parseScalar(text string, line *yamlLine) Object {
+	if text == "" {
+		return NULL
+	}
+
+	switch text[0] {
+	case '"':
+		s, err := strconv.Unquote(text)
+
+		if err != nil {
+			p.error(line, "invalid double quoted string %s", text)
+		}
+
+		return InitializeString(s)
+	case '\'':
+		if len(text) < 2 || text[len(text)-1] != '\'' {
+			p.error(line, "invalid single quoted string %s", text)
+		}
+
+		return InitializeString(strings.Replace(text[1:len(text)-1], "''", "'", -1))
+	}
+
+	switch text {
+	case "~", "null", "Null", "NULL":
+		return NULL
+	case ".inf", ".Inf", ".INF", "+.inf":
+		return InitializeFloat(math.Inf(1))
+	case "-.inf", "-.Inf", "-.INF":
+		return InitializeFloat(math.Inf(-1))
+	case ".nan", ".NaN", ".NAN":
+		return InitializeFloat(math.NaN())
+	}
+
+	if b, ok := yamlBooleans[text]; ok {
+		return toBooleanObject(b)
+	}
+
+	if yamlInteger.MatchString(text) {
+		if n, ok := new(big.Int).SetString(strings.Replace(text, "_", "", -1), 10); ok {
+			return p.vm.initBigInteger(n)
+		}
+	}
+
+	if yamlFloat.MatchString(text) && strings.ContainsAny(text, ".eE") {
+		if f, err := strconv.ParseFloat(strings.Replace(text, "_", "", -1), 64); err == nil {
+			return InitializeFloat(f)
+		}
+	}
+
+	if yamlSymbol.MatchString(text) {
+		return InternSymbol(text[1:])
+	}
+
+	return InitializeString(text)
+}
+
+// yamlFlowParser parses flow collections like `[1, 2]` and `{a: 1}`, which have to be on one line.
+type yamlFlowParser struct {
+	*yamlParser
+	line *yamlLine
+	text string
+	pos  int
+}
+
+func (f *yamlFlowParser) skipSpaces() {
+	for f.pos < len(f.text) && f.text[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+func (f *yamlFlowParser) expect(c byte) {
+	if f.skipSpaces(); f.pos >= len(f.text) || f.text[f.pos] != c {
+		f.error(f.line, "expected '%c' in flow collection", c)
+	}
+
+	f.pos++
+}
+
+// parseCollection parses the elements of a collection that ends with end, calling parseElement for each of them.
+func (f *yamlFlowParser) parseCollection(end byte, parseElement func()) {
+	f.pos++
+
+	for {
+		if f.skipSpaces(); f.pos < len(f.text) && f.text[f.pos] == end {
+			f.pos++
+			return
+		}
+
+		parseElement()
+
+		if f.skipSpaces(); f.pos < len(f.text) && f.text[f.pos] == ',' {
+			f.pos++
+			continue
+		}
+
+		f.expect(end)
+		return
+	}
+}
+
+func (f *yamlFlowParser) parseValue() Object {
+	f.skipSpaces()
+
+	if f.pos >= len(f.text) {
+		f.error(f.line, "unexpected end of flow collection")
+	}
+
+	switch f.text[f.pos] {
+	case '[':
+		elements := []Object{}
+		f.parseCollection(']', func() { elements = append(elements, f.parseValue()) })
+
+		return InitializeArray(elements)
+	case '{':
+		hash := InitializeHash(map[string]Object{})
+		f.parseCollection('}', func() {
+			key := f.parseScalar(true)
+			f.expect(':')
+			hash.set(f.vm, key, f.parseValue())
+		})
+
+		return hash
+	}
+
+	return f.parseScalar(false)
+}
+
+// parseScalar parses a scalar in a flow collection, which ends at a comma, a bracket, or the colon after a key.
+func (f *yamlFlowParser) parseScalar(key bool) Object {
+	f.skipSpaces()
+	start := f.pos
+
+	if f.pos < len(f.text) && (f.text[f.pos] == '"' || f.text[f.pos] == '\'') {
+		quote := f.text[f.pos]
+
+		for f.pos++; f.pos < len(f.text) && f.text[f.pos] != quote; f.pos++ {
+			if quote == '"' && f.text[f.pos] == '\\' {
+				f.pos++
+			}
+		}
+
+		f.pos++
+
+		if f.pos > len(f.text) {
+			f.error(f.line, "unterminated quoted string")
+		}
+	} else {
+		for ; f.pos < len(f.text) && strings.IndexByte(",]}", f.text[f.pos]) < 0; f.pos++ {
+			if key && f.text[f.pos] == ':' && f.pos > start && (f.pos+1 == len(f.text) || f.text[f.pos+1] == ' ') {
+				break
+			}
+		}
+	}
+
+	return f.yamlParser.parseScalar(strings.TrimSpace(f.text[start:f.pos]), f.line)
+}
+
+// yamlEmitter writes objects as YAML in the block style.
+type yamlEmitter struct {
+	vm *VM
+}
+
+// isYAMLCollection reports whether o is written on lines of its own, which is the case for arrays and hashes that aren't empty.
+func isYAMLCollection(o Object) bool {
+	switch o := o.(type) {
+	case *ArrayObject:
+		return len(o.Elements) > 0
+	case *HashObject:
+		return len(o.pairs) > 0
+	}
+
+	return false
+}
+
+// lines returns the lines of a collection. Like Ruby, a sequence in a mapping isn't indented more than its key,
+// and a collection in a sequence starts on the line of its entry.
+func (e *yamlEmitter) lines(o Object) []string {
+	lines := []string{}
+
+	switch o := o.(type) {
+	case *ArrayObject:
+		for _, el := range o.Elements {
+			if !isYAMLCollection(el) {
+				lines = append(lines, strings.TrimRight("- "+e.scalar(el, false), " "))
+				continue
+			}
+
+			for i, l := range e.lines(el) {
+				if i == 0 {
+					lines = append(lines, "- "+l)
+				} else {
+					lines = append(lines, "  "+l)
+				}
+			}
+		}
+	case *HashObject:
+		for _, p := range o.pairs {
+			key := e.scalar(p.key, true) + ":"
+
+			if !isYAMLCollection(p.value) {
+				lines = append(lines, strings.TrimRight(key+" "+e.scalar(p.value, false), " "))
+				continue
+			}
+
+			lines = append(lines, key)
+			_, isArray := p.value.(*ArrayObject)
+
+			for _, l := range e.lines(p.value) {
+				if isArray {
+					lines = append(lines, l)
+				} else {
+					lines = append(lines, "  "+l)
+				}
+			}
+		}
+	}
+
+	return lines
+}
+
+// dump returns the document of o.
+func (e *yamlEmitter) dump(o Object) string {
+	if isYAMLCollection(o) {
+		return "---\n" + strings.Join(e.lines(o), "\n") + "\n"
+	}
+
+	return strings.TrimRight("--- "+e.scalar(o, false), " ") + "\n"
+}
+
+// scalar returns how o is written in YAML. Strings that would be read as something else, or that contain
+// characters YAML gives a meaning to, are quoted.
+func (e *yamlEmitter) scalar(o Object, key bool) string {
+	switch o := o.(type) {
+	case *Null:
+		if key {
+			return "~"
+		}
+
+		return ""
+	case *BooleanObject, *IntegerObject:
+		return o.Inspect()
+	case *FloatObject:
+		switch {
+		case math.IsInf(o.Value, 1):
+			return ".inf"
+		case math.IsInf(o.Value, -1):
+			return "-.inf"
+		case math.IsNaN(o.Value):
+			return ".nan"
+		}
+
+		return o.Inspect()
+	case *SymbolObject:
+		return ":" + o.Name
+	case *StringObject:
+		if e.needsQuotes(o.Value) {
+			return strconv.Quote(o.Value)
+		}
+
+		return o.Value
+	case *ArrayObject:
+		if len(o.Elements) == 0 && !key {
+			return "[]"
+		}
+	case *HashObject:
+		if len(o.pairs) == 0 && !key {
+			return "{}"
+		}
+	}
+
+	e.vm.raise(TypeErrorClass, "can't dump %s to YAML", e.vm.inspectForError(o))
+	return ""
+}
+
+func (e *yamlEmitter) needsQuotes(s string) bool {
+	if s == "" || s != strings.TrimSpace(s) || strings.ContainsAny(s, "\n\r\t\"\\") {
+		return true
+	}
+
+	if strings.IndexByte("-?:,[]{}#&*!|>'%@`", s[0]) >= 0 || strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return true
+	}
+
+	// strings that look like another scalar have to stay strings
+	_, isString := (&yamlParser{vm: e.vm}).parseScalar(s, nil).(*StringObject)
+
+	return !isString || s == "..." || s == "---"
+}
+
+var builtinYAMLModuleMethods = []*BuiltInMethod{
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return newYAMLParser(vm, vm.stringArgument(args[0])).parse()
+			}
+		},
+		Name: "load",
+	},
+	{
+		Fn: func(receiver Object) BuiltinMethodBody {
+			return func(vm *VM, args []Object, blockFrame *CallFrame) Object {
+				if len(args) != 1 {
+					return newError("Expect 1 argument. got=%d", len(args))
+				}
+
+				return InitializeString((&yamlEmitter{vm: vm}).dump(args[0]))
+			}
+		},
+		Name: "dump",
+	},
+}
+
+func initYAML() {
+	YAMLModule = initializeBuiltinModule("YAML", builtinYAMLModuleMethods)
+}
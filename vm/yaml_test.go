@@ -0,0 +1,159 @@
+package vm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testYAMLLoad loads document with YAML.load and returns the result, the document is read from a file
+// since Rooby strings can't have line breaks in them.
+func testYAMLLoad(t *testing.T, document string) Object {
+	dir := testLibrary(t, map[string]string{"doc.yml": document})
+	defer os.RemoveAll(dir)
+
+	return testEval(t, fmt.Sprintf(`YAML.load(File.read("%s"))`, filepath.Join(dir, "doc.yml")))
+}
+
+func TestYAMLLoad(t *testing.T) {
+	tests := []struct {
+		document string
+		expected string
+	}{
+		{"a: 1\nb: two\n", "{ a: 1, b: two }"},
+		{"---\nname: rooby\nversion: 1.5\n", "{ name: rooby, version: 1.5 }"},
+		{"# config\nempty:\ntilde: ~\nnull: null\n", "{ empty: null, tilde: null, null => null }"},
+		{"t: true\nf: false\ny: yes\nn: Off\n", "{ t: true, f: false, y: true, n: false }"},
+		{"big: 1_000\nneg: -3\nexp: 1e3\ninf: -.inf\nzip: 01234\n", "{ big: 1000, neg: -3, exp: 1000.0, inf: -Infinity, zip: 01234 }"},
+		{"s: \"1\"\nq: 'it''s'\nesc: \"a\\tb\"\n", "{ s: 1, q: it's, esc: a\tb }"},
+		{"url: http://example.com:80/a # comment\nhash: a#b\n", "{ url: http://example.com:80/a, hash: a#b }"},
+		{"huge: 123456789012345678901234567890\n", "{ huge: 123456789012345678901234567890 }"},
+		{"sym: :name\n:key: value\n", "{ sym: :name, :key => value }"},
+		{"1: one\n\"two words\": 2\n", "{ 1 => one, two words: 2 }"},
+		{"- a\n- b\n-\n- - c\n  - d\n", "Array:[a, b, null, Array:[c, d]]"},
+		{"list:\n- a\n- b\nnested:\n  key:\n    - 1\n", "{ list: Array:[a, b], nested: { key: Array:[1] } }"},
+		{"- name: a\n  port: 1\n- name: b\n", "Array:[{ name: a, port: 1 }, { name: b }]"},
+		{"a: [1, \"x, y\", [2]]\nb: {c: 1, d: [e]}\nc: []\nd: {}\n", "{ a: Array:[1, x, y, Array:[2]], b: { c: 1, d: Array:[e] }, c: Array:[], d: {  } }"},
+		{"text: |\n  line one\n    indented\n\n  line three\nnext: 1\n", "{ text: line one\n  indented\n\nline three\n, next: 1 }"},
+		{"text: |-\n  no newline\n", "{ text: no newline }"},
+		{"text: |+\n  keep\n\n\nnext: 1\n", "{ text: keep\n\n\n, next: 1 }"},
+		{"text: >\n  folded\n  lines\n\n  para\n", "{ text: folded lines\npara\n }"},
+		{"- |\n  in a list\n- x\n", "Array:[in a list\n, x]"},
+		{"--- plain\n", "plain"},
+		{"--- 3\n...\nignored\n", "3"},
+		{"a: 1\n---\nb: 2\n", "{ a: 1 }"},
+		{"", "null"},
+		{"# only a comment\n", "null"},
+	}
+
+	for i, tt := range tests {
+		evaluated := testYAMLLoad(t, tt.document)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Fatalf("at test case %d: expect %q. got=%q", i, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestYAMLDump(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`YAML.dump({ name: "rooby", version: 1, ratio: 1.5, on: true })`, "---\nname: rooby\nversion: 1\nratio: 1.5\n\"on\": true\n"},
+		{`YAML.dump({ tags: ["a", "b"], inner: { x: ENV["NOPE"] } })`, "---\ntags:\n- a\n- b\ninner:\n  x:\n"},
+		{`YAML.dump([{ a: 1, b: [2] }, [3, 4], [], {}])`, "---\n- a: 1\n  b:\n  - 2\n- - 3\n  - 4\n- []\n- {}\n"},
+		{`YAML.dump(["1", "yes", "~", "a: b", "- x", " pad", "#c", "it's", "ok"])`, "---\n- \"1\"\n- \"yes\"\n- \"~\"\n- \"a: b\"\n- \"- x\"\n- \" pad\"\n- \"#c\"\n- it's\n- ok\n"},
+		{`YAML.dump("a".to_sym)`, "--- :a\n"},
+		{`YAML.dump("text")`, "--- text\n"},
+		{`YAML.dump(10)`, "--- 10\n"},
+		{`YAML.dump(ENV["NOPE"])`, "---\n"},
+		{`YAML.dump(1.0 / 0)`, "--- .inf\n"},
+		{`YAML.dump([File.read("%[1]s")])`, "---\n- \"line one\\nline two\\n\"\n"},
+	}
+
+	dir := testLibrary(t, map[string]string{"text.txt": "line one\nline two\n"})
+	defer os.RemoveAll(dir)
+
+	for i, tt := range tests {
+		input := tt.input
+
+		if i == len(tests)-1 {
+			input = fmt.Sprintf(input, filepath.Join(dir, "text.txt"))
+		}
+
+		evaluated := testEval(t, input)
+
+		if !testStringObject(t, evaluated, tt.expected) {
+			t.Fatalf("at test case %d: failed", i)
+		}
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	input := `
+	config = { name: "rooby", ports: [80, 443], debug: false, owner: { email: "a@b.c", note: "x: y" }, empty: [] }
+	YAML.load(YAML.dump(config)).to_s == config.to_s
+	`
+
+	testBooleanObject(t, testEval(t, input), true)
+}
+
+func TestYAMLErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`YAML.load(1)`, "TypeError: wrong argument type 1 (expected String)"},
+		{`YAML.load("a: [1, 2")`, "YAML::SyntaxError: expected ']' in flow collection at line 1"},
+		{`YAML.load("a: {b 1}")`, "YAML::SyntaxError: expected ':' in flow collection at line 1"},
+		{`YAML.load("a: 'open")`, "YAML::SyntaxError: invalid single quoted string 'open at line 1"},
+		{`YAML.load("a: [1] x")`, "YAML::SyntaxError: unexpected content after flow collection at line 1"},
+		{`YAML.dump(Object.new)`, "TypeError: can't dump <Instance of: Object> to YAML"},
+	}
+
+	for i, tt := range tests {
+		err := testEvalError(t, New(), "", tt.input)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestYAMLIndentationErrors(t *testing.T) {
+	tests := []struct {
+		document string
+		expected string
+	}{
+		{"a: 1\n  b: 2\n", "YAML::SyntaxError: bad indentation at line 2"},
+		{"a:\n  b: 1\n c: 2\n", "YAML::SyntaxError: bad indentation at line 3"},
+		{"- a\nb: 1\n", "YAML::SyntaxError: unexpected content at line 2"},
+		{"a: 1\njust text\n", "YAML::SyntaxError: could not find expected ':' at line 2"},
+	}
+
+	for i, tt := range tests {
+		dir := testLibrary(t, map[string]string{"doc.yml": tt.document})
+		path := filepath.Join(dir, "doc.yml")
+
+		err := testEvalError(t, New(), "", fmt.Sprintf(`YAML.load(File.read("%s"))`, path))
+		os.RemoveAll(dir)
+
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("at test case %d: expect error %q. got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestYAMLSyntaxErrorIsRuntimeError(t *testing.T) {
+	input := `
+	begin
+	  YAML.load("a: [1")
+	rescue RuntimeError => e
+	  e.class.name
+	end
+	`
+
+	testStringObject(t, testEval(t, input), "YAML::SyntaxError")
+}